@@ -1,34 +1,142 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// backupDirEnv is the environment variable with the directory where the
+// backups are stored, used when the --backup-dir flag is not set.
+const backupDirEnv = "EGN_BACKUP_DIR"
+
 func BackupCmd(d daemon.Daemon) *cobra.Command {
-	var instanceId string
+	var (
+		instanceId  string
+		compress    string
+		compression daemon.Compression
+		output      string
+		stdout      bool
+		consistent  bool
+		keyFile     string
+	)
 	cmd := cobra.Command{
 		Use:   "backup <instance-id>",
 		Short: "Backup an instance",
-		Long:  "Backup an instance saving the data into a tarball file. To list backups, use 'eigenlayer backup ls'",
+		Long:  "Backup an instance saving the data and the docker volumes into a tarball file. The tarball can be compressed with gzip or zstd using the --compress flag. With --output, a gzip compressed backup of the instance data (without the docker volumes) is streamed to the given file, or to stdout if it is '-', instead of being stored with the other backups. --stdout is the same as --output -, for piping the backup to other tools: the logs and the backup metadata are written to stderr. Stored backups stop the instance first, while --output backups are taken as it runs: the backup is always readable, but the files written during the backup may not be consistent with each other. Use --consistent to pause the instance while the --output backup is taken, at the cost of the instance being unavailable meanwhile. Stored backups are encrypted with the key in the file given with --encryption-key-file, and restored with the same key file. To list backups, use 'eigenlayer backup ls'. To take backups periodically, use 'eigenlayer backup schedule'",
 		Args:  cobra.MinimumNArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			instanceId = args[0]
+			if err := validateInstanceIds(instanceId); err != nil {
+				return err
+			}
+			if stdout {
+				if output != "" {
+					return fmt.Errorf("%w: --stdout and --output can't be used together", ErrInvalidArgs)
+				}
+				output = "-"
+			}
+			if consistent && output == "" {
+				return fmt.Errorf("%w: --consistent is only used with --output or --stdout, stored backups always stop the instance", ErrInvalidArgs)
+			}
+			c, err := daemon.ParseCompression(compress)
+			if err != nil {
+				return fmt.Errorf("%w: --compress must be one of none, gzip or zstd", ErrInvalidArgs)
+			}
+			compression = c
+			if output != "" && cmd.Flags().Changed("compress") && compression != daemon.CompressionGzip {
+				return fmt.Errorf("%w: --output backups are always gzip compressed", ErrInvalidArgs)
+			}
+			if output != "" && keyFile != "" {
+				return fmt.Errorf("%w: --output backups can't be encrypted", ErrInvalidArgs)
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			backupId, err := d.Backup(instanceId)
+			if output != "" {
+				return streamBackup(cmd, d, instanceId, output, daemon.StreamBackupOptions{
+					Consistent: consistent,
+				})
+			}
+			backupId, err := d.Backup(instanceId, daemon.BackupOptions{
+				Compression:       compression,
+				EncryptionKeyFile: keyFile,
+			})
 			if err != nil {
 				return err
 			}
-			log.Info("Backup created with id: ", backupId)
+			printInfo(cmd, "%s: backed up as %s\n", instanceId, backupId)
 			return nil
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().StringVar(&compress, "compress", "none", "compression codec of the backup tarball: none, gzip or zstd")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "stream a gzip compressed backup of the instance data to this file, or to stdout if it is '-'")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "stream a gzip compressed backup of the instance data to stdout, same as --output -")
+	cmd.Flags().BoolVar(&consistent, "consistent", false, "pause the instance while the --output backup is taken")
+	cmd.Flags().StringVar(&keyFile, "encryption-key-file", "", "encrypt the backup with the key in this file")
 
 	// Add ls subcommand
 	lsCmd := BackupLsCmd(d)
 	cmd.AddCommand(lsCmd)
 
+	// Add schedule subcommand
+	cmd.AddCommand(BackupScheduleCmd(d))
+
+	// Add prune subcommand
+	cmd.AddCommand(BackupPruneCmd(d))
+
+	requireRuntime(&cmd)
 	return &cmd
 }
+
+// streamBackup writes a backup of the instance data to the output file as it
+// is created, removing the file if the backup fails. If output is "-", the
+// backup is written to the command output instead, with the logs and the
+// backup metadata, as JSON, written to the command error output.
+func streamBackup(cmd *cobra.Command, d daemon.Daemon, instanceId, output string, options daemon.StreamBackupOptions) (err error) {
+	if output == "-" {
+		// Anything else written to the output would corrupt the backup
+		log.SetOutput(cmd.ErrOrStderr())
+		backup, err := d.StreamBackup(instanceId, cmd.OutOrStdout(), options)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(cmd.ErrOrStderr()).Encode(backup)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+		if err != nil {
+			os.Remove(output)
+		}
+	}()
+	backup, err := d.StreamBackup(instanceId, f, options)
+	if err != nil {
+		return err
+	}
+	printInfo(cmd, "%s: backed up as %s to %s, checksum %s\n", instanceId, backup.Id(), output, backup.Checksum)
+	return nil
+}
+
+// configureBackupDir sets the directory where the daemon stores the backups to
+// backupDir, falling back to the EGN_BACKUP_DIR environment variable. The
+// daemon default is kept if neither of them is set.
+func configureBackupDir(d daemon.Daemon, backupDir string) error {
+	if backupDir == "" {
+		backupDir = os.Getenv(backupDirEnv)
+	}
+	if backupDir == "" {
+		return nil
+	}
+	return d.SetBackupDir(backupDir)
+}
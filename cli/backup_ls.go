@@ -2,31 +2,71 @@ package cli
 
 import (
 	"fmt"
-	"io"
 	"slices"
-	"text/tabwriter"
 	"time"
 
+	"github.com/NethermindEth/eigenlayer/cli/output"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/spf13/cobra"
 	"kythe.io/kythe/go/util/datasize"
 )
 
 func BackupLsCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		jsonOutput bool
+		format     = output.FormatTable
+		instanceId string
+		since      string
+		until      string
+	)
 	cmd := cobra.Command{
-		Use:   "ls",
-		Short: "List backups",
-		Long:  "List backups showing all backups and their details.",
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List backups",
+		Long:    "List backups showing all backups and their details. Use --instance to only list the backups of an instance, and --since and --until to only list the backups created in a time window. Both take a timestamp (e.g. 2013-01-02T13:23:37Z) or a duration relative to now (e.g. 24h for the last 24 hours). Use --output to get the list as a table, JSON or YAML document.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			var from, to time.Time
+			if since != "" {
+				t, err := parseBackupTime(since, now)
+				if err != nil {
+					return fmt.Errorf("%w: --since: %w", ErrInvalidArgs, err)
+				}
+				from = t
+			}
+			if until != "" {
+				t, err := parseBackupTime(until, now)
+				if err != nil {
+					return fmt.Errorf("%w: --until: %w", ErrInvalidArgs, err)
+				}
+				to = t
+			}
 			backups, err := d.BackupList()
 			if err != nil {
 				return err
 			}
+			backups = slices.DeleteFunc(backups, func(b daemon.BackupInfo) bool {
+				return (instanceId != "" && b.Instance != instanceId) ||
+					(!from.IsZero() && b.Timestamp.Before(from)) ||
+					(!to.IsZero() && b.Timestamp.After(to))
+			})
 			sortBackupsByTimestamp(backups)
-			printBackupTable(backups, cmd.OutOrStdout())
-			return nil
+			if jsonOutput {
+				format = output.FormatJSON
+			}
+			return output.Print(cmd.OutOrStdout(), format, backups, backupTable)
 		},
 	}
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	cmd.Flags().StringVar(&instanceId, "instance", "", "only list the backups of this instance")
+	cmd.Flags().StringVar(&since, "since", "", "only list backups created since a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 24h for 24 hours)")
+	cmd.Flags().StringVar(&until, "until", "", "only list backups created before a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 24h for 24 hours)")
+	// --json is kept for compatibility, it is the same as --output json
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the backups as JSON")
+	if err := cmd.Flags().MarkHidden("json"); err != nil {
+		// Only fails if the flag is not defined
+		panic(err)
+	}
 	return &cmd
 }
 
@@ -42,40 +82,51 @@ func sortBackupsByTimestamp(backups []daemon.BackupInfo) {
 	})
 }
 
-func printBackupTable(backups []daemon.BackupInfo, out io.Writer) {
-	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
-	fmt.Fprintln(w, "ID\tAVS Instance ID\tVERSION\tCOMMIT\tTIMESTAMP\tSIZE\tURL\t")
-	for _, b := range backups {
-		fmt.Fprintln(w, backupTableItem{
-			id:        b.Id,
-			instance:  b.Instance,
-			timestamp: b.Timestamp.Format(time.DateTime),
-			size:      datasize.Size(b.SizeBytes).String(),
-			version:   b.Version,
-			commit:    b.Commit,
-			url:       b.Url,
-		})
+// parseBackupTime parses a --since or --until value, either an RFC3339
+// timestamp or a duration, like 24h, that is subtracted from now.
+func parseBackupTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is neither a duration nor an RFC3339 timestamp", value)
 	}
-	w.Flush()
+	return t, nil
 }
 
-type backupTableItem struct {
-	id        string
-	instance  string
-	timestamp string
-	size      string
-	version   string
-	commit    string
-	url       string
+var backupTable = output.Table[daemon.BackupInfo]{
+	Headers: []string{"ID", "AVS Instance ID", "VERSION", "COMMIT", "TIMESTAMP", "AGE", "SIZE", "URL"},
+	Row: func(b daemon.BackupInfo) []string {
+		instance := b.Instance
+		if b.Encrypted {
+			// The instance of an encrypted backup is unknown without its key
+			instance = "(encrypted)"
+		}
+		return []string{
+			b.Id,
+			instance,
+			b.Version,
+			b.Commit,
+			b.Timestamp.Format(time.DateTime),
+			formatAge(b.Age),
+			datasize.Size(b.SizeBytes).String(),
+			b.Url,
+		}
+	},
 }
 
-// func minifiedId(id string) string {
-// 	if len(id) > 8 {
-// 		return id[:8]
-// 	}
-// 	return id
-// }
-
-func (b backupTableItem) String() string {
-	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t", b.id, b.instance, b.version, b.commit, b.timestamp, b.size, b.url)
+// formatAge formats the age of a backup in its largest unit, from seconds to
+// days, like 45s, 12m, 5h or 3d.
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
 }
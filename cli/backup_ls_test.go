@@ -2,6 +2,10 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
 	"testing"
 	"time"
 
@@ -15,6 +19,7 @@ import (
 func TestBackupLs(t *testing.T) {
 	tc := []struct {
 		name   string
+		args   []string
 		err    error
 		stdErr []byte
 		stdOut []byte
@@ -24,7 +29,7 @@ func TestBackupLs(t *testing.T) {
 			name:   "no backups",
 			err:    nil,
 			stdErr: nil,
-			stdOut: []byte("ID    AVS Instance ID    VERSION    COMMIT    TIMESTAMP    SIZE    URL    \n"),
+			stdOut: []byte("ID    AVS Instance ID    VERSION    COMMIT    TIMESTAMP    AGE    SIZE    URL    \n"),
 			mocker: func(d *mocks.MockDaemon) {
 				d.EXPECT().BackupList().Return([]daemon.BackupInfo{}, nil)
 			},
@@ -34,9 +39,9 @@ func TestBackupLs(t *testing.T) {
 			err:    nil,
 			stdErr: nil,
 			stdOut: []byte(
-				"ID                                          AVS Instance ID     VERSION    COMMIT                                      TIMESTAMP              SIZE     URL                                              \n" +
-					"7ba32f630af2cede1388b5712d6ef3ac63175bae    mock-avs-second     v5.5.1     d5af645fffb93e8263b099082a4f512e1917d0af    2023-10-04 07:12:19    10KiB    https://github.com/NethermindEth/mock-avs-pkg    \n" +
-					"33de69fe9225b95c8fb909cb418e5102970c8d73    mock-avs-default    v5.5.0     a3406616b848164358fdd24465b8eecda5f5ae34    2023-10-03 21:18:36    10KiB    https://github.com/NethermindEth/mock-avs-pkg    \n",
+				"ID                                          AVS Instance ID     VERSION    COMMIT                                      TIMESTAMP              AGE    SIZE     URL                                              \n" +
+					"7ba32f630af2cede1388b5712d6ef3ac63175bae    mock-avs-second     v5.5.1     d5af645fffb93e8263b099082a4f512e1917d0af    2023-10-04 07:12:19    5h     10KiB    https://github.com/NethermindEth/mock-avs-pkg    \n" +
+					"33de69fe9225b95c8fb909cb418e5102970c8d73    mock-avs-default    v5.5.0     a3406616b848164358fdd24465b8eecda5f5ae34    2023-10-03 21:18:36    3d     10KiB    https://github.com/NethermindEth/mock-avs-pkg    \n",
 			),
 			mocker: func(d *mocks.MockDaemon) {
 				d.EXPECT().BackupList().Return([]daemon.BackupInfo{
@@ -46,6 +51,7 @@ func TestBackupLs(t *testing.T) {
 						Version:   "v5.5.0",
 						Commit:    "a3406616b848164358fdd24465b8eecda5f5ae34",
 						Timestamp: time.Date(2023, 10, 3, 21, 18, 36, 0, time.UTC),
+						Age:       3*24*time.Hour + 2*time.Hour,
 						SizeBytes: 10240,
 						Url:       "https://github.com/NethermindEth/mock-avs-pkg",
 					},
@@ -55,12 +61,106 @@ func TestBackupLs(t *testing.T) {
 						Version:   "v5.5.1",
 						Commit:    "d5af645fffb93e8263b099082a4f512e1917d0af",
 						Timestamp: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC),
+						Age:       5*time.Hour + 30*time.Minute,
 						SizeBytes: 10240,
 						Url:       "https://github.com/NethermindEth/mock-avs-pkg",
 					},
 				}, nil)
 			},
 		},
+		{
+			name:   "json output",
+			args:   []string{"--json"},
+			err:    nil,
+			stdErr: nil,
+			stdOut: []byte(`[
+  {
+    "id": "7ba32f630af2cede1388b5712d6ef3ac63175bae",
+    "instance_id": "mock-avs-second",
+    "timestamp": "2023-10-04T07:12:19Z",
+    "size_bytes": 10240,
+    "version": "v5.5.1",
+    "commit": "d5af645fffb93e8263b099082a4f512e1917d0af",
+    "url": "https://github.com/NethermindEth/mock-avs-pkg"
+  },
+  {
+    "id": "33de69fe9225b95c8fb909cb418e5102970c8d73",
+    "instance_id": "mock-avs-default",
+    "timestamp": "2023-10-03T21:18:36Z",
+    "size_bytes": 10240,
+    "version": "v5.5.0",
+    "commit": "a3406616b848164358fdd24465b8eecda5f5ae34",
+    "url": "https://github.com/NethermindEth/mock-avs-pkg"
+  }
+]
+`),
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().BackupList().Return([]daemon.BackupInfo{
+					{
+						Id:        "33de69fe9225b95c8fb909cb418e5102970c8d73",
+						Instance:  "mock-avs-default",
+						Version:   "v5.5.0",
+						Commit:    "a3406616b848164358fdd24465b8eecda5f5ae34",
+						Timestamp: time.Date(2023, 10, 3, 21, 18, 36, 0, time.UTC),
+						SizeBytes: 10240,
+						Url:       "https://github.com/NethermindEth/mock-avs-pkg",
+					},
+					{
+						Id:        "7ba32f630af2cede1388b5712d6ef3ac63175bae",
+						Instance:  "mock-avs-second",
+						Version:   "v5.5.1",
+						Commit:    "d5af645fffb93e8263b099082a4f512e1917d0af",
+						Timestamp: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC),
+						SizeBytes: 10240,
+						Url:       "https://github.com/NethermindEth/mock-avs-pkg",
+					},
+				}, nil)
+			},
+		},
+		{
+			name:   "yaml output",
+			args:   []string{"--output", "yaml"},
+			err:    nil,
+			stdErr: nil,
+			stdOut: []byte(`- id: 33de69fe9225b95c8fb909cb418e5102970c8d73
+  instance_id: mock-avs-default
+  timestamp: 2023-10-03T21:18:36Z
+  size_bytes: 10240
+  version: v5.5.0
+  commit: a3406616b848164358fdd24465b8eecda5f5ae34
+  url: https://github.com/NethermindEth/mock-avs-pkg
+`),
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().BackupList().Return([]daemon.BackupInfo{
+					{
+						Id:        "33de69fe9225b95c8fb909cb418e5102970c8d73",
+						Instance:  "mock-avs-default",
+						Version:   "v5.5.0",
+						Commit:    "a3406616b848164358fdd24465b8eecda5f5ae34",
+						Timestamp: time.Date(2023, 10, 3, 21, 18, 36, 0, time.UTC),
+						SizeBytes: 10240,
+						Url:       "https://github.com/NethermindEth/mock-avs-pkg",
+					},
+				}, nil)
+			},
+		},
+		{
+			name:   "json output, no backups",
+			args:   []string{"--output", "json"},
+			err:    nil,
+			stdErr: nil,
+			stdOut: []byte("[]\n"),
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().BackupList().Return(nil, nil)
+			},
+		},
+		{
+			name:   "invalid output format",
+			args:   []string{"--output", "xml"},
+			err:    errors.New(`invalid argument "xml" for "-o, --output" flag: invalid output format: xml. Must be one of table, json or yaml`),
+			stdErr: []byte("Error: invalid argument \"xml\" for \"-o, --output\" flag: invalid output format: xml. Must be one of table, json or yaml\n"),
+			mocker: func(d *mocks.MockDaemon) {},
+		},
 		{
 			name:   "error",
 			err:    assert.AnError,
@@ -87,6 +187,7 @@ func TestBackupLs(t *testing.T) {
 			)
 
 			backupLsCmd := BackupLsCmd(d)
+			backupLsCmd.SetArgs(tt.args)
 			backupLsCmd.SetOut(&stdOut)
 			backupLsCmd.SetErr(&stdErr)
 			err := backupLsCmd.Execute()
@@ -102,3 +203,143 @@ func TestBackupLs(t *testing.T) {
 		})
 	}
 }
+
+func TestBackupLsFilters(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	backups := []daemon.BackupInfo{
+		{Id: "old", Instance: "mock-avs-default", Timestamp: time.Date(2023, 10, 3, 21, 18, 36, 0, time.UTC)},
+		{Id: "second", Instance: "mock-avs-second", Timestamp: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC)},
+		{Id: "recent", Instance: "mock-avs-default", Timestamp: now.Add(-time.Hour)},
+	}
+	tc := []struct {
+		name string
+		args []string
+		want []string
+		err  error
+	}{
+		{
+			name: "no filters",
+			want: []string{"recent", "second", "old"},
+		},
+		{
+			name: "by instance",
+			args: []string{"--instance", "mock-avs-default"},
+			want: []string{"recent", "old"},
+		},
+		{
+			name: "relative since",
+			args: []string{"--since", "24h"},
+			want: []string{"recent"},
+		},
+		{
+			name: "relative until",
+			args: []string{"--until", "24h"},
+			want: []string{"second", "old"},
+		},
+		{
+			name: "absolute window",
+			args: []string{"--since", "2023-10-04T00:00:00Z", "--until", "2023-10-05T00:00:00Z"},
+			want: []string{"second"},
+		},
+		{
+			name: "absolute since and instance",
+			args: []string{"--since", "2023-10-04T00:00:00Z", "--instance", "mock-avs-second"},
+			want: []string{"second"},
+		},
+		{
+			name: "empty result",
+			args: []string{"--until", "2023-01-01T00:00:00Z"},
+			want: []string{},
+		},
+		{
+			name: "unknown instance",
+			args: []string{"--instance", "unknown"},
+			want: []string{},
+		},
+		{
+			name: "invalid since",
+			args: []string{"--since", "yesterday"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "invalid until",
+			args: []string{"--until", "2023-10-04"},
+			err:  ErrInvalidArgs,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.err == nil {
+				d.EXPECT().BackupList().Return(slices.Clone(backups), nil)
+			}
+
+			var stdOut bytes.Buffer
+			cmd := BackupLsCmd(d)
+			cmd.SetArgs(append(tt.args, "--output", "json"))
+			cmd.SetOut(&stdOut)
+			cmd.SetErr(io.Discard)
+			cmd.SilenceUsage = true
+			err := cmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			var got []daemon.BackupInfo
+			require.NoError(t, json.Unmarshal(stdOut.Bytes(), &got))
+			ids := make([]string, len(got))
+			for i, b := range got {
+				ids[i] = b.Id
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestParseBackupTime(t *testing.T) {
+	now := time.Date(2023, 10, 4, 12, 0, 0, 0, time.UTC)
+	tc := []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{value: "24h", want: time.Date(2023, 10, 3, 12, 0, 0, 0, time.UTC)},
+		{value: "90m", want: time.Date(2023, 10, 4, 10, 30, 0, 0, time.UTC)},
+		{value: "2023-10-01T08:30:00Z", want: time.Date(2023, 10, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "2023-10-01T08:30:00+02:00", want: time.Date(2023, 10, 1, 6, 30, 0, 0, time.UTC)},
+		{value: "2023-10-01", err: true},
+		{value: "1d", err: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseBackupTime(tt.value, now)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tc := []struct {
+		age  time.Duration
+		want string
+	}{
+		{age: 0, want: "0s"},
+		{age: 45 * time.Second, want: "45s"},
+		{age: 12*time.Minute + 30*time.Second, want: "12m"},
+		{age: 5*time.Hour + 59*time.Minute, want: "5h"},
+		{age: 3*24*time.Hour + 23*time.Hour, want: "3d"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatAge(tt.age))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+func BackupPruneCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		instanceId string
+		retention  daemon.RetentionPolicy
+		dryRun     bool
+		yes        bool
+		keyFile    string
+		format     = output.FormatTable
+	)
+	cmd := cobra.Command{
+		Use:   "prune <instance-id>",
+		Short: "Remove old backups of an instance",
+		Long:  "Removes the backups of an instance that are not kept by --keep-last and --keep-within, and prints the removed backup files. The most recent backup is always kept. Use --dry-run to print the backup files that would be removed without removing anything. Removing requires --yes. The instance of an encrypted backup is only known with its key, so encrypted backups are only pruned if they are encrypted with the key in the file given with --encryption-key-file.",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			instanceId = args[0]
+			if err := validateInstanceIds(instanceId); err != nil {
+				return err
+			}
+			if retention.KeepLast < 0 {
+				return fmt.Errorf("%w: --keep-last must not be negative", ErrInvalidArgs)
+			}
+			if retention.MaxAge < 0 {
+				return fmt.Errorf("%w: --keep-within must not be negative", ErrInvalidArgs)
+			}
+			if retention.KeepLast == 0 && retention.MaxAge == 0 {
+				return fmt.Errorf("%w: at least one of --keep-last and --keep-within is required", ErrInvalidArgs)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRun && !yes {
+				return fmt.Errorf("%w: use --yes to confirm the removal or --dry-run to only list what would be removed", ErrPruneNotConfirmed)
+			}
+			pruned, err := d.PruneBackups(instanceId, retention, daemon.PruneBackupsOptions{
+				DryRun:            dryRun,
+				EncryptionKeyFile: keyFile,
+			})
+			if err != nil {
+				return err
+			}
+			return output.Print(cmd.OutOrStdout(), format, pruned, backupPruneTable)
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().IntVar(&retention.KeepLast, "keep-last", 0, "number of most recent backups of the instance to keep, 0 keeps all")
+	cmd.Flags().DurationVar(&retention.MaxAge, "keep-within", 0, "keep the backups of the instance created within this duration, 0 keeps all")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the backups that would be removed without removing anything.")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "confirm the removal.")
+	cmd.Flags().StringVar(&keyFile, "encryption-key-file", "", "prune the encrypted backups with the key in this file")
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+var backupPruneTable = output.Table[daemon.PrunedBackup]{
+	Headers: []string{"ID", "PATH"},
+	Row: func(b daemon.PrunedBackup) []string {
+		return []string{b.Id, b.Path}
+	},
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupPrune(t *testing.T) {
+	pruned := []daemon.PrunedBackup{
+		{Id: "backup-1", Path: "/egn/backup/backup-1.tar"},
+		{Id: "backup-2", Path: "/egn/backup/backup-2.tar.gz"},
+	}
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "not confirmed",
+			args: []string{"mock-avs-default", "--keep-last", "1"},
+			err:  ErrPruneNotConfirmed,
+		},
+		{
+			name: "no retention rule",
+			args: []string{"mock-avs-default", "--yes"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "negative keep last",
+			args: []string{"mock-avs-default", "--keep-last", "-1", "--yes"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "negative keep within",
+			args: []string{"mock-avs-default", "--keep-within", "-1h", "--yes"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "invalid instance ID",
+			args: []string{"mock_avs", "--keep-last", "1", "--yes"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "dry run",
+			args: []string{"mock-avs-default", "--keep-last", "1", "--keep-within", "24h", "--dry-run"},
+			stdOut: "ID          PATH                           \n" +
+				"backup-1    /egn/backup/backup-1.tar       \n" +
+				"backup-2    /egn/backup/backup-2.tar.gz    \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().PruneBackups("mock-avs-default", daemon.RetentionPolicy{KeepLast: 1, MaxAge: 24 * time.Hour}, daemon.PruneBackupsOptions{DryRun: true}).Return(pruned, nil)
+			},
+		},
+		{
+			name: "prune",
+			args: []string{"mock-avs-default", "--keep-within", "72h", "-y", "-o", "json"},
+			stdOut: `[
+  {
+    "id": "backup-1",
+    "path": "/egn/backup/backup-1.tar"
+  }
+]
+`,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().PruneBackups("mock-avs-default", daemon.RetentionPolicy{MaxAge: 72 * time.Hour}, daemon.PruneBackupsOptions{}).Return(pruned[:1], nil)
+			},
+		},
+		{
+			name:   "encrypted backups",
+			args:   []string{"mock-avs-default", "--keep-last", "1", "--yes", "--encryption-key-file", "/keys/backup.key", "-o", "json"},
+			stdOut: "[]\n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().PruneBackups("mock-avs-default", daemon.RetentionPolicy{KeepLast: 1}, daemon.PruneBackupsOptions{EncryptionKeyFile: "/keys/backup.key"}).Return(nil, nil)
+			},
+		},
+		{
+			name: "prune error",
+			args: []string{"mock-avs-default", "--keep-last", "1", "--yes"},
+			err:  assert.AnError,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().PruneBackups("mock-avs-default", daemon.RetentionPolicy{KeepLast: 1}, daemon.PruneBackupsOptions{}).Return(nil, assert.AnError)
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut, stdErr bytes.Buffer
+			pruneCmd := BackupPruneCmd(d)
+			pruneCmd.SetArgs(tt.args)
+			pruneCmd.SetOut(&stdOut)
+			pruneCmd.SetErr(&stdErr)
+			pruneCmd.SilenceUsage = true
+			err := pruneCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
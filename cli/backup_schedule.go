@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+func BackupScheduleCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		instanceId string
+		interval   time.Duration
+		retention  daemon.RetentionPolicy
+		keyFile    string
+	)
+	cmd := cobra.Command{
+		Use:   "schedule <instance-id>",
+		Short: "Periodically backup an instance",
+		Long:  "Periodically backup an instance until the command is interrupted. After each backup, older backups of the instance are pruned according to --keep-last and --max-age. The instance is stopped while the backup is created and started again afterwards if it was running. With --encryption-key-file, the backups are encrypted with the key in the file, and the encrypted backups of the instance are pruned with it too.",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			instanceId = args[0]
+			if err := validateInstanceIds(instanceId); err != nil {
+				return err
+			}
+			if interval <= 0 {
+				return fmt.Errorf("%w: --interval must be greater than 0", ErrInvalidArgs)
+			}
+			if retention.KeepLast < 0 {
+				return fmt.Errorf("%w: --keep-last must not be negative", ErrInvalidArgs)
+			}
+			if retention.MaxAge < 0 {
+				return fmt.Errorf("%w: --max-age must not be negative", ErrInvalidArgs)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return d.ScheduleBackup(ctx, instanceId, interval, retention, daemon.BackupOptions{
+				EncryptionKeyFile: keyFile,
+			})
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().DurationVar(&interval, "interval", 24*time.Hour, "time between backups")
+	cmd.Flags().IntVar(&retention.KeepLast, "keep-last", 0, "number of most recent backups of the instance to keep, 0 keeps all")
+	cmd.Flags().DurationVar(&retention.MaxAge, "max-age", 0, "maximum age of the backups of the instance to keep, 0 keeps all")
+	cmd.Flags().StringVar(&keyFile, "encryption-key-file", "", "encrypt the backups with the key in this file")
+	requireRuntime(&cmd)
+	return &cmd
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupSchedule(t *testing.T) {
+	ts := []struct {
+		name   string
+		args   []string
+		err    error
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name:   "no arguments",
+			args:   []string{"schedule"},
+			err:    errors.New("accepts 1 arg(s), received 0"),
+			mocker: nil,
+		},
+		{
+			name: "default interval and retention",
+			args: []string{"schedule", "mock-avs-default"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ScheduleBackup(gomock.Any(), "mock-avs-default", 24*time.Hour, daemon.RetentionPolicy{}, daemon.BackupOptions{}).Return(nil)
+			},
+		},
+		{
+			name: "custom interval and retention",
+			args: []string{"schedule", "mock-avs-default", "--interval", "6h", "--keep-last", "4", "--max-age", "72h"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ScheduleBackup(gomock.Any(), "mock-avs-default", 6*time.Hour, daemon.RetentionPolicy{
+					KeepLast: 4,
+					MaxAge:   72 * time.Hour,
+				}, daemon.BackupOptions{}).Return(nil)
+			},
+		},
+		{
+			name: "encrypted backups",
+			args: []string{"schedule", "mock-avs-default", "--encryption-key-file", "/keys/backup.key"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ScheduleBackup(gomock.Any(), "mock-avs-default", 24*time.Hour, daemon.RetentionPolicy{}, daemon.BackupOptions{
+					EncryptionKeyFile: "/keys/backup.key",
+				}).Return(nil)
+			},
+		},
+		{
+			name:   "invalid instance ID",
+			args:   []string{"schedule", "mock_avs"},
+			err:    errors.New(`invalid arguments: invalid instance ID: "mock_avs" does not have the format <repository-name>-<tag>`),
+			mocker: nil,
+		},
+		{
+			name:   "zero interval",
+			args:   []string{"schedule", "mock-avs-default", "--interval", "0s"},
+			err:    errors.New("invalid arguments: --interval must be greater than 0"),
+			mocker: nil,
+		},
+		{
+			name:   "negative keep last",
+			args:   []string{"schedule", "mock-avs-default", "--keep-last", "-1"},
+			err:    errors.New("invalid arguments: --keep-last must not be negative"),
+			mocker: nil,
+		},
+		{
+			name:   "negative max age",
+			args:   []string{"schedule", "mock-avs-default", "--max-age", "-1h"},
+			err:    errors.New("invalid arguments: --max-age must not be negative"),
+			mocker: nil,
+		},
+		{
+			name: "schedule error",
+			args: []string{"schedule", "mock-avs-default"},
+			err:  errors.New("schedule error"),
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ScheduleBackup(gomock.Any(), "mock-avs-default", 24*time.Hour, daemon.RetentionPolicy{}, daemon.BackupOptions{}).Return(errors.New("schedule error"))
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			backupCmd := BackupCmd(d)
+			backupCmd.SetArgs(tt.args)
+			err := backupCmd.Execute()
+
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
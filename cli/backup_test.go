@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	ts := []struct {
+		name   string
+		args   []string
+		err    error
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name:   "no arguments",
+			args:   []string{},
+			err:    errors.New("requires at least 1 arg(s), only received 0"),
+			mocker: nil,
+		},
+		{
+			name: "uncompressed backup",
+			args: []string{"mock-avs-default"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{}).Return("backup-id", nil)
+			},
+		},
+		{
+			name: "gzip backup",
+			args: []string{"mock-avs-default", "--compress", "gzip"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{Compression: daemon.CompressionGzip}).Return("backup-id", nil)
+			},
+		},
+		{
+			name: "zstd backup",
+			args: []string{"mock-avs-default", "--compress", "zstd"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{Compression: daemon.CompressionZstd}).Return("backup-id", nil)
+			},
+		},
+		{
+			name: "encrypted backup",
+			args: []string{"mock-avs-default", "--compress", "gzip", "--encryption-key-file", "/backup.key"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{Compression: daemon.CompressionGzip, EncryptionKeyFile: "/backup.key"}).Return("backup-id", nil)
+			},
+		},
+		{
+			name:   "output with encryption",
+			args:   []string{"mock-avs-default", "--output", "backup.tar.gz", "--encryption-key-file", "/backup.key"},
+			err:    errors.New("invalid arguments: --output backups can't be encrypted"),
+			mocker: nil,
+		},
+		{
+			name:   "invalid compression",
+			args:   []string{"mock-avs-default", "--compress", "bzip2"},
+			err:    errors.New("invalid arguments: --compress must be one of none, gzip or zstd"),
+			mocker: nil,
+		},
+		{
+			name:   "output with zstd compression",
+			args:   []string{"mock-avs-default", "--output", "backup.tar.zst", "--compress", "zstd"},
+			err:    errors.New("invalid arguments: --output backups are always gzip compressed"),
+			mocker: nil,
+		},
+		{
+			name:   "stdout with output",
+			args:   []string{"mock-avs-default", "--stdout", "--output", "backup.tar.gz"},
+			err:    errors.New("invalid arguments: --stdout and --output can't be used together"),
+			mocker: nil,
+		},
+		{
+			name:   "stdout with zstd compression",
+			args:   []string{"mock-avs-default", "--stdout", "--compress", "zstd"},
+			err:    errors.New("invalid arguments: --output backups are always gzip compressed"),
+			mocker: nil,
+		},
+		{
+			name:   "consistent without output",
+			args:   []string{"mock-avs-default", "--consistent"},
+			err:    errors.New("invalid arguments: --consistent is only used with --output or --stdout, stored backups always stop the instance"),
+			mocker: nil,
+		},
+		{
+			name: "backup error",
+			args: []string{"mock-avs-default"},
+			err:  errors.New("backup error"),
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{}).Return("", errors.New("backup error"))
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			backupCmd := BackupCmd(d)
+			backupCmd.SetArgs(tt.args)
+			err := backupCmd.Execute()
+
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBackupOutput(t *testing.T) {
+	ts := []struct {
+		name      string
+		streamErr error
+	}{
+		{
+			name: "backup written",
+		},
+		{
+			name:      "backup error removes the file",
+			streamErr: errors.New("backup error"),
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			d.EXPECT().StreamBackup("mock-avs-default", gomock.Any(), daemon.StreamBackupOptions{}).DoAndReturn(func(instanceId string, w io.Writer, _ daemon.StreamBackupOptions) (*data.Backup, error) {
+				if _, err := w.Write([]byte("backup content")); err != nil {
+					return nil, err
+				}
+				if tt.streamErr != nil {
+					return nil, tt.streamErr
+				}
+				return &data.Backup{InstanceId: instanceId, Checksum: "checksum"}, nil
+			})
+
+			output := filepath.Join(t.TempDir(), "backup.tar.gz")
+			backupCmd := BackupCmd(d)
+			backupCmd.SetArgs([]string{"mock-avs-default", "--output", output})
+			err := backupCmd.Execute()
+
+			if tt.streamErr != nil {
+				assert.ErrorIs(t, err, tt.streamErr)
+				assert.NoFileExists(t, output)
+			} else {
+				assert.NoError(t, err)
+				content, err := os.ReadFile(output)
+				assert.NoError(t, err)
+				assert.Equal(t, "backup content", string(content))
+			}
+		})
+	}
+}
+
+func TestBackupOutputConsistent(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().StreamBackup("mock-avs-default", gomock.Any(), daemon.StreamBackupOptions{Consistent: true}).Return(&data.Backup{InstanceId: "mock-avs-default"}, nil)
+
+	backupCmd := BackupCmd(d)
+	backupCmd.SetArgs([]string{"mock-avs-default", "--output", filepath.Join(t.TempDir(), "backup.tar.gz"), "--consistent"})
+	assert.NoError(t, backupCmd.Execute())
+}
+
+func TestBackupOutputStdout(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().StreamBackup("mock-avs-default", gomock.Any(), daemon.StreamBackupOptions{}).DoAndReturn(func(instanceId string, w io.Writer, _ daemon.StreamBackupOptions) (*data.Backup, error) {
+		_, err := w.Write([]byte("backup content"))
+		return &data.Backup{InstanceId: instanceId}, err
+	})
+
+	var out bytes.Buffer
+	backupCmd := BackupCmd(d)
+	backupCmd.SetOut(&out)
+	backupCmd.SetErr(io.Discard)
+	backupCmd.SetArgs([]string{"mock-avs-default", "--output", "-"})
+	assert.NoError(t, backupCmd.Execute())
+	assert.Equal(t, "backup content", out.String())
+}
+
+func TestBackupStdout(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/nodes/mock-avs-default/state.json", []byte(`{"name": "mock-avs", "tag": "default", "version": "v0.1.0"}`), 0o644))
+
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().StreamBackup("mock-avs-default", gomock.Any(), daemon.StreamBackupOptions{}).DoAndReturn(func(instanceId string, w io.Writer, _ daemon.StreamBackupOptions) (*data.Backup, error) {
+		log.Info("Streaming instance backup")
+		return data.CreateBackup(afs, instanceId, "/nodes/mock-avs-default", w)
+	})
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var stdOut, stdErr bytes.Buffer
+	backupCmd := BackupCmd(d)
+	backupCmd.SetOut(&stdOut)
+	backupCmd.SetErr(&stdErr)
+	backupCmd.SetArgs([]string{"mock-avs-default", "--stdout"})
+	require.NoError(t, backupCmd.Execute())
+
+	// stdout only has the backup, a valid gzip compressed tar
+	gzr, err := gzip.NewReader(&stdOut)
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.Contains(t, names, "data/state.json")
+	assert.Contains(t, names, "timestamp")
+
+	// The logs and the backup metadata go to stderr
+	logs, metadata, ok := bytes.Cut(stdErr.Bytes(), []byte("\n"))
+	require.True(t, ok)
+	assert.Contains(t, string(logs), "Streaming instance backup")
+	var backup map[string]any
+	require.NoError(t, json.Unmarshal(metadata, &backup))
+	assert.Equal(t, "mock-avs-default", backup["instance_id"])
+	assert.Equal(t, "v0.1.0", backup["version"])
+	assert.NotEmpty(t, backup["id"])
+	assert.NotEmpty(t, backup["checksum"])
+}
+
+func TestConfigureBackupDir(t *testing.T) {
+	ts := []struct {
+		name      string
+		backupDir string
+		env       string
+		want      string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:      "flag",
+			backupDir: "/flag/backups",
+			want:      "/flag/backups",
+		},
+		{
+			name: "env",
+			env:  "/env/backups",
+			want: "/env/backups",
+		},
+		{
+			name:      "flag overrides env",
+			backupDir: "/flag/backups",
+			env:       "/env/backups",
+			want:      "/flag/backups",
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(backupDirEnv, tt.env)
+			d := daemonMock.NewMockDaemon(gomock.NewController(t))
+			if tt.want != "" {
+				d.EXPECT().SetBackupDir(tt.want).Return(nil)
+			}
+
+			err := configureBackupDir(d, tt.backupDir)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -12,8 +12,13 @@ func CleanMonitoringCmd(d daemon.Daemon) *cobra.Command {
 		Long:  "Stop and uninstall the monitoring stack. If the monitoring stack is already uninstalled, the command won't do anything.",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return d.CleanMonitoring()
+			if err := d.CleanMonitoring(); err != nil {
+				return err
+			}
+			printInfo(cmd, "monitoring stack: uninstalled\n")
+			return nil
 		},
 	}
+	requireRuntime(&cmd)
 	return &cmd
 }
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+func CompletionCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "completion <bash|zsh|fish>",
+		Short: "Generate the autocompletion script for the specified shell",
+		Long: `Generate the autocompletion script for eigenlayer for the specified shell.
+
+To load completions in the current bash session:
+
+	$ source <(eigenlayer completion bash)
+
+To load completions in the current zsh session:
+
+	$ source <(eigenlayer completion zsh)
+
+To load completions in the current fish session:
+
+	$ eigenlayer completion fish | source`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return cmd.Root().GenBashCompletionV2(out, true)
+			case "zsh":
+				return cmd.Root().GenZshCompletion(out)
+			case "fish":
+				return cmd.Root().GenFishCompletion(out, true)
+			default:
+				return fmt.Errorf("%w: unsupported shell %s", ErrInvalidArgs, args[0])
+			}
+		},
+	}
+	return &cmd
+}
+
+// completeInstanceIDs returns a cobra ValidArgsFunction that completes the
+// first argument of a command with the IDs of the installed instances. If
+// multiple is true, every argument is completed with the instances not given
+// yet.
+func completeInstanceIDs(d daemon.Daemon, multiple bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 && !multiple {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		instances, err := d.ListInstances()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ids := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			if strings.HasPrefix(instance.ID, toComplete) && !slices.Contains(args, instance.ID) {
+				ids = append(ids, instance.ID)
+			}
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+}
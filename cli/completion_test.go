@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteInstanceIDs(t *testing.T) {
+	instances := []daemon.ListInstanceItem{
+		{ID: "mock-avs-default"},
+		{ID: "mock-avs-second"},
+		{ID: "other-avs-default"},
+	}
+	ts := []struct {
+		name       string
+		cmd        func(daemon.Daemon) *cobra.Command
+		args       []string
+		toComplete string
+		mocker     func(d *daemonMock.MockDaemon)
+		want       []string
+		directive  cobra.ShellCompDirective
+	}{
+		{
+			name: "all instances",
+			cmd:  StopCmd,
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(instances, nil)
+			},
+			want:      []string{"mock-avs-default", "mock-avs-second", "other-avs-default"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:       "instances with prefix",
+			cmd:        StopCmd,
+			toComplete: "mock",
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(instances, nil)
+			},
+			want:      []string{"mock-avs-default", "mock-avs-second"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:      "instance already given",
+			cmd:       StopCmd,
+			args:      []string{"mock-avs-default"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name: "multiple instances, skip given ones",
+			cmd:  RunCmd,
+			args: []string{"mock-avs-default"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(instances, nil)
+			},
+			want:      []string{"mock-avs-second", "other-avs-default"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name: "list error",
+			cmd:  RunCmd,
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(nil, assert.AnError)
+			},
+			directive: cobra.ShellCompDirectiveError,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			cmd := tt.cmd(d)
+			got, directive := cmd.ValidArgsFunction(cmd, tt.args, tt.toComplete)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.directive, directive)
+		})
+	}
+}
+
+func TestCompletionCmd(t *testing.T) {
+	ts := []struct {
+		shell string
+		want  string
+	}{
+		{shell: "bash", want: "__eigenlayer_"},
+		{shell: "zsh", want: "#compdef eigenlayer"},
+		{shell: "fish", want: "complete -c eigenlayer"},
+	}
+	for _, tt := range ts {
+		t.Run(tt.shell, func(t *testing.T) {
+			root := &cobra.Command{Use: "eigenlayer"}
+			root.AddCommand(CompletionCmd())
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"completion", tt.shell})
+			require.NoError(t, root.Execute())
+			assert.Contains(t, out.String(), tt.want)
+		})
+	}
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		root := &cobra.Command{Use: "eigenlayer"}
+		root.AddCommand(CompletionCmd())
+		root.SetArgs([]string{"completion", "powershell"})
+		assert.ErrorIs(t, root.Execute(), ErrInvalidArgs)
+	})
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func ConfigCmd(d daemon.Daemon) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "config",
+		Short: "View and edit the environment of an instance",
+		Long:  "View and edit the environment variables persisted in the .env file of an instance. Changes are applied the next time the instance is started.",
+	}
+	cmd.AddCommand(
+		configListCmd(d),
+		configGetCmd(d),
+		configSetCmd(d),
+	)
+	return &cmd
+}
+
+func configListCmd(d daemon.Daemon) *cobra.Command {
+	format := output.FormatTable
+	cmd := cobra.Command{
+		Use:   "list <instance_id>",
+		Short: "List the environment variables of an instance",
+		Long:  "Lists the environment variables of an instance, with the profile option that sets each of them, if any.",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateInstanceIds(args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := d.GetConfig(args[0])
+			if err != nil {
+				return err
+			}
+			return output.Print(cmd.OutOrStdout(), format, entries, configTable)
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+func configGetCmd(d daemon.Daemon) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "get <instance_id> <key>",
+		Short: "Print an environment variable of an instance",
+		Args:  cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateInstanceIds(args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := d.GetConfig(args[0])
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.Key == args[1] {
+					fmt.Fprintln(cmd.OutOrStdout(), entry.Value)
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: %s", daemon.ErrUnknownConfigKey, args[1])
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	return &cmd
+}
+
+func configSetCmd(d daemon.Daemon) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "set <instance_id> <key> <value>",
+		Short: "Set an environment variable of an instance",
+		Long:  "Sets an environment variable of an instance. If a profile option sets the variable, the value is validated against the option. The instance must be restarted to apply the change.",
+		Args:  cobra.ExactArgs(3),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateInstanceIds(args[0])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := d.SetConfig(args[0], args[1], args[2]); err != nil {
+				return err
+			}
+			log.Warnf("%s updated. Restart the instance %s to apply the change", args[1], args[0])
+			return nil
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	return &cmd
+}
+
+var configTable = output.Table[daemon.ConfigEntry]{
+	Headers: []string{"KEY", "VALUE", "OPTION"},
+	Row: func(e daemon.ConfigEntry) []string {
+		return []string{e.Key, e.Value, e.Option}
+	},
+}
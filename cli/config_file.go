@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv is the environment variable with the path of the config file,
+// used when the --config flag is not set.
+const configFileEnv = "EGN_CONFIG"
+
+// configFilePath returns the path of the config file: the given path if not
+// empty, $EGN_CONFIG if set, or ~/.egn/config.yaml otherwise. It returns an
+// empty path if there is no home directory.
+func configFilePath(path string) string {
+	if path != "" {
+		return path
+	}
+	if path = os.Getenv(configFileEnv); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".egn", "config.yaml")
+}
+
+// flagEnv returns the environment variable that overrides the config file
+// value of a flag, like EGN_BACKUP_DIR for --backup-dir.
+func flagEnv(name string) string {
+	return "EGN_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyConfigFile sets the persistent flags of the root command to the values
+// of the YAML config file at path, which maps flag names to values, like:
+//
+//	backup-dir: /var/backups/egn
+//	log-format: json
+//	git-header:
+//	  - "Authorization: Bearer token"
+//
+// The values are defaults: a flag set in the command line keeps its value, and
+// a flag environment variable, like EGN_LOG_FORMAT for --log-format, takes
+// precedence over the file. Only the persistent flags of the root command are
+// set, so the file and the environment can't answer the confirmations of a
+// command, like --yes, or change its behavior, like --force. Keys that are not
+// persistent flags are ignored. An empty path is not an error, nor is a
+// missing file unless required is true, like when the path is given with
+// --config.
+func applyConfigFile(cmd *cobra.Command, path string, required bool) error {
+	values, err := readConfigFile(path, required)
+	if err != nil {
+		return err
+	}
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == "help" {
+			return
+		}
+		if env := os.Getenv(flagEnv(flag.Name)); env != "" {
+			if setErr := flag.Value.Set(env); setErr != nil {
+				err = fmt.Errorf("%w: %s: %w", ErrInvalidArgs, flagEnv(flag.Name), setErr)
+			}
+			return
+		}
+		if value, ok := values[flag.Name]; ok {
+			if setErr := setFlagValue(flag, value); setErr != nil {
+				err = fmt.Errorf("%w: %s: %s: %w", ErrInvalidConfigFile, path, flag.Name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// readConfigFile returns the values of the YAML config file at path, keyed by
+// flag name. It returns no values for an empty path or, unless required is
+// true, a missing file.
+func readConfigFile(path string, required bool) (map[string]any, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfigFile, path, err)
+	}
+	return values, nil
+}
+
+// setFlagValue sets the flag to the config file value, which is a scalar or,
+// for the flags that take several values, a list of scalars. The items of a
+// list are set one by one, so an item of a repeatable flag, like --git-header,
+// may hold a comma.
+func setFlagValue(flag *pflag.Flag, value any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			return slice.Replace(items)
+		}
+		return flag.Value.Set(strings.Join(items, ","))
+	case map[string]any:
+		return errors.New("expected a value or a list of values")
+	default:
+		return flag.Value.Set(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		env        map[string]string
+		args       []string
+		backupDir  string
+		logLevel   string
+		gitHeaders []string
+		err        error
+	}{
+		{
+			name:     "no config file",
+			logLevel: "info",
+		},
+		{
+			name:      "config file values",
+			config:    "backup-dir: /backups\nlog-level: debug\nunknown-flag: value\n",
+			backupDir: "/backups",
+			logLevel:  "debug",
+		},
+		{
+			name:      "flags override the config file",
+			config:    "backup-dir: /backups\nlog-level: debug\n",
+			args:      []string{"--backup-dir", "/other", "--log-level", "warn"},
+			backupDir: "/other",
+			logLevel:  "warn",
+		},
+		{
+			name:      "environment overrides the config file",
+			config:    "backup-dir: /backups\nlog-level: debug\n",
+			env:       map[string]string{backupDirEnv: "/env", "EGN_LOG_LEVEL": "warn"},
+			backupDir: "/env",
+			logLevel:  "warn",
+		},
+		{
+			name:     "environment without config file",
+			env:      map[string]string{"EGN_LOG_LEVEL": "warn"},
+			logLevel: "warn",
+		},
+		{
+			name:      "environment not in the config file",
+			config:    "backup-dir: /backups\n",
+			env:       map[string]string{"EGN_LOG_LEVEL": "debug"},
+			backupDir: "/backups",
+			logLevel:  "debug",
+		},
+		{
+			name:       "list value",
+			config:     "git-header:\n  - \"Accept: text/html, application/json\"\n  - \"X-Token: abc\"\n",
+			logLevel:   "info",
+			gitHeaders: []string{"Accept: text/html, application/json", "X-Token: abc"},
+		},
+		{
+			name:     "command flags are not set",
+			config:   "yes: true\n",
+			env:      map[string]string{"EGN_YES": "true"},
+			logLevel: "info",
+		},
+		{
+			name:   "quiet and verbose",
+			config: "quiet: true\nverbose: 1\n",
+			err:    ErrInvalidArgs,
+		},
+		{
+			name: "invalid environment value",
+			env:  map[string]string{"EGN_RETRIES": "many"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name:   "invalid value",
+			config: "retries: many\n",
+			err:    ErrInvalidConfigFile,
+		},
+		{
+			name:   "invalid YAML",
+			config: "backup-dir: [\n",
+			err:    ErrInvalidConfigFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if tt.config != "" {
+				require.NoError(t, os.WriteFile(configPath, []byte(tt.config), 0o644))
+			}
+			t.Setenv(configFileEnv, configPath)
+			t.Setenv(backupDirEnv, "")
+			t.Setenv(gitCABundleEnv, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			d := mocks.NewMockDaemon(gomock.NewController(t))
+			if tt.err == nil {
+				d.EXPECT().SetPullRetries(defaultPullRetries)
+			}
+			if tt.backupDir != "" {
+				d.EXPECT().SetBackupDir(tt.backupDir).Return(nil)
+			}
+			if tt.gitHeaders != nil {
+				d.EXPECT().SetGitOptions(daemon.GitOptions{ExtraHeaders: tt.gitHeaders}).Return(nil)
+			}
+
+			logger := log.New()
+			logger.SetOutput(io.Discard)
+			root := RootCmd(d, nil, logger, metrics.New())
+			var (
+				logLevel, yes string
+				gitHeaders    []string
+			)
+			sub := cobra.Command{
+				Use: "sub",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					logLevel = cmd.Flag("log-level").Value.String()
+					yes = cmd.Flag("yes").Value.String()
+					var err error
+					gitHeaders, err = cmd.Flags().GetStringArray("git-header")
+					return err
+				},
+			}
+			sub.Flags().BoolP("yes", "y", false, "skip the confirmation")
+			root.AddCommand(&sub)
+			root.SetArgs(append([]string{"sub"}, tt.args...))
+			err := root.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.logLevel, logLevel)
+			assert.Equal(t, "false", yes)
+			if tt.gitHeaders != nil {
+				assert.Equal(t, tt.gitHeaders, gitHeaders)
+			}
+		})
+	}
+}
+
+func TestConfigFileFlagMissing(t *testing.T) {
+	t.Setenv(configFileEnv, "")
+	d := mocks.NewMockDaemon(gomock.NewController(t))
+	root := RootCmd(d, nil, log.New(), metrics.New())
+	root.AddCommand(&cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}})
+	root.SetArgs([]string{"sub", "--config", filepath.Join(t.TempDir(), "missing.yaml")})
+	root.SetOut(io.Discard)
+
+	// A config file given with --config must exist
+	assert.ErrorIs(t, root.Execute(), ErrInvalidConfigFile)
+}
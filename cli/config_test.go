@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "list",
+			args: []string{"list", "mock-avs-default"},
+			stdOut: "KEY             VALUE         OPTION          \n" +
+				"EXTRA           extra                         \n" +
+				"NETWORK_NAME    eigenlayer    network-name    \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().GetConfig("mock-avs-default").Return([]daemon.ConfigEntry{
+					{Key: "EXTRA", Value: "extra"},
+					{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+				}, nil)
+			},
+		},
+		{
+			name:   "get",
+			args:   []string{"get", "mock-avs-default", "NETWORK_NAME"},
+			stdOut: "eigenlayer\n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().GetConfig("mock-avs-default").Return([]daemon.ConfigEntry{
+					{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+				}, nil)
+			},
+		},
+		{
+			name: "get unknown key",
+			args: []string{"get", "mock-avs-default", "UNKNOWN"},
+			err:  daemon.ErrUnknownConfigKey,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().GetConfig("mock-avs-default").Return([]daemon.ConfigEntry{
+					{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+				}, nil)
+			},
+		},
+		{
+			name: "set",
+			args: []string{"set", "mock-avs-default", "NETWORK_NAME", "eigen-net"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().SetConfig("mock-avs-default", "NETWORK_NAME", "eigen-net").Return(nil)
+			},
+		},
+		{
+			name: "set invalid value",
+			args: []string{"set", "mock-avs-default", "NETWORK_NAME", "other"},
+			err:  daemon.ErrInvalidConfigValue,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().SetConfig("mock-avs-default", "NETWORK_NAME", "other").Return(daemon.ErrInvalidConfigValue)
+			},
+		},
+		{
+			name: "invalid instance id",
+			args: []string{"list", "mockavs"},
+			err:  ErrInvalidArgs,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut, stdErr bytes.Buffer
+			configCmd := ConfigCmd(d)
+			configCmd.SetArgs(tt.args)
+			configCmd.SetOut(&stdOut)
+			configCmd.SetErr(&stdErr)
+			configCmd.SilenceUsage = true
+			err := configCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
+
+func TestConfigSetThenGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The mock daemon keeps the environment in memory
+	env := map[string]string{"NETWORK_NAME": "eigenlayer"}
+	d := mocks.NewMockDaemon(ctrl)
+	d.EXPECT().SetConfig("mock-avs-default", gomock.Any(), gomock.Any()).DoAndReturn(func(_, key, value string) error {
+		env[key] = value
+		return nil
+	})
+	d.EXPECT().GetConfig("mock-avs-default").DoAndReturn(func(string) ([]daemon.ConfigEntry, error) {
+		return []daemon.ConfigEntry{{Key: "NETWORK_NAME", Value: env["NETWORK_NAME"]}}, nil
+	})
+
+	setCmd := ConfigCmd(d)
+	setCmd.SetArgs([]string{"set", "mock-avs-default", "NETWORK_NAME", "eigen-net"})
+	require.NoError(t, setCmd.Execute())
+
+	var stdOut bytes.Buffer
+	getCmd := ConfigCmd(d)
+	getCmd.SetArgs([]string{"get", "mock-avs-default", "NETWORK_NAME"})
+	getCmd.SetOut(&stdOut)
+	require.NoError(t, getCmd.Execute())
+	assert.Equal(t, "eigen-net\n", stdOut.String())
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+// Results of the diagnostic checks in the doctor report.
+const (
+	doctorPass = "PASS"
+	doctorFail = "FAIL"
+	doctorSkip = "SKIP"
+)
+
+// doctorResult is a row of the doctor report.
+type doctorResult struct {
+	Check   string `json:"check" yaml:"check"`
+	Result  string `json:"result" yaml:"result"`
+	Details string `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+func DoctorCmd(d daemon.Daemon) *cobra.Command {
+	format := output.FormatTable
+	cmd := cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the egn environment",
+		Long:  "Checks the Docker daemon availability, the data directory permissions, the monitoring containers health, the monitoring provisioning files and the monitoring ports, and prints a pass/fail report. The command fails if any check fails.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := d.Doctor()
+			results := make([]doctorResult, 0, len(checks))
+			failed := 0
+			for _, check := range checks {
+				result := doctorResult{Check: check.Name, Result: doctorPass}
+				switch {
+				case check.Failed():
+					failed++
+					result.Result = doctorFail
+					result.Details = check.Err.Error()
+				case check.Skipped != "":
+					result.Result = doctorSkip
+					result.Details = check.Skipped
+				}
+				results = append(results, result)
+			}
+			if err := output.Print(cmd.OutOrStdout(), format, results, doctorTable); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%w: %d of %d checks failed", ErrDoctorFailed, failed, len(checks))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+var doctorTable = output.Table[doctorResult]{
+	Headers: []string{"CHECK", "RESULT", "DETAILS"},
+	Row: func(r doctorResult) []string {
+		return []string{r.Check, r.Result, r.Details}
+	},
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctor(t *testing.T) {
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "all checks pass",
+			stdOut: "CHECK                    RESULT    DETAILS                              \n" +
+				"docker                   PASS                                           \n" +
+				"data directory           PASS                                           \n" +
+				"monitoring containers    SKIP      monitoring stack is not installed    \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Doctor().Return([]daemon.DoctorCheck{
+					{Name: "docker"},
+					{Name: "data directory"},
+					{Name: "monitoring containers", Skipped: "monitoring stack is not installed"},
+				})
+			},
+		},
+		{
+			name: "failing check",
+			err:  fmt.Errorf("%w: 1 of 2 checks failed", ErrDoctorFailed),
+			stdOut: "CHECK             RESULT    DETAILS                                     \n" +
+				"docker            FAIL      " + assert.AnError.Error() + "    \n" +
+				"data directory    PASS                                                  \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Doctor().Return([]daemon.DoctorCheck{
+					{Name: "docker", Err: assert.AnError},
+					{Name: "data directory"},
+				})
+			},
+		},
+		{
+			name: "failing check, json output",
+			args: []string{"--output", "json"},
+			err:  fmt.Errorf("%w: 1 of 1 checks failed", ErrDoctorFailed),
+			stdOut: `[
+  {
+    "check": "docker",
+    "result": "FAIL",
+    "details": "` + assert.AnError.Error() + `"
+  }
+]
+`,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Doctor().Return([]daemon.DoctorCheck{
+					{Name: "docker", Err: assert.AnError},
+				})
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mocks.NewMockDaemon(ctrl)
+			tt.mocker(d)
+
+			var stdOut, stdErr bytes.Buffer
+			doctorCmd := DoctorCmd(d)
+			doctorCmd.SetArgs(tt.args)
+			doctorCmd.SetOut(&stdOut)
+			doctorCmd.SetErr(&stdErr)
+			doctorCmd.SilenceUsage = true
+			err := doctorCmd.Execute()
+
+			if tt.err != nil {
+				require.ErrorIs(t, err, ErrDoctorFailed)
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
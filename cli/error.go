@@ -7,4 +7,11 @@ var (
 	ErrOptionWithoutDefault = errors.New("option without default value")
 	ErrInvalidNumberOfArgs  = errors.New("invalid number of arguments")
 	ErrInvalidArgs          = errors.New("invalid arguments")
+	ErrInvalidLogFormat     = errors.New("invalid log format")
+	ErrInvalidLogLevel      = errors.New("invalid log level")
+	ErrRunFailed            = errors.New("run failed")
+	ErrStopFailed           = errors.New("stop failed")
+	ErrDoctorFailed         = errors.New("diagnostic checks failed")
+	ErrPruneNotConfirmed    = errors.New("prune not confirmed")
+	ErrInvalidConfigFile    = errors.New("invalid config file")
 )
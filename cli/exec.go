@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func ExecCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		instanceId  string
+		command     []string
+		service     string
+		interactive bool
+		tty         bool
+	)
+	cmd := cobra.Command{
+		Use:   "exec [INSTANCE_ID] -- CMD...",
+		Short: "Run a command inside an AVS node instance container",
+		Long:  "Runs a command inside the container of a service of a running AVS node instance, like a shell to debug the node. The command runs in the primary service of the instance, which is the service of its API target or, without one, of its first monitoring target. Use --service to run it in another service of the instance. Use --interactive and --tty to get an interactive shell.",
+		Example: `  egn exec mock-avs-default -- ls /data
+  egn exec -it mock-avs-default -- sh
+  egn exec --service main-service mock-avs-default -- cat /config.yml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+				return fmt.Errorf("%w: expected an instance ID followed by -- and the command to run", ErrInvalidNumberOfArgs)
+			}
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			instanceId = args[0]
+			command = args[1:]
+			return validateInstanceIds(instanceId)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdin := cmd.InOrStdin()
+			if f, ok := stdin.(*os.File); ok && tty && interactive && term.IsTerminal(int(f.Fd())) {
+				// Forward every key, like Ctrl+C, to the command in the container
+				state, err := term.MakeRaw(int(f.Fd()))
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = term.Restore(int(f.Fd()), state)
+				}()
+			}
+			return d.Exec(instanceId, command, daemon.ExecOptions{
+				Service:     service,
+				Interactive: interactive,
+				TTY:         tty,
+				Stdin:       stdin,
+				Stdout:      cmd.OutOrStdout(),
+				Stderr:      cmd.ErrOrStderr(),
+			})
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+
+	cmd.Flags().StringVar(&service, "service", "", "compose service to run the command in, instead of the primary service of the instance")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "keep the stdin of the command open")
+	cmd.Flags().BoolVarP(&tty, "tty", "t", false, "allocate a pseudo-TTY for the command")
+	requireRuntime(&cmd)
+	return &cmd
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	ts := []struct {
+		name   string
+		args   []string
+		err    error
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name: "no arguments",
+			args: []string{},
+			err:  ErrInvalidNumberOfArgs,
+		},
+		{
+			name: "no command",
+			args: []string{"mock-avs-default", "--"},
+			err:  ErrInvalidNumberOfArgs,
+		},
+		{
+			name: "command without dash",
+			args: []string{"mock-avs-default", "sh"},
+			err:  ErrInvalidNumberOfArgs,
+		},
+		{
+			name: "more than one instance ID",
+			args: []string{"mock-avs-default", "mock-avs-other", "--", "sh"},
+			err:  ErrInvalidNumberOfArgs,
+		},
+		{
+			name: "invalid instance ID",
+			args: []string{"mock_avs", "--", "sh"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "command with arguments and flags",
+			args: []string{"mock-avs-default", "--", "ls", "-la", "/data"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Exec("mock-avs-default", []string{"ls", "-la", "/data"}, gomock.Any()).
+					DoAndReturn(func(_ string, _ []string, opts daemon.ExecOptions) error {
+						assert.Empty(t, opts.Service)
+						assert.False(t, opts.Interactive)
+						assert.False(t, opts.TTY)
+						return nil
+					})
+			},
+		},
+		{
+			name: "interactive shell in another service",
+			args: []string{"-it", "--service", "main-service", "mock-avs-default", "--", "sh"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Exec("mock-avs-default", []string{"sh"}, gomock.Any()).
+					DoAndReturn(func(_ string, _ []string, opts daemon.ExecOptions) error {
+						assert.Equal(t, "main-service", opts.Service)
+						assert.True(t, opts.Interactive)
+						assert.True(t, opts.TTY)
+						return nil
+					})
+			},
+		},
+		{
+			name: "exec error",
+			args: []string{"mock-avs-default", "--", "sh"},
+			err:  daemon.ErrServiceNotRunning,
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().Exec("mock-avs-default", []string{"sh"}, gomock.Any()).Return(daemon.ErrServiceNotRunning)
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			execCmd := ExecCmd(d)
+			execCmd.SetArgs(tt.args)
+			execCmd.SilenceUsage = true
+			err := execCmd.Execute()
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -12,8 +12,13 @@ func InitMonitoringCmd(d daemon.Daemon) *cobra.Command {
 		Long:  "Install and run the monitoring stack. If the monitoring stack is already installed, it will be initialized with its configuration updated.",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return d.InitMonitoring(true, true)
+			if err := d.InitMonitoring(cmd.Context(), true, true); err != nil {
+				return err
+			}
+			printInfo(cmd, "monitoring stack: running\n")
+			return nil
 		},
 	}
+	requireRuntime(&cmd)
 	return &cmd
 }
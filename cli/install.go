@@ -1,25 +1,32 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/NethermindEth/eigenlayer/cli/prompter"
+	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 )
 
 func InstallCmd(d daemon.Daemon, p prompter.Prompter) *cobra.Command {
 	var (
-		url      string
-		version  string
-		profile  string
-		tag      string
-		commit   string
-		noPrompt bool
-		help     bool
-		yes      bool
+		url        string
+		version    string
+		profile    string
+		tag        string
+		commit     string
+		noPrompt   bool
+		help       bool
+		yes        bool
+		force      bool
+		noCache    bool
+		jsonOutput bool
 	)
 	cmd := cobra.Command{
 		Use:   "install [flags] <repository_url>",
@@ -36,7 +43,9 @@ responsibility in this case.
 To ensure each instance of the node software is uniquely identified, use the
 --tag flag to create an unique id which is in the format of 
 <repository-name>-<tag>. If the tag is not specified, the "default" tag will be 
-used.
+used. Installing over an existing instance with the same id fails unless the
+--force flag is given, in which case the existing instance is backed up and
+replaced.
 
 Profile options can be specified using the --option.<option-name> flag. The
 options are dynamic and depend on the profile selected. If the profile is not
@@ -68,6 +77,9 @@ the user to know which options are available for each profile.
 				return fmt.Errorf("%w: accepts 1 arg, received %d", ErrInvalidNumberOfArgs, len(args))
 			}
 			url = args[0]
+			if err := data.ValidateTag(tag); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+			}
 			return validatePkgURL(url)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -77,10 +89,10 @@ the user to know which options are available for each profile.
 			}
 
 			// Pull the package
-			pullResult, err := d.Pull(url, daemon.PullTarget{
+			pullResult, err := d.Pull(cmd.Context(), url, daemon.PullTarget{
 				Version: version,
 				Commit:  commit,
-			}, true)
+			}, noCache)
 			if err != nil {
 				return err
 			}
@@ -112,16 +124,15 @@ the user to know which options are available for each profile.
 			// Check profile hardware requirements
 			requirements := pullResult.HardwareRequirements[profile]
 
-			ok, err = d.CheckHardwareRequirements(requirements)
-			if err != nil {
-				return err
-			}
-			if !ok {
+			err = d.CheckHardwareRequirements(requirements)
+			if errors.Is(err, daemon.ErrInsufficientResources) {
 				log.Printf("Hardware requirements: %s", requirements)
 				if requirements.StopIfRequirementsAreNotMet {
-					return fmt.Errorf("profile %s does not meet the hardware requirements", profile)
+					return fmt.Errorf("profile %s does not meet the hardware requirements: %w", profile, err)
 				}
-				log.Warnf("Profile %s does not meet the hardware requirements", profile)
+				log.Warnf("Profile %s does not meet the hardware requirements: %v", profile, err)
+			} else if err != nil {
+				return err
 			} else {
 				log.Infof("Profile %s meets the hardware requirements", profile)
 			}
@@ -172,11 +183,11 @@ the user to know which options are available for each profile.
 			// // Until the engine runs as a daemon, this is the best solution.
 
 			// Init monitoring stack. If won't do anything if it is not installed or running
-			if err = d.InitMonitoring(false, false); err != nil {
+			if err = d.InitMonitoring(cmd.Context(), false, false); err != nil {
 				return err
 			}
 
-			instanceId, err := d.Install(daemon.InstallOptions{
+			result, err := d.Install(daemon.InstallOptions{
 				Name:        pullResult.Name,
 				URL:         url,
 				Version:     pullResult.Version,
@@ -185,11 +196,20 @@ the user to know which options are available for each profile.
 				Tag:         tag,
 				Profile:     profile,
 				Options:     profileOptions,
+				Force:       force,
 			})
 			if err != nil {
 				return err
 			}
+			instanceId := result.InstanceID
 			log.Info("Installed successfully with instance id: ", instanceId)
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(result); err != nil {
+					return err
+				}
+			}
 
 			if pullResult.HasPlugin {
 				// TODO: improve this message with the command to run the plugin
@@ -204,18 +224,75 @@ the user to know which options are available for each profile.
 				}
 			}
 			if ok {
-				return d.Run(instanceId)
+				return d.Run(cmd.Context(), instanceId, daemon.RunOptions{})
 			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&version, "version", "v", "", "version to install. If not specified the latest version will be installed.")
+	cmd.Flags().StringVar(&version, "version", "", "version to install. If not specified the latest version will be installed.")
 	cmd.Flags().StringVar(&commit, "commit", "", "commit to install from. If not specified the latest version will be installed.")
 	cmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to use for the new instance name. If not specified a list of available profiles will be shown to select from.")
 	cmd.Flags().StringVarP(&tag, "tag", "t", "default", "tag to use for the new instance name.")
 	cmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "disable command prompts, and all options should be passed using command flags.")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompts.")
+	cmd.Flags().BoolVar(&force, "force", false, "replace an installed instance with the same id, backing it up first.")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "download the package again instead of using the copy cached by a previous install of the same version or commit.")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print a JSON summary of the installed instance.")
 	cmd.MarkFlagsMutuallyExclusive("version", "commit")
+	requireRuntime(&cmd)
 	return &cmd
 }
+
+// defaultPullRetries is the default number of times a package download is
+// retried after a transient network error.
+const defaultPullRetries = 3
+
+// configurePullRetries sets the number of times the daemon retries a package
+// download after a transient network error.
+func configurePullRetries(d daemon.Daemon, retries int) error {
+	if retries < 0 {
+		return fmt.Errorf("%w: --retries must not be negative, got %d", ErrInvalidArgs, retries)
+	}
+	d.SetPullRetries(retries)
+	return nil
+}
+
+// gitCABundleEnv is the environment variable with the path of the CA bundle
+// used by the package clones, if the --git-ca-bundle flag is not set.
+const gitCABundleEnv = "EGN_GIT_CA_BUNDLE"
+
+// configureGitOptions sets the CA bundle and the extra HTTP headers of the
+// package clones. The CA bundle defaults to $EGN_GIT_CA_BUNDLE.
+func configureGitOptions(d daemon.Daemon, caBundle string, headers []string) error {
+	if caBundle == "" {
+		caBundle = os.Getenv(gitCABundleEnv)
+	}
+	if caBundle == "" && len(headers) == 0 {
+		return nil
+	}
+	return d.SetGitOptions(daemon.GitOptions{
+		CABundle:     caBundle,
+		ExtraHeaders: headers,
+	})
+}
+
+// registryConfigEnv is the environment variable with the path of the docker
+// config file with the registry credentials, if the --registry-config flag is
+// not set.
+const registryConfigEnv = "EGN_REGISTRY_CONFIG"
+
+// configureRegistryOptions sets the credentials of the registries the images
+// are pulled from. The docker config file defaults to $EGN_REGISTRY_CONFIG.
+func configureRegistryOptions(d daemon.Daemon, configPath string, auths []string) error {
+	if configPath == "" {
+		configPath = os.Getenv(registryConfigEnv)
+	}
+	if configPath == "" && len(auths) == 0 {
+		return nil
+	}
+	return d.SetRegistryOptions(daemon.RegistryOptions{
+		ConfigPath: configPath,
+		Auths:      auths,
+	})
+}
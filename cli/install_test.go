@@ -1,16 +1,24 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
 	prompterMock "github.com/NethermindEth/eigenlayer/cli/prompter/mocks"
 	"github.com/NethermindEth/eigenlayer/internal/common"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 )
 
@@ -36,6 +44,11 @@ func TestInstall(t *testing.T) {
 			args: []string{"invalid-url"},
 			err:  fmt.Errorf("%w: parse \"invalid-url\": invalid URI for request", ErrInvalidURL),
 		},
+		{
+			name: "invalid tag",
+			args: []string{"--tag", "my/tag", common.MockAvsPkg.Repo()},
+			err:  fmt.Errorf("%w: %w: \"my/tag\" must start with a letter or a digit, followed by letters, digits, '-', '.' or '_'", ErrInvalidArgs, data.ErrInvalidTag),
+		},
 		{
 			name: "valid arguments, run confirmed",
 			args: []string{common.MockAvsPkg.Repo()},
@@ -49,7 +62,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -70,9 +83,9 @@ func TestInstall(t *testing.T) {
 						MinRAM:                      2048,
 						MinFreeSpace:                5120,
 						StopIfRequirementsAreNotMet: true,
-					}).Return(true, nil),
+					}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -80,9 +93,9 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run("mock-avs-pkg-default").Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(nil),
 				)
 			},
 		},
@@ -99,7 +112,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -120,9 +133,9 @@ func TestInstall(t *testing.T) {
 						MinRAM:                      2048,
 						MinFreeSpace:                5120,
 						StopIfRequirementsAreNotMet: true,
-					}).Return(true, nil),
+					}).Return(nil),
 					p.EXPECT().InputHiddenString("option1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -130,7 +143,7 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(false, nil),
 				)
 			},
@@ -148,7 +161,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -159,9 +172,9 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(assert.AnError),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(assert.AnError),
 				)
 			},
 		},
@@ -178,7 +191,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -189,9 +202,9 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -199,9 +212,9 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run("mock-avs-pkg-default").Return(assert.AnError),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(assert.AnError),
 				)
 			},
 		},
@@ -218,7 +231,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -229,9 +242,9 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -239,7 +252,7 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, assert.AnError),
 				)
 			},
@@ -257,7 +270,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -268,9 +281,9 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -278,8 +291,71 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
-					d.EXPECT().Run("mock-avs-pkg-default").Return(nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(nil),
+				)
+			},
+		},
+		{
+			name: "instance already exists",
+			args: []string{common.MockAvsPkg.Repo(), "--yes"},
+			err:  fmt.Errorf("%w: mock-avs-pkg-default", daemon.ErrInstanceExists),
+			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
+				gomock.InOrder(
+					d.EXPECT().
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
+						Return(daemon.PullResult{
+							Version: common.MockAvsPkg.Version(),
+							Options: map[string][]daemon.Option{
+								"profile1": {},
+							},
+							HardwareRequirements: map[string]daemon.HardwareRequirements{
+								"profile1": {},
+							},
+						}, nil),
+					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().
+						Install(daemon.InstallOptions{
+							URL:     common.MockAvsPkg.Repo(),
+							Version: common.MockAvsPkg.Version(),
+							Profile: "profile1",
+							Options: []daemon.Option{},
+							Tag:     "default",
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, fmt.Errorf("%w: mock-avs-pkg-default", daemon.ErrInstanceExists)),
+				)
+			},
+		},
+		{
+			name: "instance already exists, with --force",
+			args: []string{common.MockAvsPkg.Repo(), "--yes", "--force"},
+			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
+				gomock.InOrder(
+					d.EXPECT().
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
+						Return(daemon.PullResult{
+							Version: common.MockAvsPkg.Version(),
+							Options: map[string][]daemon.Option{
+								"profile1": {},
+							},
+							HardwareRequirements: map[string]daemon.HardwareRequirements{
+								"profile1": {},
+							},
+						}, nil),
+					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().
+						Install(daemon.InstallOptions{
+							URL:     common.MockAvsPkg.Repo(),
+							Version: common.MockAvsPkg.Version(),
+							Profile: "profile1",
+							Options: []daemon.Option{},
+							Tag:     "default",
+							Force:   true,
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(nil),
 				)
 			},
 		},
@@ -296,7 +372,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -307,9 +383,9 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -317,8 +393,8 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
-					d.EXPECT().Run("mock-avs-pkg-default").Return(assert.AnError),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(assert.AnError),
 				)
 			},
 		},
@@ -335,7 +411,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -346,18 +422,18 @@ func TestInstall(t *testing.T) {
 							},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", errors.New("input string error")),
 				)
 			},
 		},
 		{
-			name: "pull error",
-			args: []string{"-v", common.MockAvsPkg.Version(), common.MockAvsPkg.Repo()},
+			name: "pull error, no cache",
+			args: []string{"--version", common.MockAvsPkg.Version(), "--no-cache", common.MockAvsPkg.Repo()},
 			err:  errors.New("pull error"),
 			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				d.EXPECT().
-					Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{Version: common.MockAvsPkg.Version()}, true).
+					Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{Version: common.MockAvsPkg.Version()}, true).
 					Return(daemon.PullResult{}, errors.New("pull error"))
 			},
 		},
@@ -368,7 +444,7 @@ func TestInstall(t *testing.T) {
 			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -386,7 +462,7 @@ func TestInstall(t *testing.T) {
 			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -410,7 +486,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -419,9 +495,9 @@ func TestInstall(t *testing.T) {
 							HardwareRequirements: map[string]daemon.HardwareRequirements{},
 						}, nil),
 					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
-					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(true, nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -429,7 +505,7 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", errors.New("install error")),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, errors.New("install error")),
 				)
 			},
 		},
@@ -440,7 +516,7 @@ func TestInstall(t *testing.T) {
 			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -461,18 +537,18 @@ func TestInstall(t *testing.T) {
 						MinRAM:                      2048,
 						MinFreeSpace:                5120,
 						StopIfRequirementsAreNotMet: false,
-					}).Return(false, errors.New("hardware requirements not met")),
+					}).Return(errors.New("hardware requirements not met")),
 				)
 			},
 		},
 		{
 			name: "hardware not met and stop",
 			args: []string{common.MockAvsPkg.Repo()},
-			err:  errors.New("profile profile1 does not meet the hardware requirements"),
+			err:  fmt.Errorf("profile profile1 does not meet the hardware requirements: %w: CPU: required 2.00 Cores, available 1.00 Cores", daemon.ErrInsufficientResources),
 			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Options: map[string][]daemon.Option{
@@ -493,7 +569,49 @@ func TestInstall(t *testing.T) {
 						MinRAM:                      2048,
 						MinFreeSpace:                5120,
 						StopIfRequirementsAreNotMet: true,
-					}).Return(false, nil),
+					}).Return(fmt.Errorf("%w: CPU: required 2.00 Cores, available 1.00 Cores", daemon.ErrInsufficientResources)),
+				)
+			},
+		},
+		{
+			name: "hardware not met and continue",
+			args: []string{common.MockAvsPkg.Repo()},
+			err:  nil,
+			daemonMock: func(d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
+				gomock.InOrder(
+					d.EXPECT().
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
+						Return(daemon.PullResult{
+							Version: common.MockAvsPkg.Version(),
+							Options: map[string][]daemon.Option{
+								"profile1": {},
+							},
+							HardwareRequirements: map[string]daemon.HardwareRequirements{
+								"profile1": {
+									MinCPUCores:                 2,
+									MinRAM:                      2048,
+									MinFreeSpace:                5120,
+									StopIfRequirementsAreNotMet: false,
+								},
+							},
+						}, nil),
+					p.EXPECT().Select("Select a profile", []string{"profile1"}).Return("profile1", nil),
+					d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{
+						MinCPUCores:                 2,
+						MinRAM:                      2048,
+						MinFreeSpace:                5120,
+						StopIfRequirementsAreNotMet: false,
+					}).Return(fmt.Errorf("%w: CPU: required 2.00 Cores, available 1.00 Cores", daemon.ErrInsufficientResources)),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().
+						Install(daemon.InstallOptions{
+							URL:     common.MockAvsPkg.Repo(),
+							Version: common.MockAvsPkg.Version(),
+							Profile: "profile1",
+							Options: []daemon.Option{},
+							Tag:     "default",
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
+					p.EXPECT().Confirm("Run the new instance now?").Return(false, nil),
 				)
 			},
 		},
@@ -510,7 +628,7 @@ func TestInstall(t *testing.T) {
 
 				gomock.InOrder(
 					d.EXPECT().
-						Pull(common.MockAvsPkg.Repo(), daemon.PullTarget{Commit: common.MockAvsPkg.CommitHash()}, true).
+						Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{Commit: common.MockAvsPkg.CommitHash()}, false).
 						Return(daemon.PullResult{
 							Version: common.MockAvsPkg.Version(),
 							Commit:  common.MockAvsPkg.CommitHash(),
@@ -532,9 +650,9 @@ func TestInstall(t *testing.T) {
 						MinRAM:                      2048,
 						MinFreeSpace:                5120,
 						StopIfRequirementsAreNotMet: true,
-					}).Return(true, nil),
+					}).Return(nil),
 					p.EXPECT().InputString("option1", "default1", "help1", gomock.Any()).Return("value1", nil),
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
 					d.EXPECT().
 						Install(daemon.InstallOptions{
 							URL:     common.MockAvsPkg.Repo(),
@@ -543,9 +661,9 @@ func TestInstall(t *testing.T) {
 							Profile: "profile1",
 							Options: []daemon.Option{option},
 							Tag:     "default",
-						}).Return("mock-avs-pkg-default", nil),
+						}).Return(daemon.InstallResult{InstanceID: "mock-avs-pkg-default"}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run("mock-avs-pkg-default").Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-pkg-default", daemon.RunOptions{}).Return(nil),
 				)
 			},
 		},
@@ -573,3 +691,202 @@ func TestInstall(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallJSON(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	p := prompterMock.NewMockPrompter(controller)
+	result := daemon.InstallResult{
+		InstanceID:          "mock-avs-pkg-default",
+		Version:             common.MockAvsPkg.Version(),
+		Commit:              common.MockAvsPkg.CommitHash(),
+		URL:                 common.MockAvsPkg.Repo(),
+		Profile:             "profile1",
+		DataDir:             "/egn/nodes/mock-avs-pkg-default",
+		MonitoringEndpoints: []string{"main-service:8080/metrics"},
+		Dashboards:          []string{"node-exporter"},
+	}
+	gomock.InOrder(
+		d.EXPECT().
+			Pull(gomock.Any(), common.MockAvsPkg.Repo(), daemon.PullTarget{}, false).
+			Return(daemon.PullResult{
+				Version: common.MockAvsPkg.Version(),
+				Commit:  common.MockAvsPkg.CommitHash(),
+				Options: map[string][]daemon.Option{
+					"profile1": {},
+				},
+				HardwareRequirements: map[string]daemon.HardwareRequirements{
+					"profile1": {},
+				},
+			}, nil),
+		d.EXPECT().CheckHardwareRequirements(daemon.HardwareRequirements{}).Return(nil),
+		d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+		d.EXPECT().
+			Install(daemon.InstallOptions{
+				URL:     common.MockAvsPkg.Repo(),
+				Version: common.MockAvsPkg.Version(),
+				Commit:  common.MockAvsPkg.CommitHash(),
+				Profile: "profile1",
+				Options: []daemon.Option{},
+				Tag:     "default",
+			}).Return(result, nil),
+		p.EXPECT().Confirm("Run the new instance now?").Return(false, nil),
+	)
+
+	var out bytes.Buffer
+	installCmd := InstallCmd(d, p)
+	installCmd.SetOut(&out)
+	installCmd.SetArgs([]string{"--json", "--profile", "profile1", common.MockAvsPkg.Repo()})
+	require.NoError(t, installCmd.Execute())
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "mock-avs-pkg-default", got["instance_id"])
+	assert.Equal(t, common.MockAvsPkg.Version(), got["version"])
+	assert.Equal(t, common.MockAvsPkg.CommitHash(), got["commit"])
+	assert.Equal(t, common.MockAvsPkg.Repo(), got["url"])
+	assert.Equal(t, "/egn/nodes/mock-avs-pkg-default", got["data_dir"])
+	assert.Equal(t, []interface{}{"main-service:8080/metrics"}, got["monitoring_endpoints"])
+	assert.Equal(t, []interface{}{"node-exporter"}, got["dashboards"])
+}
+
+func TestConfigurePullRetries(t *testing.T) {
+	t.Run("retries", func(t *testing.T) {
+		d := daemonMock.NewMockDaemon(gomock.NewController(t))
+		d.EXPECT().SetPullRetries(5)
+		assert.NoError(t, configurePullRetries(d, 5))
+	})
+	t.Run("negative retries", func(t *testing.T) {
+		d := daemonMock.NewMockDaemon(gomock.NewController(t))
+		assert.ErrorIs(t, configurePullRetries(d, -1), ErrInvalidArgs)
+	})
+}
+
+func TestConfigureGitOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		env    string
+		want   *daemon.GitOptions
+		setErr error
+	}{
+		{
+			name: "no git options",
+			args: []string{"version"},
+		},
+		{
+			name: "ca bundle and headers flags",
+			args: []string{"version", "--git-ca-bundle", "/etc/ssl/proxy.pem", "--git-header", "Authorization: Bearer token", "--git-header", "X-Proxy-Tenant: egn"},
+			want: &daemon.GitOptions{
+				CABundle:     "/etc/ssl/proxy.pem",
+				ExtraHeaders: []string{"Authorization: Bearer token", "X-Proxy-Tenant: egn"},
+			},
+		},
+		{
+			name: "ca bundle from env",
+			args: []string{"version"},
+			env:  "/etc/ssl/env.pem",
+			want: &daemon.GitOptions{CABundle: "/etc/ssl/env.pem"},
+		},
+		{
+			name: "flag overrides env",
+			args: []string{"version", "--git-ca-bundle", "/etc/ssl/proxy.pem"},
+			env:  "/etc/ssl/env.pem",
+			want: &daemon.GitOptions{CABundle: "/etc/ssl/proxy.pem"},
+		},
+		{
+			name:   "invalid options",
+			args:   []string{"version", "--git-header", "Authorization"},
+			want:   &daemon.GitOptions{ExtraHeaders: []string{"Authorization"}},
+			setErr: daemon.ErrInvalidGitOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(backupDirEnv, "")
+			t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+			t.Setenv(gitCABundleEnv, tt.env)
+			d := daemonMock.NewMockDaemon(gomock.NewController(t))
+			d.EXPECT().SetPullRetries(defaultPullRetries)
+			if tt.want != nil {
+				d.EXPECT().SetGitOptions(*tt.want).Return(tt.setErr)
+			}
+
+			root := RootCmd(d, nil, log.New(), metrics.New())
+			root.SetArgs(tt.args)
+			root.SetOut(io.Discard)
+			err := root.Execute()
+			if tt.setErr != nil {
+				assert.ErrorIs(t, err, tt.setErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigureRegistryOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		env    string
+		want   *daemon.RegistryOptions
+		setErr error
+	}{
+		{
+			name: "no registry options",
+			args: []string{"version"},
+		},
+		{
+			name: "auths of several registries",
+			args: []string{"version", "--registry-auth", "ghcr.io=user:token", "--registry-auth", "registry.example.com=user:password"},
+			want: &daemon.RegistryOptions{
+				Auths: []string{"ghcr.io=user:token", "registry.example.com=user:password"},
+			},
+		},
+		{
+			name: "config from env",
+			args: []string{"version"},
+			env:  "/root/.docker/config.json",
+			want: &daemon.RegistryOptions{ConfigPath: "/root/.docker/config.json"},
+		},
+		{
+			name: "flag overrides env",
+			args: []string{"version", "--registry-config", "/etc/egn/docker.json", "--registry-auth", "ghcr.io=user:token"},
+			env:  "/root/.docker/config.json",
+			want: &daemon.RegistryOptions{
+				ConfigPath: "/etc/egn/docker.json",
+				Auths:      []string{"ghcr.io=user:token"},
+			},
+		},
+		{
+			name:   "invalid options",
+			args:   []string{"version", "--registry-auth", "ghcr.io"},
+			want:   &daemon.RegistryOptions{Auths: []string{"ghcr.io"}},
+			setErr: daemon.ErrInvalidRegistryOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(backupDirEnv, "")
+			t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+			t.Setenv(gitCABundleEnv, "")
+			t.Setenv(registryConfigEnv, tt.env)
+			d := daemonMock.NewMockDaemon(gomock.NewController(t))
+			d.EXPECT().SetPullRetries(defaultPullRetries)
+			if tt.want != nil {
+				d.EXPECT().SetRegistryOptions(*tt.want).Return(tt.setErr)
+			}
+
+			root := RootCmd(d, nil, log.New(), metrics.New())
+			root.SetArgs(tt.args)
+			root.SetOut(io.Discard)
+			err := root.Execute()
+			if tt.setErr != nil {
+				assert.ErrorIs(t, err, tt.setErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
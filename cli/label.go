@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+func LabelCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		set    map[string]string
+		remove []string
+	)
+	format := output.FormatTable
+	cmd := cobra.Command{
+		Use:   "label <instance_id> [<key>=<value>...] [<key>-...]",
+		Short: "View and edit the labels of an instance",
+		Long: `View and edit the labels of an instance. Labels are key=value tags used to group instances, like env=prod. A key=value
+argument sets a label and a key- argument removes it. Without label arguments, the labels of the instance are listed.
+Labels are added to the metrics of the instance the next time it is started. Use 'egn ls --selector key=value' to
+list the instances with a label.`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err = validateInstanceIds(args[0]); err != nil {
+				return err
+			}
+			set, remove, err = parseLabelArgs(args[1:])
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels, err := d.Label(args[0], set, remove)
+			if err != nil {
+				return err
+			}
+			return output.Print(cmd.OutOrStdout(), format, labelEntries(labels), labelTable)
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+// parseLabelArgs parses the key=value arguments into the labels to set and
+// the key- arguments into the keys of the labels to remove.
+func parseLabelArgs(args []string) (set map[string]string, remove []string, err error) {
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			if set == nil {
+				set = make(map[string]string)
+			}
+			set[key] = value
+			continue
+		}
+		if key, ok := strings.CutSuffix(arg, "-"); ok && key != "" {
+			remove = append(remove, key)
+			continue
+		}
+		return nil, nil, fmt.Errorf("%w: invalid label argument %q, expected <key>=<value> or <key>-", ErrInvalidArgs, arg)
+	}
+	for _, key := range remove {
+		if _, ok := set[key]; ok {
+			return nil, nil, fmt.Errorf("%w: label %s is both set and removed", ErrInvalidArgs, key)
+		}
+	}
+	return set, remove, nil
+}
+
+// labelEntry is a label of an instance, as printed by the label command.
+type labelEntry struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// labelEntries returns the labels sorted by key.
+func labelEntries(labels map[string]string) []labelEntry {
+	entries := make([]labelEntry, 0, len(labels))
+	for key, value := range labels {
+		entries = append(entries, labelEntry{Key: key, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+var labelTable = output.Table[labelEntry]{
+	Headers: []string{"KEY", "VALUE"},
+	Row: func(e labelEntry) []string {
+		return []string{e.Key, e.Value}
+	},
+}
+
+// labelSelector is a set of key=value pairs an instance must have among its
+// labels to match, like env=prod,team=infra.
+type labelSelector map[string]string
+
+// parseLabelSelector parses the key=value pairs of the --selector flag.
+func parseLabelSelector(pairs []string) (labelSelector, error) {
+	selector := make(labelSelector, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: invalid selector %q, expected <key>=<value>", ErrInvalidArgs, pair)
+		}
+		selector[key] = value
+	}
+	return selector, nil
+}
+
+// matches returns true if the labels have all the key=value pairs of the
+// selector. An empty selector matches any labels.
+func (s labelSelector) matches(labels map[string]string) bool {
+	for key, value := range s {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
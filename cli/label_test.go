@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabel(t *testing.T) {
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "list",
+			args: []string{"mock-avs-default"},
+			stdOut: "KEY     VALUE    \n" +
+				"env     prod     \n" +
+				"team    infra    \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Label("mock-avs-default", nil, nil).Return(map[string]string{"team": "infra", "env": "prod"}, nil)
+			},
+		},
+		{
+			name:   "set and remove",
+			args:   []string{"mock-avs-default", "env=prod", "region-", "-o", "json"},
+			stdOut: "[\n  {\n    \"key\": \"env\",\n    \"value\": \"prod\"\n  }\n]\n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Label("mock-avs-default", map[string]string{"env": "prod"}, []string{"region"}).Return(map[string]string{"env": "prod"}, nil)
+			},
+		},
+		{
+			name: "invalid label",
+			args: []string{"mock-avs-default", "my-env=prod"},
+			err:  daemon.ErrInvalidLabel,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Label("mock-avs-default", map[string]string{"my-env": "prod"}, nil).Return(nil, daemon.ErrInvalidLabel)
+			},
+		},
+		{
+			name: "invalid label argument",
+			args: []string{"mock-avs-default", "env"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "set and remove the same label",
+			args: []string{"mock-avs-default", "env=prod", "env-"},
+			err:  ErrInvalidArgs,
+		},
+		{
+			name: "invalid instance id",
+			args: []string{"mockavs", "env=prod"},
+			err:  ErrInvalidArgs,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut, stdErr bytes.Buffer
+			labelCmd := LabelCmd(d)
+			labelCmd.SetArgs(tt.args)
+			labelCmd.SetOut(&stdOut)
+			labelCmd.SetErr(&stdErr)
+			labelCmd.SilenceUsage = true
+			err := labelCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	ts := []struct {
+		name     string
+		selector []string
+		labels   map[string]string
+		matches  bool
+		err      error
+	}{
+		{
+			name:    "empty selector",
+			labels:  map[string]string{"env": "prod"},
+			matches: true,
+		},
+		{
+			name:    "empty selector, no labels",
+			matches: true,
+		},
+		{
+			name:     "match",
+			selector: []string{"env=prod"},
+			labels:   map[string]string{"env": "prod", "team": "infra"},
+			matches:  true,
+		},
+		{
+			name:     "all pairs match",
+			selector: []string{"env=prod", "team=infra"},
+			labels:   map[string]string{"env": "prod", "team": "infra"},
+			matches:  true,
+		},
+		{
+			name:     "one pair does not match",
+			selector: []string{"env=prod", "team=infra"},
+			labels:   map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			name:     "missing label",
+			selector: []string{"team=infra"},
+			labels:   map[string]string{"env": "prod"},
+		},
+		{
+			name:     "no labels",
+			selector: []string{"env=prod"},
+		},
+		{
+			name:     "empty value does not match a missing label",
+			selector: []string{"env="},
+		},
+		{
+			name:     "invalid selector",
+			selector: []string{"env"},
+			err:      ErrInvalidArgs,
+		},
+		{
+			name:     "empty key",
+			selector: []string{"=prod"},
+			err:      ErrInvalidArgs,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := parseLabelSelector(tt.selector)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, selector.matches(tt.labels))
+		})
+	}
+}
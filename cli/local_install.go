@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	log "github.com/sirupsen/logrus"
@@ -20,6 +21,7 @@ func LocalInstallCmd(d daemon.Daemon) *cobra.Command {
 		tag      string
 		help     bool
 		run      bool
+		force    bool
 		options  = make(map[string]string)
 		logDebug bool
 	)
@@ -82,6 +84,9 @@ profile.`,
 			if len(args) != 1 {
 				return fmt.Errorf("%w: accepts 1 arg, received %d", ErrInvalidNumberOfArgs, len(args))
 			}
+			if err := data.ValidateTag(tag); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+			}
 			path, err = filepath.Abs(args[0])
 			if err != nil {
 				return err
@@ -120,7 +125,7 @@ profile.`,
 			// // Until the engine runs as a daemon, this is the best solution.
 
 			// Init monitoring stack. If won't do anything if it is not installed or running
-			if err = d.InitMonitoring(false, false); err != nil {
+			if err = d.InitMonitoring(cmd.Context(), false, false); err != nil {
 				return err
 			}
 
@@ -129,6 +134,7 @@ profile.`,
 				Tag:     tag,
 				Profile: profile,
 				Options: options,
+				Force:   force,
 			})
 			if err != nil {
 				return err
@@ -136,7 +142,7 @@ profile.`,
 			log.Info("Installed successfully with instance id: ", instanceId)
 
 			if run {
-				return d.Run(instanceId)
+				return d.Run(cmd.Context(), instanceId, daemon.RunOptions{})
 			}
 			return nil
 		},
@@ -145,7 +151,9 @@ profile.`,
 	cmd.Flags().BoolVarP(&run, "run", "r", false, "run the new instance after installation")
 	cmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to use for the new instance. If not specified, the installation will fail.")
 	cmd.Flags().StringVarP(&tag, "tag", "t", "default", "tag to use for the new instance.")
+	cmd.Flags().BoolVar(&force, "force", false, "replace an installed instance with the same id, backing it up first.")
 
 	cmd.MarkFlagRequired("profile")
+	requireRuntime(&cmd)
 	return &cmd
 }
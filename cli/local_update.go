@@ -142,7 +142,7 @@ and if the update process fails, the instance will be restored.`,
 			// Backup instance
 			var backupId string
 			if backup {
-				backupId, err = d.Backup(instanceId)
+				backupId, err = d.Backup(instanceId, daemon.BackupOptions{})
 				if err != nil {
 					return err
 				}
@@ -199,12 +199,13 @@ and if the update process fails, the instance will be restored.`,
 				log.Info("The installed node software has a plugin.")
 			}
 
-			return runInstance(d, newInstanceId, p, yes, noPrompt)
+			return runInstance(cmd.Context(), d, newInstanceId, p, yes, noPrompt)
 		},
 	}
 
 	cmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "disable command prompts, and all options should be passed using command flags.")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompts.")
 	cmd.Flags().BoolVar(&backup, "backup", false, "backup current instance before updating.")
+	requireRuntime(&cmd)
 	return &cmd
 }
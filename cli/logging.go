@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// configureLogger sets the output format and the level of the given logger.
+// The format must be one of "text" or "json", and the level any of the levels
+// supported by logrus. Each verbosity step raises the level once, so a
+// verbosity of 1 turns the info level into debug. If quiet is set, the level
+// is error, so only the errors are logged.
+func configureLogger(logger *log.Logger, format, level string, quiet bool, verbosity int) error {
+	switch format {
+	case logFormatText:
+		logger.SetFormatter(&log.TextFormatter{})
+	case logFormatJSON:
+		logger.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidLogFormat, format)
+	}
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLogLevel, level)
+	}
+	// The flags are mutually exclusive, but cobra only checks the command
+	// line and they can also be set by the config file or the environment
+	if quiet && verbosity > 0 {
+		return fmt.Errorf("%w: --quiet and --verbose can't be used together", ErrInvalidArgs)
+	}
+	switch {
+	case quiet:
+		lvl = log.ErrorLevel
+	case verbosity > 0:
+		lvl = min(lvl+log.Level(verbosity), log.TraceLevel)
+	}
+	logger.SetLevel(lvl)
+	return nil
+}
+
+// printInfo prints informational output of the command, like the status of
+// the instances it started. The output is omitted with --quiet, which leaves
+// the command silent on success.
+func printInfo(cmd *cobra.Command, format string, a ...any) {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return
+	}
+	cmd.Printf(format, a...)
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureLogger(t *testing.T) {
+	ts := []struct {
+		name      string
+		format    string
+		level     string
+		quiet     bool
+		verbosity int
+		err       error
+		wantLevel log.Level
+	}{
+		{
+			name:      "text format, info level",
+			format:    "text",
+			level:     "info",
+			wantLevel: log.InfoLevel,
+		},
+		{
+			name:      "json format, debug level",
+			format:    "json",
+			level:     "debug",
+			wantLevel: log.DebugLevel,
+		},
+		{
+			name:      "quiet",
+			format:    "text",
+			level:     "debug",
+			quiet:     true,
+			wantLevel: log.ErrorLevel,
+		},
+		{
+			name:      "verbose",
+			format:    "text",
+			level:     "info",
+			verbosity: 1,
+			wantLevel: log.DebugLevel,
+		},
+		{
+			name:      "very verbose",
+			format:    "text",
+			level:     "info",
+			verbosity: 2,
+			wantLevel: log.TraceLevel,
+		},
+		{
+			name:      "verbosity above trace",
+			format:    "text",
+			level:     "warn",
+			verbosity: 5,
+			wantLevel: log.TraceLevel,
+		},
+		{
+			name:      "quiet and verbose",
+			format:    "text",
+			level:     "info",
+			quiet:     true,
+			verbosity: 1,
+			err:       ErrInvalidArgs,
+		},
+		{
+			name:   "invalid format",
+			format: "xml",
+			level:  "info",
+			err:    ErrInvalidLogFormat,
+		},
+		{
+			name:   "invalid level",
+			format: "text",
+			level:  "verbose",
+			err:    ErrInvalidLogLevel,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := log.New()
+			err := configureLogger(logger, tt.format, tt.level, tt.quiet, tt.verbosity)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLevel, logger.GetLevel())
+		})
+	}
+}
+
+func TestConfigureLoggerJSONOutput(t *testing.T) {
+	logger := log.New()
+	err := configureLogger(logger, "json", "info", false, 0)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	logger.WithField("instance_id", "mock-avs-default").Info("Starting instance")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "Starting instance", entry["msg"])
+	assert.Equal(t, "mock-avs-default", entry["instance_id"])
+}
+
+func TestQuiet(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "run",
+			args: []string{"run", "mock-avs-1", "mock-avs-2"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+				d.EXPECT().CheckUpdates(gomock.Any(), gomock.Any()).Return(&daemon.UpdateInfo{}, nil).Times(2)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-1", daemon.RunOptions{}).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-2", daemon.RunOptions{}).Return(nil)
+			},
+		},
+		{
+			name: "backup",
+			args: []string{"backup", "mock-avs-default"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Backup("mock-avs-default", daemon.BackupOptions{}).Return("backup-id", nil)
+			},
+		},
+		{
+			name: "monitoring reconcile",
+			args: []string{"monitoring", "reconcile"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+				d.EXPECT().ReconcileMonitoring().Return(nil)
+			},
+		},
+		{
+			name: "init-monitoring",
+			args: []string{"init-monitoring"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), true, true).Return(nil)
+			},
+		},
+		{
+			name: "restore",
+			args: []string{"restore", "mock-avs-default-backup"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Restore("mock-avs-default-backup", daemon.RestoreOptions{}).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, quiet := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s, quiet %t", tt.name, quiet), func(t *testing.T) {
+				t.Setenv(backupDirEnv, "")
+				t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+				ctrl := gomock.NewController(t)
+				d := mocks.NewMockDaemon(ctrl)
+				d.EXPECT().SetPullRetries(defaultPullRetries)
+				d.EXPECT().CheckRuntime().Return(nil)
+				tt.mocker(d)
+
+				// The commands log with the standard logger
+				logger := log.StandardLogger()
+				level := logger.GetLevel()
+				t.Cleanup(func() {
+					logger.SetOutput(os.Stderr)
+					logger.SetLevel(level)
+				})
+				var stdout, stderr bytes.Buffer
+				logger.SetOutput(&stderr)
+
+				root := RootCmd(d, nil, logger, metrics.New())
+				root.AddCommand(RunCmd(d), BackupCmd(d), RestoreCmd(d), MonitoringCmd(d), InitMonitoringCmd(d))
+				args := tt.args
+				if quiet {
+					args = append(args, "--quiet")
+				}
+				root.SetArgs(args)
+				root.SetOut(&stdout)
+				root.SetErr(&stderr)
+				require.NoError(t, root.Execute())
+
+				if quiet {
+					assert.Empty(t, stdout.String())
+					assert.Empty(t, stderr.String())
+				} else {
+					assert.NotEmpty(t, stdout.String())
+				}
+			})
+		}
+	}
+}
+
+func TestQuietAndVerbose(t *testing.T) {
+	t.Setenv(backupDirEnv, "")
+	t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+	ctrl := gomock.NewController(t)
+	d := mocks.NewMockDaemon(ctrl)
+	d.EXPECT().SetPullRetries(defaultPullRetries).AnyTimes()
+
+	root := RootCmd(d, nil, log.New(), metrics.New())
+	root.SetArgs([]string{"version", "-q", "--verbose"})
+	root.SetOut(io.Discard)
+	err := root.Execute()
+	// The logger is configured before cobra validates the flag groups
+	assert.ErrorIs(t, err, ErrInvalidArgs)
+}
+
+func TestVerboseDoesNotClashWithSubcommandFlags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	d := mocks.NewMockDaemon(ctrl)
+
+	root := RootCmd(d, nil, log.New(), metrics.New())
+	install, plugin := InstallCmd(d, nil), PluginCmd(d)
+	root.AddCommand(install, plugin)
+	for _, cmd := range []*cobra.Command{install, plugin} {
+		assert.NotPanics(t, func() { cmd.InheritedFlags() }, cmd.Name())
+		// -v is --verbose in every command
+		require.NotNil(t, cmd.Flags().ShorthandLookup("v"), cmd.Name())
+		assert.Equal(t, "verbose", cmd.Flags().ShorthandLookup("v").Name, cmd.Name())
+	}
+}
+
+func TestVerboseShorthand(t *testing.T) {
+	t.Setenv(backupDirEnv, "")
+	t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+	ctrl := gomock.NewController(t)
+	d := mocks.NewMockDaemon(ctrl)
+	d.EXPECT().SetPullRetries(defaultPullRetries)
+	d.EXPECT().InstanceVersion(gomock.Any()).AnyTimes()
+
+	logger := log.New()
+	root := RootCmd(d, nil, logger, metrics.New())
+	root.SetArgs([]string{"version", "-vv"})
+	root.SetOut(io.Discard)
+	require.NoError(t, root.Execute())
+	assert.Equal(t, log.TraceLevel, logger.GetLevel())
+}
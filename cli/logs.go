@@ -23,8 +23,9 @@ func LogsCmd(d daemon.Daemon) *cobra.Command {
 		Short: "Show AVS node logs",
 		Long:  "Show AVS node logs, which are the logs of all the services running in the node.",
 		Args:  cobra.ExactArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			instanceID = args[0]
+			return validateInstanceIds(instanceID)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return d.NodeLogs(context.Background(), os.Stdout, instanceID, daemon.NodeLogsOptions{
@@ -36,11 +37,13 @@ func LogsCmd(d daemon.Daemon) *cobra.Command {
 			})
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
 
 	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow log output")
 	cmd.Flags().StringVar(&since, "since", "", "Show logs since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
 	cmd.Flags().StringVar(&until, "until", "", "Show logs before a timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
 	cmd.Flags().BoolVarP(&timestamps, "timestamps", "t", false, "Show timestamps")
 	cmd.Flags().StringVarP(&tail, "tail", "n", "all", "Number of lines to show from the end of the logs")
+	requireRuntime(&cmd)
 	return &cmd
 }
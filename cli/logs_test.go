@@ -37,9 +37,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 	tc := []testCase{
 		{
 			name: "success",
-			args: []string{"id1"},
+			args: []string{"mock-avs-default"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Tail: "all",
 				}).Return(nil)
 				out.Write([]byte(testOutput))
@@ -48,17 +48,17 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "daemon error",
-			args: []string{"id1"},
+			args: []string{"mock-avs-default"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", gomock.Any()).Return(assert.AnError)
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", gomock.Any()).Return(assert.AnError)
 			},
 			expectedErr: assert.AnError,
 		},
 		{
 			name: "with no flags",
-			args: []string{"id1"},
+			args: []string{"mock-avs-default"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Tail: "all",
 				}).Return(nil)
 				out.Write([]byte(testOutput))
@@ -67,9 +67,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "follow flag is set properly",
-			args: []string{"id1", "--follow"},
+			args: []string{"mock-avs-default", "--follow"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Follow: true,
 					Tail:   "all",
 				}).Return(nil)
@@ -79,9 +79,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "follow is set with the shorthand letter",
-			args: []string{"id1", "-f"},
+			args: []string{"mock-avs-default", "-f"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Follow: true,
 					Tail:   "all",
 				}).Return(nil)
@@ -91,9 +91,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "since flag is set properly",
-			args: []string{"id1", "--since", "2013-01-02T13:23:37Z"},
+			args: []string{"mock-avs-default", "--since", "2013-01-02T13:23:37Z"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Since: "2013-01-02T13:23:37Z",
 					Tail:  "all",
 				}).Return(nil)
@@ -103,9 +103,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "until flag is set properly",
-			args: []string{"id1", "--until", "2013-01-02T13:23:37Z"},
+			args: []string{"mock-avs-default", "--until", "2013-01-02T13:23:37Z"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Until: "2013-01-02T13:23:37Z",
 					Tail:  "all",
 				}).Return(nil)
@@ -115,9 +115,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "timestamps flag is set properly",
-			args: []string{"id1", "--timestamps"},
+			args: []string{"mock-avs-default", "--timestamps"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Timestamps: true,
 					Tail:       "all",
 				}).Return(nil)
@@ -127,9 +127,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "timestamps is set with the shorthand letter",
-			args: []string{"id1", "-t"},
+			args: []string{"mock-avs-default", "-t"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Timestamps: true,
 					Tail:       "all",
 				}).Return(nil)
@@ -139,9 +139,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "tail flag is set properly",
-			args: []string{"id1", "--tail", "6"},
+			args: []string{"mock-avs-default", "--tail", "6"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Tail: "6",
 				}).Return(nil)
 				out.Write([]byte(testOutput))
@@ -150,9 +150,9 @@ option-returner: 2023-07-21T10:23:35.558762590Z INFO:     172.29.0.3:38684 - "GE
 		},
 		{
 			name: "tail is set with the shorthand letter",
-			args: []string{"id1", "-n", "6"},
+			args: []string{"mock-avs-default", "-n", "6"},
 			mocker: func(d *daemonMock.MockDaemon, out io.Writer) {
-				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "id1", daemon.NodeLogsOptions{
+				d.EXPECT().NodeLogs(gomock.Any(), gomock.Any(), "mock-avs-default", daemon.NodeLogsOptions{
 					Tail: "6",
 				}).Return(nil)
 				out.Write([]byte(testOutput))
@@ -1,24 +1,29 @@
 package cli
 
 import (
-	"fmt"
-	"text/tabwriter"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/NethermindEth/eigenlayer/cli/output"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/spf13/cobra"
 )
 
-type tableItem struct {
-	avs     string
-	version string
-	commit  string
-	running bool
-	health  string
-	comment string
+var instanceTable = output.Table[daemon.ListInstanceItem]{
+	Headers: []string{"AVS Instance ID", "RUNNING", "HEALTH", "VERSION", "COMMIT", "INSTALLED", "COMMENT"},
+	Row: func(i daemon.ListInstanceItem) []string {
+		return []string{i.ID, strconv.FormatBool(i.Running), i.Health.String(), i.Version, commitPrefix(i.Commit), installedAt(i.InstalledAt), i.Comment}
+	},
 }
 
-func (i tableItem) String() string {
-	return fmt.Sprintf("%s\t%t\t%s\t%s\t%s\t%s\t", i.avs, i.running, i.health, i.version, commitPrefix(i.commit), i.comment)
+// installedAt formats the installation time of an instance. Instances
+// installed before the installation time was recorded have a zero time.
+func installedAt(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateTime)
 }
 
 func commitPrefix(commit string) string {
@@ -29,34 +34,33 @@ func commitPrefix(commit string) string {
 }
 
 func ListCmd(d daemon.Daemon) *cobra.Command {
-	return &cobra.Command{
+	var selector []string
+	format := output.FormatTable
+	cmd := cobra.Command{
 		Use:   "ls",
 		Short: "List all installed AVS nodes and their health status.",
 		Long: `List all installed AVS nodes and their health status. If the AVS node is not running the health check will not be
 performed. An AVS node is considered running if it is installed and has at least one running service. The health check
 is performed by calling the health endpoint of the AVS node, to know more about this endpoint please refer to this
-Eigenlayer AVS Specification link https://eigen.nethermind.io/docs/metrics/metrics-api#get-eigennodehealth.`,
+Eigenlayer AVS Specification link https://eigen.nethermind.io/docs/metrics/metrics-api#get-eigennodehealth.
+Use --output to get the list as a table, JSON or YAML document, and --selector to only list the AVS nodes with the
+given labels.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			instances, err := d.ListInstances()
+			matchLabels, err := parseLabelSelector(selector)
 			if err != nil {
 				return err
 			}
-
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 4, ' ', 0)
-			fmt.Fprintln(w, "AVS Instance ID\tRUNNING\tHEALTH\tVERSION\tCOMMIT\tCOMMENT\t")
-			for _, instance := range instances {
-				fmt.Fprintln(w, tableItem{
-					avs:     instance.ID,
-					running: instance.Running,
-					health:  instance.Health.String(),
-					comment: instance.Comment,
-					version: instance.Version,
-					commit:  instance.Commit,
-				})
+			instances, err := d.ListInstances()
+			if err != nil {
+				return err
 			}
-			w.Flush()
-
-			return nil
+			instances = slices.DeleteFunc(instances, func(i daemon.ListInstanceItem) bool {
+				return !matchLabels.matches(i.Labels)
+			})
+			return output.Print(cmd.OutOrStdout(), format, instances, instanceTable)
 		},
 	}
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	cmd.Flags().StringSliceVarP(&selector, "selector", "l", nil, "only list the AVS nodes with all the given labels, like env=prod,team=infra")
+	return &cmd
 }
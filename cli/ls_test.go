@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
 	"github.com/NethermindEth/eigenlayer/internal/common"
@@ -14,6 +15,7 @@ import (
 func TestList(t *testing.T) {
 	tests := []struct {
 		name   string
+		args   []string
 		mocker func(d *daemonMock.MockDaemon)
 		err    error
 		stdOut []byte
@@ -24,12 +26,13 @@ func TestList(t *testing.T) {
 			mocker: func(d *daemonMock.MockDaemon) {
 				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{
 					{
-						ID:      "id1",
-						Running: true,
-						Health:  daemon.NodeHealthy,
-						Comment: "comment1",
-						Version: common.MockAvsPkg.Version(),
-						Commit:  common.MockAvsPkg.CommitHash(),
+						ID:          "id1",
+						Running:     true,
+						Health:      daemon.NodeHealthy,
+						Comment:     "comment1",
+						Version:     common.MockAvsPkg.Version(),
+						Commit:      common.MockAvsPkg.CommitHash(),
+						InstalledAt: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC),
 					}, {
 						ID:      "id2",
 						Running: false,
@@ -41,9 +44,9 @@ func TestList(t *testing.T) {
 				}, nil)
 			},
 			stdOut: []byte(
-				"AVS Instance ID    RUNNING    HEALTH     VERSION    COMMIT          COMMENT     \n" +
-					"id1                true       healthy    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    comment1    \n" +
-					"id2                false      unknown    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    comment2    \n",
+				"AVS Instance ID    RUNNING    HEALTH     VERSION    COMMIT          INSTALLED              COMMENT     \n" +
+					"id1                true       healthy    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    2023-10-04 07:12:19    comment1    \n" +
+					"id2                false      unknown    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    -                      comment2    \n",
 			),
 		},
 		{
@@ -68,9 +71,9 @@ func TestList(t *testing.T) {
 				}, nil)
 			},
 			stdOut: []byte(
-				"AVS Instance ID    RUNNING    HEALTH     VERSION    COMMIT          COMMENT     \n" +
-					"id1                true       healthy    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    comment1    \n" +
-					"id2                false      unknown    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:7] + "         comment2    \n",
+				"AVS Instance ID    RUNNING    HEALTH     VERSION    COMMIT          INSTALLED    COMMENT     \n" +
+					"id1                true       healthy    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:12] + "    -            comment1    \n" +
+					"id2                false      unknown    " + common.MockAvsPkg.Version() + "     " + common.MockAvsPkg.CommitHash()[:7] + "         -            comment2    \n",
 			),
 		},
 		{
@@ -79,9 +82,108 @@ func TestList(t *testing.T) {
 				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{}, nil)
 			},
 			stdOut: []byte(
-				"AVS Instance ID    RUNNING    HEALTH    VERSION    COMMIT    COMMENT    \n",
+				"AVS Instance ID    RUNNING    HEALTH    VERSION    COMMIT    INSTALLED    COMMENT    \n",
 			),
 		},
+		{
+			name: "json output",
+			args: []string{"--output", "json"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{
+					{
+						ID:          "id1",
+						Running:     true,
+						Health:      daemon.NodeHealthy,
+						Comment:     "comment1",
+						Version:     "v5.5.0",
+						Commit:      "a3406616b848164358fdd24465b8eecda5f5ae34",
+						InstalledAt: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC),
+					},
+				}, nil)
+			},
+			stdOut: []byte(`[
+  {
+    "id": "id1",
+    "version": "v5.5.0",
+    "commit": "a3406616b848164358fdd24465b8eecda5f5ae34",
+    "health": "healthy",
+    "running": true,
+    "comment": "comment1",
+    "installed_at": "2023-10-04T07:12:19Z"
+  }
+]
+`),
+		},
+		{
+			name: "yaml output",
+			args: []string{"-o", "yaml"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{
+					{
+						ID:      "id1",
+						Running: false,
+						Health:  daemon.NodeHealthUnknown,
+						Version: "v5.5.0",
+						Commit:  "a3406616b848164358fdd24465b8eecda5f5ae34",
+					},
+				}, nil)
+			},
+			stdOut: []byte(`- id: id1
+  version: v5.5.0
+  commit: a3406616b848164358fdd24465b8eecda5f5ae34
+  health: unknown
+  running: false
+  comment: ""
+  installed_at: 0001-01-01T00:00:00Z
+`),
+		},
+		{
+			name: "selector",
+			args: []string{"--selector", "env=prod,team=infra", "-o", "json"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{
+					{
+						ID:      "id1",
+						Health:  daemon.NodeHealthUnknown,
+						Version: "v5.5.0",
+						Labels:  map[string]string{"env": "prod", "team": "infra"},
+					},
+					{
+						ID:      "id2",
+						Health:  daemon.NodeHealthUnknown,
+						Version: "v5.5.0",
+						Labels:  map[string]string{"env": "dev", "team": "infra"},
+					},
+					{
+						ID:      "id3",
+						Health:  daemon.NodeHealthUnknown,
+						Version: "v5.5.0",
+					},
+				}, nil)
+			},
+			stdOut: []byte(`[
+  {
+    "id": "id1",
+    "version": "v5.5.0",
+    "commit": "",
+    "health": "unknown",
+    "running": false,
+    "comment": "",
+    "installed_at": "0001-01-01T00:00:00Z",
+    "labels": {
+      "env": "prod",
+      "team": "infra"
+    }
+  }
+]
+`),
+		},
+		{
+			name:   "invalid selector",
+			args:   []string{"--selector", "env"},
+			err:    ErrInvalidArgs,
+			errOut: []byte("Error: invalid arguments: invalid selector \"env\", expected <key>=<value>\n"),
+		},
 		{
 			name: "daemon list error",
 			mocker: func(d *daemonMock.MockDaemon) {
@@ -104,12 +206,13 @@ func TestList(t *testing.T) {
 			)
 
 			cmd := ListCmd(d)
+			cmd.SetArgs(tt.args)
 			cmd.SetOut(&stdOut)
 			cmd.SetErr(&errOut)
 			err := cmd.Execute()
 
 			if tt.err != nil {
-				assert.ErrorIs(t, tt.err, err)
+				assert.ErrorIs(t, err, tt.err)
 				assert.Equal(t, tt.errOut, errOut.Bytes())
 			} else {
 				assert.NoError(t, err)
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// serveMetrics exposes the egn metrics at the metrics path of metricsAddr for
+// as long as the command runs. Nothing is served if metricsAddr is empty.
+func serveMetrics(m *metrics.Metrics, metricsAddr string, logger log.FieldLogger) (*metrics.Server, error) {
+	if metricsAddr == "" {
+		return nil, nil
+	}
+	server, err := m.Serve(metricsAddr)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Serving egn metrics at http://%s%s", server.Addr(), metrics.Path)
+	return server, nil
+}
+
+// metricsCloseTimeout is the time the metrics server waits for the active
+// scrapes to finish when the command ends.
+const metricsCloseTimeout = 5 * time.Second
+
+// closeMetrics shuts down the server started by serveMetrics, if any.
+func closeMetrics(server *metrics.Server) error {
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCloseTimeout)
+	defer cancel()
+	return server.Close(ctx)
+}
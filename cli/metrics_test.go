@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeMetrics(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	m := metrics.New()
+
+	// Nothing is served without an address
+	server, err := serveMetrics(m, "", logger)
+	require.NoError(t, err)
+	assert.Nil(t, server)
+
+	server, err = serveMetrics(m, "127.0.0.1:0", logger)
+	require.NoError(t, err)
+	m.Observe(metrics.OperationInstall, nil)
+
+	resp, err := http.Get("http://" + server.Addr().String() + metrics.Path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "egn_installs_total 1")
+
+	// The server is shut down when the command ends
+	require.NoError(t, closeMetrics(server))
+	_, err = http.Get("http://" + server.Addr().String() + metrics.Path)
+	assert.Error(t, err)
+	assert.NoError(t, closeMetrics(nil))
+}
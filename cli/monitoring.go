@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+// configureMonitoringPermissions sets the permissions and ownership of the
+// directories and files created in the monitoring stack. The daemon defaults
+// are kept if none of them is set.
+func configureMonitoringPermissions(d daemon.Daemon, options daemon.MonitoringPermissions) error {
+	if options == (daemon.MonitoringPermissions{}) {
+		return nil
+	}
+	return d.SetMonitoringPermissions(options)
+}
+
+func MonitoringCmd(d daemon.Daemon) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "monitoring",
+		Short: "Manage the monitoring stack",
+		Long:  "Manage the monitoring stack. To install or uninstall it, use 'eigenlayer init-monitoring' and 'eigenlayer clean-monitoring'.",
+		Args:  cobra.NoArgs,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(
+		MonitoringReconcileCmd(d),
+		MonitoringStatusCmd(d),
+	)
+
+	return &cmd
+}
+
+func MonitoringReconcileCmd(d daemon.Daemon) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "reconcile",
+		Short: "Remove monitoring targets of instances that are no longer installed",
+		Long:  "Remove the monitoring targets of instances that are no longer installed, like the ones left behind by an uninstall that did not complete. The monitoring stack must be running.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := d.InitMonitoring(cmd.Context(), false, false); err != nil {
+				return err
+			}
+			if err := d.ReconcileMonitoring(); err != nil {
+				return err
+			}
+			printInfo(cmd, "monitoring targets: reconciled\n")
+			return nil
+		},
+	}
+	requireRuntime(&cmd)
+	return &cmd
+}
+
+func MonitoringStatusCmd(d daemon.Daemon) *cobra.Command {
+	var jsonOutput bool
+	cmd := cobra.Command{
+		Use:   "status",
+		Short: "Show the status of the monitoring stack",
+		Long:  "Shows whether the containers of the monitoring services are running, their ports, and the number of targets scraped by Prometheus that are active, up and down. Use --json to get the status as a JSON document.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := d.InitMonitoring(cmd.Context(), false, false); err != nil {
+				return err
+			}
+			status, err := d.MonitoringStatus()
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(status)
+			}
+			if err := output.Print(cmd.OutOrStdout(), output.FormatTable, status.Services, monitoringServiceTable); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\nTargets: %d active, %d up, %d down\n", status.Targets.Active, status.Targets.Up, status.Targets.Down)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the status as a JSON document.")
+	requireRuntime(&cmd)
+	return &cmd
+}
+
+var monitoringServiceTable = output.Table[daemon.MonitoringServiceStatus]{
+	Headers: []string{"SERVICE", "RUNNING", "PORTS"},
+	Row: func(s daemon.MonitoringServiceStatus) []string {
+		return []string{s.Name, strconv.FormatBool(s.Running), strings.Join(s.Ports, ",")}
+	},
+}
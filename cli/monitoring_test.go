@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitoringReconcile(t *testing.T) {
+	ts := []struct {
+		name   string
+		args   []string
+		err    error
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name:   "unexpected argument",
+			args:   []string{"reconcile", "mock-avs-default"},
+			err:    errors.New(`unknown command "mock-avs-default" for "monitoring reconcile"`),
+			mocker: nil,
+		},
+		{
+			name: "reconcile success",
+			args: []string{"reconcile"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().ReconcileMonitoring().Return(nil),
+				)
+			},
+		},
+		{
+			name: "init monitoring error",
+			args: []string{"reconcile"},
+			err:  errors.New("init error"),
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(errors.New("init error"))
+			},
+		},
+		{
+			name: "reconcile error",
+			args: []string{"reconcile"},
+			err:  errors.New("reconcile error"),
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().ReconcileMonitoring().Return(errors.New("reconcile error")),
+				)
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			monitoringCmd := MonitoringCmd(d)
+			monitoringCmd.SetArgs(tt.args)
+			err := monitoringCmd.Execute()
+
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMonitoringStatus(t *testing.T) {
+	status := daemon.MonitoringStatus{
+		Services: []daemon.MonitoringServiceStatus{
+			{Name: "egn_grafana", Running: true, Ports: []string{"3000"}},
+			{Name: "egn_prometheus", Running: false, Ports: []string{"9090"}},
+		},
+		Targets: daemon.MonitoringTargets{Active: 3, Up: 2, Down: 1},
+	}
+	ts := []struct {
+		name   string
+		args   []string
+		out    string
+		err    error
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name: "table",
+			args: []string{"status"},
+			out: "SERVICE           RUNNING    PORTS    \n" +
+				"egn_grafana       true       3000     \n" +
+				"egn_prometheus    false      9090     \n" +
+				"\nTargets: 3 active, 2 up, 1 down\n",
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().MonitoringStatus().Return(status, nil),
+				)
+			},
+		},
+		{
+			name: "json",
+			args: []string{"status", "--json"},
+			out: `{
+  "services": [
+    {
+      "name": "egn_grafana",
+      "running": true,
+      "ports": [
+        "3000"
+      ]
+    },
+    {
+      "name": "egn_prometheus",
+      "running": false,
+      "ports": [
+        "9090"
+      ]
+    }
+  ],
+  "targets": {
+    "active": 3,
+    "up": 2,
+    "down": 1
+  }
+}
+`,
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().MonitoringStatus().Return(status, nil),
+				)
+			},
+		},
+		{
+			name: "not installed",
+			args: []string{"status"},
+			err:  daemon.ErrMonitoringStackNotInstalled,
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().MonitoringStatus().Return(daemon.MonitoringStatus{}, daemon.ErrMonitoringStackNotInstalled),
+				)
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			tt.mocker(d)
+
+			var out bytes.Buffer
+			monitoringCmd := MonitoringCmd(d)
+			monitoringCmd.SetArgs(tt.args)
+			monitoringCmd.SetOut(&out)
+			err := monitoringCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, out.String())
+		})
+	}
+}
+
+func TestConfigureMonitoringPermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		env    string
+		want   *daemon.MonitoringPermissions
+		setErr error
+	}{
+		{
+			name: "no permissions",
+			args: []string{"version"},
+		},
+		{
+			name: "modes and owner flags",
+			args: []string{"version", "--monitoring-dir-mode", "0750", "--monitoring-file-mode", "0640", "--monitoring-owner", "472:472"},
+			want: &daemon.MonitoringPermissions{DirMode: "0750", FileMode: "0640", Owner: "472:472"},
+		},
+		{
+			name: "owner from env",
+			args: []string{"version"},
+			env:  "1000:1000",
+			want: &daemon.MonitoringPermissions{Owner: "1000:1000"},
+		},
+		{
+			name:   "invalid permissions",
+			args:   []string{"version", "--monitoring-dir-mode", "rwx"},
+			want:   &daemon.MonitoringPermissions{DirMode: "rwx"},
+			setErr: daemon.ErrInvalidMonitoringPermissions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(backupDirEnv, "")
+			t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+			t.Setenv(flagEnv("monitoring-owner"), tt.env)
+			d := daemonMock.NewMockDaemon(gomock.NewController(t))
+			d.EXPECT().SetPullRetries(defaultPullRetries)
+			if tt.want != nil {
+				d.EXPECT().SetMonitoringPermissions(*tt.want).Return(tt.setErr)
+			}
+
+			root := RootCmd(d, nil, log.New(), metrics.New())
+			root.SetArgs(tt.args)
+			root.SetOut(io.Discard)
+			err := root.Execute()
+			if tt.setErr != nil {
+				assert.ErrorIs(t, err, tt.setErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func OutdatedCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		instanceIds []string
+		format      = output.FormatTable
+	)
+	cmd := cobra.Command{
+		Use:   "outdated [<instance_id>...]",
+		Short: "Check the installed instances for newer package versions",
+		Long:  "Checks whether newer versions of the packages of the installed instances are available, comparing the installed version with the version tags of the package repository. Without arguments, every installed instance is checked. The versions of each repository are cached for a few hours. Instances installed from a local package are skipped. Use --output to get the result as a table, JSON or YAML document.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			instanceIds = args
+			return validateInstanceIds(instanceIds...)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(instanceIds) == 0 {
+				instances, err := d.ListInstances()
+				if err != nil {
+					return err
+				}
+				for _, instance := range instances {
+					instanceIds = append(instanceIds, instance.ID)
+				}
+			}
+			updates := make([]daemon.UpdateInfo, 0, len(instanceIds))
+			for _, instanceId := range instanceIds {
+				info, err := d.CheckUpdates(cmd.Context(), instanceId)
+				if errors.Is(err, daemon.ErrUpdateCheckUnsupported) {
+					log.Debugf("Skipping update check of %s: %v", instanceId, err)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				updates = append(updates, *info)
+			}
+			return output.Print(cmd.OutOrStdout(), format, updates, outdatedTable)
+		},
+	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+var outdatedTable = output.Table[daemon.UpdateInfo]{
+	Headers: []string{"AVS Instance ID", "CURRENT", "LATEST", "UPDATE AVAILABLE"},
+	Row: func(u daemon.UpdateInfo) []string {
+		return []string{u.InstanceId, u.CurrentVersion, u.LatestVersion, strconv.FormatBool(u.UpdateAvailable)}
+	},
+}
+
+// updateCheckTimeout is the time warnOutdated waits for the update checks of
+// all the instances, so an unreachable package repository does not hold the
+// command.
+const updateCheckTimeout = 5 * time.Second
+
+// warnOutdated logs a warning for each of the instances with a newer package
+// version available. The update check is best effort, so its failures are
+// only logged at debug level.
+func warnOutdated(ctx context.Context, d daemon.Daemon, instanceIds []string) {
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+	for _, instanceId := range instanceIds {
+		info, err := d.CheckUpdates(ctx, instanceId)
+		if err != nil {
+			log.Debugf("Update check of %s failed: %v", instanceId, err)
+			continue
+		}
+		if info.UpdateAvailable {
+			log.Warnf("A newer version of %s is available: %s (installed %s). Run 'egn outdated' for details", instanceId, info.LatestVersion, info.CurrentVersion)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutdated(t *testing.T) {
+	outdated := &daemon.UpdateInfo{
+		InstanceId:      "mock-avs-default",
+		CurrentVersion:  "v3.0.3",
+		LatestVersion:   "v3.1.0",
+		UpdateAvailable: true,
+	}
+	upToDate := &daemon.UpdateInfo{
+		InstanceId:     "mock-avs-second",
+		CurrentVersion: "v3.1.0",
+		LatestVersion:  "v3.1.0",
+	}
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "all instances",
+			stdOut: "AVS Instance ID     CURRENT    LATEST    UPDATE AVAILABLE    \n" +
+				"mock-avs-default    v3.0.3     v3.1.0    true                \n" +
+				"mock-avs-second     v3.1.0     v3.1.0    false               \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().ListInstances().Return([]daemon.ListInstanceItem{
+					{ID: "mock-avs-default"},
+					{ID: "mock-avs-local"},
+					{ID: "mock-avs-second"},
+				}, nil)
+				d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-default").Return(outdated, nil)
+				// Local installs are skipped
+				d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-local").Return(nil, daemon.ErrUpdateCheckUnsupported)
+				d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-second").Return(upToDate, nil)
+			},
+		},
+		{
+			name: "given instance as json",
+			args: []string{"mock-avs-default", "-o", "json"},
+			stdOut: `[
+  {
+    "instance_id": "mock-avs-default",
+    "current_version": "v3.0.3",
+    "latest_version": "v3.1.0",
+    "update_available": true,
+    "checked_at": "0001-01-01T00:00:00Z"
+  }
+]
+`,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-default").Return(outdated, nil)
+			},
+		},
+		{
+			name: "check error",
+			args: []string{"mock-avs-default"},
+			err:  daemon.ErrCheckingUpdates,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-default").Return(nil, daemon.ErrCheckingUpdates)
+			},
+		},
+		{
+			name: "invalid instance ID",
+			args: []string{"mock_avs"},
+			err:  ErrInvalidArgs,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut bytes.Buffer
+			outdatedCmd := OutdatedCmd(d)
+			outdatedCmd.SetArgs(tt.args)
+			outdatedCmd.SetOut(&stdOut)
+			outdatedCmd.SetErr(&bytes.Buffer{})
+			outdatedCmd.SilenceUsage = true
+			err := outdatedCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
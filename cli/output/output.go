@@ -0,0 +1,96 @@
+// Package output renders lists of items as a table, JSON or YAML for the
+// list-style commands of the CLI.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidFormat = errors.New("invalid output format")
+
+// Format is the output format of a list. It implements the pflag.Value
+// interface, so it can be used directly as the value of a --output flag.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+)
+
+// FlagUsage is the usage message of a --output flag.
+const FlagUsage = "output format: table, json or yaml"
+
+// String implements pflag.Value.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+// Set implements pflag.Value.
+func (f *Format) Set(value string) error {
+	switch Format(value) {
+	case FormatTable, FormatJSON, FormatYAML:
+		*f = Format(value)
+		return nil
+	default:
+		return fmt.Errorf("%w: %s. Must be one of table, json or yaml", ErrInvalidFormat, value)
+	}
+}
+
+// Type implements pflag.Value.
+func (f *Format) Type() string {
+	return "format"
+}
+
+// Table describes how to render items of type T as table rows.
+type Table[T any] struct {
+	// Headers are the column names of the table.
+	Headers []string
+	// Row returns the column values of an item, in the same order as Headers.
+	Row func(item T) []string
+}
+
+// Print writes the items to out using the given format. JSON and YAML
+// outputs always hold a list, even if there are no items.
+func Print[T any](out io.Writer, format Format, items []T, table Table[T]) error {
+	if items == nil {
+		items = []T{}
+	}
+	switch format {
+	case FormatTable, "":
+		return printTable(out, items, table)
+	case FormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(items); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
+
+func printTable[T any](out io.Writer, items []T, table Table[T]) error {
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, tableRow(table.Headers))
+	for _, item := range items {
+		fmt.Fprintln(w, tableRow(table.Row(item)))
+	}
+	return w.Flush()
+}
+
+func tableRow(columns []string) string {
+	return strings.Join(columns, "\t") + "\t"
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name    string `json:"name" yaml:"name"`
+	Size    int    `json:"size" yaml:"size"`
+	Running bool   `json:"running" yaml:"running"`
+}
+
+var itemTable = Table[item]{
+	Headers: []string{"NAME", "SIZE", "RUNNING"},
+	Row: func(i item) []string {
+		return []string{i.Name, strconv.Itoa(i.Size), strconv.FormatBool(i.Running)}
+	},
+}
+
+func TestPrint(t *testing.T) {
+	items := []item{
+		{Name: "mock-avs-default", Size: 10240, Running: true},
+		{Name: "mock-avs-second", Size: 5, Running: false},
+	}
+	tc := []struct {
+		name   string
+		format Format
+		items  []item
+		want   string
+	}{
+		{
+			name:   "table",
+			format: FormatTable,
+			items:  items,
+			want: "NAME                SIZE     RUNNING    \n" +
+				"mock-avs-default    10240    true       \n" +
+				"mock-avs-second     5        false      \n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			items:  items,
+			want: `[
+  {
+    "name": "mock-avs-default",
+    "size": 10240,
+    "running": true
+  },
+  {
+    "name": "mock-avs-second",
+    "size": 5,
+    "running": false
+  }
+]
+`,
+		},
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			items:  items,
+			want: `- name: mock-avs-default
+  size: 10240
+  running: true
+- name: mock-avs-second
+  size: 5
+  running: false
+`,
+		},
+		{
+			name:   "table, no items",
+			format: FormatTable,
+			want:   "NAME    SIZE    RUNNING    \n",
+		},
+		{
+			name:   "json, no items",
+			format: FormatJSON,
+			want:   "[]\n",
+		},
+		{
+			name:   "yaml, no items",
+			format: FormatYAML,
+			want:   "[]\n",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Print(&out, tt.format, tt.items, itemTable)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestPrintInvalidFormat(t *testing.T) {
+	var out bytes.Buffer
+	err := Print(&out, Format("xml"), []item{}, itemTable)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+	assert.Empty(t, out.String())
+}
+
+func TestFormatSet(t *testing.T) {
+	var f Format
+	for _, value := range []string{"table", "json", "yaml"} {
+		require.NoError(t, f.Set(value))
+		assert.Equal(t, value, f.String())
+	}
+	assert.ErrorIs(t, f.Set("xml"), ErrInvalidFormat)
+	assert.Equal(t, "yaml", f.String())
+}
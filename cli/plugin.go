@@ -51,6 +51,9 @@ func PluginCmd(d daemon.Daemon) *cobra.Command {
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			instanceId = args[0]
+			if err := validateInstanceIds(instanceId); err != nil {
+				return err
+			}
 			if !d.HasInstance(instanceId) {
 				return errors.New("instance not found")
 			}
@@ -82,10 +85,12 @@ func PluginCmd(d daemon.Daemon) *cobra.Command {
 			return d.RunPlugin(instanceId, pluginArgs, runPluginOptions)
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
 
 	cmd.Flags().BoolVar(&noDestroyImage, "no-rm-image", false, "Do not remove the plugin image after plugin execution")
 	cmd.Flags().BoolVar(&host, "host", false, "Run the plugin on the host network instead of the AVS network")
-	cmd.Flags().StringSliceVarP(&volumes, "volume", "v", []string{}, "Bind mount a volume. Format: <volume_name>:<path> or <path>:<path>. Can be specified multiple times")
+	cmd.Flags().StringSliceVar(&volumes, "volume", []string{}, "Bind mount a volume. Format: <volume_name>:<path> or <path>:<path>. Can be specified multiple times")
 	cmd.Flags().SetInterspersed(false)
+	requireRuntime(&cmd)
 	return &cmd
 }
@@ -25,20 +25,20 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "instance not found",
-			args: []string{"instance1"},
+			args: []string{"mock-avs-default"},
 			err:  errors.New("instance not found"),
 			daemonMock: func(d *daemonMock.MockDaemon) {
-				d.EXPECT().HasInstance("instance1").Return(false)
+				d.EXPECT().HasInstance("mock-avs-default").Return(false)
 			},
 		},
 		{
 			name: "run plugin error",
-			args: []string{"instance1", "arg1"},
+			args: []string{"mock-avs-default", "arg1"},
 			err:  errors.New("run plugin error"),
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -49,12 +49,12 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "valid arguments",
-			args: []string{"instance1", "arg1"},
+			args: []string{"mock-avs-default", "arg1"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -65,12 +65,12 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "--host flag",
-			args: []string{"--host", "instance1", "arg1"},
+			args: []string{"--host", "mock-avs-default", "arg1"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    true,
 						Binds:          map[string]string{},
@@ -81,12 +81,12 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "--host flag, but as a plugin argument",
-			args: []string{"instance1", "--host", "arg1"},
+			args: []string{"mock-avs-default", "--host", "arg1"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"--host", "arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"--host", "arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -97,12 +97,12 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "plugin without arguments",
-			args: []string{"instance1"},
+			args: []string{"mock-avs-default"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", nil, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", nil, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -117,14 +117,14 @@ func TestPluginCmd(t *testing.T) {
 				name: "--volume flag",
 				args: []string{
 					"--volume", hostDir + ":/container",
-					"-v", "docker-volume:/container/path",
-					"instance1", "arg1",
+					"--volume", "docker-volume:/container/path",
+					"mock-avs-default", "arg1",
 				},
 				err: nil,
 				daemonMock: func(d *daemonMock.MockDaemon) {
 					gomock.InOrder(
-						d.EXPECT().HasInstance("instance1").Return(true),
-						d.EXPECT().RunPlugin("instance1", []string{"arg1"}, daemon.RunPluginOptions{
+						d.EXPECT().HasInstance("mock-avs-default").Return(true),
+						d.EXPECT().RunPlugin("mock-avs-default", []string{"arg1"}, daemon.RunPluginOptions{
 							NoDestroyImage: false,
 							HostNetwork:    false,
 							Binds:          map[string]string{hostDir: "/container"},
@@ -136,12 +136,12 @@ func TestPluginCmd(t *testing.T) {
 		}(t),
 		{
 			name: "--volume flag, but as a plugin argument",
-			args: []string{"instance1", "--volume", "arg1"},
+			args: []string{"mock-avs-default", "--volume", "arg1"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"--volume", "arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"--volume", "arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: false,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -152,12 +152,12 @@ func TestPluginCmd(t *testing.T) {
 		},
 		{
 			name: "--no-rm-image flag",
-			args: []string{"--no-rm-image", "instance1", "arg1"},
+			args: []string{"--no-rm-image", "mock-avs-default", "arg1"},
 			err:  nil,
 			daemonMock: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().HasInstance("instance1").Return(true),
-					d.EXPECT().RunPlugin("instance1", []string{"arg1"}, daemon.RunPluginOptions{
+					d.EXPECT().HasInstance("mock-avs-default").Return(true),
+					d.EXPECT().RunPlugin("mock-avs-default", []string{"arg1"}, daemon.RunPluginOptions{
 						NoDestroyImage: true,
 						HostNetwork:    false,
 						Binds:          map[string]string{},
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/eigenlayer/cli/output"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of the resources in the prune report.
+const (
+	pruneDataDir = "data directory"
+	pruneImage   = "image"
+)
+
+// pruneItem is a row of the prune report.
+type pruneItem struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Name string `json:"name" yaml:"name"`
+}
+
+func PruneCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		dryRun bool
+		yes    bool
+		images bool
+		format = output.FormatTable
+	)
+	cmd := cobra.Command{
+		Use:   "prune",
+		Short: "Remove orphaned data directories and dangling images",
+		Long:  "Removes the data directories without an instance state, left behind by interrupted installs and uninstalls, and prints what was removed. The directories of installed instances are never removed. Use --images to also remove the dangling docker images built by docker compose for the instances, and --dry-run to print what would be removed without removing anything. Removing requires --yes.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRun && !yes {
+				return fmt.Errorf("%w: use --yes to confirm the removal or --dry-run to only list what would be removed", ErrPruneNotConfirmed)
+			}
+			result, err := d.Prune(daemon.PruneOptions{
+				DryRun: dryRun,
+				Images: images,
+			})
+			if err != nil {
+				return err
+			}
+			items := make([]pruneItem, 0, len(result.DataDirs)+len(result.Images))
+			for _, dir := range result.DataDirs {
+				items = append(items, pruneItem{Kind: pruneDataDir, Name: dir})
+			}
+			for _, image := range result.Images {
+				items = append(items, pruneItem{Kind: pruneImage, Name: image})
+			}
+			return output.Print(cmd.OutOrStdout(), format, items, pruneTable)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print what would be removed without removing anything.")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "confirm the removal.")
+	cmd.Flags().BoolVar(&images, "images", false, "also remove the dangling docker images of the instances.")
+	cmd.Flags().VarP(&format, "output", "o", output.FlagUsage)
+	return &cmd
+}
+
+var pruneTable = output.Table[pruneItem]{
+	Headers: []string{"KIND", "NAME"},
+	Row: func(i pruneItem) []string {
+		return []string{i.Kind, i.Name}
+	},
+}
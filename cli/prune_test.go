@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	tc := []struct {
+		name   string
+		args   []string
+		err    error
+		stdOut string
+		mocker func(d *mocks.MockDaemon)
+	}{
+		{
+			name: "not confirmed",
+			err:  ErrPruneNotConfirmed,
+		},
+		{
+			name: "dry run",
+			args: []string{"--dry-run"},
+			stdOut: "KIND              NAME               \n" +
+				"data directory    mock-avs-orphan    \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Prune(daemon.PruneOptions{DryRun: true}).Return(daemon.PruneResult{
+					DataDirs: []string{"mock-avs-orphan"},
+					Images:   []string{},
+				}, nil)
+			},
+		},
+		{
+			name: "data dirs and images",
+			args: []string{"--yes", "--images"},
+			stdOut: "KIND              NAME               \n" +
+				"data directory    mock-avs-orphan    \n" +
+				"image             sha256:1           \n",
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Prune(daemon.PruneOptions{Images: true}).Return(daemon.PruneResult{
+					DataDirs: []string{"mock-avs-orphan"},
+					Images:   []string{"sha256:1"},
+				}, nil)
+			},
+		},
+		{
+			name: "json output",
+			args: []string{"-y", "-o", "json"},
+			stdOut: `[
+  {
+    "kind": "data directory",
+    "name": "mock-avs-orphan"
+  }
+]
+`,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Prune(daemon.PruneOptions{}).Return(daemon.PruneResult{
+					DataDirs: []string{"mock-avs-orphan"},
+				}, nil)
+			},
+		},
+		{
+			name: "prune error",
+			args: []string{"--yes"},
+			err:  assert.AnError,
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Prune(daemon.PruneOptions{}).Return(daemon.PruneResult{}, assert.AnError)
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mocks.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut, stdErr bytes.Buffer
+			pruneCmd := PruneCmd(d)
+			pruneCmd.SetArgs(tt.args)
+			pruneCmd.SetOut(&stdOut)
+			pruneCmd.SetErr(&stdErr)
+			pruneCmd.SilenceUsage = true
+			err := pruneCmd.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
@@ -8,20 +8,32 @@ import (
 func RestoreCmd(d daemon.Daemon) *cobra.Command {
 	var (
 		backupId string
-		run      bool
+		options  daemon.RestoreOptions
 	)
 	cmd := cobra.Command{
 		Use:   "restore [flags] <backup-id>",
 		Short: "Restore an instance from a backup",
+		Long:  "Restore an instance from a backup. By default, the backup is restored over the instance it was created from. Use --as to restore it as a new instance with a different id, to compare it side by side with the original one. Encrypted backups are restored with the key file they were created with, given with --encryption-key-file.",
 		Args:  cobra.ExactArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			backupId = args[0]
+			if options.InstanceId != "" {
+				return validateInstanceIds(options.InstanceId)
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return d.Restore(backupId, run)
+			if err := d.Restore(backupId, options); err != nil {
+				return err
+			}
+			printInfo(cmd, "%s: restored\n", backupId)
+			return nil
 		},
 	}
 
-	cmd.Flags().BoolVarP(&run, "run", "r", false, "Run the instance after restoring it")
+	cmd.Flags().BoolVarP(&options.Run, "run", "r", false, "Run the instance after restoring it")
+	cmd.Flags().StringVar(&options.InstanceId, "as", "", "Restore the backup as a new instance with the given id, with the format <repository-name>-<tag>")
+	cmd.Flags().StringVar(&options.EncryptionKeyFile, "encryption-key-file", "", "Decrypt the backup with the key in this file")
+	requireRuntime(&cmd)
 	return &cmd
 }
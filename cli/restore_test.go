@@ -2,9 +2,11 @@ package cli
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,23 +28,42 @@ func TestRestore(t *testing.T) {
 			args: []string{"backup-id"},
 			err:  assert.AnError,
 			mocker: func(d *mocks.MockDaemon) {
-				d.EXPECT().Restore("backup-id", false).Return(assert.AnError)
+				d.EXPECT().Restore("backup-id", daemon.RestoreOptions{}).Return(assert.AnError)
 			},
 		},
 		{
 			name: "daemon restore success",
 			args: []string{"backup-id"},
 			mocker: func(d *mocks.MockDaemon) {
-				d.EXPECT().Restore("backup-id", false).Return(nil)
+				d.EXPECT().Restore("backup-id", daemon.RestoreOptions{}).Return(nil)
 			},
 		},
 		{
 			name: "restore with run flag",
 			args: []string{"backup-id", "--run"},
 			mocker: func(d *mocks.MockDaemon) {
-				d.EXPECT().Restore("backup-id", true).Return(nil)
+				d.EXPECT().Restore("backup-id", daemon.RestoreOptions{Run: true}).Return(nil)
 			},
 		},
+		{
+			name: "restore as a new instance",
+			args: []string{"backup-id", "--as", "mock-avs-compare"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Restore("backup-id", daemon.RestoreOptions{InstanceId: "mock-avs-compare"}).Return(nil)
+			},
+		},
+		{
+			name: "restore encrypted backup",
+			args: []string{"backup-id", "--encryption-key-file", "/backup.key"},
+			mocker: func(d *mocks.MockDaemon) {
+				d.EXPECT().Restore("backup-id", daemon.RestoreOptions{EncryptionKeyFile: "/backup.key"}).Return(nil)
+			},
+		},
+		{
+			name: "restore as an invalid instance id",
+			args: []string{"backup-id", "--as", "mock_avs"},
+			err:  fmt.Errorf("%w: %w", ErrInvalidArgs, errors.New(`invalid instance ID: "mock_avs" does not have the format <repository-name>-<tag>`)),
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
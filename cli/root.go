@@ -2,16 +2,78 @@ package cli
 
 import (
 	"github.com/NethermindEth/eigenlayer/cli/prompter"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-func RootCmd(d daemon.Daemon, p prompter.Prompter) *cobra.Command {
+func RootCmd(d daemon.Daemon, p prompter.Prompter, logger *log.Logger, m *metrics.Metrics) *cobra.Command {
+	var (
+		configFile, logFormat, logLevel, backupDir, metricsAddr string
+		gitCABundle, registryConfig                             string
+		gitHeaders, registryAuths                               []string
+		pullRetries, verbosity                                  int
+		quiet                                                   bool
+		monitoringPerms                                         daemon.MonitoringPermissions
+		metricsServer                                           *metrics.Server
+	)
 	cmd := cobra.Command{
 		Use:           "eigenlayer",
 		SilenceUsage:  true, // Don't show usage when an error occurs
 		SilenceErrors: true, // Don't show errors when an error occurs. We handle errors ourselves
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, configFilePath(configFile), configFile != ""); err != nil {
+				return err
+			}
+			if err := configureLogger(logger, logFormat, logLevel, quiet, verbosity); err != nil {
+				return err
+			}
+			var err error
+			if metricsServer, err = serveMetrics(m, metricsAddr, logger); err != nil {
+				return err
+			}
+			if err := configurePullRetries(d, pullRetries); err != nil {
+				return err
+			}
+			if err := configureGitOptions(d, gitCABundle, gitHeaders); err != nil {
+				return err
+			}
+			if err := configureRegistryOptions(d, registryConfig, registryAuths); err != nil {
+				return err
+			}
+			if err := configureBackupDir(d, backupDir); err != nil {
+				return err
+			}
+			if err := configureMonitoringPermissions(d, monitoringPerms); err != nil {
+				return err
+			}
+			if requiresRuntime(cmd) {
+				return d.CheckRuntime()
+			}
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return closeMetrics(metricsServer)
+		},
 	}
+	cmd.PersistentFlags().StringVar(&configFile, "config", "", "YAML file with the default values of the flags, like 'log-format: json'. Defaults to $"+configFileEnv+" or to ~/.egn/config.yaml")
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", logFormatText, "log output format. One of: text, json")
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", log.InfoLevel.String(), "log level. One of: trace, debug, info, warn, error, fatal, panic")
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print the errors, suppressing the informational output and the logs below the error level")
+	cmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "increase the log level from --log-level once for each --verbose, like -v for debug logs and -vv for trace logs")
+	cmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	cmd.PersistentFlags().StringVar(&backupDir, "backup-dir", "", "directory where the backups are stored. Defaults to $"+backupDirEnv+" or to the backup directory inside the egn data directory, which is $"+data.DataDirEnv+" or the .eigen directory inside the user data directory, like $XDG_DATA_HOME on Linux")
+	cmd.PersistentFlags().IntVar(&pullRetries, "retries", defaultPullRetries, "number of times the download of a package is retried after a transient network error.")
+	cmd.PersistentFlags().StringVar(&gitCABundle, "git-ca-bundle", "", "PEM file with the certificates of additional certificate authorities trusted when cloning packages over HTTPS, like the one of a TLS-intercepting proxy. Defaults to $"+gitCABundleEnv)
+	cmd.PersistentFlags().StringArrayVar(&gitHeaders, "git-header", nil, "HTTP header in the 'Name: value' form sent when cloning packages, like git's http.extraHeader. Can be repeated")
+	cmd.PersistentFlags().StringVar(&registryConfig, "registry-config", "", "docker config file, like ~/.docker/config.json, with the credentials of the registries the images are pulled from. Defaults to $"+registryConfigEnv)
+	cmd.PersistentFlags().StringArrayVar(&registryAuths, "registry-auth", nil, "credentials of a registry the images are pulled from, in the 'registry=username:password' form, like 'ghcr.io=user:token'. Can be repeated for several registries")
+	cmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "address, like localhost:9101, where the egn metrics are served at /metrics while the command runs, for a Prometheus on the host to scrape. They only count the operations of the command and are not added to the monitoring stack. The metrics are not served if empty")
+	cmd.PersistentFlags().StringVar(&monitoringPerms.DirMode, "monitoring-dir-mode", "", "octal mode, like 0750, of the directories created in the monitoring stack. Defaults to 0755")
+	cmd.PersistentFlags().StringVar(&monitoringPerms.FileMode, "monitoring-file-mode", "", "octal mode, like 0640, of the files created in the monitoring stack. Defaults to 0644")
+	cmd.PersistentFlags().StringVar(&monitoringPerms.Owner, "monitoring-owner", "", "owner, in the uid:gid form like 472:472, of the directories and files created in the monitoring stack, so the monitoring containers running as a non-root user can read them. Defaults to the current user")
 	cmd.AddCommand(
 		// Commenting these now since we are going native installation
 		// InstallCmd(d, p),
@@ -22,13 +84,22 @@ func RootCmd(d daemon.Daemon, p prompter.Prompter) *cobra.Command {
 		// RunCmd(d),
 		// ListCmd(d),
 		// LogsCmd(d),
+		// ExecCmd(d),
 		// InitMonitoringCmd(d),
 		// CleanMonitoringCmd(d),
+		// MonitoringCmd(d),
 		// UpdateCmd(d, p),
 		// LocalUpdateCmd(d, p),
 		// BackupCmd(d),
 		// RestoreCmd(d),
+		// ConfigCmd(d),
+		// LabelCmd(d),
+		// OutdatedCmd(d),
 		OperatorCmd(p),
+		VersionCmd(d),
+		DoctorCmd(d),
+		PruneCmd(d),
+		CompletionCmd(),
 	)
 	cmd.CompletionOptions.DisableDefaultCmd = true
 	return &cmd
@@ -1,26 +1,149 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// defaultWaitTimeout is the start timeout of run --wait when no --timeout is
+// given, so the health check does not wait forever.
+const defaultWaitTimeout = 2 * time.Minute
+
 func RunCmd(d daemon.Daemon) *cobra.Command {
-	var instanceId string
+	var (
+		instanceIds []string
+		wait        bool
+		foreground  bool
+		timeout     time.Duration
+	)
 	cmd := cobra.Command{
-		Use:   "run <instance_id>",
-		Short: "Start an AVS node instance",
-		Long:  "Start an AVS node instance. The instance ID is required as the unique argument. instance_id is required as the unique argument, and it is the combination of the instance repository name and the instance tag computed during the installation, like this: <repository-name>-<tag>.",
-		Args:  cobra.ExactArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
-			instanceId = args[0]
+		Use:   "run <instance_id> [<instance_id>...]",
+		Short: "Start one or more AVS node instances",
+		Long:  "Start one or more AVS node instances. At least one instance ID is required as argument. instance_id is the combination of the instance repository name and the instance tag computed during the installation, like this: <repository-name>-<tag>. When several instances are given they are started concurrently, and a failure in one of them does not stop the others. With --timeout, starting an instance fails if it takes longer than the given duration, like when an image pull is stuck, and the containers started so far are removed. If --wait is set, the command also waits until the instance's API health endpoint reports a healthy node, within the same --timeout, which is 2 minutes by default. With --foreground, the command keeps running once the instances are started, and stops them when it gets a SIGINT or SIGTERM signal, to run the instances as a service, like a systemd unit.",
+		Args:  cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			instanceIds = args
+			if err := validateInstanceIds(instanceIds...); err != nil {
+				return err
+			}
+			if timeout < 0 || (wait && cmd.Flags().Changed("timeout") && timeout == 0) {
+				return errors.New("timeout must be greater than zero")
+			}
+			if wait && timeout == 0 {
+				timeout = defaultWaitTimeout
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := d.InitMonitoring(false, false); err != nil {
+			ctx := cmd.Context()
+			if foreground {
+				// Listen to the signals before starting the instances, so
+				// a signal arriving while starting them cancels the start
+				// and they are stopped
+				var stop context.CancelFunc
+				ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+			}
+			if err := d.InitMonitoring(cmd.Context(), false, false); err != nil {
 				return err
 			}
-			return d.Run(instanceId)
+			options := daemon.RunOptions{
+				Wait:    wait,
+				Timeout: timeout,
+			}
+			if len(instanceIds) == 1 {
+				if err := d.Run(ctx, instanceIds[0], options); err != nil {
+					if foreground {
+						// The instance can be partially started, like when
+						// a signal cancelled the start
+						return errors.Join(err, stopInstances(cmd, d, instanceIds))
+					}
+					return err
+				}
+				warnOutdated(cmd.Context(), d, instanceIds)
+				if foreground {
+					return stopOnSignal(ctx, cmd, d, instanceIds)
+				}
+				return nil
+			}
+
+			errs := make([]error, len(instanceIds))
+			var wg sync.WaitGroup
+			for i, instanceId := range instanceIds {
+				wg.Add(1)
+				go func(i int, instanceId string) {
+					defer wg.Done()
+					errs[i] = d.Run(ctx, instanceId, options)
+				}(i, instanceId)
+			}
+			wg.Wait()
+
+			var (
+				failed  []error
+				started []string
+			)
+			for i, instanceId := range instanceIds {
+				if errs[i] != nil {
+					printInfo(cmd, "%s: failed: %v\n", instanceId, errs[i])
+					failed = append(failed, fmt.Errorf("%s: %w", instanceId, errs[i]))
+				} else {
+					printInfo(cmd, "%s: running\n", instanceId)
+					started = append(started, instanceId)
+				}
+			}
+			warnOutdated(cmd.Context(), d, started)
+			if len(failed) > 0 {
+				err := fmt.Errorf("%w: %d of %d instances failed to start: %w", ErrRunFailed, len(failed), len(instanceIds), errors.Join(failed...))
+				if foreground {
+					// Don't leave the started instances, or the partially
+					// started ones, running without a process in the
+					// foreground to stop them
+					return errors.Join(err, stopInstances(cmd, d, instanceIds))
+				}
+				return err
+			}
+			if foreground {
+				return stopOnSignal(ctx, cmd, d, instanceIds)
+			}
+			return nil
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, true)
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait until the instance's health check passes")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "maximum time to start each instance, including the health check with --wait, where it defaults to 2m. Zero means no limit without --wait.")
+	cmd.Flags().BoolVar(&foreground, "foreground", false, "keep running once the instances are started, and stop them on SIGINT or SIGTERM")
+	requireRuntime(&cmd)
 	return &cmd
 }
+
+// stopOnSignal blocks until ctx is done, which happens when the process gets a
+// SIGINT or SIGTERM signal, and then stops the instances.
+func stopOnSignal(ctx context.Context, cmd *cobra.Command, d daemon.Daemon, instanceIds []string) error {
+	log.Info("Running in the foreground. Send SIGINT or SIGTERM to stop the instances")
+	<-ctx.Done()
+	log.Info("Stopping the instances")
+	return stopInstances(cmd, d, instanceIds)
+}
+
+// stopInstances stops the instances, even if the command context is cancelled,
+// as it is when the process is interrupted.
+func stopInstances(cmd *cobra.Command, d daemon.Daemon, instanceIds []string) error {
+	ctx := context.WithoutCancel(cmd.Context())
+	var errs []error
+	for _, instanceId := range instanceIds {
+		if err := d.Stop(ctx, instanceId); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", instanceId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,12 +1,21 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRun(t *testing.T) {
@@ -14,19 +23,41 @@ func TestRun(t *testing.T) {
 		name   string
 		args   []string
 		err    error
+		output []string
 		mocker func(d *daemonMock.MockDaemon)
 	}{
 		{
 			name:   "no arguments",
 			args:   []string{},
-			err:    errors.New("accepts 1 arg(s), received 0"),
+			err:    errors.New("requires at least 1 arg(s), only received 0"),
 			mocker: nil,
 		},
 		{
-			name:   "more than one argument",
-			args:   []string{"arg1", "arg2"},
-			err:    errors.New("accepts 1 arg(s), received 2"),
-			mocker: nil,
+			name: "multiple instances",
+			args: []string{"mock-avs-1", "mock-avs-2", "mock-avs-3"},
+			err:  nil,
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-1", daemon.RunOptions{}).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-2", daemon.RunOptions{}).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-3", daemon.RunOptions{}).Return(nil)
+			},
+		},
+		{
+			name: "multiple instances, one fails and the others are still started",
+			args: []string{"mock-avs-1", "mock-avs-2", "mock-avs-3"},
+			err:  errors.New("run failed: 1 of 3 instances failed to start: mock-avs-2: " + assert.AnError.Error()),
+			output: []string{
+				"mock-avs-1: running",
+				"mock-avs-2: failed: " + assert.AnError.Error(),
+				"mock-avs-3: running",
+			},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-1", daemon.RunOptions{}).Return(nil)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-2", daemon.RunOptions{}).Return(assert.AnError)
+				d.EXPECT().Run(gomock.Any(), "mock-avs-3", daemon.RunOptions{}).Return(nil)
+			},
 		},
 		{
 			name: "valid arguments, and run success",
@@ -34,8 +65,8 @@ func TestRun(t *testing.T) {
 			err:  nil,
 			mocker: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
-					d.EXPECT().Run("mock-avs-default").Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{}).Return(nil),
 				)
 			},
 		},
@@ -45,11 +76,56 @@ func TestRun(t *testing.T) {
 			err:  assert.AnError,
 			mocker: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
-					d.EXPECT().Run("mock-avs-default").Return(assert.AnError),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{}).Return(assert.AnError),
+				)
+			},
+		},
+		{
+			name: "wait for health check",
+			args: []string{"mock-avs-default", "--wait", "--timeout", "30s"},
+			err:  nil,
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{Wait: true, Timeout: 30 * time.Second}).Return(nil),
+				)
+			},
+		},
+		{
+			name: "wait for health check, timeout",
+			args: []string{"mock-avs-default", "--wait"},
+			err:  daemon.ErrHealthCheckTimeout,
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{Wait: true, Timeout: 2 * time.Minute}).Return(daemon.ErrHealthCheckTimeout),
+				)
+			},
+		},
+		{
+			name: "start timeout",
+			args: []string{"mock-avs-default", "--timeout", "5m"},
+			err:  daemon.ErrRunTimeout,
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{Timeout: 5 * time.Minute}).Return(daemon.ErrRunTimeout),
 				)
 			},
 		},
+		{
+			name:   "negative timeout",
+			args:   []string{"mock-avs-default", "--timeout", "-1s"},
+			err:    errors.New("timeout must be greater than zero"),
+			mocker: nil,
+		},
+		{
+			name:   "wait with invalid timeout",
+			args:   []string{"mock-avs-default", "--wait", "--timeout", "0s"},
+			err:    errors.New("timeout must be greater than zero"),
+			mocker: nil,
+		},
 	}
 	for _, tt := range ts {
 		t.Run(tt.name, func(t *testing.T) {
@@ -58,14 +134,188 @@ func TestRun(t *testing.T) {
 			if tt.mocker != nil {
 				tt.mocker(d)
 			}
+			d.EXPECT().CheckUpdates(gomock.Any(), gomock.Any()).Return(&daemon.UpdateInfo{}, nil).AnyTimes()
 
+			var out bytes.Buffer
 			runCmd := RunCmd(d)
 			runCmd.SetArgs(tt.args)
+			runCmd.SetOut(&out)
 			err := runCmd.Execute()
 
 			if tt.err != nil {
 				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, line := range tt.output {
+				assert.Contains(t, out.String(), line)
 			}
 		})
 	}
 }
+
+func TestRunForeground(t *testing.T) {
+	ts := []struct {
+		name        string
+		args        []string
+		instanceIds []string
+		stopErr     error
+		err         error
+	}{
+		{
+			name:        "one instance",
+			args:        []string{"mock-avs-default", "--foreground"},
+			instanceIds: []string{"mock-avs-default"},
+		},
+		{
+			name:        "multiple instances",
+			args:        []string{"mock-avs-1", "mock-avs-2", "--foreground"},
+			instanceIds: []string{"mock-avs-1", "mock-avs-2"},
+		},
+		{
+			name:        "stop error",
+			args:        []string{"mock-avs-default", "--foreground"},
+			instanceIds: []string{"mock-avs-default"},
+			stopErr:     assert.AnError,
+			err:         assert.AnError,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			started := make(chan string, len(tt.instanceIds))
+			stopped := make(chan string, len(tt.instanceIds))
+			d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+			d.EXPECT().CheckUpdates(gomock.Any(), gomock.Any()).Return(&daemon.UpdateInfo{}, nil).AnyTimes()
+			for _, instanceId := range tt.instanceIds {
+				d.EXPECT().Run(gomock.Any(), instanceId, daemon.RunOptions{}).DoAndReturn(func(_ context.Context, instanceId string, _ daemon.RunOptions) error {
+					started <- instanceId
+					return nil
+				})
+				d.EXPECT().Stop(gomock.Any(), instanceId).DoAndReturn(func(ctx context.Context, instanceId string) error {
+					// The instances are stopped with a live context
+					assert.NoError(t, ctx.Err())
+					stopped <- instanceId
+					return tt.stopErr
+				})
+			}
+
+			runCmd := RunCmd(d)
+			runCmd.SetArgs(tt.args)
+			runCmd.SetOut(io.Discard)
+			done := make(chan error, 1)
+			go func() {
+				done <- runCmd.Execute()
+			}()
+
+			for range tt.instanceIds {
+				<-started
+			}
+			// The command keeps running the instances until it gets a signal
+			select {
+			case err := <-done:
+				t.Fatalf("command returned before the signal: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+			assert.Empty(t, stopped)
+
+			process, err := os.FindProcess(os.Getpid())
+			require.NoError(t, err)
+			require.NoError(t, process.Signal(syscall.SIGTERM))
+			select {
+			case err := <-done:
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+				} else {
+					assert.NoError(t, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("command did not return after the signal")
+			}
+			assert.Len(t, stopped, len(tt.instanceIds))
+		})
+	}
+}
+
+func TestRunForegroundStartFailure(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+	d.EXPECT().CheckUpdates(gomock.Any(), gomock.Any()).Return(&daemon.UpdateInfo{}, nil).AnyTimes()
+	d.EXPECT().Run(gomock.Any(), "mock-avs-1", daemon.RunOptions{}).Return(nil)
+	d.EXPECT().Run(gomock.Any(), "mock-avs-2", daemon.RunOptions{}).Return(assert.AnError)
+	// The instances are stopped right away, as the failed one can be
+	// partially started
+	d.EXPECT().Stop(gomock.Any(), "mock-avs-1").Return(nil)
+	d.EXPECT().Stop(gomock.Any(), "mock-avs-2").Return(nil)
+
+	runCmd := RunCmd(d)
+	runCmd.SetArgs([]string{"mock-avs-1", "mock-avs-2", "--foreground"})
+	runCmd.SetOut(io.Discard)
+	err := runCmd.Execute()
+	assert.ErrorIs(t, err, ErrRunFailed)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestRunForegroundSignalWhileStarting(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+	// The signal cancels the start of the instance
+	d.EXPECT().Run(gomock.Any(), "mock-avs-default", daemon.RunOptions{}).DoAndReturn(func(ctx context.Context, _ string, _ daemon.RunOptions) error {
+		process, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, process.Signal(syscall.SIGTERM))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			t.Error("the start was not cancelled by the signal")
+			return nil
+		}
+	})
+	d.EXPECT().Stop(gomock.Any(), "mock-avs-default").DoAndReturn(func(ctx context.Context, _ string) error {
+		// The instance is stopped with a live context
+		assert.NoError(t, ctx.Err())
+		return nil
+	})
+
+	runCmd := RunCmd(d)
+	runCmd.SetArgs([]string{"mock-avs-default", "--foreground"})
+	runCmd.SetOut(io.Discard)
+	err := runCmd.Execute()
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunWarnsOutdated(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+	d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil)
+	d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-1").DoAndReturn(func(ctx context.Context, instanceId string) (*daemon.UpdateInfo, error) {
+		// The check does not hold the command for long
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		return &daemon.UpdateInfo{
+			InstanceId:      "mock-avs-1",
+			CurrentVersion:  "v3.0.3",
+			LatestVersion:   "v3.1.0",
+			UpdateAvailable: true,
+		}, nil
+	})
+	d.EXPECT().CheckUpdates(gomock.Any(), "mock-avs-2").Return(nil, daemon.ErrCheckingUpdates)
+	d.EXPECT().Run(gomock.Any(), "mock-avs-1", daemon.RunOptions{}).Return(nil)
+	d.EXPECT().Run(gomock.Any(), "mock-avs-2", daemon.RunOptions{}).Return(nil)
+
+	runCmd := RunCmd(d)
+	runCmd.SetArgs([]string{"mock-avs-1", "mock-avs-2"})
+	runCmd.SetOut(io.Discard)
+	// A failed update check does not stop the run
+	require.NoError(t, runCmd.Execute())
+	assert.Contains(t, logs.String(), "A newer version of mock-avs-1 is available: v3.1.0 (installed v3.0.3)")
+	assert.NotContains(t, logs.String(), "mock-avs-2 is available")
+}
@@ -0,0 +1,22 @@
+package cli
+
+import "github.com/spf13/cobra"
+
+// runtimeAnnotation is the annotation of the commands that need the docker
+// daemon and the docker compose plugin to run.
+const runtimeAnnotation = "egn-requires-runtime"
+
+// requireRuntime marks the command as needing the docker daemon and the docker
+// compose plugin, so the root command checks they are available before
+// running it and fails early with a clear error otherwise.
+func requireRuntime(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[runtimeAnnotation] = "true"
+}
+
+// requiresRuntime returns true if the command was marked with requireRuntime.
+func requiresRuntime(cmd *cobra.Command) bool {
+	return cmd.Annotations[runtimeAnnotation] == "true"
+}
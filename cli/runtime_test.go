@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRuntime(t *testing.T) {
+	errNoCompose := fmt.Errorf("%w: docker compose is not available", daemon.ErrRuntimeUnavailable)
+	tests := []struct {
+		name       string
+		requires   bool
+		runtimeErr error
+		ran        bool
+		err        error
+	}{
+		{
+			name: "command without runtime",
+			ran:  true,
+		},
+		{
+			name:     "runtime available",
+			requires: true,
+			ran:      true,
+		},
+		{
+			name:       "runtime unavailable",
+			requires:   true,
+			runtimeErr: errNoCompose,
+			err:        daemon.ErrRuntimeUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(backupDirEnv, "")
+			t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "config.yaml"))
+			ctrl := gomock.NewController(t)
+			d := mocks.NewMockDaemon(ctrl)
+			d.EXPECT().SetPullRetries(defaultPullRetries)
+			if tt.requires {
+				d.EXPECT().CheckRuntime().Return(tt.runtimeErr)
+			}
+
+			logger := log.New()
+			logger.SetOutput(io.Discard)
+			root := RootCmd(d, nil, logger, metrics.New())
+			ran := false
+			sub := cobra.Command{
+				Use: "sub",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					ran = true
+					return nil
+				},
+			}
+			if tt.requires {
+				requireRuntime(&sub)
+			}
+			root.AddCommand(&sub)
+			root.SetArgs([]string{"sub"})
+			err := root.Execute()
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.ran, ran)
+		})
+	}
+}
@@ -1,23 +1,60 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/spf13/cobra"
 )
 
 func StopCmd(d daemon.Daemon) *cobra.Command {
-	var instanceId string
+	var (
+		instanceId string
+		all        bool
+	)
 	cmd := cobra.Command{
 		Use:   "stop <instance_id>",
 		Short: "Stop an AVS node instance",
-		Long:  "Stops an AVS node instance. The instance ID is required as the unique argument. instance_id is required as the unique argument, and it is the combination of the instance repository name and the instance tag computed during the installation, like this: <repository-name>-<tag>.",
-		Args:  cobra.ExactArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
+		Long:  "Stops an AVS node instance. The instance ID is required as the unique argument. instance_id is required as the unique argument, and it is the combination of the instance repository name and the instance tag computed during the installation, like this: <repository-name>-<tag>. With --all, every installed instance is stopped instead, and a failure in one of them does not stop the others.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return nil
+			}
 			instanceId = args[0]
+			return validateInstanceIds(instanceId)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return d.Stop(instanceId)
+			if !all {
+				return d.Stop(cmd.Context(), instanceId)
+			}
+			instances, err := d.ListInstances()
+			if err != nil {
+				return err
+			}
+			var failed []error
+			for _, instance := range instances {
+				if err := d.Stop(cmd.Context(), instance.ID); err != nil {
+					printInfo(cmd, "%s: failed: %v\n", instance.ID, err)
+					failed = append(failed, fmt.Errorf("%s: %w", instance.ID, err))
+				} else {
+					printInfo(cmd, "%s: stopped\n", instance.ID)
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%w: %d of %d instances failed to stop: %w", ErrStopFailed, len(failed), len(instances), errors.Join(failed...))
+			}
+			return nil
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	cmd.Flags().BoolVar(&all, "all", false, "stop all the installed instances")
+	requireRuntime(&cmd)
 	return &cmd
 }
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"testing"
 
 	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,12 +31,18 @@ func TestStop(t *testing.T) {
 			err:    errors.New("accepts 1 arg(s), received 2"),
 			mocker: nil,
 		},
+		{
+			name:   "invalid instance ID",
+			args:   []string{"mock_avs"},
+			err:    errors.New(`invalid arguments: invalid instance ID: "mock_avs" does not have the format <repository-name>-<tag>`),
+			mocker: nil,
+		},
 		{
 			name: "valid arguments, and stop success",
 			args: []string{"mock-avs-default"},
 			err:  nil,
 			mocker: func(d *daemonMock.MockDaemon) {
-				d.EXPECT().Stop("mock-avs-default").Return(nil)
+				d.EXPECT().Stop(gomock.Any(), "mock-avs-default").Return(nil)
 			},
 		},
 		{
@@ -41,7 +50,7 @@ func TestStop(t *testing.T) {
 			args: []string{"mock-avs-default"},
 			err:  errors.New("stop error"),
 			mocker: func(d *daemonMock.MockDaemon) {
-				d.EXPECT().Stop("mock-avs-default").Return(errors.New("stop error"))
+				d.EXPECT().Stop(gomock.Any(), "mock-avs-default").Return(errors.New("stop error"))
 			},
 		},
 	}
@@ -63,3 +72,112 @@ func TestStop(t *testing.T) {
 		})
 	}
 }
+
+func TestStopCanceled(t *testing.T) {
+	controller := gomock.NewController(t)
+	d := daemonMock.NewMockDaemon(controller)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.EXPECT().Stop(gomock.Any(), "mock-avs-default").DoAndReturn(func(ctx context.Context, _ string) error {
+		return ctx.Err()
+	})
+
+	stopCmd := StopCmd(d)
+	stopCmd.SetArgs([]string{"mock-avs-default"})
+	err := stopCmd.ExecuteContext(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStopAll(t *testing.T) {
+	instances := []daemon.ListInstanceItem{
+		{ID: "mock-avs-default", Running: true},
+		{ID: "mock-avs-second", Running: true},
+		{ID: "mock-avs-third", Running: false},
+	}
+	ts := []struct {
+		name   string
+		args   []string
+		out    string
+		err    error
+		errMsg string
+		mocker func(d *daemonMock.MockDaemon)
+	}{
+		{
+			name: "all instances stopped",
+			args: []string{"--all"},
+			out:  "mock-avs-default: stopped\nmock-avs-second: stopped\nmock-avs-third: stopped\n",
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().ListInstances().Return(instances, nil),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-default").Return(nil),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-second").Return(nil),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-third").Return(nil),
+				)
+			},
+		},
+		{
+			name:   "a failure does not stop the others",
+			args:   []string{"--all"},
+			out:    "mock-avs-default: stopped\nmock-avs-second: failed: stop error\nmock-avs-third: stopped\n",
+			err:    ErrStopFailed,
+			errMsg: "stop failed: 1 of 3 instances failed to stop: mock-avs-second: stop error",
+			mocker: func(d *daemonMock.MockDaemon) {
+				gomock.InOrder(
+					d.EXPECT().ListInstances().Return(instances, nil),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-default").Return(nil),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-second").Return(errors.New("stop error")),
+					d.EXPECT().Stop(gomock.Any(), "mock-avs-third").Return(nil),
+				)
+			},
+		},
+		{
+			name: "no instances",
+			args: []string{"--all"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(nil, nil)
+			},
+		},
+		{
+			name:   "list error",
+			args:   []string{"--all"},
+			err:    assert.AnError,
+			errMsg: assert.AnError.Error(),
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().ListInstances().Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:   "instance ID with --all",
+			args:   []string{"--all", "mock-avs-default"},
+			errMsg: `unknown command "mock-avs-default" for "stop"`,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(controller)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var out bytes.Buffer
+			stopCmd := StopCmd(d)
+			stopCmd.SetArgs(tt.args)
+			stopCmd.SetOut(&out)
+			stopCmd.SilenceUsage = true
+			err := stopCmd.Execute()
+
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.out, out.String())
+		})
+	}
+}
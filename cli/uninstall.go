@@ -12,16 +12,19 @@ func UninstallCmd(d daemon.Daemon) *cobra.Command {
 		Short: "Uninstall an instance",
 		Long:  "Uninstall an instance. This will stop the instance and remove all its data. instance_id is required as the unique argument, and it is the combination of the instance repository name and the instance tag computed during the installation, like this: <repository-name>-<tag>.",
 		Args:  cobra.ExactArgs(1),
-		PreRun: func(cmd *cobra.Command, args []string) {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			instanceId = args[0]
+			return validateInstanceIds(instanceId)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Init monitoring stack. If won't do anything if it is not installed or running
-			if err := d.InitMonitoring(false, false); err != nil {
+			if err := d.InitMonitoring(cmd.Context(), false, false); err != nil {
 				return err
 			}
 			return d.Uninstall(instanceId)
 		},
 	}
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	requireRuntime(&cmd)
 	return &cmd
 }
@@ -28,31 +28,31 @@ func TestUninstall(t *testing.T) {
 		},
 		{
 			name: "success",
-			args: []string{"instance1"},
+			args: []string{"mock-avs-default"},
 			err:  nil,
 			mocker: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
-					d.EXPECT().Uninstall("instance1").Return(nil),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Uninstall("mock-avs-default").Return(nil),
 				)
 			},
 		},
 		{
 			name: "init monitoring error",
-			args: []string{"instance1"},
+			args: []string{"mock-avs-default"},
 			err:  assert.AnError,
 			mocker: func(d *daemonMock.MockDaemon) {
-				d.EXPECT().InitMonitoring(false, false).Return(assert.AnError)
+				d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(assert.AnError)
 			},
 		},
 		{
 			name: "uninstall error",
-			args: []string{"instance1"},
+			args: []string{"mock-avs-default"},
 			err:  errors.New("uninstall error"),
 			mocker: func(d *daemonMock.MockDaemon) {
 				gomock.InOrder(
-					d.EXPECT().InitMonitoring(false, false).Return(nil),
-					d.EXPECT().Uninstall("instance1").Return(errors.New("uninstall error")),
+					d.EXPECT().InitMonitoring(gomock.Any(), false, false).Return(nil),
+					d.EXPECT().Uninstall("mock-avs-default").Return(errors.New("uninstall error")),
 				)
 			},
 		},
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -19,6 +20,7 @@ var hashRegex = regexp.MustCompile("^[0-9a-f]{40}$")
 func UpdateCmd(d daemon.Daemon, p prompter.Prompter) *cobra.Command {
 	var (
 		instanceId string
+		ref        string
 		version    string
 		commit     string
 		noPrompt   bool
@@ -30,8 +32,8 @@ func UpdateCmd(d daemon.Daemon, p prompter.Prompter) *cobra.Command {
 		Use:   "update [flags] <instance_id> <version>",
 		Short: "Update an instance to a new version.",
 		Long: `Updates instance <instance_id> to a new version using the specified
-version or commit hash in the <version> argument. If no version is specified, the
-latest version will be used. If the new version is lower or equal to the current
+version or commit hash in the <version> argument or the --version flag. If no
+version is specified, the latest version will be used. If the new version is lower or equal to the current
 version, the update will fail. Also, if the new commit passed as argument or the
 commit of the new version is not a descendant of the current commit, the update
 will fail.
@@ -43,10 +45,10 @@ using the install command and then uninstall the current version.
 
 Options of the new version can be specified using the --option.<option-name> flag.
 
-To avoid any data loss during the update process, the user can specify the --backup
-flag. In this case, the current instance will be backed up before uninstalling it,
-and if the update process fails, the instance will be restored. Also, the backup
-could be restored manually using the 'eigenlayer restore' command.`,
+To avoid any data loss during the update process, the current instance is backed
+up before uninstalling it, and if the update process fails, the instance will be
+restored. The backup could also be restored manually using the 'eigenlayer restore'
+command. Use --backup=false to skip the backup.`,
 		Example: `
 - Updating to the latest version:
 	
@@ -60,7 +62,9 @@ could be restored manually using the 'eigenlayer restore' command.`,
 	$ eigenlayer update mock-avs-default v5.5.0
 
   In this case the version v5.5.0 of the package will be pulled and tried to be
-  installed.
+  installed. The same can be done with the --version flag:
+
+	$ eigenlayer update mock-avs-default --version v5.5.0
 
 - Updating to a specific commit:
 
@@ -100,10 +104,16 @@ could be restored manually using the 'eigenlayer restore' command.`,
 				instanceId = args[0]
 			}
 			if len(args) == 2 {
-				if semver.IsValid(args[1]) {
-					version = args[1]
-				} else if hashRegex.MatchString(args[1]) {
-					commit = args[1]
+				if ref != "" {
+					return fmt.Errorf("%w: --version and the <version> argument can't be used together", ErrInvalidArgs)
+				}
+				ref = args[1]
+			}
+			if ref != "" {
+				if semver.IsValid(ref) {
+					version = ref
+				} else if hashRegex.MatchString(ref) {
+					commit = ref
 				} else {
 					return fmt.Errorf("%w: invalid version or commit", ErrInvalidArgs)
 				}
@@ -115,7 +125,7 @@ could be restored manually using the 'eigenlayer restore' command.`,
 				return cmd.Help()
 			}
 			// Pull update
-			pullResult, err := pullUpdate(d, instanceId, version, commit)
+			pullResult, err := pullUpdate(cmd.Context(), d, instanceId, version, commit)
 			if err != nil {
 				if errors.Is(err, daemon.ErrVersionAlreadyInstalled) {
 					log.Info(err.Error())
@@ -172,7 +182,7 @@ could be restored manually using the 'eigenlayer restore' command.`,
 			// Backup instance
 			var backupId string
 			if backup {
-				backupId, err = d.Backup(instanceId)
+				backupId, err = d.Backup(instanceId, daemon.BackupOptions{})
 				if err != nil {
 					return err
 				}
@@ -211,30 +221,33 @@ could be restored manually using the 'eigenlayer restore' command.`,
 				// just log it for now.
 				log.Infof("Instance ID changed: %s -> %s", instanceId, newInstanceId)
 			}
+			log.Infof("Instance %s updated: %s -> %s", newInstanceId, versionOrCommit(pullResult.OldVersion, pullResult.OldCommit), versionOrCommit(pullResult.NewVersion, pullResult.NewCommit))
 
 			if pullResult.HasPlugin {
 				log.Info("The installed node software has a plugin.")
 			}
 
-			return runInstance(d, newInstanceId, p, yes, noPrompt)
+			return runInstance(cmd.Context(), d, newInstanceId, p, yes, noPrompt)
 		},
 	}
 
 	cmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "disable command prompts, and all options should be passed using command flags.")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompts.")
-	cmd.Flags().BoolVar(&backup, "backup", false, "backup current instance before updating.")
+	cmd.Flags().BoolVar(&backup, "backup", true, "backup current instance before updating, and restore it if the update fails.")
+	cmd.Flags().StringVar(&ref, "version", "", "version or commit hash to update to, like the <version> argument. If not specified the latest version will be used.")
+	requireRuntime(&cmd)
 	return &cmd
 }
 
 func abortWithRestore(d daemon.Daemon, backupId string, updateErr error) error {
 	log.Errorf("Update process failed with error: %s", updateErr.Error())
 	log.Infof("Restoring instance from backup %s...", backupId)
-	return d.Restore(backupId, false)
+	return d.Restore(backupId, daemon.RestoreOptions{})
 }
 
-func pullUpdate(d daemon.Daemon, instanceID, version, commit string) (daemon.PullUpdateResult, error) {
+func pullUpdate(ctx context.Context, d daemon.Daemon, instanceID, version, commit string) (daemon.PullUpdateResult, error) {
 	log.Info("Pulling package...")
-	pullResult, err := d.PullUpdate(instanceID, daemon.PullTarget{Version: version, Commit: commit})
+	pullResult, err := d.PullUpdate(ctx, instanceID, daemon.PullTarget{Version: version, Commit: commit})
 	if err == nil {
 		log.Info("Package pulled successfully")
 	}
@@ -252,14 +265,14 @@ func uninstallPackage(d daemon.Daemon, instanceID string) error {
 
 func install(d daemon.Daemon, options daemon.InstallOptions) (string, error) {
 	log.Info("Installing new package...")
-	newInstanceId, err := d.Install(options)
+	result, err := d.Install(options)
 	if err == nil {
-		log.Infof("Package installed successfully with instance ID: %s", newInstanceId)
+		log.Infof("Package installed successfully with instance ID: %s", result.InstanceID)
 	}
-	return newInstanceId, err
+	return result.InstanceID, err
 }
 
-func runInstance(d daemon.Daemon, instanceID string, p prompter.Prompter, yes, noPrompt bool) error {
+func runInstance(ctx context.Context, d daemon.Daemon, instanceID string, p prompter.Prompter, yes, noPrompt bool) error {
 	var err error
 	if !yes && !noPrompt {
 		yes, err = p.Confirm("Run the new instance now?")
@@ -269,7 +282,7 @@ func runInstance(d daemon.Daemon, instanceID string, p prompter.Prompter, yes, n
 	}
 	if yes {
 		log.Infof("Running instance %s ...", instanceID)
-		err = d.Run(instanceID)
+		err = d.Run(ctx, instanceID, daemon.RunOptions{})
 		if err == nil {
 			log.Infof("Instance %s running successfully", instanceID)
 		}
@@ -283,6 +296,14 @@ func logVersionChange(oldVersion, newVersion string) {
 	}
 }
 
+// versionOrCommit returns the version if it is set, otherwise the commit.
+func versionOrCommit(version, commit string) string {
+	if version != "" {
+		return version
+	}
+	return commit
+}
+
 func logCommitChange(oldCommit, newCommit string) {
 	if newCommit != "" {
 		log.Infof("Package commit changed from %s -> %s", oldCommit, newCommit)
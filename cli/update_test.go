@@ -23,7 +23,7 @@ func TestUpdate(t *testing.T) {
 	}{
 		{
 			name: "update to latest version",
-			args: []string{instanceId},
+			args: []string{instanceId, "--backup=false"},
 			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				oldOption := daemonMock.NewMockOption(ctrl)
 				newOption := daemonMock.NewMockOption(ctrl)
@@ -38,7 +38,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -67,15 +67,15 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return(instanceId, nil),
+					}).Return(daemon.InstallResult{InstanceID: instanceId}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run(instanceId),
+					d.EXPECT().Run(gomock.Any(), instanceId, daemon.RunOptions{}),
 				)
 			},
 		},
 		{
 			name: "update to fixed version",
-			args: []string{instanceId, common.MockAvsPkg.Version()},
+			args: []string{instanceId, common.MockAvsPkg.Version(), "--backup=false"},
 			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				oldOption := daemonMock.NewMockOption(ctrl)
 				newOption := daemonMock.NewMockOption(ctrl)
@@ -90,7 +90,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{
 						Version: common.MockAvsPkg.Version(),
 					}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
@@ -121,15 +121,15 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return(instanceId, nil),
+					}).Return(daemon.InstallResult{InstanceID: instanceId}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run(instanceId),
+					d.EXPECT().Run(gomock.Any(), instanceId, daemon.RunOptions{}),
 				)
 			},
 		},
 		{
 			name: "update to fixed commit",
-			args: []string{instanceId, common.MockAvsPkg.CommitHash()},
+			args: []string{instanceId, common.MockAvsPkg.CommitHash(), "--backup=false"},
 			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				oldOption := daemonMock.NewMockOption(ctrl)
 				newOption := daemonMock.NewMockOption(ctrl)
@@ -144,7 +144,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{
 						Commit: common.MockAvsPkg.CommitHash(),
 					}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
@@ -175,15 +175,15 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return(instanceId, nil),
+					}).Return(daemon.InstallResult{InstanceID: instanceId}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run(instanceId),
+					d.EXPECT().Run(gomock.Any(), instanceId, daemon.RunOptions{}),
 				)
 			},
 		},
 		{
-			name: "update with backup",
-			args: []string{instanceId, "--backup"},
+			name: "update with backup by default",
+			args: []string{instanceId},
 			err:  nil,
 			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
 				oldOption := daemonMock.NewMockOption(ctrl)
@@ -199,7 +199,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -219,7 +219,7 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
 					d.EXPECT().Uninstall(instanceId).Return(nil),
 					d.EXPECT().Install(daemon.InstallOptions{
 						Name:    "mock-avs",
@@ -229,9 +229,9 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return(instanceId, nil),
+					}).Return(daemon.InstallResult{InstanceID: instanceId}, nil),
 					p.EXPECT().Confirm("Run the new instance now?").Return(true, nil),
-					d.EXPECT().Run(instanceId),
+					d.EXPECT().Run(gomock.Any(), instanceId, daemon.RunOptions{}),
 				)
 			},
 		},
@@ -253,7 +253,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -273,7 +273,7 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return("", assert.AnError),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return("", assert.AnError),
 				)
 			},
 		},
@@ -295,7 +295,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -315,9 +315,9 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
 					d.EXPECT().Uninstall(instanceId).Return(assert.AnError),
-					d.EXPECT().Restore(gomock.Any(), false).Return(nil),
+					d.EXPECT().Restore(gomock.Any(), daemon.RestoreOptions{}).Return(nil),
 				)
 			},
 		},
@@ -339,7 +339,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -359,9 +359,9 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
 					d.EXPECT().Uninstall(instanceId).Return(assert.AnError),
-					d.EXPECT().Restore(gomock.Any(), false).Return(assert.AnError),
+					d.EXPECT().Restore(gomock.Any(), daemon.RestoreOptions{}).Return(assert.AnError),
 				)
 			},
 		},
@@ -383,7 +383,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -403,7 +403,7 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
 					d.EXPECT().Uninstall(instanceId).Return(nil),
 					d.EXPECT().Install(daemon.InstallOptions{
 						Name:    "mock-avs",
@@ -413,8 +413,8 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return("", assert.AnError),
-					d.EXPECT().Restore(gomock.Any(), false).Return(nil),
+					}).Return(daemon.InstallResult{}, assert.AnError),
+					d.EXPECT().Restore(gomock.Any(), daemon.RestoreOptions{}).Return(nil),
 				)
 			},
 		},
@@ -436,7 +436,7 @@ func TestUpdate(t *testing.T) {
 				mergedOption.EXPECT().Help().Return("option help")
 
 				gomock.InOrder(
-					d.EXPECT().PullUpdate(instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
+					d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{}).Return(daemon.PullUpdateResult{
 						Name:          "mock-avs",
 						Tag:           "default",
 						Url:           common.MockAvsPkg.Repo(),
@@ -456,7 +456,7 @@ func TestUpdate(t *testing.T) {
 							StopIfRequirementsAreNotMet: true,
 						},
 					}, nil),
-					d.EXPECT().Backup(instanceId).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
+					d.EXPECT().Backup(instanceId, daemon.BackupOptions{}).Return(fmt.Sprintf("%s-%d", instanceId, time.Now().Unix()), nil),
 					d.EXPECT().Uninstall(instanceId).Return(nil),
 					d.EXPECT().Install(daemon.InstallOptions{
 						Name:    "mock-avs",
@@ -466,8 +466,8 @@ func TestUpdate(t *testing.T) {
 						Version: common.MockAvsPkg.Version(),
 						Commit:  common.MockAvsPkg.CommitHash(),
 						Options: []daemon.Option{mergedOption},
-					}).Return("", assert.AnError),
-					d.EXPECT().Restore(gomock.Any(), false).Return(assert.AnError),
+					}).Return(daemon.InstallResult{}, assert.AnError),
+					d.EXPECT().Restore(gomock.Any(), daemon.RestoreOptions{}).Return(assert.AnError),
 				)
 			},
 		},
@@ -486,6 +486,34 @@ func TestUpdate(t *testing.T) {
 			args: []string{"instance-id", "invalid-version"},
 			err:  fmt.Errorf("%w: invalid version or commit", ErrInvalidArgs),
 		},
+		{
+			name: "version flag",
+			args: []string{instanceId, "--version", common.MockAvsPkg.Version()},
+			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
+				d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{
+					Version: common.MockAvsPkg.Version(),
+				}).Return(daemon.PullUpdateResult{}, daemon.ErrVersionAlreadyInstalled)
+			},
+		},
+		{
+			name: "version flag with a commit",
+			args: []string{instanceId, "--version", common.MockAvsPkg.CommitHash()},
+			mocker: func(ctrl *gomock.Controller, d *daemonMock.MockDaemon, p *prompterMock.MockPrompter) {
+				d.EXPECT().PullUpdate(gomock.Any(), instanceId, daemon.PullTarget{
+					Commit: common.MockAvsPkg.CommitHash(),
+				}).Return(daemon.PullUpdateResult{}, daemon.ErrVersionAlreadyInstalled)
+			},
+		},
+		{
+			name: "invalid arguments, version flag and argument",
+			args: []string{instanceId, common.MockAvsPkg.Version(), "--version", common.MockAvsPkg.Version()},
+			err:  fmt.Errorf("%w: --version and the <version> argument can't be used together", ErrInvalidArgs),
+		},
+		{
+			name: "invalid arguments, invalid version flag",
+			args: []string{instanceId, "--version", "invalid-version"},
+			err:  fmt.Errorf("%w: invalid version or commit", ErrInvalidArgs),
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
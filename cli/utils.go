@@ -3,6 +3,8 @@ package cli
 import (
 	"fmt"
 	"net/url"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
 )
 
 func validatePkgURL(urlStr string) error {
@@ -15,3 +17,14 @@ func validatePkgURL(urlStr string) error {
 	}
 	return nil
 }
+
+// validateInstanceIds checks that the given instance IDs have the
+// <repository-name>-<tag> format, failing fast before calling the daemon.
+func validateInstanceIds(instanceIds ...string) error {
+	for _, instanceId := range instanceIds {
+		if _, _, err := data.ParseInstanceId(instanceId); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+		}
+	}
+	return nil
+}
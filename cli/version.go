@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/spf13/cobra"
+)
+
+// readBuildInfo returns the build information embedded in the binary.
+var readBuildInfo = debug.ReadBuildInfo
+
+const unknownVersion = "unknown"
+
+// BinaryVersion is the version of the egn binary.
+type BinaryVersion struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// versionOutput is the output of the version command.
+type versionOutput struct {
+	Egn      BinaryVersion               `json:"egn"`
+	Instance *daemon.InstanceVersionInfo `json:"instance,omitempty"`
+}
+
+// binaryVersion returns the version and commit of the egn binary from its
+// build information. Values that are not available are reported as unknown.
+func binaryVersion() BinaryVersion {
+	v := BinaryVersion{
+		Version: unknownVersion,
+		Commit:  unknownVersion,
+	}
+	info, ok := readBuildInfo()
+	if !ok {
+		return v
+	}
+	if info.Main.Version != "" {
+		v.Version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != "" {
+			v.Commit = setting.Value
+		}
+	}
+	return v
+}
+
+func VersionCmd(d daemon.Daemon) *cobra.Command {
+	var (
+		instanceId string
+		jsonOutput bool
+	)
+	cmd := cobra.Command{
+		Use:   "version [<instance_id>]",
+		Short: "Print the egn version and, optionally, the version of an AVS node instance",
+		Long:  "Prints the version and commit of the egn binary. If an instance_id is given, the package version and commit of the installed AVS node instance are printed too. Use --json to get the versions as a JSON document.",
+		Args:  cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return nil
+			}
+			instanceId = args[0]
+			return validateInstanceIds(instanceId)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := versionOutput{Egn: binaryVersion()}
+			if instanceId != "" {
+				instance, err := d.InstanceVersion(instanceId)
+				if err != nil {
+					return err
+				}
+				out.Instance = &instance
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(out)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "egn version %s (commit %s)\n", out.Egn.Version, out.Egn.Commit)
+			if out.Instance != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s version %s (commit %s)\n", out.Instance.InstanceID, out.Instance.Version, out.Instance.Commit)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the versions as a JSON document.")
+	cmd.ValidArgsFunction = completeInstanceIDs(d, false)
+	return &cmd
+}
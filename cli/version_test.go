@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"runtime/debug"
+	"testing"
+
+	daemonMock "github.com/NethermindEth/eigenlayer/cli/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(read func() (*debug.BuildInfo, bool)) {
+		readBuildInfo = read
+	}(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Version: "v0.5.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "a3406616b848164358fdd24465b8eecda5f5ae34"},
+			},
+		}, true
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		mocker func(d *daemonMock.MockDaemon)
+		stdOut string
+		err    error
+	}{
+		{
+			name:   "binary version",
+			stdOut: "egn version v0.5.0 (commit a3406616b848164358fdd24465b8eecda5f5ae34)\n",
+		},
+		{
+			name: "binary and instance version",
+			args: []string{"mock-avs-default"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InstanceVersion("mock-avs-default").Return(daemon.InstanceVersionInfo{
+					InstanceID: "mock-avs-default",
+					Version:    "v5.5.0",
+					Commit:     "d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91",
+				}, nil)
+			},
+			stdOut: "egn version v0.5.0 (commit a3406616b848164358fdd24465b8eecda5f5ae34)\n" +
+				"mock-avs-default version v5.5.0 (commit d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91)\n",
+		},
+		{
+			name:   "binary version, json",
+			args:   []string{"--json"},
+			stdOut: "{\n  \"egn\": {\n    \"version\": \"v0.5.0\",\n    \"commit\": \"a3406616b848164358fdd24465b8eecda5f5ae34\"\n  }\n}\n",
+		},
+		{
+			name: "binary and instance version, json",
+			args: []string{"mock-avs-default", "--json"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InstanceVersion("mock-avs-default").Return(daemon.InstanceVersionInfo{
+					InstanceID: "mock-avs-default",
+					Version:    "v5.5.0",
+					Commit:     "d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91",
+				}, nil)
+			},
+			stdOut: `{
+  "egn": {
+    "version": "v0.5.0",
+    "commit": "a3406616b848164358fdd24465b8eecda5f5ae34"
+  },
+  "instance": {
+    "instance_id": "mock-avs-default",
+    "version": "v5.5.0",
+    "commit": "d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91"
+  }
+}
+`,
+		},
+		{
+			name: "instance not found",
+			args: []string{"mock-avs-default"},
+			mocker: func(d *daemonMock.MockDaemon) {
+				d.EXPECT().InstanceVersion("mock-avs-default").Return(daemon.InstanceVersionInfo{}, daemon.ErrInstanceNotFound)
+			},
+			err: daemon.ErrInstanceNotFound,
+		},
+		{
+			name: "invalid instance ID",
+			args: []string{"mock_avs"},
+			err:  errors.New(`invalid arguments: invalid instance ID: "mock_avs" does not have the format <repository-name>-<tag>`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			d := daemonMock.NewMockDaemon(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(d)
+			}
+
+			var stdOut bytes.Buffer
+			cmd := VersionCmd(d)
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(&stdOut)
+			err := cmd.Execute()
+			if tt.err != nil {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, tt.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.stdOut, stdOut.String())
+		})
+	}
+}
+
+func TestBinaryVersion_NoBuildInfo(t *testing.T) {
+	defer func(read func() (*debug.BuildInfo, bool)) {
+		readBuildInfo = read
+	}(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+
+	assert.Equal(t, BinaryVersion{Version: "unknown", Commit: "unknown"}, binaryVersion())
+}
@@ -1,84 +1,54 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/NethermindEth/eigenlayer/cli"
 	"github.com/NethermindEth/eigenlayer/cli/prompter"
-	"github.com/NethermindEth/eigenlayer/internal/backup"
-	"github.com/NethermindEth/eigenlayer/internal/commands"
-	"github.com/NethermindEth/eigenlayer/internal/compose"
-	"github.com/NethermindEth/eigenlayer/internal/data"
-	"github.com/NethermindEth/eigenlayer/internal/docker"
-	"github.com/NethermindEth/eigenlayer/internal/locker"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon"
-	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
-	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/grafana"
-	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/node_exporter"
-	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/prometheus"
-	"github.com/docker/docker/client"
-	"github.com/spf13/afero"
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
-	// Docker client
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer dockerClient.Close()
-
-	// Init Cmd runner
-	// runner := commands.NewCMDRunnerWithSudo() // Uncomment this line if sudo is required
-	runner := commands.NewCMDRunner()
-
-	// Init docker and compose managers
-	dockerManager := docker.NewDockerManager(dockerClient)
-	composeManager := compose.NewComposeManager(&runner)
-
-	// Set filesystem
-	// fs := afero.NewMemMapFs() // Uncomment this line if you want to use the in-memory filesystem
-	// fs := afero.NewBasePathFs(afero.NewOsFs(), "/tmp") // Uncomment this line if you want to use the real filesystem with a base path
-	fs := afero.NewOsFs() // Uncomment this line if you want to use the real filesystem
-
-	// Set locker
-	locker := locker.NewFLock()
-
-	// Get the monitoring manager
-	monitoringServices := []monitoring.ServiceAPI{
-		grafana.NewGrafana(),
-		prometheus.NewPrometheus(),
-		node_exporter.NewNodeExporter(),
-	}
-	monitoringManager := monitoring.NewMonitoringManager(
-		monitoringServices,
-		composeManager,
-		dockerManager,
-		fs,
-		locker,
-	)
-
-	// Set DataDir
-	dataDir, err := data.NewDataDirDefault(fs, locker)
-	if err != nil {
+	if err := run(); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	// Backup manager
-	backupMgr := backup.NewBackupManager(fs, dataDir, dockerManager, composeManager)
-
-	// Initialize daemon
-	daemon, err := daemon.NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+func run() error {
+	// Logger shared by the daemon and its managers. The CLI configures its
+	// format and level from the global flags.
+	logger := logrus.StandardLogger()
+
+	// Initialize daemon, counting its operations in the egn metrics
+	egnMetrics := metrics.New()
+	d, err := daemon.New(daemon.Options{
+		Logger:  logger,
+		Metrics: egnMetrics,
+	})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer d.Close()
 
 	// Initialize prompter
 	p := prompter.NewPrompter()
 	// Build CLI
-	cmd := cli.RootCmd(daemon, p)
-	// Execute CLI
-	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
-	}
+	cmd := cli.RootCmd(d, p, logger, egnMetrics)
+	// Execute CLI. Interrupting the process cancels the command context, so
+	// long-running operations can abort cleanly. The default handling of the
+	// signals is restored after the first one, so interrupting it again kills
+	// the process, like when the command doesn't watch the context.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return cmd.ExecuteContext(ctx)
 }
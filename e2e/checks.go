@@ -169,7 +169,7 @@ func checkGrafanaHealth(t *testing.T) {
 // checkMonitoringStackContainersNotRunning checks that the monitoring stack containers are not running
 func checkMonitoringStackContainersNotRunning(t *testing.T) {
 	t.Logf("Checking monitoring stack containers are not running")
-	checkContainerNotExisting(t, "egn_grafana", "egn_prometheus", "egn_node_exporter")
+	checkContainerNotExisting(t, "egn_grafana", "egn_prometheus", "egn_node_exporter", "egn_cadvisor")
 }
 
 // checkContainerNotRunning checks that the given containers are not running
@@ -124,15 +124,7 @@ func stopMonitoringStackContainers() error {
 }
 
 func dataDirPath() (string, error) {
-	userDataHome := os.Getenv("XDG_DATA_HOME")
-	if userDataHome == "" {
-		userHome, err := os.UserHomeDir()
-		if err != nil {
-			return "", err
-		}
-		userDataHome = filepath.Join(userHome, ".local", "share")
-	}
-	return filepath.Join(userDataHome, ".eigen"), nil
+	return data.LocateDataDir()
 }
 
 func getContainerIPByName(containerName string, networkName string) (string, error) {
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/spf13/afero"
+	"golang.org/x/exp/maps"
+)
+
+// availableSpace returns the available space in bytes of the filesystem that
+// contains the given path.
+func availableSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("failed to get disk free space: %w", err)
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// dirSize returns the sum of the sizes in bytes of the regular files inside
+// the given directory.
+func dirSize(fs afero.Fs, path string) (uint64, error) {
+	var size uint64
+	err := afero.Walk(fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// tarContentSize returns the sum of the sizes in bytes of the regular files in
+// the plain tar file at the given path.
+func tarContentSize(fs afero.Fs, path string) (uint64, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var size uint64
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if header.FileInfo().Mode().IsRegular() {
+			size += uint64(header.Size)
+		}
+	}
+}
+
+// backupSize estimates the size in bytes of the backup of the instance in the
+// given directory, the size of the instance data plus the size of the docker
+// volumes of its compose project. The bind mounts outside the instance
+// directory are not counted.
+func (b *BackupManager) backupSize(instancePath string, project *types.Project) (uint64, error) {
+	dataSize, err := dirSize(b.fs, instancePath)
+	if err != nil {
+		return 0, err
+	}
+	volumes, err := b.projectVolumes(project.Name)
+	if err != nil {
+		return 0, err
+	}
+	volumesSize, err := b.volumesSize(maps.Values(volumes))
+	if err != nil {
+		return 0, err
+	}
+	return dataSize + volumesSize, nil
+}
+
+// checkDiskSpace returns an ErrInsufficientDiskSpace error if the filesystem
+// that contains the given path has less than required bytes available.
+func (b *BackupManager) checkDiskSpace(path string, required uint64) error {
+	available, err := b.availableSpace(path)
+	if err != nil {
+		return err
+	}
+	if available < required {
+		return fmt.Errorf("%w: %d bytes required in %s but only %d bytes available", ErrInsufficientDiskSpace, required, path, available)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testState = `{
+	"name": "mock-avs",
+	"url": "https://github.com/NethermindEth/mock-avs-pkg",
+	"version": "v5.5.0",
+	"spec_version": "v0.1.0",
+	"commit": "a3406616b848164358fdd24465b8eecda5f5ae34",
+	"profile": "option-returner",
+	"tag": "default"
+}`
+
+// smallDisk returns an available space function of a filesystem with the
+// given number of free bytes.
+func smallDisk(free uint64) func(string) (uint64, error) {
+	return func(string) (uint64, error) {
+		return free, nil
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/data/a", make([]byte, 100), 0o644))
+	require.NoError(t, afero.WriteFile(afs, "/data/sub/b", make([]byte, 50), 0o644))
+
+	size, err := dirSize(afs, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), size)
+
+	_, err = dirSize(afs, "/missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTarContentSize(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	f, err := afs.Create("/backup.tar")
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for name, size := range map[string]int{"data/a": 100, "data/sub/b": 50} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(size), Mode: 0o644}))
+		_, err = tw.Write(make([]byte, size))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	size, err := tarContentSize(afs, "/backup.tar")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), size)
+}
+
+func TestBackupInstanceInsufficientDiskSpace(t *testing.T) {
+	afs := afero.NewOsFs()
+	dataDirPath := t.TempDir()
+	dataDir, err := data.NewDataDir(dataDirPath, afs, locker.NewFLock())
+	require.NoError(t, err)
+	instancePath := filepath.Join(dataDirPath, "nodes", "mock-avs-default")
+	require.NoError(t, afs.MkdirAll(instancePath, 0o755))
+	for name, content := range map[string][]byte{
+		"state.json":         []byte(testState),
+		"docker-compose.yml": []byte("services:\n  main-service:\n    image: busybox\n"),
+		".env":               nil,
+		"data.bin":           make([]byte, 1024),
+	} {
+		require.NoError(t, afero.WriteFile(afs, filepath.Join(instancePath, name), content, 0o644))
+	}
+
+	backupMgr := NewBackupManager(afs, dataDir, nil, nil, log.StandardLogger())
+	backupMgr.projectVolumes = func(project string) (map[string]string, error) {
+		assert.Equal(t, "mock-avs-default", project)
+		return map[string]string{"data": "mock-avs-default_data"}, nil
+	}
+	backupMgr.volumesSize = func(names []string) (uint64, error) {
+		assert.Equal(t, []string{"mock-avs-default_data"}, names)
+		return 4096, nil
+	}
+	// The instance data alone fits, but not with its volumes
+	backupMgr.availableSpace = smallDisk(4096)
+
+	_, err = backupMgr.BackupInstance("mock-avs-default", data.CompressionNone, nil)
+	require.ErrorIs(t, err, ErrInsufficientDiskSpace)
+
+	// No backup is created
+	backups, err := dataDir.BackupList()
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestRestoreInstanceInsufficientDiskSpace(t *testing.T) {
+	for _, compression := range []data.Compression{data.CompressionNone, data.CompressionGzip} {
+		t.Run(compression.String(), func(t *testing.T) {
+			afs := afero.NewOsFs()
+			dataDirPath := t.TempDir()
+			dataDir, err := data.NewDataDir(dataDirPath, afs, locker.NewFLock())
+			require.NoError(t, err)
+
+			// Create a backup with some data
+			backup := data.Backup{
+				InstanceId: "mock-avs-default",
+				Timestamp:  time.Unix(1696367916, 0),
+				Version:    "v5.5.0",
+				Commit:     "a3406616b848164358fdd24465b8eecda5f5ae34",
+				Url:        "https://github.com/NethermindEth/mock-avs-pkg",
+			}
+			require.NoError(t, dataDir.InitBackup(&backup))
+			backupFile, err := afs.OpenFile(dataDir.BackupPath(backup.Id()), os.O_WRONLY, 0o644)
+			require.NoError(t, err)
+			tarWriter := tar.NewWriter(backupFile)
+			for name, content := range map[string][]byte{
+				"data/state.json":             []byte(testState),
+				"data/data.bin":               make([]byte, 2048),
+				"volumes/data/volume-id/file": make([]byte, 2048),
+				"timestamp":                   []byte("1696367916"),
+			} {
+				require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0o644}))
+				_, err = tarWriter.Write(content)
+				require.NoError(t, err)
+			}
+			require.NoError(t, tarWriter.Close())
+			require.NoError(t, backupFile.Close())
+			require.NoError(t, dataDir.CompressBackup(backup.Id(), compression))
+
+			backupMgr := NewBackupManager(afs, dataDir, nil, nil, log.StandardLogger())
+			// The data fits without the volumes, and the compressed backup
+			// fits too
+			backupMgr.availableSpace = smallDisk(4096)
+
+			err = backupMgr.RestoreInstance(backup.Id(), nil, "")
+			require.ErrorIs(t, err, ErrInsufficientDiskSpace)
+
+			// The instance is not restored
+			assert.False(t, dataDir.HasInstance("mock-avs-default"))
+		})
+	}
+}
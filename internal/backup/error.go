@@ -0,0 +1,5 @@
+package backup
+
+import "errors"
+
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
@@ -1,18 +1,24 @@
 package backup
 
 import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
-	"github.com/NethermindEth/docker-volumes-snapshotter/pkg/backuptar"
 	"github.com/NethermindEth/eigenlayer/internal/compose"
 	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/docker"
+	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/compose-spec/compose-go/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+	"golang.org/x/exp/maps"
 )
 
 const (
@@ -20,6 +26,13 @@ const (
 	SnapshotterRepo          = "github.com/NethermindEth/docker-volumes-snapshotter"
 	SnapshotterRemoteContext = SnapshotterRepo + ".git#" + SnapshotterVersion
 	SnapshotterImage         = "eigenlayer-snapshotter:" + SnapshotterVersion
+
+	// snapshotterVolumeTarget is where a named volume is mounted in the
+	// snapshotter container.
+	snapshotterVolumeTarget = "/volume"
+	// snapshotterVolumesData is the file the snapshotter writes under the
+	// prefix of its config with the data of the archived volumes.
+	snapshotterVolumesData = "volumes-data.yml"
 )
 
 type BackupInfo struct {
@@ -33,19 +46,39 @@ type BackupManager struct {
 	dockerMgr  *docker.DockerManager
 	composeMgr *compose.ComposeManager
 	fs         afero.Fs
+	logger     log.FieldLogger
+	// availableSpace returns the available bytes on the filesystem of a path.
+	availableSpace func(path string) (uint64, error)
+	// projectVolumes returns the named volumes of a compose project, mapped
+	// from their name in the compose file to their docker volume name.
+	projectVolumes func(project string) (map[string]string, error)
+	// volumesSize returns the size in bytes of the docker volumes with the
+	// given names.
+	volumesSize func(names []string) (uint64, error)
+	// runSnapshotter runs the snapshotter on the backup tar at a path.
+	runSnapshotter func(backupPath string, s snapshot) error
 }
 
-func NewBackupManager(fs afero.Fs, dataDir *data.DataDir, dockerMgr *docker.DockerManager, composeMgr *compose.ComposeManager) *BackupManager {
-	return &BackupManager{
+func NewBackupManager(fs afero.Fs, dataDir *data.DataDir, dockerMgr *docker.DockerManager, composeMgr *compose.ComposeManager, logger log.FieldLogger) *BackupManager {
+	b := &BackupManager{
 		dataDir:    dataDir,
 		dockerMgr:  dockerMgr,
 		composeMgr: composeMgr,
 		fs:         fs,
+		logger:     logger,
+
+		availableSpace: availableSpace,
+		projectVolumes: dockerMgr.ProjectVolumes,
+		volumesSize:    dockerMgr.VolumesSize,
 	}
+	b.runSnapshotter = b.snapshotterRun
+	return b
 }
 
-// BackupInstance creates a backup of the instance with the given ID.
-func (b *BackupManager) BackupInstance(instanceId string) (string, error) {
+// BackupInstance creates a backup of the instance with the given ID. The
+// backup tar file is compressed with the given codec once it is complete, and
+// then encrypted with the key of the given provider if it is not nil.
+func (b *BackupManager) BackupInstance(instanceId string, compression data.Compression, keys data.KeyProvider) (string, error) {
 	if !b.dataDir.HasInstance(instanceId) {
 		return "", fmt.Errorf("%w: instance %s", data.ErrInstanceNotFound, instanceId)
 	}
@@ -53,14 +86,32 @@ func (b *BackupManager) BackupInstance(instanceId string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	log.Info("Backing up instance ", instanceId)
-	if err := b.buildSnapshotterImage(); err != nil {
+	b.logger.WithField("instance_id", instanceId).Info("Backing up instance")
+	instanceProject, err := instance.ComposeProject()
+	if err != nil {
 		return "", err
 	}
-	instanceProject, err := instance.ComposeProject()
+
+	// The backup tar holds the instance data and its volumes
+	instancePath, err := b.dataDir.InstancePath(instanceId)
+	if err != nil {
+		return "", err
+	}
+	required, err := b.backupSize(instancePath, instanceProject)
+	if err != nil {
+		return "", err
+	}
+	backupDir, err := b.dataDir.BackupDir()
 	if err != nil {
 		return "", err
 	}
+	if err := b.checkDiskSpace(backupDir, required); err != nil {
+		return "", err
+	}
+
+	if err := b.buildSnapshotterImage(); err != nil {
+		return "", err
+	}
 
 	backup := &data.Backup{
 		InstanceId: instanceId,
@@ -75,12 +126,10 @@ func (b *BackupManager) BackupInstance(instanceId string) (string, error) {
 		return "", err
 	}
 
-	// Add volumes of each service
-	for _, service := range instanceProject.Services {
-		err := b.backupInstanceServiceVolumes(service, backup)
-		if err != nil {
-			return "", err
-		}
+	// Add the volumes of the instance
+	err = b.backupInstanceVolumes(instanceProject, backup)
+	if err != nil {
+		return "", err
 	}
 
 	// Add instance data
@@ -95,40 +144,122 @@ func (b *BackupManager) BackupInstance(instanceId string) (string, error) {
 		return "", err
 	}
 
+	// Add integrity manifest
+	err = b.addManifest(backup)
+	if err != nil {
+		return "", err
+	}
+
+	// Compress backup
+	if compression != data.CompressionNone {
+		b.logger.WithField("compression", compression.String()).Info("Compressing backup...")
+		err = b.dataDir.CompressBackup(backup.Id(), compression)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Encrypt backup
+	if keys != nil {
+		b.logger.Info("Encrypting backup...")
+		if err = b.dataDir.EncryptBackup(backup.Id(), keys); err != nil {
+			return "", err
+		}
+	}
+
+	// Name the backup after its content
+	if err = b.dataDir.FinalizeBackup(backup); err != nil {
+		return "", err
+	}
+
 	return backup.Id(), nil
 }
 
-func (b *BackupManager) RestoreInstance(backupId string) error {
-	backup, err := b.dataDir.Backup(backupId)
+// RestoreInstance restores the backup with the given ID into the instance it
+// was created from. Encrypted backups are decrypted with the key of the given
+// provider, which can be nil if the backup is not encrypted. configDir is the
+// docker client config directory used to create the containers of the
+// instance, or empty to use the default one.
+func (b *BackupManager) RestoreInstance(backupId string, keys data.KeyProvider, configDir string) error {
+	return b.RestoreInstanceAs(backupId, "", keys, configDir)
+}
+
+// RestoreInstanceAs restores the backup with the given ID into the instance
+// with the given ID, which can differ from the ID of the backed up instance.
+// If instanceId is empty, the backup is restored into the instance it was
+// created from. Encrypted backups are decrypted with the key of the given
+// provider, and an ErrBackupEncrypted error is returned if it is nil. The
+// containers are created with the docker client config in configDir, if it is
+// not empty.
+func (b *BackupManager) RestoreInstanceAs(backupId, instanceId string, keys data.KeyProvider, configDir string) error {
+	backup, err := b.dataDir.EncryptedBackup(backupId, keys)
 	if err != nil {
 		return err
 	}
+	if instanceId == "" {
+		instanceId = backup.InstanceId
+	}
 
-	log.Infof("Restoring backup INSTANCE_ID: %s, VERSION: %s, COMMIT: %s", backup.InstanceId, backup.Version, backup.Commit)
+	b.logger.WithFields(log.Fields{
+		"instance_id":        instanceId,
+		"backup_instance_id": backup.InstanceId,
+		"version":            backup.Version,
+		"commit":             backup.Commit,
+	}).Info("Restoring backup")
 
 	backupPath := b.dataDir.BackupPath(backup.Id())
+
+	// The snapshotter only reads plain tar files
+	if backup.Encrypted {
+		backupPath, err = b.decryptBackup(backupPath, backup.Compression, keys)
+		if err != nil {
+			return err
+		}
+		defer b.fs.Remove(backupPath)
+	}
+	if backup.Compression != data.CompressionNone {
+		backupPath, err = b.decompressBackup(backupPath)
+		if err != nil {
+			return err
+		}
+		defer b.fs.Remove(backupPath)
+	}
+
+	// The restored data and volumes take the size of the files in the plain
+	// tar, not the size of the compressed backup
+	required, err := tarContentSize(b.fs, backupPath)
 	if err != nil {
 		return err
 	}
+	if err := b.checkDiskSpace(b.dataDir.Path(), required); err != nil {
+		return err
+	}
 
 	// Restore instance data
-	err = b.restoreInstanceData(backup.InstanceId, backupPath)
+	err = b.restoreInstanceData(instanceId, backupPath)
 	if err != nil {
 		return err
 	}
 
-	if err := b.buildSnapshotterImage(); err != nil {
+	instance, err := b.dataDir.Instance(instanceId)
+	if err != nil {
 		return err
 	}
+	// The restored state.json holds the ID of the backed up instance
+	if instanceId != backup.InstanceId {
+		if err := instance.SetId(instanceId); err != nil {
+			return err
+		}
+	}
 
-	instance, err := b.dataDir.Instance(backup.InstanceId)
-	if err != nil {
+	if err := b.buildSnapshotterImage(); err != nil {
 		return err
 	}
 
 	// Create compose project
 	err = b.composeMgr.Create(compose.DockerComposeCreateOptions{
-		Path: instance.ComposePath(),
+		Path:      instance.ComposePath(),
+		ConfigDir: configDir,
 	})
 	if err != nil {
 		return err
@@ -139,148 +270,275 @@ func (b *BackupManager) RestoreInstance(backupId string) error {
 		return err
 	}
 
-	// Restore volumes of each service
-	for _, service := range instanceProject.Services {
-		err := b.restoreInstanceServiceVolumes(service, backupPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	// Restore the volumes of the instance
+	return b.restoreInstanceVolumes(instanceProject, backupPath)
 }
 
 func (b *BackupManager) backupInstanceData(instanceId string, backup *data.Backup) error {
-	log.Info("Backing up instance data...")
+	b.logger.WithField("instance_id", instanceId).Info("Backing up instance data...")
 	backupPath := b.dataDir.BackupPath(backup.Id())
 
 	instancePath, err := b.dataDir.InstancePath(instanceId)
 	if err != nil {
 		return err
 	}
-	backupWriter, err := backuptar.NewBackupWriter(backupPath)
-	if err != nil {
-		return err
-	}
-	defer backupWriter.Close()
-	return backupWriter.AddDir(instancePath, filepath.Join("data"))
+	return utils.TarAppend(b.fs, backupPath, func(tw *tar.Writer) error {
+		return utils.TarWriteDir(tw, b.fs, instancePath, "data", nil, nil)
+	})
 }
 
-func (b *BackupManager) backupInstanceServiceVolumes(service types.ServiceConfig, backup *data.Backup) (err error) {
-	if len(service.Volumes) == 0 {
-		return nil
+// backupInstanceVolumes adds the volumes of the instance compose project to
+// the backup. Each named volume of the project is archived under
+// volumes/<name>/, so the state the services keep in docker volumes is backed
+// up even if no service mounts it. The other volumes of each service, like
+// bind mounts, are archived under volumes/<service>/.
+func (b *BackupManager) backupInstanceVolumes(project *types.Project, backup *data.Backup) error {
+	namedVolumes, err := b.namedVolumes(project)
+	if err != nil {
+		return err
 	}
-	log.Infof("Backing up %d volumes from service \"%s\"...", len(service.Volumes), service.Name)
 	backupPath := b.dataDir.BackupPath(backup.Id())
+	for _, service := range project.Services {
+		err := b.backupInstanceServiceVolumes(service, namedVolumes, backupPath)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(namedVolumes) {
+		b.logger.WithField("volume", name).Info("Backing up volume...")
+		err := b.runSnapshotter(backupPath, namedVolumeSnapshot("backup", name, namedVolumes[name]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func (b *BackupManager) backupInstanceServiceVolumes(service types.ServiceConfig, namedVolumes map[string]string, backupPath string) error {
 	volumes := make([]string, 0, len(service.Volumes))
 	for _, v := range service.Volumes {
+		// Named volumes are archived on their own
+		if _, ok := namedVolumes[v.Source]; ok && v.Type == types.VolumeTypeVolume {
+			continue
+		}
 		volumes = append(volumes, v.Target)
 	}
-	config := backupConfig{
-		Prefix:  snapshotterConfigPrefix(service.Name),
-		Volumes: volumes,
-	}
-	f, err := afero.TempFile(b.fs, os.TempDir(), "eigenlayer-snapshotter-config-*.yml")
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	err = config.Save(f)
-	if err != nil {
-		return err
+	if len(volumes) == 0 {
+		return nil
 	}
-	err = b.dockerMgr.Run(SnapshotterImage, docker.RunOptions{
-		Args:       []string{"backup"},
-		AutoRemove: true,
-		Mounts: []docker.Mount{
-			{
-				Type:   docker.VolumeTypeBind,
-				Source: f.Name(),
-				Target: "/snapshotter.yml",
-			},
-			{
-				Type:   docker.VolumeTypeBind,
-				Source: backupPath,
-				Target: "/backup.tar",
-			},
+	b.logger.WithFields(log.Fields{
+		"service": service.Name,
+		"volumes": len(volumes),
+	}).Info("Backing up service volumes...")
+	return b.runSnapshotter(backupPath, snapshot{
+		Command: "backup",
+		Config: backupConfig{
+			Prefix:  snapshotterConfigPrefix(service.Name),
+			Volumes: volumes,
 		},
 		VolumesFrom: []string{service.ContainerName},
 	})
-	if err != nil {
-		return fmt.Errorf("snapshotter failed with error: %w", err)
-	}
-	return nil
 }
 
 func (b *BackupManager) addTimestamp(backup *data.Backup) error {
-	log.Infof("Adding timestamp %s...", backup.Timestamp.Format(time.DateTime))
+	b.logger.WithField("timestamp", backup.Timestamp.Format(time.DateTime)).Info("Adding timestamp...")
+	timestamp := []byte(data.FormatBackupTimestamp(backup.Timestamp))
+	return utils.TarAppend(b.fs, b.dataDir.BackupPath(backup.Id()), func(tw *tar.Writer) error {
+		return utils.TarWriteFile(tw, "timestamp", timestamp, backup.Timestamp)
+	})
+}
+
+// addManifest adds to the backup tar a manifest of all the files archived so
+// far, with their sizes and checksums.
+func (b *BackupManager) addManifest(backup *data.Backup) error {
+	b.logger.Info("Adding integrity manifest...")
 	backupPath := b.dataDir.BackupPath(backup.Id())
 
-	timestampTmp, err := afero.TempFile(b.fs, afero.GetTempDir(b.fs, ""), "backup-timestamp-*")
+	manifest, err := data.BuildBackupManifest(b.fs, backupPath)
 	if err != nil {
 		return err
 	}
-	defer timestampTmp.Close()
-	defer b.fs.Remove(timestampTmp.Name())
-
-	_, err = timestampTmp.WriteString(fmt.Sprintf("%d", backup.Timestamp.Unix()))
+	manifestData, err := json.Marshal(manifest)
 	if err != nil {
 		return err
 	}
+	return utils.TarAppend(b.fs, backupPath, func(tw *tar.Writer) error {
+		return utils.TarWriteFile(tw, data.BackupManifestName, manifestData, backup.Timestamp)
+	})
+}
 
-	backupWriter, err := backuptar.NewBackupWriter(backupPath)
+func (b *BackupManager) decompressBackup(backupPath string) (string, error) {
+	b.logger.WithField("backup", filepath.Base(backupPath)).Info("Decompressing backup...")
+	tarTmp, err := afero.TempFile(b.fs, os.TempDir(), "eigenlayer-restore-*.tar")
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer backupWriter.Close()
+	if err = tarTmp.Close(); err != nil {
+		return "", err
+	}
+	if err = data.DecompressTar(b.fs, backupPath, tarTmp.Name()); err != nil {
+		b.fs.Remove(tarTmp.Name())
+		return "", err
+	}
+	return tarTmp.Name(), nil
+}
 
-	return backupWriter.AddFile(timestampTmp.Name(), "timestamp")
+// decryptBackup decrypts the encrypted backup file at the given path into a
+// temporary tar file, compressed with the given codec like the backup, and
+// returns its path.
+func (b *BackupManager) decryptBackup(backupPath string, compression data.Compression, keys data.KeyProvider) (string, error) {
+	b.logger.WithField("backup", filepath.Base(backupPath)).Info("Decrypting backup...")
+	tarTmp, err := afero.TempFile(b.fs, os.TempDir(), "eigenlayer-restore-*"+compression.Extension())
+	if err != nil {
+		return "", err
+	}
+	if err = tarTmp.Close(); err != nil {
+		return "", err
+	}
+	if err = data.DecryptBackup(b.fs, backupPath, tarTmp.Name(), keys); err != nil {
+		b.fs.Remove(tarTmp.Name())
+		return "", err
+	}
+	return tarTmp.Name(), nil
 }
 
 func (b *BackupManager) restoreInstanceData(instanceId string, backupPath string) error {
 	return b.dataDir.ReplaceInstanceDirFromTar(instanceId, backupPath, "data")
 }
 
+// restoreInstanceVolumes restores the volumes of the instance compose project
+// archived in the backup by backupInstanceVolumes. The named volumes of the
+// project missing in the backup, like in backups created before named volumes
+// were archived on their own, are left as they are.
+func (b *BackupManager) restoreInstanceVolumes(project *types.Project, backupPath string) error {
+	for _, service := range project.Services {
+		err := b.restoreInstanceServiceVolumes(service, backupPath)
+		if err != nil {
+			return err
+		}
+	}
+	namedVolumes, err := b.namedVolumes(project)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(namedVolumes) {
+		ok, err := tarHasFile(b.fs, backupPath, filepath.Join(snapshotterConfigPrefix(name), snapshotterVolumesData))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		b.logger.WithField("volume", name).Info("Restoring volume...")
+		err = b.runSnapshotter(backupPath, namedVolumeSnapshot("restore", name, namedVolumes[name]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *BackupManager) restoreInstanceServiceVolumes(service types.ServiceConfig, backupPath string) error {
 	if len(service.Volumes) == 0 {
 		return nil
 	}
-	log.Infof("Restoring %d volumes from service \"%s\"...", len(service.Volumes), service.Name)
+	b.logger.WithFields(log.Fields{
+		"service": service.Name,
+		"volumes": len(service.Volumes),
+	}).Info("Restoring service volumes...")
 
 	volumes := make([]string, 0, len(service.Volumes))
 	for _, v := range service.Volumes {
 		volumes = append(volumes, v.Target)
 	}
-	config := backupConfig{
-		Prefix:  filepath.Join("volumes", service.Name),
-		Volumes: volumes,
+	return b.runSnapshotter(backupPath, snapshot{
+		Command: "restore",
+		Config: backupConfig{
+			Prefix:  snapshotterConfigPrefix(service.Name),
+			Volumes: volumes,
+		},
+		VolumesFrom: []string{service.ContainerName},
+	})
+}
+
+// namedVolumes returns the named volumes of the project that are archived
+// under volumes/<name>/, mapped to their docker volume name. A volume named
+// like a service is archived with the volumes of the services instead, as
+// they would share the volumes/<service>/ prefix.
+func (b *BackupManager) namedVolumes(project *types.Project) (map[string]string, error) {
+	volumes, err := b.projectVolumes(project.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range project.Services {
+		delete(volumes, service.Name)
+	}
+	return volumes, nil
+}
+
+// snapshot is a run of the snapshotter, which archives volumes into the backup
+// tar or restores them from it.
+type snapshot struct {
+	// Command is the snapshotter command, backup or restore.
+	Command string
+	Config  backupConfig
+	// Mounts are the volumes mounted in the snapshotter container besides its
+	// config and the backup tar.
+	Mounts []docker.Mount
+	// VolumesFrom are the containers whose volumes are mounted in the
+	// snapshotter container.
+	VolumesFrom []string
+}
+
+// namedVolumeSnapshot returns the snapshot that runs the given command on the
+// named volume with the given name in the compose file and docker volume name.
+// The volume is archived under volumes/<name>/.
+func namedVolumeSnapshot(command, name, dockerName string) snapshot {
+	return snapshot{
+		Command: command,
+		Config: backupConfig{
+			Prefix:  snapshotterConfigPrefix(name),
+			Volumes: []string{snapshotterVolumeTarget},
+		},
+		Mounts: []docker.Mount{
+			{
+				Type:   docker.VolumeTypeVolume,
+				Source: dockerName,
+				Target: snapshotterVolumeTarget,
+			},
+		},
 	}
+}
+
+// snapshotterRun runs the snapshot in a snapshotter container with the backup
+// tar at the given path.
+func (b *BackupManager) snapshotterRun(backupPath string, s snapshot) error {
 	f, err := afero.TempFile(b.fs, os.TempDir(), "eigenlayer-snapshotter-config-*.yml")
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	err = config.Save(f)
+	err = s.Config.Save(f)
 	if err != nil {
 		return err
 	}
-	err = b.dockerMgr.Run(SnapshotterImage, docker.RunOptions{
-		Args:       []string{"restore"},
-		AutoRemove: true,
-		Mounts: []docker.Mount{
-			{
-				Type:   docker.VolumeTypeBind,
-				Source: f.Name(),
-				Target: "/snapshotter.yml",
-			},
-			{
-				Type:   docker.VolumeTypeBind,
-				Source: backupPath,
-				Target: "/backup.tar",
-			},
+	mounts := append([]docker.Mount{
+		{
+			Type:   docker.VolumeTypeBind,
+			Source: f.Name(),
+			Target: "/snapshotter.yml",
 		},
-		VolumesFrom: []string{service.ContainerName},
+		{
+			Type:   docker.VolumeTypeBind,
+			Source: backupPath,
+			Target: "/backup.tar",
+		},
+	}, s.Mounts...)
+	err = b.dockerMgr.Run(SnapshotterImage, docker.RunOptions{
+		Args:        []string{s.Command},
+		AutoRemove:  true,
+		Mounts:      mounts,
+		VolumesFrom: s.VolumesFrom,
 	})
 	if err != nil {
 		return fmt.Errorf("snapshotter failed with error: %w", err)
@@ -294,8 +552,11 @@ func (b *BackupManager) buildSnapshotterImage() error {
 		return err
 	}
 	if !ok {
-		log.Infof("Building snapshotter image \"%s\" from \"%s\" ...", SnapshotterImage, SnapshotterRemoteContext)
-		log.Infof("To learn more about the snapshotter, visit https://%s/tree/%s", SnapshotterRepo, SnapshotterVersion)
+		b.logger.WithFields(log.Fields{
+			"image":   SnapshotterImage,
+			"context": SnapshotterRemoteContext,
+		}).Info("Building snapshotter image...")
+		b.logger.Infof("To learn more about the snapshotter, visit https://%s/tree/%s", SnapshotterRepo, SnapshotterVersion)
 		err = b.dockerMgr.BuildImageFromURI(SnapshotterRemoteContext, SnapshotterImage, nil)
 		if err != nil {
 			return fmt.Errorf("%w: %s", data.ErrCreatingBackup, err.Error())
@@ -304,6 +565,35 @@ func (b *BackupManager) buildSnapshotterImage() error {
 	return nil
 }
 
-func snapshotterConfigPrefix(service string) string {
-	return filepath.Join("volumes", service)
+func snapshotterConfigPrefix(name string) string {
+	return filepath.Join("volumes", name)
+}
+
+// tarHasFile returns true if the plain tar file at the given path has a file
+// with the given name.
+func tarHasFile(fs afero.Fs, tarPath, name string) (bool, error) {
+	f, err := fs.Open(tarPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if utils.TarMemberName(header.Name) == utils.TarMemberName(name) {
+			return true, nil
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
 }
@@ -0,0 +1,226 @@
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/docker-volumes-snapshotter/pkg/backuptar"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/docker"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
+	"github.com/compose-spec/compose-go/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSnapshotter returns a snapshotter run function that records the
+// snapshots and, on backup, archives a volume file and the volumes data under
+// the prefix of the snapshot config, like the snapshotter does.
+func fakeSnapshotter(t *testing.T, snapshots *[]snapshot) func(string, snapshot) error {
+	return func(backupPath string, s snapshot) error {
+		*snapshots = append(*snapshots, s)
+		if s.Command != "backup" {
+			return nil
+		}
+		src := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(src, []byte("volume data"), 0o644))
+		w, err := backuptar.NewBackupWriter(backupPath)
+		require.NoError(t, err)
+		defer w.Close()
+		require.NoError(t, w.AddFile(src, filepath.Join(s.Config.Prefix, "volume-id", "file")))
+		return w.AddFile(src, filepath.Join(s.Config.Prefix, snapshotterVolumesData))
+	}
+}
+
+func tarNames(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return names
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+}
+
+func TestInstanceVolumes(t *testing.T) {
+	project := &types.Project{
+		Name: "mock-avs-default",
+		Services: types.Services{
+			{
+				Name:          "main-service",
+				ContainerName: "main-service-container",
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"},
+					{Type: types.VolumeTypeBind, Source: "./config", Target: "/config"},
+				},
+			},
+			{
+				Name:          "option-returner",
+				ContainerName: "option-returner-container",
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "option-returner", Target: "/options"},
+				},
+			},
+		},
+	}
+	projectVolumes := func(project string) (map[string]string, error) {
+		assert.Equal(t, "mock-avs-default", project)
+		return map[string]string{
+			"data":            "mock-avs-default_data",
+			"keystore":        "mock-avs-default_keystore",
+			"option-returner": "mock-avs-default_option-returner",
+		}, nil
+	}
+
+	afs := afero.NewOsFs()
+	dataDir, err := data.NewDataDir(t.TempDir(), afs, locker.NewFLock())
+	require.NoError(t, err)
+	backup := &data.Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696367916, 0),
+	}
+	require.NoError(t, dataDir.InitBackup(backup))
+	backupPath := dataDir.BackupPath(backup.Id())
+
+	var snapshots []snapshot
+	backupMgr := NewBackupManager(afs, dataDir, nil, nil, log.StandardLogger())
+	backupMgr.projectVolumes = projectVolumes
+	backupMgr.runSnapshotter = fakeSnapshotter(t, &snapshots)
+
+	require.NoError(t, backupMgr.backupInstanceVolumes(project, backup))
+	assert.Equal(t, []snapshot{
+		{
+			Command:     "backup",
+			Config:      backupConfig{Prefix: "volumes/main-service", Volumes: []string{"/config"}},
+			VolumesFrom: []string{"main-service-container"},
+		},
+		{
+			Command:     "backup",
+			Config:      backupConfig{Prefix: "volumes/option-returner", Volumes: []string{"/options"}},
+			VolumesFrom: []string{"option-returner-container"},
+		},
+		namedVolumeSnapshot("backup", "data", "mock-avs-default_data"),
+		namedVolumeSnapshot("backup", "keystore", "mock-avs-default_keystore"),
+	}, snapshots)
+	assert.Equal(t, docker.Mount{Type: docker.VolumeTypeVolume, Source: "mock-avs-default_keystore", Target: "/volume"}, snapshots[3].Mounts[0])
+	assert.Equal(t, []string{
+		"volumes/main-service/volume-id/file",
+		"volumes/main-service/volumes-data.yml",
+		"volumes/option-returner/volume-id/file",
+		"volumes/option-returner/volumes-data.yml",
+		"volumes/data/volume-id/file",
+		"volumes/data/volumes-data.yml",
+		"volumes/keystore/volume-id/file",
+		"volumes/keystore/volumes-data.yml",
+	}, tarNames(t, backupPath))
+
+	// The restore replays the service volumes and the named volumes in the
+	// backup. The logs volume was created after the backup.
+	snapshots = nil
+	backupMgr.projectVolumes = func(project string) (map[string]string, error) {
+		volumes, err := projectVolumes(project)
+		volumes["logs"] = "mock-avs-default_logs"
+		return volumes, err
+	}
+	require.NoError(t, backupMgr.restoreInstanceVolumes(project, backupPath))
+	assert.Equal(t, []snapshot{
+		{
+			Command:     "restore",
+			Config:      backupConfig{Prefix: "volumes/main-service", Volumes: []string{"/data", "/config"}},
+			VolumesFrom: []string{"main-service-container"},
+		},
+		{
+			Command:     "restore",
+			Config:      backupConfig{Prefix: "volumes/option-returner", Volumes: []string{"/options"}},
+			VolumesFrom: []string{"option-returner-container"},
+		},
+		namedVolumeSnapshot("restore", "data", "mock-avs-default_data"),
+		namedVolumeSnapshot("restore", "keystore", "mock-avs-default_keystore"),
+	}, snapshots)
+}
+
+func TestInstanceVolumesError(t *testing.T) {
+	afs := afero.NewOsFs()
+	dataDir, err := data.NewDataDir(t.TempDir(), afs, locker.NewFLock())
+	require.NoError(t, err)
+
+	backupMgr := NewBackupManager(afs, dataDir, nil, nil, log.StandardLogger())
+	backupMgr.projectVolumes = func(string) (map[string]string, error) {
+		return nil, assert.AnError
+	}
+	backupMgr.runSnapshotter = func(string, snapshot) error {
+		t.Fatal("unexpected snapshotter run")
+		return nil
+	}
+
+	err = backupMgr.backupInstanceVolumes(&types.Project{Name: "mock-avs-default"}, &data.Backup{InstanceId: "mock-avs-default"})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestRestoreInstanceEncrypted(t *testing.T) {
+	afs := afero.NewOsFs()
+	dataDir, err := data.NewDataDir(t.TempDir(), afs, locker.NewFLock())
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "backup.key")
+	require.NoError(t, afero.WriteFile(afs, keyPath, []byte("secret key\n"), 0o600))
+	otherKeyPath := filepath.Join(t.TempDir(), "other.key")
+	require.NoError(t, afero.WriteFile(afs, otherKeyPath, []byte("other key\n"), 0o600))
+
+	backup := data.Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696367916, 0),
+		Version:    "v5.5.0",
+		Commit:     "a3406616b848164358fdd24465b8eecda5f5ae34",
+	}
+	require.NoError(t, dataDir.InitBackup(&backup))
+	backupFile, err := afs.OpenFile(dataDir.BackupPath(backup.Id()), os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	tarWriter := tar.NewWriter(backupFile)
+	for name, content := range map[string][]byte{
+		"data/state.json": []byte(testState),
+		"timestamp":       []byte("1696367916"),
+	} {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0o644}))
+		_, err = tarWriter.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, backupFile.Close())
+	require.NoError(t, dataDir.EncryptBackup(backup.Id(), data.NewFileKeyProvider(afs, keyPath)))
+	require.NoError(t, dataDir.FinalizeBackup(&backup))
+
+	tc := []struct {
+		name string
+		keys data.KeyProvider
+		err  error
+	}{
+		{name: "without key", keys: nil, err: data.ErrBackupEncrypted},
+		{name: "wrong key", keys: data.NewFileKeyProvider(afs, otherKeyPath), err: data.ErrDecryptingBackup},
+		// The backup metadata is decrypted, the restore stops at the disk
+		// space check
+		{name: "right key", keys: data.NewFileKeyProvider(afs, keyPath), err: ErrInsufficientDiskSpace},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			backupMgr := NewBackupManager(afs, dataDir, nil, nil, log.StandardLogger())
+			backupMgr.availableSpace = smallDisk(0)
+
+			err := backupMgr.RestoreInstance(backup.Id(), tt.keys, "")
+			require.ErrorIs(t, err, tt.err)
+			assert.False(t, dataDir.HasInstance("mock-avs-default"))
+		})
+	}
+}
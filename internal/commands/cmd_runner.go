@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -35,23 +36,29 @@ func NewCMDRunnerWithSudo() CMDRunner {
 
 // RunCMD runs a command. If the command runner is configured to run with sudo and the command is not forced to run without sudo, the command is run with sudo.
 func (cr *CMDRunner) RunCMD(cmd Command) (out string, exitCode int, err error) {
+	return cr.RunCMDContext(context.Background(), cmd)
+}
+
+// RunCMDContext runs a command like RunCMD, killing it if the context is done
+// before the command finishes. In that case the context error is returned.
+func (cr *CMDRunner) RunCMDContext(ctx context.Context, cmd Command) (out string, exitCode int, err error) {
 	if cr.runWithSudo {
 		log.Debug(`Running command with sudo.`)
 		cmd.Cmd = fmt.Sprintf("sudo %s", cmd.Cmd)
 	} else {
 		log.Debug(`Running command without sudo.`)
 	}
-	return runCmd(cmd.Cmd, cmd.GetOutput)
+	return runCmd(ctx, cmd.Cmd, cmd.GetOutput)
 }
 
 // TODO: Refactor to be able to opt for show output to stdout/stderr, and by default show output to stdout/stderr and return output
 // runCmd executes a command and returns the output, exit code, and any error that occurred during execution. If getOutput is true, the output of the command is returned.
-func runCmd(cmd string, getOutput bool) (out string, exitCode int, err error) {
+func runCmd(ctx context.Context, cmd string, getOutput bool) (out string, exitCode int, err error) {
 	r := strings.ReplaceAll(cmd, "\n", "")
 	spl := strings.Split(r, " ")
 	c, args := spl[0], spl[1:]
 
-	exc := exec.Command(c, args...)
+	exc := exec.CommandContext(ctx, c, args...)
 
 	var combinedOut bytes.Buffer
 	if getOutput {
@@ -71,6 +78,9 @@ func runCmd(cmd string, getOutput bool) (out string, exitCode int, err error) {
 	// Return this error at the end as we need to check if the output from stderr is to be returned
 	err = exc.Wait()
 	exitCode = exc.ProcessState.ExitCode()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
 
 	if getOutput {
 		out = combinedOut.String()
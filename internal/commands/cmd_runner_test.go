@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestRunCmd(t *testing.T) {
@@ -46,6 +49,22 @@ func TestRunCmd(t *testing.T) {
 	}
 }
 
+func TestRunCmdContextCanceled(t *testing.T) {
+	runner := NewCMDRunner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := runner.RunCMDContext(ctx, Command{Cmd: "sleep 10", GetOutput: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunCMDContext(sleep 10) expected context.Canceled but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("RunCMDContext(sleep 10) was not killed after cancel, took %s", elapsed)
+	}
+}
+
 func ExampleCMDRunner_RunCMD() {
 	cmdRunner := NewCMDRunner()
 	out, exitCode, err := cmdRunner.RunCMD(Command{Cmd: "echo hello", GetOutput: true})
@@ -1,6 +1,7 @@
 package compose
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -18,9 +19,10 @@ func (e DockerComposeCmdError) Error() string {
 	return fmt.Sprintf("Docker Compose Manager running 'docker compose %s'", e.cmd)
 }
 
-// CMDRunner is an interface that defines a method for running commands.
+// CMDRunner is an interface that defines methods for running commands.
 type CMDRunner interface {
 	RunCMD(commands.Command) (string, int, error)
+	RunCMDContext(context.Context, commands.Command) (string, int, error)
 }
 
 // ComposeManager manages Docker Compose operations.
@@ -35,22 +37,29 @@ func NewComposeManager(runner CMDRunner) *ComposeManager {
 	}
 }
 
-// Up runs the Docker Compose 'up' command for the specified options.
-func (cm *ComposeManager) Up(opts DockerComposeUpOptions) error {
-	upCmd := fmt.Sprintf("docker compose -f %s up -d", opts.Path)
+// composeCmd returns the docker compose command, using the docker client config
+// in configDir if it is not empty.
+func composeCmd(configDir string) string {
+	if configDir == "" {
+		return "docker compose"
+	}
+	return fmt.Sprintf("docker --config %s compose", configDir)
+}
+
+// Up runs the Docker Compose 'up' command for the specified options. The
+// command is killed if the context is done before it finishes.
+func (cm *ComposeManager) Up(ctx context.Context, opts DockerComposeUpOptions) error {
+	upCmd := fmt.Sprintf("%s -f %s up -d", composeCmd(opts.ConfigDir), opts.Path)
 	if len(opts.Services) > 0 {
 		upCmd += " " + strings.Join(opts.Services, " ")
 	}
 
-	if out, exitCode, err := cm.cmdRunner.RunCMD(commands.Command{Cmd: upCmd, GetOutput: true}); err != nil || exitCode != 0 {
-		return fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: "up"}, err, out)
-	}
-	return nil
+	return cm.runContext(ctx, "up", upCmd)
 }
 
 // Pull runs the Docker Compose 'pull' command for the specified options.
 func (cm *ComposeManager) Pull(opts DockerComposePullOptions) error {
-	pullCmd := fmt.Sprintf("docker compose -f %s pull", opts.Path)
+	pullCmd := fmt.Sprintf("%s -f %s pull", composeCmd(opts.ConfigDir), opts.Path)
 	if len(opts.Services) > 0 {
 		pullCmd += " " + strings.Join(opts.Services, " ")
 	}
@@ -63,7 +72,7 @@ func (cm *ComposeManager) Pull(opts DockerComposePullOptions) error {
 
 // Create runs the Docker Compose 'create' command for the specified options.
 func (cm *ComposeManager) Create(opts DockerComposeCreateOptions) error {
-	createCmd := fmt.Sprintf("docker compose -f %s create", opts.Path)
+	createCmd := fmt.Sprintf("%s -f %s create", composeCmd(opts.ConfigDir), opts.Path)
 	if opts.Build {
 		createCmd += " --build"
 	}
@@ -173,12 +182,56 @@ func (cm *ComposeManager) Logs(opts DockerComposeLogsOptions) error {
 	return nil
 }
 
-// Stop runs the Docker Compose 'stop' command for the specified options.
-func (cm *ComposeManager) Stop(opts DockerComposeStopOptions) error {
+// Stop runs the Docker Compose 'stop' command for the specified options. The
+// command is killed if the context is done before it finishes.
+func (cm *ComposeManager) Stop(ctx context.Context, opts DockerComposeStopOptions) error {
 	stopCmd := fmt.Sprintf("docker compose -f %s stop", opts.Path)
 
-	if out, exitCode, err := cm.cmdRunner.RunCMD(commands.Command{Cmd: stopCmd, GetOutput: true}); err != nil || exitCode != 0 {
-		return fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: "stop"}, err, out)
+	return cm.runContext(ctx, "stop", stopCmd)
+}
+
+// Pause runs the Docker Compose 'pause' command for the specified options,
+// suspending the processes of the running services.
+func (cm *ComposeManager) Pause(opts DockerComposePauseOptions) error {
+	pauseCmd := fmt.Sprintf("docker compose -f %s pause", opts.Path)
+
+	if out, exitCode, err := cm.cmdRunner.RunCMD(commands.Command{Cmd: pauseCmd, GetOutput: true}); err != nil || exitCode != 0 {
+		return fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: "pause"}, err, out)
+	}
+	return nil
+}
+
+// Unpause runs the Docker Compose 'unpause' command for the specified options,
+// resuming the processes of the paused services.
+func (cm *ComposeManager) Unpause(opts DockerComposeUnpauseOptions) error {
+	unpauseCmd := fmt.Sprintf("docker compose -f %s unpause", opts.Path)
+
+	if out, exitCode, err := cm.cmdRunner.RunCMD(commands.Command{Cmd: unpauseCmd, GetOutput: true}); err != nil || exitCode != 0 {
+		return fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: "unpause"}, err, out)
+	}
+	return nil
+}
+
+// Version runs the Docker Compose 'version' command and returns the version
+// of the docker compose plugin, like 2.21.0.
+func (cm *ComposeManager) Version() (string, error) {
+	out, exitCode, err := cm.cmdRunner.RunCMD(commands.Command{Cmd: "docker compose version --short", GetOutput: true})
+	if err != nil || exitCode != 0 {
+		return "", fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: "version"}, err, out)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runContext runs the given Docker Compose command, named by subcmd in the
+// returned errors. If the context is done before the command finishes, the
+// returned error wraps the context error.
+func (cm *ComposeManager) runContext(ctx context.Context, subcmd, cmd string) error {
+	out, exitCode, err := cm.cmdRunner.RunCMDContext(ctx, commands.Command{Cmd: cmd, GetOutput: true})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %w", DockerComposeCmdError{cmd: subcmd}, ctxErr)
+	}
+	if err != nil || exitCode != 0 {
+		return fmt.Errorf("%w: %s. Output: %s", DockerComposeCmdError{cmd: subcmd}, err, out)
 	}
 	return nil
 }
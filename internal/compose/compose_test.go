@@ -1,6 +1,7 @@
 package compose
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"strings"
@@ -65,12 +66,12 @@ func TestUp(t *testing.T) {
 			}
 
 			if tt.runCMDError != nil {
-				mockRunner.EXPECT().RunCMD(commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 1, tt.runCMDError)
+				mockRunner.EXPECT().RunCMDContext(gomock.Any(), commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 1, tt.runCMDError)
 			} else {
-				mockRunner.EXPECT().RunCMD(commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 0, nil)
+				mockRunner.EXPECT().RunCMDContext(gomock.Any(), commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 0, nil)
 			}
 
-			err := manager.Up(tt.opts)
+			err := manager.Up(context.Background(), tt.opts)
 
 			if tt.wantError != nil {
 				assert.Error(t, err)
@@ -96,7 +97,26 @@ func ExampleComposeManager_Up() {
 	}
 
 	// Run the Docker Compose Up command
-	manager.Up(opts)
+	manager.Up(context.Background(), opts)
+}
+
+func TestUpContextCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRunner := mocks.NewMockCMDRunner(ctrl)
+	manager := NewComposeManager(mockRunner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRunner.EXPECT().RunCMDContext(ctx, commands.Command{Cmd: "docker compose -f /path/to/docker-compose.yml up -d", GetOutput: true}).
+		DoAndReturn(func(ctx context.Context, _ commands.Command) (string, int, error) {
+			cancel()
+			return "", -1, ctx.Err()
+		})
+
+	err := manager.Up(ctx, DockerComposeUpOptions{Path: "/path/to/docker-compose.yml"})
+	assert.ErrorIs(t, err, DockerComposeCmdError{cmd: "up"})
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 func TestPull(t *testing.T) {
@@ -186,6 +206,46 @@ func ExampleComposeManager_Pull() {
 	manager.Pull(opts)
 }
 
+func TestConfigDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(manager *ComposeManager) error
+		expectedCmd string
+	}{
+		{
+			name: "up",
+			run: func(manager *ComposeManager) error {
+				return manager.Up(context.Background(), DockerComposeUpOptions{Path: "/path/to/docker-compose.yml", ConfigDir: "/tmp/egn-registry"})
+			},
+			expectedCmd: "docker --config /tmp/egn-registry compose -f /path/to/docker-compose.yml up -d",
+		},
+		{
+			name: "pull",
+			run: func(manager *ComposeManager) error {
+				return manager.Pull(DockerComposePullOptions{Path: "/path/to/docker-compose.yml", ConfigDir: "/tmp/egn-registry"})
+			},
+			expectedCmd: "docker --config /tmp/egn-registry compose -f /path/to/docker-compose.yml pull",
+		},
+		{
+			name: "create",
+			run: func(manager *ComposeManager) error {
+				return manager.Create(DockerComposeCreateOptions{Path: "/path/to/docker-compose.yml", ConfigDir: "/tmp/egn-registry", Build: true})
+			},
+			expectedCmd: "docker --config /tmp/egn-registry compose -f /path/to/docker-compose.yml create --build",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRunner := mocks.NewMockCMDRunner(gomock.NewController(t))
+			command := commands.Command{Cmd: tt.expectedCmd, GetOutput: true}
+			mockRunner.EXPECT().RunCMD(command).Return("", 0, nil).AnyTimes()
+			mockRunner.EXPECT().RunCMDContext(gomock.Any(), command).Return("", 0, nil).AnyTimes()
+
+			assert.NoError(t, tt.run(NewComposeManager(mockRunner)))
+		})
+	}
+}
+
 func TestCreate(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -656,12 +716,12 @@ func TestStop(t *testing.T) {
 			expectedCmd := "docker compose -f " + tt.opts.Path + " stop"
 
 			if tt.runCMDError != nil {
-				mockRunner.EXPECT().RunCMD(commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 1, tt.runCMDError)
+				mockRunner.EXPECT().RunCMDContext(gomock.Any(), commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 1, tt.runCMDError)
 			} else {
-				mockRunner.EXPECT().RunCMD(commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 0, nil)
+				mockRunner.EXPECT().RunCMDContext(gomock.Any(), commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", 0, nil)
 			}
 
-			err := manager.Stop(tt.opts)
+			err := manager.Stop(context.Background(), tt.opts)
 
 			if tt.wantError != nil {
 				assert.Error(t, err)
@@ -673,6 +733,115 @@ func TestStop(t *testing.T) {
 	}
 }
 
+func TestPauseUnpause(t *testing.T) {
+	tests := []struct {
+		name        string
+		subcmd      string
+		runCMDError error
+		exitCode    int
+		wantError   error
+	}{
+		{
+			name:   "pause runs the correct command",
+			subcmd: "pause",
+		},
+		{
+			name:        "pause returns an error if RunCMD fails",
+			subcmd:      "pause",
+			runCMDError: errors.New("command failed"),
+			exitCode:    1,
+			wantError:   DockerComposeCmdError{cmd: "pause"},
+		},
+		{
+			name:   "unpause runs the correct command",
+			subcmd: "unpause",
+		},
+		{
+			name:      "unpause returns an error on a non-zero exit code",
+			subcmd:    "unpause",
+			exitCode:  1,
+			wantError: DockerComposeCmdError{cmd: "unpause"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRunner := mocks.NewMockCMDRunner(ctrl)
+			manager := NewComposeManager(mockRunner)
+
+			path := "/path/to/docker-compose.yml"
+			expectedCmd := "docker compose -f " + path + " " + tt.subcmd
+			mockRunner.EXPECT().RunCMD(commands.Command{Cmd: expectedCmd, GetOutput: true}).Return("", tt.exitCode, tt.runCMDError)
+
+			var err error
+			if tt.subcmd == "pause" {
+				err = manager.Pause(DockerComposePauseOptions{Path: path})
+			} else {
+				err = manager.Unpause(DockerComposeUnpauseOptions{Path: path})
+			}
+
+			if tt.wantError != nil {
+				assert.ErrorIs(t, err, tt.wantError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		out         string
+		runCMDError error
+		exitCode    int
+		want        string
+		wantError   error
+	}{
+		{
+			name: "it returns the trimmed version",
+			out:  "2.21.0\n",
+			want: "2.21.0",
+		},
+		{
+			name:        "it returns an error if RunCMD fails",
+			runCMDError: errors.New("command failed"),
+			exitCode:    1,
+			wantError:   DockerComposeCmdError{cmd: "version"},
+		},
+		{
+			name:      "it returns an error without the compose plugin",
+			out:       "docker: 'compose' is not a docker command.",
+			exitCode:  1,
+			wantError: DockerComposeCmdError{cmd: "version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRunner := mocks.NewMockCMDRunner(ctrl)
+			manager := NewComposeManager(mockRunner)
+
+			mockRunner.EXPECT().RunCMD(commands.Command{Cmd: "docker compose version --short", GetOutput: true}).Return(tt.out, tt.exitCode, tt.runCMDError)
+
+			version, err := manager.Version()
+
+			if tt.wantError != nil {
+				assert.ErrorIs(t, err, tt.wantError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, version)
+			}
+		})
+	}
+}
+
 func TestDown(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -6,6 +6,10 @@ type DockerComposeUpOptions struct {
 	Path string
 	// Services lists the names of the services to be started.
 	Services []string
+	// ConfigDir is the docker client config directory, with the credentials
+	// of the registries the images are pulled from. The default docker
+	// config is used if empty.
+	ConfigDir string
 }
 
 // DockerComposePullOptions defines the options for the 'docker compose pull' command.
@@ -14,6 +18,10 @@ type DockerComposePullOptions struct {
 	Path string
 	// Services lists the names of the services for which images should be pulled.
 	Services []string
+	// ConfigDir is the docker client config directory, with the credentials
+	// of the registries the images are pulled from. The default docker
+	// config is used if empty.
+	ConfigDir string
 }
 
 // DockerComposeCreateOptions defines the options for the 'docker compose create' command.
@@ -24,6 +32,10 @@ type DockerComposeCreateOptions struct {
 	Services []string
 	// Build specifies whether to build images before starting containers.
 	Build bool
+	// ConfigDir is the docker client config directory, with the credentials
+	// of the registries the images are pulled from. The default docker
+	// config is used if empty.
+	ConfigDir string
 }
 
 // DockerComposeBuildOptions defines the options for the 'docker compose build' command.
@@ -83,6 +95,18 @@ type DockerComposeStopOptions struct {
 	Path string
 }
 
+// DockerComposePauseOptions defines the options for the 'docker compose pause' command.
+type DockerComposePauseOptions struct {
+	// Path specifies the location of the docker-compose.yaml file.
+	Path string
+}
+
+// DockerComposeUnpauseOptions defines the options for the 'docker compose unpause' command.
+type DockerComposeUnpauseOptions struct {
+	// Path specifies the location of the docker-compose.yaml file.
+	Path string
+}
+
 // DockerComposeDownOptions defines the options for the 'docker compose down' command.
 type DockerComposeDownOptions struct {
 	// Path specifies the location of the docker-compose.yaml file.
@@ -1,7 +1,11 @@
 package data
 
 import (
-	"crypto/sha1"
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -10,33 +14,160 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/NethermindEth/docker-volumes-snapshotter/pkg/backuptar"
+	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/spf13/afero"
 )
 
-var backupFileNameRegex = regexp.MustCompile(`^(?P<instance_id>.*)-(?P<timestamp>[0-9]+)\.tar$`)
+// backupFileNameRegex matches backup file names with the format
+// <instance_id>-<timestamp>[-<label>].tar[.gz|.zst], where the label is
+// optional and must start with a letter. The timestamp is formatted with
+// FormatBackupTimestamp.
+var backupFileNameRegex = regexp.MustCompile(`^(?P<instance_id>.*)-(?P<timestamp>[0-9]+(?:\.[0-9]{9})?)(?:-(?P<label>[a-zA-Z][a-zA-Z0-9_-]*))?\.tar(?:\.gz|\.zst)?$`)
 
 type Backup struct {
-	id         string
+	id   string
+	path string
+	// fs is the filesystem of the backup file, if the backup was loaded from
+	// one. It is used to compute the checksum of the file when it is needed.
+	fs         afero.Fs
 	InstanceId string
 	Timestamp  time.Time
 	Version    string
 	Commit     string
 	Url        string
+	// Label is an optional short description of the backup, like "before-upgrade".
+	Label string
+	// Compression is the codec used to compress the backup tar file.
+	Compression Compression
+	// Checksum is the hex encoded SHA-256 of the backup file. It is only known
+	// for backups created with CreateBackup.
+	Checksum string
+	// HasManifest is true if the backup tar holds an integrity manifest that
+	// can be checked with VerifyBackupManifest. It is only known for backups
+	// created with CreateBackup: the manifest is the last member of the tar,
+	// so finding it in a loaded backup means reading the whole file, which is
+	// left to VerifyBackupManifest.
+	HasManifest bool
+	// Encrypted is true if the backup file is encrypted with EncryptBackup.
+	Encrypted bool
 }
 
+// backupIdRegex matches the backup IDs, the hex encoded SHA-256 computed by
+// Id or the SHA-1 of the backups created before the content of the backups was
+// part of their ID.
+var backupIdRegex = regexp.MustCompile(`^(?:[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// Id returns the ID of the backup, the hex encoded SHA-256 of its metadata and
+// its checksum, so backups with the same metadata and different contents get
+// different IDs. The checksum is computed from the backup file the first time
+// the ID is needed if it is not known yet, and left out if there is no file
+// yet, like for a backup that is being created.
+//
+// The backups stored in the data directory are named after their ID, so
+// backups loaded with BackupFromTar from a file named after an ID take it.
 func (b *Backup) Id() string {
 	if b.id == "" {
-		h := sha1.Sum([]byte(fmt.Sprintf("%s-%d-%s-%s", b.InstanceId, b.Timestamp.Unix(), b.Version, b.Commit)))
+		if b.Checksum == "" && b.fs != nil && b.path != "" {
+			// The ID is still computed from the metadata if the file can't
+			// be read
+			if checksum, err := fileChecksum(b.fs, b.path); err == nil {
+				b.Checksum = checksum
+			}
+		}
+		h := sha256.Sum256([]byte(fmt.Sprintf("%s-%s-%s-%s-%s", b.InstanceId, FormatBackupTimestamp(b.Timestamp), b.Version, b.Commit, b.Checksum)))
 		b.id = hex.EncodeToString(h[:])
 	}
 	return b.id
 }
 
-// BackupFromTar loads a backup information from a tar file.
+// fileChecksum returns the hex encoded SHA-256 of the file at the given path.
+func fileChecksum(fs afero.Fs, path string) (string, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FileName returns the human-friendly file name of the backup with the format
+// <instance_id>-<timestamp>[-<label>].tar[.gz|.zst]. The label is omitted if
+// it is empty and the extension depends on the backup compression.
+func (b *Backup) FileName() string {
+	name := b.InstanceId + "-" + FormatBackupTimestamp(b.Timestamp)
+	if b.Label != "" {
+		name += "-" + b.Label
+	}
+	return name + b.Compression.Extension()
+}
+
+// Size returns the size in bytes of the backup file, as it is stored, so the
+// size of a compressed backup is the size of the compressed file. It is only
+// known for backups loaded with BackupFromTar.
+func (b *Backup) Size(fs afero.Fs) (int64, error) {
+	if b.path == "" {
+		return -1, fmt.Errorf("%w: %s", ErrBackupFileUnknown, b.Id())
+	}
+	stat, err := fs.Stat(b.path)
+	if err != nil {
+		return -1, err
+	}
+	return stat.Size(), nil
+}
+
+// Age returns the time elapsed since the backup was created.
+func (b *Backup) Age() time.Duration {
+	return time.Since(b.Timestamp)
+}
+
+// backupJSON is the JSON representation of a Backup.
+type backupJSON struct {
+	Id         string `json:"id"`
+	InstanceId string `json:"instance_id"`
+	Timestamp  string `json:"timestamp"`
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	Url        string `json:"url"`
+	Label      string `json:"label,omitempty"`
+	Checksum   string `json:"checksum,omitempty"`
+	Manifest   bool   `json:"manifest,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The output includes the computed
+// backup Id and the timestamp formatted as RFC3339 in UTC.
+func (b Backup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(backupJSON{
+		Id:         b.Id(),
+		InstanceId: b.InstanceId,
+		Timestamp:  b.Timestamp.UTC().Format(time.RFC3339),
+		Version:    b.Version,
+		Commit:     b.Commit,
+		Url:        b.Url,
+		Label:      b.Label,
+		Checksum:   b.Checksum,
+		Manifest:   b.HasManifest,
+	})
+}
+
+// BackupFromTar loads a backup information from a tar file. Encrypted backups
+// are loaded without their metadata, see BackupFromEncryptedTar.
 func BackupFromTar(fs afero.Fs, src string) (*Backup, error) {
+	return BackupFromEncryptedTar(fs, src, nil)
+}
+
+// BackupFromEncryptedTar loads a backup information from a tar file like
+// BackupFromTar, decrypting encrypted backups with the key of the given
+// provider. The metadata of an encrypted backup can't be read without its key,
+// so if the provider is nil, the backup is loaded with Encrypted set, the ID
+// of its file name and the modification time of the file as timestamp.
+func BackupFromEncryptedTar(fs afero.Fs, src string, keys KeyProvider) (*Backup, error) {
 	// Check if file exists
 	ok, err := afero.Exists(fs, src)
 	if err != nil {
@@ -46,112 +177,277 @@ func BackupFromTar(fs afero.Fs, src string) (*Backup, error) {
 		return nil, fmt.Errorf("%w: %s", os.ErrNotExist, src)
 	}
 	// Check file name extension
-	if ext := filepath.Ext(src); ext != ".tar" {
+	compression, ok := compressionFromPath(src)
+	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrInvalidBackupName, src)
 	}
+	var id string
+	if name := strings.TrimSuffix(filepath.Base(src), compression.Extension()); backupIdRegex.MatchString(name) {
+		id = name
+	}
+	encrypted, err := isEncryptedFile(fs, src)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted && keys == nil {
+		stat, err := fs.Stat(src)
+		if err != nil {
+			return nil, err
+		}
+		return &Backup{
+			id:          id,
+			path:        src,
+			fs:          fs,
+			Timestamp:   stat.ModTime(),
+			Compression: compression,
+			Encrypted:   true,
+		}, nil
+	}
 	// Load state.json from tar
-	instance, err := loadBackupTarStateJson(fs, src)
+	instance, err := loadBackupTarStateJson(fs, src, keys)
 	if err != nil {
 		return nil, err
 	}
 	// Load timestamp
-	timestamp, err := loadBackupTarTimestamp(fs, src)
+	timestamp, err := loadBackupTarTimestamp(fs, src, keys)
 	if err != nil {
 		return nil, err
 	}
 	return &Backup{
-		InstanceId: instance.ID(),
-		Timestamp:  timestamp,
-		Version:    instance.Version,
-		Commit:     instance.Commit,
-		Url:        instance.URL,
+		id:          id,
+		path:        src,
+		fs:          fs,
+		InstanceId:  instance.ID(),
+		Timestamp:   timestamp,
+		Version:     instance.Version,
+		Commit:      instance.Commit,
+		Url:         instance.URL,
+		Compression: compression,
+		Encrypted:   encrypted,
 	}, nil
 }
 
-// loadStateJsonFromTar loads the state.json file from a tar file.F
-func loadBackupTarStateJson(fs afero.Fs, tarPath string) (*Instance, error) {
-	// Open tar file
-	tarFile, err := fs.OpenFile(tarPath, os.O_RDONLY, 0o644)
+// CreateBackup writes a gzip compressed backup tar of the instance with the
+// given ID to w, reading the instance data from the dataDir directory. The tar
+// is written incrementally, so the backup is never held in memory, and its
+// SHA-256 checksum is computed while writing. The backup only holds the
+// instance data, not the volumes of its services.
+func CreateBackup(fs afero.Fs, instanceId string, dataDir string, w io.Writer) (*Backup, error) {
+	stateData, err := afero.ReadFile(fs, filepath.Join(dataDir, "state.json"))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	var instance Instance
+	if err := json.Unmarshal(stateData, &instance); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	backup := &Backup{
+		InstanceId:  instanceId,
+		Timestamp:   time.Now(),
+		Version:     instance.Version,
+		Commit:      instance.Commit,
+		Url:         instance.URL,
+		Compression: CompressionGzip,
 	}
-	defer tarFile.Close()
 
-	stateTmp, err := afero.TempFile(fs, "", "state-*.json")
+	hash := sha256.New()
+	gzw := gzip.NewWriter(io.MultiWriter(w, hash))
+	tw := tar.NewWriter(gzw)
+	manifest := &BackupManifest{Files: []BackupManifestFile{}}
+	err = utils.TarWriteDir(tw, fs, dataDir, "data", nil, func(name string, content io.Reader) error {
+		file, err := manifestFile(name, content)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
 	}
-	defer stateTmp.Close()
-	defer fs.Remove(stateTmp.Name())
-
-	// Load state.json
-	err = backuptar.ExtractFile(tarPath, "data/state.json", stateTmp.Name())
+	timestamp := []byte(FormatBackupTimestamp(backup.Timestamp))
+	if err := addTarFile(tw, "timestamp", timestamp, backup.Timestamp, manifest); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	manifestData, err := json.Marshal(manifest)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	if err := addTarFile(tw, BackupManifestName, manifestData, backup.Timestamp, nil); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
 	}
+	backup.HasManifest = true
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	backup.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return backup, nil
+}
 
-	_, err = stateTmp.Seek(0, 0)
+// addTarFile writes a regular file with the given name, content and
+// modification time to the tar writer. The file is added to the manifest if it
+// is not nil.
+func addTarFile(tw *tar.Writer, name string, content []byte, modTime time.Time, manifest *BackupManifest) error {
+	if err := utils.TarWriteFile(tw, name, content, modTime); err != nil {
+		return err
+	}
+	if manifest == nil {
+		return nil
+	}
+	file, err := manifestFile(name, bytes.NewReader(content))
 	if err != nil {
-		return nil, err
+		return err
 	}
+	manifest.Files = append(manifest.Files, file)
+	return nil
+}
 
-	stateData, err := io.ReadAll(stateTmp)
+// loadStateJsonFromTar loads the state.json file from a tar file.
+func loadBackupTarStateJson(fs afero.Fs, tarPath string, keys KeyProvider) (*Instance, error) {
+	stateData, err := readBackupTarFile(fs, tarPath, "data/state.json", keys)
 	if err != nil {
 		return nil, err
 	}
 
 	var instance Instance
-	return &instance, json.Unmarshal(stateData, &instance)
+	if err := json.Unmarshal(stateData, &instance); err != nil {
+		return nil, err
+	}
+	if err := checkBackupState(&instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
 }
 
-func loadBackupTarTimestamp(fs afero.Fs, tarPath string) (time.Time, error) {
-	// Open file
-	tarFile, err := fs.OpenFile(tarPath, os.O_RDONLY, 0o644)
-	if err != nil {
-		return time.Time{}, err
+// checkBackupState returns an ErrIncompatibleBackup error if the state of a
+// backup was written in a newer format than the supported one, or if it misses
+// the fields needed to restore it, which happens when they were renamed.
+func checkBackupState(instance *Instance) error {
+	if instance.StateVersion > stateVersion {
+		return fmt.Errorf("%w: state version %d is newer than the supported version %d", ErrIncompatibleBackup, instance.StateVersion, stateVersion)
+	}
+	if instance.Name == "" || instance.Tag == "" {
+		return fmt.Errorf("%w: instance id is empty", ErrIncompatibleBackup)
 	}
-	defer tarFile.Close()
+	if instance.Version == "" && instance.Commit == "" {
+		return fmt.Errorf("%w: version and commit are empty", ErrIncompatibleBackup)
+	}
+	return nil
+}
 
-	timestampTmp, err := afero.TempFile(fs, "", "state-*.json")
+func loadBackupTarTimestamp(fs afero.Fs, tarPath string, keys KeyProvider) (time.Time, error) {
+	timestampData, err := readBackupTarFile(fs, tarPath, "timestamp", keys)
 	if err != nil {
 		return time.Time{}, err
 	}
-	defer timestampTmp.Close()
-	defer fs.Remove(timestampTmp.Name())
 
-	// Load timestamp
-	err = backuptar.ExtractFile(tarPath, "timestamp", timestampTmp.Name())
-	if err != nil {
-		return time.Time{}, err
+	return parseBackupTimestamp(string(timestampData))
+}
+
+// FormatBackupTimestamp formats the timestamp of a backup as unix seconds,
+// followed by the nanoseconds as a 9-digit fraction if the timestamp is not a
+// whole second, like 1696317683.123456789. Backups of the same instance
+// created within the same second then get different ids and file names,
+// while backups with a whole-second timestamp, like the ones created before
+// the fraction was added, keep theirs.
+func FormatBackupTimestamp(t time.Time) string {
+	if t.Nanosecond() == 0 {
+		return strconv.FormatInt(t.Unix(), 10)
 	}
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
 
-	_, err = timestampTmp.Seek(0, 0)
+// parseBackupTimestamp parses a timestamp formatted with FormatBackupTimestamp.
+func parseBackupTimestamp(s string) (time.Time, error) {
+	secs, nanos, hasNanos := strings.Cut(s, ".")
+	secsInt, err := strconv.ParseInt(secs, 10, 64)
 	if err != nil {
 		return time.Time{}, err
 	}
+	var nanosInt int64
+	if hasNanos {
+		if len(nanos) != 9 {
+			return time.Time{}, fmt.Errorf("invalid backup timestamp: %s", s)
+		}
+		if nanosInt, err = strconv.ParseInt(nanos, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(secsInt, nanosInt), nil
+}
+
+// readBackupTarFile reads the file with the given name from a backup tar
+// file. Compressed tar files are decompressed on the fly based on their
+// extension, and encrypted ones are decrypted with the key of the given
+// provider if it is not nil. The tar file is only opened for reading and
+// nothing is written to fs, so backups can be read from a read-only
+// filesystem.
+func readBackupTarFile(fs afero.Fs, tarPath, name string, keys KeyProvider) ([]byte, error) {
+	if keys != nil {
+		return ReadEncryptedBackupFile(fs, tarPath, name, keys)
+	}
+	return extractTarFile(fs, tarPath, name)
+}
 
-	timestampData, err := io.ReadAll(timestampTmp)
+// ReadBackupFile reads the member file with the given name, like
+// data/state.json, from the backup file at path without extracting the
+// backup. The format of the backup, a plain, gzip or zstd compressed tar, is
+// detected from its content, so any file name works. An ErrBackupEncrypted
+// error is returned for encrypted backups, which are read with
+// ReadEncryptedBackupFile.
+func ReadBackupFile(fs afero.Fs, path, member string) ([]byte, error) {
+	return ReadEncryptedBackupFile(fs, path, member, nil)
+}
+
+// ReadEncryptedBackupFile reads the member file with the given name from the
+// backup file at path like ReadBackupFile, decrypting encrypted backups on the
+// fly with the key of the given provider. The provider can be nil if the
+// backup is not encrypted.
+func ReadEncryptedBackupFile(fs afero.Fs, path, member string, keys KeyProvider) ([]byte, error) {
+	f, err := fs.Open(path)
 	if err != nil {
-		return time.Time{}, err
+		return nil, err
 	}
+	defer f.Close()
 
-	timestampInt, err := strconv.ParseInt(string(timestampData), 10, 64)
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if isEncrypted(br) {
+		if keys == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBackupEncrypted, path)
+		}
+		if r, err = newDecryptReader(r, keys); err != nil {
+			return nil, err
+		}
+	}
+	tarReader, err := sniffDecompress(r)
 	if err != nil {
-		return time.Time{}, err
+		return nil, err
+	}
+	defer tarReader.Close()
+	content, err := utils.TarReadFile(tarReader, member)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, path)
 	}
-	return time.Unix(timestampInt, 0), nil
+	return content, nil
 }
 
-func ParseBackupName(backupName string) (instanceId string, timestamp time.Time, err error) {
+// ParseBackupName parses a backup file name with the format
+// <instance_id>-<timestamp>[-<label>].tar[.gz|.zst]. The label is empty if the name
+// does not include it.
+func ParseBackupName(backupName string) (instanceId string, timestamp time.Time, label string, err error) {
 	match := backupFileNameRegex.FindStringSubmatch(backupName)
-	if len(match) != 3 {
-		return "", time.Time{}, fmt.Errorf("%w: %s", ErrInvalidBackupName, backupName)
+	if len(match) != 4 {
+		return "", time.Time{}, "", fmt.Errorf("%w: %s", ErrInvalidBackupName, backupName)
 	}
 	instanceId = match[1]
-	timestampInt, err := strconv.ParseInt(match[2], 10, 64)
+	timestamp, err = parseBackupTimestamp(match[2])
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("%w: %s", ErrInvalidBackupName, backupName)
+		return "", time.Time{}, "", fmt.Errorf("%w: %s", ErrInvalidBackupName, backupName)
 	}
-	timestamp = time.Unix(timestampInt, 0)
-	return instanceId, timestamp, nil
+	label = match[3]
+	return instanceId, timestamp, label, nil
 }
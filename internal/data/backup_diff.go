@@ -0,0 +1,107 @@
+package data
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// backupDataPrefix is the directory of the instance data inside a backup tar.
+const backupDataPrefix = "data/"
+
+// BackupDiff is the difference between the instance data archived in a backup
+// and the live data directory of the instance. The file paths are relative to
+// the data directory and sorted.
+type BackupDiff struct {
+	// Added are the files in the data directory that are not in the backup.
+	Added []string `json:"added"`
+	// Removed are the files in the backup that are not in the data directory.
+	Removed []string `json:"removed"`
+	// Changed are the files whose content in the data directory differs from
+	// the backup.
+	Changed []string `json:"changed"`
+}
+
+// Empty returns true if the backup matches the data directory.
+func (d BackupDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffBackup compares the instance data archived in the backup tar at
+// backupPath with the dataDir directory, by the list of regular files and
+// their SHA-256 checksums. The volumes of the instance services are not
+// compared.
+func DiffBackup(fs afero.Fs, backupPath, dataDir string) (BackupDiff, error) {
+	manifest, err := BuildBackupManifest(fs, backupPath)
+	if err != nil {
+		return BackupDiff{}, err
+	}
+	archived := make(map[string]BackupManifestFile, len(manifest.Files))
+	for _, file := range manifest.Files {
+		if path, ok := strings.CutPrefix(file.Path, backupDataPrefix); ok {
+			archived[path] = file
+		}
+	}
+	live, err := dataDirFiles(fs, dataDir)
+	if err != nil {
+		return BackupDiff{}, err
+	}
+
+	diff := BackupDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for path, file := range live {
+		want, ok := archived[path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, path)
+		case file.Size != want.Size || file.SHA256 != want.SHA256:
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range archived {
+		if _, ok := live[path]; !ok {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff, nil
+}
+
+// dataDirFiles returns the size and checksum of the regular files of the dir
+// directory by their slash separated path relative to dir.
+func dataDirFiles(afs afero.Fs, dir string) (map[string]BackupManifestFile, error) {
+	files := make(map[string]BackupManifestFile)
+	err := afero.Walk(afs, dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := afs.Open(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		defer f.Close()
+		file, err := manifestFile(filepath.ToSlash(relPath), f)
+		if err != nil {
+			return err
+		}
+		files[file.Path] = file
+		return nil
+	})
+	return files, err
+}
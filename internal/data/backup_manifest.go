@@ -0,0 +1,128 @@
+package data
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NethermindEth/eigenlayer/internal/utils"
+	"github.com/spf13/afero"
+)
+
+// BackupManifestName is the name of the integrity manifest inside a backup tar.
+const BackupManifestName = "manifest.json"
+
+// BackupManifest lists every regular file archived in a backup tar, so the
+// integrity of each file can be verified individually.
+type BackupManifest struct {
+	Files []BackupManifestFile `json:"files"`
+}
+
+// BackupManifestFile is a file entry of a BackupManifest.
+type BackupManifestFile struct {
+	// Path is the name of the file inside the backup tar.
+	Path string `json:"path"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA-256 of the file content.
+	SHA256 string `json:"sha256"`
+}
+
+// BuildBackupManifest returns the manifest of the regular files archived in the
+// tar file at src. An existing manifest file is not included.
+func BuildBackupManifest(fs afero.Fs, src string) (*BackupManifest, error) {
+	r, err := openTar(fs, src)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	manifest := &BackupManifest{Files: []BackupManifestFile{}}
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return manifest, nil
+			}
+			return nil, err
+		}
+		name := utils.TarMemberName(header.Name)
+		if header.Typeflag != tar.TypeReg || name == BackupManifestName {
+			continue
+		}
+		file, err := manifestFile(name, tarReader)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+}
+
+// VerifyBackupManifest checks the files archived in the backup tar at src
+// against its manifest. It returns ErrBackupManifestNotFound if the backup has
+// no manifest, and an ErrBackupManifestMismatch error for each file that is
+// missing, modified or not listed in the manifest.
+func VerifyBackupManifest(fs afero.Fs, src string) error {
+	manifestData, err := extractTarFile(fs, src, BackupManifestName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrBackupManifestNotFound, src)
+		}
+		return err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBackupManifest, err)
+	}
+
+	actual, err := BuildBackupManifest(fs, src)
+	if err != nil {
+		return err
+	}
+	archived := make(map[string]BackupManifestFile, len(actual.Files))
+	for _, file := range actual.Files {
+		archived[file.Path] = file
+	}
+
+	var errs []error
+	for _, want := range manifest.Files {
+		want.Path = utils.TarMemberName(want.Path)
+		got, ok := archived[want.Path]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%w: %s is missing", ErrBackupManifestMismatch, want.Path))
+		case got.Size != want.Size:
+			errs = append(errs, fmt.Errorf("%w: %s has size %d, expected %d", ErrBackupManifestMismatch, want.Path, got.Size, want.Size))
+		case got.SHA256 != want.SHA256:
+			errs = append(errs, fmt.Errorf("%w: %s has checksum %s, expected %s", ErrBackupManifestMismatch, want.Path, got.SHA256, want.SHA256))
+		}
+		delete(archived, want.Path)
+	}
+	for _, file := range actual.Files {
+		if _, ok := archived[file.Path]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s is not in the manifest", ErrBackupManifestMismatch, file.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// manifestFile returns the manifest entry of the file with the given name,
+// reading its content from r.
+func manifestFile(name string, r io.Reader) (BackupManifestFile, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return BackupManifestFile{}, err
+	}
+	return BackupManifestFile{
+		Path:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
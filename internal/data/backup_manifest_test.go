@@ -0,0 +1,135 @@
+package data
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createManifestBackup creates a gzip compressed backup with a manifest from
+// a small instance data dir and returns its path.
+func createManifestBackup(t *testing.T, fs afero.Fs) string {
+	t.Helper()
+	dataDir := t.TempDir()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, ".env"), []byte("PORT=8080"), 0o644))
+
+	backupPath := filepath.Join(t.TempDir(), "mock-avs-default-1696317683.tar.gz")
+	backupFile, err := os.Create(backupPath)
+	require.NoError(t, err)
+	defer backupFile.Close()
+	backup, err := CreateBackup(fs, "mock-avs-default", dataDir, backupFile)
+	require.NoError(t, err)
+	assert.True(t, backup.HasManifest)
+	return backupPath
+}
+
+// rewriteTar copies the tar file at src into a plain tar file at dst, passing
+// the content of each regular file through edit.
+func rewriteTar(t *testing.T, fs afero.Fs, src, dst string, edit func(name string, content []byte) []byte) {
+	t.Helper()
+	r, err := openTar(fs, src)
+	require.NoError(t, err)
+	defer r.Close()
+	dstFile, err := fs.Create(dst)
+	require.NoError(t, err)
+	defer dstFile.Close()
+
+	tarReader := tar.NewReader(r)
+	tarWriter := tar.NewWriter(dstFile)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			content = edit(header.Name, content)
+			header.Size = int64(len(content))
+		}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err = tarWriter.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+}
+
+func TestVerifyBackupManifest(t *testing.T) {
+	fs := afero.NewOsFs()
+	backupPath := createManifestBackup(t, fs)
+
+	manifest, err := BuildBackupManifest(fs, backupPath)
+	require.NoError(t, err)
+	var paths []string
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.ElementsMatch(t, []string{"data/state.json", "data/.env", "timestamp"}, paths)
+
+	assert.NoError(t, VerifyBackupManifest(fs, backupPath))
+}
+
+func TestVerifyBackupManifest_Corrupted(t *testing.T) {
+	fs := afero.NewOsFs()
+	backupPath := createManifestBackup(t, fs)
+
+	tests := []struct {
+		name string
+		edit func(name string, content []byte) []byte
+		err  string
+	}{
+		{
+			name: "modified content",
+			edit: func(name string, content []byte) []byte {
+				if name == "data/.env" {
+					return []byte("PORT=9090")
+				}
+				return content
+			},
+			err: "backup manifest mismatch: data/.env has checksum",
+		},
+		{
+			name: "truncated file",
+			edit: func(name string, content []byte) []byte {
+				if name == "data/.env" {
+					return content[:4]
+				}
+				return content
+			},
+			err: "backup manifest mismatch: data/.env has size 4, expected 9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corruptedPath := filepath.Join(t.TempDir(), "mock-avs-default-1696317683.tar")
+			rewriteTar(t, fs, backupPath, corruptedPath, tt.edit)
+
+			err := VerifyBackupManifest(fs, corruptedPath)
+			assert.ErrorIs(t, err, ErrBackupManifestMismatch)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestVerifyBackupManifest_NotFound(t *testing.T) {
+	fs := afero.NewOsFs()
+	tarFile, err := afero.TempFile(fs, t.TempDir(), "backup-*.tar")
+	require.NoError(t, err)
+	defer tarFile.Close()
+	tarWriter := tar.NewWriter(tarFile)
+	tarAddStateJson(t, tarWriter, []byte(`{"name":"mock-avs","tag":"default"}`))
+	tarAddTimestamp(t, tarWriter, time.Unix(1696367916, 0))
+	require.NoError(t, tarWriter.Close())
+
+	err = VerifyBackupManifest(fs, tarFile.Name())
+	assert.ErrorIs(t, err, ErrBackupManifestNotFound)
+}
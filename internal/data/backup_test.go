@@ -2,10 +2,21 @@ package data
 
 import (
 	"archive/tar"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,7 +30,114 @@ func TestBackupId(t *testing.T) {
 		Commit:     "a3406616b848164358fdd24465b8eecda5f5ae34",
 		Url:        "https://github.com/NethermindEth/mock-avs-pkg",
 	}
-	assert.Equal(t, b.Id(), "33de69fe9225b95c8fb909cb418e5102970c8d73")
+	assert.Equal(t, b.Id(), "8e9c1286fa17e5f13e4166fc15851cc0e001f6b8247edfb9981f71b77fc39a98")
+}
+
+func TestBackupMarshalJSON(t *testing.T) {
+	b := Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696367916, 0),
+		Version:    "v5.5.0",
+		Commit:     "a3406616b848164358fdd24465b8eecda5f5ae34",
+		Url:        "https://github.com/NethermindEth/mock-avs-pkg",
+	}
+	got, err := json.Marshal(b)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "8e9c1286fa17e5f13e4166fc15851cc0e001f6b8247edfb9981f71b77fc39a98",
+		"instance_id": "mock-avs-default",
+		"timestamp": "2023-10-03T21:18:36Z",
+		"version": "v5.5.0",
+		"commit": "a3406616b848164358fdd24465b8eecda5f5ae34",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg"
+	}`, string(got))
+
+	// A list of backups is marshalled as a JSON array
+	got, err = json.Marshal([]Backup{b})
+	require.NoError(t, err)
+	var list []map[string]string
+	require.NoError(t, json.Unmarshal(got, &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "8e9c1286fa17e5f13e4166fc15851cc0e001f6b8247edfb9981f71b77fc39a98", list[0]["id"])
+	_, err = time.Parse(time.RFC3339, list[0]["timestamp"])
+	assert.NoError(t, err)
+}
+
+func TestBackupFileName(t *testing.T) {
+	b := Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696317683, 0),
+	}
+	assert.Equal(t, "mock-avs-default-1696317683.tar", b.FileName())
+
+	b.Label = "before-upgrade"
+	assert.Equal(t, "mock-avs-default-1696317683-before-upgrade.tar", b.FileName())
+
+	b.Compression = CompressionZstd
+	assert.Equal(t, "mock-avs-default-1696317683-before-upgrade.tar.zst", b.FileName())
+
+	// The file name can be parsed back
+	instanceId, timestamp, label, err := ParseBackupName(b.FileName())
+	require.NoError(t, err)
+	assert.Equal(t, b.InstanceId, instanceId)
+	assert.True(t, b.Timestamp.Equal(timestamp))
+	assert.Equal(t, b.Label, label)
+
+	// Sub-second timestamps are kept in the file name
+	b.Timestamp = time.Unix(1696317683, 5000)
+	assert.Equal(t, "mock-avs-default-1696317683.000005000-before-upgrade.tar.zst", b.FileName())
+	_, timestamp, _, err = ParseBackupName(b.FileName())
+	require.NoError(t, err)
+	assert.True(t, b.Timestamp.Equal(timestamp))
+}
+
+func TestBackupIdWithinSameSecond(t *testing.T) {
+	a := Backup{InstanceId: "mock-avs-default", Timestamp: time.Unix(1696317683, 100), Version: "v0.1.0"}
+	b := Backup{InstanceId: "mock-avs-default", Timestamp: time.Unix(1696317683, 200), Version: "v0.1.0"}
+	assert.NotEqual(t, a.Id(), b.Id())
+	assert.NotEqual(t, a.FileName(), b.FileName())
+
+	// Whole-second timestamps are formatted without the sub-second part
+	c := Backup{InstanceId: "mock-avs-default", Timestamp: time.Unix(1696317683, 0), Version: "v0.1.0"}
+	h := sha256.Sum256([]byte("mock-avs-default-1696317683-v0.1.0--"))
+	assert.Equal(t, hex.EncodeToString(h[:]), c.Id())
+}
+
+func TestBackupIdContent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	state := []byte(`{"name":"mock-avs","tag":"default","version":"v0.1.0"}`)
+	writeBackup := func(path, content string) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, utils.TarWriteFile(tw, "timestamp", []byte("1696317683"), time.Unix(1696317683, 0)))
+		require.NoError(t, utils.TarWriteFile(tw, "data/state.json", state, time.Unix(1696317683, 0)))
+		require.NoError(t, utils.TarWriteFile(tw, "data/db", []byte(content), time.Unix(1696317683, 0)))
+		require.NoError(t, tw.Close())
+		require.NoError(t, afero.WriteFile(fs, path, buf.Bytes(), 0o644))
+	}
+	writeBackup("/a/mock-avs-default-1696317683.tar", "first")
+	writeBackup("/b/mock-avs-default-1696317683.tar", "second")
+
+	// Same metadata, different contents
+	a, err := BackupFromTar(fs, "/a/mock-avs-default-1696317683.tar")
+	require.NoError(t, err)
+	b, err := BackupFromTar(fs, "/b/mock-avs-default-1696317683.tar")
+	require.NoError(t, err)
+	assert.Equal(t, a.InstanceId, b.InstanceId)
+	assert.True(t, a.Timestamp.Equal(b.Timestamp))
+	assert.NotEqual(t, a.Id(), b.Id())
+	assert.Len(t, a.Id(), 64)
+	assert.NotEmpty(t, a.Checksum)
+
+	// Backups named after their ID keep it, including the SHA-1 IDs of the
+	// backups created before
+	for _, id := range []string{a.Id(), "33de69fe9225b95c8fb909cb418e5102970c8d73"} {
+		require.NoError(t, fs.Rename("/a/mock-avs-default-1696317683.tar", "/a/"+id+".tar"))
+		named, err := BackupFromTar(fs, "/a/"+id+".tar")
+		require.NoError(t, err)
+		assert.Equal(t, id, named.Id())
+		require.NoError(t, fs.Rename("/a/"+id+".tar", "/a/mock-avs-default-1696317683.tar"))
+	}
 }
 
 func TestParseBackupName(t *testing.T) {
@@ -28,6 +146,7 @@ func TestParseBackupName(t *testing.T) {
 		backupName string
 		instanceId string
 		timestamp  time.Time
+		label      string
 		err        error
 	}{
 		{
@@ -37,6 +156,80 @@ func TestParseBackupName(t *testing.T) {
 			timestamp:  time.Unix(1696317683, 0),
 			err:        nil,
 		},
+		{
+			name:       "valid backup name with label",
+			backupName: "mock-avs-default-1696317683-before-upgrade.tar",
+			instanceId: "mock-avs-default",
+			timestamp:  time.Unix(1696317683, 0),
+			label:      "before-upgrade",
+			err:        nil,
+		},
+		{
+			name:       "valid backup name with numeric instance tag and label",
+			backupName: "mock-avs-2-1696317683-daily_1.tar",
+			instanceId: "mock-avs-2",
+			timestamp:  time.Unix(1696317683, 0),
+			label:      "daily_1",
+			err:        nil,
+		},
+		{
+			name:       "valid gzip backup name",
+			backupName: "mock-avs-default-1696317683.tar.gz",
+			instanceId: "mock-avs-default",
+			timestamp:  time.Unix(1696317683, 0),
+			err:        nil,
+		},
+		{
+			name:       "valid zstd backup name with label",
+			backupName: "mock-avs-default-1696317683-before-upgrade.tar.zst",
+			instanceId: "mock-avs-default",
+			timestamp:  time.Unix(1696317683, 0),
+			label:      "before-upgrade",
+			err:        nil,
+		},
+		{
+			name:       "valid backup name with nanoseconds",
+			backupName: "mock-avs-default-1696317683.123456789.tar.gz",
+			instanceId: "mock-avs-default",
+			timestamp:  time.Unix(1696317683, 123456789),
+			err:        nil,
+		},
+		{
+			name:       "valid backup name with nanoseconds and label",
+			backupName: "mock-avs-2-1696317683.000000001-daily_1.tar",
+			instanceId: "mock-avs-2",
+			timestamp:  time.Unix(1696317683, 1),
+			label:      "daily_1",
+			err:        nil,
+		},
+		{
+			name:       "truncated nanoseconds",
+			backupName: "mock-avs-default-1696317683.123.tar",
+			instanceId: "",
+			timestamp:  time.Time{},
+			err:        ErrInvalidBackupName,
+		},
+		{
+			name:       "unsupported compression extension",
+			backupName: "mock-avs-default-1696317683.tar.bz2",
+			instanceId: "",
+			timestamp:  time.Time{},
+			err:        ErrInvalidBackupName,
+		},
+		{
+			name:       "label must start with a letter",
+			backupName: "mock-avs-default-1696317683-1abc.tar",
+			instanceId: "",
+			timestamp:  time.Time{},
+			err:        ErrInvalidBackupName,
+		},
+		{
+			name:       "label with invalid characters",
+			backupName: "mock-avs-default-1696317683-before.upgrade.tar",
+			instanceId: "",
+			timestamp:  time.Time{},
+			err:        ErrInvalidBackupName,
+		},
 		{
 			name:       "no .tar file",
 			backupName: "mock-avs-default-1696317683",
@@ -61,13 +254,14 @@ func TestParseBackupName(t *testing.T) {
 	}
 	for _, tt := range tc {
 		t.Run(tt.name, func(t *testing.T) {
-			instanceId, timestamp, err := ParseBackupName(tt.backupName)
+			instanceId, timestamp, label, err := ParseBackupName(tt.backupName)
 			if tt.err != nil {
 				assert.ErrorIs(t, err, tt.err)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.instanceId, instanceId)
-				assert.Equal(t, tt.timestamp.Unix(), timestamp.Unix())
+				assert.True(t, tt.timestamp.Equal(timestamp), "want %s, got %s", tt.timestamp, timestamp)
+				assert.Equal(t, tt.label, label)
 			}
 		})
 	}
@@ -155,6 +349,8 @@ func TestBackupFromTar(t *testing.T) {
 	require.NotNil(t, b)
 	assert.Equal(t,
 		Backup{
+			path:       backupTar.Name(),
+			fs:         fs,
 			InstanceId: "mock-avs-default",
 			Timestamp:  timestamp,
 			Version:    "v5.5.0",
@@ -164,6 +360,43 @@ func TestBackupFromTar(t *testing.T) {
 		*b)
 }
 
+func TestBackupSizeAndAge(t *testing.T) {
+	fs := afero.NewOsFs()
+	dataDir := t.TempDir()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, ".env"), []byte("MAIN_PORT=8080\n"), 0o644))
+
+	backupsDir := t.TempDir()
+	compressedPath := filepath.Join(backupsDir, "mock-avs-default-1696317683.tar.gz")
+	compressedFile, err := fs.Create(compressedPath)
+	require.NoError(t, err)
+	_, err = CreateBackup(fs, "mock-avs-default", dataDir, compressedFile)
+	require.NoError(t, err)
+	require.NoError(t, compressedFile.Close())
+	plainPath := filepath.Join(backupsDir, "mock-avs-default-1696317683.tar")
+	require.NoError(t, DecompressTar(fs, compressedPath, plainPath))
+
+	for _, path := range []string{compressedPath, plainPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			b, err := BackupFromTar(fs, path)
+			require.NoError(t, err)
+
+			// The size is the one of the file as it is stored
+			stat, err := fs.Stat(path)
+			require.NoError(t, err)
+			size, err := b.Size(fs)
+			require.NoError(t, err)
+			assert.Equal(t, stat.Size(), size)
+
+			assert.Positive(t, b.Age())
+		})
+	}
+
+	// The size of a backup not loaded from a file is unknown
+	_, err = (&Backup{InstanceId: "mock-avs-default", Timestamp: time.Now()}).Size(fs)
+	assert.ErrorIs(t, err, ErrBackupFileUnknown)
+}
+
 func TestLoadBackupTarStateJson(t *testing.T) {
 	fs := afero.NewOsFs()
 	tarFile, err := afero.TempFile(fs, t.TempDir(), "backup-*.tar")
@@ -197,7 +430,7 @@ func TestLoadBackupTarStateJson(t *testing.T) {
 		}
 	  }
 	`))
-	got, err := loadBackupTarStateJson(fs, tarFile.Name())
+	got, err := loadBackupTarStateJson(fs, tarFile.Name(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, got)
 	assert.Equal(t, Instance{
@@ -227,6 +460,58 @@ func TestLoadBackupTarStateJson(t *testing.T) {
 	}, *got)
 }
 
+func TestLoadBackupTarStateJsonIncompatible(t *testing.T) {
+	tc := []struct {
+		name  string
+		state string
+		err   error
+	}{
+		{
+			name:  "extra fields",
+			state: `{"name":"mock-avs","tag":"default","version":"v5.5.0","url":"https://github.com/NethermindEth/mock-avs-pkg","new_field":"value"}`,
+		},
+		{
+			name:  "legacy state without version",
+			state: `{"name":"mock-avs","tag":"default","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`,
+		},
+		{
+			name:  "empty id",
+			state: `{"instance_name":"mock-avs","instance_tag":"default","version":"v5.5.0"}`,
+			err:   ErrIncompatibleBackup,
+		},
+		{
+			name:  "empty version and commit",
+			state: `{"name":"mock-avs","tag":"default","package_version":"v5.5.0"}`,
+			err:   ErrIncompatibleBackup,
+		},
+		{
+			name:  "newer state version",
+			state: `{"state_version":2,"name":"mock-avs","tag":"default","version":"v5.5.0"}`,
+			err:   ErrIncompatibleBackup,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewOsFs()
+			tarFile, err := afero.TempFile(fs, t.TempDir(), "backup-*.tar")
+			require.NoError(t, err)
+			defer tarFile.Close()
+			tarWriter := tar.NewWriter(tarFile)
+			tarAddStateJson(t, tarWriter, []byte(tt.state))
+			require.NoError(t, tarWriter.Close())
+
+			got, err := loadBackupTarStateJson(fs, tarFile.Name(), nil)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "mock-avs-default", got.ID())
+		})
+	}
+}
+
 func TestLoadBackupTarTimestamp(t *testing.T) {
 	fs := afero.NewOsFs()
 	tarFile, err := afero.TempFile(fs, t.TempDir(), "backup-*.tar")
@@ -235,8 +520,304 @@ func TestLoadBackupTarTimestamp(t *testing.T) {
 	tarWriter := tar.NewWriter(tarFile)
 	timestamp := time.Unix(1696367916, 0)
 	tarAddTimestamp(t, tarWriter, timestamp)
-	got, err := loadBackupTarTimestamp(fs, tarFile.Name())
+	got, err := loadBackupTarTimestamp(fs, tarFile.Name(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, got)
 	assert.True(t, timestamp.Equal(got))
 }
+
+func TestBackupTarWindowsNames(t *testing.T) {
+	// A backup created on Windows, with backslash-separated member names
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/backups", 0o755))
+	tarFile, err := fs.Create("/backups/backup.tar")
+	require.NoError(t, err)
+	tw := tar.NewWriter(tarFile)
+	for _, entry := range []struct{ name, content string }{
+		{name: `data\state.json`, content: `{"name":"mock-avs","tag":"default","version":"v0.1.0","url":"https://github.com/NethermindEth/mock-avs-pkg"}`},
+		{name: `data\config\.env`, content: "MAIN_PORT=8080\n"},
+		{name: `.\timestamp`, content: "1696367916"},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: entry.name, Mode: 0o644, Size: int64(len(entry.content))}))
+		_, err = tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, tarFile.Close())
+
+	backup, err := BackupFromTar(fs, "/backups/backup.tar")
+	require.NoError(t, err)
+	assert.Equal(t, "mock-avs-default", backup.InstanceId)
+	assert.Equal(t, "v0.1.0", backup.Version)
+	assert.True(t, time.Unix(1696367916, 0).Equal(backup.Timestamp))
+
+	require.NoError(t, extractTarDir(fs, "/backups/backup.tar", "data", "/restore"))
+	env, err := afero.ReadFile(fs, "/restore/config/.env")
+	require.NoError(t, err)
+	assert.Equal(t, "MAIN_PORT=8080\n", string(env))
+
+	manifest, err := BuildBackupManifest(fs, "/backups/backup.tar")
+	require.NoError(t, err)
+	var paths []string
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"data/state.json", "data/config/.env", "timestamp"}, paths)
+}
+
+func TestCreateBackupSameSecond(t *testing.T) {
+	fs := afero.NewOsFs()
+	dataDir := t.TempDir()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`), 0o644))
+
+	// Two backups in quick succession, most likely within the same second
+	backupsDir := t.TempDir()
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		backup, err := CreateBackup(fs, "mock-avs-default", dataDir, &buf)
+		require.NoError(t, err)
+		ids[backup.Id()] = true
+		names[backup.FileName()] = true
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(backupsDir, backup.FileName()), buf.Bytes(), 0o644))
+
+		// The id is kept when the backup is read back
+		loaded, err := BackupFromTar(fs, filepath.Join(backupsDir, backup.FileName()))
+		require.NoError(t, err)
+		assert.Equal(t, backup.Id(), loaded.Id())
+	}
+	assert.Len(t, ids, 2)
+	assert.Len(t, names, 2)
+	entries, err := os.ReadDir(backupsDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestCreateBackup(t *testing.T) {
+	const (
+		fileCount = 16
+		fileSize  = 1 << 20 // 1 MiB
+	)
+	fs := afero.NewOsFs()
+
+	// Synthetic instance data dir with incompressible files
+	dataDir := t.TempDir()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`), 0o644))
+	require.NoError(t, fs.MkdirAll(filepath.Join(dataDir, "db"), 0o755))
+	chunk := make([]byte, fileSize)
+	for i := 0; i < fileCount; i++ {
+		_, err := rand.Read(chunk)
+		require.NoError(t, err)
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "db", fmt.Sprintf("chunk-%d", i)), chunk, 0o644))
+	}
+	lastChunk := append([]byte(nil), chunk...)
+
+	backupPath := filepath.Join(t.TempDir(), "mock-avs-default-1696317683.tar.gz")
+	backupFile, err := os.Create(backupPath)
+	require.NoError(t, err)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	backup, err := CreateBackup(fs, "mock-avs-default", dataDir, backupFile)
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+	require.NoError(t, backupFile.Close())
+
+	// The backup is streamed, so the allocated memory is far less than the
+	// size of the data
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(fileCount*fileSize/4))
+
+	assert.Equal(t, "mock-avs-default", backup.InstanceId)
+	assert.Equal(t, "v0.1.0", backup.Version)
+	assert.Equal(t, "a3406616b848164358fdd24465b8eecda5f5ae34", backup.Commit)
+	assert.Equal(t, "https://github.com/NethermindEth/mock-avs-pkg", backup.Url)
+	assert.Equal(t, CompressionGzip, backup.Compression)
+
+	// The checksum is the one of the written file
+	backupData, err := os.Open(backupPath)
+	require.NoError(t, err)
+	defer backupData.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, backupData)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(h.Sum(nil)), backup.Checksum)
+
+	// The backup can be read back
+	loaded, err := BackupFromTar(fs, backupPath)
+	require.NoError(t, err)
+	assert.True(t, backup.Timestamp.Equal(loaded.Timestamp))
+	assert.Equal(t, backup.Id(), loaded.Id())
+	assert.Equal(t, backup.Version, loaded.Version)
+	content, err := extractTarFile(fs, backupPath, fmt.Sprintf("data/db/chunk-%d", fileCount-1))
+	require.NoError(t, err)
+	assert.Equal(t, lastChunk, content)
+}
+
+func TestReadBackupFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	state := []byte(`{"name":"mock-avs","tag":"default","version":"v0.1.0"}`)
+	var plain bytes.Buffer
+	tw := tar.NewWriter(&plain)
+	require.NoError(t, utils.TarWriteFile(tw, "timestamp", []byte("1696317683"), time.Unix(1696317683, 0)))
+	require.NoError(t, utils.TarWriteFile(tw, "data/state.json", state, time.Unix(1696317683, 0)))
+	require.NoError(t, tw.Close())
+	require.NoError(t, afero.WriteFile(fs, "/backups/backup.tar", plain.Bytes(), 0o644))
+	require.NoError(t, CompressTar(fs, "/backups/backup.tar", "/backups/backup.tar.gz", CompressionGzip))
+	require.NoError(t, CompressTar(fs, "/backups/backup.tar", "/backups/backup.tar.zst", CompressionZstd))
+	keys := staticKeyProvider("secret key")
+	require.NoError(t, EncryptBackup(fs, "/backups/backup.tar", "/backups/backup.tar.enc", keys))
+	require.NoError(t, EncryptBackup(fs, "/backups/backup.tar.gz", "/backups/backup.tar.gz.enc", keys))
+	require.NoError(t, EncryptBackup(fs, "/backups/backup.tar.zst", "/backups/backup.tar.zst.enc", keys))
+	// The format is detected from the content, not the file name
+	require.NoError(t, fs.Rename("/backups/backup.tar.zst.enc", "/backups/backup"))
+
+	tc := []struct {
+		name string
+		path string
+		keys KeyProvider
+		err  error
+	}{
+		{name: "plain", path: "/backups/backup.tar"},
+		{name: "gzip", path: "/backups/backup.tar.gz"},
+		{name: "zstd", path: "/backups/backup.tar.zst"},
+		{name: "encrypted", path: "/backups/backup.tar.enc", keys: keys},
+		{name: "encrypted gzip", path: "/backups/backup.tar.gz.enc", keys: keys},
+		{name: "encrypted zstd without extension", path: "/backups/backup", keys: keys},
+		{name: "plain with key", path: "/backups/backup.tar.gz", keys: keys},
+		{name: "encrypted without key", path: "/backups/backup.tar.gz.enc", err: ErrBackupEncrypted},
+		{name: "encrypted with wrong key", path: "/backups/backup.tar.gz.enc", keys: staticKeyProvider("other key"), err: ErrDecryptingBackup},
+		{name: "missing backup", path: "/backups/missing.tar", err: os.ErrNotExist},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got []byte
+				err error
+			)
+			if tt.keys == nil {
+				got, err = ReadBackupFile(fs, tt.path, "data/state.json")
+			} else {
+				got, err = ReadEncryptedBackupFile(fs, tt.path, "data/state.json", tt.keys)
+			}
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, state, got)
+
+			_, err = ReadEncryptedBackupFile(fs, tt.path, "data/missing.json", tt.keys)
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+// writingFs is an afero.Fs that runs onOpen right after a file is opened,
+// simulating an instance that writes its data while it is backed up.
+type writingFs struct {
+	afero.Fs
+	onOpen func(name string)
+}
+
+func (w writingFs) Open(name string) (afero.File, error) {
+	f, err := w.Fs.Open(name)
+	if err == nil {
+		w.onOpen(name)
+	}
+	return f, err
+}
+
+func TestCreateBackupHot(t *testing.T) {
+	osFs := afero.NewOsFs()
+	dataDir := t.TempDir()
+	require.NoError(t, afero.WriteFile(osFs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`), 0o644))
+	require.NoError(t, osFs.MkdirAll(filepath.Join(dataDir, "db"), 0o755))
+	for _, name := range []string{"a", "head", "tmp", "wal"} {
+		require.NoError(t, afero.WriteFile(osFs, filepath.Join(dataDir, "db", name), bytes.Repeat([]byte(name), 1024), 0o644))
+	}
+
+	fs := writingFs{Fs: osFs, onOpen: func(name string) {
+		switch filepath.Base(name) {
+		case "a":
+			// A temporary file is removed after it is listed
+			require.NoError(t, osFs.Remove(filepath.Join(dataDir, "db", "tmp")))
+		case "head":
+			// A file grows while it is archived
+			f, err := osFs.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o644)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("appended"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		case "wal":
+			// A file shrinks while it is archived
+			require.NoError(t, os.Truncate(name, 10))
+		}
+	}}
+
+	backupPath := filepath.Join(t.TempDir(), "mock-avs-default-1696317683.tar.gz")
+	backupFile, err := os.Create(backupPath)
+	require.NoError(t, err)
+	_, err = CreateBackup(fs, "mock-avs-default", dataDir, backupFile)
+	require.NoError(t, err)
+	require.NoError(t, backupFile.Close())
+
+	// The hot backup is still a readable tar matching its manifest
+	_, err = BackupFromTar(osFs, backupPath)
+	require.NoError(t, err)
+	require.NoError(t, VerifyBackupManifest(osFs, backupPath))
+
+	_, err = extractTarFile(osFs, backupPath, "data/db/tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	head, err := extractTarFile(osFs, backupPath, "data/db/head")
+	require.NoError(t, err)
+	assert.Equal(t, 4*1024+len("appended"), len(head))
+	wal, err := extractTarFile(osFs, backupPath, "data/db/wal")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("walwalwalw"), wal)
+}
+
+func TestCreateBackupMissingState(t *testing.T) {
+	_, err := CreateBackup(afero.NewMemMapFs(), "mock-avs-default", "/nodes/mock-avs-default", io.Discard)
+	assert.ErrorIs(t, err, ErrCreatingBackup)
+}
+
+func TestDiffBackup(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	dataDir := "/egn/nodes/mock-avs-default"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, ".env"), []byte("MAIN_PORT=8080\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "db", "chunk-0"), []byte("chunk 0"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "db", "chunk-1"), []byte("chunk 1"), 0o644))
+
+	backupPath := "/egn/backup/mock-avs-default-1696317683.tar.gz"
+	backupFile, err := fs.Create(backupPath)
+	require.NoError(t, err)
+	_, err = CreateBackup(fs, "mock-avs-default", dataDir, backupFile)
+	require.NoError(t, err)
+	require.NoError(t, backupFile.Close())
+
+	// The backup matches the data it was taken from
+	diff, err := DiffBackup(fs, backupPath, dataDir)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	// Change a file keeping its size, grow another, and remove and add files
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, ".env"), []byte("MAIN_PORT=9090\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "db", "chunk-1"), []byte("chunk 1 appended"), 0o644))
+	require.NoError(t, fs.Remove(filepath.Join(dataDir, "db", "chunk-0")))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "db", "chunk-2"), []byte("chunk 2"), 0o644))
+
+	diff, err = DiffBackup(fs, backupPath, dataDir)
+	require.NoError(t, err)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, BackupDiff{
+		Added:   []string{"db/chunk-2"},
+		Removed: []string{"db/chunk-0"},
+		Changed: []string{".env", "db/chunk-1"},
+	}, diff)
+
+	_, err = DiffBackup(fs, "/egn/backup/mock-avs-default-1696317684.tar.gz", dataDir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
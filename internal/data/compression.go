@@ -0,0 +1,241 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/eigenlayer/internal/utils"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+)
+
+// Compression is the codec used to compress a backup tar file.
+type Compression string
+
+const (
+	// CompressionNone stores the backup as a plain tar file.
+	CompressionNone Compression = ""
+	// CompressionGzip compresses the backup tar file with gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses the backup tar file with zstd.
+	CompressionZstd Compression = "zstd"
+)
+
+const (
+	tarExt     = ".tar"
+	tarGzipExt = ".tar.gz"
+	tarZstdExt = ".tar.zst"
+)
+
+// ParseCompression parses the name of a compression codec. Both an empty
+// string and "none" are parsed as CompressionNone.
+func ParseCompression(name string) (Compression, error) {
+	switch name {
+	case "", "none":
+		return CompressionNone, nil
+	case string(CompressionGzip):
+		return CompressionGzip, nil
+	case string(CompressionZstd):
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, fmt.Errorf("%w: %s", ErrInvalidCompression, name)
+	}
+}
+
+// String returns the name of the compression codec.
+func (c Compression) String() string {
+	if c == CompressionNone {
+		return "none"
+	}
+	return string(c)
+}
+
+// Extension returns the file extension of a tar file compressed with the codec.
+func (c Compression) Extension() string {
+	switch c {
+	case CompressionGzip:
+		return tarGzipExt
+	case CompressionZstd:
+		return tarZstdExt
+	default:
+		return tarExt
+	}
+}
+
+// compressionFromPath returns the compression codec of the tar file at the
+// given path based on its extension. The second return value is false if the
+// path is not a tar file.
+func compressionFromPath(path string) (Compression, bool) {
+	switch {
+	case strings.HasSuffix(path, tarGzipExt):
+		return CompressionGzip, true
+	case strings.HasSuffix(path, tarZstdExt):
+		return CompressionZstd, true
+	case strings.HasSuffix(path, tarExt):
+		return CompressionNone, true
+	default:
+		return CompressionNone, false
+	}
+}
+
+// CompressTar compresses the tar file src into dst using the given codec.
+func CompressTar(fs afero.Fs, src, dst string, c Compression) (err error) {
+	srcFile, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := fs.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := dstFile.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	var w io.WriteCloser
+	switch c {
+	case CompressionNone:
+		_, err = io.Copy(dstFile, srcFile)
+		return err
+	case CompressionGzip:
+		w = gzip.NewWriter(dstFile)
+	case CompressionZstd:
+		w, err = zstd.NewWriter(dstFile)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidCompression, c)
+	}
+	if _, err = io.Copy(w, srcFile); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// DecompressTar writes the plain tar content of the tar file src into dst.
+// The compression codec of src is detected from its extension.
+func DecompressTar(fs afero.Fs, src, dst string) (err error) {
+	r, err := openTar(fs, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	dstFile, err := fs.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := dstFile.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(dstFile, r)
+	return err
+}
+
+// extractTarFile returns the content of the file with the given name from
+// the tar file at tarPath, decompressing it if needed.
+func extractTarFile(fs afero.Fs, tarPath, name string) ([]byte, error) {
+	r, err := openTar(fs, tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := utils.TarReadFile(r, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, tarPath)
+	}
+	return content, nil
+}
+
+// openTar opens the tar file at the given path and returns a reader of its
+// plain tar content.
+func openTar(fs afero.Fs, path string) (io.ReadCloser, error) {
+	c, ok := compressionFromPath(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackupName, path)
+	}
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch c {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: gr, closers: []func() error{gr.Close, f.Close}}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []func() error{func() error { zr.Close(); return nil }, f.Close}}, nil
+	default:
+		return f, nil
+	}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// sniffDecompress returns a reader of the plain tar content read from r. The
+// compression codec is detected from the magic bytes at the start of the
+// content instead of a file extension.
+func sniffDecompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressReader{Reader: gr, closers: []func() error{gr.Close}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []func() error{func() error { zr.Close(); return nil }}}, nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// decompressReader reads from a decompressor and closes it along with the
+// underlying file.
+type decompressReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (r *decompressReader) Close() error {
+	var errs []error
+	for _, closer := range r.closers {
+		errs = append(errs, closer())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package data
+
+import (
+	"archive/tar"
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompression(t *testing.T) {
+	tc := []struct {
+		name        string
+		compression string
+		want        Compression
+		err         error
+	}{
+		{name: "empty", compression: "", want: CompressionNone},
+		{name: "none", compression: "none", want: CompressionNone},
+		{name: "gzip", compression: "gzip", want: CompressionGzip},
+		{name: "zstd", compression: "zstd", want: CompressionZstd},
+		{name: "unsupported", compression: "bzip2", err: ErrInvalidCompression},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompression(tt.compression)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompressTarRoundTrip(t *testing.T) {
+	state := []byte(`{
+		"name": "mock-avs",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg",
+		"version": "v5.5.0",
+		"spec_version": "v0.1.0",
+		"commit": "a3406616b848164358fdd24465b8eecda5f5ae34",
+		"profile": "option-returner",
+		"tag": "default"
+	}`)
+	timestamp := time.Unix(1696367916, 0)
+
+	tc := []struct {
+		name        string
+		compression Compression
+		ext         string
+	}{
+		{name: "none", compression: CompressionNone, ext: ".tar"},
+		{name: "gzip", compression: CompressionGzip, ext: ".tar.gz"},
+		{name: "zstd", compression: CompressionZstd, ext: ".tar.zst"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewOsFs()
+			dir := t.TempDir()
+
+			// Build plain backup tar
+			var tarData bytes.Buffer
+			tarWriter := tar.NewWriter(&tarData)
+			tarAddStateJson(t, tarWriter, state)
+			tarAddTimestamp(t, tarWriter, timestamp)
+			require.NoError(t, tarWriter.Close())
+			tarPath := filepath.Join(dir, "backup.tar")
+			require.NoError(t, afero.WriteFile(fs, tarPath, tarData.Bytes(), 0o644))
+
+			// Compress
+			assert.Equal(t, tt.ext, tt.compression.Extension())
+			compressedPath := filepath.Join(dir, "mock-avs-default-1696367916"+tt.compression.Extension())
+			require.NoError(t, CompressTar(fs, tarPath, compressedPath, tt.compression))
+
+			// Load backup information from the compressed tar
+			b, err := BackupFromTar(fs, compressedPath)
+			require.NoError(t, err)
+			assert.Equal(t, "mock-avs-default", b.InstanceId)
+			assert.True(t, timestamp.Equal(b.Timestamp))
+			assert.Equal(t, "v5.5.0", b.Version)
+			assert.Equal(t, tt.compression, b.Compression)
+
+			// Decompress
+			decompressedPath := filepath.Join(dir, "decompressed.tar")
+			require.NoError(t, DecompressTar(fs, compressedPath, decompressedPath))
+			got, err := afero.ReadFile(fs, decompressedPath)
+			require.NoError(t, err)
+			assert.Equal(t, tarData.Bytes(), got)
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package data
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/NethermindEth/docker-volumes-snapshotter/pkg/backuptar"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
@@ -18,6 +20,7 @@ const (
 	tempDir      = "temp"
 	pluginsDir   = "plugin"
 	backupDir    = "backup"
+	cacheDir     = "cache"
 )
 
 const monitoringStackDirName = "monitoring"
@@ -27,6 +30,12 @@ type DataDir struct {
 	path   string
 	fs     afero.Fs
 	locker locker.Locker
+	// backupPath overrides the directory where the backups are stored. If it
+	// is empty, the backups are stored in the backup directory of the data dir.
+	backupPath string
+	// monitoringPerms are the permissions of the monitoring stacks returned
+	// by MonitoringStack.
+	monitoringPerms StackPermissions
 }
 
 // NewDataDir creates a new DataDir instance with the given path as root.
@@ -43,20 +52,19 @@ func (d *DataDir) Path() string {
 	return d.path
 }
 
-// NewDataDirDefault creates a new DataDir instance with the default path as root.
-// Default path is $XDG_DATA_HOME/.eigen or $HOME/.local/share/.eigen if $XDG_DATA_HOME is not set
-// as defined in the XDG Base Directory Specification
+// Fs returns the file system of the data directory.
+func (d *DataDir) Fs() afero.Fs {
+	return d.fs
+}
+
+// NewDataDirDefault creates a new DataDir instance with the default path as root,
+// located by LocateDataDir. The directory is created if it does not exist.
 func NewDataDirDefault(fs afero.Fs, locker locker.Locker) (*DataDir, error) {
-	userDataHome := os.Getenv("XDG_DATA_HOME")
-	if userDataHome == "" {
-		userHome, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		userDataHome = filepath.Join(userHome, ".local", "share")
+	dataDir, err := LocateDataDir()
+	if err != nil {
+		return nil, err
 	}
-	dataDir := filepath.Join(userDataHome, ".eigen")
-	err := fs.MkdirAll(dataDir, 0o755)
+	err = fs.MkdirAll(dataDir, 0o755)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +122,15 @@ func (d *DataDir) InstancePath(instanceId string) (string, error) {
 	return instancePath, nil
 }
 
+// ReplaceInstanceDirFromTar replaces the instance dir with the given id with
+// the srcPath directory of the tar file at tarPath. If the tar has entries that
+// would be extracted outside the instance dir, an ErrUnsafeTarEntry error is
+// returned and the instance dir is left untouched.
 func (d *DataDir) ReplaceInstanceDirFromTar(instanceId, tarPath, srcPath string) error {
+	// Reject unsafe tars before touching the current instance dir
+	if err := checkTarDir(d.fs, tarPath, srcPath); err != nil {
+		return err
+	}
 	// Clear instance dir
 	instancePath := filepath.Join(d.path, nodesDirName, instanceId)
 	err := d.fs.RemoveAll(instancePath)
@@ -126,7 +142,7 @@ func (d *DataDir) ReplaceInstanceDirFromTar(instanceId, tarPath, srcPath string)
 	if err != nil {
 		return err
 	}
-	return backuptar.ExtractDir(tarPath, srcPath, instancePath)
+	return extractTarDir(d.fs, tarPath, srcPath, instancePath)
 }
 
 // RemoveInstance removes the instance with the given id.
@@ -170,6 +186,18 @@ func (d *DataDir) RemoveTemp(id string) error {
 	return d.fs.RemoveAll(filepath.Join(d.path, tempDir, id))
 }
 
+// PackageCache returns the cache of downloaded packages stored in the data
+// directory, limited by the given policy.
+func (d *DataDir) PackageCache(policy package_handler.CachePolicy) *package_handler.PackageCache {
+	return package_handler.NewPackageCache(filepath.Join(d.path, cacheDir, "packages"), d.fs, policy)
+}
+
+// VersionsCache returns the cache of the versions of the package repositories,
+// stored in the cache directory of the data dir.
+func (d *DataDir) VersionsCache(maxAge time.Duration) *package_handler.VersionsCache {
+	return package_handler.NewVersionsCache(filepath.Join(d.path, cacheDir, "versions"), d.fs, maxAge)
+}
+
 // TempPath returns the path to the temporary directory with the given id.
 func (d *DataDir) TempPath(id string) (string, error) {
 	tempPath := filepath.Join(d.path, tempDir, id)
@@ -186,21 +214,34 @@ func (d *DataDir) TempPath(id string) (string, error) {
 	return tempPath, nil
 }
 
-// BackupList returns the list of paths to all the backups.
+// BackupList returns the list of paths to all the backups. The backups are only
+// read, so they can be listed from a read-only filesystem. If the backup
+// directory does not exist, there are no backups.
 func (d *DataDir) BackupList() ([]Backup, error) {
-	err := d.initBackupDir()
-	if err != nil {
-		return nil, err
-	}
+	return d.EncryptedBackupList(nil)
+}
+
+// EncryptedBackupList returns the list of all the backups like BackupList,
+// loading the metadata of the encrypted backups with the key of the given
+// provider. The encrypted backups that can't be decrypted with the key, like
+// the ones encrypted with another key, are listed without their metadata.
+func (d *DataDir) EncryptedBackupList(keys KeyProvider) ([]Backup, error) {
 	backupFiles, err := afero.ReadDir(d.fs, d.backupsDir())
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
 	var backups []Backup
 	for _, backupFile := range backupFiles {
-		if !backupFile.IsDir() && filepath.Ext(backupFile.Name()) == ".tar" {
-			b, err := BackupFromTar(d.fs, filepath.Join(d.backupsDir(), backupFile.Name()))
+		if _, ok := compressionFromPath(backupFile.Name()); !backupFile.IsDir() && ok {
+			path := filepath.Join(d.backupsDir(), backupFile.Name())
+			b, err := BackupFromEncryptedTar(d.fs, path, keys)
+			if errors.Is(err, ErrDecryptingBackup) {
+				b, err = BackupFromTar(d.fs, path)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -234,6 +275,24 @@ func (d *DataDir) Backup(backupId string) (*Backup, error) {
 	return nil, ErrBackupNotFound
 }
 
+// EncryptedBackup returns the backup with the given id like Backup, loading
+// the metadata of an encrypted backup with the key of the given provider. If
+// the backup is encrypted and the provider is nil, an ErrBackupEncrypted error
+// is returned.
+func (d *DataDir) EncryptedBackup(backupId string, keys KeyProvider) (*Backup, error) {
+	backup, err := d.Backup(backupId)
+	if err != nil {
+		return nil, err
+	}
+	if !backup.Encrypted {
+		return backup, nil
+	}
+	if keys == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBackupEncrypted, backupId)
+	}
+	return BackupFromEncryptedTar(d.fs, backup.path, keys)
+}
+
 // HasBackup returns true if the backup with the given id exists.
 func (d *DataDir) HasBackup(backupId string) (bool, error) {
 	_, err := d.fs.Stat(d.BackupPath(backupId))
@@ -246,13 +305,99 @@ func (d *DataDir) HasBackup(backupId string) (bool, error) {
 	return true, nil
 }
 
-// BackupPath returns the path to the backup with the given id.
+// BackupPath returns the path to the backup with the given id. If the backup
+// has been compressed, the path to the compressed tar file is returned.
 func (d *DataDir) BackupPath(backupId string) string {
-	return filepath.Join(d.path, backupDir, backupId+".tar")
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		compressedPath := filepath.Join(d.backupsDir(), backupId+c.Extension())
+		if ok, err := afero.Exists(d.fs, compressedPath); err == nil && ok {
+			return compressedPath
+		}
+	}
+	return filepath.Join(d.backupsDir(), backupId+CompressionNone.Extension())
+}
+
+// CreateBackup writes a gzip compressed backup of the data of the instance with
+// the given id to w. See CreateBackup for the details.
+func (d *DataDir) CreateBackup(instanceId string, w io.Writer) (*Backup, error) {
+	instancePath, err := d.InstancePath(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	return CreateBackup(d.fs, instanceId, instancePath, w)
+}
+
+// CompressBackup compresses the plain tar file of the backup with the given
+// id using the given codec, replacing the plain tar file.
+func (d *DataDir) CompressBackup(backupId string, c Compression) error {
+	if c == CompressionNone {
+		return nil
+	}
+	src := filepath.Join(d.backupsDir(), backupId+CompressionNone.Extension())
+	dst := filepath.Join(d.backupsDir(), backupId+c.Extension())
+	if err := CompressTar(d.fs, src, dst, c); err != nil {
+		d.fs.Remove(dst)
+		return fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	return d.fs.Remove(src)
+}
+
+// EncryptBackup encrypts the tar file of the backup with the given id with
+// the key of the given provider, replacing the plain tar file. The file keeps
+// its name, as encrypted backups are detected from their content.
+func (d *DataDir) EncryptBackup(backupId string, keys KeyProvider) error {
+	src := d.BackupPath(backupId)
+	// The temporary file is not listed with the backups, as its extension is
+	// not one of a backup
+	dst := src + ".enc"
+	if err := EncryptBackup(d.fs, src, dst, keys); err != nil {
+		d.fs.Remove(dst)
+		return fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	if err := d.fs.Rename(dst, src); err != nil {
+		d.fs.Remove(dst)
+		return fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	return nil
+}
+
+// FinalizeBackup sets the checksum of the backup from its file, once the
+// backup is complete, and renames the file after the resulting ID, which
+// includes the checksum. The backup is named after an ID computed without
+// its checksum until then, see InitBackup.
+func (d *DataDir) FinalizeBackup(b *Backup) error {
+	src := d.BackupPath(b.Id())
+	checksum, err := fileChecksum(d.fs, src)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	b.Checksum, b.id = checksum, ""
+	c, _ := compressionFromPath(src)
+	dst := filepath.Join(d.backupsDir(), b.Id()+c.Extension())
+	if err = d.fs.Rename(src, dst); err != nil {
+		return fmt.Errorf("%w: %w", ErrCreatingBackup, err)
+	}
+	b.path, b.fs = dst, d.fs
+	return nil
+}
+
+// RemoveBackup removes the tar file of the backup with the given id. If the
+// backup does not exist, an ErrBackupNotFound error is returned.
+func (d *DataDir) RemoveBackup(backupId string) error {
+	ok, err := d.HasBackup(backupId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBackupNotFound, backupId)
+	}
+	return d.fs.Remove(d.BackupPath(backupId))
 }
 
 // InitBackup initialized a new backup. If a backup with the same id already
-// exists, an ErrBackupAlreadyExists error is returned.
+// exists, an ErrBackupAlreadyExists error is returned. The backup content is
+// not known yet, so the backup file is named after an ID without checksum
+// until FinalizeBackup is called.
 func (d *DataDir) InitBackup(b *Backup) error {
 	// Check if backup already exists
 	exists, err := d.HasBackup(b.Id())
@@ -272,7 +417,30 @@ func (d *DataDir) InitBackup(b *Backup) error {
 	// return utils.TarInit(d.fs, d.BackupPath(b.Id()))
 }
 
+// SetBackupDir sets the directory where the backups are stored instead of the
+// backup directory of the data dir.
+func (d *DataDir) SetBackupDir(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	d.backupPath = absPath
+	return nil
+}
+
+// BackupDir returns the path to the directory where the backups are stored,
+// creating it if it does not exist.
+func (d *DataDir) BackupDir() (string, error) {
+	if err := d.initBackupDir(); err != nil {
+		return "", err
+	}
+	return d.backupsDir(), nil
+}
+
 func (d *DataDir) backupsDir() string {
+	if d.backupPath != "" {
+		return d.backupPath
+	}
 	return filepath.Join(d.path, backupDir)
 }
 
@@ -291,6 +459,40 @@ func (d *DataDir) initBackupDir() error {
 	return nil
 }
 
+// CheckPermissions checks that files can be created in the data dir and in its
+// existing nodes, backup and monitoring directories, by creating and removing
+// a probe file in each of them. It returns an ErrDataDirNotWritable error with
+// the first directory that is not writable.
+func (d *DataDir) CheckPermissions() error {
+	dirs := []string{
+		d.path,
+		filepath.Join(d.path, nodesDirName),
+		d.backupsDir(),
+		filepath.Join(d.path, monitoringStackDirName),
+	}
+	for i, dir := range dirs {
+		// The data dir must exist, the rest are created when needed
+		if i > 0 {
+			ok, err := afero.DirExists(d.fs, dir)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrDataDirNotWritable, dir, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		probe, err := afero.TempFile(d.fs, dir, ".egn-probe-*")
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrDataDirNotWritable, dir, err)
+		}
+		probe.Close()
+		if err = d.fs.Remove(probe.Name()); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrDataDirNotWritable, dir, err)
+		}
+	}
+	return nil
+}
+
 // MonitoringStack checks if a monitoring stack directory exists in the data directory.
 // If the directory does not exist, it creates it and initializes a new MonitoringStack instance.
 // If the directory exists, it simply returns a new MonitoringStack instance.
@@ -303,7 +505,7 @@ func (d *DataDir) MonitoringStack() (*MonitoringStack, error) {
 			return nil, err
 		}
 
-		monitoringStack := &MonitoringStack{path: monitoringStackPath, fs: d.fs, l: d.locker}
+		monitoringStack := &MonitoringStack{path: monitoringStackPath, fs: d.fs, l: d.locker, perms: d.monitoringPerms}
 		if err = monitoringStack.Init(); err != nil {
 			return nil, err
 		}
@@ -312,7 +514,16 @@ func (d *DataDir) MonitoringStack() (*MonitoringStack, error) {
 		return nil, err
 	}
 
-	return newMonitoringStack(monitoringStackPath, d.fs, d.locker), nil
+	monitoringStack := newMonitoringStack(monitoringStackPath, d.fs, d.locker)
+	monitoringStack.perms = d.monitoringPerms
+	return monitoringStack, nil
+}
+
+// SetMonitoringStackPermissions sets the permissions and ownership of the
+// directories and files created in the monitoring stacks returned by
+// MonitoringStack from now on. See MonitoringStack.SetPermissions.
+func (d *DataDir) SetMonitoringStackPermissions(perms StackPermissions) {
+	d.monitoringPerms = perms
 }
 
 // RemoveMonitoringStack removes the monitoring stack directory from the data directory.
@@ -357,6 +568,74 @@ func (d *DataDir) ListInstances() ([]Instance, error) {
 	return instances, nil
 }
 
+// InstanceDirs returns the names of the directories in the nodes directory,
+// which are the IDs of the installed instances and of the orphan ones.
+func (d *DataDir) InstanceDirs() ([]string, error) {
+	nodesDirPath := filepath.Join(d.path, nodesDirName)
+	dirEntries, err := afero.ReadDir(d.fs, nodesDirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	dirs := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			dirs = append(dirs, dirEntry.Name())
+		}
+	}
+	return dirs, nil
+}
+
+// OrphanInstances returns the IDs of the directories in the nodes directory
+// without an instance state file, like the ones left behind by an interrupted
+// install or uninstall.
+func (d *DataDir) OrphanInstances() ([]string, error) {
+	dirs, err := d.InstanceDirs()
+	if err != nil {
+		return nil, err
+	}
+	orphans := make([]string, 0)
+	for _, dir := range dirs {
+		orphan, err := d.isOrphanInstance(dir)
+		if err != nil {
+			return nil, err
+		}
+		if orphan {
+			orphans = append(orphans, dir)
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanInstance removes the directory of the nodes directory with the
+// given ID if it has no instance state file. Otherwise, it returns an
+// ErrInstanceNotOrphan error and leaves the directory untouched.
+func (d *DataDir) RemoveOrphanInstance(instanceId string) error {
+	if !d.HasInstance(instanceId) {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	orphan, err := d.isOrphanInstance(instanceId)
+	if err != nil {
+		return err
+	}
+	if !orphan {
+		return fmt.Errorf("%w: %s", ErrInstanceNotOrphan, instanceId)
+	}
+	return d.fs.RemoveAll(filepath.Join(d.path, nodesDirName, instanceId))
+}
+
+// isOrphanInstance returns true if the directory of the nodes directory with
+// the given ID has no instance state file.
+func (d *DataDir) isOrphanInstance(instanceId string) (bool, error) {
+	exists, err := afero.Exists(d.fs, filepath.Join(d.path, nodesDirName, instanceId, "state.json"))
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
 // SavePluginImageContext saves the plugin image context to the data dir as a tar file.
 func (d *DataDir) SavePluginImageContext(id string, ctx io.ReadCloser) (err error) {
 	defer ctx.Close()
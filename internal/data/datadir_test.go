@@ -2,6 +2,7 @@ package data
 
 import (
 	"archive/tar"
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -13,6 +14,8 @@ import (
 	"github.com/NethermindEth/eigenlayer/internal/common"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
 	"github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/package_handler"
+	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -922,7 +925,7 @@ func TestMonitoringStack(t *testing.T) {
 	// Create a mock locker
 	ctrl := gomock.NewController(t)
 	locker := mocks.NewMockLocker(ctrl)
-	locker.EXPECT().New(filepath.Join(basePath, "/monitoring", ".lock")).Return(locker).Times(2)
+	locker.EXPECT().New(filepath.Join(basePath, "/monitoring", ".lock")).Return(locker).Times(3)
 
 	verify := func(t *testing.T, stack *MonitoringStack) {
 		t.Helper()
@@ -951,6 +954,14 @@ func TestMonitoringStack(t *testing.T) {
 	monitoringStack, err = dataDir.MonitoringStack()
 	require.NoError(t, err)
 	verify(t, monitoringStack)
+	assert.Equal(t, StackPermissions{}, monitoringStack.perms)
+
+	// The stacks get the permissions set on the data dir
+	perms := StackPermissions{DirMode: 0o750, FileMode: 0o640, Owner: &Owner{UID: 472, GID: 472}}
+	dataDir.SetMonitoringStackPermissions(perms)
+	monitoringStack, err = dataDir.MonitoringStack()
+	require.NoError(t, err)
+	assert.Equal(t, perms, monitoringStack.perms)
 }
 
 func TestDataDir_BackupList(t *testing.T) {
@@ -1006,11 +1017,12 @@ func TestDataDir_BackupList(t *testing.T) {
 				},
 				{
 					backup: Backup{
-						InstanceId: "mock-avs-second",
-						Timestamp:  time.Unix(1696421646, 0),
-						Version:    "v5.5.0",
-						Commit:     "a3406616b848164358fdd24465b8eecda5f5ae34",
-						Url:        "https://github.com/NethermindEth/mock-avs-pkg",
+						InstanceId:  "mock-avs-second",
+						Timestamp:   time.Unix(1696421646, 0),
+						Version:     "v5.5.0",
+						Commit:      "a3406616b848164358fdd24465b8eecda5f5ae34",
+						Url:         "https://github.com/NethermindEth/mock-avs-pkg",
+						Compression: CompressionGzip,
 					},
 					state: []byte(`
 					{
@@ -1066,6 +1078,10 @@ func TestDataDir_BackupList(t *testing.T) {
 				tarAddTimestamp(t, tarWriter, d.timestamp)
 				err = tarWriter.Close()
 				require.NoError(t, err)
+				err = dataDir.CompressBackup(d.backup.Id(), d.backup.Compression)
+				require.NoError(t, err)
+				d.backup.path = dataDir.BackupPath(d.backup.Id())
+				d.backup.fs = fs
 				backups = append(backups, d.backup)
 			}
 
@@ -1086,6 +1102,275 @@ func TestDataDir_BackupList(t *testing.T) {
 	}
 }
 
+func TestDataDir_BackupListReadOnly(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afs.MkdirAll("/egn/backup", 0o755))
+	state := []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v5.5.0","commit":"a3406616b848164358fdd24465b8eecda5f5ae34","profile":"option-returner","tag":"default"}`)
+	timestamp := time.Unix(1696421646, 0)
+	for i, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		backupId := fmt.Sprintf("mock-avs-default-%d", timestamp.Unix()+int64(i))
+		tarPath := filepath.Join("/egn/backup", backupId+CompressionNone.Extension())
+		tarFile, err := afs.Create(tarPath)
+		require.NoError(t, err)
+		tarWriter := tar.NewWriter(tarFile)
+		tarAddStateJson(t, tarWriter, state)
+		tarAddTimestamp(t, tarWriter, timestamp.Add(time.Duration(i)*time.Second))
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, tarFile.Close())
+		if c != CompressionNone {
+			require.NoError(t, CompressTar(afs, tarPath, filepath.Join("/egn/backup", backupId+c.Extension()), c))
+			require.NoError(t, afs.Remove(tarPath))
+		}
+	}
+
+	// Listing and reading the backups doesn't write to the filesystem
+	dataDir, err := NewDataDir("/egn", afero.NewReadOnlyFs(afs), nil)
+	require.NoError(t, err)
+	backups, err := dataDir.BackupList()
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	for i, backup := range backups {
+		assert.Equal(t, "mock-avs-default", backup.InstanceId)
+		assert.Equal(t, "v5.5.0", backup.Version)
+		assert.True(t, timestamp.Add(time.Duration(i)*time.Second).Equal(backup.Timestamp))
+	}
+	backup, err := dataDir.Backup(backups[1].Id())
+	require.NoError(t, err)
+	assert.Equal(t, CompressionGzip, backup.Compression)
+	instance, err := loadBackupTarStateJson(dataDir.fs, backup.path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "option-returner", instance.Profile)
+
+	// A missing backup directory has no backups
+	dataDir, err = NewDataDir("/other", afero.NewReadOnlyFs(afs), nil)
+	require.NoError(t, err)
+	backups, err = dataDir.BackupList()
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestDataDir_CompressBackup(t *testing.T) {
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			fs := afero.NewOsFs()
+			dataDir, err := NewDataDir(t.TempDir(), fs, nil)
+			require.NoError(t, err)
+
+			backup := Backup{
+				InstanceId: "mock-avs-default",
+				Timestamp:  time.Unix(1696367916, 0),
+			}
+			require.NoError(t, dataDir.InitBackup(&backup))
+			plainPath := dataDir.BackupPath(backup.Id())
+			assert.Equal(t, ".tar", filepath.Ext(plainPath))
+
+			require.NoError(t, dataDir.CompressBackup(backup.Id(), compression))
+
+			// The plain tar is replaced by the compressed one
+			ok, err := afero.Exists(fs, plainPath)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.Equal(t, plainPath[:len(plainPath)-len(".tar")]+compression.Extension(), dataDir.BackupPath(backup.Id()))
+			ok, err = dataDir.HasBackup(backup.Id())
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// A backup with the same id can't be initialized again
+			assert.ErrorIs(t, dataDir.InitBackup(&backup), ErrBackupAlreadyExists)
+		})
+	}
+}
+
+func TestDataDir_FinalizeBackup(t *testing.T) {
+	fs := afero.NewOsFs()
+	dataDir, err := NewDataDir(t.TempDir(), fs, nil)
+	require.NoError(t, err)
+
+	// Two backups with the same metadata and different contents
+	var ids []string
+	for _, content := range []string{"first", "second"} {
+		backup := Backup{
+			InstanceId: "mock-avs-default",
+			Timestamp:  time.Unix(1696367916, 0),
+			Version:    "v0.1.0",
+		}
+		require.NoError(t, dataDir.InitBackup(&backup))
+		workingId := backup.Id()
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, utils.TarWriteFile(tw, "timestamp", []byte("1696367916"), time.Unix(1696367916, 0)))
+		require.NoError(t, utils.TarWriteFile(tw, "data/state.json", []byte(`{"name":"mock-avs","tag":"default","version":"v0.1.0"}`), time.Unix(1696367916, 0)))
+		require.NoError(t, utils.TarWriteFile(tw, "data/db", []byte(content), time.Unix(1696367916, 0)))
+		require.NoError(t, tw.Close())
+		require.NoError(t, afero.WriteFile(fs, dataDir.BackupPath(workingId), buf.Bytes(), 0o644))
+		require.NoError(t, dataDir.CompressBackup(workingId, CompressionGzip))
+
+		require.NoError(t, dataDir.FinalizeBackup(&backup))
+		assert.NotEqual(t, workingId, backup.Id())
+		assert.NotEmpty(t, backup.Checksum)
+		ok, err := dataDir.HasBackup(workingId)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, filepath.Join(dataDir.backupsDir(), backup.Id()+".tar.gz"), dataDir.BackupPath(backup.Id()))
+		ids = append(ids, backup.Id())
+	}
+	assert.NotEqual(t, ids[0], ids[1])
+
+	// The backups are loaded with the IDs they were finalized with
+	backups, err := dataDir.BackupList()
+	require.NoError(t, err)
+	var listed []string
+	for _, backup := range backups {
+		listed = append(listed, backup.Id())
+	}
+	assert.ElementsMatch(t, ids, listed)
+	backup, err := dataDir.Backup(ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, ids[1], backup.Id())
+}
+
+func TestDataDir_EncryptBackup(t *testing.T) {
+	fs := afero.NewOsFs()
+	dataDir, err := NewDataDir(t.TempDir(), fs, nil)
+	require.NoError(t, err)
+
+	backup := Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696367916, 0),
+		Version:    "v0.1.0",
+	}
+	require.NoError(t, dataDir.InitBackup(&backup))
+	workingId := backup.Id()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, utils.TarWriteFile(tw, "timestamp", []byte("1696367916"), time.Unix(1696367916, 0)))
+	require.NoError(t, utils.TarWriteFile(tw, "data/state.json", []byte(`{"name":"mock-avs","tag":"default","version":"v0.1.0"}`), time.Unix(1696367916, 0)))
+	require.NoError(t, tw.Close())
+	require.NoError(t, afero.WriteFile(fs, dataDir.BackupPath(workingId), buf.Bytes(), 0o644))
+	require.NoError(t, dataDir.CompressBackup(workingId, CompressionGzip))
+
+	keys := staticKeyProvider("secret key")
+	require.NoError(t, dataDir.EncryptBackup(workingId, keys))
+	require.NoError(t, dataDir.FinalizeBackup(&backup))
+	backupPath := dataDir.BackupPath(backup.Id())
+	assert.Equal(t, filepath.Join(dataDir.backupsDir(), backup.Id()+".tar.gz"), backupPath)
+	files, err := afero.ReadDir(fs, dataDir.backupsDir())
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// The metadata is unknown without the key
+	backups, err := dataDir.BackupList()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, backup.Id(), backups[0].Id())
+	assert.True(t, backups[0].Encrypted)
+	assert.Empty(t, backups[0].InstanceId)
+	_, err = dataDir.EncryptedBackup(backup.Id(), nil)
+	assert.ErrorIs(t, err, ErrBackupEncrypted)
+
+	got, err := dataDir.EncryptedBackup(backup.Id(), keys)
+	require.NoError(t, err)
+	assert.Equal(t, backup.Id(), got.Id())
+	assert.True(t, got.Encrypted)
+	assert.Equal(t, "mock-avs-default", got.InstanceId)
+	assert.Equal(t, "v0.1.0", got.Version)
+	assert.Equal(t, CompressionGzip, got.Compression)
+
+	_, err = dataDir.EncryptedBackup(backup.Id(), staticKeyProvider("other key"))
+	assert.ErrorIs(t, err, ErrDecryptingBackup)
+
+	backups, err = dataDir.EncryptedBackupList(keys)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, backup.Id(), backups[0].Id())
+	assert.Equal(t, "mock-avs-default", backups[0].InstanceId)
+
+	// The backups encrypted with another key are listed without metadata
+	backups, err = dataDir.EncryptedBackupList(staticKeyProvider("other key"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, backup.Id(), backups[0].Id())
+	assert.Empty(t, backups[0].InstanceId)
+
+	// The decrypted file is the compressed backup tar
+	require.NoError(t, DecryptBackup(fs, backupPath, filepath.Join(t.TempDir(), "backup.tar.gz"), keys))
+}
+
+func TestDataDir_RemoveBackup(t *testing.T) {
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			fs := afero.NewOsFs()
+			dataDir, err := NewDataDir(t.TempDir(), fs, nil)
+			require.NoError(t, err)
+
+			backup := Backup{
+				InstanceId: "mock-avs-default",
+				Timestamp:  time.Unix(1696367916, 0),
+			}
+			require.NoError(t, dataDir.InitBackup(&backup))
+			require.NoError(t, dataDir.CompressBackup(backup.Id(), compression))
+
+			require.NoError(t, dataDir.RemoveBackup(backup.Id()))
+			ok, err := dataDir.HasBackup(backup.Id())
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.ErrorIs(t, dataDir.RemoveBackup(backup.Id()), ErrBackupNotFound)
+		})
+	}
+}
+
+func TestDataDir_SetBackupDir(t *testing.T) {
+	backup := Backup{
+		InstanceId: "mock-avs-default",
+		Timestamp:  time.Unix(1696340865, 0),
+		Version:    common.MockAvsPkg.Version(),
+		Commit:     common.MockAvsPkg.CommitHash(),
+		Url:        common.MockAvsPkg.Repo(),
+	}
+	fs := afero.NewOsFs()
+	dataDir, err := NewDataDir(t.TempDir(), fs, nil)
+	require.NoError(t, err)
+	customDir := filepath.Join(t.TempDir(), "backups")
+	require.NoError(t, dataDir.SetBackupDir(customDir))
+
+	// The custom directory is created if missing
+	backupDirPath, err := dataDir.BackupDir()
+	require.NoError(t, err)
+	assert.Equal(t, customDir, backupDirPath)
+	assert.DirExists(t, customDir)
+
+	require.NoError(t, dataDir.InitBackup(&backup))
+	assert.Equal(t, filepath.Join(customDir, backup.Id()+".tar"), dataDir.BackupPath(backup.Id()))
+	assert.FileExists(t, filepath.Join(customDir, backup.Id()+".tar"))
+	assert.NoDirExists(t, filepath.Join(dataDir.Path(), backupDir))
+
+	ok, err := dataDir.HasBackup(backup.Id())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, dataDir.RemoveBackup(backup.Id()))
+	assert.NoFileExists(t, filepath.Join(customDir, backup.Id()+".tar"))
+}
+
+func TestDataDir_PackageCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	dataDir, err := NewDataDir("/egn", fs, nil)
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, "/pkg/manifest.yml", []byte("manifest"), 0o644))
+
+	cache := dataDir.PackageCache(package_handler.CachePolicy{})
+	require.NoError(t, cache.Put("https://github.com/NethermindEth/mock-avs-pkg", "v0.1.0", "/pkg"))
+
+	entries, err := afero.ReadDir(fs, "/egn/cache/packages")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	ok, err := dataDir.PackageCache(package_handler.CachePolicy{}).Get("https://github.com/NethermindEth/mock-avs-pkg", "v0.1.0", "/dst")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
 func TestRemoveMonitoringStack(t *testing.T) {
 	// Create monitoring stack
 	// Create a memory filesystem
@@ -1159,3 +1444,235 @@ func tarAddTimestamp(t *testing.T, tarWriter *tar.Writer, timestamp time.Time) {
 	_, err = tarWriter.Write([]byte(data))
 	require.NoError(t, err)
 }
+
+func TestDataDir_CheckPermissions(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afs.MkdirAll("/egn/nodes", 0o755))
+	dataDir, err := NewDataDir("/egn", afs, nil)
+	require.NoError(t, err)
+	require.NoError(t, dataDir.CheckPermissions())
+
+	// The probe files are removed
+	entries, err := afero.ReadDir(afs, "/egn/nodes")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	dataDir, err = NewDataDir("/egn", afero.NewReadOnlyFs(afs), nil)
+	require.NoError(t, err)
+	err = dataDir.CheckPermissions()
+	assert.ErrorIs(t, err, ErrDataDirNotWritable)
+}
+
+func TestDataDir_OrphanInstances(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	dataDir, err := NewDataDir("/egn", afs, nil)
+	require.NoError(t, err)
+
+	orphans, err := dataDir.OrphanInstances()
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	// A valid instance, an orphan directory and a stray file
+	require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+	require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{}`), 0o644))
+	require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-orphan/data", 0o755))
+	require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-file", nil, 0o644))
+
+	orphans, err = dataDir.OrphanInstances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mock-avs-orphan"}, orphans)
+
+	err = dataDir.RemoveOrphanInstance("mock-avs-default")
+	assert.ErrorIs(t, err, ErrInstanceNotOrphan)
+	exists, err := afero.Exists(afs, "/egn/nodes/mock-avs-default/state.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	err = dataDir.RemoveOrphanInstance("mock-avs-missing")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+
+	require.NoError(t, dataDir.RemoveOrphanInstance("mock-avs-orphan"))
+	exists, err = afero.DirExists(afs, "/egn/nodes/mock-avs-orphan")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestDataDir_ReplaceInstanceDirFromTarSymlinks(t *testing.T) {
+	fs := afero.NewOsFs()
+	sandbox := t.TempDir()
+	dataDir, err := NewDataDir(filepath.Join(sandbox, "datadir"), fs, nil)
+	require.NoError(t, err)
+
+	// Instance data with symlinks to a file and to a directory of its own
+	instanceId := "mock-avs-default"
+	instancePath := filepath.Join(dataDir.path, nodesDirName, instanceId)
+	require.NoError(t, fs.MkdirAll(filepath.Join(instancePath, "config"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(instancePath, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(instancePath, "config", ".env"), []byte("KEY=value"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("config", ".env"), filepath.Join(instancePath, ".env")))
+	require.NoError(t, os.Symlink("config", filepath.Join(instancePath, "current")))
+
+	var buf bytes.Buffer
+	backup, err := CreateBackup(fs, instanceId, instancePath, &buf)
+	require.NoError(t, err)
+	tarPath := filepath.Join(sandbox, backup.FileName())
+	require.NoError(t, afero.WriteFile(fs, tarPath, buf.Bytes(), 0o644))
+
+	// The restored instance has the same symlinks
+	require.NoError(t, dataDir.ReplaceInstanceDirFromTar(instanceId, tarPath, "data"))
+	link, err := os.Readlink(filepath.Join(instancePath, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("config", ".env"), link)
+	link, err = os.Readlink(filepath.Join(instancePath, "current"))
+	require.NoError(t, err)
+	assert.Equal(t, "config", link)
+	env, err := afero.ReadFile(fs, filepath.Join(instancePath, "current", ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "KEY=value", string(env))
+}
+
+func TestDataDir_ReplaceInstanceDirFromTar(t *testing.T) {
+	type tarEntry struct {
+		header  tar.Header
+		content string
+	}
+	ts := []struct {
+		name    string
+		entries []tarEntry
+		err     error
+	}{
+		{
+			name: "valid tar",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0o755}},
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/.env", Typeflag: tar.TypeReg, Mode: 0o644}, content: "KEY=value"},
+				{header: tar.Header{Name: "volumes/volume.tar", Typeflag: tar.TypeReg, Mode: 0o644}, content: "volume"},
+			},
+		},
+		{
+			name: "parent dir entry",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/../../evil.txt", Typeflag: tar.TypeReg, Mode: 0o644}, content: "evil"},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "windows parent dir entry",
+			entries: []tarEntry{
+				{header: tar.Header{Name: `data\state.json`, Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: `data\..\..\evil.txt`, Typeflag: tar.TypeReg, Mode: 0o644}, content: "evil"},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "links inside the instance dir",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/config/", Typeflag: tar.TypeDir, Mode: 0o755}},
+				{header: tar.Header{Name: "data/config/.env", Typeflag: tar.TypeReg, Mode: 0o644}, content: "KEY=value"},
+				{header: tar.Header{Name: "data/.env", Typeflag: tar.TypeSymlink, Linkname: "config/.env"}},
+				{header: tar.Header{Name: "data/config/state.json", Typeflag: tar.TypeSymlink, Linkname: "../state.json"}},
+				{header: tar.Header{Name: "data/state-copy.json", Typeflag: tar.TypeLink, Linkname: "data/state.json"}},
+			},
+		},
+		{
+			name: "symlink entry",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/evil", Typeflag: tar.TypeSymlink, Linkname: "../../evil.txt"}},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "absolute symlink entry",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/evil", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "symlink through another symlink",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/evil", Typeflag: tar.TypeSymlink, Linkname: "dir/up/../evil.txt"}},
+				{header: tar.Header{Name: "data/dir/up", Typeflag: tar.TypeSymlink, Linkname: ".."}},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "entry under a symlink",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/root", Typeflag: tar.TypeSymlink, Linkname: "."}},
+				{header: tar.Header{Name: "data/root/up", Typeflag: tar.TypeSymlink, Linkname: "../evil.txt"}},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+		{
+			name: "hard link outside the instance dir",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "data/state.json", Typeflag: tar.TypeReg, Mode: 0o644}, content: "{}"},
+				{header: tar.Header{Name: "data/evil", Typeflag: tar.TypeLink, Linkname: "volumes/volume.tar"}},
+			},
+			err: ErrUnsafeTarEntry,
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := afero.NewOsFs()
+			sandbox := t.TempDir()
+			dataDir, err := NewDataDir(filepath.Join(sandbox, "datadir"), fs, nil)
+			require.NoError(t, err)
+
+			// Existing instance dir, which must be kept if the tar is unsafe
+			instanceId := "mock-avs-default"
+			instancePath := filepath.Join(dataDir.path, nodesDirName, instanceId)
+			require.NoError(t, fs.MkdirAll(instancePath, 0o755))
+			require.NoError(t, afero.WriteFile(fs, filepath.Join(instancePath, "state.json"), []byte("old"), 0o644))
+
+			tarPath := filepath.Join(sandbox, "backup.tar")
+			tarFile, err := fs.Create(tarPath)
+			require.NoError(t, err)
+			tw := tar.NewWriter(tarFile)
+			for _, e := range tc.entries {
+				header := e.header
+				header.Size = int64(len(e.content))
+				require.NoError(t, tw.WriteHeader(&header))
+				_, err := tw.Write([]byte(e.content))
+				require.NoError(t, err)
+			}
+			require.NoError(t, tw.Close())
+			require.NoError(t, tarFile.Close())
+
+			err = dataDir.ReplaceInstanceDirFromTar(instanceId, tarPath, "data")
+
+			state, readErr := afero.ReadFile(fs, filepath.Join(instancePath, "state.json"))
+			require.NoError(t, readErr)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Equal(t, "old", string(state))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "{}", string(state))
+				env, err := afero.ReadFile(fs, filepath.Join(instancePath, ".env"))
+				require.NoError(t, err)
+				assert.Equal(t, "KEY=value", string(env))
+				ok, err := afero.Exists(fs, filepath.Join(instancePath, "volume.tar"))
+				require.NoError(t, err)
+				assert.False(t, ok)
+			}
+			// Nothing is written outside the instance dir
+			for _, p := range []string{
+				filepath.Join(sandbox, "evil.txt"),
+				filepath.Join(dataDir.path, "evil.txt"),
+				filepath.Join(dataDir.path, nodesDirName, "evil.txt"),
+			} {
+				ok, err := afero.Exists(fs, p)
+				require.NoError(t, err)
+				assert.False(t, ok, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeyProvider provides the key used to encrypt and decrypt backups. It lets
+// automated backups get the key without an interactive passphrase, from a key
+// file with FileKeyProvider or from an external system, like a KMS, with other
+// implementations.
+type KeyProvider interface {
+	// Key returns the key material. The AES-256 key of the backup is derived
+	// from it, so it can have any non-zero length.
+	Key() ([]byte, error)
+}
+
+// FileKeyProvider is a KeyProvider that reads the key from a file. Leading and
+// trailing whitespace of the file is ignored, so keys written by editors or
+// generated with tools like `openssl rand -base64 32` can be used as they are.
+type FileKeyProvider struct {
+	fs   afero.Fs
+	path string
+}
+
+// NewFileKeyProvider creates a new FileKeyProvider of the key file at the given
+// path.
+func NewFileKeyProvider(fs afero.Fs, path string) *FileKeyProvider {
+	return &FileKeyProvider{
+		fs:   fs,
+		path: path,
+	}
+}
+
+// Key returns the content of the key file.
+func (p *FileKeyProvider) Key() ([]byte, error) {
+	key, err := afero.ReadFile(p.fs, p.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptionKey, err)
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidEncryptionKey, p.path)
+	}
+	return key, nil
+}
+
+const (
+	// encryptionMagic starts the encrypted backup files, and includes the
+	// version of the format.
+	encryptionMagic = "egnenc1\n"
+	// encryptionChunkSize is the size of the plaintext chunks encrypted
+	// separately, so backups are encrypted without loading them in memory.
+	encryptionChunkSize = 64 * 1024
+	// encryptionNoncePrefixSize is the size of the random prefix of the chunk
+	// nonces. The rest of the nonce is the chunk counter.
+	encryptionNoncePrefixSize = 8
+	// encryptionSaltSize is the size of the random salt of the key derivation,
+	// stored after the magic.
+	encryptionSaltSize = 16
+)
+
+// The scrypt parameters of the key derivation, the ones recommended for
+// interactive logins, so deriving the key of a backup stays under a second
+// while guessing weak keys, like passphrases, is expensive.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// EncryptBackup encrypts the backup file src into dst with AES-256-GCM, using
+// a key derived with scrypt from the key of the given provider and a random
+// salt stored in dst. The file is encrypted in chunks, each one authenticated
+// along with its position and whether it is the last one, so reordered or
+// truncated files fail to decrypt.
+func EncryptBackup(fs afero.Fs, src, dst string, keys KeyProvider) (err error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := backupCipher(keys, salt)
+	if err != nil {
+		return err
+	}
+	srcFile, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := fs.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := dstFile.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	noncePrefix := make([]byte, encryptionNoncePrefixSize)
+	if _, err = rand.Read(noncePrefix); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(dstFile)
+	if _, err = w.WriteString(encryptionMagic); err != nil {
+		return err
+	}
+	if _, err = w.Write(salt); err != nil {
+		return err
+	}
+	if _, err = w.Write(noncePrefix); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(srcFile, encryptionChunkSize)
+	chunk := make([]byte, encryptionChunkSize)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, chunk)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		// The chunk is the last one if there is nothing left to read after it
+		_, peekErr := r.Peek(1)
+		last := errors.Is(peekErr, io.EOF)
+
+		header := make([]byte, 5)
+		if last {
+			header[0] = 1
+		}
+		sealed := aead.Seal(nil, chunkNonce(noncePrefix, counter), chunk[:n], header[:1])
+		binary.BigEndian.PutUint32(header[1:], uint32(len(sealed)))
+		if _, err = w.Write(header); err != nil {
+			return err
+		}
+		if _, err = w.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+	}
+	return w.Flush()
+}
+
+// DecryptBackup decrypts the backup file src, encrypted with EncryptBackup,
+// into dst, using the key of the given provider. An ErrDecryptingBackup error
+// is returned if the key is wrong or the file is not a complete encrypted
+// backup. The content is decrypted into a temporary file next to dst, which
+// is renamed to dst once the whole file is authenticated, so dst never holds
+// a partially decrypted backup.
+func DecryptBackup(fs afero.Fs, src, dst string, keys KeyProvider) (err error) {
+	srcFile, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	r, err := newDecryptReader(srcFile, keys)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := afero.TempFile(fs, filepath.Dir(dst), filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			fs.Remove(tmpFile.Name())
+		}
+	}()
+	_, err = io.Copy(tmpFile, r)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = fs.Chmod(tmpFile.Name(), 0o644); err != nil {
+		return err
+	}
+	return fs.Rename(tmpFile.Name(), dst)
+}
+
+// isEncrypted returns true if the given reader starts like an encrypted
+// backup file. Nothing is consumed from the reader.
+func isEncrypted(r *bufio.Reader) bool {
+	magic, _ := r.Peek(len(encryptionMagic))
+	return string(magic) == encryptionMagic
+}
+
+// isEncryptedFile returns true if the file at the given path is an encrypted
+// backup file.
+func isEncryptedFile(fs afero.Fs, path string) (bool, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isEncrypted(bufio.NewReader(f)), nil
+}
+
+// decryptReader reads the plain content of an encrypted backup, decrypting
+// one chunk at a time.
+type decryptReader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	counter     uint32
+	chunk       []byte
+	done        bool
+}
+
+// newDecryptReader returns a reader of the plain content of the encrypted
+// backup read from r, decrypted with the key of the given provider.
+func newDecryptReader(r io.Reader, keys KeyProvider) (*decryptReader, error) {
+	br := bufio.NewReader(r)
+	prelude := make([]byte, len(encryptionMagic)+encryptionSaltSize+encryptionNoncePrefixSize)
+	if _, err := io.ReadFull(br, prelude); err != nil || string(prelude[:len(encryptionMagic)]) != encryptionMagic {
+		return nil, fmt.Errorf("%w: not an encrypted backup", ErrDecryptingBackup)
+	}
+	salt := prelude[len(encryptionMagic) : len(encryptionMagic)+encryptionSaltSize]
+	aead, err := backupCipher(keys, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:           br,
+		aead:        aead,
+		noncePrefix: prelude[len(encryptionMagic)+encryptionSaltSize:],
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.chunk) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.chunk)
+	d.chunk = d.chunk[n:]
+	return n, nil
+}
+
+// next decrypts the next chunk. After the last one, it checks that there is
+// nothing left to read.
+func (d *decryptReader) next() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return fmt.Errorf("%w: truncated file", ErrDecryptingBackup)
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > encryptionChunkSize+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("%w: invalid chunk", ErrDecryptingBackup)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("%w: truncated file", ErrDecryptingBackup)
+	}
+	chunk, err := d.aead.Open(nil, chunkNonce(d.noncePrefix, d.counter), sealed, header[:1])
+	if err != nil {
+		return fmt.Errorf("%w: wrong key or corrupted file: %w", ErrDecryptingBackup, err)
+	}
+	d.counter++
+	d.chunk = chunk
+	if header[0] == 1 {
+		d.done = true
+		if _, err = d.r.Peek(1); !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: data after the last chunk", ErrDecryptingBackup)
+		}
+	}
+	return nil
+}
+
+// backupCipher returns the AES-256-GCM cipher with the key derived from the
+// key of the given provider and the given salt.
+func backupCipher(keys KeyProvider, salt []byte) (cipher.AEAD, error) {
+	key, err := keys.Key()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: empty key", ErrInvalidEncryptionKey)
+	}
+	derivedKey, err := scrypt.Key(key, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of the chunk with the given counter.
+func chunkNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, 0, encryptionNoncePrefixSize+4)
+	nonce = append(nonce, prefix...)
+	return binary.BigEndian.AppendUint32(nonce, counter)
+}
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticKeyProvider is a KeyProvider with a fixed key, like the providers of
+// external systems.
+type staticKeyProvider []byte
+
+func (p staticKeyProvider) Key() ([]byte, error) {
+	return p, nil
+}
+
+func TestEncryptBackupRoundTrip(t *testing.T) {
+	tc := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "small", size: 100},
+		{name: "exact chunks", size: 2 * encryptionChunkSize},
+		{name: "several chunks", size: 3*encryptionChunkSize + 17},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			content := make([]byte, tt.size)
+			rand.New(rand.NewSource(int64(tt.size))).Read(content)
+			require.NoError(t, afero.WriteFile(fs, "/backup.tar", content, 0o644))
+			require.NoError(t, afero.WriteFile(fs, "/backup.key", []byte("  secret key\n"), 0o600))
+			keys := NewFileKeyProvider(fs, "/backup.key")
+
+			require.NoError(t, EncryptBackup(fs, "/backup.tar", "/backup.tar.enc", keys))
+			encrypted, err := afero.ReadFile(fs, "/backup.tar.enc")
+			require.NoError(t, err)
+			if tt.size > 0 {
+				assert.False(t, bytes.Contains(encrypted, content))
+			}
+
+			require.NoError(t, DecryptBackup(fs, "/backup.tar.enc", "/decrypted.tar", keys))
+			decrypted, err := afero.ReadFile(fs, "/decrypted.tar")
+			require.NoError(t, err)
+			assert.Equal(t, content, decrypted)
+
+			// The whitespace around the key in the file is ignored
+			require.NoError(t, DecryptBackup(fs, "/backup.tar.enc", "/decrypted.tar", staticKeyProvider("secret key")))
+		})
+	}
+}
+
+func TestDecryptBackupError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := make([]byte, 2*encryptionChunkSize+10)
+	require.NoError(t, afero.WriteFile(fs, "/backup.tar", content, 0o644))
+	require.NoError(t, EncryptBackup(fs, "/backup.tar", "/backup.tar.enc", staticKeyProvider("secret key")))
+	encrypted, err := afero.ReadFile(fs, "/backup.tar.enc")
+	require.NoError(t, err)
+
+	tc := []struct {
+		name      string
+		encrypted []byte
+		keys      KeyProvider
+		err       error
+	}{
+		{
+			name:      "wrong key",
+			encrypted: encrypted,
+			keys:      staticKeyProvider("other key"),
+			err:       ErrDecryptingBackup,
+		},
+		{
+			name:      "truncated",
+			encrypted: encrypted[:len(encrypted)-100],
+			keys:      staticKeyProvider("secret key"),
+			err:       ErrDecryptingBackup,
+		},
+		{
+			name:      "last chunk removed",
+			encrypted: encrypted[:len(encryptionMagic)+encryptionSaltSize+encryptionNoncePrefixSize+2*(5+encryptionChunkSize+16)],
+			keys:      staticKeyProvider("secret key"),
+			err:       ErrDecryptingBackup,
+		},
+		{
+			name:      "trailing data",
+			encrypted: append(bytes.Clone(encrypted), 0),
+			keys:      staticKeyProvider("secret key"),
+			err:       ErrDecryptingBackup,
+		},
+		{
+			name:      "not encrypted",
+			encrypted: content,
+			keys:      staticKeyProvider("secret key"),
+			err:       ErrDecryptingBackup,
+		},
+		{
+			name:      "empty key file",
+			encrypted: encrypted,
+			keys:      NewFileKeyProvider(fs, "/empty.key"),
+			err:       ErrInvalidEncryptionKey,
+		},
+		{
+			name:      "missing key file",
+			encrypted: encrypted,
+			keys:      NewFileKeyProvider(fs, "/missing.key"),
+			err:       ErrInvalidEncryptionKey,
+		},
+	}
+	require.NoError(t, afero.WriteFile(fs, "/empty.key", []byte("\n"), 0o600))
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, afero.WriteFile(fs, "/input.tar.enc", tt.encrypted, 0o644))
+			err := DecryptBackup(fs, "/input.tar.enc", "/decrypted.tar", tt.keys)
+			assert.ErrorIs(t, err, tt.err)
+
+			// Nothing is left behind when the decryption fails
+			files, err := afero.Glob(fs, "/decrypted.tar*")
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
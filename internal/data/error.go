@@ -6,7 +6,10 @@ var (
 	ErrInstanceAlreadyExists       = errors.New("instance already exists")
 	ErrInstanceNotFound            = errors.New("instance not found")
 	ErrInvalidInstance             = errors.New("invalid instance")
+	ErrInvalidInstanceId           = errors.New("invalid instance ID")
+	ErrInvalidTag                  = errors.New("invalid tag")
 	ErrInvalidInstanceDir          = errors.New("invalid instance directory")
+	ErrInstanceNotOrphan           = errors.New("instance directory has an instance state")
 	ErrTempDirDoesNotExist         = errors.New("temp directory does not exist")
 	ErrTempIsNotDir                = errors.New("temp is not a directory")
 	ErrMonitoringStackNotFound     = errors.New("monitoring stack not found")
@@ -14,8 +17,20 @@ var (
 	ErrReadingFile                 = errors.New("failed reading file")
 	ErrWritingFile                 = errors.New("failed writing file")
 	ErrStackNotInitialized         = errors.New("stack not initialized")
+	ErrInvalidMonitoringBundle     = errors.New("invalid monitoring bundle")
 	ErrBackupAlreadyExists         = errors.New("backup already exists")
 	ErrCreatingBackup              = errors.New("failed creating backup")
 	ErrInvalidBackupName           = errors.New("invalid backup name")
 	ErrBackupNotFound              = errors.New("backup not found")
+	ErrInvalidCompression          = errors.New("invalid compression")
+	ErrInvalidEncryptionKey        = errors.New("invalid encryption key")
+	ErrDecryptingBackup            = errors.New("failed decrypting backup")
+	ErrBackupEncrypted             = errors.New("backup is encrypted")
+	ErrBackupManifestNotFound      = errors.New("backup manifest not found")
+	ErrInvalidBackupManifest       = errors.New("invalid backup manifest")
+	ErrBackupManifestMismatch      = errors.New("backup manifest mismatch")
+	ErrBackupFileUnknown           = errors.New("backup file unknown")
+	ErrIncompatibleBackup          = errors.New("incompatible backup")
+	ErrUnsafeTarEntry              = errors.New("unsafe tar entry")
+	ErrDataDirNotWritable          = errors.New("data directory is not writable")
 )
@@ -0,0 +1,251 @@
+package data
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// checkTarDir returns an ErrUnsafeTarEntry error if any entry of the directory
+// srcPath of the tar file at tarPath is not safe to extract. Entries whose path
+// escapes the extraction directory, like ../ entries or absolute paths, and
+// links pointing outside of it are not safe.
+func checkTarDir(fs afero.Fs, tarPath, srcPath string) error {
+	return walkTarDir(fs, tarPath, srcPath, func(*tar.Header, string, io.Reader) error {
+		return nil
+	})
+}
+
+// extractTarDir extracts the entries of the directory srcPath of the tar file
+// at tarPath into the dst directory. The tar file is decompressed if needed.
+// Unsafe entries are rejected with an ErrUnsafeTarEntry error, so nothing is
+// ever written outside dst. Use checkTarDir to reject an unsafe tar before
+// extracting any entry. Hard links are extracted as copies of the file they
+// link to, and symlinks need a fs that supports them, like afero.OsFs.
+func extractTarDir(fs afero.Fs, tarPath, srcPath, dst string) error {
+	return walkTarDir(fs, tarPath, srcPath, func(header *tar.Header, relPath string, r io.Reader) error {
+		target := filepath.Join(dst, relPath)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := fs.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			return writeTarFile(fs, target, header.FileInfo().Mode().Perm(), header.Size, r)
+		case tar.TypeLink:
+			linked, err := fs.Open(filepath.Join(dst, tarLinkTarget(header, srcPath)))
+			if err != nil {
+				return err
+			}
+			defer linked.Close()
+			info, err := linked.Stat()
+			if err != nil {
+				return err
+			}
+			return writeTarFile(fs, target, info.Mode().Perm(), info.Size(), linked)
+		case tar.TypeSymlink:
+			linker, ok := fs.(afero.Linker)
+			if !ok {
+				return fmt.Errorf("failed to create symlink %s: %w", target, afero.ErrNoSymlink)
+			}
+			if err := fs.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(target), err)
+			}
+			return linker.SymlinkIfPossible(filepath.FromSlash(tarSymlinkTarget(header)), target)
+		}
+		return nil
+	})
+}
+
+// writeTarFile writes the size bytes of r to the file at target, creating it
+// with the given permissions if it doesn't exist.
+func writeTarFile(fs afero.Fs, target string, perm os.FileMode, size int64, r io.Reader) error {
+	if err := fs.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(target), err)
+	}
+	f, err := fs.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", target, err)
+	}
+	if n != size {
+		return fmt.Errorf("failed to copy file %s: copied %d bytes instead of %d", target, n, size)
+	}
+	return f.Close()
+}
+
+// walkTarDir calls fn for each entry of the directory srcPath of the tar file
+// at tarPath, with the entry path relative to srcPath. Backslashes in the entry
+// names are taken as separators, as in the tars created on Windows. It returns
+// an ErrUnsafeTarEntry error for the entries that are not safe to extract.
+//
+// A symlink can only be checked once all the symlinks of the directory are
+// known, as its target could go through another one, so fn is called for the
+// symlinks after all the other entries.
+func walkTarDir(fs afero.Fs, tarPath, srcPath string, fn func(header *tar.Header, relPath string, r io.Reader) error) error {
+	r, err := openTar(fs, tarPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var symlinks []*tar.Header
+	links := make(map[string]bool)
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		// Only the separators are normalized, the entry path is not cleaned
+		// so the entries escaping srcPath are still rejected
+		relPath, ok := strings.CutPrefix(strings.ReplaceAll(header.Name, `\`, "/"), srcPath+"/")
+		if !ok || relPath == "" {
+			continue
+		}
+		if err := checkTarDirEntry(header, relPath, srcPath, links); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			links[path.Clean(relPath)] = true
+			symlinks = append(symlinks, header)
+			continue
+		}
+		if err := fn(header, relPath, tarReader); err != nil {
+			return err
+		}
+	}
+	for _, header := range symlinks {
+		relPath := strings.TrimPrefix(strings.ReplaceAll(header.Name, `\`, "/"), srcPath+"/")
+		if err := checkTarSymlink(header, relPath, links); err != nil {
+			return err
+		}
+		if err := fn(header, relPath, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTarDirEntry returns an ErrUnsafeTarEntry error if the entry of a tar
+// directory with the given path, relative to the extraction root, is not safe
+// to extract. Unlike checkTarEntry, links are accepted: a hard link must link
+// to another file of the directory, and the symlinks are checked by
+// checkTarSymlink. links holds the paths of the symlinks found so far, as the
+// entries under a symlink would be written wherever it points to.
+func checkTarDirEntry(header *tar.Header, relPath, srcPath string, links map[string]bool) error {
+	if err := checkTarEntryPath(header, relPath); err != nil {
+		return err
+	}
+	if underTarSymlink(path.Clean(relPath), links) {
+		return fmt.Errorf("%w: %s is under a symlink", ErrUnsafeTarEntry, header.Name)
+	}
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		return nil
+	case tar.TypeLink:
+		target := tarLinkTarget(header, srcPath)
+		if target == "" || !isLocalTarPath(target) || links[path.Clean(target)] || underTarSymlink(path.Clean(target), links) {
+			return fmt.Errorf("%w: %s links outside the extraction directory", ErrUnsafeTarEntry, header.Name)
+		}
+		return nil
+	default:
+		return checkTarEntry(header, relPath)
+	}
+}
+
+// checkTarSymlink returns an ErrUnsafeTarEntry error if the target of the
+// symlink with the given path, relative to the extraction root, is not inside
+// the root. The target is resolved from the directory of the symlink, and it
+// must not go through any of the links, as it would then be resolved from
+// wherever they point to.
+func checkTarSymlink(header *tar.Header, relPath string, links map[string]bool) error {
+	target := tarSymlinkTarget(header)
+	if target == "" || path.IsAbs(target) {
+		return fmt.Errorf("%w: %s links outside the extraction directory", ErrUnsafeTarEntry, header.Name)
+	}
+	current := path.Dir(path.Clean(relPath))
+	elems := strings.Split(target, "/")
+	for i, elem := range elems {
+		if current = path.Join(current, elem); !isLocalTarPath(current) {
+			return fmt.Errorf("%w: %s links outside the extraction directory", ErrUnsafeTarEntry, header.Name)
+		}
+		if i < len(elems)-1 && links[current] {
+			return fmt.Errorf("%w: %s links through the symlink %s", ErrUnsafeTarEntry, header.Name, current)
+		}
+	}
+	return nil
+}
+
+// tarSymlinkTarget returns the target of the symlink entry with slash
+// separators.
+func tarSymlinkTarget(header *tar.Header) string {
+	return strings.ReplaceAll(header.Linkname, `\`, "/")
+}
+
+// tarLinkTarget returns the path, relative to srcPath, of the file the hard
+// link entry links to. It is empty if the file is not inside srcPath.
+func tarLinkTarget(header *tar.Header, srcPath string) string {
+	target, ok := strings.CutPrefix(strings.ReplaceAll(header.Linkname, `\`, "/"), srcPath+"/")
+	if !ok {
+		return ""
+	}
+	return target
+}
+
+// underTarSymlink returns true if any of the parent directories of the
+// cleaned path is one of the links.
+func underTarSymlink(name string, links map[string]bool) bool {
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if links[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalTarPath returns true if the tar path, with slash separators, stays
+// inside the directory it is relative to.
+func isLocalTarPath(name string) bool {
+	return filepath.IsLocal(filepath.FromSlash(path.Clean(name))) && !strings.Contains(name, `\`)
+}
+
+// checkTarEntryPath returns an ErrUnsafeTarEntry error if the tar entry with
+// the given path, relative to the extraction root, escapes it.
+func checkTarEntryPath(header *tar.Header, relPath string) error {
+	if !isLocalTarPath(relPath) {
+		return fmt.Errorf("%w: %s escapes the extraction directory", ErrUnsafeTarEntry, header.Name)
+	}
+	return nil
+}
+
+// checkTarEntry returns an ErrUnsafeTarEntry error if the tar entry with the
+// given path, relative to the extraction root, is not safe to extract. Links
+// are not safe, as they could point outside of the root.
+func checkTarEntry(header *tar.Header, relPath string) error {
+	if err := checkTarEntryPath(header, relPath); err != nil {
+		return err
+	}
+	switch header.Typeflag {
+	case tar.TypeDir, tar.TypeReg:
+		return nil
+	case tar.TypeSymlink, tar.TypeLink:
+		return fmt.Errorf("%w: %s is a link", ErrUnsafeTarEntry, header.Name)
+	default:
+		return fmt.Errorf("%w: unexpected type %q of %s", ErrUnsafeTarEntry, header.Typeflag, header.Name)
+	}
+}
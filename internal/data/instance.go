@@ -1,6 +1,7 @@
 package data
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,10 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/env"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
@@ -19,13 +24,74 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// InstanceContainerLabel is the docker label added to the services of the
+// instances, with the instance ID as value. It tells the containers of the
+// instances apart from other containers, like the Prometheus docker service
+// discovery does.
+const InstanceContainerLabel = "egn.instance_id"
+
+// MonitoringTargetContainerLabel is the docker label added, with the true
+// value, to the services of the instances that are monitoring targets of
+// their profile. Prometheus scrapes them with the jobs of their targets, so
+// its docker service discovery skips them.
+const MonitoringTargetContainerLabel = "egn.monitoring_target"
+
+// MetricsPortContainerLabel and MetricsPathContainerLabel are the docker labels
+// the services of a package set to have their metrics scraped by the
+// Prometheus docker service discovery, at the given port and path. The path
+// defaults to /metrics.
+const (
+	MetricsPortContainerLabel = "egn.metrics_port"
+	MetricsPathContainerLabel = "egn.metrics_path"
+)
+
 // InstanceId returns the instance ID for the given name and tag
 func InstanceId(name, tag string) string {
 	return fmt.Sprintf("%s-%s", name, tag)
 }
 
+// tagPattern matches the tags of the instances. Like the repository names, tags
+// can have dashes.
+const tagPattern = `[a-zA-Z0-9][a-zA-Z0-9_.-]*`
+
+var tagRegex = regexp.MustCompile(`^` + tagPattern + `$`)
+
+// instanceIdRegex matches instance IDs with the format <repository-name>-<tag>.
+// As both the name and the tag can have dashes, the ID is split at the last dash.
+var instanceIdRegex = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9_.-]*)-(` + tagPattern + `)$`)
+
+// ValidateTag returns an ErrInvalidTag error if the given tag can't be part of
+// an instance ID. A valid tag starts with a letter or a digit, followed by
+// letters, digits, dashes, dots and underscores.
+func ValidateTag(tag string) error {
+	if !tagRegex.MatchString(tag) {
+		return fmt.Errorf("%w: %q must start with a letter or a digit, followed by letters, digits, '-', '.' or '_'", ErrInvalidTag, tag)
+	}
+	return nil
+}
+
+// ParseInstanceId parses an instance ID with the format <repository-name>-<tag>,
+// as built by InstanceId, and returns the repository name and the tag. The ID
+// is split at the last dash, so the dashes of a tag are taken as part of the
+// repository name. Any ID built by InstanceId with a valid tag is accepted.
+func ParseInstanceId(s string) (repo, tag string, err error) {
+	match := instanceIdRegex.FindStringSubmatch(s)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: %q does not have the format <repository-name>-<tag>", ErrInvalidInstanceId, s)
+	}
+	return match[1], match[2], nil
+}
+
+// stateVersion is the version of the format of the state.json file written by
+// this version of egn. It is increased on breaking changes of the format, like
+// renamed fields, so older versions of egn can detect states they can't read.
+const stateVersion = 1
+
 // Instance represents the data stored about a node software instance
 type Instance struct {
+	// StateVersion is the version of the format of the state.json file. It is
+	// zero for the states written before the format was versioned.
+	StateVersion      int               `json:"state_version,omitempty"`
 	Name              string            `json:"name"`
 	URL               string            `json:"url"`
 	Version           string            `json:"version"`
@@ -36,6 +102,8 @@ type Instance struct {
 	MonitoringTargets MonitoringTargets `json:"monitoring"`
 	APITarget         *APITarget        `json:"api,omitempty"`
 	Plugin            *Plugin           `json:"plugin,omitempty"`
+	InstalledAt       time.Time         `json:"installed_at"`
+	Labels            map[string]string `json:"labels,omitempty"`
 	path              string
 	fs                afero.Fs
 	locker            locker.Locker
@@ -47,17 +115,32 @@ func (i *Instance) ID() string {
 
 type MonitoringTargets struct {
 	Targets []MonitoringTarget `json:"targets"`
+	Rules   []string           `json:"rules,omitempty"`
+	// Dashboards are the paths, relative to the instance directory, of the
+	// Grafana dashboards of the instance profile.
+	Dashboards []string `json:"dashboards,omitempty"`
 }
 
 type MonitoringTarget struct {
 	Service string `json:"service"`
 	Port    string `json:"port"`
 	Path    string `json:"path"`
+	Scheme  string `json:"scheme,omitempty"`
+	// ScrapeTimeout is the Prometheus scrape timeout of the target, e.g. 10s.
+	ScrapeTimeout string `json:"scrape_timeout,omitempty"`
+	// HonorLabels keeps the labels exposed by the target on conflicts.
+	HonorLabels bool `json:"honor_labels,omitempty"`
 }
 
 type APITarget struct {
-	Service string `json:"service"`
-	Port    string `json:"port"`
+	Service     string       `json:"service"`
+	Port        string       `json:"port"`
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+}
+
+type HealthCheck struct {
+	Path   string `json:"path"`
+	Status int    `json:"status"`
 }
 
 type Plugin struct {
@@ -132,6 +215,7 @@ func (i *Instance) init(instancePath string, fs afero.Fs, locker locker.Locker)
 	i.locker = i.locker.New(filepath.Join(i.path, ".lock"))
 
 	// Create state file
+	i.StateVersion = stateVersion
 	stateFile, err := i.fs.Create(filepath.Join(i.path, "state.json"))
 	if err != nil {
 		return err
@@ -221,6 +305,178 @@ func (i *Instance) Setup(env map[string]string, profilePath string) (err error)
 	if !exists {
 		return fmt.Errorf("%w: docker-compose.yml not found", ErrInvalidInstance)
 	}
+	return i.labelServices()
+}
+
+// labelServices adds the InstanceContainerLabel to every service of the
+// docker-compose.yml file of the instance, and the
+// MonitoringTargetContainerLabel to the services that are monitoring targets.
+// Labels are either a mapping or a list of key=value strings in compose files,
+// and both are supported.
+func (i *Instance) labelServices() error {
+	composeData, err := afero.ReadFile(i.fs, i.ComposePath())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrReadingFile, err)
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(composeData, &doc); err != nil {
+		return fmt.Errorf("%w: docker-compose.yml: %w", ErrInvalidInstance, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	services := mappingValue(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	targets := make(map[string]bool, len(i.MonitoringTargets.Targets))
+	for _, target := range i.MonitoringTargets.Targets {
+		targets[target.Service] = true
+	}
+	id := i.ID()
+	for j := 1; j < len(services.Content); j += 2 {
+		service := services.Content[j]
+		if service.Kind != yaml.MappingNode {
+			continue
+		}
+		name := services.Content[j-1].Value
+		if err := setServiceLabel(service, name, InstanceContainerLabel, id); err != nil {
+			return err
+		}
+		if targets[name] {
+			if err := setServiceLabel(service, name, MonitoringTargetContainerLabel, "true"); err != nil {
+				return err
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(&doc); err != nil {
+		return err
+	}
+	if err = afero.WriteFile(i.fs, i.ComposePath(), out.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWritingFile, err)
+	}
+	return nil
+}
+
+// setServiceLabel sets the label with the given key and value in the labels of
+// the given compose service node, replacing the value of the label if the
+// service already has it.
+func setServiceLabel(service *yaml.Node, name, key, value string) error {
+	labels := mappingValue(service, "labels")
+	switch {
+	case labels == nil:
+		service.Content = append(service.Content, stringNode("labels"), &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{stringNode(key), stringNode(value)},
+		})
+	case labels.Tag == "!!null":
+		*labels = yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{stringNode(key), stringNode(value)},
+		}
+	case labels.Kind == yaml.MappingNode:
+		if current := mappingValue(labels, key); current != nil {
+			*current = *stringNode(value)
+		} else {
+			labels.Content = append(labels.Content, stringNode(key), stringNode(value))
+		}
+	case labels.Kind == yaml.SequenceNode:
+		var kept []*yaml.Node
+		for _, label := range labels.Content {
+			if !strings.HasPrefix(label.Value, key+"=") {
+				kept = append(kept, label)
+			}
+		}
+		labels.Content = append(kept, stringNode(key+"="+value))
+	default:
+		return fmt.Errorf("%w: docker-compose.yml: labels of service %s are not a mapping or a list", ErrInvalidInstance, name)
+	}
+	return nil
+}
+
+// mappingValue returns the value of the given key in a YAML mapping node, or
+// nil if the node is not a mapping or does not have the key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for j := 0; j+1 < len(node.Content); j += 2 {
+		if node.Content[j].Value == key {
+			return node.Content[j+1]
+		}
+	}
+	return nil
+}
+
+// stringNode returns a YAML string scalar node with the given value.
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// SetId changes the ID of the instance to the given one, by updating the name
+// and tag of the instance in its state.json file. It is used when the data of
+// an instance is restored under a different instance ID. The instance
+// directory is not moved.
+func (i *Instance) SetId(instanceId string) (err error) {
+	name, tag, err := ParseInstanceId(instanceId)
+	if err != nil {
+		return err
+	}
+	// The ID is split at its last dash, so a tag with dashes is told apart
+	// from the name of the instance when the name is kept
+	if t, ok := strings.CutPrefix(instanceId, i.Name+"-"); ok && i.Name != "" {
+		name, tag = i.Name, t
+	}
+	if err = i.lock(); err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := i.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	i.Name, i.Tag = name, tag
+	return i.writeState()
+}
+
+// SetLabels replaces the labels of the instance with the given ones in its
+// state.json file. An empty map removes all the labels.
+func (i *Instance) SetLabels(labels map[string]string) (err error) {
+	if err = i.lock(); err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := i.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	i.Labels = maps.Clone(labels)
+	if len(i.Labels) == 0 {
+		i.Labels = nil
+	}
+	return i.writeState()
+}
+
+// writeState writes the instance to its state.json file. The instance must be
+// locked.
+func (i *Instance) writeState() error {
+	i.StateVersion = stateVersion
+	stateData, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	if err = afero.WriteFile(i.fs, filepath.Join(i.path, "state.json"), stateData, 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWritingFile, err)
+	}
 	return nil
 }
 
@@ -278,6 +534,45 @@ func (i *Instance) ProfileFile() (*profile.Profile, error) {
 	return &p, p.Validate()
 }
 
+// ReadFile reads the file at the given path, relative to the instance
+// directory. The path must be local to the instance directory.
+func (i *Instance) ReadFile(path string) ([]byte, error) {
+	if !filepath.IsLocal(path) {
+		return nil, fmt.Errorf("%w: %s is not inside the instance directory", ErrReadingFile, path)
+	}
+	if err := i.lock(); err != nil {
+		return nil, err
+	}
+	defer i.unlock()
+
+	data, err := afero.ReadFile(i.fs, filepath.Join(i.path, path))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadingFile, err)
+	}
+	return data, nil
+}
+
+// WriteFile writes the given data to the file at the given path, relative to
+// the instance directory, creating its parent directories if needed.
+func (i *Instance) WriteFile(path string, data []byte) error {
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("%w: %s is not inside the instance directory", ErrWritingFile, path)
+	}
+	if err := i.lock(); err != nil {
+		return err
+	}
+	defer i.unlock()
+
+	filePath := filepath.Join(i.path, path)
+	if err := i.fs.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("%w: %w", ErrWritingFile, err)
+	}
+	if err := afero.WriteFile(i.fs, filePath, data, 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWritingFile, err)
+	}
+	return nil
+}
+
 // Env returns the environment variables from the .env file of the instance.
 func (i *Instance) Env() (map[string]string, error) {
 	if err := i.lock(); err != nil {
@@ -288,6 +583,56 @@ func (i *Instance) Env() (map[string]string, error) {
 	return env.LoadEnv(i.fs, envPath)
 }
 
+// SetEnv sets the given environment variables in the .env file of the
+// instance. The variables already in the file are changed in place and the new
+// ones are appended, sorted by key, so the other lines, like comments, are
+// kept.
+func (i *Instance) SetEnv(env map[string]string) error {
+	if err := i.lock(); err != nil {
+		return err
+	}
+	defer i.unlock()
+
+	envPath := filepath.Join(i.path, ".env")
+	rawEnv, err := afero.ReadFile(i.fs, envPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrReadingFile, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(rawEnv), "\n"), "\n")
+	if len(rawEnv) == 0 {
+		lines = nil
+	}
+	written := make(map[string]bool, len(env))
+	for n, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, ok := strings.Cut(line, "=")
+		key = strings.Trim(key, " ")
+		value, set := env[key]
+		if !ok || !set {
+			continue
+		}
+		lines[n] = fmt.Sprintf("%s=%s", key, value)
+		written[key] = true
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		if !written[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	envData := strings.Join(lines, "\n") + "\n"
+	if err := afero.WriteFile(i.fs, envPath, []byte(envData), 0o644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWritingFile, err)
+	}
+	return nil
+}
+
 // lock locks the .lock file of the instance.
 func (i *Instance) lock() error {
 	return i.locker.Lock()
@@ -1,11 +1,13 @@
 package data
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/common"
 	"github.com/NethermindEth/eigenlayer/internal/data/testdata"
@@ -14,6 +16,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestNewInstance(t *testing.T) {
@@ -247,8 +250,9 @@ func TestInstance_Init(t *testing.T) {
 						},
 					},
 				},
+				InstalledAt: time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC),
 			},
-			stateJSON: []byte(`{"name":"test_name","url":"` + common.MockAvsPkg.Repo() + `","version":"` + common.MockAvsPkg.Version() + `","spec_version":"` + common.SpecVersion + `","commit":"` + common.MockAvsPkg.CommitHash() + `","profile":"option-returner","tag":"test_tag","monitoring":{"targets":[{"service":"main-service","port":"8080","path":"/metrics"}]}}`),
+			stateJSON: []byte(`{"state_version":1,"name":"test_name","url":"` + common.MockAvsPkg.Repo() + `","version":"` + common.MockAvsPkg.Version() + `","spec_version":"` + common.SpecVersion + `","commit":"` + common.MockAvsPkg.CommitHash() + `","profile":"option-returner","tag":"test_tag","monitoring":{"targets":[{"service":"main-service","port":"8080","path":"/metrics"}]},"installed_at":"2023-10-04T07:12:19Z"}`),
 			mocker: func(path string, locker *mocks.MockLocker) {
 				locker.EXPECT().New(filepath.Join(path, ".lock")).Return(locker)
 			},
@@ -283,6 +287,87 @@ func TestInstance_Init(t *testing.T) {
 	}
 }
 
+func TestInstance_InstalledAtJSON(t *testing.T) {
+	installedAt := time.Date(2023, 10, 4, 7, 12, 19, 0, time.UTC)
+
+	// Current state files keep the installation time
+	stateData, err := json.Marshal(Instance{Name: "mock-avs", Tag: "default", InstalledAt: installedAt})
+	require.NoError(t, err)
+	assert.Contains(t, string(stateData), `"installed_at":"2023-10-04T07:12:19Z"`)
+	var got Instance
+	require.NoError(t, json.Unmarshal(stateData, &got))
+	assert.True(t, installedAt.Equal(got.InstalledAt))
+
+	// State files written before the installation time was recorded are
+	// loaded with a zero installation time
+	got = Instance{}
+	err = json.Unmarshal([]byte(`{"name":"mock-avs","url":"`+common.MockAvsPkg.Repo()+`","version":"`+common.MockAvsPkg.Version()+`","profile":"option-returner","tag":"default"}`), &got)
+	require.NoError(t, err)
+	assert.True(t, got.InstalledAt.IsZero())
+
+	// Older versions ignore the unknown field
+	var older struct {
+		Name string `json:"name"`
+		Tag  string `json:"tag"`
+	}
+	require.NoError(t, json.Unmarshal(stateData, &older))
+	assert.Equal(t, "mock-avs", older.Name)
+	assert.Equal(t, "default", older.Tag)
+}
+
+func TestParseInstanceId(t *testing.T) {
+	ts := []struct {
+		id   string
+		repo string
+		tag  string
+		err  bool
+	}{
+		{id: "mock-avs-default", repo: "mock-avs", tag: "default"},
+		{id: "mock-avs-v1.0.0", repo: "mock-avs", tag: "v1.0.0"},
+		{id: "avs-my_tag", repo: "avs", tag: "my_tag"},
+		{id: "mock-avs-my-tag", repo: "mock-avs-my", tag: "tag"},
+		{id: "", err: true},
+		{id: "noDash", err: true},
+		{id: "-tag", err: true},
+		{id: "repo-", err: true},
+		{id: "repo-t@g", err: true},
+		{id: "repo/name-tag", err: true},
+	}
+	for _, tc := range ts {
+		t.Run(tc.id, func(t *testing.T) {
+			repo, tag, err := ParseInstanceId(tc.id)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrInvalidInstanceId)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.repo, repo)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+}
+
+func TestInstanceIdRoundTrip(t *testing.T) {
+	names := []string{"avs", "mock-avs", "eigenda-operator-setup"}
+	tags := []string{"default", "v1.0.0", "my_tag", "my-tag", "node-1.2_3"}
+	for _, name := range names {
+		for _, tag := range tags {
+			require.NoError(t, ValidateTag(tag))
+			// The IDs built by install are accepted everywhere an ID is parsed
+			instanceId := InstanceId(name, tag)
+			repo, parsedTag, err := ParseInstanceId(instanceId)
+			require.NoError(t, err, instanceId)
+			assert.Equal(t, instanceId, InstanceId(repo, parsedTag))
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"", "-tag", ".tag", "t@g", "my/tag", "my tag"} {
+		assert.ErrorIs(t, ValidateTag(tag), ErrInvalidTag, tag)
+	}
+}
+
 func TestInstance_Setup(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	instancePath, err := afero.TempDir(fs, "", "instance")
@@ -339,6 +424,87 @@ func TestInstance_Setup(t *testing.T) {
 	envData, err := io.ReadAll(envFile)
 	assert.NoError(t, err)
 	assert.Equal(t, []byte("VAR_1=value-1\n"), envData)
+
+	// The services are labeled with the instance ID
+	composeData, err := afero.ReadFile(fs, filepath.Join(instancePath, "docker-compose.yml"))
+	require.NoError(t, err)
+	var composeFile struct {
+		Services map[string]struct {
+			Labels map[string]string `yaml:"labels"`
+		} `yaml:"services"`
+	}
+	require.NoError(t, yaml.Unmarshal(composeData, &composeFile))
+	assert.Equal(t, "mock-avs-test-tag", composeFile.Services["main-service"].Labels[InstanceContainerLabel])
+}
+
+func TestInstance_LabelServices(t *testing.T) {
+	ts := []struct {
+		name    string
+		compose string
+		want    string
+		err     error
+	}{
+		{
+			name:    "no labels",
+			compose: "services:\n  main:\n    image: busybox\n",
+			want:    "services:\n  main:\n    image: busybox\n    labels:\n      egn.instance_id: mock-avs-default\n",
+		},
+		{
+			name:    "empty labels",
+			compose: "services:\n  main:\n    labels:\n",
+			want:    "services:\n  main:\n    labels:\n      egn.instance_id: mock-avs-default\n",
+		},
+		{
+			name:    "labels mapping",
+			compose: "services:\n  main:\n    labels:\n      app: main\n      egn.instance_id: other\n",
+			want:    "services:\n  main:\n    labels:\n      app: main\n      egn.instance_id: mock-avs-default\n",
+		},
+		{
+			name:    "labels list",
+			compose: "services:\n  main:\n    labels:\n      - app=main\n      - egn.instance_id=other\n",
+			want:    "services:\n  main:\n    labels:\n      - app=main\n      - egn.instance_id=mock-avs-default\n",
+		},
+		{
+			name:    "monitoring target",
+			compose: "services:\n  main:\n    image: busybox\n  metrics:\n    labels:\n      - app=metrics\n",
+			want:    "services:\n  main:\n    image: busybox\n    labels:\n      egn.instance_id: mock-avs-default\n  metrics:\n    labels:\n      - app=metrics\n      - egn.instance_id=mock-avs-default\n      - egn.monitoring_target=true\n",
+		},
+		{
+			name:    "no services",
+			compose: "networks:\n  default: {}\n",
+			want:    "networks:\n  default: {}\n",
+		},
+		{
+			name:    "invalid labels",
+			compose: "services:\n  main:\n    labels: app\n",
+			err:     ErrInvalidInstance,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			i := Instance{
+				Name: "mock-avs",
+				Tag:  "default",
+				MonitoringTargets: MonitoringTargets{
+					Targets: []MonitoringTarget{{Service: "metrics", Port: "9090", Path: "/metrics"}},
+				},
+				path: "/instance",
+				fs:   fs,
+			}
+			require.NoError(t, afero.WriteFile(fs, i.ComposePath(), []byte(tt.compose), 0o644))
+
+			err := i.labelServices()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			got, err := afero.ReadFile(fs, i.ComposePath())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
 }
 
 func TestInstance_Env(t *testing.T) {
@@ -407,6 +573,132 @@ func TestInstance_Env(t *testing.T) {
 	}
 }
 
+func TestInstance_SetEnv(t *testing.T) {
+	tc := []struct {
+		name    string
+		env     string
+		set     map[string]string
+		want    string
+		wantEnv map[string]string
+	}{
+		{
+			name: "keeps comments and values with equal signs",
+			env:  "# Keys\nKEY=a=b\nURL=http://localhost/?a=b&c=d\n\n# Ports\nMAIN_PORT=8080\n",
+			set:  map[string]string{"MAIN_PORT": "9090"},
+			want: "# Keys\nKEY=a=b\nURL=http://localhost/?a=b&c=d\n\n# Ports\nMAIN_PORT=9090\n",
+			wantEnv: map[string]string{
+				"KEY":       "a=b",
+				"URL":       "http://localhost/?a=b&c=d",
+				"MAIN_PORT": "9090",
+			},
+		},
+		{
+			name: "value with equal signs",
+			env:  "# Keys\nKEY=a=b\n",
+			set:  map[string]string{"KEY": "c2VjcmV0==", "EXTRA": "x=y"},
+			want: "# Keys\nKEY=c2VjcmV0==\nEXTRA=x=y\n",
+			wantEnv: map[string]string{
+				"KEY":   "c2VjcmV0==",
+				"EXTRA": "x=y",
+			},
+		},
+		{
+			name:    "new variables sorted",
+			env:     "",
+			set:     map[string]string{"B": "2", "A": "1"},
+			want:    "A=1\nB=2\n",
+			wantEnv: map[string]string{"A": "1", "B": "2"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/instance/.env", []byte(tt.env), 0o644))
+
+			ctrl := gomock.NewController(t)
+			l := mocks.NewMockLocker(ctrl)
+			l.EXPECT().Lock().Return(nil).Times(2)
+			l.EXPECT().Locked().Return(true).Times(2)
+			l.EXPECT().Unlock().Return(nil).Times(2)
+
+			i := Instance{
+				path:   "/instance",
+				fs:     fs,
+				locker: l,
+			}
+			require.NoError(t, i.SetEnv(tt.set))
+
+			got, err := afero.ReadFile(fs, "/instance/.env")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			e, err := i.Env()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnv, e)
+		})
+	}
+}
+
+func TestInstance_ReadFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	instancePath, err := afero.TempDir(fs, "", "instance")
+	require.NoError(t, err)
+	require.NoError(t, fs.MkdirAll(filepath.Join(instancePath, "rules"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(instancePath, "rules", "avs.yml"), []byte("groups: []"), 0o644))
+
+	tc := []struct {
+		name    string
+		path    string
+		locks   bool
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:  "existing file",
+			path:  "rules/avs.yml",
+			locks: true,
+			want:  []byte("groups: []"),
+		},
+		{
+			name:    "missing file",
+			path:    "rules/missing.yml",
+			locks:   true,
+			wantErr: ErrReadingFile,
+		},
+		{
+			name:    "path outside the instance",
+			path:    "../other/rules.yml",
+			wantErr: ErrReadingFile,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			l := mocks.NewMockLocker(ctrl)
+			if tt.locks {
+				gomock.InOrder(
+					l.EXPECT().Lock().Return(nil),
+					l.EXPECT().Locked().Return(true),
+					l.EXPECT().Unlock().Return(nil),
+				)
+			}
+
+			i := Instance{
+				path:   instancePath,
+				fs:     fs,
+				locker: l,
+			}
+
+			got, err := i.ReadFile(tt.path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
 func TestInstance_ComposeProject(t *testing.T) {
 	fs := afero.NewOsFs()
 	dir := testdata.SetupProfileFS(t, "option-returner", fs)
@@ -439,3 +731,95 @@ func TestInstance_ComposeProject(t *testing.T) {
 	// Check main-service container name
 	require.Equal(t, "main-service", mainService.ContainerName)
 }
+
+func TestInstance_SetId(t *testing.T) {
+	fs := afero.NewOsFs()
+
+	// Back up an instance and restore its data under a new instance ID
+	instanceData := t.TempDir()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(instanceData, "state.json"), []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","profile":"option-returner"}`), 0o644))
+	require.NoError(t, fs.MkdirAll(filepath.Join(instanceData, "db"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(instanceData, "db", "chain.db"), []byte("chain data"), 0o644))
+	backupPath := filepath.Join(t.TempDir(), "mock-avs-default-1696317683.tar.gz")
+	backupFile, err := fs.Create(backupPath)
+	require.NoError(t, err)
+	_, err = CreateBackup(fs, "mock-avs-default", instanceData, backupFile)
+	require.NoError(t, err)
+	require.NoError(t, backupFile.Close())
+	tarPath := filepath.Join(t.TempDir(), "backup.tar")
+	require.NoError(t, DecompressTar(fs, backupPath, tarPath))
+
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	dataDir, err := NewDataDir(t.TempDir(), fs, locker)
+	require.NoError(t, err)
+	require.NoError(t, dataDir.ReplaceInstanceDirFromTar("mock-avs-compare", tarPath, "data"))
+
+	locker.EXPECT().New(gomock.Any()).Return(locker).Times(2)
+	gomock.InOrder(
+		locker.EXPECT().Lock().Return(nil),
+		locker.EXPECT().Locked().Return(true),
+		locker.EXPECT().Unlock().Return(nil),
+	)
+	instance, err := dataDir.Instance("mock-avs-compare")
+	require.NoError(t, err)
+	assert.Equal(t, "mock-avs-default", instance.ID())
+	require.NoError(t, instance.SetId("mock-avs-compare"))
+
+	restored, err := dataDir.Instance("mock-avs-compare")
+	require.NoError(t, err)
+	assert.Equal(t, "mock-avs-compare", restored.ID())
+	assert.Equal(t, "mock-avs", restored.Name)
+	assert.Equal(t, "compare", restored.Tag)
+	assert.Equal(t, "v0.1.0", restored.Version)
+	assert.Equal(t, "option-returner", restored.Profile)
+	chainData, err := afero.ReadFile(fs, filepath.Join(dataDir.Path(), "nodes", "mock-avs-compare", "db", "chain.db"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("chain data"), chainData)
+
+	// Invalid instance IDs are rejected without changing the state
+	assert.ErrorIs(t, restored.SetId("mock_avs"), ErrInvalidInstanceId)
+
+	// A tag with dashes is told apart from the name of the instance
+	gomock.InOrder(
+		locker.EXPECT().Lock().Return(nil),
+		locker.EXPECT().Locked().Return(true),
+		locker.EXPECT().Unlock().Return(nil),
+	)
+	require.NoError(t, restored.SetId("mock-avs-my-compare"))
+	assert.Equal(t, "mock-avs", restored.Name)
+	assert.Equal(t, "my-compare", restored.Tag)
+}
+
+func TestInstance_SetLabels(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+	dataDir, err := NewDataDir("/egn", fs, locker)
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","tag":"default","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v0.1.0","profile":"option-returner"}`), 0o644))
+
+	instance, err := dataDir.Instance("mock-avs-default")
+	require.NoError(t, err)
+	assert.Nil(t, instance.Labels)
+
+	labels := map[string]string{"env": "prod", "team": "infra"}
+	require.NoError(t, instance.SetLabels(labels))
+	// The instance does not share the map of the caller
+	labels["env"] = "dev"
+
+	labeled, err := dataDir.Instance("mock-avs-default")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "infra"}, labeled.Labels)
+	assert.Equal(t, "v0.1.0", labeled.Version)
+
+	// Removing all the labels drops them from the state
+	require.NoError(t, labeled.SetLabels(map[string]string{}))
+	state, err := afero.ReadFile(fs, "/egn/nodes/mock-avs-default/state.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(state), "labels")
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// DataDirEnv is the environment variable that overrides the path of the egn
+// data directory.
+const DataDirEnv = "EGN_DATA_DIR"
+
+// dataDirName is the name of the egn data directory inside the user data
+// directory of the OS.
+const dataDirName = ".eigen"
+
+// LocateDataDir returns the path of the egn data directory, where the
+// instances, the backups and the monitoring stack are stored. It is
+// $EGN_DATA_DIR if set, or the .eigen directory inside the user data directory
+// of the OS otherwise:
+//
+//   - Linux and other Unix systems: $XDG_DATA_HOME, or ~/.local/share if not set.
+//   - macOS: ~/Library/Application Support. $XDG_DATA_HOME is used if set, as
+//     is ~/.local/share if it holds the data directory of an earlier egn
+//     version.
+//   - Windows: %APPDATA%.
+func LocateDataDir() (string, error) {
+	return dataDirLocator{
+		goos:        runtime.GOOS,
+		getenv:      os.Getenv,
+		userHomeDir: os.UserHomeDir,
+		dirExists: func(path string) bool {
+			info, err := os.Stat(path)
+			return err == nil && info.IsDir()
+		},
+	}.locate()
+}
+
+// dataDirLocator locates the egn data directory with the given OS, environment
+// and filesystem, so the location on each OS can be tested anywhere.
+type dataDirLocator struct {
+	goos        string
+	getenv      func(string) string
+	userHomeDir func() (string, error)
+	dirExists   func(string) bool
+}
+
+func (l dataDirLocator) locate() (string, error) {
+	if dataDir := l.getenv(DataDirEnv); dataDir != "" {
+		return filepath.Abs(dataDir)
+	}
+	if l.goos == "windows" {
+		if appData := l.getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, dataDirName), nil
+		}
+		home, err := l.userHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "AppData", "Roaming", dataDirName), nil
+	}
+
+	if dataHome := l.getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, dataDirName), nil
+	}
+	home, err := l.userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	xdgDataDir := filepath.Join(home, ".local", "share", dataDirName)
+	if l.goos == "darwin" && !l.dirExists(xdgDataDir) {
+		return filepath.Join(home, "Library", "Application Support", dataDirName), nil
+	}
+	return xdgDataDir, nil
+}
@@ -0,0 +1,123 @@
+package data
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocateDataDir(t *testing.T) {
+	home := filepath.FromSlash("/home/egn")
+	ts := []struct {
+		name     string
+		goos     string
+		env      map[string]string
+		existing []string
+		homeErr  error
+		want     string
+		err      error
+	}{
+		{
+			name: "linux",
+			goos: "linux",
+			want: filepath.Join(home, ".local", "share", ".eigen"),
+		},
+		{
+			name: "linux, XDG_DATA_HOME",
+			goos: "linux",
+			env:  map[string]string{"XDG_DATA_HOME": filepath.FromSlash("/data")},
+			want: filepath.Join("/data", ".eigen"),
+		},
+		{
+			name: "linux, EGN_DATA_DIR",
+			goos: "linux",
+			env:  map[string]string{DataDirEnv: filepath.FromSlash("/egn"), "XDG_DATA_HOME": filepath.FromSlash("/data")},
+			want: filepath.FromSlash("/egn"),
+		},
+		{
+			name: "macOS",
+			goos: "darwin",
+			want: filepath.Join(home, "Library", "Application Support", ".eigen"),
+		},
+		{
+			name:     "macOS, data dir of an earlier version",
+			goos:     "darwin",
+			existing: []string{filepath.Join(home, ".local", "share", ".eigen")},
+			want:     filepath.Join(home, ".local", "share", ".eigen"),
+		},
+		{
+			name: "macOS, XDG_DATA_HOME",
+			goos: "darwin",
+			env:  map[string]string{"XDG_DATA_HOME": filepath.FromSlash("/data")},
+			want: filepath.Join("/data", ".eigen"),
+		},
+		{
+			name: "macOS, EGN_DATA_DIR",
+			goos: "darwin",
+			env:  map[string]string{DataDirEnv: filepath.FromSlash("/egn")},
+			want: filepath.FromSlash("/egn"),
+		},
+		{
+			name: "windows",
+			goos: "windows",
+			env:  map[string]string{"APPDATA": filepath.FromSlash("/Users/egn/AppData/Roaming"), "XDG_DATA_HOME": filepath.FromSlash("/data")},
+			want: filepath.Join("/Users/egn/AppData/Roaming", ".eigen"),
+		},
+		{
+			name: "windows, no APPDATA",
+			goos: "windows",
+			want: filepath.Join(home, "AppData", "Roaming", ".eigen"),
+		},
+		{
+			name: "windows, EGN_DATA_DIR",
+			goos: "windows",
+			env:  map[string]string{DataDirEnv: filepath.FromSlash("/egn"), "APPDATA": filepath.FromSlash("/Users/egn/AppData/Roaming")},
+			want: filepath.FromSlash("/egn"),
+		},
+		{
+			name:    "no home directory",
+			goos:    "linux",
+			homeErr: errors.New("$HOME is not defined"),
+			err:     errors.New("$HOME is not defined"),
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			locator := dataDirLocator{
+				goos: tt.goos,
+				getenv: func(key string) string {
+					return tt.env[key]
+				},
+				userHomeDir: func() (string, error) {
+					return home, tt.homeErr
+				},
+				dirExists: func(path string) bool {
+					for _, existing := range tt.existing {
+						if path == existing {
+							return true
+						}
+					}
+					return false
+				},
+			}
+			got, err := locator.locate()
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocateDataDirOverride(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(DataDirEnv, dataDir)
+	got, err := LocateDataDir()
+	require.NoError(t, err)
+	assert.Equal(t, dataDir, got)
+}
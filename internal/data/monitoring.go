@@ -1,14 +1,19 @@
 package data
 
 import (
+	"archive/tar"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/NethermindEth/eigenlayer/internal/locker"
+	"github.com/NethermindEth/eigenlayer/internal/utils"
 	"github.com/spf13/afero"
 )
 
@@ -17,6 +22,39 @@ type MonitoringStack struct {
 	path string
 	fs   afero.Fs
 	l    locker.Locker
+	// mu serializes the stack operations inside the process, as the file lock
+	// is shared by all the goroutines using the same stack.
+	mu    sync.Mutex
+	perms StackPermissions
+}
+
+// StackPermissions are the permissions and ownership of the directories and
+// files created in the monitoring stack. The zero value keeps the default
+// permissions and the ownership of the current user.
+type StackPermissions struct {
+	// DirMode is the mode of the created directories. Defaults to 0o755.
+	DirMode fs.FileMode
+	// FileMode is the mode of the created files. Defaults to 0o644 for the
+	// files written with WriteFile and to the file system default for Create.
+	FileMode fs.FileMode
+	// Owner is the owner of the created directories and files. The current
+	// user owns them if it is nil.
+	Owner *Owner
+}
+
+// Owner is the user and group ownership of a file.
+type Owner struct {
+	UID int
+	GID int
+}
+
+// SetPermissions sets the permissions and ownership of the directories and
+// files created in the monitoring stack from now on, so they match the user
+// of the containers that read them. Existing files are not changed.
+func (m *MonitoringStack) SetPermissions(perms StackPermissions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.perms = perms
 }
 
 // newMonitoringStack creates a new monitoring stack with the given path as root.
@@ -38,14 +76,21 @@ func (m *MonitoringStack) Init() error {
 
 // Lock locks the monitoring stack
 func (m *MonitoringStack) lock() error {
+	m.mu.Lock()
 	if m.l == nil {
+		m.mu.Unlock()
 		return ErrStackNotInitialized
 	}
-	return m.l.Lock()
+	if err := m.l.Lock(); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	return nil
 }
 
 // Unlock unlocks the monitoring stack
 func (m *MonitoringStack) unlock() error {
+	defer m.mu.Unlock()
 	if m.l == nil || !m.l.Locked() {
 		return errors.New("monitoring stack is not locked")
 	}
@@ -67,7 +112,7 @@ func (m *MonitoringStack) Setup(env map[string]string, monitoringFs fs.FS) (err
 	}()
 
 	// Create .env file
-	envFile, err := m.fs.Create(filepath.Join(m.path, ".env"))
+	envFile, err := m.create(filepath.Join(m.path, ".env"))
 	if err != nil {
 		return err
 	}
@@ -82,7 +127,7 @@ func (m *MonitoringStack) Setup(env map[string]string, monitoringFs fs.FS) (err
 		return err
 	}
 	defer mComposeFile.Close()
-	composeFile, err := m.fs.Create(filepath.Join(m.path, "docker-compose.yml"))
+	composeFile, err := m.create(filepath.Join(m.path, "docker-compose.yml"))
 	if err != nil {
 		return err
 	}
@@ -109,7 +154,7 @@ func (m *MonitoringStack) CreateDir(path string) (err error) {
 		}
 	}()
 
-	return m.fs.MkdirAll(filepath.Join(m.path, path), 0o755)
+	return m.mkdirAll(filepath.Join(m.path, path))
 }
 
 // Create creates a new file in the monitoring stack at the given path.
@@ -125,7 +170,77 @@ func (m *MonitoringStack) Create(path string) (f afero.File, err error) {
 		}
 	}()
 
-	return m.fs.Create(filepath.Join(m.path, path))
+	return m.create(filepath.Join(m.path, path))
+}
+
+// mkdirAll creates the directory at the given absolute path and its missing
+// parents, with the permissions of the stack.
+func (m *MonitoringStack) mkdirAll(path string) error {
+	// Find the directories to create, so only those get the permissions
+	var missing []string
+	for dir := path; dir != m.path && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := m.fs.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, dir)
+	}
+	mode := m.perms.DirMode
+	if mode == 0 {
+		mode = 0o755
+	}
+	if err := m.fs.MkdirAll(path, mode); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := m.applyPermissions(missing[i], m.perms.DirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create creates or truncates the file at the given absolute path, with the
+// permissions of the stack.
+func (m *MonitoringStack) create(path string) (afero.File, error) {
+	if m.perms.FileMode == 0 {
+		f, err := m.fs.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = m.applyPermissions(path, 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+	f, err := m.fs.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, m.perms.FileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.applyPermissions(path, m.perms.FileMode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// applyPermissions sets the given mode, if any, and the owner of the stack,
+// if any, to the file at the given absolute path. The mode is set explicitly
+// as the mode used to create a file is masked by the umask.
+func (m *MonitoringStack) applyPermissions(path string, mode fs.FileMode) error {
+	if mode != 0 {
+		if err := m.fs.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	if m.perms.Owner != nil {
+		if err := m.fs.Chown(path, m.perms.Owner.UID, m.perms.Owner.GID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ReadFile reads the file at the given path in the monitoring stack.
@@ -163,13 +278,71 @@ func (m *MonitoringStack) WriteFile(path string, data []byte) (err error) {
 		}
 	}()
 
-	err = afero.WriteFile(m.fs, filepath.Join(m.path, path), data, 0o644)
+	mode := m.perms.FileMode
+	if mode == 0 {
+		mode = 0o644
+	}
+	filePath := filepath.Join(m.path, path)
+	err = afero.WriteFile(m.fs, filePath, data, mode)
+	if err == nil {
+		err = m.applyPermissions(filePath, m.perms.FileMode)
+	}
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrWritingFile, err)
 	}
 	return nil
 }
 
+// RemoveFile removes the file at the given path in the monitoring stack.
+func (m *MonitoringStack) RemoveFile(path string) (err error) {
+	err = m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return m.fs.Remove(filepath.Join(m.path, path))
+}
+
+// RemoveAll removes the file or directory at the given path in the monitoring
+// stack and any children it contains.
+func (m *MonitoringStack) RemoveAll(path string) (err error) {
+	err = m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return m.fs.RemoveAll(filepath.Join(m.path, path))
+}
+
+// Exists checks if a file or directory exists at the given path in the
+// monitoring stack.
+func (m *MonitoringStack) Exists(path string) (exists bool, err error) {
+	err = m.lock()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return afero.Exists(m.fs, filepath.Join(m.path, path))
+}
+
 // Installed checks if the monitoring stack is installed.
 func (m *MonitoringStack) Installed() (installed bool, err error) {
 	err = m.lock()
@@ -202,6 +375,136 @@ func (m *MonitoringStack) Installed() (installed bool, err error) {
 	return true, nil
 }
 
+// stackLockFile is the lock file of the monitoring stack, which is not part of
+// its provisioning.
+const stackLockFile = ".lock"
+
+// Export writes the provisioning of the monitoring stack, every file and
+// directory in it but the lock file, to w as a tar bundle, so it can be
+// imported in the stack of another host with Import.
+func (m *MonitoringStack) Export(w io.Writer) (err error) {
+	err = m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return utils.TarWrite(w, m.path, m.fs, func(name string, info fs.FileInfo) bool {
+		// Only directories and regular files can be imported
+		return name == stackLockFile || (!info.IsDir() && !info.Mode().IsRegular())
+	})
+}
+
+// Import replaces the provisioning of the monitoring stack with the tar bundle
+// read from r, written by Export. The whole bundle is checked before the
+// stack is changed: an ErrInvalidMonitoringBundle error is returned if it is
+// not a tar bundle of an installed stack, and an ErrUnsafeTarEntry error if an
+// entry could be written outside the stack. The files and directories are
+// created with the permissions of the stack.
+func (m *MonitoringStack) Import(r io.Reader) (err error) {
+	dirs, files, err := readMonitoringBundle(r)
+	if err != nil {
+		return err
+	}
+
+	err = m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	entries, err := afero.ReadDir(m.fs, m.path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == stackLockFile {
+			continue
+		}
+		if err := m.fs.RemoveAll(filepath.Join(m.path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirs {
+		if err := m.mkdirAll(filepath.Join(m.path, filepath.FromSlash(dir))); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		filePath := filepath.Join(m.path, filepath.FromSlash(name))
+		if err := m.mkdirAll(filepath.Dir(filePath)); err != nil {
+			return err
+		}
+		f, err := m.create(filePath)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(files[name])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrWritingFile, err)
+		}
+	}
+	return nil
+}
+
+// readMonitoringBundle reads the directories and the files, keyed by their
+// path, of a monitoring bundle. The bundle is small, it only has the
+// provisioning of the stack, so it is read in memory to check it completely
+// before changing the stack.
+func readMonitoringBundle(r io.Reader) (dirs []string, files map[string][]byte, err error) {
+	files = make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidMonitoringBundle, err)
+		}
+		name := path.Clean(header.Name)
+		if err := checkTarEntry(header, name); err != nil {
+			return nil, nil, err
+		}
+		if name == stackLockFile {
+			continue
+		}
+		if header.Typeflag == tar.TypeDir {
+			dirs = append(dirs, name)
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidMonitoringBundle, err)
+		}
+		files[name] = content
+	}
+	for _, required := range []string{".env", "docker-compose.yml"} {
+		if _, ok := files[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: %s not found", ErrInvalidMonitoringBundle, required)
+		}
+	}
+	return dirs, files, nil
+}
+
 // Path returns the path to the monitoring stack datadir.
 func (m *MonitoringStack) Path() string {
 	return m.path
@@ -219,6 +522,7 @@ func (m *MonitoringStack) Cleanup(force bool) (err error) {
 		defer func() {
 			// Reset locker
 			m.l = nil
+			m.mu.Unlock()
 		}()
 	}
 	return m.fs.RemoveAll(m.path)
@@ -1,15 +1,20 @@
 package data
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
 	"errors"
 	"io/fs"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/data/testdata"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
 	"github.com/NethermindEth/eigenlayer/internal/locker/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/afero"
@@ -788,6 +793,62 @@ func TestInstalled(t *testing.T) {
 	}
 }
 
+func TestExistsAndRemoveAll(t *testing.T) {
+	// Create an in-memory filesystem
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/testdir/sub/file.txt", []byte("data"), 0o644))
+
+	// Create a mock locker
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+
+	// Expect the lock to be acquired on every call
+	for i := 0; i < 4; i++ {
+		gomock.InOrder(
+			locker.EXPECT().Lock().Return(nil),
+			locker.EXPECT().Locked().Return(true),
+			locker.EXPECT().Unlock().Return(nil),
+		)
+	}
+
+	// Create a new MonitoringStack with the in-memory filesystem
+	stack := &MonitoringStack{
+		path: "/",
+		l:    locker,
+		fs:   afs,
+	}
+
+	exists, err := stack.Exists("testdir/sub/file.txt")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Remove a non-empty directory
+	require.NoError(t, stack.RemoveAll("testdir"))
+
+	exists, err = stack.Exists("testdir/sub/file.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = stack.Exists("testdir")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestExistsLockError(t *testing.T) {
+	// Create a mock locker
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().Lock().Return(errors.New("lock error"))
+
+	stack := &MonitoringStack{
+		path: "/",
+		l:    locker,
+		fs:   afero.NewMemMapFs(),
+	}
+
+	_, err := stack.Exists("testdir")
+	assert.EqualError(t, err, "lock error")
+}
+
 func TestPath(t *testing.T) {
 	// dumbest test of all times lol
 	t.Parallel()
@@ -910,3 +971,316 @@ func TestCleanup(t *testing.T) {
 		})
 	}
 }
+
+// countingLocker is a locker that records the maximum number of concurrent
+// holders. As a file lock, it does not exclude goroutines of the same process.
+type countingLocker struct {
+	mu         sync.Mutex
+	holders    int
+	maxHolders int
+}
+
+func (l *countingLocker) New(path string) locker.Locker { return l }
+
+func (l *countingLocker) Lock() error {
+	l.mu.Lock()
+	l.holders++
+	if l.holders > l.maxHolders {
+		l.maxHolders = l.holders
+	}
+	l.mu.Unlock()
+	// Give other goroutines the chance to enter the critical section
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (l *countingLocker) TryLockContext(ctx context.Context, retryDelay time.Duration) (bool, error) {
+	return true, l.Lock()
+}
+
+func (l *countingLocker) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holders--
+	return nil
+}
+
+func (l *countingLocker) Locked() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holders > 0
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	afs := afero.NewOsFs()
+	l := &countingLocker{}
+	stack := newMonitoringStack(t.TempDir(), afs, l)
+	require.NoError(t, stack.Init())
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Every writer creates the same shared directory and its own
+			// service directory, as services do during Setup.
+			if err := stack.CreateDir(filepath.Join("shared", "provisioning")); err != nil {
+				errs <- err
+				return
+			}
+			serviceDir := filepath.Join("service-"+strconv.Itoa(i), "config")
+			if err := stack.CreateDir(serviceDir); err != nil {
+				errs <- err
+				return
+			}
+			if err := stack.WriteFile(filepath.Join(serviceDir, "config.yml"), []byte("service: "+strconv.Itoa(i))); err != nil {
+				errs <- err
+				return
+			}
+			if err := stack.WriteFile(filepath.Join("shared", "provisioning", strconv.Itoa(i)+".yml"), []byte(strconv.Itoa(i))); err != nil {
+				errs <- err
+				return
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, l.maxHolders, "stack operations overlapped")
+
+	for i := 0; i < writers; i++ {
+		data, err := stack.ReadFile(filepath.Join("service-"+strconv.Itoa(i), "config", "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "service: "+strconv.Itoa(i), string(data))
+		data, err = stack.ReadFile(filepath.Join("shared", "provisioning", strconv.Itoa(i)+".yml"))
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i), string(data))
+	}
+}
+
+// chownRecorderFs is a file system that records the Chown calls instead of
+// changing the owner, which requires privileges.
+type chownRecorderFs struct {
+	afero.Fs
+	owners map[string]Owner
+}
+
+func (c *chownRecorderFs) Chown(name string, uid, gid int) error {
+	c.owners[name] = Owner{UID: uid, GID: gid}
+	return nil
+}
+
+func TestStackPermissions(t *testing.T) {
+	ts := []struct {
+		name     string
+		perms    StackPermissions
+		dirMode  fs.FileMode
+		fileMode fs.FileMode
+		// createMode is the mode of the files created with Create
+		createMode fs.FileMode
+		// exact is false if the modes are the defaults, which are masked by
+		// the umask
+		exact bool
+		owned bool
+	}{
+		{
+			name:       "default permissions",
+			dirMode:    0o755,
+			fileMode:   0o644,
+			createMode: 0o644,
+		},
+		{
+			name: "custom modes",
+			perms: StackPermissions{
+				DirMode:  0o750,
+				FileMode: 0o640,
+			},
+			dirMode:    0o750,
+			fileMode:   0o640,
+			createMode: 0o640,
+			exact:      true,
+		},
+		{
+			name: "modes wider than the umask",
+			perms: StackPermissions{
+				DirMode:  0o777,
+				FileMode: 0o666,
+			},
+			dirMode:    0o777,
+			fileMode:   0o666,
+			createMode: 0o666,
+			exact:      true,
+		},
+		{
+			name: "owner",
+			perms: StackPermissions{
+				Owner: &Owner{UID: 1000, GID: 1000},
+			},
+			dirMode:    0o755,
+			fileMode:   0o644,
+			createMode: 0o644,
+			owned:      true,
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.name, func(t *testing.T) {
+			afs := &chownRecorderFs{Fs: afero.NewOsFs(), owners: make(map[string]Owner)}
+			root := t.TempDir()
+			stack := newMonitoringStack(root, afs, &countingLocker{})
+			require.NoError(t, stack.Init())
+			stack.SetPermissions(tc.perms)
+
+			require.NoError(t, stack.CreateDir(filepath.Join("grafana", "provisioning")))
+			require.NoError(t, stack.WriteFile(filepath.Join("grafana", "grafana.ini"), []byte("[server]")))
+			f, err := stack.Create(filepath.Join("grafana", "provisioning", "prom.yml"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			for path, mode := range map[string]fs.FileMode{
+				filepath.Join(root, "grafana"):                             tc.dirMode,
+				filepath.Join(root, "grafana", "provisioning"):             tc.dirMode,
+				filepath.Join(root, "grafana", "grafana.ini"):              tc.fileMode,
+				filepath.Join(root, "grafana", "provisioning", "prom.yml"): tc.createMode,
+			} {
+				info, err := afs.Stat(path)
+				require.NoError(t, err)
+				if tc.exact {
+					assert.Equal(t, mode, info.Mode().Perm(), path)
+				} else {
+					assert.Equal(t, fs.FileMode(0), info.Mode().Perm()&^mode, path)
+				}
+				if tc.owned {
+					assert.Equal(t, *tc.perms.Owner, afs.owners[path], path)
+				} else {
+					assert.NotContains(t, afs.owners, path)
+				}
+			}
+		})
+	}
+}
+
+// stackTree returns the directories and the files, with their content, of the
+// monitoring stack, keyed by their path relative to the stack.
+func stackTree(t *testing.T, stack *MonitoringStack) map[string]string {
+	tree := make(map[string]string)
+	err := afero.Walk(stack.fs, stack.path, func(path string, info fs.FileInfo, err error) error {
+		require.NoError(t, err)
+		relPath, err := filepath.Rel(stack.path, path)
+		require.NoError(t, err)
+		if relPath == "." || relPath == stackLockFile {
+			return nil
+		}
+		if info.IsDir() {
+			tree[relPath+"/"] = ""
+			return nil
+		}
+		content, err := afero.ReadFile(stack.fs, path)
+		require.NoError(t, err)
+		tree[relPath] = string(content)
+		return nil
+	})
+	require.NoError(t, err)
+	return tree
+}
+
+func TestExportImport(t *testing.T) {
+	afs := afero.NewOsFs()
+	stack := newMonitoringStack(t.TempDir(), afs, &countingLocker{})
+	require.NoError(t, stack.Init())
+	for path, content := range map[string]string{
+		".env":                      "GRAFANA_PORT=3000\n",
+		"docker-compose.yml":        "services: {}\n",
+		"prometheus/prometheus.yml": "scrape_configs: []\n",
+		"prometheus/targets.json":   `[{"targets":["mock-avs:8080"]}]`,
+		"grafana/grafana.ini":       "[server]\n",
+		"grafana/provisioning/datasources/datasource.yml":    "datasources: []\n",
+		"grafana/provisioning/dashboards/dashboards.yml":     "providers: []\n",
+		"grafana/data/dashboards/mock-avs-default/node.json": `{"uid":"node"}`,
+	} {
+		require.NoError(t, stack.CreateDir(filepath.Dir(path)))
+		require.NoError(t, stack.WriteFile(path, []byte(content)))
+	}
+	require.NoError(t, stack.CreateDir(filepath.Join("prometheus", "rules")))
+	want := stackTree(t, stack)
+
+	var bundle bytes.Buffer
+	require.NoError(t, stack.Export(&bundle))
+
+	// A fresh stack gets the same tree, and the stale files of another stack
+	// are replaced
+	for _, name := range []string{"fresh", "existing"} {
+		t.Run(name, func(t *testing.T) {
+			other := newMonitoringStack(t.TempDir(), afs, &countingLocker{})
+			require.NoError(t, other.Init())
+			if name == "existing" {
+				require.NoError(t, other.CreateDir("old"))
+				require.NoError(t, other.WriteFile(filepath.Join("old", "prometheus.yml"), []byte("old")))
+				require.NoError(t, other.WriteFile(".env", []byte("OLD=1\n")))
+			}
+
+			require.NoError(t, other.Import(bytes.NewReader(bundle.Bytes())))
+			assert.Equal(t, want, stackTree(t, other))
+			installed, err := other.Installed()
+			require.NoError(t, err)
+			assert.True(t, installed)
+		})
+	}
+}
+
+func TestImportError(t *testing.T) {
+	bundle := func(entries ...*tar.Header) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, header := range entries {
+			require.NoError(t, tw.WriteHeader(header))
+		}
+		require.NoError(t, tw.Close())
+		return buf.Bytes()
+	}
+	file := func(name string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644}
+	}
+
+	tests := []struct {
+		name   string
+		bundle []byte
+		err    error
+	}{
+		{
+			name:   "not a tar",
+			bundle: []byte("not a tar bundle, but long enough to be read as a tar header block"),
+			err:    ErrInvalidMonitoringBundle,
+		},
+		{
+			name:   "missing .env",
+			bundle: bundle(file("docker-compose.yml")),
+			err:    ErrInvalidMonitoringBundle,
+		},
+		{
+			name:   "entry outside the stack",
+			bundle: bundle(file(".env"), file("docker-compose.yml"), file("../prometheus.yml")),
+			err:    ErrUnsafeTarEntry,
+		},
+		{
+			name:   "link",
+			bundle: bundle(file(".env"), file("docker-compose.yml"), &tar.Header{Typeflag: tar.TypeSymlink, Name: "prometheus.yml", Linkname: "/etc/passwd"}),
+			err:    ErrUnsafeTarEntry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := newMonitoringStack(t.TempDir(), afero.NewOsFs(), &countingLocker{})
+			require.NoError(t, stack.Init())
+			require.NoError(t, stack.WriteFile(".env", []byte("GRAFANA_PORT=3000\n")))
+
+			err := stack.Import(bytes.NewReader(tt.bundle))
+			assert.ErrorIs(t, err, tt.err)
+			// The stack is not changed
+			assert.Equal(t, map[string]string{".env": "GRAFANA_PORT=3000\n"}, stackTree(t, stack))
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,8 +14,10 @@ import (
 	dockerCt "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stdcopy"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/NethermindEth/eigenlayer/internal/common"
@@ -23,6 +26,15 @@ import (
 
 const NetworkHost = "host"
 
+const (
+	// composeProjectLabel is the label with the project name that docker
+	// compose sets on the resources it creates.
+	composeProjectLabel = "com.docker.compose.project"
+	// composeVolumeLabel is the label with the name of the volume in the
+	// compose file that docker compose sets on the volumes it creates.
+	composeVolumeLabel = "com.docker.compose.volume"
+)
+
 // NewDockerManager returns a new instance of DockerManager
 func NewDockerManager(dockerClient client.APIClient) *DockerManager {
 	return &DockerManager{dockerClient}
@@ -81,6 +93,12 @@ func (d *DockerManager) Stop(container string) error {
 	return nil
 }
 
+// Ping checks that the Docker daemon is reachable.
+func (d *DockerManager) Ping() error {
+	_, err := d.dockerClient.Ping(context.Background())
+	return err
+}
+
 // ContainerID retrieves the ID of a specified Docker container name.
 // The function lists all containers and filters them by name. If a container with the specified name is found, its ID is returned.
 // If no container with the specified name is found, the function returns an error.
@@ -102,9 +120,14 @@ func (d *DockerManager) ContainerID(containerName string) (string, error) {
 
 // Pull pulls a specified Docker image.
 // The function attempts to pull the image and returns any error that occurs during the process.
-func (d *DockerManager) Pull(image string) error {
+// The image is pulled with the given registry credentials, or anonymously if auth is the zero RegistryAuth.
+func (d *DockerManager) Pull(image string, auth RegistryAuth) error {
 	log.Debugf("Pulling image: %s", image)
-	_, err := d.dockerClient.ImagePull(context.Background(), image, types.ImagePullOptions{})
+	registryAuth, err := encodeRegistryAuth(image, auth)
+	if err != nil {
+		return err
+	}
+	_, err = d.dockerClient.ImagePull(context.Background(), image, types.ImagePullOptions{RegistryAuth: registryAuth})
 	return err
 }
 
@@ -228,6 +251,9 @@ func (d *DockerManager) Wait(container string, condition WaitCondition) (<-chan
 func (d *DockerManager) ContainerStatus(container string) (common.Status, error) {
 	ctInfo, err := d.dockerClient.ContainerInspect(context.Background(), container)
 	if err != nil {
+		if client.IsErrNotFound(err) {
+			return common.Unknown, fmt.Errorf("%w: %s", ErrContainerNotFound, container)
+		}
 		return common.Unknown, err
 	}
 	switch ctInfo.State.Status {
@@ -418,6 +444,73 @@ func (d *DockerManager) ImageRemove(image string) error {
 	return err
 }
 
+// DanglingImages returns the IDs of the dangling images, which are the images
+// without a tag that are not used by any tagged image, that docker compose
+// built for any of the given projects. The dangling images of other projects,
+// or not built by docker compose, are left out.
+func (d *DockerManager) DanglingImages(projects []string) ([]string, error) {
+	images, err := d.dockerClient.ImageList(context.Background(), types.ImageListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("dangling", "true"),
+			filters.Arg("label", composeProjectLabel),
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		if slices.Contains(projects, image.Labels[composeProjectLabel]) {
+			ids = append(ids, image.ID)
+		}
+	}
+	return ids, nil
+}
+
+// ProjectVolumes returns the named volumes docker compose created for the
+// given project, mapped from their name in the compose file to their docker
+// volume name, e.g. data to mock-avs-default_data.
+func (d *DockerManager) ProjectVolumes(project string) (map[string]string, error) {
+	resp, err := d.dockerClient.VolumeList(context.Background(), volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", composeProjectLabel+"="+project)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	volumes := make(map[string]string, len(resp.Volumes))
+	for _, v := range resp.Volumes {
+		name := v.Labels[composeVolumeLabel]
+		if name == "" {
+			name = v.Name
+		}
+		volumes[name] = v.Name
+	}
+	return volumes, nil
+}
+
+// VolumesSize returns the sum of the sizes in bytes of the docker volumes with
+// the given names. Docker only knows the size of the volumes of the local
+// driver, so the volumes of other drivers are not counted.
+func (d *DockerManager) VolumesSize(names []string) (uint64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	usage, err := d.dockerClient.DiskUsage(context.Background(), types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var size uint64
+	for _, v := range usage.Volumes {
+		if !slices.Contains(names, v.Name) || v.UsageData == nil || v.UsageData.Size < 0 {
+			continue
+		}
+		size += uint64(v.UsageData.Size)
+	}
+	return size, nil
+}
+
 type RunOptions struct {
 	Network     string
 	Args        []string
@@ -507,6 +600,73 @@ func (d *DockerManager) Run(image string, options RunOptions) (err error) {
 	}
 }
 
+// ExecOptions are the options of an Exec call.
+type ExecOptions struct {
+	// Interactive keeps the stdin of the command open, reading it from Stdin.
+	Interactive bool
+	// TTY allocates a pseudo-TTY for the command. With a TTY, the stdout and
+	// stderr of the command are merged into Stdout.
+	TTY    bool
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Exec runs the given command inside the specified running container, attaching
+// its output to the writers of the options, and waits for it to finish. A
+// command that exits with a non-zero code returns an ErrExecFailed error.
+func (d *DockerManager) Exec(ctx context.Context, container string, cmd []string, options ExecOptions) error {
+	log.Debugf("Running %v in container %s", cmd, container)
+	execCreate, err := d.dockerClient.ContainerExecCreate(ctx, container, types.ExecConfig{
+		Cmd:          cmd,
+		Tty:          options.TTY,
+		AttachStdin:  options.Interactive,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return err
+	}
+	attach, err := d.dockerClient.ContainerExecAttach(ctx, execCreate.ID, types.ExecStartCheck{Tty: options.TTY})
+	if err != nil {
+		return err
+	}
+	defer attach.Close()
+
+	if options.Interactive && options.Stdin != nil {
+		go func() {
+			// The copy ends when the command exits and the connection is closed
+			_, _ = io.Copy(attach.Conn, options.Stdin)
+			_ = attach.CloseWrite()
+		}()
+	}
+	stdout, stderr := options.Stdout, options.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	if options.TTY {
+		_, err = io.Copy(stdout, attach.Reader)
+	} else {
+		// Without a TTY, stdout and stderr are multiplexed in the same stream
+		_, err = stdcopy.StdCopy(stdout, stderr, attach.Reader)
+	}
+	if err != nil {
+		return err
+	}
+
+	inspect, err := d.dockerClient.ContainerExecInspect(ctx, execCreate.ID)
+	if err != nil {
+		return err
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("%w: exit code %d", ErrExecFailed, inspect.ExitCode)
+	}
+	return nil
+}
+
 // ImageExist checks if a specified Docker image exists.
 func (d *DockerManager) ImageExist(image string) (bool, error) {
 	_, _, err := d.dockerClient.ImageInspectWithRaw(context.Background(), image)
@@ -16,6 +16,8 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
 	"github.com/golang/mock/gomock"
@@ -353,7 +355,7 @@ func TestPullError(t *testing.T) {
 		Return(nil, wantErr)
 
 	dockerManager := NewDockerManager(dockerClient)
-	err := dockerManager.Pull(imageName)
+	err := dockerManager.Pull(imageName, RegistryAuth{})
 	assert.ErrorIs(t, err, wantErr)
 }
 
@@ -369,10 +371,31 @@ func TestPull(t *testing.T) {
 		Return(nil, nil)
 
 	dockerManager := NewDockerManager(dockerClient)
-	err := dockerManager.Pull(imageName)
+	err := dockerManager.Pull(imageName, RegistryAuth{})
 	assert.Nil(t, err)
 }
 
+func TestPullWithAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dockerClient := mocks.NewMockAPIClient(ctrl)
+
+	imageName := "ghcr.io/nethermindeth/eigen:latest"
+	wantAuth, err := registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      "user",
+		Password:      "token",
+		ServerAddress: "ghcr.io",
+	})
+	require.NoError(t, err)
+
+	dockerClient.EXPECT().
+		ImagePull(gomock.Any(), imageName, types.ImagePullOptions{RegistryAuth: wantAuth}).
+		Return(nil, nil)
+
+	dockerManager := NewDockerManager(dockerClient)
+	err = dockerManager.Pull(imageName, RegistryAuth{Username: "user", Password: "token"})
+	assert.NoError(t, err)
+}
+
 // ContainerLogs tests
 
 func TestContainerLogsError(t *testing.T) {
@@ -635,6 +658,7 @@ func TestContainerStatus(t *testing.T) {
 		want     common.Status
 		errorMsg string
 		wantErr  bool
+		notFound bool
 	}{
 		{
 			name:   "container created",
@@ -683,6 +707,13 @@ func TestContainerStatus(t *testing.T) {
 			wantErr:  true,
 			errorMsg: "error",
 		},
+		{
+			name:     "missing container",
+			want:     common.Unknown,
+			wantErr:  true,
+			notFound: true,
+			errorMsg: "container not found: ",
+		},
 	}
 
 	for _, tt := range tests {
@@ -699,7 +730,11 @@ func TestContainerStatus(t *testing.T) {
 				},
 			}
 
-			if tt.wantErr {
+			if tt.notFound {
+				dockerClient.EXPECT().
+					ContainerInspect(context.Background(), container.ID).
+					Return(container, errdefs.NotFound(errors.New("error")))
+			} else if tt.wantErr {
 				dockerClient.EXPECT().
 					ContainerInspect(context.Background(), container.ID).
 					Return(container, errors.New("error"))
@@ -711,6 +746,9 @@ func TestContainerStatus(t *testing.T) {
 
 			dockerManager := NewDockerManager(dockerClient)
 			status, err := dockerManager.ContainerStatus(container.ID)
+			if tt.notFound {
+				assert.ErrorIs(t, err, ErrContainerNotFound)
+			}
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, tt.errorMsg, err.Error())
@@ -1711,6 +1749,196 @@ func (e notFoundError) Error() string {
 	return "not found"
 }
 
+func TestDanglingImages(t *testing.T) {
+	listOptions := types.ImageListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("dangling", "true"),
+			filters.Arg("label", "com.docker.compose.project"),
+		),
+	}
+	projects := []string{"mock-avs-default", "mock-avs-orphan"}
+	tc := []struct {
+		name  string
+		want  []string
+		err   error
+		setup func(*mocks.MockAPIClient)
+	}{
+		{
+			name: "dangling images",
+			want: []string{"sha256:1", "sha256:2"},
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().ImageList(context.Background(), listOptions).Return([]types.ImageSummary{
+					{ID: "sha256:1", Labels: map[string]string{"com.docker.compose.project": "mock-avs-default"}},
+					{ID: "sha256:2", Labels: map[string]string{"com.docker.compose.project": "mock-avs-orphan"}},
+				}, nil)
+			},
+		},
+		{
+			name: "images of other projects",
+			want: []string{"sha256:1"},
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().ImageList(context.Background(), listOptions).Return([]types.ImageSummary{
+					{ID: "sha256:1", Labels: map[string]string{"com.docker.compose.project": "mock-avs-default"}},
+					{ID: "sha256:2", Labels: map[string]string{"com.docker.compose.project": "other-app"}},
+				}, nil)
+			},
+		},
+		{
+			name: "no dangling images",
+			want: []string{},
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().ImageList(context.Background(), listOptions).Return(nil, nil)
+			},
+		},
+		{
+			name: "image list error",
+			err:  assert.AnError,
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().ImageList(context.Background(), listOptions).Return(nil, assert.AnError)
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dockerClient := mocks.NewMockAPIClient(ctrl)
+			tt.setup(dockerClient)
+
+			dockerManager := NewDockerManager(dockerClient)
+			images, err := dockerManager.DanglingImages(projects)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, images)
+		})
+	}
+}
+
+func TestProjectVolumes(t *testing.T) {
+	listOptions := volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "com.docker.compose.project=mock-avs-default")),
+	}
+	tc := []struct {
+		name  string
+		want  map[string]string
+		err   error
+		setup func(*mocks.MockAPIClient)
+	}{
+		{
+			name: "project volumes",
+			want: map[string]string{
+				"data":     "mock-avs-default_data",
+				"keystore": "keystore",
+			},
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().VolumeList(context.Background(), listOptions).Return(volume.ListResponse{
+					Volumes: []*volume.Volume{
+						{
+							Name: "mock-avs-default_data",
+							Labels: map[string]string{
+								"com.docker.compose.project": "mock-avs-default",
+								"com.docker.compose.volume":  "data",
+							},
+						},
+						{
+							Name:   "keystore",
+							Labels: map[string]string{"com.docker.compose.project": "mock-avs-default"},
+						},
+					},
+				}, nil)
+			},
+		},
+		{
+			name: "no volumes",
+			want: map[string]string{},
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().VolumeList(context.Background(), listOptions).Return(volume.ListResponse{}, nil)
+			},
+		},
+		{
+			name: "volume list error",
+			err:  assert.AnError,
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().VolumeList(context.Background(), listOptions).Return(volume.ListResponse{}, assert.AnError)
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dockerClient := mocks.NewMockAPIClient(ctrl)
+			tt.setup(dockerClient)
+
+			dockerManager := NewDockerManager(dockerClient)
+			volumes, err := dockerManager.ProjectVolumes("mock-avs-default")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, volumes)
+		})
+	}
+}
+
+func TestVolumesSize(t *testing.T) {
+	diskUsageOptions := types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}}
+	tc := []struct {
+		name  string
+		names []string
+		want  uint64
+		err   error
+		setup func(*mocks.MockAPIClient)
+	}{
+		{
+			name:  "volumes size",
+			names: []string{"mock-avs-default_data", "mock-avs-default_keystore", "mock-avs-default_remote"},
+			want:  1536,
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().DiskUsage(context.Background(), diskUsageOptions).Return(types.DiskUsage{
+					Volumes: []*volume.Volume{
+						{Name: "mock-avs-default_data", UsageData: &volume.UsageData{Size: 1024}},
+						{Name: "mock-avs-default_keystore", UsageData: &volume.UsageData{Size: 512}},
+						// The size of the volumes of other drivers is unknown
+						{Name: "mock-avs-default_remote", UsageData: &volume.UsageData{Size: -1}},
+						{Name: "other_data", UsageData: &volume.UsageData{Size: 2048}},
+					},
+				}, nil)
+			},
+		},
+		{
+			name:  "no volumes",
+			setup: func(dockerClient *mocks.MockAPIClient) {},
+		},
+		{
+			name:  "disk usage error",
+			names: []string{"mock-avs-default_data"},
+			err:   assert.AnError,
+			setup: func(dockerClient *mocks.MockAPIClient) {
+				dockerClient.EXPECT().DiskUsage(context.Background(), diskUsageOptions).Return(types.DiskUsage{}, assert.AnError)
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dockerClient := mocks.NewMockAPIClient(ctrl)
+			tt.setup(dockerClient)
+
+			dockerManager := NewDockerManager(dockerClient)
+			size, err := dockerManager.VolumesSize(tt.names)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, size)
+		})
+	}
+}
+
 func TestImageExist(t *testing.T) {
 	image := "test-image:v1.0.0"
 
@@ -7,4 +7,5 @@ var (
 	ErrContainerNotFound = errors.New("container not found")
 	ErrStoppingContainer = errors.New("error stopping container")
 	ErrNetworksNotFound  = errors.New("networks not found")
+	ErrExecFailed        = errors.New("exec command failed")
 )
@@ -0,0 +1,237 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// dockerHubRegistry is the registry of the images without a registry in their
+// reference, like ubuntu:22.04, and dockerHubConfigKey is the key of its
+// credentials in the docker config files.
+const (
+	dockerHubRegistry  = "docker.io"
+	dockerHubConfigKey = "https://index.docker.io/v1/"
+)
+
+// RegistryAuth is the credentials of a container registry. The zero value
+// pulls images anonymously.
+type RegistryAuth struct {
+	Username string
+	Password string
+}
+
+// IsZero returns true if there are no credentials.
+func (a RegistryAuth) IsZero() bool {
+	return a.Username == "" && a.Password == ""
+}
+
+// RegistryAuths maps registry hosts, like ghcr.io, to their credentials.
+type RegistryAuths map[string]RegistryAuth
+
+// For returns the credentials of the registry of the given image, or the zero
+// RegistryAuth if there are none.
+func (a RegistryAuths) For(image string) RegistryAuth {
+	return a[ImageRegistry(image)]
+}
+
+// ImageRegistry returns the registry host of the given image reference, like
+// ghcr.io for ghcr.io/org/image:tag, or docker.io for the images of Docker
+// Hub. An invalid reference has no registry.
+func ImageRegistry(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// NormalizeRegistry returns the registry host of a registry address in any of
+// the forms used by docker, like https://index.docker.io/v1/ or ghcr.io.
+func NormalizeRegistry(address string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// dockerConfig is the part of the docker config file, config.json, with the
+// registry credentials.
+type dockerConfig struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+type dockerConfigAuth struct {
+	Auth     string `json:"auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// LoadRegistryAuths reads the registry credentials of the docker config file at
+// the given path, like ~/.docker/config.json. Only the credentials stored in
+// the file are loaded, the ones of credential helpers are not.
+func LoadRegistryAuths(path string) (RegistryAuths, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %w", path, err)
+	}
+	auths := make(RegistryAuths, len(config.Auths))
+	for address, entry := range config.Auths {
+		auth := RegistryAuth{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid docker config %s: auth of %s: %w", path, address, err)
+			}
+			username, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid docker config %s: auth of %s is not in the username:password form", path, address)
+			}
+			auth = RegistryAuth{Username: username, Password: password}
+		}
+		if auth.IsZero() {
+			// Stored in a credential helper
+			continue
+		}
+		auths[NormalizeRegistry(address)] = auth
+	}
+	return auths, nil
+}
+
+// ConfigDir returns the directory of the docker client config, $DOCKER_CONFIG
+// or else ~/.docker, as an absolute path.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+// WriteDockerConfig writes a docker config file with the given credentials in
+// the dir directory, so the docker CLI uses them when it runs with --config
+// dir. The config starts from the one of the base config directory, which may
+// not exist, so the other settings, like the credentials store, the credential
+// helpers, the current context and the proxies, are kept, and the credentials
+// of the other registries too. The other files of base, like the contexts and
+// the CLI plugins, are linked into dir.
+func WriteDockerConfig(dir, base string, auths RegistryAuths) error {
+	basePath := filepath.Join(base, "config.json")
+	config := make(map[string]json.RawMessage)
+	raw, err := os.ReadFile(basePath)
+	if err == nil {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return fmt.Errorf("invalid docker config %s: %w", basePath, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var (
+		configAuths map[string]json.RawMessage
+		credHelpers map[string]string
+	)
+	if rawAuths, ok := config["auths"]; ok {
+		if err := json.Unmarshal(rawAuths, &configAuths); err != nil {
+			return fmt.Errorf("invalid docker config %s: auths: %w", basePath, err)
+		}
+	}
+	if rawHelpers, ok := config["credHelpers"]; ok {
+		if err := json.Unmarshal(rawHelpers, &credHelpers); err != nil {
+			return fmt.Errorf("invalid docker config %s: credHelpers: %w", basePath, err)
+		}
+	}
+	if configAuths == nil {
+		configAuths = make(map[string]json.RawMessage)
+	}
+	if credHelpers == nil {
+		credHelpers = make(map[string]string)
+	}
+
+	for host, auth := range auths {
+		// Drop the entries of the registry under other addresses, like
+		// https://ghcr.io for ghcr.io
+		for address := range configAuths {
+			if NormalizeRegistry(address) == host {
+				delete(configAuths, address)
+			}
+		}
+		for address := range credHelpers {
+			if NormalizeRegistry(address) == host {
+				delete(credHelpers, address)
+			}
+		}
+		key := host
+		if host == dockerHubRegistry {
+			key = dockerHubConfigKey
+		}
+		entry, err := json.Marshal(dockerConfigAuth{
+			Auth: base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password)),
+		})
+		if err != nil {
+			return err
+		}
+		configAuths[key] = entry
+		// An empty credential helper makes the docker CLI read the credentials
+		// of the registry from the file instead of the credentials store
+		credHelpers[key] = ""
+	}
+	if config["auths"], err = json.Marshal(configAuths); err != nil {
+		return err
+	}
+	if config["credHelpers"], err = json.Marshal(credHelpers); err != nil {
+		return err
+	}
+	if raw, err = json.Marshal(config); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(dir, "config.json"), raw, 0o600); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(base)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == "config.json" {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(base, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeRegistryAuth returns the credentials in the form of the RegistryAuth
+// option of the docker API, or an empty string for the zero RegistryAuth.
+func encodeRegistryAuth(image string, auth RegistryAuth) (string, error) {
+	if auth.IsZero() {
+		return "", nil
+	}
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		ServerAddress: ImageRegistry(image),
+	})
+}
@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "ubuntu:22.04", want: "docker.io"},
+		{image: "nethermindeth/eigenlayer:latest", want: "docker.io"},
+		{image: "ghcr.io/nethermindeth/eigenlayer:latest", want: "ghcr.io"},
+		{image: "registry.example.com:5000/avs/node@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", want: "registry.example.com:5000"},
+		{image: "Invalid Image", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, ImageRegistry(tt.image))
+		})
+	}
+}
+
+func TestRegistryAuthsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	userToken := base64.StdEncoding.EncodeToString([]byte("hub-user:hub-token"))
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "`+userToken+`"},
+			"ghcr.io": {"username": "gh-user", "password": "gh-token"},
+			"https://registry.example.com/v2/": {"username": "user", "password": "p:ss"},
+			"helper.example.com": {}
+		},
+		"credsStore": "desktop"
+	}`), 0o600))
+
+	auths, err := LoadRegistryAuths(configPath)
+	require.NoError(t, err)
+	want := RegistryAuths{
+		"docker.io":            {Username: "hub-user", Password: "hub-token"},
+		"ghcr.io":              {Username: "gh-user", Password: "gh-token"},
+		"registry.example.com": {Username: "user", Password: "p:ss"},
+	}
+	assert.Equal(t, want, auths)
+	assert.Equal(t, want["ghcr.io"], auths.For("ghcr.io/org/avs:v1"))
+	assert.Equal(t, want["docker.io"], auths.For("ubuntu"))
+	assert.True(t, auths.For("quay.io/org/avs").IsZero())
+
+	// The written config is read back by the docker CLI, and by
+	// LoadRegistryAuths, with the same credentials
+	outDir := t.TempDir()
+	require.NoError(t, WriteDockerConfig(outDir, filepath.Join(dir, "missing"), auths))
+	info, err := os.Stat(filepath.Join(outDir, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	written, err := LoadRegistryAuths(filepath.Join(outDir, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, want, written)
+}
+
+func TestWriteDockerConfigKeepsBase(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "config.json"), []byte(`{
+		"auths": {
+			"https://ghcr.io": {"username": "old-user", "password": "old-token"},
+			"registry.example.com": {"username": "user", "password": "token"}
+		},
+		"credsStore": "desktop",
+		"credHelpers": {"ghcr.io": "gh", "gcr.io": "gcloud"},
+		"currentContext": "remote",
+		"proxies": {"default": {"httpProxy": "http://proxy.example.com:3128"}}
+	}`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "contexts", "meta"), 0o755))
+
+	dir := t.TempDir()
+	require.NoError(t, WriteDockerConfig(dir, base, RegistryAuths{"ghcr.io": {Username: "gh-user", Password: "gh-token"}}))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	var config struct {
+		CredsStore     string                       `json:"credsStore"`
+		CredHelpers    map[string]string            `json:"credHelpers"`
+		CurrentContext string                       `json:"currentContext"`
+		Proxies        map[string]map[string]string `json:"proxies"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &config))
+	assert.Equal(t, "desktop", config.CredsStore)
+	assert.Equal(t, "remote", config.CurrentContext)
+	assert.Equal(t, "http://proxy.example.com:3128", config.Proxies["default"]["httpProxy"])
+	// The added registry is read from the file, the others keep their helpers
+	assert.Equal(t, map[string]string{"ghcr.io": "", "gcr.io": "gcloud"}, config.CredHelpers)
+
+	auths, err := LoadRegistryAuths(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, RegistryAuths{
+		"ghcr.io":              {Username: "gh-user", Password: "gh-token"},
+		"registry.example.com": {Username: "user", Password: "token"},
+	}, auths)
+
+	// The contexts of the current context are found in the new directory
+	contexts, err := os.Stat(filepath.Join(dir, "contexts", "meta"))
+	require.NoError(t, err)
+	assert.True(t, contexts.IsDir())
+}
+
+func TestLoadRegistryAuthsError(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid json", content: `{"auths":`},
+		{name: "invalid base64", content: `{"auths": {"ghcr.io": {"auth": "not base64!"}}}`},
+		{name: "auth without password", content: `{"auths": {"ghcr.io": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("user")) + `"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			_, err := LoadRegistryAuths(path)
+			assert.Error(t, err)
+		})
+	}
+	_, err := LoadRegistryAuths(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -18,11 +18,12 @@ func LoadEnv(fs afero.Fs, path string) (map[string]string, error) {
 		if strings.HasPrefix(line, "#") {
 			continue
 		}
-		parts := strings.Split(line, "=")
-		if len(parts) != 2 {
+		// Values can contain '=', like base64 keys and URLs with query strings
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
 			continue
 		}
-		env[strings.Trim(parts[0], " ")] = strings.Trim(parts[1], " ")
+		env[strings.Trim(key, " ")] = strings.Trim(value, " ")
 	}
 	return env, nil
 }
@@ -19,6 +19,23 @@ func (h *HardwareMetrics) Meets(hm HardwareMetrics) bool {
 	return h.CPU >= hm.CPU && h.RAM >= hm.RAM && h.DiskSpace >= hm.DiskSpace
 }
 
+// Shortfalls returns a description of each metric of the current HardwareMetrics
+// instance that does not meet the specified hardware metrics. It returns nil if
+// all of them are met.
+func (h *HardwareMetrics) Shortfalls(hm HardwareMetrics) []string {
+	var shortfalls []string
+	if h.CPU < hm.CPU {
+		shortfalls = append(shortfalls, fmt.Sprintf("CPU: required %.2f Cores, available %.2f Cores", hm.CPU, h.CPU))
+	}
+	if h.RAM < hm.RAM {
+		shortfalls = append(shortfalls, fmt.Sprintf("RAM: required %.2f Mb, available %.2f Mb", hm.RAM, h.RAM))
+	}
+	if h.DiskSpace < hm.DiskSpace {
+		shortfalls = append(shortfalls, fmt.Sprintf("Disk Space: required %.2f Mb, available %.2f Mb", hm.DiskSpace, h.DiskSpace))
+	}
+	return shortfalls
+}
+
 func (h *HardwareMetrics) String() string {
 	return fmt.Sprintf("CPU: %.2f Cores, RAM: %.2f Mb, Disk Space: %.2f Mb", h.CPU, h.RAM, h.DiskSpace)
 }
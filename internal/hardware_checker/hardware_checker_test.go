@@ -95,3 +95,41 @@ func TestHardwareMetrics_Meets(t *testing.T) {
 		})
 	}
 }
+
+func TestHardwareMetrics_Shortfalls(t *testing.T) {
+	h := &HardwareMetrics{
+		CPU:       4,
+		RAM:       8,
+		DiskSpace: 100,
+	}
+	tests := []struct {
+		name string
+		hm   HardwareMetrics
+		want []string
+	}{
+		{
+			name: "all requirements met",
+			hm:   HardwareMetrics{CPU: 4, RAM: 4, DiskSpace: 50},
+			want: nil,
+		},
+		{
+			name: "only disk space not met",
+			hm:   HardwareMetrics{CPU: 2, RAM: 8, DiskSpace: 200},
+			want: []string{"Disk Space: required 200.00 Mb, available 100.00 Mb"},
+		},
+		{
+			name: "no requirement met",
+			hm:   HardwareMetrics{CPU: 8, RAM: 16, DiskSpace: 200},
+			want: []string{
+				"CPU: required 8.00 Cores, available 4.00 Cores",
+				"RAM: required 16.00 Mb, available 8.00 Mb",
+				"Disk Space: required 200.00 Mb, available 100.00 Mb",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.Shortfalls(tt.hm))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// ErrHookFailed is returned when a pre-hook exits with a non-zero code,
+// vetoing the operation.
+var ErrHookFailed = errors.New("hook failed")
+
+// Event is a lifecycle event of an instance.
+type Event string
+
+const (
+	PostInstall Event = "post-install"
+	PreRun      Event = "pre-run"
+	PostStop    Event = "post-stop"
+	PostBackup  Event = "post-backup"
+)
+
+// pre returns true if the hook of the event runs before the operation, and
+// can then veto it.
+func (e Event) pre() bool {
+	return strings.HasPrefix(string(e), "pre-")
+}
+
+// Runner runs the hooks of a directory. The hook of an event is the
+// executable file named after the event, like git hooks, so a post-install
+// hook is the <dir>/post-install executable.
+type Runner struct {
+	fs     afero.Fs
+	dir    string
+	logger log.FieldLogger
+}
+
+// NewRunner creates a new Runner of the hooks in the given directory of fs.
+// The hooks are looked up in fs, but executed from the host, so fs must be
+// backed by the host file system for them to run.
+func NewRunner(fs afero.Fs, dir string, logger log.FieldLogger) *Runner {
+	return &Runner{
+		fs:     fs,
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+// Run runs the hook of the event, if there is one, with the event name and
+// the metadata in its environment. The event name is in EGN_EVENT and each
+// metadata key is upper-cased and prefixed with EGN_, so the instance_id key
+// is in EGN_INSTANCE_ID. The hook output is captured and logged.
+//
+// If a pre-hook exits with a non-zero code an ErrHookFailed error is
+// returned, vetoing the operation. Post-hooks run once the operation is
+// done, so their failures are only logged.
+func (r *Runner) Run(ctx context.Context, event Event, metadata map[string]string) error {
+	hookPath := filepath.Join(r.dir, string(event))
+	info, err := r.fs.Stat(hookPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+		r.logger.WithField("hook", hookPath).Warn("Hook is not an executable file. Skipping it")
+		return nil
+	}
+
+	logger := r.logger.WithFields(log.Fields{"hook": hookPath, "event": event})
+	logger.Info("Running hook")
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, hookPath)
+	cmd.Env = append(os.Environ(), hookEnv(event, metadata)...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	if out.Len() > 0 {
+		logger.WithField("output", strings.TrimSpace(out.String())).Debug("Hook output")
+	}
+	if err == nil {
+		return nil
+	}
+	if !event.pre() {
+		logger.WithError(err).Warn("Hook failed")
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %w. Output: %s", ErrHookFailed, event, err, strings.TrimSpace(out.String()))
+}
+
+// hookEnv returns the environment variables with the event metadata, sorted
+// by name.
+func hookEnv(event Event, metadata map[string]string) []string {
+	env := make([]string, 0, len(metadata)+1)
+	env = append(env, "EGN_EVENT="+string(event))
+	for key, value := range metadata {
+		env = append(env, "EGN_"+strings.ToUpper(key)+"="+value)
+	}
+	sort.Strings(env[1:])
+	return env
+}
@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ts := []struct {
+		name   string
+		event  Event
+		script string
+		mode   os.FileMode
+		// memFs looks up the hooks in an empty in-memory file system instead
+		// of the host one.
+		memFs bool
+		ran   bool
+		err   error
+	}{
+		{
+			name:  "no hook",
+			event: PostInstall,
+		},
+		{
+			name:   "post-install hook",
+			event:  PostInstall,
+			script: "#!/bin/sh\nenv > \"$OUT\"\n",
+			mode:   0o755,
+			ran:    true,
+		},
+		{
+			name:   "pre-run hook vetoes the operation",
+			event:  PreRun,
+			script: "#!/bin/sh\nenv > \"$OUT\"\necho not allowed\nexit 1\n",
+			mode:   0o755,
+			ran:    true,
+			err:    ErrHookFailed,
+		},
+		{
+			name:   "failed post-stop hook",
+			event:  PostStop,
+			script: "#!/bin/sh\nenv > \"$OUT\"\nexit 1\n",
+			mode:   0o755,
+			ran:    true,
+		},
+		{
+			name:   "hook outside the file system",
+			event:  PostInstall,
+			script: "#!/bin/sh\nenv > \"$OUT\"\n",
+			mode:   0o755,
+			memFs:  true,
+		},
+		{
+			name:   "not executable hook",
+			event:  PreRun,
+			script: "#!/bin/sh\nenv > \"$OUT\"\nexit 1\n",
+			mode:   0o644,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out := filepath.Join(t.TempDir(), "env")
+			t.Setenv("OUT", out)
+			if tt.script != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, string(tt.event)), []byte(tt.script), tt.mode))
+			}
+
+			var afs afero.Fs = afero.NewOsFs()
+			if tt.memFs {
+				afs = afero.NewMemMapFs()
+			}
+			runner := NewRunner(afs, dir, log.StandardLogger())
+			err := runner.Run(context.Background(), tt.event, map[string]string{"instance_id": "mock-avs-default"})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.ErrorContains(t, err, "not allowed")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			env, err := os.ReadFile(out)
+			if !tt.ran {
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, string(env), "EGN_EVENT="+string(tt.event)+"\n")
+			assert.Contains(t, string(env), "EGN_INSTANCE_ID=mock-avs-default\n")
+		})
+	}
+}
+
+func TestHookEnv(t *testing.T) {
+	env := hookEnv(PostBackup, map[string]string{
+		"instance_id": "mock-avs-default",
+		"backup_id":   "mock-avs-default-1696317683",
+	})
+	assert.Equal(t, []string{
+		"EGN_EVENT=post-backup",
+		"EGN_BACKUP_ID=mock-avs-default-1696317683",
+		"EGN_INSTANCE_ID=mock-avs-default",
+	}, env)
+}
@@ -1,10 +1,19 @@
 package locker
 
-import "github.com/gofrs/flock"
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/flock"
+)
 
 type Locker interface {
 	New(path string) Locker
 	Lock() error
+	// TryLockContext tries to acquire the lock every retryDelay until it is
+	// acquired or the context is done. It returns false and the context error
+	// if the lock was not acquired.
+	TryLockContext(ctx context.Context, retryDelay time.Duration) (bool, error)
 	Unlock() error
 	Locked() bool
 }
@@ -17,15 +26,20 @@ func NewFLock() Locker {
 	return &FLock{}
 }
 
+// New returns a new FLock of the file at the given path. The FLock is not
+// changed, so the lock of each path is held by its own FLock.
 func (l *FLock) New(path string) Locker {
-	l.locker = flock.New(path)
-	return l
+	return &FLock{locker: flock.New(path)}
 }
 
 func (l *FLock) Lock() error {
 	return l.locker.Lock()
 }
 
+func (l *FLock) TryLockContext(ctx context.Context, retryDelay time.Duration) (bool, error) {
+	return l.locker.TryLockContext(ctx, retryDelay)
+}
+
 func (l *FLock) Unlock() error {
 	return l.locker.Unlock()
 }
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Operations of egn counted by the metrics.
+const (
+	OperationInstall = "install"
+	OperationRun     = "run"
+	OperationBackup  = "backup"
+)
+
+// Path is the path of the HTTP endpoint that exposes the metrics.
+const Path = "/metrics"
+
+// Metrics holds the counters of the operations done by egn. The counters are
+// exposed in the Prometheus text and OpenMetrics formats.
+//
+// The counters live in the egn process, so they only count the operations of
+// the running command and are only served while it runs. They are meant to be
+// scraped by a Prometheus reachable from the host while long-running commands,
+// like `eigenlayer backup schedule`, run, or scraped once at the end of a
+// command. They are not added as a target of the monitoring stack: its
+// Prometheus runs in a docker network that does not reach the host, and the
+// target would be gone after the command exits.
+type Metrics struct {
+	registry   *prometheus.Registry
+	operations map[string]prometheus.Counter
+	errors     *prometheus.CounterVec
+}
+
+// New creates a new Metrics with all the counters set to zero.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		operations: map[string]prometheus.Counter{
+			OperationInstall: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "egn_installs_total",
+				Help: "Total number of AVS node installations.",
+			}),
+			OperationRun: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "egn_runs_total",
+				Help: "Total number of AVS node runs.",
+			}),
+			OperationBackup: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "egn_backups_total",
+				Help: "Total number of AVS node backups.",
+			}),
+		},
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "egn_errors_total",
+			Help: "Total number of failed operations, by operation.",
+		}, []string{"operation"}),
+	}
+	for operation, counter := range m.operations {
+		m.registry.MustRegister(counter)
+		// Initialize the errors of each operation, so they are exposed before
+		// the first failure.
+		m.errors.WithLabelValues(operation)
+	}
+	m.registry.MustRegister(m.errors)
+	return m
+}
+
+// Observe counts an operation with the given name and, if err is not nil, an
+// error of the operation. Unknown operations only count their errors.
+func (m *Metrics) Observe(operation string, err error) {
+	if counter, ok := m.operations[operation]; ok {
+		counter.Inc()
+	}
+	if err != nil {
+		m.errors.WithLabelValues(operation).Inc()
+	}
+}
+
+// Handler returns the HTTP handler that exposes the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// Server is an HTTP server that exposes the metrics at Path.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// Serve starts an HTTP server listening on the given address that exposes the
+// metrics at Path. The server runs in the background until it is closed.
+func (m *Metrics) Serve(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+	s := &Server{
+		server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		listener: listener,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			listener.Close()
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close shuts down the server, waiting for the active scrapes to finish
+// until the context is done.
+func (s *Server) Close(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
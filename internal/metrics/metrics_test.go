@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	server, err := m.Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close(context.Background())
+	url := "http://" + server.Addr().String() + Path
+
+	// All the counters are exposed before any operation
+	body := scrape(t, url)
+	for _, line := range []string{
+		"egn_installs_total 0",
+		"egn_runs_total 0",
+		"egn_backups_total 0",
+		`egn_errors_total{operation="install"} 0`,
+		`egn_errors_total{operation="run"} 0`,
+		`egn_errors_total{operation="backup"} 0`,
+	} {
+		assert.Contains(t, body, line)
+	}
+
+	m.Observe(OperationInstall, nil)
+	m.Observe(OperationInstall, errors.New("install failed"))
+	m.Observe(OperationRun, nil)
+	m.Observe(OperationBackup, nil)
+	m.Observe(OperationBackup, nil)
+	m.Observe("uninstall", errors.New("uninstall failed"))
+
+	body = scrape(t, url)
+	for _, line := range []string{
+		"egn_installs_total 2",
+		"egn_runs_total 1",
+		"egn_backups_total 2",
+		`egn_errors_total{operation="install"} 1`,
+		`egn_errors_total{operation="run"} 0`,
+		`egn_errors_total{operation="backup"} 0`,
+		`egn_errors_total{operation="uninstall"} 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestMetricsOpenMetrics(t *testing.T) {
+	m := New()
+	server, err := m.Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close(context.Background())
+	m.Observe(OperationRun, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+server.Addr().String()+Path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+	assert.Contains(t, string(body), "egn_runs_total 1")
+	assert.Contains(t, string(body), "# EOF")
+}
+
+func TestServeAddressInUse(t *testing.T) {
+	server, err := New().Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close(context.Background())
+
+	_, err = New().Serve(server.Addr().String())
+	assert.Error(t, err)
+}
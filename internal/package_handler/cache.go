@@ -0,0 +1,220 @@
+package package_handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// CachePolicy limits the entries kept in a PackageCache. A zero value disables
+// the corresponding limit.
+type CachePolicy struct {
+	// MaxSize is the maximum total size in bytes of the cached packages. When it
+	// is exceeded, the oldest entries are evicted first.
+	MaxSize int64
+	// MaxAge is the maximum time a package is kept in the cache since it was
+	// stored.
+	MaxAge time.Duration
+}
+
+// DefaultCachePolicy is the cache policy used when none is configured.
+var DefaultCachePolicy = CachePolicy{
+	MaxSize: 1 << 30, // 1 GiB
+	MaxAge:  30 * 24 * time.Hour,
+}
+
+// PackageCache stores cloned package repositories keyed by repository URL and
+// ref (a version tag or a commit hash), so a package that was already
+// downloaded can be copied from the cache instead of being cloned again.
+type PackageCache struct {
+	path   string
+	afs    afero.Fs
+	policy CachePolicy
+	now    func() time.Time
+}
+
+// NewPackageCache creates a new PackageCache stored in the given path.
+func NewPackageCache(path string, afs afero.Fs, policy CachePolicy) *PackageCache {
+	return &PackageCache{
+		path:   path,
+		afs:    afs,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Get copies the cached package for the given repository URL and ref into dst.
+// It returns false if the package is not cached or its entry expired.
+func (c *PackageCache) Get(url, ref, dst string) (bool, error) {
+	entryPath := c.entryPath(url, ref)
+	entryStat, err := c.afs.Stat(entryPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if c.expired(entryStat) {
+		return false, c.afs.RemoveAll(entryPath)
+	}
+	if err := copyDir(c.afs, entryPath, dst); err != nil {
+		return false, fmt.Errorf("copying cached package %s@%s: %w", url, ref, err)
+	}
+	return true, nil
+}
+
+// Put stores a copy of the package in src as the cached package for the given
+// repository URL and ref, replacing any previous entry. Entries exceeding the
+// cache policy are evicted afterwards.
+func (c *PackageCache) Put(url, ref, src string) error {
+	entryPath := c.entryPath(url, ref)
+	tmpPath := entryPath + ".tmp"
+	if err := c.afs.RemoveAll(tmpPath); err != nil {
+		return err
+	}
+	if err := copyDir(c.afs, src, tmpPath); err != nil {
+		return errors.Join(fmt.Errorf("caching package %s@%s: %w", url, ref, err), c.afs.RemoveAll(tmpPath))
+	}
+	if err := c.afs.RemoveAll(entryPath); err != nil {
+		return err
+	}
+	if err := c.afs.Rename(tmpPath, entryPath); err != nil {
+		return err
+	}
+	now := c.now()
+	if err := c.afs.Chtimes(entryPath, now, now); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the expired entries and then the oldest ones until the cache
+// size is within the policy limit.
+func (c *PackageCache) evict() error {
+	dirEntries, err := afero.ReadDir(c.afs, c.path)
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		entries   []entry
+		totalSize int64
+	)
+	for _, e := range dirEntries {
+		if !e.IsDir() || filepath.Ext(e.Name()) == ".tmp" {
+			continue
+		}
+		path := filepath.Join(c.path, e.Name())
+		if c.expired(e) {
+			if err := c.afs.RemoveAll(path); err != nil {
+				return err
+			}
+			continue
+		}
+		size, err := dirSize(c.afs, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path: path, size: size, modTime: e.ModTime()})
+		totalSize += size
+	}
+	if c.policy.MaxSize <= 0 {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		if totalSize <= c.policy.MaxSize {
+			break
+		}
+		if err := c.afs.RemoveAll(e.path); err != nil {
+			return err
+		}
+		totalSize -= e.size
+	}
+	return nil
+}
+
+func (c *PackageCache) expired(entry fs.FileInfo) bool {
+	return c.policy.MaxAge > 0 && c.now().Sub(entry.ModTime()) > c.policy.MaxAge
+}
+
+func (c *PackageCache) entryPath(url, ref string) string {
+	key := sha256.Sum256([]byte(url + "@" + ref))
+	return filepath.Join(c.path, hex.EncodeToString(key[:]))
+}
+
+// copyDir copies the src directory into dst, creating dst if it does not exist.
+// Symbolic links are copied as links when the filesystem supports them.
+func copyDir(afs afero.Fs, src, dst string) error {
+	return afero.Walk(afs, src, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+		switch {
+		case info.IsDir():
+			return afs.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			linker, ok := afs.(afero.Symlinker)
+			if !ok {
+				return nil
+			}
+			link, err := linker.ReadlinkIfPossible(path)
+			if err != nil {
+				return err
+			}
+			return linker.SymlinkIfPossible(link, target)
+		default:
+			return copyFile(afs, path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(afs afero.Fs, src, dst string, perm fs.FileMode) error {
+	srcFile, err := afs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	dstFile, err := afs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	return dstFile.Close()
+}
+
+func dirSize(afs afero.Fs, path string) (size int64, err error) {
+	err = afero.Walk(afs, path, func(_ string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,150 @@
+package package_handler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageCache_GetPut(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/src/pkg/manifest.yml", []byte("manifest"), 0o644))
+	require.NoError(t, afero.WriteFile(afs, "/src/.git/HEAD", []byte("ref: refs/heads/main"), 0o644))
+
+	cache := NewPackageCache("/cache", afs, CachePolicy{})
+
+	ok, err := cache.Get("https://github.com/NethermindEth/mock-avs-pkg", "v0.1.0", "/dst")
+	require.NoError(t, err)
+	assert.False(t, ok, "empty cache should miss")
+
+	require.NoError(t, cache.Put("https://github.com/NethermindEth/mock-avs-pkg", "v0.1.0", "/src"))
+
+	ok, err = cache.Get("https://github.com/NethermindEth/mock-avs-pkg", "v0.2.0", "/dst")
+	require.NoError(t, err)
+	assert.False(t, ok, "other ref should miss")
+
+	ok, err = cache.Get("https://github.com/NethermindEth/mock-avs-pkg", "v0.1.0", "/dst")
+	require.NoError(t, err)
+	require.True(t, ok)
+	for file, content := range map[string]string{
+		"/dst/pkg/manifest.yml": "manifest",
+		"/dst/.git/HEAD":        "ref: refs/heads/main",
+	} {
+		data, err := afero.ReadFile(afs, file)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+}
+
+func TestPackageCache_Eviction(t *testing.T) {
+	const url = "https://github.com/NethermindEth/mock-avs-pkg"
+
+	tests := []struct {
+		name   string
+		policy CachePolicy
+		// elapsed is the time passed between each Put and the Get calls.
+		elapsed time.Duration
+		cached  []string
+		evicted []string
+	}{
+		{
+			name:    "no limits",
+			policy:  CachePolicy{},
+			elapsed: 24 * time.Hour,
+			cached:  []string{"v0.1.0", "v0.2.0", "v0.3.0"},
+		},
+		{
+			name:    "max age",
+			policy:  CachePolicy{MaxAge: 90 * time.Minute},
+			elapsed: time.Hour,
+			cached:  []string{"v0.3.0"},
+			evicted: []string{"v0.1.0", "v0.2.0"},
+		},
+		{
+			name:    "max size evicts oldest first",
+			policy:  CachePolicy{MaxSize: 25},
+			elapsed: time.Minute,
+			cached:  []string{"v0.2.0", "v0.3.0"},
+			evicted: []string{"v0.1.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			// Each package is 10 bytes
+			require.NoError(t, afero.WriteFile(afs, "/src/manifest.yml", []byte("0123456789"), 0o644))
+
+			now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+			cache := NewPackageCache("/cache", afs, tt.policy)
+			cache.now = func() time.Time { return now }
+
+			for _, ref := range []string{"v0.1.0", "v0.2.0", "v0.3.0"} {
+				require.NoError(t, cache.Put(url, ref, "/src"))
+				now = now.Add(tt.elapsed)
+			}
+
+			for _, ref := range tt.cached {
+				ok, err := cache.Get(url, ref, filepath.Join("/dst", ref))
+				require.NoError(t, err)
+				assert.True(t, ok, "%s should be cached", ref)
+			}
+			for _, ref := range tt.evicted {
+				ok, err := cache.Get(url, ref, filepath.Join("/dst", ref))
+				require.NoError(t, err)
+				assert.False(t, ok, "%s should be evicted", ref)
+			}
+		})
+	}
+}
+
+func TestNewPackageHandlerFromURL_Cache(t *testing.T) {
+	// Local repository with a tagged version
+	repoPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(repoPath, "readme.txt"), []byte("Test file for cached clone"), 0o644)
+	require.NoError(t, err)
+	for _, cmd := range []*exec.Cmd{
+		exec.Command("git", "-C", repoPath, "init"),
+		exec.Command("git", "-C", repoPath, "add", "readme.txt"),
+		exec.Command("git", "-C", repoPath, "config", "user.name", "user"),
+		exec.Command("git", "-C", repoPath, "config", "user.email", "user@email.com"),
+		exec.Command("git", "-C", repoPath, "commit", "-m", "Initial commit"),
+		exec.Command("git", "-C", repoPath, "tag", "-a", "v0.1.0", "-m", "Version: v0.1.0"),
+	} {
+		require.NoError(t, cmd.Run())
+	}
+
+	clones := 0
+	defer func(clone func(context.Context, string, bool, *git.CloneOptions) (*git.Repository, error)) {
+		plainClone = clone
+	}(plainClone)
+	plainClone = func(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+		clones++
+		return git.PlainCloneContext(ctx, path, isBare, o)
+	}
+
+	cache := NewPackageCache(t.TempDir(), afero.NewOsFs(), DefaultCachePolicy)
+	for i, path := range []string{t.TempDir(), t.TempDir()} {
+		pkgHandler, err := NewPackageHandlerFromURL(NewPackageHandlerOptions{
+			Path:  path,
+			URL:   repoPath,
+			Cache: cache,
+			Ref:   "v0.1.0",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, clones, "resolution %d", i+1)
+
+		// The package is usable in both cases
+		require.NoError(t, pkgHandler.CheckoutVersion("v0.1.0"))
+		readme, err := os.ReadFile(filepath.Join(path, "readme.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "Test file for cached clone", string(readme))
+	}
+}
@@ -17,6 +17,8 @@ var (
 	ErrNoPlugin                   = errors.New("no plugin found")
 	ErrProfileComposeFileNotFound = errors.New("profile compose file not found")
 	ErrBuildContextNotAllowed     = errors.New("build context not allowed")
+	ErrUndefinedEnvVar            = errors.New("undefined environment variable")
+	ErrMultipleManifests          = errors.New("more than one manifest file")
 )
 
 // PackageFileNotFoundError is returned when a package file is not found.
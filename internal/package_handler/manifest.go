@@ -1,20 +1,49 @@
 package package_handler
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 
 	"github.com/docker/distribution/reference"
+	"gopkg.in/yaml.v3"
 )
 
+// manifestFileNames are the accepted names of the manifest file of a package.
+var manifestFileNames = []string{"manifest.yml", "manifest.yaml", "manifest.json"}
+
+// ParseManifest parses the manifest file with the given name and content. The
+// manifest can be written in YAML or JSON, which is detected by the file
+// extension or, for other extensions, by the content starting with '{'. Both
+// formats have the same fields and produce the same Manifest.
+func ParseManifest(name string, data []byte) (*Manifest, error) {
+	var manifest Manifest
+	if filepath.Ext(name) == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		// JSON is decoded as JSON, even if it's also valid YAML, for the JSON
+		// escapes that YAML doesn't have, like \/
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid JSON manifest %s: %w", name, err)
+		}
+		return &manifest, nil
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
 // Manifest represents the manifest file of a package
 type Manifest struct {
-	Version              string               `yaml:"version"`
-	Name                 string               `yaml:"name"`
-	Upgrade              string               `yaml:"upgrade"`
-	HardwareRequirements hardwareRequirements `yaml:"hardware_requirements"`
-	Plugin               *Plugin              `yaml:"plugin"`
-	Profiles             []string             `yaml:"profiles"`
+	Version              string               `yaml:"version" json:"version"`
+	Name                 string               `yaml:"name" json:"name"`
+	Upgrade              string               `yaml:"upgrade" json:"upgrade"`
+	HardwareRequirements hardwareRequirements `yaml:"hardware_requirements" json:"hardware_requirements"`
+	Plugin               *Plugin              `yaml:"plugin" json:"plugin"`
+	Profiles             []string             `yaml:"profiles" json:"profiles"`
+	Dashboards           []Dashboard          `yaml:"dashboards" json:"dashboards"`
 }
 
 func (m *Manifest) validate() error {
@@ -39,6 +68,24 @@ func (m *Manifest) validate() error {
 		pluginErr = m.Plugin.validate()
 	}
 
+	var dashboardsErr error
+	fileNames := make(map[string]bool)
+	for i, dashboard := range m.Dashboards {
+		if err := dashboard.validate(i, m.Profiles); err != nil {
+			dashboardsErr = errors.Join(dashboardsErr, err)
+			continue
+		}
+		// The dashboards of an instance are provisioned in the same directory
+		fileName := filepath.Base(dashboard.Path)
+		if fileNames[fileName] {
+			dashboardsErr = errors.Join(dashboardsErr, InvalidConfError{
+				message:       "Invalid dashboard",
+				invalidFields: []string{fmt.Sprintf("dashboards[%d].path -> (duplicated file name %s)", i, fileName)},
+			})
+		}
+		fileNames[fileName] = true
+	}
+
 	profileErr := errors.New("invalid profiles")
 	invalidProfiles := false
 	for i, profile := range m.Profiles {
@@ -48,7 +95,7 @@ func (m *Manifest) validate() error {
 		}
 	}
 
-	if hardReqErr != nil || pluginErr != nil || invalidProfiles || len(missingFields) > 0 {
+	if hardReqErr != nil || pluginErr != nil || dashboardsErr != nil || invalidProfiles || len(missingFields) > 0 {
 		var err error = InvalidConfError{
 			message:       "Invalid manifest file",
 			missingFields: missingFields,
@@ -59,6 +106,9 @@ func (m *Manifest) validate() error {
 		if pluginErr != nil {
 			err = fmt.Errorf("%w: %w", err, pluginErr)
 		}
+		if dashboardsErr != nil {
+			err = fmt.Errorf("%w: %w", err, dashboardsErr)
+		}
 		if invalidProfiles {
 			err = fmt.Errorf("%w: %w", err, profileErr)
 		}
@@ -69,10 +119,10 @@ func (m *Manifest) validate() error {
 }
 
 type hardwareRequirements struct {
-	MinCPUCores                 int  `yaml:"min_cpu_cores"`
-	MinRAM                      int  `yaml:"min_ram"`
-	MinFreeSpace                int  `yaml:"min_free_space"`
-	StopIfRequirementsAreNotMet bool `yaml:"stop_if_requirements_are_not_met"`
+	MinCPUCores                 int  `yaml:"min_cpu_cores" json:"min_cpu_cores"`
+	MinRAM                      int  `yaml:"min_ram" json:"min_ram"`
+	MinFreeSpace                int  `yaml:"min_free_space" json:"min_free_space"`
+	StopIfRequirementsAreNotMet bool `yaml:"stop_if_requirements_are_not_met" json:"stop_if_requirements_are_not_met"`
 }
 
 func (h *hardwareRequirements) validate() error {
@@ -96,7 +146,7 @@ func (h *hardwareRequirements) validate() error {
 }
 
 type Plugin struct {
-	Image string `yaml:"image"`
+	Image string `yaml:"image" json:"image"`
 }
 
 func (p *Plugin) validate() error {
@@ -118,3 +168,40 @@ func (p *Plugin) validate() error {
 	}
 	return nil
 }
+
+// Dashboard is a Grafana dashboard shipped with the package.
+type Dashboard struct {
+	// Path is the path of the dashboard JSON file, relative to the pkg
+	// directory of the package.
+	Path string `yaml:"path" json:"path"`
+	// Profiles are the names of the profiles the dashboard is provisioned for.
+	// If empty, the dashboard is provisioned for all the profiles.
+	Profiles []string `yaml:"profiles" json:"profiles"`
+}
+
+func (d *Dashboard) validate(idx int, profiles []string) error {
+	var invalidFields, missingFields []string
+	if d.Path == "" {
+		missingFields = append(missingFields, fmt.Sprintf("dashboards[%d].path", idx))
+	} else if !filepath.IsLocal(d.Path) || filepath.Ext(d.Path) != ".json" {
+		invalidFields = append(invalidFields, fmt.Sprintf("dashboards[%d].path -> (must be a JSON file inside the package)", idx))
+	}
+	for _, profile := range d.Profiles {
+		if !slices.Contains(profiles, profile) {
+			invalidFields = append(invalidFields, fmt.Sprintf("dashboards[%d].profiles -> (unknown profile %s)", idx, profile))
+		}
+	}
+	if len(invalidFields) > 0 || len(missingFields) > 0 {
+		return InvalidConfError{
+			message:       "Invalid dashboard",
+			invalidFields: invalidFields,
+			missingFields: missingFields,
+		}
+	}
+	return nil
+}
+
+// appliesTo returns true if the dashboard is provisioned for the given profile.
+func (d *Dashboard) appliesTo(profile string) bool {
+	return len(d.Profiles) == 0 || slices.Contains(d.Profiles, profile)
+}
@@ -1,6 +1,7 @@
 package package_handler
 
 import (
+	"bytes"
 	"path/filepath"
 	"testing"
 
@@ -72,6 +73,70 @@ func TestManifest_ValidateFromYML(t *testing.T) {
 	}
 }
 
+func TestParseManifest(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	testDir, err := afero.TempDir(afs, "", "test")
+	require.NoError(t, err)
+	testdata.SetupDir(t, "manifests", testDir, afs)
+
+	yamlData, err := afero.ReadFile(afs, filepath.Join(testDir, "manifests", "full-ok", "pkg", "manifest.yml"))
+	require.NoError(t, err)
+	jsonData, err := afero.ReadFile(afs, filepath.Join(testDir, "manifests", "full-ok-json", "pkg", "manifest.json"))
+	require.NoError(t, err)
+
+	want, err := ParseManifest("manifest.yml", yamlData)
+	require.NoError(t, err)
+	require.NoError(t, want.validate())
+	assert.Equal(t, "sample-avs", want.Name)
+	assert.Equal(t, "your-organization/plugin-service:latest", want.Plugin.Image)
+
+	tests := []struct {
+		name      string
+		fileName  string
+		data      []byte
+		wantError bool
+	}{
+		{
+			name:     "json manifest",
+			fileName: "manifest.json",
+			data:     jsonData,
+		},
+		{
+			name:     "json content with a yaml extension",
+			fileName: "manifest.yaml",
+			data:     jsonData,
+		},
+		{
+			name:     "json escapes",
+			fileName: "manifest.json",
+			data:     bytes.ReplaceAll(jsonData, []byte("your-organization/"), []byte(`your-organization\/`)),
+		},
+		{
+			name:      "invalid json",
+			fileName:  "manifest.json",
+			data:      []byte(`{"name": "sample-avs",}`),
+			wantError: true,
+		},
+		{
+			name:      "yaml content with a json extension",
+			fileName:  "manifest.json",
+			data:      yamlData,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := ParseManifest(tt.fileName, tt.data)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, manifest)
+		})
+	}
+}
+
 // TODO: Add more test cases
 func TestManifest_Validate(t *testing.T) {
 	tests := []struct {
@@ -117,6 +182,46 @@ func TestManifest_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "dashboard for unknown profile",
+			manifest: &Manifest{
+				Version:  "1.0.0",
+				Name:     "test-package",
+				Upgrade:  "manual",
+				Profiles: []string{"test-profile"},
+				Dashboards: []Dashboard{
+					{Path: "dashboards/main.json", Profiles: []string{"other-profile"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dashboard outside the package",
+			manifest: &Manifest{
+				Version:  "1.0.0",
+				Name:     "test-package",
+				Upgrade:  "manual",
+				Profiles: []string{"test-profile"},
+				Dashboards: []Dashboard{
+					{Path: "../main.json"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated dashboard file name",
+			manifest: &Manifest{
+				Version:  "1.0.0",
+				Name:     "test-package",
+				Upgrade:  "manual",
+				Profiles: []string{"test-profile"},
+				Dashboards: []Dashboard{
+					{Path: "a/main.json"},
+					{Path: "b/main.json"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,19 +1,29 @@
 package package_handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"net"
+	nethttp "net/http"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/env"
 	"github.com/NethermindEth/eigenlayer/internal/profile"
 	"github.com/compose-spec/compose-go/cli"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/spf13/afero"
 	"golang.org/x/mod/semver"
 	"gopkg.in/yaml.v3"
@@ -22,12 +32,14 @@ import (
 const (
 	pkgDirName             = "pkg"
 	checksumFileName       = "checksum.txt"
-	manifestFileName       = "manifest.yml"
 	profileFileName        = "profile.yml"
 	manifestSchemaFileName = "schema/manifest_schema.yml"
 	profileSchemaFileName  = "schema/profile_schema.yml"
 )
 
+// DefaultRetryBackoff is the wait before the first retry of a failed clone.
+const DefaultRetryBackoff = time.Second
+
 // PackageHandler is used to interact with an AVS node software package at the given
 // path.
 type PackageHandler struct {
@@ -48,6 +60,29 @@ type NewPackageHandlerOptions struct {
 	URL string
 	// GitAuth is used to provide authentication to a private git repository
 	GitAuth *GitAuth
+	// Cache, if set together with Ref, is used to get the package without cloning
+	// it when it was already downloaded, and to store it after cloning otherwise.
+	Cache *PackageCache
+	// Ref is the version tag or commit hash of the package that will be used.
+	// It is only used as part of the cache key; the clone is not checked out.
+	Ref string
+	// Context cancels the clone and the waits between its retries. If nil,
+	// context.Background is used.
+	Context context.Context
+	// Retries is the number of times the clone is retried after a transient
+	// failure, like a network timeout. Fatal failures, like a repository that
+	// does not exist, are never retried.
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubled on each of the
+	// following retries. If zero, DefaultRetryBackoff is used.
+	RetryBackoff time.Duration
+	// CABundle is a PEM bundle with the certificates of additional certificate
+	// authorities trusted by clones over HTTPS, like the one of a
+	// TLS-intercepting proxy. The system certificates are still trusted.
+	CABundle []byte
+	// ExtraHeaders are sent with every HTTP request of the clone, like the git
+	// http.extraHeader config, usually to pass an auth token.
+	ExtraHeaders nethttp.Header
 }
 
 // GitAuth is used to provide authentication to a private git repository. Two types of
@@ -84,13 +119,65 @@ func (g *NewPackageHandlerOptions) getAuth() *http.BasicAuth {
 	}
 }
 
+// cloneAuth returns the auth method of the clone. The extra headers, if any,
+// are set on the requests together with the basic auth.
+func (g *NewPackageHandlerOptions) cloneAuth() transport.AuthMethod {
+	if len(g.ExtraHeaders) == 0 {
+		return g.getAuth()
+	}
+	return &headerAuth{basic: g.getAuth(), headers: g.ExtraHeaders}
+}
+
+// headerAuth is an HTTP auth method that sets extra headers on the requests,
+// in addition to the basic auth, if any.
+type headerAuth struct {
+	basic   *http.BasicAuth
+	headers nethttp.Header
+}
+
+func (a *headerAuth) Name() string {
+	return "http-extra-header"
+}
+
+// String does not print the header values, as they usually hold credentials.
+func (a *headerAuth) String() string {
+	names := make([]string, 0, len(a.headers))
+	for name := range a.headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return fmt.Sprintf("%s - %s", a.Name(), strings.Join(names, ", "))
+}
+
+func (a *headerAuth) SetAuth(r *nethttp.Request) {
+	a.basic.SetAuth(r)
+	for name, values := range a.headers {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+}
+
+// plainClone clones a git repository. It is a variable so tests can check when
+// a clone happens.
+var plainClone = git.PlainCloneContext
+
 // NewPackageHandlerFromURL clones the package from the given URL and returns. The GitAuth
-// field could be used to provide authentication to a private git repository.
+// field could be used to provide authentication to a private git repository. If
+// a Cache and a Ref are given, the package is copied from the cache when it is
+// there, and stored in it after cloning when it is not.
 func NewPackageHandlerFromURL(opts NewPackageHandlerOptions) (*PackageHandler, error) {
-	_, err := git.PlainClone(opts.Path, false, &git.CloneOptions{
-		URL:  opts.URL,
-		Auth: opts.getAuth(),
-	})
+	useCache := opts.Cache != nil && opts.Ref != ""
+	if useCache {
+		ok, err := opts.Cache.Get(opts.URL, opts.Ref, opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return NewPackageHandler(opts.Path), nil
+		}
+	}
+	err := clone(opts)
 	if err != nil {
 		if errors.Is(err, transport.ErrAuthenticationRequired) {
 			return nil, RepositoryNotFoundOrPrivateError{
@@ -104,13 +191,80 @@ func NewPackageHandlerFromURL(opts NewPackageHandlerOptions) (*PackageHandler, e
 		}
 		return nil, err
 	}
+	if useCache {
+		if err := opts.Cache.Put(opts.URL, opts.Ref, opts.Path); err != nil {
+			return nil, err
+		}
+	}
 	return NewPackageHandler(opts.Path), nil
 }
 
+// clone clones the package repository, retrying with exponential backoff the
+// clones that fail with a transient error. A failed clone leaves the
+// destination directory empty, so it can be retried in the same path.
+func clone(opts NewPackageHandlerOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		_, err := plainClone(ctx, opts.Path, false, &git.CloneOptions{
+			URL:      opts.URL,
+			Auth:     opts.cloneAuth(),
+			CABundle: opts.CABundle,
+		})
+		if err == nil || attempt >= opts.Retries || !isTransientCloneError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientCloneError returns true if the clone failed with an error that
+// could go away by trying again, like a network error or a server error.
+func isTransientCloneError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var httpErr *http.Err
+	if errors.As(err, &httpErr) && httpErr.Response != nil {
+		code := httpErr.Response.StatusCode
+		return code >= nethttp.StatusInternalServerError || code == nethttp.StatusTooManyRequests
+	}
+	return false
+}
+
+// ChecksumProgress is called by CheckWithProgress after the checksum of each
+// package file is computed, with the file path relative to the package, the
+// number of files hashed so far and the total number of files to hash.
+type ChecksumProgress func(file string, done, total int)
+
 // Check validates a package. It returns an error if the package is invalid.
 // It checks the existence of some required files and directories and computes the
 // checksums comparing them with the ones listed in the checksum.txt file.
 func (p *PackageHandler) Check() error {
+	return p.CheckWithProgress(nil)
+}
+
+// CheckWithProgress is like Check, but it calls progress, if not nil, after
+// the checksum of each package file is computed.
+func (p *PackageHandler) CheckWithProgress(progress ChecksumProgress) error {
 	if err := checkPackageDirExist(p.path, pkgDirName, p.afs); err != nil {
 		return err
 	}
@@ -122,7 +276,7 @@ func (p *PackageHandler) Check() error {
 		}
 		return err
 	}
-	return p.checkSum()
+	return p.checkSum(progress)
 }
 
 // Versions returns the descending sorted list of available versions for the package.
@@ -151,6 +305,48 @@ func (p *PackageHandler) Versions() ([]string, error) {
 	return versions, nil
 }
 
+// RemoteVersions returns the ascending sorted list of the versions of the
+// package repository at opts.URL, like Versions, listing the remote tags
+// without cloning the repository. Only the URL, auth, CA bundle, extra headers
+// and context of the options are used.
+func RemoteVersions(opts NewPackageHandlerOptions) ([]string, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{opts.URL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{
+		Auth:     opts.cloneAuth(),
+		CABundle: opts.CABundle,
+	})
+	if err != nil {
+		if errors.Is(err, transport.ErrAuthenticationRequired) {
+			return nil, RepositoryNotFoundOrPrivateError{URL: opts.URL}
+		}
+		if errors.Is(err, transport.ErrRepositoryNotFound) {
+			return nil, RepositoryNotFoundError{URL: opts.URL}
+		}
+		return nil, err
+	}
+	var versions []string
+	for _, ref := range refs {
+		if !ref.Name().IsTag() {
+			continue
+		}
+		if tag := ref.Name().Short(); semver.IsValid(tag) {
+			versions = append(versions, tag)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrNoVersionsFound
+	}
+	semver.Sort(versions)
+	return versions, nil
+}
+
 // HasVersion returns an error if the given version is not available for the package.
 func (p *PackageHandler) HasVersion(version string) error {
 	versions, err := p.Versions()
@@ -317,6 +513,9 @@ func (p *PackageHandler) CurrentCommitHash() (string, error) {
 }
 
 // Profiles returns the list of profiles defined in the package for the current version.
+// The environment variables in the option defaults are expanded, but a profile
+// referencing an undefined variable keeps its defaults as they are, so it does not
+// prevent using the other profiles. Profile returns the error instead.
 func (p *PackageHandler) Profiles() ([]profile.Profile, error) {
 	names, err := p.profilesNames()
 	if err != nil {
@@ -330,6 +529,9 @@ func (p *PackageHandler) Profiles() ([]profile.Profile, error) {
 			return nil, err
 		}
 		profile.Name = profileName
+		if err := expandDefaults(profile); err != nil && !errors.Is(err, ErrUndefinedEnvVar) {
+			return nil, err
+		}
 
 		if err := profile.Validate(); err != nil {
 			return nil, err
@@ -341,7 +543,9 @@ func (p *PackageHandler) Profiles() ([]profile.Profile, error) {
 	return profiles, nil
 }
 
-// Profiles returns the list of profiles defined in the package for the current version.
+// Profile returns the profile with the given name defined in the package for the current
+// version, with the environment variables in its option defaults expanded. Referencing
+// an undefined variable returns an ErrUndefinedEnvVar error.
 func (p *PackageHandler) Profile(name string) (*profile.Profile, error) {
 	names, err := p.profilesNames()
 	if err != nil {
@@ -355,6 +559,9 @@ func (p *PackageHandler) Profile(name string) (*profile.Profile, error) {
 				return nil, err
 			}
 			profile.Name = profileName
+			if err := expandDefaults(profile); err != nil {
+				return nil, err
+			}
 			err = profile.Validate()
 			if err != nil {
 				return nil, err
@@ -366,6 +573,42 @@ func (p *PackageHandler) Profile(name string) (*profile.Profile, error) {
 	return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
 }
 
+// ProfileDashboards returns the dashboards declared in the manifest for the
+// given profile, keyed by their file name. Dashboards declared for other
+// profiles are left out. If the profile does not exist, an ErrProfileNotFound
+// error is returned.
+func (p *PackageHandler) ProfileDashboards(profileName string) (map[string][]byte, error) {
+	manifest, err := p.parseManifest()
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(manifest.Profiles, profileName) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, profileName)
+	}
+
+	dashboards := make(map[string][]byte)
+	for _, dashboard := range manifest.Dashboards {
+		if !dashboard.appliesTo(profileName) {
+			continue
+		}
+		raw, err := afero.ReadFile(p.afs, filepath.Join(p.path, pkgDirName, dashboard.Path))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, PackageFileNotFoundError{
+					fileRelativePath: filepath.Join(pkgDirName, dashboard.Path),
+					packagePath:      p.path,
+				}
+			}
+			return nil, err
+		}
+		dashboards[filepath.Base(dashboard.Path)] = raw
+	}
+	return dashboards, nil
+}
+
 // CheckComposeProject checks if the compose project for the given profile is valid.
 func (p *PackageHandler) CheckComposeProject(profileName string, env map[string]string) error {
 	composeFile := filepath.Join(p.path, pkgDirName, profileName, "docker-compose.yml")
@@ -442,7 +685,10 @@ func (p *PackageHandler) Plugin() (*Plugin, error) {
 }
 
 func (p *PackageHandler) parseManifest() (*Manifest, error) {
-	manifestPath := filepath.Join(p.path, pkgDirName, manifestFileName)
+	manifestPath, err := p.manifestPath()
+	if err != nil {
+		return nil, err
+	}
 	// Validate YAML Schema
 	// TODO: Fix the relative path
 	// err := validateYAMLSchema(manifestSchemaFileName, manifestPath)
@@ -457,15 +703,40 @@ func (p *PackageHandler) parseManifest() (*Manifest, error) {
 		}, err)
 	}
 
-	var manifest Manifest
-	err = yaml.Unmarshal(data, &manifest)
+	manifest, err := ParseManifest(filepath.Base(manifestPath), data)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ParsingManifestError{
 			pkgPath: p.path,
 		}, err)
 	}
 
-	return &manifest, nil
+	return manifest, nil
+}
+
+// manifestPath returns the path of the manifest file of the package, which is
+// one of manifestFileNames. If there is none, the path of the manifest.yml
+// file is returned, so reading it fails with a not found error. An
+// ErrMultipleManifests error is returned if there is more than one.
+func (p *PackageHandler) manifestPath() (string, error) {
+	var found []string
+	for _, name := range manifestFileNames {
+		path := filepath.Join(p.path, pkgDirName, name)
+		ok, err := afero.Exists(p.afs, path)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return filepath.Join(p.path, pkgDirName, manifestFileNames[0]), nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrMultipleManifests, strings.Join(found, ", "))
+	}
 }
 
 // HardwareRequirements returns the hardware requirements for the specified profile.
@@ -500,9 +771,14 @@ func (p *PackageHandler) Path() string {
 	return p.path
 }
 
-// ManifestFilePath returns the path of the manifest file.
+// ManifestFilePath returns the path of the manifest file, which is
+// manifest.yml, manifest.yaml or manifest.json.
 func (p *PackageHandler) ManifestFilePath() string {
-	return filepath.Join(p.path, pkgDirName, manifestFileName)
+	manifestPath, err := p.manifestPath()
+	if err != nil {
+		return filepath.Join(p.path, pkgDirName, manifestFileNames[0])
+	}
+	return manifestPath
 }
 
 func (p *PackageHandler) profilesNames() ([]string, error) {
@@ -545,12 +821,49 @@ func (p *PackageHandler) parseProfile(profileName string) (*profile.Profile, err
 	return &profile, nil
 }
 
-func (p *PackageHandler) checkSum() error {
+// expandDefaults expands the environment variables in the option defaults of
+// the profile. The profile is left unchanged if any of them fails.
+func expandDefaults(p *profile.Profile) error {
+	defaults := make([]string, len(p.Options))
+	for i, o := range p.Options {
+		def, err := expandEnv(o.Default)
+		if err != nil {
+			return fmt.Errorf("%w: option %s: %w", ParsingProfileError{
+				profileName: p.Name,
+			}, o.Name, err)
+		}
+		defaults[i] = def
+	}
+	for i := range p.Options {
+		p.Options[i].Default = defaults[i]
+	}
+	return nil
+}
+
+// expandEnv replaces ${var} or $var in s with the value of the environment
+// variable var. Unlike os.ExpandEnv, referencing an undefined variable is an
+// error instead of being replaced by an empty string.
+func expandEnv(s string) (string, error) {
+	var undefined []string
+	expanded := os.Expand(s, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUndefinedEnvVar, strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
+
+func (p *PackageHandler) checkSum(progress ChecksumProgress) error {
 	currentChecksums, err := parseChecksumFile(filepath.Join(p.path, checksumFileName), p.afs)
 	if err != nil {
 		return err
 	}
-	computedChecksums, err := packageHashes(p.path, p.afs)
+	computedChecksums, err := packageHashes(p.path, p.afs, progress)
 	if err != nil {
 		return err
 	}
@@ -565,6 +878,25 @@ func (p *PackageHandler) checkSum() error {
 	return nil
 }
 
+// GenerateChecksums computes the checksums of the files in the pkg directory of
+// the package at pkgDir and writes them to its checksum.txt file, replacing any
+// existing one. The generated file is accepted by Check as long as the package
+// files do not change.
+func GenerateChecksums(pkgDir string) error {
+	return NewPackageHandler(pkgDir).generateChecksums()
+}
+
+func (p *PackageHandler) generateChecksums() error {
+	if err := checkPackageDirExist(p.path, pkgDirName, p.afs); err != nil {
+		return err
+	}
+	checksums, err := packageHashes(p.path, p.afs, nil)
+	if err != nil {
+		return err
+	}
+	return writeChecksumFile(filepath.Join(p.path, checksumFileName), checksums, p.afs)
+}
+
 func (p *PackageHandler) SpecVersion() (string, error) {
 	manifest, err := p.parseManifest()
 	if err != nil {
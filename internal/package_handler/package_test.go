@@ -1,15 +1,26 @@
 package package_handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	nethttp "net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/common"
 	"github.com/NethermindEth/eigenlayer/internal/package_handler/testdata"
 	"github.com/NethermindEth/eigenlayer/internal/profile"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,6 +84,202 @@ func TestNewPackageHandlerFromURL(t *testing.T) {
 	}
 }
 
+func TestNewPackageHandlerFromURL_Retries(t *testing.T) {
+	// Local repository to clone
+	repoPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "readme.txt"), []byte("Test file for flaky clone"), 0o644))
+	for _, cmd := range []*exec.Cmd{
+		exec.Command("git", "-C", repoPath, "init"),
+		exec.Command("git", "-C", repoPath, "add", "readme.txt"),
+		exec.Command("git", "-C", repoPath, "config", "user.name", "user"),
+		exec.Command("git", "-C", repoPath, "config", "user.email", "user@email.com"),
+		exec.Command("git", "-C", repoPath, "commit", "-m", "Initial commit"),
+	} {
+		require.NoError(t, cmd.Run())
+	}
+	transientErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	tc := []struct {
+		name       string
+		failures   []error
+		retries    int
+		ctx        func() context.Context
+		wantClones int
+		wantErr    error
+	}{
+		{
+			name:       "transient failures, eventually succeeds",
+			failures:   []error{transientErr, io.ErrUnexpectedEOF},
+			retries:    3,
+			wantClones: 3,
+		},
+		{
+			name:       "transient failures, out of retries",
+			failures:   []error{transientErr, transientErr, transientErr},
+			retries:    2,
+			wantClones: 3,
+			wantErr:    transientErr,
+		},
+		{
+			name:       "fatal failure is not retried",
+			failures:   []error{transport.ErrRepositoryNotFound},
+			retries:    3,
+			wantClones: 1,
+			wantErr:    RepositoryNotFoundError{URL: repoPath},
+		},
+		{
+			name:     "cancelled context stops the retries",
+			failures: []error{transientErr},
+			retries:  3,
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			wantClones: 1,
+			wantErr:    context.Canceled,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			clones := 0
+			defer func(clone func(context.Context, string, bool, *git.CloneOptions) (*git.Repository, error)) {
+				plainClone = clone
+			}(plainClone)
+			// Flaky transport: the first clones fail with the given errors
+			plainClone = func(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+				clones++
+				if clones <= len(tt.failures) {
+					return nil, tt.failures[clones-1]
+				}
+				return git.PlainCloneContext(ctx, path, isBare, o)
+			}
+
+			opts := NewPackageHandlerOptions{
+				Path:         t.TempDir(),
+				URL:          repoPath,
+				Retries:      tt.retries,
+				RetryBackoff: time.Millisecond,
+			}
+			if tt.ctx != nil {
+				opts.Context = tt.ctx()
+			}
+			_, err := NewPackageHandlerFromURL(opts)
+			assert.Equal(t, tt.wantClones, clones)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			readme, err := os.ReadFile(filepath.Join(opts.Path, "readme.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "Test file for flaky clone", string(readme))
+		})
+	}
+}
+
+func TestCloneGitOptions(t *testing.T) {
+	caBundle := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+	tc := []struct {
+		name        string
+		opts        NewPackageHandlerOptions
+		wantHeaders nethttp.Header
+	}{
+		{
+			name:        "defaults",
+			wantHeaders: nethttp.Header{},
+		},
+		{
+			name:        "ca bundle",
+			opts:        NewPackageHandlerOptions{CABundle: caBundle},
+			wantHeaders: nethttp.Header{},
+		},
+		{
+			name: "extra headers",
+			opts: NewPackageHandlerOptions{
+				ExtraHeaders: nethttp.Header{
+					"Authorization":  {"Bearer token"},
+					"X-Proxy-Tenant": {"egn"},
+				},
+			},
+			wantHeaders: nethttp.Header{
+				"Authorization":  {"Bearer token"},
+				"X-Proxy-Tenant": {"egn"},
+			},
+		},
+		{
+			name: "extra headers and basic auth",
+			opts: NewPackageHandlerOptions{
+				GitAuth:      &GitAuth{Username: "user", Pat: "pat"},
+				ExtraHeaders: nethttp.Header{"X-Proxy-Tenant": {"egn"}},
+			},
+			wantHeaders: nethttp.Header{
+				"Authorization":  {"Basic dXNlcjpwYXQ="},
+				"X-Proxy-Tenant": {"egn"},
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			var cloneOpts *git.CloneOptions
+			defer func(clone func(context.Context, string, bool, *git.CloneOptions) (*git.Repository, error)) {
+				plainClone = clone
+			}(plainClone)
+			plainClone = func(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+				cloneOpts = o
+				return nil, transport.ErrRepositoryNotFound
+			}
+
+			opts := tt.opts
+			opts.Path = t.TempDir()
+			opts.URL = "https://github.com/NethermindEth/mock-avs-pkg"
+			_, err := NewPackageHandlerFromURL(opts)
+			require.ErrorIs(t, err, RepositoryNotFoundError{URL: opts.URL})
+			require.NotNil(t, cloneOpts)
+			assert.Equal(t, opts.URL, cloneOpts.URL)
+			assert.Equal(t, tt.opts.CABundle, cloneOpts.CABundle)
+
+			// The auth method sets the headers of the clone requests
+			auth, ok := cloneOpts.Auth.(http.AuthMethod)
+			require.True(t, ok)
+			req, err := nethttp.NewRequest(nethttp.MethodGet, opts.URL, nil)
+			require.NoError(t, err)
+			auth.SetAuth(req)
+			assert.Equal(t, tt.wantHeaders, req.Header)
+			for _, values := range tt.opts.ExtraHeaders {
+				assert.NotContains(t, auth.String(), values[0])
+			}
+		})
+	}
+}
+
+func TestIsTransientCloneError(t *testing.T) {
+	httpErr := func(code int) error {
+		return &http.Err{Response: &nethttp.Response{StatusCode: code}}
+	}
+	tc := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "unexpected EOF", err: fmt.Errorf("fetching: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "connection reset", err: syscall.ECONNRESET, want: true},
+		{name: "bad gateway", err: httpErr(nethttp.StatusBadGateway), want: true},
+		{name: "too many requests", err: httpErr(nethttp.StatusTooManyRequests), want: true},
+		{name: "bad request", err: httpErr(nethttp.StatusBadRequest), want: false},
+		{name: "repository not found", err: transport.ErrRepositoryNotFound, want: false},
+		{name: "authentication required", err: transport.ErrAuthenticationRequired, want: false},
+		{name: "context cancelled", err: context.Canceled, want: false},
+		{name: "reference not found", err: errors.New("reference not found"), want: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTransientCloneError(tt.err))
+		})
+	}
+}
+
 func TestCheck(t *testing.T) {
 	type testCase struct {
 		name      string
@@ -169,6 +376,115 @@ func setupPackage(t *testing.T) string {
 	return pkgFolder
 }
 
+func TestGenerateChecksums(t *testing.T) {
+	afs := afero.NewOsFs()
+
+	t.Run("round trip", func(t *testing.T) {
+		testDir := t.TempDir()
+		testdata.SetupDir(t, "mock-avs", testDir, afs)
+		pkgDir := filepath.Join(testDir, "mock-avs")
+		// Stale checksums are replaced
+		require.NoError(t, afero.WriteFile(afs, filepath.Join(pkgDir, "checksum.txt"), []byte("0000  pkg/manifest.yml\n"), 0o644))
+
+		require.NoError(t, GenerateChecksums(pkgDir))
+
+		checksums, err := os.ReadFile(filepath.Join(pkgDir, "checksum.txt"))
+		require.NoError(t, err)
+		manifestHash, err := hashFile(filepath.Join(pkgDir, "pkg", "manifest.yml"), afs)
+		require.NoError(t, err)
+		var files []string
+		for _, line := range strings.Split(strings.TrimSuffix(string(checksums), "\n"), "\n") {
+			hash, file, ok := strings.Cut(line, "  ")
+			require.True(t, ok, "invalid line %q", line)
+			if file == "pkg/manifest.yml" {
+				assert.Equal(t, manifestHash, hash)
+			}
+			files = append(files, file)
+		}
+		// One line per file, sorted by file
+		assert.Equal(t, []string{
+			"pkg/manifest.yml",
+			"pkg/sepolia/.env",
+			"pkg/sepolia/docker-compose.yml",
+			"pkg/sepolia/profile.yml",
+		}, files)
+
+		assert.NoError(t, NewPackageHandler(pkgDir).Check())
+
+		// Changes after generating the checksums are detected
+		require.NoError(t, afero.WriteFile(afs, filepath.Join(pkgDir, "pkg", "manifest.yml"), []byte("changed"), 0o644))
+		assert.ErrorIs(t, NewPackageHandler(pkgDir).Check(), ErrInvalidChecksum)
+	})
+	t.Run("pkg folder does not exist", func(t *testing.T) {
+		pkgDir := t.TempDir()
+		err := GenerateChecksums(pkgDir)
+		assert.ErrorIs(t, err, PackageDirNotFoundError{
+			dirRelativePath: "pkg",
+			packagePath:     pkgDir,
+		})
+	})
+}
+
+func TestJSONManifest(t *testing.T) {
+	afs := afero.NewOsFs()
+	testDir := t.TempDir()
+	testdata.SetupDir(t, "mock-avs", testDir, afs)
+	pkgDir := filepath.Join(testDir, "mock-avs")
+	yamlManifest, err := NewPackageHandler(pkgDir).parseManifest()
+	require.NoError(t, err)
+
+	// Replace the YAML manifest with its JSON version
+	require.NoError(t, os.Remove(filepath.Join(pkgDir, "pkg", "manifest.yml")))
+	jsonManifest := `{"version": "v0.1.0", "name": "eth-cl", "upgrade": "recommended", "profiles": ["sepolia"]}`
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "pkg", "manifest.json"), []byte(jsonManifest), 0o644))
+	require.NoError(t, GenerateChecksums(pkgDir))
+
+	pkgHandler := NewPackageHandler(pkgDir)
+	assert.NoError(t, pkgHandler.Check())
+	assert.Equal(t, filepath.Join(pkgDir, "pkg", "manifest.json"), pkgHandler.ManifestFilePath())
+	manifest, err := pkgHandler.parseManifest()
+	require.NoError(t, err)
+	assert.Equal(t, yamlManifest, manifest)
+	profiles, err := pkgHandler.profilesNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sepolia"}, profiles)
+
+	// A package with more than one manifest is ambiguous
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "pkg", "manifest.yaml"), []byte("name: eth-cl\n"), 0o644))
+	_, err = pkgHandler.parseManifest()
+	assert.ErrorIs(t, err, ErrMultipleManifests)
+}
+
+func TestCheckWithProgress(t *testing.T) {
+	afs := afero.NewOsFs()
+	testDir := t.TempDir()
+	testdata.SetupDir(t, "mock-avs", testDir, afs)
+	pkgDir := filepath.Join(testDir, "mock-avs")
+	require.NoError(t, GenerateChecksums(pkgDir))
+
+	var (
+		files []string
+		dones []int
+	)
+	err := NewPackageHandler(pkgDir).CheckWithProgress(func(file string, done, total int) {
+		files = append(files, file)
+		dones = append(dones, done)
+		assert.Equal(t, 4, total)
+	})
+	require.NoError(t, err)
+	// Called once per file with increasing done
+	assert.ElementsMatch(t, []string{
+		"pkg/manifest.yml",
+		"pkg/sepolia/.env",
+		"pkg/sepolia/docker-compose.yml",
+		"pkg/sepolia/profile.yml",
+	}, files)
+	assert.Equal(t, []int{1, 2, 3, 4}, dones)
+
+	// Without a callback the result is the same
+	assert.NoError(t, NewPackageHandler(pkgDir).CheckWithProgress(nil))
+}
+
 func TestProfilesNames(t *testing.T) {
 	afs := afero.NewOsFs()
 	testDir, err := afero.TempDir(afs, "", "test")
@@ -191,6 +507,11 @@ func TestProfilesNames(t *testing.T) {
 			folderPath: "minimal",
 			profiles:   []string{"profile1", "profile2"},
 		},
+		{
+			name:       "valid json manifest",
+			folderPath: "full-ok-json",
+			profiles:   []string{"profile1"},
+		},
 		{
 			name:       "invalid manifest",
 			folderPath: "invalid-fields",
@@ -323,7 +644,7 @@ func TestProfiles(t *testing.T) {
 			name:    "invalid profile",
 			pkgPath: "invalid-profile",
 			want:    []profile.Profile{},
-			err:     fmt.Errorf("Invalid profile: invalid options: %w: %w: invalid monitoring: %w", InvalidConfError{message: "Option #1 is invalid", invalidFields: []string{"options.default"}}, InvalidConfError{message: "Option #2 is invalid", missingFields: []string{"options.type", "options.help"}}, InvalidConfError{message: "Monitoring target #1 is invalid", missingFields: []string{"monitoring.targets.port", "monitoring.targets.path"}}),
+			err:     fmt.Errorf("Invalid profile: invalid options: %w: %w: invalid monitoring: %w", InvalidConfError{message: "Option #1 is invalid", invalidFields: []string{`options.default -> (port "808O" is not a number)`}}, InvalidConfError{message: "Option #2 is invalid", missingFields: []string{"options.type", "options.help"}}, InvalidConfError{message: "Monitoring target #1 is invalid", missingFields: []string{"monitoring.targets.port", "monitoring.targets.path"}}),
 		},
 	}
 
@@ -421,6 +742,124 @@ func TestProfile(t *testing.T) {
 	}
 }
 
+func TestProfile_EnvDefaults(t *testing.T) {
+	afs := afero.NewOsFs()
+	testDir, err := afero.TempDir(afs, "", "test")
+	require.NoError(t, err)
+	testdata.SetupDir(t, "packages", testDir, afs)
+	pkgHandler := NewPackageHandler(filepath.Join(testDir, "packages", "env-defaults"))
+
+	t.Run("defined variable", func(t *testing.T) {
+		t.Setenv("EGN_TEST_HOME", "/home/egn")
+
+		profile, err := pkgHandler.Profile("ok")
+		require.NoError(t, err)
+		require.Len(t, profile.Options, 2)
+		assert.Equal(t, "/home/egn/data", profile.Options[0].Default)
+		assert.Equal(t, "8080", profile.Options[1].Default)
+	})
+	t.Run("undefined variable", func(t *testing.T) {
+		// Register the cleanup restoring the variable before unsetting it
+		t.Setenv("EGN_TEST_HOME", "")
+		require.NoError(t, os.Unsetenv("EGN_TEST_HOME"))
+
+		_, err := pkgHandler.Profile("ok")
+		assert.ErrorIs(t, err, ErrUndefinedEnvVar)
+		assert.ErrorContains(t, err, "option data-dir: undefined environment variable: EGN_TEST_HOME")
+	})
+	t.Run("undefined variable in another profile", func(t *testing.T) {
+		t.Setenv("EGN_TEST_HOME", "/home/egn")
+
+		// The profile is only expanded when it is selected
+		profiles, err := pkgHandler.Profiles()
+		require.NoError(t, err)
+		require.Len(t, profiles, 2)
+		assert.Equal(t, "/home/egn/data", profiles[0].Options[0].Default)
+		assert.Equal(t, "${EGN_TEST_OTHER_HOME}/data", profiles[1].Options[0].Default)
+
+		_, err = pkgHandler.Profile("other")
+		assert.ErrorIs(t, err, ErrUndefinedEnvVar)
+	})
+}
+
+func TestProfileDashboards(t *testing.T) {
+	afs := afero.NewOsFs()
+	testDir, err := afero.TempDir(afs, "", "test")
+	require.NoError(t, err)
+	testdata.SetupDir(t, "packages", testDir, afs)
+	pkgHandler := NewPackageHandler(filepath.Join(testDir, "packages", "profile-dashboards"))
+
+	tests := []struct {
+		name    string
+		profile string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "mainnet",
+			profile: "mainnet",
+			want:    []string{"mainnet.json", "overview.json"},
+		},
+		{
+			name:    "testnet",
+			profile: "testnet",
+			want:    []string{"overview.json", "testnet.json"},
+		},
+		{
+			name:    "unknown profile",
+			profile: "devnet",
+			wantErr: ErrProfileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dashboards, err := pkgHandler.ProfileDashboards(tt.profile)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(dashboards))
+			for name, data := range dashboards {
+				names = append(names, name)
+				assert.NotEmpty(t, data)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("EGN_TEST_HOME", "/home/egn")
+	t.Setenv("EGN_TEST_EMPTY", "")
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		err   string
+	}{
+		{name: "no variables", value: "8080", want: "8080"},
+		{name: "braces", value: "${EGN_TEST_HOME}/data", want: "/home/egn/data"},
+		{name: "no braces", value: "$EGN_TEST_HOME/data", want: "/home/egn/data"},
+		{name: "defined but empty", value: "${EGN_TEST_EMPTY}data", want: "data"},
+		{name: "undefined", value: "${EGN_TEST_UNDEFINED}/data", err: "undefined environment variable: EGN_TEST_UNDEFINED"},
+		{name: "several undefined", value: "$EGN_TEST_A:${EGN_TEST_HOME}:$EGN_TEST_B", err: "undefined environment variable: EGN_TEST_A, EGN_TEST_B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnv(tt.value)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, ErrUndefinedEnvVar)
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestDotEnv(t *testing.T) {
 	afs := afero.NewOsFs()
 	testDir, err := afero.TempDir(afs, "", "test")
@@ -456,6 +895,7 @@ func TestDotEnv(t *testing.T) {
 			profile: "invalid-yml",
 			want: map[string]string{
 				"$$$FACADE": "trueAvocado666%!",
+				"NFT":       "Token=Asset",
 			},
 		},
 		{
@@ -552,6 +992,52 @@ func TestVersions(t *testing.T) {
 	}
 }
 
+func TestRemoteVersions(t *testing.T) {
+	ts := []struct {
+		name     string
+		gitTags  []string
+		versions []string
+		err      error
+	}{
+		{
+			name:     "sorted versions",
+			gitTags:  []string{"v4.3.0", "v0.2.0", "some-tag", "v0.0.0"},
+			versions: []string{"v0.0.0", "v0.2.0", "v4.3.0"},
+		},
+		{
+			name:    "no versions",
+			gitTags: []string{"0.0", "some-tag"},
+			err:     ErrNoVersionsFound,
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.name, func(t *testing.T) {
+			path := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(path, "readme.txt"), []byte("Test file for test "+tc.name), 0o644))
+			for _, cmd := range []*exec.Cmd{
+				exec.Command("git", "-C", path, "init"),
+				exec.Command("git", "-C", path, "add", "readme.txt"),
+				exec.Command("git", "-C", path, "config", "user.name", "user"),
+				exec.Command("git", "-C", path, "config", "user.email", "user@email.com"),
+				exec.Command("git", "-C", path, "commit", "-m", "Initial commit"),
+			} {
+				require.NoError(t, cmd.Run())
+			}
+			for _, tag := range tc.gitTags {
+				require.NoError(t, exec.Command("git", "-C", path, "tag", "-a", tag, "-m", "Version: "+tag).Run())
+			}
+
+			versions, err := RemoteVersions(NewPackageHandlerOptions{URL: path})
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.versions, versions)
+		})
+	}
+}
+
 func TestLatestVersion(t *testing.T) {
 	type testCase struct {
 		name          string
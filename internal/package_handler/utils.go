@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/afero"
@@ -66,27 +67,40 @@ func hashFile(path string, afs afero.Fs) (hash string, err error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-func packageHashes(pkgPath string, afs afero.Fs) (map[string]string, error) {
-	hashes := make(map[string]string, 0)
-
+// packageHashes computes the checksums of the files in the pkg directory of the
+// package at pkgPath, keyed by their path relative to pkgPath. If progress is
+// not nil, it is called after each file is hashed.
+func packageHashes(pkgPath string, afs afero.Fs, progress ChecksumProgress) (map[string]string, error) {
+	var files []string
 	err := afero.Walk(afs, filepath.Join(pkgPath, pkgDirName), func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		if !info.IsDir() {
-			h, err := hashFile(path, afs)
-			if err != nil {
-				return err
-			}
-			relativePath := strings.TrimPrefix(path, pkgPath)
-			if relativePath[0] == filepath.Separator {
-				relativePath = relativePath[1:]
-			}
-			hashes[relativePath] = h
+			files = append(files, path)
 		}
 		return nil
 	})
-	return hashes, err
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]string, len(files))
+	for i, path := range files {
+		h, err := hashFile(path, afs)
+		if err != nil {
+			return nil, err
+		}
+		relativePath := strings.TrimPrefix(path, pkgPath)
+		if relativePath[0] == filepath.Separator {
+			relativePath = relativePath[1:]
+		}
+		hashes[relativePath] = h
+		if progress != nil {
+			progress(relativePath, i+1, len(files))
+		}
+	}
+	return hashes, nil
 }
 
 func parseChecksumFile(path string, afs afero.Fs) (map[string]string, error) {
@@ -114,3 +128,20 @@ func parseChecksumFile(path string, afs afero.Fs) (map[string]string, error) {
 
 	return checksums, nil
 }
+
+// writeChecksumFile writes the checksums to the file at the given path, one
+// "<hash>  <file>" line per file sorted by file path, in the format read by
+// parseChecksumFile.
+func writeChecksumFile(path string, checksums map[string]string, afs afero.Fs) error {
+	files := make([]string, 0, len(checksums))
+	for file := range checksums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var sb strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&sb, "%s  %s\n", checksums[file], file)
+	}
+	return afero.WriteFile(afs, path, []byte(sb.String()), 0o644)
+}
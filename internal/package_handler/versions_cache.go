@@ -0,0 +1,115 @@
+package package_handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultVersionsCacheMaxAge is the time the versions of a package repository
+// are cached when no other max age is configured.
+const DefaultVersionsCacheMaxAge = 6 * time.Hour
+
+// FailedVersionsCacheMaxAge is the time a failed listing of the versions of a
+// package repository is cached, so an unreachable repository is not queried on
+// every check but is retried sooner than a successful listing.
+const FailedVersionsCacheMaxAge = 15 * time.Minute
+
+// CachedVersions are the versions of a package repository stored in a
+// VersionsCache.
+type CachedVersions struct {
+	URL       string    `json:"url"`
+	Versions  []string  `json:"versions"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Error is the error of the listing of the versions, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// VersionsCache stores the versions listed from package repositories keyed by
+// repository URL, so the repositories are not queried every time the versions
+// are checked.
+type VersionsCache struct {
+	path   string
+	afs    afero.Fs
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewVersionsCache creates a new VersionsCache stored in the given path, with
+// entries that expire after maxAge. A zero maxAge never expires the entries.
+func NewVersionsCache(path string, afs afero.Fs, maxAge time.Duration) *VersionsCache {
+	return &VersionsCache{
+		path:   path,
+		afs:    afs,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+// Get returns the cached versions of the repository with the given URL. It
+// returns false if the versions are not cached or their entry expired.
+func (c *VersionsCache) Get(url string) (CachedVersions, bool, error) {
+	entryData, err := afero.ReadFile(c.afs, c.entryPath(url))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return CachedVersions{}, false, nil
+		}
+		return CachedVersions{}, false, err
+	}
+	var entry CachedVersions
+	if err := json.Unmarshal(entryData, &entry); err != nil {
+		// A corrupted entry is a cache miss, it is replaced on the next Put
+		return CachedVersions{}, false, nil
+	}
+	maxAge := c.maxAge
+	if entry.Error != "" && (maxAge == 0 || maxAge > FailedVersionsCacheMaxAge) {
+		maxAge = FailedVersionsCacheMaxAge
+	}
+	if maxAge > 0 && c.now().Sub(entry.CheckedAt) > maxAge {
+		return CachedVersions{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// Put stores the versions of the repository with the given URL, checked now,
+// replacing any previous entry.
+func (c *VersionsCache) Put(url string, versions []string) (CachedVersions, error) {
+	return c.put(CachedVersions{
+		URL:       url,
+		Versions:  versions,
+		CheckedAt: c.now(),
+	})
+}
+
+// PutError stores the error of the listing of the versions of the repository
+// with the given URL, checked now, replacing any previous entry. The entry
+// expires after FailedVersionsCacheMaxAge at most.
+func (c *VersionsCache) PutError(url string, listErr error) (CachedVersions, error) {
+	return c.put(CachedVersions{
+		URL:       url,
+		CheckedAt: c.now(),
+		Error:     listErr.Error(),
+	})
+}
+
+func (c *VersionsCache) put(entry CachedVersions) (CachedVersions, error) {
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return CachedVersions{}, err
+	}
+	if err := c.afs.MkdirAll(c.path, 0o755); err != nil {
+		return CachedVersions{}, err
+	}
+	return entry, afero.WriteFile(c.afs, c.entryPath(entry.URL), entryData, 0o644)
+}
+
+func (c *VersionsCache) entryPath(url string) string {
+	key := sha256.Sum256([]byte(url))
+	return filepath.Join(c.path, hex.EncodeToString(key[:])+".json")
+}
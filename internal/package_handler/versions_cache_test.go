@@ -0,0 +1,90 @@
+package package_handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionsCache(t *testing.T) {
+	const url = "https://github.com/NethermindEth/mock-avs-pkg"
+	afs := afero.NewMemMapFs()
+	now := time.Date(2023, 10, 4, 12, 0, 0, 0, time.UTC)
+	cache := NewVersionsCache("/cache/versions", afs, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	_, ok, err := cache.Get(url)
+	require.NoError(t, err)
+	assert.False(t, ok, "empty cache should miss")
+
+	_, err = cache.Put(url, []string{"v0.1.0", "v0.2.0"})
+	require.NoError(t, err)
+
+	_, ok, err = cache.Get("https://github.com/NethermindEth/other-pkg")
+	require.NoError(t, err)
+	assert.False(t, ok, "other URL should miss")
+
+	now = now.Add(30 * time.Minute)
+	entry, ok, err := cache.Get(url)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, CachedVersions{
+		URL:       url,
+		Versions:  []string{"v0.1.0", "v0.2.0"},
+		CheckedAt: now.Add(-30 * time.Minute),
+	}, entry)
+
+	now = now.Add(time.Hour)
+	_, ok, err = cache.Get(url)
+	require.NoError(t, err)
+	assert.False(t, ok, "expired entry should miss")
+}
+
+func TestVersionsCache_Corrupted(t *testing.T) {
+	const url = "https://github.com/NethermindEth/mock-avs-pkg"
+	afs := afero.NewMemMapFs()
+	cache := NewVersionsCache("/cache/versions", afs, 0)
+	require.NoError(t, afero.WriteFile(afs, cache.entryPath(url), []byte("{"), 0o644))
+
+	_, ok, err := cache.Get(url)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = cache.Put(url, []string{"v0.1.0"})
+	require.NoError(t, err)
+	entry, ok, err := cache.Get(url)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"v0.1.0"}, entry.Versions)
+}
+
+func TestVersionsCache_Error(t *testing.T) {
+	const url = "https://github.com/NethermindEth/mock-avs-pkg"
+	afs := afero.NewMemMapFs()
+	now := time.Date(2023, 10, 4, 12, 0, 0, 0, time.UTC)
+	cache := NewVersionsCache("/cache/versions", afs, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.PutError(url, errors.New("connection refused"))
+	require.NoError(t, err)
+
+	now = now.Add(10 * time.Minute)
+	entry, ok, err := cache.Get(url)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, CachedVersions{
+		URL:       url,
+		CheckedAt: now.Add(-10 * time.Minute),
+		Error:     "connection refused",
+	}, entry)
+
+	// Failures expire before the successful listings
+	now = now.Add(10 * time.Minute)
+	_, ok, err = cache.Get(url)
+	require.NoError(t, err)
+	assert.False(t, ok, "expired failure should miss")
+}
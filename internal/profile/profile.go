@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/utils"
 )
@@ -43,7 +44,12 @@ func (p *Profile) Validate() error {
 
 	invalidMonitoringErr := p.Monitoring.validate()
 
-	if len(missingFields) > 0 || invalidOptions || invalidMonitoringErr != nil {
+	var invalidAPIErr error
+	if p.API != nil {
+		invalidAPIErr = p.API.validate()
+	}
+
+	if len(missingFields) > 0 || invalidOptions || invalidMonitoringErr != nil || invalidAPIErr != nil {
 		var err error = InvalidProfileError{
 			message:       "Invalid profile",
 			missingFields: missingFields,
@@ -54,6 +60,9 @@ func (p *Profile) Validate() error {
 		if invalidMonitoringErr != nil {
 			err = fmt.Errorf("%w: %w", err, invalidMonitoringErr)
 		}
+		if invalidAPIErr != nil {
+			err = fmt.Errorf("%w: %w", err, invalidAPIErr)
+		}
 		return err
 	}
 
@@ -105,6 +114,7 @@ func (o *Option) validate(idx int) error {
 	}
 
 	var invalidDefault bool
+	var invalidDefaultReason string
 	if o.Default != "" {
 		switch o.Type {
 		case "str":
@@ -123,8 +133,9 @@ func (o *Option) validate(idx int) error {
 				}
 			}
 		case "port":
-			port, err := strconv.Atoi(o.Default)
-			invalidDefault = err != nil || port <= 0 || port > math.MaxUint16
+			minPort, maxPort := o.PortRange()
+			invalidDefaultReason = checkPort(o.Default, minPort, maxPort)
+			invalidDefault = invalidDefaultReason != ""
 		case "float":
 			val, err := strconv.ParseFloat(o.Default, 64)
 			invalidDefault = err != nil
@@ -163,7 +174,9 @@ func (o *Option) validate(idx int) error {
 			invalidDefault = true
 		}
 	}
-	if invalidDefault {
+	if invalidDefaultReason != "" {
+		invalidFields = append(invalidFields, "options.default -> ("+invalidDefaultReason+")")
+	} else if invalidDefault {
 		invalidFields = append(invalidFields, "options.default")
 	}
 
@@ -178,6 +191,41 @@ func (o *Option) validate(idx int) error {
 	return nil
 }
 
+// PortRange returns the range of the valid values of a port option. It is 1 to
+// 65535, unless it is narrowed with the min_value and max_value of the option
+// validate field, e.g. a min_value of 1024 to only allow non-privileged ports.
+func (o *Option) PortRange() (minPort, maxPort int) {
+	minPort, maxPort = 1, math.MaxUint16
+	if o.ValidateDef != nil {
+		if o.ValidateDef.MinValue != nil && int(*o.ValidateDef.MinValue) > minPort {
+			minPort = int(*o.ValidateDef.MinValue)
+		}
+		if o.ValidateDef.MaxValue != nil && int(*o.ValidateDef.MaxValue) < maxPort {
+			maxPort = int(*o.ValidateDef.MaxValue)
+		}
+	}
+	return minPort, maxPort
+}
+
+// checkPort returns why the given value is not a port between minPort and
+// maxPort, or an empty string if it is.
+func checkPort(value string, minPort, maxPort int) string {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Sprintf("port %q is not a number", value)
+	}
+	return checkPortRange(port, minPort, maxPort)
+}
+
+// checkPortRange returns why the given port is not between minPort and
+// maxPort, or an empty string if it is.
+func checkPortRange(port, minPort, maxPort int) string {
+	if port < minPort || port > maxPort {
+		return fmt.Sprintf("port %d is out of range, must be between %d and %d", port, minPort, maxPort)
+	}
+	return ""
+}
+
 // Validate represents the validate field of an option
 type Validate struct {
 	Re2Regex  string   `yaml:"re2_regex"`
@@ -191,6 +239,9 @@ type Validate struct {
 // Monitoring represents the monitoring field of a profile
 type Monitoring struct {
 	Targets []MonitoringTarget `yaml:"targets"`
+	// Rules are paths, relative to the profile directory, of Prometheus
+	// alerting rule files shipped with the package.
+	Rules []string `yaml:"rules,omitempty"`
 }
 
 func (m *Monitoring) validate() error {
@@ -207,6 +258,12 @@ func (m *Monitoring) validate() error {
 			err = fmt.Errorf("%w: %w", err, valErr)
 		}
 	}
+	for i, rule := range m.Rules {
+		if !filepath.IsLocal(rule) {
+			ok = false
+			err = fmt.Errorf("%w: monitoring.rules[%d] must be a relative path inside the profile directory", err, i)
+		}
+	}
 	if !ok {
 		return err
 	}
@@ -218,6 +275,13 @@ type MonitoringTarget struct {
 	Service string `yaml:"service"`
 	Port    *int   `yaml:"port"`
 	Path    string `yaml:"path"`
+	Scheme  string `yaml:"scheme"`
+	// ScrapeTimeout is the Prometheus scrape timeout of the target, e.g. 10s.
+	// The Prometheus default is used if it is empty.
+	ScrapeTimeout string `yaml:"scrape_timeout"`
+	// HonorLabels keeps the labels exposed by the target, like instance, when
+	// they conflict with the labels added by the monitoring stack.
+	HonorLabels bool `yaml:"honor_labels"`
 }
 
 func (m *MonitoringTarget) validate(idx int) error {
@@ -231,8 +295,8 @@ func (m *MonitoringTarget) validate(idx int) error {
 
 	if m.Port == nil {
 		missingFields = append(missingFields, "monitoring.targets.port")
-	} else if *m.Port <= 0 || *m.Port > math.MaxUint16 {
-		invalidFields = append(invalidFields, "monitoring.targets.port")
+	} else if reason := checkPortRange(*m.Port, 1, math.MaxUint16); reason != "" {
+		invalidFields = append(invalidFields, "monitoring.targets.port -> ("+reason+")")
 	}
 
 	if m.Path == "" {
@@ -244,6 +308,16 @@ func (m *MonitoringTarget) validate(idx int) error {
 		}
 	}
 
+	if m.Scheme != "" && m.Scheme != "http" && m.Scheme != "https" {
+		invalidFields = append(invalidFields, "monitoring.targets.scheme")
+	}
+
+	if m.ScrapeTimeout != "" {
+		if d, err := time.ParseDuration(m.ScrapeTimeout); err != nil || d <= 0 {
+			invalidFields = append(invalidFields, "monitoring.targets.scrape_timeout")
+		}
+	}
+
 	if len(missingFields) > 0 || len(invalidFields) > 0 {
 		return InvalidProfileError{
 			message:       "Monitoring target #" + strconv.Itoa(idx+1) + " is invalid",
@@ -257,6 +331,24 @@ func (m *MonitoringTarget) validate(idx int) error {
 
 // API represents the api field of a profile
 type APITarget struct {
-	Service string `yaml:"service"`
-	Port    int    `yaml:"port"`
+	Service     string       `yaml:"service"`
+	Port        int          `yaml:"port"`
+	HealthCheck *HealthCheck `yaml:"health_check,omitempty"`
+}
+
+func (a *APITarget) validate() error {
+	if reason := checkPortRange(a.Port, 1, math.MaxUint16); reason != "" {
+		return InvalidProfileError{
+			message:       "API target is invalid",
+			invalidFields: []string{"api.port -> (" + reason + ")"},
+		}
+	}
+	return nil
+}
+
+// HealthCheck represents the health_check field of an api target. It declares
+// the HTTP path to probe and the status code expected from a healthy node.
+type HealthCheck struct {
+	Path   string `yaml:"path"`
+	Status int    `yaml:"status"`
 }
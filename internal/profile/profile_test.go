@@ -3,6 +3,7 @@ package profile
 import (
 	"errors"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -106,7 +107,7 @@ func TestOptionValidate(t *testing.T) {
 			filePath: "check-invalid-port/pkg/option.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"options.default"},
+				invalidFields: []string{"options.default -> (port \"8O8O\" is not a number)"},
 			},
 		},
 		{
@@ -114,7 +115,7 @@ func TestOptionValidate(t *testing.T) {
 			filePath: "check-negative-port/pkg/option.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"options.default"},
+				invalidFields: []string{"options.default -> (port -8080 is out of range, must be between 1 and 65535)"},
 			},
 		},
 		{
@@ -122,7 +123,7 @@ func TestOptionValidate(t *testing.T) {
 			filePath: "check-huge-port/pkg/option.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"options.default"},
+				invalidFields: []string{"options.default -> (port 165535 is out of range, must be between 1 and 65535)"},
 			},
 		},
 		{
@@ -130,7 +131,7 @@ func TestOptionValidate(t *testing.T) {
 			filePath: "check-zero-port/pkg/option.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"options.default"},
+				invalidFields: []string{"options.default -> (port 0 is out of range, must be between 1 and 65535)"},
 			},
 		},
 		{
@@ -138,7 +139,7 @@ func TestOptionValidate(t *testing.T) {
 			filePath: "check-decimal-port/pkg/option.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"options.default"},
+				invalidFields: []string{"options.default -> (port \"80.80\" is not a number)"},
 			},
 		},
 		{
@@ -296,6 +297,90 @@ func TestOptionValidate(t *testing.T) {
 	}
 }
 
+func TestOptionPortValidate(t *testing.T) {
+	nonPrivileged := float64(1024)
+	tests := []struct {
+		name     string
+		def      string
+		validate *Validate
+		want     string
+	}{
+		{
+			name: "valid port",
+			def:  "8080",
+		},
+		{
+			name: "zero",
+			def:  "0",
+			want: "options.default -> (port 0 is out of range, must be between 1 and 65535)",
+		},
+		{
+			name: "out of range",
+			def:  "70000",
+			want: "options.default -> (port 70000 is out of range, must be between 1 and 65535)",
+		},
+		{
+			name: "not a number",
+			def:  "abc",
+			want: `options.default -> (port "abc" is not a number)`,
+		},
+		{
+			name:     "non-privileged port",
+			def:      "8080",
+			validate: &Validate{MinValue: &nonPrivileged},
+		},
+		{
+			name:     "privileged port",
+			def:      "80",
+			validate: &Validate{MinValue: &nonPrivileged},
+			want:     "options.default -> (port 80 is out of range, must be between 1024 and 65535)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option := Option{
+				Name:        "main-port",
+				Target:      "MAIN_PORT",
+				Type:        "port",
+				Default:     tt.def,
+				Help:        "Main service server port",
+				ValidateDef: tt.validate,
+			}
+			err := option.validate(0)
+			if tt.want == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, InvalidProfileError{
+					message:       "Option #1 is invalid",
+					invalidFields: []string{tt.want},
+				}, err)
+			}
+		})
+	}
+}
+
+func TestAPITargetValidate(t *testing.T) {
+	tests := []struct {
+		port int
+		want string
+	}{
+		{port: 8080},
+		{port: 0, want: "API target is invalid -> invalid fields: api.port -> (port 0 is out of range, must be between 1 and 65535)"},
+		{port: 70000, want: "API target is invalid -> invalid fields: api.port -> (port 70000 is out of range, must be between 1 and 65535)"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.port), func(t *testing.T) {
+			api := APITarget{Service: "main-service", Port: tt.port}
+			err := api.validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want)
+			}
+		})
+	}
+}
+
 func TestMonitoringTargetValidate(t *testing.T) {
 	afs := afero.NewMemMapFs()
 	testDir, err := afero.TempDir(afs, "", "test")
@@ -325,7 +410,7 @@ func TestMonitoringTargetValidate(t *testing.T) {
 			filePath: "invalid-port/pkg/target.yml",
 			want: InvalidProfileError{
 				message:       message,
-				invalidFields: []string{"monitoring.targets.port"},
+				invalidFields: []string{"monitoring.targets.port -> (port 9999999 is out of range, must be between 1 and 65535)"},
 			},
 		},
 		{
@@ -377,6 +462,30 @@ func TestMonitoringTargetValidate(t *testing.T) {
 			name:     "Multiple Targets Monitoring Target",
 			filePath: "multiple-targets/pkg/target.yml",
 		},
+		{
+			name:     "HTTPS Scheme Monitoring Target",
+			filePath: "https-scheme/pkg/target.yml",
+		},
+		{
+			name:     "Invalid Scheme Monitoring Target",
+			filePath: "invalid-scheme/pkg/target.yml",
+			want: InvalidProfileError{
+				message:       message,
+				invalidFields: []string{"monitoring.targets.scheme"},
+			},
+		},
+		{
+			name:     "Scrape Options Monitoring Target",
+			filePath: "scrape-options/pkg/target.yml",
+		},
+		{
+			name:     "Invalid Scrape Timeout Monitoring Target",
+			filePath: "invalid-scrape-timeout/pkg/target.yml",
+			want: InvalidProfileError{
+				message:       message,
+				invalidFields: []string{"monitoring.targets.scrape_timeout"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
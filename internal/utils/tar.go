@@ -6,12 +6,246 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
+var (
+	// ErrUnsafeTarPath is returned when a tar entry would be written with a
+	// path that is absolute or escapes the root of the tar.
+	ErrUnsafeTarPath = errors.New("unsafe tar path")
+	// ErrTarNotAppendable is returned when entries are appended to a file that
+	// does not end like a tar.
+	ErrTarNotAppendable = errors.New("tar file can't be appended to")
+)
+
+// tarEndSize is the size of the end-of-archive marker of a tar, two zero
+// blocks of 512 bytes.
+const tarEndSize = 2 * 512
+
+// TarWrite writes the content of the root directory of afs to w as a plain
+// tar, with the paths relative to root. See TarWriteDir for the details and
+// skip.
+func TarWrite(w io.Writer, root string, afs afero.Fs, skip func(name string, info fs.FileInfo) bool) error {
+	tw := tar.NewWriter(w)
+	if err := TarWriteDir(tw, afs, root, "", skip, nil); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// TarWriteFile writes a regular file with the given name, content and
+// modification time to the tar writer. The name must be a relative path inside
+// the tar, otherwise an ErrUnsafeTarPath error is returned.
+func TarWriteFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	name, err := tarPath(name)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// TarAppend appends entries to the plain tar file at the given path of afs,
+// like the backups the volumes snapshotter writes to. The end-of-archive
+// marker of the tar, its last two zero blocks, is replaced with the entries
+// written by fn and a new marker. An ErrTarNotAppendable error is returned if
+// the file does not end with the marker.
+func TarAppend(afs afero.Fs, path string, fn func(tw *tar.Writer) error) (err error) {
+	f, err := afs.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	end := info.Size() - tarEndSize
+	if end < 0 {
+		return fmt.Errorf("%w: %s is smaller than the end-of-archive marker", ErrTarNotAppendable, path)
+	}
+	marker := make([]byte, tarEndSize)
+	if _, err := f.ReadAt(marker, end); err != nil {
+		return err
+	}
+	for _, b := range marker {
+		if b != 0 {
+			return fmt.Errorf("%w: %s does not end with the end-of-archive marker", ErrTarNotAppendable, path)
+		}
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		return err
+	}
+	tw := tar.NewWriter(f)
+	if err := fn(tw); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// TarWriteDir writes the root directory of fs and its content to the tar
+// writer, one file at a time, under the prefix path. The root directory
+// itself is only written if the prefix is not empty, and the prefix must be a
+// relative path inside the tar. If skip is not nil, the files and directories
+// for which it returns true, given their path relative to root with forward
+// slashes, are left out, with the content of the directories. If onFile is not
+// nil, it is called with the name and the content of each regular file, and it
+// must read the content to the end for it to be written to the tar, e.g. while
+// checksumming it.
+//
+// The directory can be written while it is archived, as the data directory of
+// a running instance is. Files removed before they are archived are skipped,
+// and a file always takes the size it had when it was opened: data appended
+// later is left out, and a truncated file is padded with zeros. The tar is
+// then always readable, but the files written during the archive may not be
+// consistent with each other.
+func TarWriteDir(tw *tar.Writer, afs afero.Fs, root, prefix string, skip func(name string, info fs.FileInfo) bool, onFile func(name string, content io.Reader) error) error {
+	if prefix != "" {
+		var err error
+		if prefix, err = tarPath(prefix); err != nil {
+			return err
+		}
+	}
+	return afero.Walk(afs, root, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			if filePath != root && errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		if relPath == "." && prefix == "" {
+			return nil
+		}
+		if relPath != "." && skip != nil && skip(filepath.ToSlash(relPath), info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			reader, ok := afs.(afero.LinkReader)
+			if !ok {
+				return nil
+			}
+			if link, err = reader.ReadlinkIfPossible(filePath); err != nil {
+				return err
+			}
+		}
+		var f afero.File
+		if info.Mode().IsRegular() {
+			f, err = afs.Open(filePath)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			defer f.Close()
+			// The size of the open file is the closest to the archived content
+			if info, err = f.Stat(); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(relPath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if f == nil {
+			return nil
+		}
+		content := io.LimitReader(io.MultiReader(f, zeroReader{}), header.Size)
+		if onFile == nil {
+			_, err = io.Copy(tw, content)
+			return err
+		}
+		return onFile(header.Name, io.TeeReader(content, tw))
+	})
+}
+
+// TarReadFile returns the content of the file with the given name from the
+// plain tar read from r. The names are compared with TarMemberName, so the
+// files of tars created on Windows are found too. An error wrapping
+// os.ErrNotExist is returned if the tar does not have the file.
+func TarReadFile(r io.Reader, name string) ([]byte, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: %s not found in tar", os.ErrNotExist, name)
+			}
+			return nil, err
+		}
+		if TarMemberName(header.Name) == TarMemberName(name) {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// TarMemberName returns the name of a tar member in its canonical form, with
+// forward slashes and cleaned, like data/state.json for data\state.json or
+// ./data/state.json. Tars created on Windows may separate the path elements
+// with backslashes, so member names are compared in this form.
+func TarMemberName(name string) string {
+	cleaned := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if cleaned == "." {
+		return ""
+	}
+	return cleaned
+}
+
+// tarPath returns the given path cleaned and with forward slashes, as paths
+// are stored in tar files. An ErrUnsafeTarPath error is returned if the path is
+// absolute or escapes the root of the tar.
+func tarPath(name string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(name))
+	if name == "" || cleaned == "." || path.IsAbs(cleaned) || filepath.IsAbs(name) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeTarPath, name)
+	}
+	return cleaned, nil
+}
+
+// zeroReader is an endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
 func CompressToTarGz(srcDir string, tarFile io.Writer) error {
 	gw := gzip.NewWriter(tarFile)
 	defer gw.Close()
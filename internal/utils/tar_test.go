@@ -1,12 +1,18 @@
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/common"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -59,6 +65,230 @@ func TestDecompressTarGz(t *testing.T) {
 	assertEqualDirs(t, pkgDir, outDir)
 }
 
+func TestTarWriteRoundTrip(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	files := map[string]string{
+		"state.json":           `{"name": "mock-avs"}`,
+		".env":                 "MAIN_PORT=8080\n",
+		"src/main.py":          "print('hello')\n",
+		"src/config/empty.yml": "",
+	}
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(afs, filepath.Join("/instance", name), []byte(content), 0o644))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, TarWrite(&buf, "/instance", afs, nil))
+
+	for name, content := range files {
+		got, err := TarReadFile(bytes.NewReader(buf.Bytes()), name)
+		require.NoError(t, err, name)
+		assert.Equal(t, content, string(got), name)
+	}
+	_, err := TarReadFile(bytes.NewReader(buf.Bytes()), "missing.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// The paths are relative to the root, without the root itself
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.ElementsMatch(t, []string{".env", "src/", "src/config/", "src/config/empty.yml", "src/main.py", "state.json"}, names)
+}
+
+func TestTarWriteSkip(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	for _, name := range []string{".lock", ".env", "cache/data.bin", "src/main.py"} {
+		require.NoError(t, afero.WriteFile(afs, filepath.Join("/instance", name), []byte("content"), 0o644))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, TarWrite(&buf, "/instance", afs, func(name string, info fs.FileInfo) bool {
+		return name == ".lock" || name == "cache"
+	}))
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.ElementsMatch(t, []string{".env", "src/", "src/main.py"}, names)
+}
+
+func TestTarAppend(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	// An empty tar is only the end-of-archive marker
+	require.NoError(t, afero.WriteFile(afs, "/backup.tar", make([]byte, 1024), 0o644))
+
+	modTime := time.Unix(1696367916, 0)
+	require.NoError(t, TarAppend(afs, "/backup.tar", func(tw *tar.Writer) error {
+		return TarWriteFile(tw, "data/state.json", []byte("{}"), modTime)
+	}))
+	require.NoError(t, TarAppend(afs, "/backup.tar", func(tw *tar.Writer) error {
+		return TarWriteFile(tw, "timestamp", []byte("1696367916"), modTime)
+	}))
+
+	f, err := afs.Open("/backup.tar")
+	require.NoError(t, err)
+	defer f.Close()
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"data/state.json", "timestamp"}, names)
+
+	// Files that don't end like a tar are not changed
+	require.NoError(t, afero.WriteFile(afs, "/short.tar", make([]byte, 512), 0o644))
+	require.NoError(t, afero.WriteFile(afs, "/garbage.tar", bytes.Repeat([]byte("x"), 2048), 0o644))
+	for _, path := range []string{"/short.tar", "/garbage.tar"} {
+		err := TarAppend(afs, path, func(tw *tar.Writer) error {
+			t.Error("entries written to", path)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrTarNotAppendable, path)
+	}
+	assert.ErrorIs(t, TarAppend(afs, "/missing.tar", func(*tar.Writer) error { return nil }), os.ErrNotExist)
+}
+
+func TestTarWriteDir(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/instance/state.json", []byte("{}"), 0o644))
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	var seen []string
+	err := TarWriteDir(tw, afs, "/instance", "./data/", nil, func(name string, content io.Reader) error {
+		seen = append(seen, name)
+		_, err := io.Copy(io.Discard, content)
+		return err
+	})
+	require.NoError(t, err)
+	modTime := time.Unix(1696367916, 0)
+	require.NoError(t, TarWriteFile(tw, "timestamp", []byte("1696367916"), modTime))
+	require.NoError(t, tw.Close())
+
+	assert.Equal(t, []string{"data/state.json"}, seen)
+	got, err := TarReadFile(bytes.NewReader(buf.Bytes()), "data/state.json")
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(got))
+	got, err = TarReadFile(bytes.NewReader(buf.Bytes()), "timestamp")
+	require.NoError(t, err)
+	assert.Equal(t, "1696367916", string(got))
+	// The files get the given modification time
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		header, err := tr.Next()
+		require.NoError(t, err)
+		if header.Name == "timestamp" {
+			assert.True(t, modTime.Equal(header.ModTime))
+			break
+		}
+	}
+
+	err = TarWriteDir(tw, afs, "/instance", "../data", nil, nil)
+	assert.ErrorIs(t, err, ErrUnsafeTarPath)
+}
+
+func TestTarWriteFileUnsafePath(t *testing.T) {
+	ts := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{name: "data/state.json", want: "data/state.json"},
+		{name: "./data//state.json", want: "data/state.json"},
+		{name: "data/../timestamp", want: "timestamp"},
+		{name: "", err: ErrUnsafeTarPath},
+		{name: ".", err: ErrUnsafeTarPath},
+		{name: "/etc/passwd", err: ErrUnsafeTarPath},
+		{name: "..", err: ErrUnsafeTarPath},
+		{name: "../state.json", err: ErrUnsafeTarPath},
+		{name: "data/../../state.json", err: ErrUnsafeTarPath},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			err := TarWriteFile(tw, tt.name, []byte("content"), time.Now())
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, tw.Close())
+			got, err := TarReadFile(&buf, tt.want)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(got))
+		})
+	}
+}
+
+func TestTarReadFileWindowsNames(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{`data\state.json`, `.\timestamp`} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(name))}))
+		_, err := tw.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	ts := []struct {
+		name string
+		want string
+	}{
+		{name: "data/state.json", want: `data\state.json`},
+		{name: `data\state.json`, want: `data\state.json`},
+		{name: "./data/state.json", want: `data\state.json`},
+		{name: "timestamp", want: `.\timestamp`},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TarReadFile(bytes.NewReader(buf.Bytes()), tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+	_, err := TarReadFile(bytes.NewReader(buf.Bytes()), "state.json")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTarMemberName(t *testing.T) {
+	ts := []struct {
+		name string
+		want string
+	}{
+		{name: "data/state.json", want: "data/state.json"},
+		{name: `data\state.json`, want: "data/state.json"},
+		{name: `.\data\config\.env`, want: "data/config/.env"},
+		{name: "./data//state.json", want: "data/state.json"},
+		{name: `data\`, want: "data"},
+		{name: "./", want: ""},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TarMemberName(tt.name))
+		})
+	}
+}
+
 func assertEqualDirs(t *testing.T, dir1, dir2 string) {
 	err := filepath.Walk(dir1, func(path1 string, info1 os.FileInfo, err1 error) error {
 		if err1 != nil {
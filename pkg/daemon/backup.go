@@ -1,7 +1,18 @@
 package daemon
 
+import "github.com/NethermindEth/eigenlayer/internal/data"
+
 type BackupManager interface {
-	// BackupInstance creates a backup of the instance with the given ID.
-	BackupInstance(instanceId string) (string, error)
-	RestoreInstance(backupId string) error
+	// BackupInstance creates a backup of the instance with the given ID,
+	// compressed with the given codec and encrypted with the key of the given
+	// provider if it is not nil.
+	BackupInstance(instanceId string, compression data.Compression, keys data.KeyProvider) (string, error)
+	// RestoreInstance restores the backup with the given ID, decrypting it
+	// with the key of the given provider if it is encrypted. The containers
+	// are created with the docker client config in configDir, if it is not
+	// empty.
+	RestoreInstance(backupId string, keys data.KeyProvider, configDir string) error
+	// RestoreInstanceAs restores the backup with the given ID into the
+	// instance with the given ID instead of the backed up one.
+	RestoreInstanceAs(backupId, instanceId string, keys data.KeyProvider, configDir string) error
 }
@@ -1,16 +1,26 @@
 package daemon
 
 import (
+	"context"
+
 	"github.com/NethermindEth/eigenlayer/internal/compose"
 )
 
 // ComposeManager is an interface that defines methods for managing Docker Compose operations.
 type ComposeManager interface {
 	// Up starts the Docker Compose services defined in the Docker Compose file specified in the options.
-	Up(opts compose.DockerComposeUpOptions) error
+	// It is aborted if the context is done before the services are started.
+	Up(ctx context.Context, opts compose.DockerComposeUpOptions) error
 
 	// Stop stops the Docker Compose services defined in the Docker Compose file.
-	Stop(opts compose.DockerComposeStopOptions) error
+	// It is aborted if the context is done before the services are stopped.
+	Stop(ctx context.Context, opts compose.DockerComposeStopOptions) error
+
+	// Pause suspends the processes of the running Docker Compose services.
+	Pause(opts compose.DockerComposePauseOptions) error
+
+	// Unpause resumes the processes of the paused Docker Compose services.
+	Unpause(opts compose.DockerComposeUnpauseOptions) error
 
 	// Down stops and removes the Docker Compose services defined in the Docker Compose file specified in the options.
 	Down(opts compose.DockerComposeDownOptions) error
@@ -18,6 +28,9 @@ type ComposeManager interface {
 	// PS runs the Docker Compose 'ps' command for the specified options and returns the list of services.
 	PS(opts compose.DockerComposePsOptions) ([]compose.ComposeService, error)
 
+	// Version returns the version of the docker compose plugin.
+	Version() (string, error)
+
 	// Create creates the Docker Compose services defined in the Docker Compose file specified in the options, but does not start them.
 	Create(opts compose.DockerComposeCreateOptions) error
 }
@@ -5,54 +5,112 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
 )
 
 // Daemon is the interface for the egn daemon. It should be used as the entrypoint
-// for all the functionalities of egn.
+// for all the functionalities of egn, by the CLI and by Go programs that embed
+// egn. Use New to create it, and see the package errors to handle its failures.
 type Daemon interface {
 	// Pull downloads a node software package from the given URL and returns the
-	// version and options of each profile in the package. If force is true and
-	// the package already exists, it will be removed and re-downloaded. After
-	// calling Pull all is ready to call Install.
-	Pull(url string, ref PullTarget, force bool) (PullResult, error)
+	// version and options of each profile in the package. If a version or commit
+	// is given and the package was pulled before for it, the cached copy is used
+	// instead of downloading it again. If force is true, the package is
+	// downloaded again without using the cache. After calling Pull all is ready
+	// to call Install. Cancelling ctx aborts the
+	// download and its retries.
+	Pull(ctx context.Context, url string, ref PullTarget, force bool) (PullResult, error)
 
 	// PullUpdate downloads a node software package from the given URL and returns
-	// the result of merging both packages configs.
-	PullUpdate(instanceID string, ref PullTarget) (PullUpdateResult, error)
+	// the result of merging both packages configs. Like Pull, a package pulled
+	// before for the same version or commit is copied from the cache.
+	// Cancelling ctx aborts the download and its retries. Like Install, it
+	// returns ErrOperationInProgress if another operation on the instances does
+	// not finish in time.
+	PullUpdate(ctx context.Context, instanceID string, ref PullTarget) (PullUpdateResult, error)
 
 	// LocalPullUpdate loads a node software package from a local tarball and
-	// returns the result of merging both packages configs.
+	// returns the result of merging both packages configs. Like PullUpdate, it
+	// returns ErrOperationInProgress if another operation on the instances does
+	// not finish in time.
 	LocalPullUpdate(instanceID string, pkgTar io.Reader) (PullUpdateResult, error)
 
 	// Install downloads and installs a node software package using the provided options,
-	// and returns the instance ID of the installed package. Make sure to call Pull
-	// before calling Install to ensure that the package is downloaded.
-	Install(options InstallOptions) (string, error)
+	// and returns a summary of the installed instance. Make sure to call Pull
+	// before calling Install to ensure that the package is downloaded. If an
+	// instance with the same ID is already installed, ErrInstanceExists
+	// is returned unless options.Force is set. ErrOperationInProgress is
+	// returned if another process installing or uninstalling an instance does
+	// not finish in time.
+	Install(options InstallOptions) (InstallResult, error)
 
 	// HasInstance returns true if there is an installed instance with the given ID.
 	HasInstance(instanceId string) bool
 
+	// InstanceVersion returns the package version and commit of the installed
+	// instance with the given ID. If there is no installed instance with the
+	// given ID an error will be returned.
+	InstanceVersion(instanceId string) (InstanceVersionInfo, error)
+
 	// Run starts the instance with the given ID running docker compose in the
 	// instance directory. If there is no installed instance with the given ID,
-	// an error will be returned.
-	Run(instanceId string) error
+	// an error will be returned. If options.Wait is true, it also waits until
+	// the instance's API health endpoint reports a healthy node, returning
+	// ErrHealthCheckTimeout if that does not happen within options.Timeout.
+	// If options.Timeout is set and the instance is not started within it,
+	// the containers started so far are removed and ErrRunTimeout is returned.
+	// If ctx is done before the instance is started, the returned error wraps
+	// the context error.
+	Run(ctx context.Context, instanceId string, options RunOptions) error
 
 	// Stop stops the instance with the given ID. If there is no installed instance
-	// with the given ID an error will be returned.
-	Stop(instanceId string) error
+	// with the given ID an error will be returned. If ctx is done before the
+	// instance is stopped, the returned error wraps the context error.
+	Stop(ctx context.Context, instanceId string) error
 
 	// Uninstall stops and removes the instance with the given ID. If there is no
-	// installed instance with the given ID an error will be returned.
+	// installed instance with the given ID nothing is done, so the leftovers of
+	// an incomplete installation can be uninstalled. Like Install, it returns
+	// ErrOperationInProgress if another process installing or uninstalling an
+	// instance does not finish in time.
 	Uninstall(instanceId string) error
 
 	// InitMonitoring initializes the MonitoringStack. If install is true, the
 	// MonitoringStack will be installed if it is not already installed. If run
 	// is true, the MonitoringStack will be run if it is not already running.
-	InitMonitoring(install, run bool) error
+	// The installation is aborted if the context is done before it finishes.
+	InitMonitoring(ctx context.Context, install, run bool) error
 
 	// CleanMonitoring stops and uninstalls the MonitoringStack
 	CleanMonitoring() error
 
+	// ReconcileMonitoring removes the monitoring targets of instances that are
+	// no longer installed. It does nothing if the MonitoringStack is not
+	// installed, and returns ErrMonitoringStackNotRunning if it is not running.
+	ReconcileMonitoring() error
+
+	// MonitoringStatus returns whether the containers of the monitoring
+	// services are running, their ports and the health of the targets scraped
+	// by Prometheus. The targets are only counted if the MonitoringStack was
+	// initialized by InitMonitoring. ErrMonitoringStackNotInstalled is returned
+	// if the MonitoringStack is not installed.
+	MonitoringStatus() (MonitoringStatus, error)
+
+	// ExportMonitoring writes the provisioning of the MonitoringStack, its
+	// configs, datasources, dashboards, rules and targets, to w as a tar
+	// bundle, to move or back up the monitoring setup alone.
+	// ErrMonitoringStackNotInstalled is returned if the MonitoringStack is not
+	// installed.
+	ExportMonitoring(w io.Writer) error
+
+	// ImportMonitoring replaces the provisioning of the MonitoringStack with
+	// the bundle written by ExportMonitoring read from r, installing the
+	// MonitoringStack if it is not installed. The MonitoringStack must not be
+	// running, otherwise ErrMonitoringStackRunning is returned. Run it with
+	// InitMonitoring to use the imported provisioning.
+	ImportMonitoring(r io.Reader) error
+
 	// RunPlugin runs a plugin with the given arguments on the instance with the
 	// given ID. If there is no installed and running instance with the given ID
 	// an error will be returned. If noDestroyImage is true, the plugin image will
@@ -60,9 +118,9 @@ type Daemon interface {
 	RunPlugin(instanceId string, pluginArgs []string, options RunPluginOptions) error
 
 	// CheckHardwareRequirements checks if the hardware of the system meets the
-	// specified requirements. It takes a HardwareRequirements struct as input and returns
-	// a boolean value indicating whether the hardware meets the requirements.
-	CheckHardwareRequirements(requirements HardwareRequirements) (bool, error)
+	// specified requirements. If it does not, an ErrInsufficientResources error
+	// describing each unmet requirement is returned.
+	CheckHardwareRequirements(requirements HardwareRequirements) error
 
 	// ListInstances returns a list of all the installed instances and their health.
 	ListInstances() ([]ListInstanceItem, error)
@@ -76,19 +134,135 @@ type Daemon interface {
 	// installed instance with the given ID an error will be returned.
 	NodeLogs(ctx context.Context, w io.Writer, instanceID string, opts NodeLogsOptions) error
 
+	// Doctor runs the diagnostic checks of the environment egn runs in and
+	// returns their results. A failed check is reported in its result.
+	Doctor() []DoctorCheck
+
+	// CheckRuntime checks that the docker daemon is reachable and that the
+	// docker compose plugin is installed with a supported version. An
+	// ErrRuntimeUnavailable error, with how to fix it, is returned otherwise.
+	CheckRuntime() error
+
+	// Prune removes the data directories without an instance state left by
+	// interrupted installs and uninstalls and, if options.Images is true, the
+	// dangling docker images. The directories of installed instances are never
+	// removed. If options.DryRun is true, nothing is removed and the result
+	// holds what would have been removed. Like Install, it returns
+	// ErrOperationInProgress if another operation on the instances does not
+	// finish in time.
+	Prune(options PruneOptions) (PruneResult, error)
+
+	// Exec runs the given command inside the container of a service of the
+	// instance with the given ID, which must be running. The primary service
+	// of the instance is used if the options do not set a service.
+	Exec(instanceId string, cmd []string, opts ExecOptions) error
+
+	// GetConfig returns the variables of the environment of the instance with
+	// the given ID, sorted by key. If there is no installed instance with the
+	// given ID an error will be returned.
+	GetConfig(instanceId string) ([]ConfigEntry, error)
+
+	// SetConfig sets the variable of the environment of the instance with the
+	// given ID to value. If the variable is the target of a profile option,
+	// the value is validated against the option. Variables that are neither in
+	// the environment nor targets of an option are rejected. The instance must
+	// be restarted to use the new value.
+	SetConfig(instanceId, key, value string) error
+
+	// Label sets the labels in set on the instance with the given ID,
+	// replacing the values of the keys it already has, removes the labels
+	// with the keys in remove, and returns the resulting labels. Labels are
+	// added to the metrics of the instance the next time it is started.
+	Label(instanceId string, set map[string]string, remove []string) (map[string]string, error)
+
 	// Backup creates a backup of the instance with the given ID and returns the
 	// backup ID. If there is no installed instance with the given ID an error
 	// will be returned.
-	Backup(instanceId string) (backupId string, err error)
+	Backup(instanceId string, options BackupOptions) (backupId string, err error)
+
+	// StreamBackup writes a gzip compressed backup of the instance data to w
+	// as it is created, without storing it in the data directory, and returns
+	// the backup information including its checksum. The backup does not hold
+	// the volumes of the instance services. Unlike Backup, the instance is not
+	// stopped, so the backup is taken while it runs unless options.Consistent
+	// is set. If there is no installed instance with the given ID an error
+	// will be returned.
+	StreamBackup(instanceId string, w io.Writer, options StreamBackupOptions) (*data.Backup, error)
 
 	// Restore restores the backup with the given ID. If the AVS instance id of
 	// the backup exists, then the command will uninstall it before restoring
 	// the backup. If the AVS instance does not exist, then the command will
-	// create it. If run is true, the instance will be run after the restore.
-	Restore(backupId string, run bool) error
+	// create it. If options.InstanceId is set, the backup is restored as a new
+	// instance with that ID instead, and an error is returned if an instance
+	// with that ID already exists. If options.Run is true, the instance will be
+	// run after the restore. Like Install, it returns ErrOperationInProgress if
+	// another operation on the instances does not finish in time.
+	Restore(backupId string, options RestoreOptions) error
 
 	// BackupList returns a list of all the backups and their information.
 	BackupList() ([]BackupInfo, error)
+
+	// SetBackupDir sets the directory where the backups are created, listed,
+	// pruned and restored from. By default, the backups are stored in the data
+	// directory.
+	SetBackupDir(path string) error
+
+	// SetPullRetries sets the number of times the download of a package is
+	// retried after a transient failure, like a network timeout. Downloads are
+	// not retried until it is called. The egn CLI calls it with its --retries
+	// flag, which defaults to 3.
+	SetPullRetries(retries int)
+
+	// SetGitOptions sets the options of the git clones of the packages, like a
+	// CA bundle for the HTTPS clones behind a TLS-intercepting proxy. An
+	// ErrInvalidGitOptions error is returned if the CA bundle can't be read or
+	// has no certificates, or if an extra header is not in the "Name: value"
+	// form.
+	SetGitOptions(options GitOptions) error
+
+	// SetRegistryOptions sets the credentials of the container registries
+	// used to pull the images of the instances and plugins, so packages can
+	// use images of private registries. An ErrInvalidRegistryOptions error is
+	// returned if the docker config file can't be read or a registry auth is
+	// not in the "registry=username:password" form.
+	SetRegistryOptions(options RegistryOptions) error
+
+	// SetMonitoringPermissions sets the permissions and ownership of the
+	// directories and files created in the monitoring stack from now on, so
+	// they can be read by the monitoring containers running as a non-root
+	// user. Existing files are not changed. An ErrInvalidMonitoringPermissions
+	// error is returned if a mode is not an octal permission mode or the owner
+	// is not in the "uid:gid" form.
+	SetMonitoringPermissions(options MonitoringPermissions) error
+
+	// CheckUpdates checks whether a newer version of the package of the
+	// instance with the given ID is available, comparing the installed
+	// version with the version tags of the package repository. The tags are
+	// cached for a few hours, so the repository is not queried on every check.
+	// A failed query is cached too, for a shorter time, and its error is
+	// returned until it expires. The query is aborted when ctx is done.
+	// An ErrUpdateCheckUnsupported error is returned for the instances not
+	// installed from a package version, like the local installs.
+	CheckUpdates(ctx context.Context, instanceId string) (*UpdateInfo, error)
+
+	// PruneBackups removes the backups of the instance with the given ID that
+	// are not kept by the retention policy and returns the removed backups. In
+	// a dry run nothing is removed, and the backups that would be removed are
+	// returned. The instance of an encrypted backup is only known with its
+	// key, so the encrypted backups are only pruned if they can be decrypted
+	// with options.EncryptionKeyFile.
+	PruneBackups(instanceId string, retention RetentionPolicy, options PruneBackupsOptions) ([]PrunedBackup, error)
+
+	// ScheduleBackup backs up the instance with the given ID every interval
+	// until the context is canceled, pruning its backups after each cycle
+	// according to the retention policy. The backups are created with the
+	// given options, and pruned with their encryption key. A cycle is skipped
+	// if the backup of the previous cycle is still running.
+	ScheduleBackup(ctx context.Context, instanceId string, interval time.Duration, retention RetentionPolicy, options BackupOptions) error
+
+	// Close releases the resources of the daemon, like the docker client created
+	// by New. The Daemon must not be used after calling Close.
+	Close() error
 }
 
 type PullTarget struct {
@@ -96,6 +270,151 @@ type PullTarget struct {
 	Commit  string
 }
 
+// GitOptions are the options of the git clones of the packages.
+type GitOptions struct {
+	// CABundle is the path of a PEM file with the certificates of additional
+	// certificate authorities trusted by the clones over HTTPS. The system
+	// certificates are still trusted.
+	CABundle string
+	// ExtraHeaders are HTTP headers in the "Name: value" form sent with every
+	// request of the clones, like the git http.extraHeader config.
+	ExtraHeaders []string
+}
+
+// RegistryOptions are the credentials of the container registries. The
+// credentials of Auths take precedence over the ones of the config file for
+// the same registry.
+type RegistryOptions struct {
+	// ConfigPath is the path of a docker config file, like
+	// ~/.docker/config.json, with the credentials of the registries. The
+	// credentials of credential helpers are not used.
+	ConfigPath string
+	// Auths are registry credentials in the "registry=username:password"
+	// form, like "ghcr.io=user:token".
+	Auths []string
+}
+
+// MonitoringPermissions are the permissions and ownership of the directories
+// and files created in the monitoring stack. The zero value keeps the default
+// permissions and the ownership of the current user.
+type MonitoringPermissions struct {
+	// DirMode is the octal mode of the directories, like "0750". Defaults to
+	// 0755.
+	DirMode string
+	// FileMode is the octal mode of the files, like "0640". Defaults to 0644.
+	FileMode string
+	// Owner is the owner of the directories and files in the "uid:gid" form,
+	// like "472:472".
+	Owner string
+}
+
+type RunOptions struct {
+	Wait bool
+	// Timeout bounds the whole start of the instance, including the health
+	// check if Wait is true. Zero means no limit, and it must be set if Wait
+	// is true.
+	Timeout time.Duration
+}
+
+// Compression is the codec a backup tar file is compressed with.
+type Compression string
+
+const (
+	// CompressionNone stores the backup as a plain tar file.
+	CompressionNone Compression = ""
+	// CompressionGzip compresses the backup tar file with gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses the backup tar file with zstd.
+	CompressionZstd Compression = "zstd"
+)
+
+// ParseCompression returns the Compression named s, one of none, gzip or zstd.
+// It returns ErrInvalidCompression for any other name.
+func ParseCompression(s string) (Compression, error) {
+	switch s {
+	case "none":
+		return CompressionNone, nil
+	case string(CompressionGzip):
+		return CompressionGzip, nil
+	case string(CompressionZstd):
+		return CompressionZstd, nil
+	}
+	return CompressionNone, fmt.Errorf("%w: %s", ErrInvalidCompression, s)
+}
+
+type BackupOptions struct {
+	// Compression is the codec used to compress the backup tar file. Backup
+	// returns ErrInvalidCompression if it is not one of the Compression
+	// constants.
+	Compression Compression
+	// EncryptionKeyFile is the path of the key file the backup is encrypted
+	// with. The backup is not encrypted if it is empty.
+	EncryptionKeyFile string
+}
+
+// StreamBackupOptions defines the options of a streamed backup.
+type StreamBackupOptions struct {
+	// Consistent pauses the services of the instance while the backup is
+	// taken, resuming them afterwards. A backup taken while the instance runs
+	// is always readable, but the files written during the backup may not be
+	// consistent with each other. Pausing the instance avoids it at the cost of
+	// the instance being unavailable during the backup.
+	Consistent bool
+}
+
+type RestoreOptions struct {
+	// Run runs the instance after restoring it.
+	Run bool
+	// InstanceId is the ID of the restored instance. If empty, the backup is
+	// restored into the instance it was created from.
+	InstanceId string
+	// EncryptionKeyFile is the path of the key file an encrypted backup is
+	// decrypted with. Restoring an encrypted backup without it fails with
+	// data.ErrBackupEncrypted.
+	EncryptionKeyFile string
+}
+
+// RetentionPolicy defines which backups of an instance are kept when pruning.
+// Rules with a zero value are disabled, and the most recent backup is always
+// kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent backups to keep.
+	KeepLast int
+	// MaxAge is the maximum age of a backup to be kept.
+	MaxAge time.Duration
+}
+
+// PruneBackupsOptions defines the options of PruneBackups.
+type PruneBackupsOptions struct {
+	// DryRun lists the backups that would be removed without removing them.
+	DryRun bool
+	// EncryptionKeyFile is the path of the key file the encrypted backups are
+	// decrypted with to know their instance. The encrypted backups are not
+	// pruned if it is empty.
+	EncryptionKeyFile string
+}
+
+// UpdateInfo is the result of an update check of an instance.
+type UpdateInfo struct {
+	InstanceId     string `json:"instance_id" yaml:"instance_id"`
+	CurrentVersion string `json:"current_version" yaml:"current_version"`
+	// LatestVersion is the latest version of the package, or the current
+	// version if there is no newer one.
+	LatestVersion   string `json:"latest_version" yaml:"latest_version"`
+	UpdateAvailable bool   `json:"update_available" yaml:"update_available"`
+	// CheckedAt is when the versions of the package repository were listed,
+	// which is earlier than the check if they were cached.
+	CheckedAt time.Time `json:"checked_at" yaml:"checked_at"`
+}
+
+// PrunedBackup is a backup removed by PruneBackups, or that would be removed
+// in a dry run.
+type PrunedBackup struct {
+	Id string `json:"id" yaml:"id"`
+	// Path is the path of the backup tar file.
+	Path string `json:"path" yaml:"path"`
+}
+
 type RunPluginOptions struct {
 	NoDestroyImage bool
 	HostNetwork    bool
@@ -105,12 +424,22 @@ type RunPluginOptions struct {
 
 // ListInstanceItem is an item in the list of instances returned by ListInstances.
 type ListInstanceItem struct {
-	ID      string
-	Version string
-	Commit  string
-	Health  NodeHealth
-	Running bool
-	Comment string
+	ID          string            `json:"id" yaml:"id"`
+	Version     string            `json:"version" yaml:"version"`
+	Commit      string            `json:"commit" yaml:"commit"`
+	Health      NodeHealth        `json:"health" yaml:"health"`
+	Running     bool              `json:"running" yaml:"running"`
+	Comment     string            `json:"comment" yaml:"comment"`
+	InstalledAt time.Time         `json:"installed_at" yaml:"installed_at"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// InstanceVersionInfo is the package version of an installed instance, as
+// returned by InstanceVersion.
+type InstanceVersionInfo struct {
+	InstanceID string `json:"instance_id" yaml:"instance_id"`
+	Version    string `json:"version" yaml:"version"`
+	Commit     string `json:"commit" yaml:"commit"`
 }
 
 // NodeHealth is the health of a node, matching the HTTP status codes.
@@ -136,6 +465,12 @@ func (n NodeHealth) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler, so the health is encoded
+// by its name instead of its status code.
+func (n NodeHealth) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
 type NodeLogsOptions struct {
 	Follow     bool
 	Since      string
@@ -144,6 +479,86 @@ type NodeLogsOptions struct {
 	Tail       string
 }
 
+// MonitoringStatus is the status of the monitoring stack, as returned by
+// MonitoringStatus.
+type MonitoringStatus struct {
+	Services []MonitoringServiceStatus `json:"services" yaml:"services"`
+	// Targets is the health of the targets scraped by Prometheus.
+	Targets MonitoringTargets `json:"targets" yaml:"targets"`
+}
+
+// MonitoringServiceStatus is the status of a service of the monitoring stack.
+type MonitoringServiceStatus struct {
+	// Name is the container name of the service.
+	Name    string   `json:"name" yaml:"name"`
+	Running bool     `json:"running" yaml:"running"`
+	Ports   []string `json:"ports" yaml:"ports"`
+}
+
+// MonitoringTargets is the health of the targets scraped by the monitoring
+// stack. Targets that were not scraped yet are active but neither up nor down.
+type MonitoringTargets struct {
+	Active int `json:"active" yaml:"active"`
+	Up     int `json:"up" yaml:"up"`
+	Down   int `json:"down" yaml:"down"`
+}
+
+// DoctorCheck is the result of a diagnostic check run by Doctor.
+type DoctorCheck struct {
+	// Name is the name of the check.
+	Name string
+	// Err is the reason the check failed, nil if it passed or was skipped.
+	Err error
+	// Skipped is the reason the check was not run, empty if it was run.
+	Skipped string
+}
+
+// Failed returns true if the check was run and failed.
+func (c DoctorCheck) Failed() bool {
+	return c.Err != nil
+}
+
+// PruneOptions are the options of a Prune call.
+type PruneOptions struct {
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+	// Images also removes the dangling docker images built for the instances.
+	Images bool
+}
+
+// PruneResult holds what was removed by a Prune call, or what would have been
+// removed in a dry run.
+type PruneResult struct {
+	// DataDirs are the IDs of the removed data directories.
+	DataDirs []string
+	// Images are the IDs of the removed docker images.
+	Images []string
+}
+
+// ConfigEntry is a variable of the environment of an instance.
+type ConfigEntry struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+	// Option is the name of the profile option that targets the variable,
+	// empty if there is none.
+	Option string `json:"option,omitempty" yaml:"option,omitempty"`
+}
+
+// ExecOptions are the options of an Exec call.
+type ExecOptions struct {
+	// Service is the compose service to run the command in. If it is empty,
+	// the primary service of the instance is used, which is the service of
+	// its API target or, without one, of its first monitoring target.
+	Service string
+	// Interactive keeps the stdin of the command open, reading it from Stdin.
+	Interactive bool
+	// TTY allocates a pseudo-TTY for the command.
+	TTY    bool
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
 // PullResult is the result of a Pull operation, containing all the necessary
 // information from the package.
 type PullResult struct {
@@ -234,6 +649,41 @@ type InstallOptions struct {
 
 	// Options is the list of options to use for the instance.
 	Options []Option
+
+	// Force replaces an installed instance with the same ID, taking a backup
+	// of it first. Without Force, installing over an existing instance fails
+	// with ErrInstanceExists.
+	Force bool
+}
+
+// InstallResult describes an installed instance.
+type InstallResult struct {
+	// InstanceID is the ID of the installed instance.
+	InstanceID string `json:"instance_id" yaml:"instance_id"`
+
+	// Version is the installed version of the node software package.
+	Version string `json:"version" yaml:"version"`
+
+	// Commit is the installed commit of the node software package.
+	Commit string `json:"commit" yaml:"commit"`
+
+	// URL is the URL of the git repository of the node software package.
+	URL string `json:"url" yaml:"url"`
+
+	// Profile is the name of the profile used for the instance.
+	Profile string `json:"profile" yaml:"profile"`
+
+	// DataDir is the path to the directory of the instance.
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+
+	// MonitoringEndpoints are the metrics endpoints of the instance services
+	// with the format [<scheme>://]<service>:<port><path>. They are added to
+	// the monitoring stack when the instance runs.
+	MonitoringEndpoints []string `json:"monitoring_endpoints" yaml:"monitoring_endpoints"`
+
+	// Dashboards are the names of the dashboards of the instance profile. They
+	// are added to the monitoring stack when the instance runs.
+	Dashboards []string `json:"dashboards" yaml:"dashboards"`
 }
 
 // LocalInstallOptions is a set of options for installing a node software package
@@ -253,6 +703,10 @@ type LocalInstallOptions struct {
 	// passed as strings because the local installation method is for development
 	// purposes only, and the user is responsible for passing the correct options.
 	Options map[string]string
+
+	// Force replaces an installed instance with the same ID, taking a backup
+	// of it first.
+	Force bool
 }
 
 type HardwareRequirements struct {
@@ -267,11 +721,18 @@ func (h HardwareRequirements) String() string {
 }
 
 type BackupInfo struct {
-	Id        string
-	Instance  string
-	Timestamp time.Time
-	SizeBytes int64
-	Version   string
-	Commit    string
-	Url       string
+	Id        string    `json:"id" yaml:"id"`
+	Instance  string    `json:"instance_id" yaml:"instance_id"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	// Age is the time elapsed since the backup was created. It is left out of
+	// the JSON and YAML documents, which already hold the timestamp.
+	Age       time.Duration `json:"-" yaml:"-"`
+	SizeBytes int64         `json:"size_bytes" yaml:"size_bytes"`
+	Version   string        `json:"version" yaml:"version"`
+	Commit    string        `json:"commit" yaml:"commit"`
+	Url       string        `json:"url" yaml:"url"`
+	// Encrypted is true for encrypted backups, whose instance, version,
+	// commit and URL are unknown until they are decrypted. Their timestamp is
+	// the modification time of the backup file.
+	Encrypted bool `json:"encrypted,omitempty" yaml:"encrypted,omitempty"`
 }
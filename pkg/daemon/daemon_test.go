@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		name string
+		want Compression
+		err  error
+	}{
+		{name: "none", want: CompressionNone},
+		{name: "gzip", want: CompressionGzip},
+		{name: "zstd", want: CompressionZstd},
+		{name: "", err: ErrInvalidCompression},
+		{name: "bzip2", err: ErrInvalidCompression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompression(tt.name)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
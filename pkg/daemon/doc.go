@@ -1,3 +1,23 @@
 // The daemon package is responsible for providing all the functionalities for
 // external API calls, like CLI or gRPC. The starting point is the Daemon interface.
+//
+// Go programs can drive egn through the Daemon returned by New, without
+// running the egn CLI:
+//
+//	d, err := daemon.New(daemon.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	defer d.Close()
+//	if err := d.CheckRuntime(); err != nil {
+//		return err
+//	}
+//	instances, err := d.ListInstances()
+//
+// The Daemon methods return the sentinel errors of this package wrapped with
+// the details of the failure, so check them with errors.Is:
+//
+//	if errors.Is(err, daemon.ErrInstanceNotFound) {
+//		// ...
+//	}
 package daemon
@@ -14,8 +14,9 @@ type DockerManager interface {
 	// ContainerNetworks returns the networks of a container.
 	ContainerNetworks(container string) ([]string, error)
 
-	// Pull pulls the given image.
-	Pull(image string) error
+	// Pull pulls the given image with the given registry credentials, or
+	// anonymously if auth is the zero RegistryAuth.
+	Pull(image string, auth docker.RegistryAuth) error
 
 	// LoadImageContext loads the given context.
 	LoadImageContext(path string) (io.ReadCloser, error)
@@ -31,4 +32,14 @@ type DockerManager interface {
 
 	// ImageExists checks if the given image exists.
 	ImageExist(image string) (bool, error)
+
+	// DanglingImages returns the IDs of the dangling images docker compose
+	// built for any of the given projects.
+	DanglingImages(projects []string) ([]string, error)
+
+	// Ping checks that the Docker daemon is reachable.
+	Ping() error
+
+	// Exec runs the given command inside the given running container.
+	Exec(ctx context.Context, container string, cmd []string, options docker.ExecOptions) error
 }
@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,10 +12,15 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"golang.org/x/exp/maps"
 	"golang.org/x/mod/semver"
 
@@ -23,7 +29,9 @@ import (
 	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/docker"
 	hardwarechecker "github.com/NethermindEth/eigenlayer/internal/hardware_checker"
+	"github.com/NethermindEth/eigenlayer/internal/hooks"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
 	"github.com/NethermindEth/eigenlayer/internal/package_handler"
 	"github.com/NethermindEth/eigenlayer/internal/profile"
 	"github.com/NethermindEth/eigenlayer/internal/utils"
@@ -31,6 +39,10 @@ import (
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
 )
 
+// hooksDir is the directory of the data dir with the hooks of the instance
+// lifecycle events.
+const hooksDir = "hooks"
+
 // Checks that EgnDaemon implements Daemon.
 var _ = Daemon(&EgnDaemon{})
 
@@ -42,6 +54,33 @@ type EgnDaemon struct {
 	monitoringMgr MonitoringManager
 	locker        locker.Locker
 	backupManager BackupManager
+	logger        log.FieldLogger
+	// hardwareMetrics returns the hardware metrics of the host.
+	hardwareMetrics func() (hardwarechecker.HardwareMetrics, error)
+	// pullRetries is the number of times a package download is retried.
+	pullRetries int
+	// gitCABundle and gitHeaders are the CA bundle and the extra HTTP headers
+	// of the package clones.
+	gitCABundle []byte
+	gitHeaders  http.Header
+	// registryAuths are the credentials of the registries the images are
+	// pulled from.
+	registryAuths docker.RegistryAuths
+	// hooks runs the hooks of the instance lifecycle events.
+	hooks HookRunner
+	// dockerClient is the docker client closed by Close, if the daemon owns it.
+	dockerClient io.Closer
+	// metrics records the backups of the daemon, if not nil. It is set by
+	// WithMetrics, which records the installs and runs.
+	metrics MetricsRecorder
+	// operationLocker locks the data directory while an operation changes the
+	// instances. The operations are not serialized if it is nil.
+	operationLocker locker.Locker
+	// operationLockTimeout is the time an operation waits for the lock.
+	operationLockTimeout time.Duration
+	// listVersions lists the versions of the package repository with the
+	// given URL.
+	listVersions func(ctx context.Context, url string) ([]string, error)
 }
 
 // NewDaemon create a new daemon instance.
@@ -52,29 +91,37 @@ func NewEgnDaemon(
 	mtrMgr MonitoringManager,
 	backupMgr BackupManager,
 	locker locker.Locker,
+	logger log.FieldLogger,
 ) (*EgnDaemon, error) {
-	return &EgnDaemon{
-		dataDir:       dataDir,
-		dockerCompose: cmpMgr,
-		docker:        dockerMgr,
-		monitoringMgr: mtrMgr,
-		locker:        locker,
-		backupManager: backupMgr,
-	}, nil
+	d := &EgnDaemon{
+		dataDir:         dataDir,
+		dockerCompose:   cmpMgr,
+		docker:          dockerMgr,
+		monitoringMgr:   mtrMgr,
+		locker:          locker,
+		backupManager:   backupMgr,
+		logger:          logger,
+		hardwareMetrics: hardwarechecker.GetMetrics,
+		hooks:           hooks.NewRunner(dataDir.Fs(), filepath.Join(dataDir.Path(), hooksDir), logger),
+
+		operationLockTimeout: defaultOperationLockTimeout,
+	}
+	d.listVersions = d.remoteVersions
+	return d, nil
 }
 
 // Init initializes the Monitoring Stack. If install is true, it will install the Monitoring Stack if it is not installed.
 // If run is true, it will run the Monitoring Stack if it is not running.
-func (d *EgnDaemon) InitMonitoring(install, run bool) error {
+func (d *EgnDaemon) InitMonitoring(ctx context.Context, install, run bool) error {
 	// Check if the monitoring stack is installed.
 	installStatus, err := d.monitoringMgr.InstallationStatus()
 	if err != nil {
 		return err
 	}
-	log.Debugf("Monitoring stack installation status: %v", installStatus == common.Installed)
+	d.logger.WithField("installed", installStatus == common.Installed).Debug("Monitoring stack installation status")
 	// If the monitoring stack is not installed, install it.
 	if installStatus == common.NotInstalled && install {
-		err = d.monitoringMgr.InstallStack()
+		err = d.monitoringMgr.InstallStack(ctx)
 		if errors.Is(err, monitoring.ErrInstallingMonitoringMngr) {
 			// If the monitoring stack installation fails, remove the monitoring stack directory.
 			if cerr := d.monitoringMgr.Cleanup(true); cerr != nil {
@@ -88,7 +135,7 @@ func (d *EgnDaemon) InitMonitoring(install, run bool) error {
 	// Check if the monitoring stack is running.
 	status, err := d.monitoringMgr.Status()
 	if err != nil {
-		log.Debugf("Monitoring stack status: unknown. Got error: %v", err)
+		d.logger.WithError(err).Debug("Monitoring stack status: unknown")
 	}
 	// If the monitoring stack is not running, start it.
 	if status != common.Running && status != common.Restarting && run {
@@ -127,7 +174,7 @@ func (d *EgnDaemon) CleanMonitoring() error {
 	if err != nil {
 		return err
 	}
-	log.Debugf("Monitoring stack installation status: %v", installStatus == common.Installed)
+	d.logger.WithField("installed", installStatus == common.Installed).Debug("Monitoring stack installation status")
 	// If the monitoring stack is installed, uninstall it.
 	if installStatus == common.Installed {
 		if err := d.monitoringMgr.Cleanup(false); err != nil {
@@ -137,6 +184,96 @@ func (d *EgnDaemon) CleanMonitoring() error {
 	return nil
 }
 
+// ReconcileMonitoring implements Daemon.ReconcileMonitoring.
+func (d *EgnDaemon) ReconcileMonitoring() error {
+	installStatus, err := d.monitoringMgr.InstallationStatus()
+	if err != nil {
+		return err
+	}
+	if installStatus != common.Installed {
+		return nil
+	}
+	status, err := d.monitoringMgr.Status()
+	if err != nil {
+		return err
+	}
+	if status != common.Running && status != common.Restarting {
+		return ErrMonitoringStackNotRunning
+	}
+	instances, err := d.dataDir.ListInstances()
+	if err != nil {
+		return err
+	}
+	active := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		active = append(active, instance.ID())
+	}
+	return d.monitoringMgr.ReconcileTargets(active)
+}
+
+// MonitoringStatus implements Daemon.MonitoringStatus.
+func (d *EgnDaemon) MonitoringStatus() (MonitoringStatus, error) {
+	installStatus, err := d.monitoringMgr.InstallationStatus()
+	if err != nil {
+		return MonitoringStatus{}, err
+	}
+	if installStatus != common.Installed {
+		return MonitoringStatus{}, ErrMonitoringStackNotInstalled
+	}
+	stackStatus, err := d.monitoringMgr.StackStatus()
+	if err != nil {
+		return MonitoringStatus{}, err
+	}
+	status := MonitoringStatus{
+		Services: make([]MonitoringServiceStatus, 0, len(stackStatus.Services)),
+		Targets: MonitoringTargets{
+			Active: stackStatus.Targets.Active,
+			Up:     stackStatus.Targets.Up,
+			Down:   stackStatus.Targets.Down,
+		},
+	}
+	for _, service := range stackStatus.Services {
+		status.Services = append(status.Services, MonitoringServiceStatus{
+			Name:    service.Name,
+			Running: service.Running,
+			Ports:   service.Ports,
+		})
+	}
+	return status, nil
+}
+
+// ExportMonitoring implements Daemon.ExportMonitoring.
+func (d *EgnDaemon) ExportMonitoring(w io.Writer) error {
+	installStatus, err := d.monitoringMgr.InstallationStatus()
+	if err != nil {
+		return err
+	}
+	if installStatus != common.Installed {
+		return ErrMonitoringStackNotInstalled
+	}
+	stack, err := d.dataDir.MonitoringStack()
+	if err != nil {
+		return err
+	}
+	return stack.Export(w)
+}
+
+// ImportMonitoring implements Daemon.ImportMonitoring.
+func (d *EgnDaemon) ImportMonitoring(r io.Reader) error {
+	status, err := d.monitoringMgr.Status()
+	if err != nil {
+		d.logger.WithError(err).Debug("Monitoring stack status: unknown")
+	}
+	if status == common.Running || status == common.Restarting {
+		return fmt.Errorf("%w: stop it before importing the monitoring bundle", ErrMonitoringStackRunning)
+	}
+	stack, err := d.dataDir.MonitoringStack()
+	if err != nil {
+		return err
+	}
+	return stack.Import(r)
+}
+
 // ListInstances implements Daemon.ListInstances.
 func (d *EgnDaemon) ListInstances() ([]ListInstanceItem, error) {
 	var result []ListInstanceItem
@@ -148,11 +285,13 @@ func (d *EgnDaemon) ListInstances() ([]ListInstanceItem, error) {
 		running, err := d.instanceRunning(instance.ID())
 		if err != nil {
 			result = append(result, ListInstanceItem{
-				ID:      instance.ID(),
-				Health:  NodeHealthUnknown,
-				Comment: fmt.Sprintf("Failed to get instance status: %v", err),
-				Version: instance.Version,
-				Commit:  instance.Commit,
+				ID:          instance.ID(),
+				Health:      NodeHealthUnknown,
+				Comment:     fmt.Sprintf("Failed to get instance status: %v", err),
+				Version:     instance.Version,
+				Commit:      instance.Commit,
+				InstalledAt: instance.InstalledAt,
+				Labels:      instance.Labels,
 			})
 			continue
 		}
@@ -164,6 +303,8 @@ func (d *EgnDaemon) ListInstances() ([]ListInstanceItem, error) {
 		item.Running = running
 		item.Version = instance.Version
 		item.Commit = instance.Commit
+		item.InstalledAt = instance.InstalledAt
+		item.Labels = instance.Labels
 		result = append(result, item)
 	}
 	return result, nil
@@ -232,8 +373,10 @@ func (d *EgnDaemon) instanceHealth(instanceId string) (out ListInstanceItem) {
 	return
 }
 
+const defaultHealthPath = "/eigen/node/health"
+
 func checkHealth(ip string, port string) (NodeHealth, error) {
-	url := fmt.Sprintf("http://%s:%s/eigen/node/health", ip, port)
+	url := fmt.Sprintf("http://%s:%s%s", ip, port, defaultHealthPath)
 
 	// HTTP client with timeout
 	client := &http.Client{
@@ -255,13 +398,13 @@ func checkHealth(ip string, port string) (NodeHealth, error) {
 	case 503:
 		return NodeUnhealthy, nil
 	default:
-		return NodeHealthUnknown, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return NodeHealthUnknown, fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, resp.StatusCode)
 	}
 }
 
 // Pull implements Daemon.Pull.
-func (d *EgnDaemon) Pull(url string, ref PullTarget, force bool) (result PullResult, err error) {
-	pkgHandler, err := d.pullPackage(url, force)
+func (d *EgnDaemon) Pull(ctx context.Context, url string, ref PullTarget, force bool) (result PullResult, err error) {
+	pkgHandler, err := d.pullPackage(ctx, url, ref, force)
 	if err != nil {
 		return
 	}
@@ -304,7 +447,7 @@ func (d *EgnDaemon) Pull(url string, ref PullTarget, force bool) (result PullRes
 	if err != nil {
 		return
 	}
-	if err = pkgHandler.Check(); err != nil {
+	if err = pkgHandler.CheckWithProgress(d.logChecksumProgress); err != nil {
 		return
 	}
 	// Get profiles names and its options
@@ -341,7 +484,76 @@ func (d *EgnDaemon) Pull(url string, ref PullTarget, force bool) (result PullRes
 	return result, err
 }
 
-func (d *EgnDaemon) PullUpdate(instanceID string, ref PullTarget) (PullUpdateResult, error) {
+// CheckUpdates implements Daemon.CheckUpdates.
+func (d *EgnDaemon) CheckUpdates(ctx context.Context, instanceId string) (*UpdateInfo, error) {
+	if !d.dataDir.HasInstance(instanceId) {
+		return nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	instance, err := d.dataDir.Instance(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	if !semver.IsValid(instance.Version) {
+		return nil, fmt.Errorf("%w: %s is not installed from a package version", ErrUpdateCheckUnsupported, instanceId)
+	}
+
+	cache := d.dataDir.VersionsCache(package_handler.DefaultVersionsCacheMaxAge)
+	entry, ok, err := cache.Get(instance.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		versions, err := d.listVersions(ctx, instance.URL)
+		if err != nil {
+			// A cancelled check is not a failure of the repository
+			if !errors.Is(ctx.Err(), context.Canceled) {
+				if _, err := cache.PutError(instance.URL, err); err != nil {
+					return nil, err
+				}
+			}
+			return nil, fmt.Errorf("%w: %w", ErrCheckingUpdates, err)
+		}
+		if entry, err = cache.Put(instance.URL, versions); err != nil {
+			return nil, err
+		}
+	}
+	if entry.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrCheckingUpdates, entry.Error)
+	}
+
+	info := &UpdateInfo{
+		InstanceId:     instanceId,
+		CurrentVersion: instance.Version,
+		LatestVersion:  instance.Version,
+		CheckedAt:      entry.CheckedAt,
+	}
+	if len(entry.Versions) > 0 {
+		latest := entry.Versions[len(entry.Versions)-1]
+		if semver.Compare(latest, instance.Version) > 0 {
+			info.LatestVersion = latest
+			info.UpdateAvailable = true
+		}
+	}
+	return info, nil
+}
+
+// remoteVersions lists the versions of the package repository with the given
+// URL, with the git options of the package clones.
+func (d *EgnDaemon) remoteVersions(ctx context.Context, url string) ([]string, error) {
+	return package_handler.RemoteVersions(package_handler.NewPackageHandlerOptions{
+		Context:      ctx,
+		URL:          url,
+		CABundle:     d.gitCABundle,
+		ExtraHeaders: d.gitHeaders,
+	})
+}
+
+func (d *EgnDaemon) PullUpdate(ctx context.Context, instanceID string, ref PullTarget) (PullUpdateResult, error) {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return PullUpdateResult{}, err
+	}
+	defer unlock()
 	if !d.dataDir.HasInstance(instanceID) {
 		return PullUpdateResult{}, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
 	}
@@ -349,7 +561,7 @@ func (d *EgnDaemon) PullUpdate(instanceID string, ref PullTarget) (PullUpdateRes
 	if err != nil {
 		return PullUpdateResult{}, err
 	}
-	pkgHandler, err := d.pullPackage(instance.URL, true)
+	pkgHandler, err := d.pullPackage(ctx, instance.URL, ref, false)
 	if err != nil {
 		return PullUpdateResult{}, err
 	}
@@ -388,6 +600,10 @@ func (d *EgnDaemon) PullUpdate(instanceID string, ref PullTarget) (PullUpdateRes
 			return PullUpdateResult{}, err
 		}
 	}
+	// Verify the checked out package
+	if err = pkgHandler.CheckWithProgress(d.logChecksumProgress); err != nil {
+		return PullUpdateResult{}, err
+	}
 	// Get new commit hash
 	newCommit, err := pkgHandler.CurrentCommitHash()
 	if err != nil {
@@ -438,7 +654,7 @@ func (d *EgnDaemon) PullUpdate(instanceID string, ref PullTarget) (PullUpdateRes
 		if v, ok := valuesOld[o.Target()]; ok {
 			err := o.Set(v)
 			if err != nil {
-				return PullUpdateResult{}, fmt.Errorf("error setting old option value: %v", err)
+				return PullUpdateResult{}, fmt.Errorf("error setting old option value: %w", err)
 			}
 		} else {
 			return PullUpdateResult{}, fmt.Errorf("%w: old option %s", ErrOptionWithoutValue, o.Name())
@@ -467,6 +683,11 @@ func (d *EgnDaemon) PullUpdate(instanceID string, ref PullTarget) (PullUpdateRes
 }
 
 func (d *EgnDaemon) LocalPullUpdate(instanceID string, pkgTar io.Reader) (PullUpdateResult, error) {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return PullUpdateResult{}, err
+	}
+	defer unlock()
 	// Get instance to update
 	if !d.dataDir.HasInstance(instanceID) {
 		return PullUpdateResult{}, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
@@ -520,7 +741,7 @@ func (d *EgnDaemon) LocalPullUpdate(instanceID string, pkgTar io.Reader) (PullUp
 		if v, ok := valuesOld[o.Target()]; ok {
 			err := o.Set(v)
 			if err != nil {
-				return PullUpdateResult{}, fmt.Errorf("error setting old option value: %v", err)
+				return PullUpdateResult{}, fmt.Errorf("error setting old option value: %w", err)
 			}
 		} else {
 			return PullUpdateResult{}, fmt.Errorf("%w: old option %s", ErrOptionWithoutValue, o.Name())
@@ -599,33 +820,116 @@ func mergeOptions(oldOptions, newOptions []Option) ([]Option, error) {
 	return mergedOptions, nil
 }
 
-func (d *EgnDaemon) pullPackage(url string, force bool) (*package_handler.PackageHandler, error) {
+// pullPackage downloads the package from the given URL into a temp directory.
+// Unless force is true, a package pulled before for the same version or commit
+// is copied from the package cache instead of being cloned again. Clones that
+// fail with a transient error are retried up to d.pullRetries times.
+func (d *EgnDaemon) pullPackage(ctx context.Context, url string, ref PullTarget, force bool) (*package_handler.PackageHandler, error) {
+	// The package is always copied or cloned into an empty directory
 	tID := tempID(url)
-	if force {
-		err := d.dataDir.RemoveTemp(tID)
-		if err != nil {
-			return nil, err
-		}
+	if err := d.dataDir.RemoveTemp(tID); err != nil {
+		return nil, err
 	}
 	tempPath, err := d.dataDir.InitTemp(tID)
 	if err != nil {
 		return nil, err
 	}
-	return package_handler.NewPackageHandlerFromURL(package_handler.NewPackageHandlerOptions{
-		Path: tempPath,
-		URL:  url,
-	})
+	opts := package_handler.NewPackageHandlerOptions{
+		Path:         tempPath,
+		URL:          url,
+		Context:      ctx,
+		Retries:      d.pullRetries,
+		CABundle:     d.gitCABundle,
+		ExtraHeaders: d.gitHeaders,
+	}
+	if !force {
+		opts.Cache = d.dataDir.PackageCache(package_handler.DefaultCachePolicy)
+		opts.Ref = ref.Version
+		if opts.Ref == "" {
+			opts.Ref = ref.Commit
+		}
+	}
+	return package_handler.NewPackageHandlerFromURL(opts)
 }
 
 // Install implements Daemon.Install.
-func (d *EgnDaemon) Install(options InstallOptions) (string, error) {
+func (d *EgnDaemon) Install(options InstallOptions) (InstallResult, error) {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return InstallResult{InstanceID: data.InstanceId(options.Name, options.Tag)}, err
+	}
+	defer unlock()
+	var replacedBackupId string
+	if options.Force {
+		instanceId := data.InstanceId(options.Name, options.Tag)
+		if replacedBackupId, err = d.replaceInstance(instanceId); err != nil {
+			return InstallResult{InstanceID: instanceId}, err
+		}
+	}
 	instanceId, tempDirID, err := d.remoteInstall(options)
-	return instanceId, d.postInstallation(instanceId, tempDirID, err)
+	if err = d.postInstallation(instanceId, tempDirID, err); err != nil {
+		return InstallResult{InstanceID: instanceId}, d.restoreReplaced(instanceId, replacedBackupId, err)
+	}
+	if err := d.runHook(context.Background(), hooks.PostInstall, instanceId, nil); err != nil {
+		return InstallResult{InstanceID: instanceId}, err
+	}
+	return d.installResult(instanceId)
+}
+
+// installResult builds the InstallResult of the installed instance with the
+// given ID.
+func (d *EgnDaemon) installResult(instanceId string) (InstallResult, error) {
+	result := InstallResult{InstanceID: instanceId}
+	instance, err := d.dataDir.Instance(instanceId)
+	if err != nil {
+		return result, err
+	}
+	instancePath, err := d.dataDir.InstancePath(instanceId)
+	if err != nil {
+		return result, err
+	}
+	// The dashboards of the instance are named after their files, see
+	// addTarget
+	dashboards := make([]string, 0, len(instance.MonitoringTargets.Dashboards))
+	for _, dashboardPath := range instance.MonitoringTargets.Dashboards {
+		dashboards = append(dashboards, strings.TrimSuffix(filepath.Base(dashboardPath), ".json"))
+	}
+	endpoints := make([]string, 0, len(instance.MonitoringTargets.Targets))
+	for _, target := range instance.MonitoringTargets.Targets {
+		endpoint := target.Service + ":" + target.Port + target.Path
+		if target.Scheme != "" {
+			endpoint = target.Scheme + "://" + endpoint
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	result.Version = instance.Version
+	result.Commit = instance.Commit
+	result.URL = instance.URL
+	result.Profile = instance.Profile
+	result.DataDir = instancePath
+	result.MonitoringEndpoints = endpoints
+	result.Dashboards = dashboards
+	return result, nil
 }
 
 func (d *EgnDaemon) LocalInstall(pkgTar io.Reader, options LocalInstallOptions) (string, error) {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return data.InstanceId(options.Name, options.Tag), err
+	}
+	defer unlock()
+	var replacedBackupId string
+	if options.Force {
+		instanceId := data.InstanceId(options.Name, options.Tag)
+		if replacedBackupId, err = d.replaceInstance(instanceId); err != nil {
+			return instanceId, err
+		}
+	}
 	instanceId, tempDirID, err := d.localInstall(pkgTar, options)
-	return instanceId, d.postInstallation(instanceId, tempDirID, err)
+	if err := d.postInstallation(instanceId, tempDirID, err); err != nil {
+		return instanceId, d.restoreReplaced(instanceId, replacedBackupId, err)
+	}
+	return instanceId, d.runHook(context.Background(), hooks.PostInstall, instanceId, nil)
 }
 
 func (d *EgnDaemon) localInstall(pkgTar io.Reader, options LocalInstallOptions) (string, string, error) {
@@ -647,7 +951,7 @@ func (d *EgnDaemon) localInstall(pkgTar io.Reader, options LocalInstallOptions)
 	instanceID := data.InstanceId(options.Name, options.Tag)
 	// Check if instance already exists
 	if d.dataDir.HasInstance(instanceID) {
-		return instanceID, "", fmt.Errorf("%w: %s", ErrInstanceAlreadyExists, instanceID)
+		return instanceID, "", fmt.Errorf("%w: %s", ErrInstanceExists, instanceID)
 	}
 
 	// Get Spec version
@@ -655,24 +959,9 @@ func (d *EgnDaemon) localInstall(pkgTar io.Reader, options LocalInstallOptions)
 	if err != nil {
 		return instanceID, tID, err
 	}
-	// Get profiles
-	pkgProfiles, err := pkgHandler.Profiles()
-	if err != nil {
-		return instanceID, tID, err
-	}
-	// Select selectedProfile
-	var selectedProfile *profile.Profile
-	for _, pkgProfile := range pkgProfiles {
-		if pkgProfile.Name == options.Profile {
-			selectedProfile = &pkgProfile
-			break
-		}
-	}
-	if selectedProfile == nil {
-		return instanceID, tID, fmt.Errorf("%w: %s", ErrProfileDoesNotExist, options.Profile)
-	}
-	// Validate profile
-	err = selectedProfile.Validate()
+	// Get the selected profile. Only its option defaults need the environment
+	// variables they reference.
+	selectedProfile, err := selectProfile(pkgHandler, options.Profile)
 	if err != nil {
 		return instanceID, tID, err
 	}
@@ -699,7 +988,7 @@ func (d *EgnDaemon) localInstall(pkgTar io.Reader, options LocalInstallOptions)
 		case "port":
 			profileOptions[o.Name], err = NewOptionPort(o)
 		default:
-			err = errors.New("unknown option type: " + o.Type)
+			err = fmt.Errorf("%w: %s", ErrUnknownOptionType, o.Type)
 			return instanceID, tID, err
 		}
 	}
@@ -723,7 +1012,7 @@ func (d *EgnDaemon) localInstall(pkgTar io.Reader, options LocalInstallOptions)
 		} else if o.Default() != "" && !o.Hidden() {
 			optionsEnv[o.Target()] = o.Default()
 		} else {
-			log.Warn("Option ", o.Name(), " does not have a default value. Using empty string as value.")
+			d.logger.WithField("option", o.Name()).Warn("Option does not have a default value. Using empty string as value.")
 			optionsEnv[o.Target()] = "\"\""
 		}
 	}
@@ -751,7 +1040,7 @@ func (d *EgnDaemon) remoteInstall(options InstallOptions) (string, string, error
 	instanceID := data.InstanceId(options.Name, options.Tag)
 
 	if d.dataDir.HasInstance(instanceID) {
-		return instanceID, tID, fmt.Errorf("%w: %s", ErrInstanceAlreadyExists, instanceID)
+		return instanceID, tID, fmt.Errorf("%w: %s", ErrInstanceExists, instanceID)
 	}
 
 	// Init package handler from temp path
@@ -773,21 +1062,11 @@ func (d *EgnDaemon) remoteInstall(options InstallOptions) (string, string, error
 		return instanceID, tID, fmt.Errorf("%w: %s", ErrVersionOrCommitNotSet, options.URL)
 	}
 
-	pkgProfiles, err := pkgHandler.Profiles()
+	// Check if selected profile is valid
+	selectedProfile, err := selectProfile(pkgHandler, options.Profile)
 	if err != nil {
 		return instanceID, tID, err
 	}
-	var selectedProfile *profile.Profile
-	// Check if selected profile is valid
-	for _, pkgProfile := range pkgProfiles {
-		if pkgProfile.Name == options.Profile {
-			selectedProfile = &pkgProfile
-			break
-		}
-	}
-	if selectedProfile == nil {
-		return instanceID, tID, fmt.Errorf("%w: %s", ErrProfileDoesNotExist, options.Profile)
-	}
 
 	// Build environment variables
 	env, err := pkgHandler.DotEnv(selectedProfile.Name)
@@ -807,6 +1086,10 @@ func (d *EgnDaemon) remoteInstall(options InstallOptions) (string, string, error
 	return d.install(options.Name, instanceID, tID, pkgHandler, selectedProfile, env, options)
 }
 
+// instanceDashboardsDir is the directory, relative to the instance directory,
+// where the dashboards of the instance profile are stored.
+const instanceDashboardsDir = "dashboards"
+
 func (d *EgnDaemon) install(
 	instanceName, instanceID, tID string,
 	pkgHandler *package_handler.PackageHandler,
@@ -829,6 +1112,10 @@ func (d *EgnDaemon) install(
 			Service: target.Service,
 			Port:    strconv.Itoa(*target.Port),
 			Path:    target.Path,
+			Scheme:  target.Scheme,
+
+			ScrapeTimeout: target.ScrapeTimeout,
+			HonorLabels:   target.HonorLabels,
 		}
 		monitoringTargets = append(monitoringTargets, mt)
 	}
@@ -846,20 +1133,42 @@ func (d *EgnDaemon) install(
 			Service: selectedProfile.API.Service,
 			Port:    strconv.Itoa(selectedProfile.API.Port),
 		}
+		if hc := selectedProfile.API.HealthCheck; hc != nil {
+			apiTarget.HealthCheck = &data.HealthCheck{
+				Path:   hc.Path,
+				Status: hc.Status,
+			}
+		}
+	}
+
+	// Get the dashboards of the selected profile
+	dashboards, err := pkgHandler.ProfileDashboards(selectedProfile.Name)
+	if err != nil {
+		return instanceID, tID, err
+	}
+	dashboardPaths := make([]string, 0, len(dashboards))
+	for name := range dashboards {
+		dashboardPaths = append(dashboardPaths, filepath.Join(instanceDashboardsDir, name))
 	}
+	sort.Strings(dashboardPaths)
 
 	// Init instance
 	instance := data.Instance{
-		Name:              instanceName,
-		Profile:           selectedProfile.Name,
-		Version:           options.Version,
-		SpecVersion:       options.SpecVersion,
-		Commit:            options.Commit,
-		URL:               options.URL,
-		Tag:               options.Tag,
-		MonitoringTargets: data.MonitoringTargets{Targets: monitoringTargets},
-		APITarget:         apiTarget,
-		Plugin:            plugin,
+		Name:        instanceName,
+		Profile:     selectedProfile.Name,
+		Version:     options.Version,
+		SpecVersion: options.SpecVersion,
+		Commit:      options.Commit,
+		URL:         options.URL,
+		Tag:         options.Tag,
+		MonitoringTargets: data.MonitoringTargets{
+			Targets:    monitoringTargets,
+			Rules:      selectedProfile.Monitoring.Rules,
+			Dashboards: dashboardPaths,
+		},
+		APITarget:   apiTarget,
+		Plugin:      plugin,
+		InstalledAt: time.Now().UTC(),
 	}
 	if err = d.dataDir.InitInstance(&instance); err != nil {
 		return instanceID, tID, err
@@ -868,12 +1177,20 @@ func (d *EgnDaemon) install(
 	if err = instance.Setup(env, pkgHandler.ProfilePath(instance.Profile)); err != nil {
 		return instanceID, tID, err
 	}
+	for name, dashboard := range dashboards {
+		if err = instance.WriteFile(filepath.Join(instanceDashboardsDir, name), dashboard); err != nil {
+			return instanceID, tID, err
+		}
+	}
 
 	// Create containers
 	// TODO: Log Create output and log to wait as containers might be built
-	if err = d.dockerCompose.Create(compose.DockerComposeCreateOptions{
-		Path:  instance.ComposePath(),
-		Build: true,
+	if err = d.withRegistryConfig(func(configDir string) error {
+		return d.dockerCompose.Create(compose.DockerComposeCreateOptions{
+			Path:      instance.ComposePath(),
+			Build:     true,
+			ConfigDir: configDir,
+		})
 	}); err != nil {
 		return instanceID, tID, err
 	}
@@ -881,6 +1198,15 @@ func (d *EgnDaemon) install(
 	return instanceID, tID, nil
 }
 
+// logChecksumProgress logs the progress of the package checksum verification.
+func (d *EgnDaemon) logChecksumProgress(file string, done, total int) {
+	d.logger.WithFields(log.Fields{
+		"file":  file,
+		"done":  done,
+		"total": total,
+	}).Debug("Verified package file checksum")
+}
+
 func (d *EgnDaemon) getPluginData(dataDir *data.DataDir, pkgHandler *package_handler.PackageHandler, instanceID string) (*data.Plugin, error) {
 	hasPlugin, err := pkgHandler.HasPlugin()
 	if err != nil {
@@ -898,8 +1224,45 @@ func (d *EgnDaemon) getPluginData(dataDir *data.DataDir, pkgHandler *package_han
 	}, nil
 }
 
+// replaceInstance backs up and uninstalls the instance with the given ID, if
+// there is one, to install a new instance with the same ID. It returns the ID
+// of the backup, or an empty string if there is no instance to replace. The
+// backup is logged so the replaced instance can be restored.
+func (d *EgnDaemon) replaceInstance(instanceId string) (string, error) {
+	if !d.dataDir.HasInstance(instanceId) {
+		return "", nil
+	}
+	logger := d.logger.WithField("instance_id", instanceId)
+	logger.Info("Instance already exists. Backing it up before replacing it")
+	backupId, err := d.Backup(instanceId, BackupOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to back up the existing instance %s: %w", instanceId, err)
+	}
+	logger.WithField("backup_id", backupId).Warn("Existing instance backed up. Restore the backup to recover it")
+	return backupId, d.uninstall(instanceId, true)
+}
+
+// restoreReplaced restores the instance replaced by a failed install from the
+// backup with the given ID, so the failed install does not leave the user
+// without the instance. It does nothing if backupId is empty. It returns the
+// install error, joined with the restore error if the restore fails.
+func (d *EgnDaemon) restoreReplaced(instanceId, backupId string, installErr error) error {
+	if backupId == "" {
+		return installErr
+	}
+	logger := d.logger.WithFields(log.Fields{"instance_id": instanceId, "backup_id": backupId})
+	logger.Warn("Install failed. Restoring the replaced instance")
+	if err := d.withRegistryConfig(func(configDir string) error {
+		return d.backupManager.RestoreInstance(backupId, nil, configDir)
+	}); err != nil {
+		return fmt.Errorf("%w. Failed to restore the replaced instance from backup %s: %w", installErr, backupId, err)
+	}
+	logger.Info("Replaced instance restored")
+	return installErr
+}
+
 func (d *EgnDaemon) postInstallation(instanceId string, tempDirID string, installErr error) error {
-	if installErr != nil && !errors.Is(installErr, ErrInstanceAlreadyExists) {
+	if installErr != nil && !errors.Is(installErr, ErrInstanceExists) {
 		// Cleanup if Install fails
 		if cerr := d.uninstall(instanceId, false); cerr != nil {
 			return fmt.Errorf("install failed: %w. Failed to cleanup after installation failure: %w", installErr, cerr)
@@ -921,36 +1284,211 @@ func (d *EgnDaemon) HasInstance(instanceID string) bool {
 	return d.dataDir.HasInstance(instanceID)
 }
 
+// InstanceVersion implements Daemon.InstanceVersion.
+func (d *EgnDaemon) InstanceVersion(instanceID string) (InstanceVersionInfo, error) {
+	if !d.dataDir.HasInstance(instanceID) {
+		return InstanceVersionInfo{}, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
+	}
+	instance, err := d.dataDir.Instance(instanceID)
+	if err != nil {
+		return InstanceVersionInfo{}, err
+	}
+	return InstanceVersionInfo{
+		InstanceID: instanceID,
+		Version:    instance.Version,
+		Commit:     instance.Commit,
+	}, nil
+}
+
 // Run implements Daemon.Run.
-func (d *EgnDaemon) Run(instanceID string) error {
-	instancePath, err := d.dataDir.InstancePath(instanceID)
+func (d *EgnDaemon) Run(ctx context.Context, instanceID string, options RunOptions) error {
+	instancePath, err := d.instancePath(instanceID)
 	if err != nil {
 		return err
 	}
+	startCtx, deadline := ctx, time.Time{}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		startCtx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+		deadline, _ = startCtx.Deadline()
+	}
+	// startErr turns the errors caused by the start timeout into
+	// ErrRunTimeout errors, removing the containers started so far.
 	composePath := path.Join(instancePath, "docker-compose.yml")
-	if err := d.dockerCompose.Up(compose.DockerComposeUpOptions{
-		Path: composePath,
+	startErr := func(err error) error {
+		if ctx.Err() != nil || !errors.Is(startCtx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		d.logger.WithField("instance_id", instanceID).Warn("Instance did not start in time, removing its containers")
+		if downErr := d.dockerCompose.Down(compose.DockerComposeDownOptions{Path: composePath}); downErr != nil {
+			return fmt.Errorf("%w: instance %s after %s: %w", ErrRunTimeout, instanceID, options.Timeout, downErr)
+		}
+		return fmt.Errorf("%w: instance %s after %s", ErrRunTimeout, instanceID, options.Timeout)
+	}
+
+	if err := d.runHook(startCtx, hooks.PreRun, instanceID, nil); err != nil {
+		return startErr(err)
+	}
+	d.logger.WithField("instance_id", instanceID).Debug("Starting instance")
+	if err := d.withRegistryConfig(func(configDir string) error {
+		return d.dockerCompose.Up(startCtx, compose.DockerComposeUpOptions{
+			Path:      composePath,
+			ConfigDir: configDir,
+		})
 	}); err != nil {
+		return startErr(err)
+	}
+	if err := startCtx.Err(); err != nil {
+		return startErr(err)
+	}
+
+	if err := d.addTarget(instanceID); err != nil {
+		return err
+	}
+
+	if options.Wait {
+		// The health check gets what is left of the start timeout. An
+		// unhealthy instance is left running to check its logs.
+		return d.waitHealthy(ctx, instanceID, time.Until(deadline))
+	}
+	return nil
+}
+
+// waitHealthy polls the health endpoint of the instance's API target until it
+// answers with the expected status code. If the endpoint is not healthy before
+// the timeout, ErrHealthCheckTimeout is returned. If the parent context is done
+// first, its error is returned instead. Instances without an API target are not
+// checked.
+func (d *EgnDaemon) waitHealthy(parent context.Context, instanceID string, timeout time.Duration) error {
+	instance, err := d.dataDir.Instance(instanceID)
+	if err != nil {
+		return err
+	}
+	if instance.APITarget == nil {
+		d.logger.WithField("instance_id", instanceID).Warn("Instance does not specify an API target, skipping health check")
+		return nil
+	}
+	healthPath := defaultHealthPath
+	expectedStatus := int(NodeHealthy)
+	if hc := instance.APITarget.HealthCheck; hc != nil {
+		if hc.Path != "" {
+			healthPath = hc.Path
+		}
+		if hc.Status != 0 {
+			expectedStatus = hc.Status
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var lastErr error
+	operation := func() error {
+		lastErr = d.probeHealth(ctx, instance, healthPath, expectedStatus)
+		if lastErr != nil {
+			d.logger.WithField("instance_id", instanceID).WithError(lastErr).Debug("Instance is not healthy yet")
+		}
+		return lastErr
+	}
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxInterval = 5 * time.Second
+	bo.MaxElapsedTime = 0 // Stop is driven by the context
+	if err := backoff.Retry(operation, backoff.WithContext(bo, ctx)); err != nil {
+		if parentErr := parent.Err(); parentErr != nil {
+			return parentErr
+		}
+		if lastErr == nil {
+			lastErr = err
+		}
+		return fmt.Errorf("%w: instance %s after %s: %w", ErrHealthCheckTimeout, instanceID, timeout, lastErr)
+	}
+	d.logger.WithFields(log.Fields{
+		"instance_id": instanceID,
+		"service":     instance.APITarget.Service,
+	}).Info("Instance is healthy")
+	return nil
+}
+
+// probeHealth makes a single request to the health endpoint of the instance's
+// API container and checks the response status code.
+func (d *EgnDaemon) probeHealth(ctx context.Context, instance *data.Instance, healthPath string, expectedStatus int) error {
+	psServices, err := d.dockerCompose.PS(compose.DockerComposePsOptions{
+		ServiceName: instance.APITarget.Service,
+		Path:        instance.ComposePath(),
+		Format:      "json",
+		All:         true,
+	})
+	if err != nil {
+		return err
+	}
+	if len(psServices) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoAPIContainer, instance.ID())
+	}
+	apiCtIP, err := d.docker.ContainerIP(psServices[0].Id)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s:%s%s", apiCtIP, instance.APITarget.Port, healthPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	return d.addTarget(instanceID)
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatusCode, resp.StatusCode)
+	}
+	return nil
 }
 
 // Stop implements Daemon.Stop.
-func (d *EgnDaemon) Stop(instanceID string) error {
-	instancePath, err := d.dataDir.InstancePath(instanceID)
+func (d *EgnDaemon) Stop(ctx context.Context, instanceID string) error {
+	instancePath, err := d.instancePath(instanceID)
 	if err != nil {
 		return err
 	}
 	composePath := path.Join(instancePath, "docker-compose.yml")
-	return d.dockerCompose.Stop(compose.DockerComposeStopOptions{
+	if err := d.dockerCompose.Stop(ctx, compose.DockerComposeStopOptions{
 		Path: composePath,
-	})
+	}); err != nil {
+		return err
+	}
+	return d.runHook(ctx, hooks.PostStop, instanceID, nil)
+}
+
+// instancePath returns the path of the instance with the given ID, or an
+// ErrInstanceNotFound error if there is no such instance.
+func (d *EgnDaemon) instancePath(instanceID string) (string, error) {
+	instancePath, err := d.dataDir.InstancePath(instanceID)
+	if errors.Is(err, data.ErrInstanceNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
+	}
+	return instancePath, err
+}
+
+// runHook runs the hook of the event for the instance with the given ID. The
+// hook metadata holds the instance ID besides the given metadata.
+func (d *EgnDaemon) runHook(ctx context.Context, event hooks.Event, instanceId string, metadata map[string]string) error {
+	hookMetadata := map[string]string{"instance_id": instanceId}
+	for k, v := range metadata {
+		hookMetadata[k] = v
+	}
+	return d.hooks.Run(ctx, event, hookMetadata)
 }
 
 // Uninstall implements Daemon.Uninstall.
 func (d *EgnDaemon) Uninstall(instanceID string) error {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return d.uninstall(instanceID, true)
 }
 
@@ -958,7 +1496,7 @@ func (d *EgnDaemon) uninstall(instanceID string, down bool) error {
 	instancePath, err := d.dataDir.InstancePath(instanceID)
 	if err != nil {
 		if errors.Is(err, data.ErrInstanceNotFound) {
-			log.Warnf("Instance %s not found. It may be due to a incomplete instance installation process.", instanceID)
+			d.logger.WithField("instance_id", instanceID).Warn("Instance not found. It may be due to a incomplete instance installation process.")
 			return nil
 		}
 		return err
@@ -970,7 +1508,7 @@ func (d *EgnDaemon) uninstall(instanceID string, down bool) error {
 
 	if err := d.removeTarget(instanceID); err != nil {
 		if errors.Is(err, monitoring.ErrNonexistingTarget) {
-			log.Warnf("Monitoring target for instance %s not found. It may be due to an incomplete instance installation process or because the instance was never started.", instanceID)
+			d.logger.WithField("instance_id", instanceID).Warn("Monitoring target for instance not found. It may be due to an incomplete instance installation process or because the instance was never started.")
 		} else {
 			return err
 		}
@@ -992,21 +1530,27 @@ func (d *EgnDaemon) uninstall(instanceID string, down bool) error {
 }
 
 // CheckHardwareRequirements implements Daemon.CheckHardwareRequirements
-func (d *EgnDaemon) CheckHardwareRequirements(req HardwareRequirements) (bool, error) {
-	metrics, err := hardwarechecker.GetMetrics()
+func (d *EgnDaemon) CheckHardwareRequirements(req HardwareRequirements) error {
+	metrics, err := d.hardwareMetrics()
 	if err != nil {
-		return false, err
+		return err
 	}
 	requirements := hardwarechecker.HardwareMetrics{
 		CPU:       float64(req.MinCPUCores),
 		RAM:       float64(req.MinRAM),
 		DiskSpace: float64(req.MinFreeSpace),
 	}
-	return metrics.Meets(requirements), nil
+	if shortfalls := metrics.Shortfalls(requirements); len(shortfalls) > 0 {
+		return fmt.Errorf("%w: %s", ErrInsufficientResources, strings.Join(shortfalls, ", "))
+	}
+	return nil
 }
 
 // RunPlugin implements Daemon.RunPlugin.
 func (d *EgnDaemon) RunPlugin(instanceId string, pluginArgs []string, options RunPluginOptions) error {
+	if !d.dataDir.HasInstance(instanceId) {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
 	instance, err := d.dataDir.Instance(instanceId)
 	if err != nil {
 		return err
@@ -1045,7 +1589,7 @@ func (d *EgnDaemon) RunPlugin(instanceId string, pluginArgs []string, options Ru
 		return err
 	}
 	if !ok {
-		err = d.docker.Pull(instance.Plugin.Image)
+		err = d.docker.Pull(instance.Plugin.Image, d.registryAuths.For(instance.Plugin.Image))
 		if err != nil {
 			return err
 		}
@@ -1054,23 +1598,27 @@ func (d *EgnDaemon) RunPlugin(instanceId string, pluginArgs []string, options Ru
 	if !options.NoDestroyImage {
 		defer func() {
 			if err := d.docker.ImageRemove(instance.Plugin.Image); err != nil {
-				log.Errorf("Failed to destroy plugin image %s: %v", instance.Plugin.Image, err)
+				d.logger.WithField("image", instance.Plugin.Image).WithError(err).Error("Failed to destroy plugin image")
 			}
 		}()
 	}
-	log.Infof("Running plugin with image %s on network %s", instance.Plugin.Image, network)
+	d.logger.WithFields(log.Fields{
+		"instance_id": instanceId,
+		"image":       instance.Plugin.Image,
+		"network":     network,
+	}).Info("Running plugin")
 	mounts := make([]docker.Mount, 0, len(options.Binds)+len(options.Volumes))
 	for src, dst := range options.Binds {
 		_, err := os.Stat(src)
 		if os.IsNotExist(err) {
 			if filepath.Ext(src) != "" {
-				return fmt.Errorf("bound file %s does not exist", src)
+				return fmt.Errorf("%w: bound file %s does not exist", ErrInvalidPluginBind, src)
 			}
 			if err := os.MkdirAll(src, 0o755); err != nil {
-				return fmt.Errorf("failed to create bound directory %s: %v", src, err)
+				return fmt.Errorf("%w: failed to create bound directory %s: %w", ErrInvalidPluginBind, src, err)
 			}
 		} else if err != nil {
-			return fmt.Errorf("failed to stat bound source %s: %v", src, err)
+			return fmt.Errorf("%w: failed to stat bound source %s: %w", ErrInvalidPluginBind, src, err)
 		}
 		mounts = append(mounts, docker.Mount{
 			Type:   docker.VolumeTypeBind,
@@ -1094,6 +1642,9 @@ func (d *EgnDaemon) RunPlugin(instanceId string, pluginArgs []string, options Ru
 
 // NodeLogs implements Daemon.NodeLogs.
 func (d *EgnDaemon) NodeLogs(ctx context.Context, w io.Writer, instanceID string, opts NodeLogsOptions) error {
+	if !d.dataDir.HasInstance(instanceID) {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
+	}
 	i, err := d.dataDir.Instance(instanceID)
 	if err != nil {
 		return err
@@ -1120,48 +1671,391 @@ func (d *EgnDaemon) NodeLogs(ctx context.Context, w io.Writer, instanceID string
 	})
 }
 
-func (d *EgnDaemon) Backup(instanceId string) (string, error) {
-	if !d.HasInstance(instanceId) {
-		return "", fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+// Doctor implements Daemon.Doctor.
+func (d *EgnDaemon) Doctor() []DoctorCheck {
+	checks := []DoctorCheck{
+		{Name: "docker", Err: d.docker.Ping()},
+		{Name: "data directory", Err: d.dataDir.CheckPermissions()},
 	}
-	log.Infof("Stopping instance %s", instanceId)
-	err := d.Stop(instanceId)
-	if err != nil {
-		return "", err
+
+	containers := DoctorCheck{Name: "monitoring containers"}
+	provisioning := DoctorCheck{Name: "provisioning files"}
+	ports := DoctorCheck{Name: "ports"}
+	installStatus, err := d.monitoringMgr.InstallationStatus()
+	switch {
+	case err != nil:
+		containers.Err = err
+		provisioning.Err = err
+		ports.Err = err
+	case installStatus != common.Installed:
+		containers.Skipped = "monitoring stack is not installed"
+		provisioning.Skipped = "monitoring stack is not installed"
+		ports.Err = d.monitoringMgr.CheckPorts()
+	default:
+		_, containers.Err = d.monitoringMgr.Status()
+		provisioning.Err = d.monitoringMgr.ValidateProvisioning()
+		if containers.Err == nil {
+			// The ports are in use by the monitoring stack itself
+			ports.Skipped = "monitoring stack is running"
+		} else {
+			ports.Err = d.monitoringMgr.CheckPorts()
+		}
 	}
-	return d.backupManager.BackupInstance(instanceId)
+	return append(checks, containers, provisioning, ports)
 }
 
-func (d *EgnDaemon) Restore(backupId string, run bool) error {
-	// Check if the backup exists
-	ok, err := d.dataDir.HasBackup(backupId)
+// minComposeVersion is the minimum version of the docker compose plugin
+// supported by egn.
+const minComposeVersion = "v2.0.0"
+
+// Close implements Daemon.Close.
+func (d *EgnDaemon) Close() error {
+	if d.dockerClient == nil {
+		return nil
+	}
+	return d.dockerClient.Close()
+}
+
+// CheckRuntime implements Daemon.CheckRuntime.
+func (d *EgnDaemon) CheckRuntime() error {
+	if err := d.docker.Ping(); err != nil {
+		return fmt.Errorf("%w: the docker daemon is not reachable, check that docker is installed and running and that the current user can access it: %w", ErrRuntimeUnavailable, err)
+	}
+	version, err := d.dockerCompose.Version()
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: docker compose is not available, install the docker compose plugin %s or later: %w", ErrRuntimeUnavailable, minComposeVersion, err)
 	}
-	if !ok {
-		return fmt.Errorf("%w: %s", ErrBackupNotFound, backupId)
+	semVersion := version
+	if !strings.HasPrefix(semVersion, "v") {
+		semVersion = "v" + semVersion
 	}
-	// Get backup information
-	backup, err := d.dataDir.Backup(backupId)
+	if !semver.IsValid(semVersion) || semver.Compare(semVersion, minComposeVersion) < 0 {
+		return fmt.Errorf("%w: docker compose %s is not supported, upgrade the docker compose plugin to %s or later", ErrRuntimeUnavailable, version, minComposeVersion)
+	}
+	return nil
+}
+
+// Prune implements Daemon.Prune.
+func (d *EgnDaemon) Prune(options PruneOptions) (PruneResult, error) {
+	result := PruneResult{DataDirs: []string{}, Images: []string{}}
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	// The compose projects of the instances are named after their directories.
+	// Only their images are pruned, including the ones of the orphan
+	// directories removed below.
+	projects, err := d.dataDir.InstanceDirs()
+	if err != nil {
+		return result, err
+	}
+	orphans, err := d.dataDir.OrphanInstances()
+	if err != nil {
+		return result, err
+	}
+	for _, orphan := range orphans {
+		if !options.DryRun {
+			d.logger.WithField("dir", orphan).Debug("Removing orphan data directory")
+			if err = d.dataDir.RemoveOrphanInstance(orphan); err != nil {
+				return result, err
+			}
+		}
+		result.DataDirs = append(result.DataDirs, orphan)
+	}
+
+	if !options.Images {
+		return result, nil
+	}
+	images, err := d.docker.DanglingImages(projects)
+	if err != nil {
+		return result, err
+	}
+	for _, image := range images {
+		if !options.DryRun {
+			if err = d.docker.ImageRemove(image); err != nil {
+				return result, err
+			}
+		}
+		result.Images = append(result.Images, image)
+	}
+	return result, nil
+}
+
+// Exec implements Daemon.Exec.
+func (d *EgnDaemon) Exec(instanceId string, cmd []string, opts ExecOptions) error {
+	if !d.HasInstance(instanceId) {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	instance, err := d.dataDir.Instance(instanceId)
 	if err != nil {
 		return err
 	}
-	// Check if the instance exists
-	if d.dataDir.HasInstance(backup.InstanceId) {
-		log.Infof("Instance %s already exists. Uninstalling it", backup.InstanceId)
-		err = d.Uninstall(backup.InstanceId)
+	service := opts.Service
+	if service == "" {
+		service, err = primaryService(instance)
 		if err != nil {
 			return err
 		}
-		log.Info("Instance uninstalled")
 	}
+	psServices, err := d.dockerCompose.PS(compose.DockerComposePsOptions{
+		Path:          instance.ComposePath(),
+		Format:        "json",
+		FilterRunning: true,
+		ServiceName:   service,
+	})
+	if err != nil {
+		return err
+	}
+	if len(psServices) == 0 {
+		return fmt.Errorf("%w: %s of instance %s", ErrServiceNotRunning, service, instanceId)
+	}
+	return d.docker.Exec(context.Background(), psServices[0].Id, cmd, docker.ExecOptions{
+		Interactive: opts.Interactive,
+		TTY:         opts.TTY,
+		Stdin:       opts.Stdin,
+		Stdout:      opts.Stdout,
+		Stderr:      opts.Stderr,
+	})
+}
+
+// primaryService returns the primary service of the instance, which is the
+// service of its API target or, without one, of its first monitoring target.
+func primaryService(instance *data.Instance) (string, error) {
+	if instance.APITarget != nil && instance.APITarget.Service != "" {
+		return instance.APITarget.Service, nil
+	}
+	for _, target := range instance.MonitoringTargets.Targets {
+		if target.Service != "" {
+			return target.Service, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s, set the service to use", ErrNoPrimaryService, instance.ID())
+}
+
+// GetConfig implements Daemon.GetConfig.
+func (d *EgnDaemon) GetConfig(instanceId string) ([]ConfigEntry, error) {
+	instance, options, err := d.instanceOptions(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	env, err := instance.Env()
+	if err != nil {
+		return nil, err
+	}
+	optionNames := make(map[string]string, len(options))
+	for _, o := range options {
+		optionNames[o.Target()] = o.Name()
+	}
+	entries := make([]ConfigEntry, 0, len(env))
+	for key, value := range env {
+		entries = append(entries, ConfigEntry{Key: key, Value: value, Option: optionNames[key]})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// SetConfig implements Daemon.SetConfig.
+func (d *EgnDaemon) SetConfig(instanceId, key, value string) error {
+	instance, options, err := d.instanceOptions(instanceId)
+	if err != nil {
+		return err
+	}
+	env, err := instance.Env()
+	if err != nil {
+		return err
+	}
+	// The .env file has a variable per line, without quoting
+	if strings.Contains(value, "\n") {
+		return fmt.Errorf("%w: %s: value can't contain line breaks", ErrInvalidConfigValue, key)
+	}
+	var option Option
+	for _, o := range options {
+		if o.Target() == key {
+			option = o
+			break
+		}
+	}
+	if option != nil {
+		if err := option.Set(value); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidConfigValue, key, err)
+		}
+	} else if _, ok := env[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownConfigKey, key)
+	}
+	return instance.SetEnv(map[string]string{key: value})
+}
+
+// labelKeyRegex matches the valid label keys. Labels are added to the metrics
+// of the instance, so their keys must be valid Prometheus label names.
+var labelKeyRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Label implements Daemon.Label.
+func (d *EgnDaemon) Label(instanceId string, set map[string]string, remove []string) (map[string]string, error) {
+	if !d.dataDir.HasInstance(instanceId) {
+		return nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	for key, value := range set {
+		if err := validateLabel(key, value); err != nil {
+			return nil, err
+		}
+	}
+	instance, err := d.dataDir.Instance(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	if len(set) == 0 && len(remove) == 0 {
+		return instance.Labels, nil
+	}
+
+	labels := maps.Clone(instance.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	maps.Copy(labels, set)
+	for _, key := range remove {
+		delete(labels, key)
+	}
+	if err := instance.SetLabels(labels); err != nil {
+		return nil, err
+	}
+	return instance.Labels, nil
+}
+
+// validateLabel checks that the label can be set on an instance and added to
+// its metrics.
+func validateLabel(key, value string) error {
+	if !labelKeyRegex.MatchString(key) || strings.HasPrefix(key, "__") {
+		return fmt.Errorf("%w: %q is not a valid key, keys must start with a letter or an underscore followed by letters, digits or underscores, and can't start with __", ErrInvalidLabel, key)
+	}
+	switch key {
+	case "job", "instance", monitoring.InstanceIDLabel, monitoring.CommitHashLabel, monitoring.AVSNameLabel, monitoring.AVSVersionLabel, monitoring.SpecVersionLabel:
+		return fmt.Errorf("%w: %s is reserved by egn", ErrInvalidLabel, key)
+	}
+	if value == "" || strings.ContainsAny(value, ",\n") {
+		return fmt.Errorf("%w: the value of %s must not be empty nor contain commas or line breaks", ErrInvalidLabel, key)
+	}
+	return nil
+}
+
+// instanceOptions returns the instance with the given ID and the options of its
+// profile.
+func (d *EgnDaemon) instanceOptions(instanceId string) (*data.Instance, []Option, error) {
+	if !d.dataDir.HasInstance(instanceId) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	instance, err := d.dataDir.Instance(instanceId)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := instance.ProfileFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	options, err := optionsFromProfile(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return instance, options, nil
+}
+
+// Backup implements Daemon.Backup. The backup is recorded in the metrics of
+// the daemon, including the ones taken by ScheduleBackup.
+func (d *EgnDaemon) Backup(instanceId string, options BackupOptions) (string, error) {
+	backupId, err := d.backup(instanceId, options)
+	d.observe(metrics.OperationBackup, err)
+	return backupId, err
+}
+
+func (d *EgnDaemon) backup(instanceId string, options BackupOptions) (string, error) {
+	if !d.HasInstance(instanceId) {
+		return "", fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	compression, err := backupCompression(options.Compression)
+	if err != nil {
+		return "", err
+	}
+	d.logger.WithField("instance_id", instanceId).Info("Stopping instance")
+	err = d.Stop(context.Background(), instanceId)
+	if err != nil {
+		return "", err
+	}
+	backupId, err := d.backupManager.BackupInstance(instanceId, compression, backupKeys(options.EncryptionKeyFile))
+	if err != nil {
+		return "", err
+	}
+	return backupId, d.runHook(context.Background(), hooks.PostBackup, instanceId, map[string]string{"backup_id": backupId})
+}
+
+// StreamBackup implements Daemon.StreamBackup. The backup is recorded in the
+// metrics of the daemon.
+func (d *EgnDaemon) StreamBackup(instanceId string, w io.Writer, options StreamBackupOptions) (*data.Backup, error) {
+	backup, err := d.streamBackup(instanceId, w, options)
+	d.observe(metrics.OperationBackup, err)
+	return backup, err
+}
+
+func (d *EgnDaemon) streamBackup(instanceId string, w io.Writer, options StreamBackupOptions) (*data.Backup, error) {
+	if !d.HasInstance(instanceId) {
+		return nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	// The hook runs once a consistent backup resumed the instance
+	backup, err := d.writeBackup(instanceId, w, options.Consistent)
+	if err != nil {
+		return nil, err
+	}
+	return backup, d.runHook(context.Background(), hooks.PostBackup, instanceId, map[string]string{"backup_id": backup.Id()})
+}
+
+// writeBackup writes the backup of the instance with the given ID to w,
+// pausing the instance meanwhile if consistent is true.
+func (d *EgnDaemon) writeBackup(instanceId string, w io.Writer, consistent bool) (backup *data.Backup, err error) {
+	if consistent {
+		var resume func() error
+		resume, err = d.pauseInstance(instanceId)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			err = errors.Join(err, resume())
+		}()
+	}
+	d.logger.WithField("instance_id", instanceId).Info("Streaming instance backup")
+	return d.dataDir.CreateBackup(instanceId, w)
+}
 
-	err = d.backupManager.RestoreInstance(backupId)
+// pauseInstance pauses the services of the instance with the given ID if it is
+// running, and returns a function that resumes them.
+func (d *EgnDaemon) pauseInstance(instanceId string) (resume func() error, err error) {
+	running, err := d.instanceRunning(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	if !running {
+		return func() error { return nil }, nil
+	}
+	instance, err := d.dataDir.Instance(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	composePath := instance.ComposePath()
+	d.logger.WithField("instance_id", instanceId).Info("Pausing instance")
+	if err := d.dockerCompose.Pause(compose.DockerComposePauseOptions{Path: composePath}); err != nil {
+		return nil, err
+	}
+	return func() error {
+		d.logger.WithField("instance_id", instanceId).Info("Resuming instance")
+		return d.dockerCompose.Unpause(compose.DockerComposeUnpauseOptions{Path: composePath})
+	}, nil
+}
+
+func (d *EgnDaemon) Restore(backupId string, options RestoreOptions) error {
+	instanceId, err := d.restore(backupId, options)
 	if err != nil {
 		return err
 	}
-	if run {
-		err = d.Run(backup.InstanceId)
+	if options.Run {
+		err = d.Run(context.Background(), instanceId, RunOptions{})
 		if err != nil {
 			return err
 		}
@@ -1169,6 +2063,85 @@ func (d *EgnDaemon) Restore(backupId string, run bool) error {
 	return nil
 }
 
+// restore restores the backup with the given ID, holding the operation lock,
+// and returns the ID of the restored instance.
+func (d *EgnDaemon) restore(backupId string, options RestoreOptions) (string, error) {
+	unlock, err := d.lockOperation()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// Check if the backup exists
+	ok, err := d.dataDir.HasBackup(backupId)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrBackupNotFound, backupId)
+	}
+	// Get backup information
+	keys := backupKeys(options.EncryptionKeyFile)
+	backup, err := d.dataDir.EncryptedBackup(backupId, keys)
+	if err != nil {
+		return "", err
+	}
+	instanceId := backup.InstanceId
+	if options.InstanceId != "" && options.InstanceId != backup.InstanceId {
+		// Restore as a new instance, never over an existing one
+		if _, _, err := data.ParseInstanceId(options.InstanceId); err != nil {
+			return "", err
+		}
+		if d.dataDir.HasInstance(options.InstanceId) {
+			return "", fmt.Errorf("%w: %s", ErrInstanceExists, options.InstanceId)
+		}
+		instanceId = options.InstanceId
+		err = d.withRegistryConfig(func(configDir string) error {
+			return d.backupManager.RestoreInstanceAs(backupId, instanceId, keys, configDir)
+		})
+	} else {
+		// Check if the instance exists
+		if d.dataDir.HasInstance(instanceId) {
+			d.logger.WithField("instance_id", instanceId).Info("Instance already exists. Uninstalling it")
+			err = d.uninstall(instanceId, true)
+			if err != nil {
+				return "", err
+			}
+			d.logger.WithField("instance_id", instanceId).Info("Instance uninstalled")
+		}
+		err = d.withRegistryConfig(func(configDir string) error {
+			return d.backupManager.RestoreInstance(backupId, keys, configDir)
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	return instanceId, nil
+}
+
+// backupCompression returns the codec of the given backup compression.
+func backupCompression(c Compression) (data.Compression, error) {
+	switch c {
+	case CompressionNone:
+		return data.CompressionNone, nil
+	case CompressionGzip:
+		return data.CompressionGzip, nil
+	case CompressionZstd:
+		return data.CompressionZstd, nil
+	default:
+		return data.CompressionNone, fmt.Errorf("%w: %s", ErrInvalidCompression, c)
+	}
+}
+
+// backupKeys returns the provider of the key in the given key file, or nil if
+// the path is empty.
+func backupKeys(keyFile string) data.KeyProvider {
+	if keyFile == "" {
+		return nil
+	}
+	return data.NewFileKeyProvider(afero.NewOsFs(), keyFile)
+}
+
 func (d *EgnDaemon) BackupList() ([]BackupInfo, error) {
 	backups, err := d.dataDir.BackupList()
 	if err != nil {
@@ -1184,15 +2157,230 @@ func (d *EgnDaemon) BackupList() ([]BackupInfo, error) {
 			Id:        b.Id(),
 			Instance:  b.InstanceId,
 			Timestamp: b.Timestamp,
+			Age:       b.Age(),
 			SizeBytes: size,
 			Version:   b.Version,
 			Commit:    b.Commit,
 			Url:       b.Url,
+			Encrypted: b.Encrypted,
 		}
 	}
 	return out, nil
 }
 
+// SetBackupDir implements Daemon.SetBackupDir.
+func (d *EgnDaemon) SetBackupDir(path string) error {
+	return d.dataDir.SetBackupDir(path)
+}
+
+// SetPullRetries implements Daemon.SetPullRetries.
+func (d *EgnDaemon) SetPullRetries(retries int) {
+	d.pullRetries = retries
+}
+
+// SetMonitoringPermissions implements Daemon.SetMonitoringPermissions.
+func (d *EgnDaemon) SetMonitoringPermissions(options MonitoringPermissions) error {
+	var (
+		perms data.StackPermissions
+		err   error
+	)
+	if perms.DirMode, err = parseFileMode(options.DirMode); err != nil {
+		return fmt.Errorf("%w: directory mode: %w", ErrInvalidMonitoringPermissions, err)
+	}
+	if perms.FileMode, err = parseFileMode(options.FileMode); err != nil {
+		return fmt.Errorf("%w: file mode: %w", ErrInvalidMonitoringPermissions, err)
+	}
+	if options.Owner != "" {
+		uid, gid, ok := strings.Cut(options.Owner, ":")
+		uidInt, uidErr := strconv.ParseUint(uid, 10, 31)
+		gidInt, gidErr := strconv.ParseUint(gid, 10, 31)
+		if !ok || uidErr != nil || gidErr != nil {
+			return fmt.Errorf("%w: owner %q is not in the uid:gid form", ErrInvalidMonitoringPermissions, options.Owner)
+		}
+		perms.Owner = &data.Owner{UID: int(uidInt), GID: int(gidInt)}
+	}
+	d.dataDir.SetMonitoringStackPermissions(perms)
+	d.monitoringMgr.SetStackPermissions(perms)
+	return nil
+}
+
+// parseFileMode parses an octal permission mode, like 0750. An empty mode is
+// the zero mode, which keeps the default one.
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%q is not an octal permission mode", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// SetGitOptions implements Daemon.SetGitOptions.
+func (d *EgnDaemon) SetGitOptions(options GitOptions) error {
+	var caBundle []byte
+	if options.CABundle != "" {
+		var err error
+		caBundle, err = os.ReadFile(options.CABundle)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidGitOptions, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+			return fmt.Errorf("%w: no certificates found in CA bundle %s", ErrInvalidGitOptions, options.CABundle)
+		}
+	}
+	var headers http.Header
+	for _, header := range options.ExtraHeaders {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("%w: extra header %q is not in the \"Name: value\" form", ErrInvalidGitOptions, header)
+		}
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	d.gitCABundle = caBundle
+	d.gitHeaders = headers
+	return nil
+}
+
+// SetRegistryOptions implements Daemon.SetRegistryOptions.
+func (d *EgnDaemon) SetRegistryOptions(options RegistryOptions) error {
+	auths := make(docker.RegistryAuths)
+	if options.ConfigPath != "" {
+		configAuths, err := docker.LoadRegistryAuths(options.ConfigPath)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRegistryOptions, err)
+		}
+		for host, auth := range configAuths {
+			auths[host] = auth
+		}
+	}
+	for _, registryAuth := range options.Auths {
+		host, credentials, ok := strings.Cut(registryAuth, "=")
+		username, password, hasPassword := strings.Cut(credentials, ":")
+		if !ok || host == "" || !hasPassword || username == "" {
+			return fmt.Errorf("%w: registry auth is not in the \"registry=username:password\" form", ErrInvalidRegistryOptions)
+		}
+		auths[docker.NormalizeRegistry(host)] = docker.RegistryAuth{Username: username, Password: password}
+	}
+	if len(auths) == 0 {
+		auths = nil
+	}
+	d.registryAuths = auths
+	return nil
+}
+
+// withRegistryConfig calls fn with a docker client config directory holding
+// the registry credentials, removed once fn returns, so the docker compose
+// commands can pull the images of private registries. The directory is a copy
+// of the default docker config with the credentials added. fn gets an empty
+// directory, to use the default docker config, if there are no credentials.
+func (d *EgnDaemon) withRegistryConfig(fn func(configDir string) error) error {
+	if len(d.registryAuths) == 0 {
+		return fn("")
+	}
+	baseDir, err := docker.ConfigDir()
+	if err != nil {
+		return err
+	}
+	configDir, err := os.MkdirTemp("", "egn-registry-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(configDir)
+	if err := docker.WriteDockerConfig(configDir, baseDir, d.registryAuths); err != nil {
+		return err
+	}
+	return fn(configDir)
+}
+
+// PruneBackups implements Daemon.PruneBackups.
+func (d *EgnDaemon) PruneBackups(instanceId string, retention RetentionPolicy, options PruneBackupsOptions) ([]PrunedBackup, error) {
+	backups, err := d.dataDir.EncryptedBackupList(backupKeys(options.EncryptionKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	var instanceBackups []data.Backup
+	for _, b := range backups {
+		if b.InstanceId == instanceId {
+			instanceBackups = append(instanceBackups, b)
+		}
+	}
+
+	var removed []PrunedBackup
+	for _, b := range backupsToPrune(instanceBackups, retention, time.Now()) {
+		pruned := PrunedBackup{Id: b.Id(), Path: d.dataDir.BackupPath(b.Id())}
+		if !options.DryRun {
+			if err := d.dataDir.RemoveBackup(b.Id()); err != nil {
+				return removed, err
+			}
+			d.logger.WithField("backup_id", b.Id()).Info("Backup pruned")
+		}
+		removed = append(removed, pruned)
+	}
+	return removed, nil
+}
+
+// ScheduleBackup implements Daemon.ScheduleBackup.
+func (d *EgnDaemon) ScheduleBackup(ctx context.Context, instanceId string, interval time.Duration, retention RetentionPolicy, options BackupOptions) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidBackupInterval, interval)
+	}
+	if !d.HasInstance(instanceId) {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceId)
+	}
+	d.logger.WithFields(log.Fields{
+		"instance_id": instanceId,
+		"interval":    interval.String(),
+	}).Info("Scheduling backups")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	runScheduled(ctx, ticker.C, d.logger, func() {
+		d.backupCycle(instanceId, retention, options)
+	})
+	return nil
+}
+
+// backupCycle backs up the instance with the given ID with the given options
+// and prunes its backups, logging the outcome. If the instance was running before the backup, it is
+// started again once the backup is done.
+func (d *EgnDaemon) backupCycle(instanceId string, retention RetentionPolicy, options BackupOptions) {
+	logger := d.logger.WithField("instance_id", instanceId)
+	running, err := d.instanceRunning(instanceId)
+	if err != nil {
+		logger.Errorf("Scheduled backup failed: %v", err)
+		return
+	}
+
+	backupId, err := d.Backup(instanceId, options)
+	if running {
+		// Not tied to the schedule context, so an instance that was running is
+		// started again even if the schedule is being cancelled.
+		if runErr := d.Run(context.Background(), instanceId, RunOptions{}); runErr != nil {
+			logger.Errorf("Failed to start instance after backup: %v", runErr)
+		}
+	}
+	if err != nil {
+		logger.Errorf("Scheduled backup failed: %v", err)
+		return
+	}
+
+	pruned, err := d.PruneBackups(instanceId, retention, PruneBackupsOptions{EncryptionKeyFile: options.EncryptionKeyFile})
+	if err != nil {
+		logger.WithField("backup_id", backupId).Errorf("Backup created but pruning failed: %v", err)
+		return
+	}
+	logger.WithFields(log.Fields{
+		"backup_id": backupId,
+		"pruned":    len(pruned),
+	}).Info("Scheduled backup completed")
+}
+
 func tempID(url string) string {
 	tempHash := sha256.Sum256([]byte(url))
 	return hex.EncodeToString(tempHash[:])
@@ -1221,7 +2409,7 @@ func (d *EgnDaemon) monitoringTargetsEndpoints(serviceNames []string, composePat
 	// Validate that all monitoring targets were found
 	for _, serviceName := range serviceNames {
 		if _, ok := monitoringTargets[serviceName]; !ok {
-			return nil, fmt.Errorf("monitoring target %s not found, there is not such service running in the docker compose stack", serviceName)
+			return nil, fmt.Errorf("%w: %s, there is not such service running in the docker compose stack", ErrMonitoringTargetNotFound, serviceName)
 		}
 	}
 
@@ -1250,7 +2438,7 @@ func (d *EgnDaemon) addTarget(instanceID string) error {
 	// Check if the monitoring stack is running.
 	status, err := d.monitoringMgr.Status()
 	if err != nil {
-		return fmt.Errorf("monitoring stack status: unknown. Got error: %v", err)
+		return fmt.Errorf("monitoring stack status: unknown. Got error: %w", err)
 	}
 	// If the monitoring stack is not running, skip.
 	if status != common.Running && status != common.Restarting {
@@ -1290,22 +2478,62 @@ func (d *EgnDaemon) addTarget(instanceID string) error {
 			return err
 		}
 
-		labels := map[string]string{
+		// The labels of the instance are added to its metrics, but the egn
+		// labels take precedence
+		labels := maps.Clone(instance.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		maps.Copy(labels, map[string]string{
 			monitoring.InstanceIDLabel:  instanceID,
 			monitoring.CommitHashLabel:  instance.Commit,
 			monitoring.AVSNameLabel:     instance.Name,
 			monitoring.AVSVersionLabel:  instance.Version,
 			monitoring.SpecVersionLabel: instance.SpecVersion,
-		}
+		})
 		if err = d.monitoringMgr.AddTarget(types.MonitoringTarget{
-			Host: endpoint,
-			Port: uint16(port),
-			Path: target.Path,
+			Host:   endpoint,
+			Port:   uint16(port),
+			Path:   target.Path,
+			Scheme: target.Scheme,
+
+			ScrapeTimeout: target.ScrapeTimeout,
+			HonorLabels:   target.HonorLabels,
 		}, labels, networks[0]); err != nil {
 			return err
 		}
 	}
 
+	// Add the alerting rules shipped with the package
+	if len(instance.MonitoringTargets.Rules) > 0 {
+		rules := make([][]byte, 0, len(instance.MonitoringTargets.Rules))
+		for _, rulePath := range instance.MonitoringTargets.Rules {
+			rule, err := instance.ReadFile(rulePath)
+			if err != nil {
+				return err
+			}
+			rules = append(rules, rule)
+		}
+		if err = d.monitoringMgr.AddRules(instanceID, rules); err != nil {
+			return err
+		}
+	}
+
+	// Add the dashboards of the instance profile
+	if len(instance.MonitoringTargets.Dashboards) > 0 {
+		dashboards := make(map[string][]byte, len(instance.MonitoringTargets.Dashboards))
+		for _, dashboardPath := range instance.MonitoringTargets.Dashboards {
+			dashboard, err := instance.ReadFile(dashboardPath)
+			if err != nil {
+				return err
+			}
+			dashboards[filepath.Base(dashboardPath)] = dashboard
+		}
+		if err = d.monitoringMgr.AddDashboards(instanceID, dashboards); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1323,7 +2551,7 @@ func (d *EgnDaemon) removeTarget(instanceID string) error {
 	// Check if the monitoring stack is running.
 	status, err := d.monitoringMgr.Status()
 	if err != nil {
-		return fmt.Errorf("monitoring stack status: unknown. Got error: %v", err)
+		return fmt.Errorf("monitoring stack status: unknown. Got error: %w", err)
 	}
 	// If the monitoring stack is not running, skip.
 	if status != common.Running && status != common.Restarting {
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -15,6 +17,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	log "github.com/sirupsen/logrus"
@@ -27,9 +30,12 @@ import (
 	"github.com/NethermindEth/eigenlayer/internal/compose"
 	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/docker"
+	hardwarechecker "github.com/NethermindEth/eigenlayer/internal/hardware_checker"
+	"github.com/NethermindEth/eigenlayer/internal/hooks"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
 	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
 	"github.com/NethermindEth/eigenlayer/internal/package_handler"
+	"github.com/NethermindEth/eigenlayer/internal/package_handler/testdata"
 	"github.com/NethermindEth/eigenlayer/pkg/daemon/mocks"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
@@ -214,7 +220,7 @@ func TestInitMonitoring(t *testing.T) {
 				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
 				gomock.InOrder(
 					monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
-					monitoringMgr.EXPECT().InstallStack().Return(nil),
+					monitoringMgr.EXPECT().InstallStack(gomock.Any()).Return(nil),
 					monitoringMgr.EXPECT().Status().Return(common.Running, nil),
 					monitoringMgr.EXPECT().Init().Return(nil),
 				)
@@ -227,7 +233,7 @@ func TestInitMonitoring(t *testing.T) {
 				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
 				gomock.InOrder(
 					monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
-					monitoringMgr.EXPECT().InstallStack().Return(monitoring.ErrInstallingMonitoringMngr),
+					monitoringMgr.EXPECT().InstallStack(gomock.Any()).Return(monitoring.ErrInstallingMonitoringMngr),
 					monitoringMgr.EXPECT().Cleanup(true).Return(nil),
 				)
 				return monitoringMgr
@@ -240,7 +246,7 @@ func TestInitMonitoring(t *testing.T) {
 				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
 				gomock.InOrder(
 					monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
-					monitoringMgr.EXPECT().InstallStack().Return(monitoring.ErrInstallingMonitoringMngr),
+					monitoringMgr.EXPECT().InstallStack(gomock.Any()).Return(monitoring.ErrInstallingMonitoringMngr),
 					monitoringMgr.EXPECT().Cleanup(true).Return(errors.New("cleanup error")),
 				)
 				return monitoringMgr
@@ -253,7 +259,7 @@ func TestInitMonitoring(t *testing.T) {
 				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
 				gomock.InOrder(
 					monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
-					monitoringMgr.EXPECT().InstallStack().Return(errors.New("init error")),
+					monitoringMgr.EXPECT().InstallStack(gomock.Any()).Return(errors.New("init error")),
 				)
 				return monitoringMgr
 			},
@@ -393,10 +399,10 @@ func TestInitMonitoring(t *testing.T) {
 			monitoringMgr := tt.mocker(t, ctrl)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeMgr, dockerMgr, monitoringMgr, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeMgr, dockerMgr, monitoringMgr, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
-			err = daemon.InitMonitoring(true, true)
+			err = daemon.InitMonitoring(context.Background(), true, true)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -485,7 +491,7 @@ func TestCleanMonitoring(t *testing.T) {
 			monitoringMgr := tt.mocker(t, ctrl)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeMgr, dockerMgr, monitoringMgr, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeMgr, dockerMgr, monitoringMgr, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			err = daemon.CleanMonitoring()
@@ -498,6 +504,253 @@ func TestCleanMonitoring(t *testing.T) {
 	}
 }
 
+func TestReconcileMonitoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		mocker  func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager
+		wantErr error
+	}{
+		{
+			name: "not installed, nothing to do",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+				return monitoringMgr
+			},
+		},
+		{
+			name: "installed but not running",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				gomock.InOrder(
+					monitoringMgr.EXPECT().InstallationStatus().Return(common.Installed, nil),
+					monitoringMgr.EXPECT().Status().Return(common.Created, nil),
+				)
+				return monitoringMgr
+			},
+			wantErr: ErrMonitoringStackNotRunning,
+		},
+		{
+			name: "running, reconciles with installed instances",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				gomock.InOrder(
+					monitoringMgr.EXPECT().InstallationStatus().Return(common.Installed, nil),
+					monitoringMgr.EXPECT().Status().Return(common.Running, nil),
+					monitoringMgr.EXPECT().ReconcileTargets([]string{"mock-avs-default", "mock-avs-second"}).Return(nil),
+				)
+				return monitoringMgr
+			},
+		},
+		{
+			name: "reconcile error",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				gomock.InOrder(
+					monitoringMgr.EXPECT().InstallationStatus().Return(common.Installed, nil),
+					monitoringMgr.EXPECT().Status().Return(common.Running, nil),
+					monitoringMgr.EXPECT().ReconcileTargets(gomock.Any()).Return(assert.AnError),
+				)
+				return monitoringMgr
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			composeMgr := mocks.NewMockComposeManager(ctrl)
+			dockerMgr := mocks.NewMockDockerManager(ctrl)
+			backupMgr := mocks.NewMockBackupManager(ctrl)
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+
+			afs := afero.NewMemMapFs()
+			dataDir, err := data.NewDataDir("/tmp", afs, locker)
+			require.NoError(t, err)
+			for _, tag := range []string{"default", "second"} {
+				initInstanceDir(t, afs, "/tmp", "mock-avs-"+tag, `{
+					"name": "mock-avs",
+					"tag": "`+tag+`",
+					"version": "v0.1.0",
+					"profile": "option-returner",
+					"url": "https://github.com/NethermindEth/mock-avs-pkg"
+				}`)
+			}
+
+			daemon, err := NewEgnDaemon(dataDir, composeMgr, dockerMgr, tt.mocker(t, ctrl), backupMgr, locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			err = daemon.ReconcileMonitoring()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// monitoringTree returns the files of the monitoring stack in the data
+// directory, with their content, keyed by their path relative to the stack.
+func monitoringTree(t *testing.T, dataDirPath string) map[string]string {
+	root := filepath.Join(dataDirPath, "monitoring")
+	tree := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		relPath, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+		if entry.IsDir() || relPath == ".lock" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		tree[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	}))
+	return tree
+}
+
+func TestExportImportMonitoring(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	afs := afero.NewOsFs()
+	newDaemon := func(dataDirPath string, monitoringMgr MonitoringManager) *EgnDaemon {
+		dataDir, err := data.NewDataDir(dataDirPath, afs, locker.NewFLock())
+		require.NoError(t, err)
+		daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), monitoringMgr, mocks.NewMockBackupManager(ctrl), locker.NewFLock(), log.StandardLogger())
+		require.NoError(t, err)
+		return daemon
+	}
+
+	// The monitoring stack of the source host
+	srcPath := t.TempDir()
+	srcMonitoring := mocks.NewMockMonitoringManager(ctrl)
+	srcMonitoring.EXPECT().InstallationStatus().Return(common.Installed, nil)
+	src := newDaemon(srcPath, srcMonitoring)
+	stack, err := src.dataDir.MonitoringStack()
+	require.NoError(t, err)
+	for path, content := range map[string]string{
+		".env":                      "PROM_PORT=9090\n",
+		"docker-compose.yml":        "services: {}\n",
+		"prometheus/prometheus.yml": "scrape_configs: []\n",
+		"prometheus/rules/mock-avs-default/rules.yml":          "groups: []\n",
+		"grafana/provisioning/datasources/datasource.yml":      "datasources: []\n",
+		"grafana/data/dashboards/mock-avs-default/node.json":   `{"uid":"node"}`,
+		"grafana/data/dashboards/extra/operator-overview.json": `{"uid":"overview"}`,
+	} {
+		require.NoError(t, stack.CreateDir(filepath.Dir(path)))
+		require.NoError(t, stack.WriteFile(path, []byte(content)))
+	}
+	var bundle bytes.Buffer
+	require.NoError(t, src.ExportMonitoring(&bundle))
+
+	// The bundle is imported in a fresh data directory of another host
+	dstPath := t.TempDir()
+	dstMonitoring := mocks.NewMockMonitoringManager(ctrl)
+	dstMonitoring.EXPECT().Status().Return(common.Unknown, assert.AnError)
+	dst := newDaemon(dstPath, dstMonitoring)
+	require.NoError(t, dst.ImportMonitoring(bytes.NewReader(bundle.Bytes())))
+	assert.Equal(t, monitoringTree(t, srcPath), monitoringTree(t, dstPath))
+	assert.Len(t, monitoringTree(t, dstPath), 7)
+}
+
+func TestExportImportMonitoringError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dataDir, err := data.NewDataDir(t.TempDir(), afero.NewOsFs(), locker.NewFLock())
+	require.NoError(t, err)
+	monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), monitoringMgr, mocks.NewMockBackupManager(ctrl), locker.NewFLock(), log.StandardLogger())
+	require.NoError(t, err)
+
+	monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+	err = daemon.ExportMonitoring(io.Discard)
+	assert.ErrorIs(t, err, ErrMonitoringStackNotInstalled)
+
+	monitoringMgr.EXPECT().Status().Return(common.Running, nil)
+	err = daemon.ImportMonitoring(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrMonitoringStackRunning)
+
+	monitoringMgr.EXPECT().Status().Return(common.Created, nil)
+	err = daemon.ImportMonitoring(&bytes.Buffer{})
+	assert.ErrorIs(t, err, data.ErrInvalidMonitoringBundle)
+}
+
+func TestMonitoringStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		mocker  func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager
+		want    MonitoringStatus
+		wantErr error
+	}{
+		{
+			name: "not installed",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				monitoringMgr.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+				return monitoringMgr
+			},
+			wantErr: ErrMonitoringStackNotInstalled,
+		},
+		{
+			name: "installed",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				gomock.InOrder(
+					monitoringMgr.EXPECT().InstallationStatus().Return(common.Installed, nil),
+					monitoringMgr.EXPECT().StackStatus().Return(monitoring.StackStatus{
+						Services: []monitoring.ServiceStatus{
+							{Name: monitoring.GrafanaContainerName, Running: true, Ports: []string{"3000"}},
+							{Name: monitoring.PrometheusContainerName, Running: true, Ports: []string{"9090"}},
+						},
+						Targets: monitoring.TargetsHealth{Active: 3, Up: 2, Down: 1},
+					}, nil),
+				)
+				return monitoringMgr
+			},
+			want: MonitoringStatus{
+				Services: []MonitoringServiceStatus{
+					{Name: monitoring.GrafanaContainerName, Running: true, Ports: []string{"3000"}},
+					{Name: monitoring.PrometheusContainerName, Running: true, Ports: []string{"9090"}},
+				},
+				Targets: MonitoringTargets{Active: 3, Up: 2, Down: 1},
+			},
+		},
+		{
+			name: "stack status error",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockMonitoringManager {
+				monitoringMgr := mocks.NewMockMonitoringManager(ctrl)
+				gomock.InOrder(
+					monitoringMgr.EXPECT().InstallationStatus().Return(common.Installed, nil),
+					monitoringMgr.EXPECT().StackStatus().Return(monitoring.StackStatus{}, assert.AnError),
+				)
+				return monitoringMgr
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/tmp", afero.NewMemMapFs(), locker)
+			require.NoError(t, err)
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), tt.mocker(t, ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			status, err := daemon.MonitoringStatus()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
+
 func TestPull(t *testing.T) {
 	afs := afero.NewOsFs()
 
@@ -595,10 +848,10 @@ func TestPull(t *testing.T) {
 			dataDir := tt.mocker(t, locker)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, nil, nil, nil, nil, locker)
+			daemon, err := NewEgnDaemon(dataDir, nil, nil, nil, nil, locker, log.StandardLogger())
 			require.NoError(t, err)
 
-			result, err := daemon.Pull(tt.url, tt.ref, tt.force)
+			result, err := daemon.Pull(context.Background(), tt.url, tt.ref, tt.force)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -623,6 +876,47 @@ func TestPull(t *testing.T) {
 	}
 }
 
+func TestPullCache(t *testing.T) {
+	// Local repository of a package with a tagged version
+	pkgDir := "packages/good-profiles"
+	root := t.TempDir()
+	testdata.SetupDir(t, pkgDir, root, afero.NewOsFs())
+	repoPath := filepath.Join(root, pkgDir)
+	require.NoError(t, package_handler.GenerateChecksums(repoPath))
+	for _, args := range [][]string{
+		{"init"},
+		{"add", "-A"},
+		{"commit", "-m", "Initial commit"},
+		{"tag", "-a", "v0.1.0", "-m", "Version: v0.1.0"},
+	} {
+		args = append([]string{"-C", repoPath, "-c", "user.name=user", "-c", "user.email=user@email.com"}, args...)
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+
+	ctrl := gomock.NewController(t)
+	dataDir, err := data.NewDataDir(t.TempDir(), afero.NewOsFs(), mock_locker.NewMockLocker(ctrl))
+	require.NoError(t, err)
+	daemon, err := NewEgnDaemon(dataDir, nil, nil, nil, nil, nil, log.StandardLogger())
+	require.NoError(t, err)
+
+	// The first pull clones the package and caches it
+	ref := PullTarget{Version: "v0.1.0"}
+	want, err := daemon.Pull(context.Background(), repoPath, ref, false)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0", want.Version)
+
+	// The next pulls are copied from the cache, even when the repository is
+	// gone, unless they are forced
+	require.NoError(t, os.RemoveAll(repoPath))
+	got, err := daemon.Pull(context.Background(), repoPath, ref, false)
+	require.NoError(t, err)
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Commit, got.Commit)
+	assert.Len(t, got.Options, len(want.Options))
+	_, err = daemon.Pull(context.Background(), repoPath, ref, true)
+	assert.Error(t, err)
+}
+
 func Test_MergeOptions(t *testing.T) {
 	tc := []struct {
 		name          string
@@ -844,6 +1138,7 @@ func TestInstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 				)
 			},
 		},
@@ -875,6 +1170,7 @@ func TestInstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-specific", ".lock")).Return(locker),
 				)
 			},
 		},
@@ -1065,11 +1361,11 @@ func TestInstall(t *testing.T) {
 			tt.mocker(tmp, composeManager, dockerManager, locker, monitoringManager)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, nil, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, nil, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			// Pull the package
-			pullResult, err := daemon.Pull(tt.options.URL, PullTarget{Version: tt.options.Version}, true)
+			pullResult, err := daemon.Pull(context.Background(), tt.options.URL, PullTarget{Version: tt.options.Version}, true)
 			require.NoError(t, err)
 			tt.options.Options = make([]Option, 0)
 			for _, option := range pullResult.Options[tt.options.Profile] {
@@ -1104,7 +1400,17 @@ func TestInstall(t *testing.T) {
 				}
 			} else {
 				require.NoError(t, err)
-				assert.Equal(t, tt.want, result)
+				assert.Equal(t, tt.want, result.InstanceID)
+				assert.Equal(t, tt.options.Version, result.Version)
+				assert.Equal(t, tt.options.URL, result.URL)
+				assert.Equal(t, tt.options.Profile, result.Profile)
+				assert.Equal(t, filepath.Join(tmp, "nodes", tt.want), result.DataDir)
+				endpoints := make([]string, 0, len(tt.monitoringTargets.Targets))
+				for _, target := range tt.monitoringTargets.Targets {
+					endpoints = append(endpoints, target.Service+":"+target.Port+target.Path)
+				}
+				assert.Equal(t, endpoints, result.MonitoringEndpoints)
+				assert.Equal(t, []string{}, result.Dashboards)
 
 				// Check the instance was installed
 				exists, err := afero.DirExists(afs, filepath.Join(tmp, "nodes", tt.want))
@@ -1136,6 +1442,163 @@ func TestInstall(t *testing.T) {
 	}
 }
 
+func TestInstallExistingInstance(t *testing.T) {
+	ts := []struct {
+		name     string
+		force    bool
+		mocker   func(c *mocks.MockComposeManager, m *mocks.MockMonitoringManager, b *mocks.MockBackupManager, restore func())
+		err      error
+		restored bool
+		lost     bool
+	}{
+		{
+			name: "without force",
+			mocker: func(c *mocks.MockComposeManager, m *mocks.MockMonitoringManager, b *mocks.MockBackupManager, restore func()) {
+			},
+			err: ErrInstanceExists,
+		},
+		{
+			name:     "with force",
+			force:    true,
+			restored: true,
+			mocker: func(c *mocks.MockComposeManager, m *mocks.MockMonitoringManager, b *mocks.MockBackupManager, restore func()) {
+				composePath := "/egn/nodes/mock-avs-default/docker-compose.yml"
+				gomock.InOrder(
+					c.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: composePath}).Return(nil),
+					b.EXPECT().BackupInstance("mock-avs-default", data.CompressionNone, nil).Return("mock-avs-default-1696317683", nil),
+					m.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
+					c.EXPECT().Down(compose.DockerComposeDownOptions{Path: composePath, Volumes: true}).Return(nil),
+					// The new instance fails to install, so the replaced one is restored
+					b.EXPECT().RestoreInstance("mock-avs-default-1696317683", nil, "").DoAndReturn(func(string, data.KeyProvider, string) error {
+						restore()
+						return nil
+					}),
+				)
+			},
+		},
+		{
+			name:  "with force, restore error",
+			force: true,
+			mocker: func(c *mocks.MockComposeManager, m *mocks.MockMonitoringManager, b *mocks.MockBackupManager, restore func()) {
+				gomock.InOrder(
+					c.EXPECT().Stop(gomock.Any(), gomock.Any()).Return(nil),
+					b.EXPECT().BackupInstance("mock-avs-default", data.CompressionNone, nil).Return("mock-avs-default-1696317683", nil),
+					m.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
+					c.EXPECT().Down(gomock.Any()).Return(nil),
+					b.EXPECT().RestoreInstance("mock-avs-default-1696317683", nil, "").Return(assert.AnError),
+				)
+			},
+			err:  assert.AnError,
+			lost: true,
+		},
+		{
+			name:  "with force, backup error",
+			force: true,
+			mocker: func(c *mocks.MockComposeManager, m *mocks.MockMonitoringManager, b *mocks.MockBackupManager, restore func()) {
+				gomock.InOrder(
+					c.EXPECT().Stop(gomock.Any(), gomock.Any()).Return(nil),
+					b.EXPECT().BackupInstance("mock-avs-default", data.CompressionNone, nil).Return("", assert.AnError),
+				)
+			},
+			err: assert.AnError,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			state := []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default"}`)
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", state, 0o644))
+			// The package is not pulled, so the new instance is never installed
+			require.NoError(t, afs.MkdirAll(filepath.Join("/egn/temp", tempID(common.MockAvsPkg.Repo())), 0o755))
+
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+			backupManager := mocks.NewMockBackupManager(ctrl)
+			tt.mocker(composeManager, monitoringManager, backupManager, func() {
+				require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+				require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", state, 0o644))
+			})
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), monitoringManager, backupManager, locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			_, err = daemon.Install(InstallOptions{
+				Name:    MockAVSName,
+				URL:     common.MockAvsPkg.Repo(),
+				Version: common.MockAvsPkg.Version(),
+				Profile: "option-returner",
+				Tag:     "default",
+				Force:   tt.force,
+			})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				// The package was not pulled, so the install fails after
+				// replacing the existing instance
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrInstanceExists)
+			}
+			// The existing instance is kept, or restored if it was replaced
+			assert.Equal(t, !tt.lost, dataDir.HasInstance("mock-avs-default"))
+			if tt.restored {
+				got, err := afero.ReadFile(afs, "/egn/nodes/mock-avs-default/state.json")
+				require.NoError(t, err)
+				assert.Equal(t, state, got)
+			}
+		})
+	}
+}
+
+func TestInstallResult(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	ctrl := gomock.NewController(t)
+	locker := mock_locker.NewMockLocker(ctrl)
+	locker.EXPECT().New(filepath.Join("/egn", "nodes", "mock-avs-default", ".lock")).Return(locker)
+	monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+
+	dataDir, err := data.NewDataDir("/egn", afs, locker)
+	require.NoError(t, err)
+	initInstanceDir(t, afs, "/egn", "mock-avs-default", `{
+		"name": "mock-avs",
+		"tag": "default",
+		"version": "v0.1.0",
+		"commit": "b64c50c15e53ae7afebbdbe210b834d1ee471043",
+		"profile": "health-checker",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg",
+		"monitoring": {
+			"targets": [
+				{"service": "main-service", "port": "8090", "path": "/metrics"},
+				{"service": "exporter", "port": "9100", "path": "/metrics", "scheme": "https"}
+			],
+			"dashboards": ["dashboards/overview.json", "dashboards/mainnet.json"]
+		}
+	}`)
+
+	daemon, err := NewEgnDaemon(dataDir, nil, nil, monitoringManager, nil, locker, log.StandardLogger())
+	require.NoError(t, err)
+
+	result, err := daemon.installResult("mock-avs-default")
+	require.NoError(t, err)
+
+	rawResult, err := json.Marshal(result)
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(rawResult, &got))
+	assert.Equal(t, "mock-avs-default", got["instance_id"])
+	assert.Equal(t, "v0.1.0", got["version"])
+	assert.Equal(t, "b64c50c15e53ae7afebbdbe210b834d1ee471043", got["commit"])
+	assert.Equal(t, "https://github.com/NethermindEth/mock-avs-pkg", got["url"])
+	assert.Equal(t, "health-checker", got["profile"])
+	assert.Equal(t, filepath.Join("/egn", "nodes", "mock-avs-default"), got["data_dir"])
+	assert.Equal(t, []interface{}{"main-service:8090/metrics", "https://exporter:9100/metrics"}, got["monitoring_endpoints"])
+	assert.Equal(t, []interface{}{"overview", "mainnet"}, got["dashboards"])
+}
+
 func TestRun(t *testing.T) {
 	afs := afero.NewOsFs()
 
@@ -1166,14 +1629,14 @@ func TestRun(t *testing.T) {
 			mocker: func(tmp string, composeManager *mocks.MockComposeManager, dockerManager *mocks.MockDockerManager, locker *mock_locker.MockLocker, monitoringManager *mocks.MockMonitoringManager) {
 				path := filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml")
 
-				locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).Times(2)
+				locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).Times(3)
 				locker.EXPECT().Lock().Return(nil)
 				locker.EXPECT().Locked().Return(true)
 				locker.EXPECT().Unlock().Return(nil)
 				// Init, install and run
 				gomock.InOrder(
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
-					composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: path}).Return(nil),
+					composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: path}).Return(nil),
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Running, nil),
 					composeManager.EXPECT().PS(compose.DockerComposePsOptions{
@@ -1211,14 +1674,14 @@ func TestRun(t *testing.T) {
 			mocker: func(tmp string, composeManager *mocks.MockComposeManager, dockerManager *mocks.MockDockerManager, locker *mock_locker.MockLocker, monitoringManager *mocks.MockMonitoringManager) {
 				path := filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml")
 
-				locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).Times(2)
+				locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).Times(3)
 				locker.EXPECT().Lock().Return(nil)
 				locker.EXPECT().Locked().Return(true)
 				locker.EXPECT().Unlock().Return(nil)
 				// Init, install and run
 				gomock.InOrder(
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
-					composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: path}).Return(nil),
+					composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: path}).Return(nil),
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Running, nil),
 					composeManager.EXPECT().PS(compose.DockerComposePsOptions{
@@ -1264,7 +1727,8 @@ func TestRun(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
-					composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: path}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker),
+					composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: path}).Return(nil),
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Unknown, nil),
 				)
@@ -1292,7 +1756,8 @@ func TestRun(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
-					composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: path}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker),
+					composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: path}).Return(nil),
 					monitoringManager.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
 				)
 			},
@@ -1327,7 +1792,8 @@ func TestRun(t *testing.T) {
 					locker.EXPECT().Unlock().Return(nil),
 				)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: path}).Return(errors.New("error"))
+				locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: path}).Return(errors.New("error"))
 			},
 			options: &InstallOptions{
 				Name:        MockAVSName,
@@ -1366,12 +1832,12 @@ func TestRun(t *testing.T) {
 			tt.mocker(tmp, composeManager, dockerManager, locker, monitoringManager)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			if tt.options != nil {
 				// Pull the package
-				pullResult, err := daemon.Pull(tt.options.URL, PullTarget{Version: tt.options.Version}, true)
+				pullResult, err := daemon.Pull(context.Background(), tt.options.URL, PullTarget{Version: tt.options.Version}, true)
 				require.NoError(t, err)
 				tt.options.Options = pullResult.Options[tt.options.Profile]
 
@@ -1385,7 +1851,7 @@ func TestRun(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			err = daemon.Run(tt.instanceID)
+			err = daemon.Run(context.Background(), tt.instanceID, RunOptions{})
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -1395,43 +1861,328 @@ func TestRun(t *testing.T) {
 	}
 }
 
-func TestStop(t *testing.T) {
+func TestRunCanceled(t *testing.T) {
+	afs := afero.NewOsFs()
+	instanceID := "mock-avs-default"
+
+	tmp, err := afero.TempDir(afs, "", "egn-test-run-canceled")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	composeManager := mocks.NewMockComposeManager(ctrl)
+	dockerManager := mocks.NewMockDockerManager(ctrl)
+	locker := mock_locker.NewMockLocker(ctrl)
+	monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+	backupMgr := mocks.NewMockBackupManager(ctrl)
+
+	dataDir, err := data.NewDataDir(tmp, afs, locker)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	composePath := filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml")
+	locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).AnyTimes()
+	// Cancel while docker compose is starting the instance. No monitoring
+	// target must be added afterwards.
+	composeManager.EXPECT().Up(ctx, compose.DockerComposeUpOptions{Path: composePath}).
+		DoAndReturn(func(ctx context.Context, _ compose.DockerComposeUpOptions) error {
+			cancel()
+			return fmt.Errorf("%w: %w", compose.DockerComposeCmdError{}, ctx.Err())
+		})
+	initInstanceDir(t, afs, tmp, instanceID, `{
+		"name": "mock-avs",
+		"tag": "default",
+		"version": "v0.1.0",
+		"profile": "health-checker",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg"
+	}`)
+
+	daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
+	require.NoError(t, err)
+
+	err = daemon.Run(ctx, instanceID, RunOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunTimeout(t *testing.T) {
 	afs := afero.NewOsFs()
+	instanceID := "mock-avs-default"
 
 	tests := []struct {
-		name       string
-		instanceID string
-		mocker     func(string, *mocks.MockComposeManager, *mocks.MockDockerManager, *mock_locker.MockLocker, *mocks.MockMonitoringManager)
-		options    *InstallOptions
-		wantErr    bool
+		name    string
+		downErr error
 	}{
 		{
-			name:       "success",
-			instanceID: "mock-avs-default",
-			mocker: func(tmp string, composeManager *mocks.MockComposeManager, dockerManager *mocks.MockDockerManager, locker *mock_locker.MockLocker, monitoringManager *mocks.MockMonitoringManager) {
-				path := filepath.Join(tmp, "nodes", "mock-avs-default", "docker-compose.yml")
-
-				gomock.InOrder(
-					// Init and install
-					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
-					locker.EXPECT().Lock().Return(nil),
-					locker.EXPECT().Locked().Return(true),
-					locker.EXPECT().Unlock().Return(nil),
-					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
-					// Stop
-					composeManager.EXPECT().Stop(compose.DockerComposeStopOptions{Path: path}).Return(nil),
-				)
-			},
-			options: &InstallOptions{
-				Name:    MockAVSName,
-				URL:     common.MockAvsPkg.Repo(),
-				Version: common.MockAvsPkg.Version(),
-				Profile: "health-checker",
-				Tag:     "default",
-			},
+			name: "containers removed",
 		},
 		{
-			name:       "failure, not installed instance",
+			name:    "containers not removed",
+			downErr: assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := afero.TempDir(afs, "", "egn-test-run-timeout")
+			require.NoError(t, err)
+
+			ctrl := gomock.NewController(t)
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			locker := mock_locker.NewMockLocker(ctrl)
+
+			dataDir, err := data.NewDataDir(tmp, afs, locker)
+			require.NoError(t, err)
+
+			composePath := filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml")
+			locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).AnyTimes()
+			// docker compose is stuck pulling an image until the start
+			// timeout expires. The containers started so far are removed,
+			// keeping the volumes, and no monitoring target is added.
+			gomock.InOrder(
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: composePath}).
+					DoAndReturn(func(ctx context.Context, _ compose.DockerComposeUpOptions) error {
+						<-ctx.Done()
+						return fmt.Errorf("%w: %w", compose.DockerComposeCmdError{}, ctx.Err())
+					}),
+				composeManager.EXPECT().Down(compose.DockerComposeDownOptions{Path: composePath}).Return(tt.downErr),
+			)
+			initInstanceDir(t, afs, tmp, instanceID, `{
+				"name": "mock-avs",
+				"tag": "default",
+				"version": "v0.1.0",
+				"profile": "health-checker",
+				"url": "https://github.com/NethermindEth/mock-avs-pkg"
+			}`)
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			start := time.Now()
+			err = daemon.Run(context.Background(), instanceID, RunOptions{Timeout: 200 * time.Millisecond})
+			assert.ErrorIs(t, err, ErrRunTimeout)
+			assert.NotErrorIs(t, err, context.DeadlineExceeded)
+			if tt.downErr != nil {
+				assert.ErrorIs(t, err, tt.downErr)
+			}
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestInstanceVersion(t *testing.T) {
+	afs := afero.NewOsFs()
+	instanceID := "mock-avs-default"
+
+	tmp, err := afero.TempDir(afs, "", "egn-test-instance-version")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	locker := mock_locker.NewMockLocker(ctrl)
+	locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).AnyTimes()
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir(tmp, afs, locker)
+	require.NoError(t, err)
+	initInstanceDir(t, afs, tmp, instanceID, `{
+		"name": "mock-avs",
+		"tag": "default",
+		"version": "v0.1.0",
+		"commit": "d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91",
+		"profile": "health-checker",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg"
+	}`)
+
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+	require.NoError(t, err)
+
+	version, err := daemon.InstanceVersion(instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, InstanceVersionInfo{
+		InstanceID: instanceID,
+		Version:    "v0.1.0",
+		Commit:     "d2c2ba9a0cb0b1a6a6e3dbb5ee3d8d0f0cf3de91",
+	}, version)
+
+	_, err = daemon.InstanceVersion("mock-avs-other")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+}
+
+func TestRunWait(t *testing.T) {
+	afs := afero.NewOsFs()
+	instanceID := "mock-avs-default"
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		healthCheck string
+		noAPI       bool
+		timeout     time.Duration
+		cancelAfter time.Duration
+		wantErr     error
+	}{
+		{
+			name: "healthy, default health check",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/eigen/node/health" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			},
+			timeout: 5 * time.Second,
+		},
+		{
+			name: "healthy after some retries",
+			handler: func() http.HandlerFunc {
+				calls := 0
+				return func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					if calls < 3 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					w.WriteHeader(http.StatusOK)
+				}
+			}(),
+			timeout: 10 * time.Second,
+		},
+		{
+			name: "healthy, declared health check",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/ready" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			},
+			healthCheck: `, "health_check": {"path": "/ready", "status": 204}`,
+			timeout:     5 * time.Second,
+		},
+		{
+			name: "unhealthy, timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			timeout: time.Second,
+			wantErr: ErrHealthCheckTimeout,
+		},
+		{
+			name: "unhealthy, canceled",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			timeout:     10 * time.Second,
+			cancelAfter: 500 * time.Millisecond,
+			wantErr:     context.Canceled,
+		},
+		{
+			name:  "no API target",
+			noAPI: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := afero.TempDir(afs, "", "egn-test-run-wait")
+			require.NoError(t, err)
+
+			ctrl := gomock.NewController(t)
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+			locker := mock_locker.NewMockLocker(ctrl)
+			monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+			backupMgr := mocks.NewMockBackupManager(ctrl)
+
+			dataDir, err := data.NewDataDir(tmp, afs, locker)
+			require.NoError(t, err)
+
+			composePath := filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml")
+			locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).AnyTimes()
+			composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: composePath}).Return(nil)
+			monitoringManager.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+
+			api := ""
+			if !tt.noAPI {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+				serverURL, err := url.Parse(server.URL)
+				require.NoError(t, err)
+
+				api = `, "api": {"service": "main-service", "port": "` + serverURL.Port() + `"` + tt.healthCheck + `}`
+				composeManager.EXPECT().PS(compose.DockerComposePsOptions{
+					ServiceName: "main-service",
+					Path:        composePath,
+					Format:      "json",
+					All:         true,
+				}).Return([]compose.ComposeService{{Id: "1", Service: "main-service"}}, nil).MinTimes(1)
+				dockerManager.EXPECT().ContainerIP("1").Return(serverURL.Hostname(), nil).MinTimes(1)
+			}
+			initInstanceDir(t, afs, tmp, instanceID, `{
+				"name": "mock-avs",
+				"tag": "default",
+				"version": "v0.1.0",
+				"profile": "health-checker",
+				"url": "https://github.com/NethermindEth/mock-avs-pkg"`+api+`
+			}`)
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAfter > 0 {
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+
+			err = daemon.Run(ctx, instanceID, RunOptions{Wait: true, Timeout: tt.timeout})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	afs := afero.NewOsFs()
+
+	tests := []struct {
+		name       string
+		instanceID string
+		mocker     func(string, *mocks.MockComposeManager, *mocks.MockDockerManager, *mock_locker.MockLocker, *mocks.MockMonitoringManager)
+		options    *InstallOptions
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			instanceID: "mock-avs-default",
+			mocker: func(tmp string, composeManager *mocks.MockComposeManager, dockerManager *mocks.MockDockerManager, locker *mock_locker.MockLocker, monitoringManager *mocks.MockMonitoringManager) {
+				path := filepath.Join(tmp, "nodes", "mock-avs-default", "docker-compose.yml")
+
+				gomock.InOrder(
+					// Init and install
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
+					// Stop
+					composeManager.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: path}).Return(nil),
+				)
+			},
+			options: &InstallOptions{
+				Name:    MockAVSName,
+				URL:     common.MockAvsPkg.Repo(),
+				Version: common.MockAvsPkg.Version(),
+				Profile: "health-checker",
+				Tag:     "default",
+			},
+		},
+		{
+			name:       "failure, not installed instance",
 			instanceID: "mock-avs-default",
 			mocker: func(tmp string, composeManager *mocks.MockComposeManager, dockerManager *mocks.MockDockerManager, locker *mock_locker.MockLocker, monitoringManager *mocks.MockMonitoringManager) {
 			},
@@ -1450,8 +2201,9 @@ func TestStop(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 					// Stop
-					composeManager.EXPECT().Stop(compose.DockerComposeStopOptions{Path: path}).Return(errors.New("error")),
+					composeManager.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: path}).Return(errors.New("error")),
 				)
 			},
 			options: &InstallOptions{
@@ -1489,12 +2241,12 @@ func TestStop(t *testing.T) {
 			tt.mocker(tmp, composeManager, dockerManager, locker, monitoringManager)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			if tt.options != nil {
 				// Pull the package
-				pullResult, err := daemon.Pull(tt.options.URL, PullTarget{Version: tt.options.Version}, true)
+				pullResult, err := daemon.Pull(context.Background(), tt.options.URL, PullTarget{Version: tt.options.Version}, true)
 				require.NoError(t, err)
 				tt.options.Options = pullResult.Options[tt.options.Profile]
 
@@ -1508,7 +2260,7 @@ func TestStop(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			err = daemon.Stop(tt.instanceID)
+			err = daemon.Stop(context.Background(), tt.instanceID)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -1541,6 +2293,7 @@ func TestUninstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 					// Uninstall
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Running, nil),
@@ -1569,6 +2322,7 @@ func TestUninstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 					// Uninstall
 					monitoringManager.EXPECT().InstallationStatus().Return(common.NotInstalled, nil),
 					composeManager.EXPECT().Down(compose.DockerComposeDownOptions{Path: path, Volumes: true}).Return(nil),
@@ -1595,6 +2349,7 @@ func TestUninstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 					// Uninstall
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Unknown, nil),
@@ -1628,6 +2383,7 @@ func TestUninstall(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 					composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: path, Build: true}).Return(nil),
+					locker.EXPECT().New(filepath.Join(tmp, "nodes", "mock-avs-default", ".lock")).Return(locker),
 					// Uninstall
 					monitoringManager.EXPECT().InstallationStatus().Return(common.Installed, nil),
 					monitoringManager.EXPECT().Status().Return(common.Running, nil),
@@ -1670,12 +2426,12 @@ func TestUninstall(t *testing.T) {
 			tt.mocker(tmp, composeManager, dockerManager, locker, monitoringManager)
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			if tt.options != nil {
 				// Pull the package
-				pullResult, err := daemon.Pull(tt.options.URL, PullTarget{Version: tt.options.Version}, true)
+				pullResult, err := daemon.Pull(context.Background(), tt.options.URL, PullTarget{Version: tt.options.Version}, true)
 				require.NoError(t, err)
 				tt.options.Options = pullResult.Options[tt.options.Profile]
 
@@ -2670,7 +3426,7 @@ func TestListInstances(t *testing.T) {
 			}
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			// List instances
@@ -2824,7 +3580,7 @@ func TestNodeLogs(t *testing.T) {
 			}
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			err = daemon.NodeLogs(tt.ctx, tt.w, tt.instanceID, tt.opts)
@@ -2948,7 +3704,7 @@ func TestRunPlugin(t *testing.T) {
 					}, nil),
 					d.dockerManager.EXPECT().ContainerNetworks("abc123").Return([]string{"network-el"}, nil),
 					d.dockerManager.EXPECT().ImageExist(common.PluginImage.FullImage()).Return(false, nil),
-					d.dockerManager.EXPECT().Pull(common.PluginImage.FullImage()).Return(nil),
+					d.dockerManager.EXPECT().Pull(common.PluginImage.FullImage(), docker.RegistryAuth{}).Return(nil),
 					d.dockerManager.EXPECT().Run(common.PluginImage.FullImage(), docker.RunOptions{
 						Network: "network-el",
 						Args:    []string{"arg1", "arg2"},
@@ -3006,7 +3762,7 @@ func TestRunPlugin(t *testing.T) {
 					}, nil),
 					d.dockerManager.EXPECT().ContainerNetworks("abc123").Return([]string{"network-el"}, nil),
 					d.dockerManager.EXPECT().ImageExist(common.PluginImage.FullImage()).Return(false, nil),
-					d.dockerManager.EXPECT().Pull(common.PluginImage.FullImage()).Return(assert.AnError),
+					d.dockerManager.EXPECT().Pull(common.PluginImage.FullImage(), docker.RegistryAuth{}).Return(assert.AnError),
 				)
 			},
 		},
@@ -3210,7 +3966,7 @@ func TestRunPlugin(t *testing.T) {
 			}
 
 			// Create a daemon
-			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker)
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupMgr, locker, log.StandardLogger())
 			require.NoError(t, err)
 
 			err = daemon.RunPlugin(tt.instanceId, tt.args, tt.options)
@@ -3255,7 +4011,7 @@ func TestGetPluginData(t *testing.T) {
 	}
 	defer dockerClient.Close()
 	dockerManager := docker.NewDockerManager(dockerClient)
-	daemon, err := NewEgnDaemon(dataDir, nil, dockerManager, nil, nil, lock)
+	daemon, err := NewEgnDaemon(dataDir, nil, dockerManager, nil, nil, lock, log.StandardLogger())
 	require.NoError(t, err, "failed to initialize daemon")
 
 	// Tests
@@ -3316,3 +4072,1112 @@ func changeManifestPluginBuildFrom(t *testing.T, fs afero.Fs, manifestPath strin
 	_, err = manifestFile.Write(manifestData)
 	require.NoError(t, err, "failed to write manifest file")
 }
+
+func TestCheckHardwareRequirements(t *testing.T) {
+	host := hardwarechecker.HardwareMetrics{
+		CPU:       2,
+		RAM:       4096,
+		DiskSpace: 10240,
+	}
+	tests := []struct {
+		name         string
+		requirements HardwareRequirements
+		metricsErr   error
+		wantErr      error
+		wantMsg      string
+	}{
+		{
+			name: "requirements met",
+			requirements: HardwareRequirements{
+				MinCPUCores:  2,
+				MinRAM:       2048,
+				MinFreeSpace: 10240,
+			},
+		},
+		{
+			name: "insufficient resources",
+			requirements: HardwareRequirements{
+				MinCPUCores:  4,
+				MinRAM:       8192,
+				MinFreeSpace: 5120,
+			},
+			wantErr: ErrInsufficientResources,
+			wantMsg: "insufficient resources: CPU: required 4.00 Cores, available 2.00 Cores, RAM: required 8192.00 Mb, available 4096.00 Mb",
+		},
+		{
+			name:         "metrics error",
+			requirements: HardwareRequirements{},
+			metricsErr:   assert.AnError,
+			wantErr:      assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemon := &EgnDaemon{
+				hardwareMetrics: func() (hardwarechecker.HardwareMetrics, error) {
+					return host, tt.metricsErr
+				},
+			}
+
+			err := daemon.CheckHardwareRequirements(tt.requirements)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if tt.wantMsg != "" {
+					assert.EqualError(t, err, tt.wantMsg)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	ts := []struct {
+		name   string
+		mocker func(d *mocks.MockDockerManager, m *mocks.MockMonitoringManager)
+		want   []DoctorCheck
+	}{
+		{
+			name: "monitoring stack not installed",
+			mocker: func(d *mocks.MockDockerManager, m *mocks.MockMonitoringManager) {
+				d.EXPECT().Ping().Return(nil)
+				m.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+				m.EXPECT().CheckPorts().Return(nil)
+			},
+			want: []DoctorCheck{
+				{Name: "docker"},
+				{Name: "data directory"},
+				{Name: "monitoring containers", Skipped: "monitoring stack is not installed"},
+				{Name: "provisioning files", Skipped: "monitoring stack is not installed"},
+				{Name: "ports"},
+			},
+		},
+		{
+			name: "monitoring stack running",
+			mocker: func(d *mocks.MockDockerManager, m *mocks.MockMonitoringManager) {
+				d.EXPECT().Ping().Return(nil)
+				m.EXPECT().InstallationStatus().Return(common.Installed, nil)
+				m.EXPECT().Status().Return(common.Running, nil)
+				m.EXPECT().ValidateProvisioning().Return(nil)
+			},
+			want: []DoctorCheck{
+				{Name: "docker"},
+				{Name: "data directory"},
+				{Name: "monitoring containers"},
+				{Name: "provisioning files"},
+				{Name: "ports", Skipped: "monitoring stack is running"},
+			},
+		},
+		{
+			name: "failing checks",
+			mocker: func(d *mocks.MockDockerManager, m *mocks.MockMonitoringManager) {
+				d.EXPECT().Ping().Return(assert.AnError)
+				m.EXPECT().InstallationStatus().Return(common.Installed, nil)
+				m.EXPECT().Status().Return(common.Unknown, assert.AnError)
+				m.EXPECT().ValidateProvisioning().Return(assert.AnError)
+				m.EXPECT().CheckPorts().Return(assert.AnError)
+			},
+			want: []DoctorCheck{
+				{Name: "docker", Err: assert.AnError},
+				{Name: "data directory"},
+				{Name: "monitoring containers", Err: assert.AnError},
+				{Name: "provisioning files", Err: assert.AnError},
+				{Name: "ports", Err: assert.AnError},
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+			monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+			tt.mocker(dockerManager, monitoringManager)
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), dockerManager, monitoringManager, mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			checks := daemon.Doctor()
+			assert.Equal(t, tt.want, checks)
+			for _, check := range checks {
+				assert.Equal(t, check.Err != nil, check.Failed(), check.Name)
+			}
+		})
+	}
+}
+
+func TestCheckRuntime(t *testing.T) {
+	ts := []struct {
+		name   string
+		mocker func(d *mocks.MockDockerManager, c *mocks.MockComposeManager)
+		err    string
+	}{
+		{
+			name: "runtime available",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(nil)
+				c.EXPECT().Version().Return("2.21.0", nil)
+			},
+		},
+		{
+			name: "prefixed and desktop versions",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(nil)
+				c.EXPECT().Version().Return("v2.23.0-desktop.1", nil)
+			},
+		},
+		{
+			name: "docker daemon not reachable",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(assert.AnError)
+			},
+			err: "the docker daemon is not reachable",
+		},
+		{
+			name: "compose plugin missing",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(nil)
+				c.EXPECT().Version().Return("", assert.AnError)
+			},
+			err: "docker compose is not available, install the docker compose plugin v2.0.0 or later",
+		},
+		{
+			name: "compose plugin too old",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(nil)
+				c.EXPECT().Version().Return("1.29.2", nil)
+			},
+			err: "docker compose 1.29.2 is not supported, upgrade the docker compose plugin to v2.0.0 or later",
+		},
+		{
+			name: "unknown compose version",
+			mocker: func(d *mocks.MockDockerManager, c *mocks.MockComposeManager) {
+				d.EXPECT().Ping().Return(nil)
+				c.EXPECT().Version().Return("dev", nil)
+			},
+			err: "docker compose dev is not supported",
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			tt.mocker(dockerManager, composeManager)
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			err = daemon.CheckRuntime()
+			if tt.err != "" {
+				assert.ErrorIs(t, err, ErrRuntimeUnavailable)
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ts := []struct {
+		name    string
+		options PruneOptions
+		mocker  func(d *mocks.MockDockerManager)
+		want    PruneResult
+		removed bool
+		err     error
+	}{
+		{
+			name:    "data dirs",
+			want:    PruneResult{DataDirs: []string{"mock-avs-orphan"}, Images: []string{}},
+			removed: true,
+		},
+		{
+			name:    "dry run",
+			options: PruneOptions{DryRun: true, Images: true},
+			mocker: func(d *mocks.MockDockerManager) {
+				d.EXPECT().DanglingImages([]string{"mock-avs-default", "mock-avs-orphan"}).Return([]string{"sha256:1"}, nil)
+			},
+			want: PruneResult{DataDirs: []string{"mock-avs-orphan"}, Images: []string{"sha256:1"}},
+		},
+		{
+			name:    "data dirs and images",
+			options: PruneOptions{Images: true},
+			mocker: func(d *mocks.MockDockerManager) {
+				gomock.InOrder(
+					d.EXPECT().DanglingImages([]string{"mock-avs-default", "mock-avs-orphan"}).Return([]string{"sha256:1", "sha256:2"}, nil),
+					d.EXPECT().ImageRemove("sha256:1").Return(nil),
+					d.EXPECT().ImageRemove("sha256:2").Return(nil),
+				)
+			},
+			want:    PruneResult{DataDirs: []string{"mock-avs-orphan"}, Images: []string{"sha256:1", "sha256:2"}},
+			removed: true,
+		},
+		{
+			name:    "image remove error",
+			options: PruneOptions{Images: true},
+			mocker: func(d *mocks.MockDockerManager) {
+				gomock.InOrder(
+					d.EXPECT().DanglingImages([]string{"mock-avs-default", "mock-avs-orphan"}).Return([]string{"sha256:1"}, nil),
+					d.EXPECT().ImageRemove("sha256:1").Return(assert.AnError),
+				)
+			},
+			want:    PruneResult{DataDirs: []string{"mock-avs-orphan"}, Images: []string{}},
+			removed: true,
+			err:     assert.AnError,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+
+			// A valid instance and the leftovers of an interrupted install
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default/data", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default"}`), 0o644))
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-orphan/data", 0o755))
+
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(dockerManager)
+			}
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), dockerManager, mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			result, err := daemon.Prune(tt.options)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, result)
+
+			// The valid instance is never touched
+			exists, err := afero.Exists(afs, "/egn/nodes/mock-avs-default/state.json")
+			require.NoError(t, err)
+			assert.True(t, exists)
+			exists, err = afero.DirExists(afs, "/egn/nodes/mock-avs-default/data")
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			exists, err = afero.DirExists(afs, "/egn/nodes/mock-avs-orphan")
+			require.NoError(t, err)
+			assert.Equal(t, !tt.removed, exists)
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	ts := []struct {
+		name  string
+		key   string
+		value string
+		want  []ConfigEntry
+		err   error
+	}{
+		{
+			name:  "option",
+			key:   "MAIN_PORT",
+			value: "9090",
+			want: []ConfigEntry{
+				{Key: "EXTRA", Value: "extra"},
+				{Key: "MAIN_PORT", Value: "9090", Option: "main-port"},
+				{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+			},
+		},
+		{
+			name:  "variable without option",
+			key:   "EXTRA",
+			value: "other",
+			want: []ConfigEntry{
+				{Key: "EXTRA", Value: "other"},
+				{Key: "MAIN_PORT", Value: "8080", Option: "main-port"},
+				{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+			},
+		},
+		{
+			name:  "invalid option value",
+			key:   "NETWORK_NAME",
+			value: "other",
+			err:   ErrInvalidConfigValue,
+		},
+		{
+			name:  "value with equal sign",
+			key:   "EXTRA",
+			value: "a=b",
+			want: []ConfigEntry{
+				{Key: "EXTRA", Value: "a=b"},
+				{Key: "MAIN_PORT", Value: "8080", Option: "main-port"},
+				{Key: "NETWORK_NAME", Value: "eigenlayer", Option: "network-name"},
+			},
+		},
+		{
+			name:  "value with line break",
+			key:   "EXTRA",
+			value: "a\nMAIN_PORT=1",
+			err:   ErrInvalidConfigValue,
+		},
+		{
+			name:  "unknown key",
+			key:   "UNKNOWN",
+			value: "value",
+			err:   ErrUnknownConfigKey,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default"}`), 0o644))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/profile.yml", []byte(`options:
+  - name: "main-port"
+    target: MAIN_PORT
+    type: port
+    default: 8080
+    help: "Main service server port"
+  - name: "network-name"
+    target: NETWORK_NAME
+    type: str
+    validate:
+      re2_regex: "^eigen.*"
+    default: eigenlayer
+    help: "Docker network name"
+monitoring:
+  targets:
+    - service: main-service
+      port: 9090
+      path: /metrics
+`), 0o644))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/.env", []byte("MAIN_PORT=8080\nNETWORK_NAME=eigenlayer\nEXTRA=extra\n"), 0o644))
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			err = daemon.SetConfig("mock-avs-default", tt.key, tt.value)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := daemon.GetConfig("mock-avs-default")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigInstanceNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	afs := afero.NewMemMapFs()
+	locker := mock_locker.NewMockLocker(ctrl)
+	dataDir, err := data.NewDataDir("/egn", afs, locker)
+	require.NoError(t, err)
+
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+	require.NoError(t, err)
+
+	_, err = daemon.GetConfig("mock-avs-default")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+	err = daemon.SetConfig("mock-avs-default", "MAIN_PORT", "9090")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+}
+
+func TestLabel(t *testing.T) {
+	ts := []struct {
+		name   string
+		id     string
+		set    map[string]string
+		remove []string
+		want   map[string]string
+		err    error
+	}{
+		{
+			name: "list",
+			id:   "mock-avs-default",
+			want: map[string]string{"env": "dev"},
+		},
+		{
+			name:   "set and remove",
+			id:     "mock-avs-default",
+			set:    map[string]string{"env": "prod", "team": "infra"},
+			remove: []string{"region"},
+			want:   map[string]string{"env": "prod", "team": "infra"},
+		},
+		{
+			name:   "remove all",
+			id:     "mock-avs-default",
+			remove: []string{"env"},
+			want:   nil,
+		},
+		{
+			name: "invalid key",
+			id:   "mock-avs-default",
+			set:  map[string]string{"my-env": "prod"},
+			err:  ErrInvalidLabel,
+		},
+		{
+			name: "reserved key",
+			id:   "mock-avs-default",
+			set:  map[string]string{"instance_id": "other"},
+			err:  ErrInvalidLabel,
+		},
+		{
+			name: "prometheus internal key",
+			id:   "mock-avs-default",
+			set:  map[string]string{"__name__": "other"},
+			err:  ErrInvalidLabel,
+		},
+		{
+			name: "value with comma",
+			id:   "mock-avs-default",
+			set:  map[string]string{"env": "prod,dev"},
+			err:  ErrInvalidLabel,
+		},
+		{
+			name: "empty value",
+			id:   "mock-avs-default",
+			set:  map[string]string{"env": ""},
+			err:  ErrInvalidLabel,
+		},
+		{
+			name: "instance not found",
+			id:   "mock-avs-other",
+			set:  map[string]string{"env": "prod"},
+			err:  ErrInstanceNotFound,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default","labels":{"env":"dev"}}`), 0o644))
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			got, err := daemon.Label(tt.id, tt.set, tt.remove)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			instance, err := dataDir.Instance("mock-avs-default")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, instance.Labels)
+		})
+	}
+}
+
+func TestStreamBackup(t *testing.T) {
+	composePath := "/egn/nodes/mock-avs-default/docker-compose.yml"
+	psOptions := compose.DockerComposePsOptions{Path: composePath, Format: "json", FilterRunning: true}
+	ts := []struct {
+		name       string
+		consistent bool
+		mocker     func(c *mocks.MockComposeManager)
+		err        error
+	}{
+		{
+			name: "hot backup",
+		},
+		{
+			name:       "consistent backup of a running instance",
+			consistent: true,
+			mocker: func(c *mocks.MockComposeManager) {
+				gomock.InOrder(
+					c.EXPECT().PS(psOptions).Return([]compose.ComposeService{{Id: "1", Service: "main-service"}}, nil),
+					c.EXPECT().Pause(compose.DockerComposePauseOptions{Path: composePath}).Return(nil),
+					c.EXPECT().Unpause(compose.DockerComposeUnpauseOptions{Path: composePath}).Return(nil),
+				)
+			},
+		},
+		{
+			name:       "consistent backup of a stopped instance",
+			consistent: true,
+			mocker: func(c *mocks.MockComposeManager) {
+				c.EXPECT().PS(psOptions).Return([]compose.ComposeService{}, nil)
+			},
+		},
+		{
+			name:       "pause error",
+			consistent: true,
+			mocker: func(c *mocks.MockComposeManager) {
+				gomock.InOrder(
+					c.EXPECT().PS(psOptions).Return([]compose.ComposeService{{Id: "1", Service: "main-service"}}, nil),
+					c.EXPECT().Pause(compose.DockerComposePauseOptions{Path: composePath}).Return(assert.AnError),
+				)
+			},
+			err: assert.AnError,
+		},
+		{
+			name:       "unpause error",
+			consistent: true,
+			mocker: func(c *mocks.MockComposeManager) {
+				gomock.InOrder(
+					c.EXPECT().PS(psOptions).Return([]compose.ComposeService{{Id: "1", Service: "main-service"}}, nil),
+					c.EXPECT().Pause(compose.DockerComposePauseOptions{Path: composePath}).Return(nil),
+					c.EXPECT().Unpause(compose.DockerComposeUnpauseOptions{Path: composePath}).Return(assert.AnError),
+				)
+			},
+			err: assert.AnError,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default/data", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default"}`), 0o644))
+
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			if tt.mocker != nil {
+				tt.mocker(composeManager)
+			}
+			daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			backup, err := daemon.StreamBackup("mock-avs-default", &out, StreamBackupOptions{Consistent: tt.consistent})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "mock-avs-default", backup.InstanceId)
+
+			// The streamed backup is a readable tar
+			require.NoError(t, afero.WriteFile(afs, "/backup.tar.gz", out.Bytes(), 0o644))
+			loaded, err := data.BackupFromTar(afs, "/backup.tar.gz")
+			require.NoError(t, err)
+			assert.Equal(t, "v3.0.3", loaded.Version)
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	composePath := "/egn/nodes/mock-avs-default/docker-compose.yml"
+	metadata := map[string]string{"instance_id": "mock-avs-default"}
+	ts := []struct {
+		name   string
+		mocker func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager)
+		op     func(d *EgnDaemon) error
+		err    error
+	}{
+		{
+			name: "pre-run hook vetoes the run",
+			mocker: func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager) {
+				h.EXPECT().Run(gomock.Any(), hooks.PreRun, metadata).Return(hooks.ErrHookFailed)
+			},
+			op: func(d *EgnDaemon) error {
+				return d.Run(context.Background(), "mock-avs-default", RunOptions{})
+			},
+			err: hooks.ErrHookFailed,
+		},
+		{
+			name: "post-stop hook",
+			mocker: func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager) {
+				gomock.InOrder(
+					c.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: composePath}).Return(nil),
+					h.EXPECT().Run(gomock.Any(), hooks.PostStop, metadata).Return(nil),
+				)
+			},
+			op: func(d *EgnDaemon) error {
+				return d.Stop(context.Background(), "mock-avs-default")
+			},
+		},
+		{
+			name: "no post-stop hook if the stop fails",
+			mocker: func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager) {
+				c.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: composePath}).Return(assert.AnError)
+			},
+			op: func(d *EgnDaemon) error {
+				return d.Stop(context.Background(), "mock-avs-default")
+			},
+			err: assert.AnError,
+		},
+		{
+			name: "post-backup hook",
+			mocker: func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager) {
+				gomock.InOrder(
+					c.EXPECT().Stop(gomock.Any(), compose.DockerComposeStopOptions{Path: composePath}).Return(nil),
+					h.EXPECT().Run(gomock.Any(), hooks.PostStop, metadata).Return(nil),
+					b.EXPECT().BackupInstance("mock-avs-default", data.CompressionNone, nil).Return("mock-avs-default-1696317683", nil),
+					h.EXPECT().Run(gomock.Any(), hooks.PostBackup, map[string]string{
+						"instance_id": "mock-avs-default",
+						"backup_id":   "mock-avs-default-1696317683",
+					}).Return(nil),
+				)
+			},
+			op: func(d *EgnDaemon) error {
+				_, err := d.Backup("mock-avs-default", BackupOptions{})
+				return err
+			},
+		},
+		{
+			name: "post-backup hook after resuming a consistent stream backup",
+			mocker: func(h *mocks.MockHookRunner, c *mocks.MockComposeManager, b *mocks.MockBackupManager) {
+				gomock.InOrder(
+					c.EXPECT().PS(compose.DockerComposePsOptions{Path: composePath, Format: "json", FilterRunning: true}).Return([]compose.ComposeService{{Id: "1", Service: "main-service"}}, nil),
+					c.EXPECT().Pause(compose.DockerComposePauseOptions{Path: composePath}).Return(nil),
+					c.EXPECT().Unpause(compose.DockerComposeUnpauseOptions{Path: composePath}).Return(nil),
+					h.EXPECT().Run(gomock.Any(), hooks.PostBackup, gomock.Any()).Return(nil),
+				)
+			},
+			op: func(d *EgnDaemon) error {
+				_, err := d.StreamBackup("mock-avs-default", io.Discard, StreamBackupOptions{Consistent: true})
+				return err
+			},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			require.NoError(t, afs.MkdirAll("/egn/nodes/mock-avs-default", 0o755))
+			require.NoError(t, afero.WriteFile(afs, "/egn/nodes/mock-avs-default/state.json", []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","profile":"option-returner","tag":"default"}`), 0o644))
+
+			hookRunner := mocks.NewMockHookRunner(ctrl)
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			backupManager := mocks.NewMockBackupManager(ctrl)
+			tt.mocker(hookRunner, composeManager, backupManager)
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), backupManager, locker, log.StandardLogger())
+			require.NoError(t, err)
+			daemon.hooks = hookRunner
+
+			err = tt.op(daemon)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetGitOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, caBundle, 0o644))
+	noCertsPath := filepath.Join(dir, "no-certs.pem")
+	require.NoError(t, os.WriteFile(noCertsPath, []byte("not a certificate"), 0o644))
+
+	tests := []struct {
+		name         string
+		options      GitOptions
+		wantCABundle []byte
+		wantHeaders  http.Header
+		wantErr      error
+	}{
+		{
+			name: "no options",
+		},
+		{
+			name: "ca bundle and extra headers",
+			options: GitOptions{
+				CABundle:     caPath,
+				ExtraHeaders: []string{"Authorization: Bearer token", "x-proxy-tenant:egn"},
+			},
+			wantCABundle: caBundle,
+			wantHeaders: http.Header{
+				"Authorization":  {"Bearer token"},
+				"X-Proxy-Tenant": {"egn"},
+			},
+		},
+		{
+			name:    "missing ca bundle",
+			options: GitOptions{CABundle: filepath.Join(dir, "missing.pem")},
+			wantErr: ErrInvalidGitOptions,
+		},
+		{
+			name:    "ca bundle without certificates",
+			options: GitOptions{CABundle: noCertsPath},
+			wantErr: ErrInvalidGitOptions,
+		},
+		{
+			name:    "header without value",
+			options: GitOptions{ExtraHeaders: []string{"Authorization"}},
+			wantErr: ErrInvalidGitOptions,
+		},
+		{
+			name:    "header without name",
+			options: GitOptions{ExtraHeaders: []string{": Bearer token"}},
+			wantErr: ErrInvalidGitOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemon := &EgnDaemon{}
+			err := daemon.SetGitOptions(tt.options)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCABundle, daemon.gitCABundle)
+			assert.Equal(t, tt.wantHeaders, daemon.gitHeaders)
+		})
+	}
+}
+
+func TestSetMonitoringPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options MonitoringPermissions
+		want    data.StackPermissions
+		wantErr error
+	}{
+		{
+			name:    "modes and owner",
+			options: MonitoringPermissions{DirMode: "0750", FileMode: "640", Owner: "472:0"},
+			want:    data.StackPermissions{DirMode: 0o750, FileMode: 0o640, Owner: &data.Owner{UID: 472, GID: 0}},
+		},
+		{
+			name:    "file mode only",
+			options: MonitoringPermissions{FileMode: "0600"},
+			want:    data.StackPermissions{FileMode: 0o600},
+		},
+		{
+			name:    "mode not octal",
+			options: MonitoringPermissions{DirMode: "0789"},
+			wantErr: ErrInvalidMonitoringPermissions,
+		},
+		{
+			name:    "mode with file type bits",
+			options: MonitoringPermissions{FileMode: "01777"},
+			wantErr: ErrInvalidMonitoringPermissions,
+		},
+		{
+			name:    "owner without group",
+			options: MonitoringPermissions{Owner: "472"},
+			wantErr: ErrInvalidMonitoringPermissions,
+		},
+		{
+			name:    "negative owner",
+			options: MonitoringPermissions{Owner: "-1:472"},
+			wantErr: ErrInvalidMonitoringPermissions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir, err := data.NewDataDir(t.TempDir(), afero.NewMemMapFs(), nil)
+			require.NoError(t, err)
+			monitoringMgr := mocks.NewMockMonitoringManager(gomock.NewController(t))
+			if tt.wantErr == nil {
+				monitoringMgr.EXPECT().SetStackPermissions(tt.want)
+			}
+			daemon := &EgnDaemon{dataDir: dataDir, monitoringMgr: monitoringMgr}
+
+			err = daemon.SetMonitoringPermissions(tt.options)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSetRegistryOptions(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"username": "hub-user", "password": "hub-token"},
+		"ghcr.io": {"username": "config-user", "password": "config-token"}
+	}}`), 0o600))
+
+	tests := []struct {
+		name    string
+		options RegistryOptions
+		want    docker.RegistryAuths
+		wantErr error
+	}{
+		{
+			name: "no options",
+		},
+		{
+			name:    "several registries",
+			options: RegistryOptions{Auths: []string{"ghcr.io=user:token", "registry.example.com:5000=user:p:ss"}},
+			want: docker.RegistryAuths{
+				"ghcr.io":                   {Username: "user", Password: "token"},
+				"registry.example.com:5000": {Username: "user", Password: "p:ss"},
+			},
+		},
+		{
+			name: "auths override the config file",
+			options: RegistryOptions{
+				ConfigPath: configPath,
+				Auths:      []string{"https://ghcr.io=user:token"},
+			},
+			want: docker.RegistryAuths{
+				"docker.io": {Username: "hub-user", Password: "hub-token"},
+				"ghcr.io":   {Username: "user", Password: "token"},
+			},
+		},
+		{
+			name:    "missing config file",
+			options: RegistryOptions{ConfigPath: filepath.Join(dir, "missing.json")},
+			wantErr: ErrInvalidRegistryOptions,
+		},
+		{
+			name:    "auth without credentials",
+			options: RegistryOptions{Auths: []string{"ghcr.io"}},
+			wantErr: ErrInvalidRegistryOptions,
+		},
+		{
+			name:    "auth without password",
+			options: RegistryOptions{Auths: []string{"ghcr.io=user"}},
+			wantErr: ErrInvalidRegistryOptions,
+		},
+		{
+			name:    "auth without registry",
+			options: RegistryOptions{Auths: []string{"=user:token"}},
+			wantErr: ErrInvalidRegistryOptions,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemon := &EgnDaemon{}
+			err := daemon.SetRegistryOptions(tt.options)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, daemon.registryAuths)
+		})
+	}
+}
+
+func TestRunRegistryAuth(t *testing.T) {
+	afs := afero.NewOsFs()
+	instanceID := "mock-avs-default"
+	tmp := t.TempDir()
+
+	ctrl := gomock.NewController(t)
+	composeManager := mocks.NewMockComposeManager(ctrl)
+	locker := mock_locker.NewMockLocker(ctrl)
+	monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+	dataDir, err := data.NewDataDir(tmp, afs, locker)
+	require.NoError(t, err)
+	locker.EXPECT().New(filepath.Join(tmp, "nodes", instanceID, ".lock")).Return(locker).AnyTimes()
+	monitoringManager.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+	initInstanceDir(t, afs, tmp, instanceID, `{
+		"name": "mock-avs",
+		"tag": "default",
+		"version": "v0.1.0",
+		"profile": "option-returner",
+		"url": "https://github.com/NethermindEth/mock-avs-pkg"
+	}`)
+
+	// docker compose pulls the images with a docker config holding the
+	// credentials of every registry, removed once the instance is started
+	var configDir string
+	composeManager.EXPECT().Up(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, opts compose.DockerComposeUpOptions) error {
+			assert.Equal(t, filepath.Join(tmp, "nodes", instanceID, "docker-compose.yml"), opts.Path)
+			configDir = opts.ConfigDir
+			require.NotEmpty(t, configDir)
+			auths, err := docker.LoadRegistryAuths(filepath.Join(configDir, "config.json"))
+			require.NoError(t, err)
+			assert.Equal(t, docker.RegistryAuths{
+				"ghcr.io":              {Username: "gh-user", Password: "gh-token"},
+				"registry.example.com": {Username: "user", Password: "token"},
+			}, auths)
+			return nil
+		})
+
+	daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), monitoringManager, mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+	require.NoError(t, err)
+	require.NoError(t, daemon.SetRegistryOptions(RegistryOptions{
+		Auths: []string{"ghcr.io=gh-user:gh-token", "registry.example.com=user:token"},
+	}))
+
+	require.NoError(t, daemon.Run(context.Background(), instanceID, RunOptions{}))
+	assert.NoDirExists(t, configDir)
+}
+
+func TestRestoreRegistryAuth(t *testing.T) {
+	afs := afero.NewOsFs()
+	tmp := t.TempDir()
+
+	ctrl := gomock.NewController(t)
+	backupManager := mocks.NewMockBackupManager(ctrl)
+	dataDir, err := data.NewDataDir(tmp, afs, mock_locker.NewMockLocker(ctrl))
+	require.NoError(t, err)
+	require.NoError(t, afs.MkdirAll(filepath.Join(tmp, "backup"), 0o755))
+	backupId := writeBackupTar(t, afs, dataDir, "default", time.Unix(1696317683, 0))
+
+	// The restored containers are created with the registry credentials, as
+	// their images can be missing
+	var configDir string
+	backupManager.EXPECT().RestoreInstance(backupId, nil, gomock.Any()).
+		DoAndReturn(func(_ string, _ data.KeyProvider, dir string) error {
+			configDir = dir
+			require.NotEmpty(t, configDir)
+			auths, err := docker.LoadRegistryAuths(filepath.Join(configDir, "config.json"))
+			require.NoError(t, err)
+			assert.Equal(t, docker.RegistryAuths{"ghcr.io": {Username: "gh-user", Password: "gh-token"}}, auths)
+			return nil
+		})
+
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), backupManager, mock_locker.NewMockLocker(ctrl), log.StandardLogger())
+	require.NoError(t, err)
+	daemon.operationLocker = locker.NewFLock()
+	require.NoError(t, daemon.SetRegistryOptions(RegistryOptions{Auths: []string{"ghcr.io=gh-user:gh-token"}}))
+
+	require.NoError(t, daemon.Restore(backupId, RestoreOptions{}))
+	assert.NoDirExists(t, configDir)
+}
+
+func TestCheckUpdates(t *testing.T) {
+	const url = "https://github.com/NethermindEth/mock-avs-pkg"
+	ts := []struct {
+		name     string
+		state    string
+		versions []string
+		listErr  error
+		want     *UpdateInfo
+		err      error
+	}{
+		{
+			name:     "update available",
+			state:    `{"name":"mock-avs","url":"` + url + `","version":"v3.0.3","profile":"option-returner","tag":"default"}`,
+			versions: []string{"v3.0.2", "v3.0.3", "v3.1.0"},
+			want: &UpdateInfo{
+				InstanceId:      "mock-avs-default",
+				CurrentVersion:  "v3.0.3",
+				LatestVersion:   "v3.1.0",
+				UpdateAvailable: true,
+			},
+		},
+		{
+			name:     "up to date",
+			state:    `{"name":"mock-avs","url":"` + url + `","version":"v3.1.0","profile":"option-returner","tag":"default"}`,
+			versions: []string{"v3.0.3", "v3.1.0"},
+			want: &UpdateInfo{
+				InstanceId:     "mock-avs-default",
+				CurrentVersion: "v3.1.0",
+				LatestVersion:  "v3.1.0",
+			},
+		},
+		{
+			name:     "installed version is newer than the tags",
+			state:    `{"name":"mock-avs","url":"` + url + `","version":"v3.2.0","profile":"option-returner","tag":"default"}`,
+			versions: []string{"v3.0.3", "v3.1.0"},
+			want: &UpdateInfo{
+				InstanceId:     "mock-avs-default",
+				CurrentVersion: "v3.2.0",
+				LatestVersion:  "v3.2.0",
+			},
+		},
+		{
+			name:  "local install",
+			state: `{"name":"mock-avs","url":"http://localhost","version":"local","profile":"option-returner","tag":"default"}`,
+			err:   ErrUpdateCheckUnsupported,
+		},
+		{
+			name:    "listing error",
+			state:   `{"name":"mock-avs","url":"` + url + `","version":"v3.0.3","profile":"option-returner","tag":"default"}`,
+			listErr: assert.AnError,
+			err:     ErrCheckingUpdates,
+		},
+		{
+			name: "instance not found",
+			err:  ErrInstanceNotFound,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			if tt.state != "" {
+				initInstanceDir(t, afs, "/egn", "mock-avs-default", tt.state)
+			}
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+			var listed int
+			daemon.listVersions = func(ctx context.Context, u string) ([]string, error) {
+				listed++
+				assert.Equal(t, url, u)
+				return tt.versions, tt.listErr
+			}
+
+			info, err := daemon.CheckUpdates(context.Background(), "mock-avs-default")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, info)
+				if tt.listErr != nil {
+					// The failure is cached, so the repository is listed only once
+					_, err := daemon.CheckUpdates(context.Background(), "mock-avs-default")
+					assert.ErrorIs(t, err, tt.err)
+					assert.ErrorContains(t, err, tt.listErr.Error())
+					assert.Equal(t, 1, listed)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), info.CheckedAt, time.Minute)
+			info.CheckedAt = time.Time{}
+			assert.Equal(t, tt.want, info)
+
+			// The versions are cached, so the repository is listed only once
+			cached, err := daemon.CheckUpdates(context.Background(), "mock-avs-default")
+			require.NoError(t, err)
+			cached.CheckedAt = time.Time{}
+			assert.Equal(t, tt.want, cached)
+			assert.Equal(t, 1, listed)
+		})
+	}
+}
@@ -2,22 +2,91 @@ package daemon
 
 import "errors"
 
+// The daemon errors are sentinel errors wrapped with the details of the
+// failure, like the instance ID, so compare them with errors.Is instead of ==.
+// Every error of a Daemon method caused by one of the failures below matches
+// its sentinel error, whatever the details are, so callers can branch on them.
+// The errors of option values are InvalidOptionValueError values, to check
+// with errors.As. Other errors, like the docker errors or the package errors,
+// are returned as they are or wrapped with %w, so errors.Is and errors.As work
+// with the errors of their packages too.
+
+// Instance errors, of the operations on the installed instances.
+var (
+	ErrInstanceExists      = errors.New("instance already exists")
+	ErrInstanceNotFound    = errors.New("instance not found")
+	ErrInstanceNotRunning  = errors.New("instance is not running")
+	ErrInstanceHasNoPlugin = errors.New("instance has no plugin")
+	ErrNoPrimaryService    = errors.New("instance has no primary service")
+	ErrServiceNotRunning   = errors.New("service is not running")
+	ErrNoAPIContainer      = errors.New("no API container found")
+	ErrOperationInProgress = errors.New("another operation is in progress")
+	ErrRunTimeout          = errors.New("instance start timeout")
+)
+
+// ErrInstanceAlreadyExists is the former name of ErrInstanceExists.
+//
+// Deprecated: Use ErrInstanceExists.
+var ErrInstanceAlreadyExists = ErrInstanceExists
+
+// Profile, option and configuration errors.
+var (
+	ErrProfileDoesNotExist    = errors.New("profile does not exist")
+	ErrOptionWithoutValue     = errors.New("option without value")
+	ErrOptionNotSet           = errors.New("option not set")
+	ErrUnknownOptionType      = errors.New("unknown option type")
+	ErrUnknownConfigKey       = errors.New("unknown config key")
+	ErrInvalidConfigValue     = errors.New("invalid config value")
+	ErrInvalidLabel           = errors.New("invalid label")
+	ErrInvalidGitOptions      = errors.New("invalid git options")
+	ErrInvalidRegistryOptions = errors.New("invalid registry options")
+)
+
+// Update errors.
+var (
+	ErrVersionOrCommitNotSet   = errors.New("version or commit not set")
+	ErrInvalidUpdateVersion    = errors.New("invalid update version")
+	ErrInvalidUpdateCommit     = errors.New("invalid update commit")
+	ErrVersionAlreadyInstalled = errors.New("version already installed")
+	ErrUpdateCheckUnsupported  = errors.New("update check not supported")
+	ErrCheckingUpdates         = errors.New("error checking for updates")
+)
+
+// Plugin errors.
 var (
-	ErrInstanceAlreadyExists      = errors.New("instance already exists")
-	ErrProfileDoesNotExist        = errors.New("profile does not exist")
-	ErrInstanceNotRunning         = errors.New("instance is not running")
-	ErrInstanceNotFound           = errors.New("instance not found")
-	ErrOptionWithoutValue         = errors.New("option without value")
-	ErrMonitoringTargetPortNotSet = errors.New("monitoring target port is not set")
-	ErrInstanceHasNoPlugin        = errors.New("instance has no plugin")
-	ErrVersionOrCommitNotSet      = errors.New("version or commit not set")
 	ErrPluginPathNotInsidePackage = errors.New("plugin path is not inside package")
 	ErrUnknownPluginType          = errors.New("unknown plugin type")
-	ErrInvalidUpdateVersion       = errors.New("invalid update version")
-	ErrInvalidUpdateCommit        = errors.New("invalid update commit")
-	ErrOptionNotSet               = errors.New("option not set")
-	ErrVersionAlreadyInstalled    = errors.New("version already installed")
-	ErrBackupNotFound             = errors.New("backup not found")
+	ErrInvalidPluginBind          = errors.New("invalid plugin bind")
+)
+
+// Health errors. ErrUnexpectedStatusCode is the error of a health check that
+// got a response, but not a healthy one.
+var (
+	ErrHealthCheckTimeout   = errors.New("health check timeout")
+	ErrUnexpectedStatusCode = errors.New("unexpected status code")
+)
+
+// Backup errors.
+var (
+	ErrBackupNotFound        = errors.New("backup not found")
+	ErrInvalidBackupInterval = errors.New("invalid backup interval")
+	ErrInvalidCompression    = errors.New("invalid compression")
+)
+
+// Monitoring errors.
+var (
+	ErrMonitoringTargetPortNotSet   = errors.New("monitoring target port is not set")
+	ErrMonitoringTargetNotFound     = errors.New("monitoring target not found")
+	ErrMonitoringStackNotRunning    = errors.New("monitoring stack is not running")
+	ErrMonitoringStackNotInstalled  = errors.New("monitoring stack is not installed")
+	ErrMonitoringStackRunning       = errors.New("monitoring stack is running")
+	ErrInvalidMonitoringPermissions = errors.New("invalid monitoring permissions")
+)
+
+// Environment errors, of the host egn runs in.
+var (
+	ErrInsufficientResources = errors.New("insufficient resources")
+	ErrRuntimeUnavailable    = errors.New("container runtime unavailable")
 )
 
 // InvalidOptionValueError is returned when an Option's value is invalid.
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/profile"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newErrorTestDaemon returns a daemon with an empty data directory and mocks
+// without expectations.
+func newErrorTestDaemon(t *testing.T) *EgnDaemon {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	locker := mock_locker.NewMockLocker(ctrl)
+	locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+	dataDir, err := data.NewDataDir("/egn", afero.NewMemMapFs(), locker)
+	require.NoError(t, err)
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+	require.NoError(t, err)
+	return daemon
+}
+
+func TestInstanceNotFoundErrors(t *testing.T) {
+	const instanceId = "mock-avs-default"
+	ts := []struct {
+		name string
+		call func(d *EgnDaemon) error
+	}{
+		{"PullUpdate", func(d *EgnDaemon) error {
+			_, err := d.PullUpdate(context.Background(), instanceId, PullTarget{Version: "v3.1.0"})
+			return err
+		}},
+		{"LocalPullUpdate", func(d *EgnDaemon) error {
+			_, err := d.LocalPullUpdate(instanceId, &bytes.Buffer{})
+			return err
+		}},
+		{"InstanceVersion", func(d *EgnDaemon) error {
+			_, err := d.InstanceVersion(instanceId)
+			return err
+		}},
+		{"Run", func(d *EgnDaemon) error { return d.Run(context.Background(), instanceId, RunOptions{}) }},
+		{"Stop", func(d *EgnDaemon) error { return d.Stop(context.Background(), instanceId) }},
+		{"RunPlugin", func(d *EgnDaemon) error { return d.RunPlugin(instanceId, nil, RunPluginOptions{}) }},
+		{"NodeLogs", func(d *EgnDaemon) error {
+			return d.NodeLogs(context.Background(), &bytes.Buffer{}, instanceId, NodeLogsOptions{})
+		}},
+		{"Exec", func(d *EgnDaemon) error { return d.Exec(instanceId, []string{"ls"}, ExecOptions{}) }},
+		{"GetConfig", func(d *EgnDaemon) error {
+			_, err := d.GetConfig(instanceId)
+			return err
+		}},
+		{"SetConfig", func(d *EgnDaemon) error { return d.SetConfig(instanceId, "KEY", "value") }},
+		{"Label", func(d *EgnDaemon) error {
+			_, err := d.Label(instanceId, map[string]string{"env": "prod"}, nil)
+			return err
+		}},
+		{"Backup", func(d *EgnDaemon) error {
+			_, err := d.Backup(instanceId, BackupOptions{})
+			return err
+		}},
+		{"StreamBackup", func(d *EgnDaemon) error {
+			_, err := d.StreamBackup(instanceId, &bytes.Buffer{}, StreamBackupOptions{})
+			return err
+		}},
+		{"CheckUpdates", func(d *EgnDaemon) error {
+			_, err := d.CheckUpdates(context.Background(), instanceId)
+			return err
+		}},
+		{"ScheduleBackup", func(d *EgnDaemon) error {
+			return d.ScheduleBackup(context.Background(), instanceId, time.Hour, RetentionPolicy{}, BackupOptions{})
+		}},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(newErrorTestDaemon(t))
+			assert.ErrorIs(t, err, ErrInstanceNotFound)
+		})
+	}
+
+	// Uninstalling a missing instance is not an error, to clean up the
+	// leftovers of incomplete installations
+	assert.NoError(t, newErrorTestDaemon(t).Uninstall(instanceId))
+}
+
+func TestErrorsIs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ts := []struct {
+		name string
+		call func(d *EgnDaemon) error
+		err  error
+	}{
+		{
+			name: "unknown option type",
+			call: func(d *EgnDaemon) error {
+				_, err := optionFromProfileOption(profile.Option{Name: "option", Type: "unknown"})
+				return err
+			},
+			err: ErrUnknownOptionType,
+		},
+		{
+			name: "unexpected health status code",
+			call: func(d *EgnDaemon) error {
+				_, err := checkHealth(serverURL.Hostname(), serverURL.Port())
+				return err
+			},
+			err: ErrUnexpectedStatusCode,
+		},
+		{
+			name: "invalid backup interval",
+			call: func(d *EgnDaemon) error {
+				return d.ScheduleBackup(context.Background(), "mock-avs-default", 0, RetentionPolicy{}, BackupOptions{})
+			},
+			err: ErrInvalidBackupInterval,
+		},
+		{
+			name: "invalid git options",
+			call: func(d *EgnDaemon) error {
+				return d.SetGitOptions(GitOptions{ExtraHeaders: []string{"no-colon"}})
+			},
+			err: ErrInvalidGitOptions,
+		},
+		{
+			name: "invalid registry options",
+			call: func(d *EgnDaemon) error {
+				return d.SetRegistryOptions(RegistryOptions{Auths: []string{"ghcr.io"}})
+			},
+			err: ErrInvalidRegistryOptions,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(newErrorTestDaemon(t))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -4,3 +4,4 @@ package daemon
 //go:generate mockgen -destination=./mocks/compose.go -package=mocks github.com/NethermindEth/eigenlayer/pkg/daemon ComposeManager
 //go:generate mockgen -destination=./mocks/docker.go -package=mocks github.com/NethermindEth/eigenlayer/pkg/daemon DockerManager
 //go:generate mockgen -destination=./mocks/backup.go -package=mocks github.com/NethermindEth/eigenlayer/pkg/daemon BackupManager
+//go:generate mockgen -destination=./mocks/hooks.go -package=mocks github.com/NethermindEth/eigenlayer/pkg/daemon HookRunner
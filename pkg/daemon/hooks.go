@@ -0,0 +1,14 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/NethermindEth/eigenlayer/internal/hooks"
+)
+
+// HookRunner runs the user-defined hooks of the instance lifecycle events.
+type HookRunner interface {
+	// Run runs the hook of the event, if any, with the given metadata. An
+	// error returned for a pre-event vetoes the operation.
+	Run(ctx context.Context, event hooks.Event, metadata map[string]string) error
+}
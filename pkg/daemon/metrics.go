@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"context"
+	"io"
+
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+)
+
+// MetricsRecorder records the outcome of the operations of the daemon.
+type MetricsRecorder interface {
+	// Observe counts an operation and, if err is not nil, an error of the
+	// operation.
+	Observe(operation string, err error)
+}
+
+// metricsDaemon is a Daemon that records the installs and runs of the
+// wrapped Daemon.
+type metricsDaemon struct {
+	Daemon
+	metrics MetricsRecorder
+}
+
+var _ Daemon = &metricsDaemon{}
+
+// WithMetrics returns a Daemon that records the installs and runs of the given
+// Daemon, and their errors, in the given MetricsRecorder. Only the calls made
+// through the returned Daemon are recorded. The backups are recorded by the
+// EgnDaemon itself, so the scheduled ones are counted too: if d is an
+// EgnDaemon, its backups are recorded in m as well.
+func WithMetrics(d Daemon, m MetricsRecorder) Daemon {
+	if egnDaemon, ok := d.(*EgnDaemon); ok {
+		egnDaemon.metrics = m
+	}
+	return &metricsDaemon{
+		Daemon:  d,
+		metrics: m,
+	}
+}
+
+func (d *metricsDaemon) Install(options InstallOptions) (InstallResult, error) {
+	result, err := d.Daemon.Install(options)
+	d.metrics.Observe(metrics.OperationInstall, err)
+	return result, err
+}
+
+func (d *metricsDaemon) LocalInstall(pkgTar io.Reader, options LocalInstallOptions) (string, error) {
+	instanceId, err := d.Daemon.LocalInstall(pkgTar, options)
+	d.metrics.Observe(metrics.OperationInstall, err)
+	return instanceId, err
+}
+
+func (d *metricsDaemon) Run(ctx context.Context, instanceId string, options RunOptions) error {
+	err := d.Daemon.Run(ctx, instanceId, options)
+	d.metrics.Observe(metrics.OperationRun, err)
+	return err
+}
+
+// observe records the operation in the metrics of the daemon, if any.
+func (d *EgnDaemon) observe(operation string, err error) {
+	if d.metrics != nil {
+		d.metrics.Observe(operation, err)
+	}
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDaemon is a Daemon whose instrumented operations return err.
+type fakeDaemon struct {
+	Daemon
+	err error
+}
+
+func (d *fakeDaemon) Install(InstallOptions) (InstallResult, error) {
+	return InstallResult{}, d.err
+}
+
+func (d *fakeDaemon) LocalInstall(io.Reader, LocalInstallOptions) (string, error) {
+	return "", d.err
+}
+
+func (d *fakeDaemon) Run(context.Context, string, RunOptions) error {
+	return d.err
+}
+
+type observation struct {
+	operation string
+	err       error
+}
+
+type recorder []observation
+
+func (r *recorder) Observe(operation string, err error) {
+	*r = append(*r, observation{operation, err})
+}
+
+func TestWithMetrics(t *testing.T) {
+	for _, opErr := range []error{nil, errors.New("operation failed")} {
+		var r recorder
+		d := WithMetrics(&fakeDaemon{err: opErr}, &r)
+
+		_, err := d.Install(InstallOptions{})
+		assert.Equal(t, opErr, err)
+		_, err = d.LocalInstall(strings.NewReader(""), LocalInstallOptions{})
+		assert.Equal(t, opErr, err)
+		assert.Equal(t, opErr, d.Run(context.Background(), "mock-avs-default", RunOptions{}))
+
+		assert.Equal(t, recorder{
+			{metrics.OperationInstall, opErr},
+			{metrics.OperationInstall, opErr},
+			{metrics.OperationRun, opErr},
+		}, r)
+	}
+}
+
+func TestEgnDaemonBackupMetrics(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	ctrl := gomock.NewController(t)
+	locker := mock_locker.NewMockLocker(ctrl)
+	locker.EXPECT().New(gomock.Any()).Return(locker).AnyTimes()
+	dataDir, err := data.NewDataDir("/egn", afs, locker)
+	require.NoError(t, err)
+	initInstanceDir(t, afs, "/egn", "mock-avs-default", `{"name": "mock-avs", "tag": "default", "version": "v0.1.0", "profile": "health-checker", "url": "https://github.com/NethermindEth/mock-avs-pkg"}`)
+	composeManager := mocks.NewMockComposeManager(ctrl)
+	composeManager.EXPECT().PS(gomock.Any()).Return(nil, nil).AnyTimes()
+	composeManager.EXPECT().Stop(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	backupManager := mocks.NewMockBackupManager(ctrl)
+	backupManager.EXPECT().BackupInstance("mock-avs-default", data.CompressionNone, nil).Return("", assert.AnError).Times(2)
+	egnDaemon, err := NewEgnDaemon(dataDir, composeManager, nil, nil, backupManager, locker, log.StandardLogger())
+	require.NoError(t, err)
+	var r recorder
+	d := WithMetrics(egnDaemon, &r)
+
+	// The backups are recorded by the EgnDaemon, so the ones it takes on its
+	// own, like the scheduled backups, are counted too
+	_, err = d.Backup("mock-avs-default", BackupOptions{})
+	require.ErrorIs(t, err, assert.AnError)
+	_, err = d.StreamBackup("mock-avs-other", io.Discard, StreamBackupOptions{})
+	require.ErrorIs(t, err, ErrInstanceNotFound)
+	egnDaemon.backupCycle("mock-avs-default", RetentionPolicy{}, BackupOptions{})
+
+	assert.Equal(t, recorder{
+		{metrics.OperationBackup, assert.AnError},
+		{metrics.OperationBackup, fmt.Errorf("%w: mock-avs-other", ErrInstanceNotFound)},
+		{metrics.OperationBackup, assert.AnError},
+	}, r)
+}
@@ -1,7 +1,11 @@
 package daemon
 
 import (
+	"context"
+
 	"github.com/NethermindEth/eigenlayer/internal/common"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
 )
 
@@ -9,8 +13,12 @@ type MonitoringManager interface {
 	// Init initializes the monitoring stack. Assumes that the stack is already installed.
 	Init() error
 
+	// SetStackPermissions sets the permissions and ownership of the
+	// directories and files created in the monitoring stack from now on.
+	SetStackPermissions(perms data.StackPermissions)
+
 	// InstallStack installs the monitoring stack.
-	InstallStack() error
+	InstallStack(ctx context.Context) error
 
 	// AddTarget adds a new target to all services in the monitoring stack.
 	// It also connects the target to the docker network of the monitoring stack if it isn't already connected.
@@ -21,6 +29,22 @@ type MonitoringManager interface {
 	// The dockerNetwork is the name of the network the node is connected to.
 	RemoveTarget(endpoint string) error
 
+	// ReconcileTargets removes the targets of the monitoring stack whose
+	// instance ID is not in the given active instance IDs.
+	ReconcileTargets(active []string) error
+
+	// AddRules adds the given alerting rule files of the instance to the
+	// services of the monitoring stack that support them.
+	AddRules(instanceID string, rules [][]byte) error
+
+	// AddDashboards adds the given dashboards of the instance, keyed by their
+	// file name, to the services of the monitoring stack that support them.
+	AddDashboards(instanceID string, dashboards map[string][]byte) error
+
+	// StackStatus returns the status of the containers and the ports of the
+	// monitoring services and the health of the scrape targets.
+	StackStatus() (monitoring.StackStatus, error)
+
 	// Status returns the status of the monitoring stack.
 	Status() (common.Status, error)
 
@@ -36,6 +60,13 @@ type MonitoringManager interface {
 	// Cleanup removes the monitoring stack. If force is true, it will remove the stack directly bypassing any checks.
 	Cleanup(force bool) error
 
+	// ValidateProvisioning checks the files provisioned in the monitoring stack.
+	ValidateProvisioning() error
+
+	// CheckPorts checks that the ports of the monitoring services are not in
+	// use by other processes. The monitoring stack must not be running.
+	CheckPorts() error
+
 	// ServiceEndpoints returns the endpoints of the monitoring services.
 	ServiceEndpoints() map[string]string
 }
@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"github.com/docker/docker/client"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/NethermindEth/eigenlayer/internal/backup"
+	"github.com/NethermindEth/eigenlayer/internal/commands"
+	"github.com/NethermindEth/eigenlayer/internal/compose"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/docker"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
+	// Monitoring services register themselves in the monitoring registry
+	_ "github.com/NethermindEth/eigenlayer/pkg/monitoring/services/cadvisor"
+	_ "github.com/NethermindEth/eigenlayer/pkg/monitoring/services/grafana"
+	_ "github.com/NethermindEth/eigenlayer/pkg/monitoring/services/node_exporter"
+	_ "github.com/NethermindEth/eigenlayer/pkg/monitoring/services/prometheus"
+)
+
+// Options configures the Daemon built by New. The zero value builds the same
+// Daemon the egn CLI uses.
+type Options struct {
+	// DataDir is the directory where the instances and backups are stored,
+	// and where ExportMonitoring and ImportMonitoring read and write the
+	// monitoring stack. If empty, $EGN_DATA_DIR is used, or the .eigen
+	// directory inside the user data directory of the OS, like ~/.local/share
+	// on Linux. The monitoring stack the Daemon installs and runs is stored in
+	// that default directory even if DataDir is set.
+	DataDir string
+
+	// DockerClient is the client of the docker daemon. If nil, a client is
+	// configured from the DOCKER_* environment variables, like the docker CLI
+	// does, and closed by Daemon.Close. A given client is not closed by it.
+	DockerClient client.APIClient
+
+	// Logger is the logger of the Daemon and its managers. If nil, the logrus
+	// standard logger is used.
+	Logger log.FieldLogger
+
+	// Metrics records the installs, runs and backups of the Daemon, including
+	// the scheduled backups, if not nil. See WithMetrics.
+	Metrics MetricsRecorder
+}
+
+// New creates a Daemon with the docker daemon, the docker compose plugin, the
+// monitoring stack and the backups of the host, configured by opts. It is the
+// entrypoint to drive egn from Go code, and creating it does not connect to
+// the docker daemon: CheckRuntime checks the docker daemon and the docker
+// compose plugin are available. The installs and uninstalls of the Daemon
+// wait for the ones of other processes using the same data directory, and
+// fail with ErrOperationInProgress if they don't finish in time. New fails if
+// an asset embedded for the monitoring stack is invalid, see
+// monitoring.ValidateEmbeddedTemplates.
+func New(opts Options) (d Daemon, err error) {
+	if err := monitoring.ValidateEmbeddedTemplates(); err != nil {
+		return nil, err
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.StandardLogger()
+	}
+	dockerClient := opts.DockerClient
+	var ownedClient *client.Client
+	if dockerClient == nil {
+		ownedClient, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				ownedClient.Close()
+			}
+		}()
+		dockerClient = ownedClient
+	}
+
+	runner := commands.NewCMDRunner()
+	dockerManager := docker.NewDockerManager(dockerClient)
+	composeManager := compose.NewComposeManager(&runner)
+	fs := afero.NewOsFs()
+	fileLocker := locker.NewFLock()
+
+	var dataDir *data.DataDir
+	if opts.DataDir != "" {
+		if err = fs.MkdirAll(opts.DataDir, 0o755); err != nil {
+			return nil, err
+		}
+		dataDir, err = data.NewDataDir(opts.DataDir, fs, fileLocker)
+	} else {
+		dataDir, err = data.NewDataDirDefault(fs, fileLocker)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	monitoringManager := monitoring.NewMonitoringManager(
+		monitoring.RegisteredServices(),
+		composeManager,
+		dockerManager,
+		fs,
+		fileLocker,
+		logger,
+	)
+	backupManager := backup.NewBackupManager(fs, dataDir, dockerManager, composeManager, logger)
+
+	egnDaemon, err := NewEgnDaemon(dataDir, composeManager, dockerManager, monitoringManager, backupManager, fileLocker, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Serialize the installs and uninstalls of concurrent egn processes
+	egnDaemon.operationLocker = locker.NewFLock()
+	if ownedClient != nil {
+		egnDaemon.dockerClient = ownedClient
+	}
+
+	d = egnDaemon
+	if opts.Metrics != nil {
+		d = WithMetrics(d, opts.Metrics)
+	}
+	return d, nil
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/internal/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	// The monitoring stack is stored in the default data directory
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+
+	t.Run("default data directory", func(t *testing.T) {
+		d, err := New(Options{Logger: logger})
+		require.NoError(t, err)
+		instances, err := d.ListInstances()
+		require.NoError(t, err)
+		assert.Empty(t, instances)
+		assert.DirExists(t, filepath.Join(os.Getenv("XDG_DATA_HOME"), ".eigen"))
+		assert.NoError(t, d.Close())
+	})
+
+	dataDir := filepath.Join(t.TempDir(), "egn")
+	d, err := New(Options{DataDir: dataDir, Logger: logger, Metrics: metrics.New()})
+	require.NoError(t, err)
+	defer d.Close()
+	instances, err := d.ListInstances()
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+
+	// Install an instance by hand, as the package install needs the network
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "nodes", "mock-avs-default"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "nodes", "mock-avs-default", "state.json"), []byte(`{"name":"mock-avs","url":"https://github.com/NethermindEth/mock-avs-pkg","version":"v3.0.3","commit":"a3406616b848164358fdd24465b8eecda5f5ae34","profile":"option-returner","tag":"default"}`), 0o644))
+	assert.True(t, d.HasInstance("mock-avs-default"))
+	version, err := d.InstanceVersion("mock-avs-default")
+	require.NoError(t, err)
+	assert.Equal(t, "v3.0.3", version.Version)
+	labels, err := d.Label("mock-avs-default", map[string]string{"env": "prod"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, labels)
+
+	// The failures are reported with sentinel errors
+	_, err = d.InstanceVersion("mock-avs-other")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+	err = d.Run(context.Background(), "mock-avs-other", RunOptions{})
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+	err = d.Stop(context.Background(), "mock-avs-other")
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+	_, err = d.Backup("mock-avs-other", BackupOptions{})
+	assert.ErrorIs(t, err, ErrInstanceNotFound)
+	_, err = d.Backup("mock-avs-default", BackupOptions{Compression: "bzip2"})
+	assert.ErrorIs(t, err, ErrInvalidCompression)
+	err = d.Restore("mock-avs-other-1696317683", RestoreOptions{})
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+	_, err = d.Label("mock-avs-default", map[string]string{"instance_id": "other"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidLabel)
+}
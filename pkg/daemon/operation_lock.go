@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// operationLockFile is the lock file in the data directory that
+	// serializes the operations changing the instances.
+	operationLockFile = ".operation.lock"
+	// defaultOperationLockTimeout is the time an operation waits for another
+	// one to finish before failing with ErrOperationInProgress.
+	defaultOperationLockTimeout = 5 * time.Minute
+	// operationLockRetryDelay is the time between the attempts to acquire the
+	// operation lock.
+	operationLockRetryDelay = 100 * time.Millisecond
+)
+
+// lockOperation acquires the lock of the data directory that serializes the
+// operations changing the installed instances, like Install and Uninstall, so
+// concurrent egn processes don't race on the data directory and the
+// monitoring provisioning. It waits for the lock up to the operation lock
+// timeout, and returns an ErrOperationInProgress error if another operation
+// still holds it. The returned function releases the lock.
+//
+// Operations are not serialized if the daemon has no operation locker, like
+// when it is created with NewEgnDaemon, see New.
+func (d *EgnDaemon) lockOperation() (unlock func(), err error) {
+	if d.operationLocker == nil {
+		return func() {}, nil
+	}
+	lock := d.operationLocker.New(filepath.Join(d.dataDir.Path(), operationLockFile))
+	ctx, cancel := context.WithTimeout(context.Background(), d.operationLockTimeout)
+	defer cancel()
+	locked, err := lock.TryLockContext(ctx, operationLockRetryDelay)
+	if !locked {
+		if err == nil || errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: the data directory %s is locked by another egn process. Try again once it finishes", ErrOperationInProgress, d.dataDir.Path())
+		}
+		return nil, err
+	}
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			d.logger.WithError(err).Warn("Failed to release the operation lock")
+		}
+	}, nil
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/common"
+	"github.com/NethermindEth/eigenlayer/internal/compose"
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
+	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentUninstall(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// err is the error of the uninstall that runs while the first one
+		// holds the lock
+		err error
+	}{
+		{
+			name:    "second uninstall waits",
+			timeout: 10 * time.Second,
+		},
+		{
+			name:    "second uninstall times out",
+			timeout: 200 * time.Millisecond,
+			err:     ErrOperationInProgress,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewOsFs()
+			dataDirPath := t.TempDir()
+			instanceLocker := mock_locker.NewMockLocker(ctrl)
+			instanceLocker.EXPECT().New(gomock.Any()).Return(instanceLocker).AnyTimes()
+			dataDir, err := data.NewDataDir(dataDirPath, afs, instanceLocker)
+			require.NoError(t, err)
+			initInstanceDir(t, afs, dataDirPath, "mock-avs-default", `{"name": "mock-avs", "tag": "default"}`)
+
+			// The first uninstall is stopped in docker compose down until
+			// the second one is running
+			composeManager := mocks.NewMockComposeManager(ctrl)
+			monitoringManager := mocks.NewMockMonitoringManager(ctrl)
+			monitoringManager.EXPECT().InstallationStatus().Return(common.NotInstalled, nil)
+			downStarted, releaseDown := make(chan struct{}), make(chan struct{})
+			composeManager.EXPECT().Down(compose.DockerComposeDownOptions{
+				Path:    filepath.Join(dataDirPath, "nodes", "mock-avs-default", "docker-compose.yml"),
+				Volumes: true,
+			}).DoAndReturn(func(compose.DockerComposeDownOptions) error {
+				close(downStarted)
+				<-releaseDown
+				return nil
+			})
+
+			daemon, err := NewEgnDaemon(dataDir, composeManager, mocks.NewMockDockerManager(ctrl), monitoringManager, mocks.NewMockBackupManager(ctrl), instanceLocker, log.StandardLogger())
+			require.NoError(t, err)
+			daemon.operationLocker = locker.NewFLock()
+			daemon.operationLockTimeout = tt.timeout
+
+			var (
+				wg       sync.WaitGroup
+				firstErr error
+			)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				firstErr = daemon.Uninstall("mock-avs-default")
+			}()
+			<-downStarted
+
+			// The second uninstall finds the instance removed once the first
+			// one finishes, or times out waiting for it
+			time.AfterFunc(500*time.Millisecond, func() { close(releaseDown) })
+			err = daemon.Uninstall("mock-avs-default")
+			wg.Wait()
+			require.NoError(t, firstErr)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.False(t, dataDir.HasInstance("mock-avs-default"))
+		})
+	}
+}
+
+func TestOperationInProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dataDirPath := t.TempDir()
+	dataDir, err := data.NewDataDir(dataDirPath, afero.NewOsFs(), mock_locker.NewMockLocker(ctrl))
+	require.NoError(t, err)
+
+	daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), mock_locker.NewMockLocker(ctrl), log.StandardLogger())
+	require.NoError(t, err)
+	daemon.operationLocker = locker.NewFLock()
+	daemon.operationLockTimeout = 200 * time.Millisecond
+
+	// Another egn process holds the lock
+	other := locker.NewFLock().New(filepath.Join(dataDirPath, operationLockFile))
+	require.NoError(t, other.Lock())
+	defer other.Unlock()
+
+	_, err = daemon.Install(InstallOptions{Name: "mock-avs", Tag: "default"})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	_, err = daemon.LocalInstall(&bytes.Buffer{}, LocalInstallOptions{Name: "mock-avs", Tag: "default"})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	err = daemon.Uninstall("mock-avs-default")
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	_, err = daemon.Prune(PruneOptions{Images: true})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	err = daemon.Restore("backup-id", RestoreOptions{})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	_, err = daemon.PullUpdate(context.Background(), "mock-avs-default", PullTarget{})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+	_, err = daemon.LocalPullUpdate("mock-avs-default", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrOperationInProgress)
+
+	// Nothing is left behind in the data directory
+	assert.False(t, dataDir.HasInstance("mock-avs-default"))
+}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"fmt"
+	"math"
 	"net/url"
 	"path/filepath"
 	"regexp"
@@ -703,6 +704,9 @@ type OptionPort struct {
 	option
 	value    *int
 	defValue int
+	validate bool
+	MinValue int
+	MaxValue int
 }
 
 func NewOptionPort(pkgOption profile.Option) (*OptionPort, error) {
@@ -710,7 +714,7 @@ func NewOptionPort(pkgOption profile.Option) (*OptionPort, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &OptionPort{
+	op := &OptionPort{
 		option: option{
 			name:   pkgOption.Name,
 			target: pkgOption.Target,
@@ -718,7 +722,21 @@ func NewOptionPort(pkgOption profile.Option) (*OptionPort, error) {
 			hidden: pkgOption.Hidden,
 		},
 		defValue: defaultValue,
-	}, nil
+		validate: pkgOption.ValidateDef != nil,
+	}
+	if op.validate {
+		op.MinValue, op.MaxValue = pkgOption.PortRange()
+	}
+	return op, nil
+}
+
+// portRange returns the range of the valid ports of the option, 1 to 65535
+// unless the profile narrows it.
+func (op *OptionPort) portRange() (int, int) {
+	if op.validate {
+		return op.MinValue, op.MaxValue
+	}
+	return 1, math.MaxUint16
 }
 
 var _ Option = (*OptionPort)(nil)
@@ -728,7 +746,8 @@ func (op *OptionPort) Name() string {
 }
 
 func (op *OptionPort) Help() string {
-	return fmt.Sprintf("%s (min: 0, max: 65535)", op.option.help)
+	minPort, maxPort := op.portRange()
+	return fmt.Sprintf("%s (min: %d, max: %d)", op.option.help, minPort, maxPort)
 }
 
 func (op *OptionPort) Hidden() bool {
@@ -746,11 +765,11 @@ func (op *OptionPort) Set(value string) error {
 		}
 	}
 
-	if port < 0 || port > 65535 {
+	if minPort, maxPort := op.portRange(); port < minPort || port > maxPort {
 		return InvalidOptionValueError{
 			optionName: op.name,
 			value:      value,
-			msg:        "it is not a valid port. Port must be between 0 and 65535",
+			msg:        fmt.Sprintf("it is not a valid port. Port must be between %d and %d", minPort, maxPort),
 			hidden:     op.hidden,
 		}
 	}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NethermindEth/eigenlayer/internal/profile"
+)
+
+func TestOptionPortSet(t *testing.T) {
+	nonPrivileged := float64(1024)
+	tests := []struct {
+		name     string
+		validate *profile.Validate
+		value    string
+		err      string
+	}{
+		{
+			name:  "valid port",
+			value: "8080",
+		},
+		{
+			name:  "zero",
+			value: "0",
+			err:   "invalid value for option main-port: 0. it is not a valid port. Port must be between 1 and 65535",
+		},
+		{
+			name:  "out of range",
+			value: "70000",
+			err:   "invalid value for option main-port: 70000. it is not a valid port. Port must be between 1 and 65535",
+		},
+		{
+			name:  "not a number",
+			value: "abc",
+			err:   "invalid value for option main-port: abc. it is not a valid port number",
+		},
+		{
+			name:     "privileged port",
+			validate: &profile.Validate{MinValue: &nonPrivileged},
+			value:    "80",
+			err:      "invalid value for option main-port: 80. it is not a valid port. Port must be between 1024 and 65535",
+		},
+		{
+			name:     "non-privileged port",
+			validate: &profile.Validate{MinValue: &nonPrivileged},
+			value:    "8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := NewOptionPort(profile.Option{
+				Name:        "main-port",
+				Target:      "MAIN_PORT",
+				Type:        "port",
+				Default:     "8080",
+				Help:        "Main service server port",
+				ValidateDef: tt.validate,
+			})
+			require.NoError(t, err)
+
+			err = op.Set(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.False(t, op.IsSet())
+			} else {
+				require.NoError(t, err)
+				value, err := op.Value()
+				require.NoError(t, err)
+				assert.Equal(t, tt.value, value)
+			}
+		})
+	}
+}
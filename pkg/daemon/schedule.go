@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	log "github.com/sirupsen/logrus"
+)
+
+// runScheduled runs job on every tick received from ticks until the context is
+// canceled. Ticks received while the previous run is still in progress are
+// skipped. Once the context is canceled, runScheduled waits for the run in
+// progress to finish before returning.
+func runScheduled(ctx context.Context, ticks <-chan time.Time, logger log.FieldLogger, job func()) {
+	// done is nil while there is no run in progress
+	var done chan struct{}
+	for {
+		select {
+		case <-ctx.Done():
+			if done != nil {
+				logger.Info("Waiting for the backup in progress to finish...")
+				<-done
+			}
+			return
+		case <-done:
+			done = nil
+		case <-ticks:
+			if done != nil {
+				logger.Warn("Previous backup is still running, skipping cycle")
+				continue
+			}
+			done = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				job()
+			}(done)
+		}
+	}
+}
+
+// backupsToPrune returns the backups that are not kept by the retention policy
+// at the given time. The most recent backup is always kept.
+func backupsToPrune(backups []data.Backup, retention RetentionPolicy, now time.Time) []data.Backup {
+	sorted := make([]data.Backup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	var prune []data.Backup
+	for i, b := range sorted {
+		if i == 0 {
+			continue
+		}
+		if retention.KeepLast > 0 && i >= retention.KeepLast {
+			prune = append(prune, b)
+		} else if retention.MaxAge > 0 && now.Sub(b.Timestamp) > retention.MaxAge {
+			prune = append(prune, b)
+		}
+	}
+	return prune
+}
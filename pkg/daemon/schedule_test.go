@@ -0,0 +1,290 @@
+package daemon
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer/internal/data"
+	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/daemon/mocks"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScheduled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var runs atomic.Int32
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		runScheduled(ctx, ticks, log.StandardLogger(), func() {
+			runs.Add(1)
+			started <- struct{}{}
+			<-release
+		})
+	}()
+
+	now := time.Now()
+	ticks <- now
+	<-started
+
+	// The previous run is still in progress, so the cycle is skipped
+	ticks <- now.Add(time.Minute)
+	assert.Equal(t, int32(1), runs.Load())
+
+	// Once the run finishes, the next tick starts a new run
+	release <- struct{}{}
+	require.Eventually(t, func() bool {
+		select {
+		case ticks <- now.Add(2 * time.Minute):
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+		select {
+		case <-started:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, time.Second, 20*time.Millisecond)
+	assert.Equal(t, int32(2), runs.Load())
+
+	// Canceling waits for the run in progress to finish
+	cancel()
+	select {
+	case <-exited:
+		t.Fatal("runScheduled returned before the run in progress finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release <- struct{}{}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("runScheduled did not return after the context was canceled")
+	}
+	assert.Equal(t, int32(2), runs.Load())
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	now := time.Date(2023, 10, 4, 12, 0, 0, 0, time.UTC)
+	backup := func(age time.Duration) data.Backup {
+		return data.Backup{InstanceId: "mock-avs-default", Timestamp: now.Add(-age)}
+	}
+	backups := []data.Backup{
+		backup(3 * time.Hour),
+		backup(time.Hour),
+		backup(4 * time.Hour),
+		backup(2 * time.Hour),
+	}
+
+	ts := []struct {
+		name      string
+		backups   []data.Backup
+		retention RetentionPolicy
+		want      []data.Backup
+	}{
+		{
+			name:    "zero policy keeps everything",
+			backups: backups,
+		},
+		{
+			name:      "keep last",
+			backups:   backups,
+			retention: RetentionPolicy{KeepLast: 2},
+			want:      []data.Backup{backup(3 * time.Hour), backup(4 * time.Hour)},
+		},
+		{
+			name:      "max age",
+			backups:   backups,
+			retention: RetentionPolicy{MaxAge: 150 * time.Minute},
+			want:      []data.Backup{backup(3 * time.Hour), backup(4 * time.Hour)},
+		},
+		{
+			name:      "keep last and max age",
+			backups:   backups,
+			retention: RetentionPolicy{KeepLast: 3, MaxAge: 90 * time.Minute},
+			want:      []data.Backup{backup(2 * time.Hour), backup(3 * time.Hour), backup(4 * time.Hour)},
+		},
+		{
+			name:      "most recent backup is always kept",
+			backups:   backups,
+			retention: RetentionPolicy{MaxAge: time.Minute},
+			want:      []data.Backup{backup(2 * time.Hour), backup(3 * time.Hour), backup(4 * time.Hour)},
+		},
+		{
+			name:      "no backups",
+			retention: RetentionPolicy{KeepLast: 1},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backupsToPrune(tt.backups, tt.retention, now)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// writeBackupTar writes a backup tar file of the mock-avs instance with the
+// given tag, with the state and timestamp read by the backup list, and returns
+// the backup ID.
+func writeBackupTar(t *testing.T, afs afero.Fs, dataDir *data.DataDir, tag string, timestamp time.Time) string {
+	t.Helper()
+	backup := data.Backup{InstanceId: "mock-avs-" + tag, Timestamp: timestamp, Version: "v3.0.3"}
+	path := dataDir.BackupPath(backup.Id())
+	f, err := afs.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	files := map[string]string{
+		"data/state.json": `{"name":"mock-avs","tag":"` + tag + `","version":"v3.0.3"}`,
+		"timestamp":       strconv.FormatInt(timestamp.Unix(), 10),
+	}
+	for _, name := range []string{"data/state.json", "timestamp"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(files[name])), Mode: 0o644}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return backup.Id()
+}
+
+func TestPruneBackups(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	ts := []struct {
+		name      string
+		retention RetentionPolicy
+		dryRun    bool
+		// pruned are the indexes of the pruned backups, the backups are
+		// created 1h, 2h and 3h ago
+		pruned []int
+	}{
+		{
+			name:      "dry run keeps the backups",
+			retention: RetentionPolicy{KeepLast: 1},
+			dryRun:    true,
+			pruned:    []int{1, 2},
+		},
+		{
+			name:      "dry run with max age",
+			retention: RetentionPolicy{MaxAge: 150 * time.Minute},
+			dryRun:    true,
+			pruned:    []int{2},
+		},
+		{
+			name:      "prune",
+			retention: RetentionPolicy{KeepLast: 1},
+			pruned:    []int{1, 2},
+		},
+		{
+			name:      "nothing to prune",
+			retention: RetentionPolicy{KeepLast: 3},
+			dryRun:    true,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			require.NoError(t, afs.MkdirAll("/egn/backup", 0o755))
+			var ids []string
+			for i := 1; i <= 3; i++ {
+				ids = append(ids, writeBackupTar(t, afs, dataDir, "default", now.Add(-time.Duration(i)*time.Hour)))
+			}
+			// Backups of other instances are never pruned
+			other := writeBackupTar(t, afs, dataDir, "second", now.Add(-5*time.Hour))
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			var want []PrunedBackup
+			for _, i := range tt.pruned {
+				want = append(want, PrunedBackup{Id: ids[i], Path: dataDir.BackupPath(ids[i])})
+			}
+			pruned, err := daemon.PruneBackups("mock-avs-default", tt.retention, PruneBackupsOptions{DryRun: tt.dryRun})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, want, pruned)
+
+			for i, id := range ids {
+				ok, err := afero.Exists(afs, dataDir.BackupPath(id))
+				require.NoError(t, err)
+				removed := !tt.dryRun && slices.Contains(tt.pruned, i)
+				assert.Equal(t, !removed, ok, "backup %d", i)
+			}
+			ok, err := afero.Exists(afs, dataDir.BackupPath(other))
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestPruneEncryptedBackups(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	keyFile := filepath.Join(t.TempDir(), "backup.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("secret"), 0o600))
+	otherKeyFile := filepath.Join(t.TempDir(), "other.key")
+	require.NoError(t, os.WriteFile(otherKeyFile, []byte("other secret"), 0o600))
+
+	ts := []struct {
+		name    string
+		keyFile string
+		pruned  []int
+	}{
+		{
+			name:    "with the key",
+			keyFile: keyFile,
+			pruned:  []int{1, 2},
+		},
+		{
+			name: "without key",
+		},
+		{
+			name:    "with another key",
+			keyFile: otherKeyFile,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			afs := afero.NewMemMapFs()
+			locker := mock_locker.NewMockLocker(ctrl)
+			dataDir, err := data.NewDataDir("/egn", afs, locker)
+			require.NoError(t, err)
+			require.NoError(t, afs.MkdirAll("/egn/backup", 0o755))
+			var ids []string
+			for i := 1; i <= 3; i++ {
+				id := writeBackupTar(t, afs, dataDir, "default", now.Add(-time.Duration(i)*time.Hour))
+				path := dataDir.BackupPath(id)
+				require.NoError(t, data.EncryptBackup(afs, path, path+".enc", data.NewFileKeyProvider(afero.NewOsFs(), keyFile)))
+				require.NoError(t, afs.Rename(path+".enc", path))
+				ids = append(ids, id)
+			}
+
+			daemon, err := NewEgnDaemon(dataDir, mocks.NewMockComposeManager(ctrl), mocks.NewMockDockerManager(ctrl), mocks.NewMockMonitoringManager(ctrl), mocks.NewMockBackupManager(ctrl), locker, log.StandardLogger())
+			require.NoError(t, err)
+
+			var want []PrunedBackup
+			for _, i := range tt.pruned {
+				want = append(want, PrunedBackup{Id: ids[i], Path: dataDir.BackupPath(ids[i])})
+			}
+			pruned, err := daemon.PruneBackups("mock-avs-default", RetentionPolicy{KeepLast: 1}, PruneBackupsOptions{EncryptionKeyFile: tt.keyFile})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, want, pruned)
+		})
+	}
+}
@@ -2,10 +2,22 @@ package daemon
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/NethermindEth/eigenlayer/internal/package_handler"
 	"github.com/NethermindEth/eigenlayer/internal/profile"
 )
 
+// selectProfile returns the profile of the package with the given name, or an
+// ErrProfileDoesNotExist error if there is none.
+func selectProfile(pkgHandler *package_handler.PackageHandler, name string) (*profile.Profile, error) {
+	selected, err := pkgHandler.Profile(name)
+	if errors.Is(err, package_handler.ErrProfileNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileDoesNotExist, name)
+	}
+	return selected, err
+}
+
 func optionsFromProfile(profile *profile.Profile) ([]Option, error) {
 	options := make([]Option, len(profile.Options))
 	for i, o := range profile.Options {
@@ -39,6 +51,6 @@ func optionFromProfileOption(profileOption profile.Option) (Option, error) {
 	case "port":
 		return NewOptionPort(profileOption)
 	default:
-		return nil, errors.New("unknown option type: " + profileOption.Type)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOptionType, profileOption.Type)
 	}
 }
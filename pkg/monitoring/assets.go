@@ -0,0 +1,66 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateEmbeddedTemplates checks the assets embedded in egn for the
+// monitoring stack and its services: the config templates must parse and the
+// static YAML files must be valid YAML. Services validate their assets by
+// implementing EmbeddedAssetsValidator. It returns an ErrInvalidEmbeddedAsset
+// error for each bad asset, joined.
+func ValidateEmbeddedTemplates() error {
+	errs := []error{ValidateAssets(script, composeTemplate)}
+	for _, service := range RegisteredServices() {
+		if validator, ok := service.(EmbeddedAssetsValidator); ok {
+			if err := validator.ValidateEmbeddedAssets(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", service.ContainerName(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateAssets checks the files of fsys. The files in templates are parsed
+// with ParseTemplate, and the other .yml and .yaml files must be valid YAML.
+// Other files are not checked. It returns an ErrInvalidEmbeddedAsset error for
+// each bad file, joined.
+func ValidateAssets(fsys fs.FS, templates ...string) error {
+	var errs []error
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		isTemplate := slices.Contains(templates, p)
+		if ext := path.Ext(p); !isTemplate && ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+		raw, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if isTemplate {
+			_, err = ParseTemplate(path.Base(p), string(raw))
+		} else {
+			var content any
+			err = yaml.Unmarshal(raw, &content)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidEmbeddedAsset, p, err))
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidEmbeddedAsset, err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAssets(t *testing.T) {
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		templates []string
+		bad       []string
+	}{
+		{
+			name: "valid assets",
+			fsys: fstest.MapFS{
+				"config/static.yml":   {Data: []byte("key: value\n")},
+				"config/template.yml": {Data: []byte("port: {{ .Port | default \"9090\" }}\n")},
+				"config/app.ini":      {Data: []byte("[server]\nport = {{ .Port }}\n")},
+				"config/notes.txt":    {Data: []byte("key: [\n")},
+			},
+			templates: []string{"config/template.yml", "config/app.ini"},
+		},
+		{
+			name: "invalid assets",
+			fsys: fstest.MapFS{
+				"config/static.yml":   {Data: []byte("key: [value\n")},
+				"config/other.yaml":   {Data: []byte("key:\n\t- value\n")},
+				"config/template.yml": {Data: []byte("port: {{ .Port | unknown }}\n")},
+				"config/valid.yml":    {Data: []byte("key: value\n")},
+			},
+			templates: []string{"config/template.yml"},
+			bad:       []string{"config/static.yml", "config/other.yaml", "config/template.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAssets(tt.fsys, tt.templates...)
+			if len(tt.bad) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidEmbeddedAsset)
+			assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), len(tt.bad))
+			for _, path := range tt.bad {
+				assert.ErrorContains(t, err, path)
+			}
+			assert.NotContains(t, err.Error(), "config/valid.yml")
+		})
+	}
+}
+
+func TestValidateEmbeddedTemplates(t *testing.T) {
+	assert.NoError(t, ValidateEmbeddedTemplates())
+}
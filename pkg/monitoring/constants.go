@@ -7,6 +7,9 @@ const (
 	GrafanaContainerName      = "egn_grafana"
 	NodeExporterServiceName   = "node_exporter"
 	NodeExporterContainerName = "egn_node_exporter"
+	CadvisorServiceName       = "cadvisor"
+	CadvisorContainerName     = "egn_cadvisor"
+	MonitoringNetwork         = "egn-monitor-network"
 	monitoringPath            = "monitoring"
 	InstanceIDLabel           = "instance_id"
 	CommitHashLabel           = "instance_commit_hash"
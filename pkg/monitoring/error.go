@@ -9,4 +9,9 @@ var (
 	ErrInstallingMonitoringMngr      = errors.New("error installing monitoring manager")
 	ErrConfiguringMonitoringServices = errors.New("error configuring monitoring services")
 	ErrNonexistingTarget             = errors.New("target to remove does not exist")
+	ErrMissingTargetLabel            = errors.New("target label is missing")
+	ErrMissingOption                 = errors.New("required option is missing")
+	ErrReadingSecret                 = errors.New("error reading secret file")
+	ErrPortConflict                  = errors.New("port conflict")
+	ErrInvalidEmbeddedAsset          = errors.New("invalid embedded asset")
 )
@@ -1,17 +1,21 @@
 package monitoring
 
 import (
-	"bytes"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"net"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/NethermindEth/eigenlayer/internal/common"
 	"github.com/NethermindEth/eigenlayer/internal/compose"
 	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/docker"
 	"github.com/NethermindEth/eigenlayer/internal/locker"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
 	log "github.com/sirupsen/logrus"
@@ -29,15 +33,20 @@ type MonitoringManager struct {
 	composeManager ComposeManager
 	dockerManager  DockerManager
 	stack          *data.MonitoringStack
+	logger         log.FieldLogger
+	// targetsMu serializes the changes of the targets, so the instances
+	// started concurrently don't overwrite each other's service configs.
+	targetsMu sync.Mutex
 }
 
-// NewMonitoringManager creates a new MonitoringManager with the given services, compose manager, docker manager, file system, and locker.
+// NewMonitoringManager creates a new MonitoringManager with the given services, compose manager, docker manager, file system, locker, and logger.
 func NewMonitoringManager(
 	services []ServiceAPI,
 	cmpMgr ComposeManager,
 	dockerMgr DockerManager,
 	fs afero.Fs,
 	locker locker.Locker,
+	logger log.FieldLogger,
 ) *MonitoringManager {
 	// Create stack
 	datadir, err := data.NewDataDirDefault(fs, locker)
@@ -54,28 +63,32 @@ func NewMonitoringManager(
 		composeManager: cmpMgr,
 		dockerManager:  dockerMgr,
 		stack:          stack,
+		logger:         logger,
 	}
 }
 
+// SetStackPermissions sets the permissions and ownership of the directories
+// and files the services create in the monitoring stack from now on.
+func (m *MonitoringManager) SetStackPermissions(perms data.StackPermissions) {
+	m.stack.SetPermissions(perms)
+}
+
 // Init initializes the monitoring stack. Assumes that the stack is already installed.
 func (m *MonitoringManager) Init() error {
-	// Read installed .env
-	rawDotEnv, err := m.stack.ReadFile(filepath.Join(".env"))
+	dotEnv, err := m.readDotEnv()
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, err)
 	}
-
-	dotEnv := make(map[string]string)
-	for _, line := range bytes.Split(rawDotEnv, []byte("\n")) {
-		split := bytes.Split(line, []byte("="))
-		if len(split) != 2 {
-			continue
-		}
-		dotEnv[string(split[0])] = string(split[1])
+	services, err := enabledServices(m.services, dotEnv)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, err)
+	}
+	if errs := ValidateOptions(services, dotEnv); len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, errors.Join(errs...))
 	}
 
 	// Initialize stack
-	for _, service := range m.services {
+	for _, service := range services {
 		if err := service.Init(types.ServiceOptions{
 			Stack:  m.stack,
 			Dotenv: dotEnv,
@@ -85,15 +98,72 @@ func (m *MonitoringManager) Init() error {
 	}
 
 	// Save container IPs of monitoring services
-	if err := m.saveServiceIP(); err != nil {
+	if err := m.saveServiceIP(services); err != nil {
 		return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, err)
 	}
 
+	// Scrape the metrics exporters of the stack
+	for _, service := range services {
+		exporter, ok := service.(MetricsExporter)
+		if !ok {
+			continue
+		}
+		labels := map[string]string{InstanceIDLabel: service.ContainerName()}
+		if err := m.AddTarget(exporter.MetricsTarget(), labels, MonitoringNetwork); err != nil {
+			return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, err)
+		}
+	}
+
 	return nil
 }
 
+// readDotEnv returns the variables of the .env file of the installed stack.
+func (m *MonitoringManager) readDotEnv() (map[string]string, error) {
+	rawDotEnv, err := m.stack.ReadFile(".env")
+	if err != nil {
+		return nil, err
+	}
+	dotEnv := make(map[string]string)
+	for _, line := range strings.Split(string(rawDotEnv), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok {
+			dotEnv[k] = v
+		}
+	}
+	return dotEnv, nil
+}
+
+// enabledServices returns the services that are part of the stack with the
+// given .env variables, that is, all of them but the services that implement
+// OptionalService and are not enabled.
+func enabledServices(services []ServiceAPI, dotEnv map[string]string) ([]ServiceAPI, error) {
+	var enabled []ServiceAPI
+	for _, service := range services {
+		if optional, ok := service.(OptionalService); ok {
+			ok, err := optional.Enabled(dotEnv)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		enabled = append(enabled, service)
+	}
+	return enabled, nil
+}
+
+// stackServices returns the services that are part of the installed stack.
+func (m *MonitoringManager) stackServices() ([]ServiceAPI, error) {
+	dotEnv, err := m.readDotEnv()
+	if err != nil {
+		return nil, err
+	}
+	return enabledServices(m.services, dotEnv)
+}
+
 // InitStack initializes the monitoring stack by merging all environment variables, checking ports, setting up the stack and services, and creating containers.
-func (m *MonitoringManager) InstallStack() error {
+// The installation is aborted if the context is done before the services are set up.
+func (m *MonitoringManager) InstallStack(ctx context.Context) error {
 	// Merge all dotEnv
 	dotEnv := make(map[string]string)
 	defaultPorts := make(map[string]uint16)
@@ -120,9 +190,19 @@ func (m *MonitoringManager) InstallStack() error {
 	for k, v := range ports {
 		dotEnv[k] = strconv.Itoa(int(v))
 	}
+	services, err := enabledServices(m.services, dotEnv)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
+	}
+	if errs := ValidateOptions(services, dotEnv); len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, errors.Join(errs...))
+	}
+	if err := checkPortConflicts("localhost", dotEnv); err != nil {
+		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
+	}
 
 	// Intialize stack
-	for _, service := range m.services {
+	for _, service := range services {
 		if err := service.Init(types.ServiceOptions{
 			Stack:  m.stack,
 			Dotenv: dotEnv,
@@ -131,14 +211,18 @@ func (m *MonitoringManager) InstallStack() error {
 		}
 	}
 
-	if err = m.stack.Setup(dotEnv, script); err != nil {
+	stackFiles, err := renderScript(dotEnv)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
+	}
+	if err = m.stack.Setup(dotEnv, stackFiles); err != nil {
 		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
 	}
 
 	// Setup services
-	log.Debug("Setting up monitoring stack...")
-	for _, service := range m.services {
-		if err = service.Setup(dotEnv); err != nil {
+	m.logger.Debug("Setting up monitoring stack...")
+	for _, service := range services {
+		if err = service.Setup(ctx, dotEnv); err != nil {
 			return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
 		}
 	}
@@ -148,13 +232,13 @@ func (m *MonitoringManager) InstallStack() error {
 		return fmt.Errorf("%w: %w", ErrInstallingMonitoringMngr, err)
 	}
 
-	log.Debug("Starting monitoring stack...")
-	if err := m.composeManager.Up(compose.DockerComposeUpOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml")}); err != nil {
+	m.logger.Debug("Starting monitoring stack...")
+	if err := m.composeManager.Up(ctx, compose.DockerComposeUpOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml")}); err != nil {
 		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 	}
 
 	// Save container IPs of monitoring services
-	if err := m.saveServiceIP(); err != nil {
+	if err := m.saveServiceIP(services); err != nil {
 		return fmt.Errorf("%w: %w", ErrInitializingMonitoringMngr, err)
 	}
 
@@ -163,8 +247,16 @@ func (m *MonitoringManager) InstallStack() error {
 
 // AddTarget adds a new target to all services in the monitoring stack.
 // It also connects the target to the docker network of the monitoring stack if it isn't already connected.
-// The labels are added to the service's metrics.
+// The labels are added to the service's metrics and must include the instance ID
+// label, so the metrics of different instances can be told apart.
+// It is safe to call it concurrently.
 func (m *MonitoringManager) AddTarget(target types.MonitoringTarget, labels map[string]string, dockerNetwork string) error {
+	instanceID := labels[InstanceIDLabel]
+	if instanceID == "" {
+		return fmt.Errorf("%w: %s", ErrMissingTargetLabel, InstanceIDLabel)
+	}
+	m.targetsMu.Lock()
+	defer m.targetsMu.Unlock()
 	for _, service := range m.services {
 		// Check if network was already added to service
 		containerName := service.ContainerName()
@@ -179,40 +271,264 @@ func (m *MonitoringManager) AddTarget(target types.MonitoringTarget, labels map[
 				}
 			}
 		}
-		if err := service.AddTarget(target, labels, labels[InstanceIDLabel]+"--"+containerName+"++"+dockerNetwork); err != nil {
+		if err := service.AddTarget(target, labels, instanceID+"--"+containerName+"++"+dockerNetwork); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// AddRules adds the alerting rules of the given instance to all services in the
+// monitoring stack that implement RulesProvisioner.
+func (m *MonitoringManager) AddRules(instanceID string, rules [][]byte) error {
+	for _, service := range m.services {
+		if provisioner, ok := service.(RulesProvisioner); ok {
+			if err := provisioner.AddRules(instanceID, rules); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddDashboards adds the dashboards of the given instance to all services in
+// the monitoring stack that implement DashboardsProvisioner.
+func (m *MonitoringManager) AddDashboards(instanceID string, dashboards map[string][]byte) error {
+	for _, service := range m.services {
+		if provisioner, ok := service.(DashboardsProvisioner); ok {
+			if err := provisioner.AddDashboards(instanceID, dashboards); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // RemoveTarget removes a target from all services in the monitoring stack.
 // It also disconnects the target from the docker network of the monitoring stack if it isn't already disconnected.
+// The alerting rules of the instance are removed from the services that implement RulesProvisioner, and its
+// dashboards from the services that implement DashboardsProvisioner.
 func (m *MonitoringManager) RemoveTarget(instanceID string) error {
+	m.targetsMu.Lock()
+	defer m.targetsMu.Unlock()
+	return m.removeTarget(instanceID)
+}
+
+// removeTarget implements RemoveTarget. The caller must hold targetsMu.
+func (m *MonitoringManager) removeTarget(instanceID string) error {
 	for _, service := range m.services {
+		if provisioner, ok := service.(RulesProvisioner); ok {
+			if err := provisioner.RemoveRules(instanceID); err != nil {
+				return err
+			}
+		}
+		if provisioner, ok := service.(DashboardsProvisioner); ok {
+			if err := provisioner.RemoveDashboards(instanceID); err != nil {
+				return err
+			}
+		}
 		network, err := service.RemoveTarget(instanceID)
 		if err != nil {
 			return err
 		}
 		// Disconnect may fail if the network was already disconnected or if the container was already removed
 		// so we ignore the error
+		// The stack's own services stay in its network
+		if network == MonitoringNetwork {
+			continue
+		}
 		serviceName := service.ContainerName()
 		if err := m.dockerManager.NetworkDisconnect(serviceName, network); err != nil {
-			log.Debugf("Error disconnecting %s from %s: %s", serviceName, network, err)
+			m.logger.WithFields(log.Fields{
+				"service": serviceName,
+				"network": network,
+			}).WithError(err).Debug("Error disconnecting service from network")
 		}
 	}
 	return nil
 }
 
+// ReconcileTargets removes the targets of the services that implement
+// TargetLister whose instance ID is not in the given active instance IDs, like
+// the targets left behind by an uninstall that did not complete. The targets of
+// the enabled services that implement MetricsExporter are kept.
+func (m *MonitoringManager) ReconcileTargets(active []string) error {
+	services, err := m.stackServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		if _, ok := service.(MetricsExporter); ok {
+			active = append(active, service.ContainerName())
+		}
+	}
+	m.targetsMu.Lock()
+	defer m.targetsMu.Unlock()
+	var stale []string
+	for _, service := range m.services {
+		lister, ok := service.(TargetLister)
+		if !ok {
+			continue
+		}
+		targets, err := lister.Targets()
+		if err != nil {
+			return err
+		}
+		for _, instanceID := range targets {
+			if !funk.ContainsString(active, instanceID) && !funk.ContainsString(stale, instanceID) {
+				stale = append(stale, instanceID)
+			}
+		}
+	}
+	for _, instanceID := range stale {
+		m.logger.WithField("instance_id", instanceID).Info("Removing stale monitoring target")
+		if err := m.removeTarget(instanceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateProvisioning checks the files provisioned in the monitoring stack by
+// the services that implement ProvisioningValidator. It returns the errors of
+// all the services, joined.
+func (m *MonitoringManager) ValidateProvisioning() error {
+	var errs []error
+	for _, service := range m.services {
+		if validator, ok := service.(ProvisioningValidator); ok {
+			if err := validator.ValidateProvisioning(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", service.ContainerName(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckPorts checks that the ports of the monitoring services are valid, not
+// shared by two services and not used by other processes. The ports are the
+// ones in the .env file of the installed stack or, if the stack is not
+// installed, the default ports of the services. The ports are in use while the
+// stack is running, so it must not be running.
+func (m *MonitoringManager) CheckPorts() error {
+	ports, err := m.ports()
+	if err != nil {
+		return err
+	}
+	return checkPortConflicts("localhost", ports)
+}
+
+// ports returns the ports of the monitoring services, keyed by their .env
+// variable. The ports are the ones in the .env file of the installed stack or,
+// if the stack is not installed, the default ports of the services.
+func (m *MonitoringManager) ports() (map[string]string, error) {
+	ports := make(map[string]string)
+	for _, service := range m.services {
+		for k, v := range service.DotEnv() {
+			if strings.HasSuffix(k, "_PORT") {
+				ports[k] = v
+			}
+		}
+	}
+	installed, err := m.stack.Installed()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
+	}
+	if installed {
+		rawEnv, err := m.stack.ReadFile(".env")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
+		}
+		for _, line := range strings.Split(string(rawEnv), "\n") {
+			k, v, ok := strings.Cut(line, "=")
+			if ok && strings.HasSuffix(k, "_PORT") {
+				ports[k] = v
+			}
+		}
+	}
+	return ports, nil
+}
+
+// ServiceStatus is the status of a monitoring service.
+type ServiceStatus struct {
+	// Name is the container name of the service.
+	Name string
+	// Running is true if the container is running or restarting.
+	Running bool
+	// Ports are the ports of the service.
+	Ports []string
+}
+
+// StackStatus is the status of the monitoring stack.
+type StackStatus struct {
+	Services []ServiceStatus
+	Targets  TargetsHealth
+}
+
+// StackStatus returns the status of the container and the ports of each
+// monitoring service and the health of the scrape targets. The targets health
+// is reported by the running services that implement TargetsHealthReporter,
+// so the stack must be initialized to get it. The services whose container
+// doesn't exist are reported as not running.
+func (m *MonitoringManager) StackStatus() (StackStatus, error) {
+	ports, err := m.ports()
+	if err != nil {
+		return StackStatus{}, err
+	}
+	services, err := m.stackServices()
+	if err != nil {
+		return StackStatus{}, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
+	}
+	var status StackStatus
+	for _, service := range services {
+		// A missing container, like one removed by hand, is not running
+		containerStatus, err := m.dockerManager.ContainerStatus(service.ContainerName())
+		if err != nil && !errors.Is(err, docker.ErrContainerNotFound) {
+			return StackStatus{}, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
+		}
+		serviceStatus := ServiceStatus{
+			Name:    service.ContainerName(),
+			Running: containerStatus == common.Running || containerStatus == common.Restarting,
+		}
+		var keys []string
+		for k := range service.DotEnv() {
+			if strings.HasSuffix(k, "_PORT") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			serviceStatus.Ports = append(serviceStatus.Ports, ports[k])
+		}
+		status.Services = append(status.Services, serviceStatus)
+
+		reporter, ok := service.(TargetsHealthReporter)
+		if !ok || !serviceStatus.Running {
+			continue
+		}
+		health, err := reporter.TargetsHealth()
+		if err != nil {
+			return StackStatus{}, fmt.Errorf("%w: %s: %w", ErrCheckingMonitoringStack, service.ContainerName(), err)
+		}
+		status.Targets.Active += health.Active
+		status.Targets.Up += health.Up
+		status.Targets.Down += health.Down
+	}
+	return status, nil
+}
+
 // Run starts the monitoring stack by shutting down any existing stack and starting a new one.
 func (m *MonitoringManager) Run() error {
-	log.Info("Starting monitoring stack...")
-	if err := m.composeManager.Up(compose.DockerComposeUpOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml")}); err != nil {
+	m.logger.Info("Starting monitoring stack...")
+	if err := m.composeManager.Up(context.Background(), compose.DockerComposeUpOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml")}); err != nil {
 		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 	}
 
 	// Save container IPs of monitoring services
-	if err := m.saveServiceIP(); err != nil {
+	services, err := m.stackServices()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
+	}
+	if err := m.saveServiceIP(services); err != nil {
 		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 	}
 
@@ -221,7 +537,7 @@ func (m *MonitoringManager) Run() error {
 
 // Stop shuts down the monitoring stack.
 func (m *MonitoringManager) Stop() error {
-	log.Info("Shutting down monitoring stack...")
+	m.logger.Info("Shutting down monitoring stack...")
 	if err := m.composeManager.Down(compose.DockerComposeDownOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml")}); err != nil {
 		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 	}
@@ -231,13 +547,13 @@ func (m *MonitoringManager) Stop() error {
 
 // Status checks the status of the containers in the monitoring stack and returns the status.
 func (m *MonitoringManager) Status() (status common.Status, err error) {
-	containers := []string{
-		GrafanaContainerName,
-		PrometheusContainerName,
-		NodeExporterContainerName,
+	services, err := m.stackServices()
+	if err != nil {
+		return common.Unknown, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
 	}
 
-	for _, container := range containers {
+	for _, service := range services {
+		container := service.ContainerName()
 		status, err = m.dockerManager.ContainerStatus(container)
 		if err != nil {
 			return common.Unknown, fmt.Errorf("%w: %w", ErrCheckingMonitoringStack, err)
@@ -268,13 +584,13 @@ func (m *MonitoringManager) InstallationStatus() (common.Status, error) {
 // Cleanup removes the monitoring stack. If force is true, it bypasses locks and removes the stack without running 'docker compose down'.
 func (m *MonitoringManager) Cleanup(force bool) error {
 	if !force {
-		log.Info("Shutting down monitoring stack...")
+		m.logger.Info("Shutting down monitoring stack...")
 		if err := m.composeManager.Down(compose.DockerComposeDownOptions{Path: filepath.Join(m.stack.Path(), "docker-compose.yml"), Volumes: true}); err != nil {
 			return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 		}
 	}
 
-	log.Info("Cleaning up monitoring stack...")
+	m.logger.Info("Cleaning up monitoring stack...")
 	if err := m.stack.Cleanup(force); err != nil {
 		return fmt.Errorf("%w: %w", ErrRunningMonitoringStack, err)
 	}
@@ -283,9 +599,14 @@ func (m *MonitoringManager) Cleanup(force bool) error {
 }
 
 // ServiceEndpoints returns a map of the service's container names and their endpoints.
+// If the .env file of the stack can't be read, the endpoints of all the services are returned.
 func (m *MonitoringManager) ServiceEndpoints() map[string]string {
+	services, err := m.stackServices()
+	if err != nil {
+		services = m.services
+	}
 	endpoints := make(map[string]string)
-	for _, service := range m.services {
+	for _, service := range services {
 		endpoints[service.ContainerName()] = service.Endpoint()
 	}
 	return endpoints
@@ -299,8 +620,8 @@ func (m *MonitoringManager) idToIP(id string) (string, error) {
 	return ip, nil
 }
 
-func (m *MonitoringManager) saveServiceIP() error {
-	for _, service := range m.services {
+func (m *MonitoringManager) saveServiceIP(services []ServiceAPI) error {
+	for _, service := range services {
 		name := service.ContainerName()
 		ip, err := m.idToIP(name)
 		if err != nil {
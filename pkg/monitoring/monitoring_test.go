@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -16,6 +17,8 @@ import (
 	"github.com/NethermindEth/eigenlayer/internal/common"
 	"github.com/NethermindEth/eigenlayer/internal/compose"
 	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/docker"
+	"github.com/NethermindEth/eigenlayer/internal/locker"
 	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/mocks"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
@@ -231,6 +234,7 @@ func TestInit(t *testing.T) {
 				mocks.NewMockDockerManager(ctrl),
 				afs,
 				locker,
+				log.StandardLogger(),
 			)
 
 			services, dockerManager := tt.mocker(t, ctrl, manager.stack, tt.dotenv)
@@ -325,14 +329,14 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(nil),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 					servicer.EXPECT().ContainerName().Return("node"),
 					servicer.EXPECT().SetContainerIP(net.ParseIP("127.0.0.1")).Return(),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node").Return("127.0.0.1", nil)
@@ -361,7 +365,7 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					service1.EXPECT().Setup(dotenv).Return(nil),
+					service1.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 					service1.EXPECT().ContainerName().Return("node1"),
 				)
 				gomock.InOrder(
@@ -372,7 +376,7 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					service2.EXPECT().Setup(dotenv).Return(nil),
+					service2.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 					service2.EXPECT().ContainerName().Return("node2"),
 				)
 				service1.EXPECT().SetContainerIP(net.ParseIP("168.0.2.1")).Return()
@@ -380,7 +384,7 @@ func TestInstallStack(t *testing.T) {
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node1").Return("168.0.2.1", nil)
@@ -412,14 +416,14 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(nil),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 					servicer.EXPECT().ContainerName().Return("node"),
 					servicer.EXPECT().SetContainerIP(net.ParseIP("127.1.1.6")).Return(),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node").Return("127.1.1.6", nil)
@@ -558,7 +562,7 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(errors.New("error")),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(errors.New("error")),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
@@ -597,7 +601,7 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(nil),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
@@ -638,12 +642,12 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(nil),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(errors.New("error"))
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(errors.New("error"))
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 
@@ -680,13 +684,13 @@ func TestInstallStack(t *testing.T) {
 						Stack:  stack,
 						Dotenv: dotenv,
 					}).Return(nil),
-					servicer.EXPECT().Setup(dotenv).Return(nil),
+					servicer.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
 					servicer.EXPECT().ContainerName().Return("node"),
 				)
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(stack.Path(), "docker-compose.yml")}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node").Return("", errors.New("error"))
@@ -713,6 +717,7 @@ func TestInstallStack(t *testing.T) {
 				mocks.NewMockDockerManager(ctrl),
 				afero.NewMemMapFs(),
 				locker,
+				log.StandardLogger(),
 			)
 
 			services, composeManager, dockerManager := tt.mocker(t, ctrl, manager.stack)
@@ -721,7 +726,7 @@ func TestInstallStack(t *testing.T) {
 			manager.dockerManager = dockerManager
 
 			// Init the stack
-			err := manager.InstallStack()
+			err := manager.InstallStack(context.Background())
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -934,6 +939,25 @@ func TestAddAndRemoveTarget(t *testing.T) {
 			wantErr: true,
 			add:     true,
 		},
+		{
+			name:         "add, missing instance ID label",
+			mockerLocker: okLocker,
+			mocker: func(t *testing.T, ctrl *gomock.Controller, labels map[string]string, dockerNetwork string, target types.MonitoringTarget) ([]ServiceAPI, *mocks.MockDockerManager) {
+				// No service is configured without the instance ID label
+				return []ServiceAPI{
+					mocks.NewMockServiceAPI(ctrl),
+				}, mocks.NewMockDockerManager(ctrl)
+			},
+			target: types.MonitoringTarget{
+				Host: "localhost",
+				Port: 9000,
+			},
+			labels: map[string]string{
+				CommitHashLabel: "76973ce6755edb6cce37efd62266e98c838f6968",
+			},
+			wantErr: true,
+			add:     true,
+		},
 		{
 			name:         "remove, ok, 1 service",
 			mockerLocker: okLocker,
@@ -1062,6 +1086,7 @@ func TestAddAndRemoveTarget(t *testing.T) {
 				dockerManager,
 				afero.NewMemMapFs(),
 				locker,
+				log.StandardLogger(),
 			)
 
 			var err error
@@ -1110,7 +1135,7 @@ func TestRun(t *testing.T) {
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				// Expect the compose manager to be triggered
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: composePath}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: composePath}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node1").Return("168.0.2.1", nil)
@@ -1126,7 +1151,7 @@ func TestRun(t *testing.T) {
 			mocker: func(t *testing.T, ctrl *gomock.Controller) ([]ServiceAPI, *mocks.MockComposeManager, *mocks.MockDockerManager) {
 				composeManager := mocks.NewMockComposeManager(ctrl)
 				// Expect the compose manager to be triggered
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: composePath}).Return(errors.New("error"))
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: composePath}).Return(errors.New("error"))
 				return []ServiceAPI{mocks.NewMockServiceAPI(ctrl)}, composeManager, mocks.NewMockDockerManager(ctrl)
 			},
 			wantErr: true,
@@ -1138,7 +1163,7 @@ func TestRun(t *testing.T) {
 				servicer.EXPECT().ContainerName().Return("node")
 
 				composeManager := mocks.NewMockComposeManager(ctrl)
-				composeManager.EXPECT().Up(compose.DockerComposeUpOptions{Path: composePath}).Return(nil)
+				composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: composePath}).Return(nil)
 
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				dockerManager.EXPECT().ContainerIP("node").Return("", errors.New("error"))
@@ -1163,6 +1188,11 @@ func TestRun(t *testing.T) {
 			locker := mock_locker.NewMockLocker(ctrl)
 			// Expect the lock to be acquired
 			locker.EXPECT().New(filepath.Join(userDataHome, ".eigen", "monitoring", ".lock")).Return(locker)
+			// The .env file is read after the stack is started
+			locker.EXPECT().Lock().Return(nil).MaxTimes(1)
+			locker.EXPECT().Locked().Return(true).MaxTimes(1)
+			locker.EXPECT().Unlock().Return(nil).MaxTimes(1)
+			require.NoError(t, afero.WriteFile(afs, filepath.Join(userDataHome, ".eigen", "monitoring", ".env"), []byte("GRAFANA_PORT=3000\n"), 0o644))
 
 			services, composeManager, dockerManager := tt.mocker(t, ctrl)
 
@@ -1173,6 +1203,7 @@ func TestRun(t *testing.T) {
 				dockerManager,
 				afs,
 				locker,
+				log.StandardLogger(),
 			)
 
 			// Run the stack
@@ -1243,6 +1274,7 @@ func TestStop(t *testing.T) {
 				mocks.NewMockDockerManager(ctrl),
 				afero.NewMemMapFs(),
 				locker,
+				log.StandardLogger(),
 			)
 
 			// Stop the stack
@@ -1266,12 +1298,30 @@ func TestStatus(t *testing.T) {
 
 	tests := []struct {
 		name    string
+		dotEnv  string
 		mocker  func(t *testing.T, ctrl *gomock.Controller) *mocks.MockDockerManager
 		want    common.Status
 		wantErr bool
 	}{
 		{
-			name: "ok",
+			name:   "ok",
+			dotEnv: "CADVISOR_ENABLED=true\n",
+			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockDockerManager {
+				dockerManager := mocks.NewMockDockerManager(ctrl)
+				// Expect the docker manager to be triggered
+				gomock.InOrder(
+					dockerManager.EXPECT().ContainerStatus(GrafanaContainerName).Return(common.Running, nil),
+					dockerManager.EXPECT().ContainerStatus(PrometheusContainerName).Return(common.Running, nil),
+					dockerManager.EXPECT().ContainerStatus(NodeExporterContainerName).Return(common.Running, nil),
+					dockerManager.EXPECT().ContainerStatus(CadvisorContainerName).Return(common.Running, nil),
+				)
+				return dockerManager
+			},
+			want: common.Running,
+		},
+		{
+			name:   "ok, cadvisor disabled",
+			dotEnv: "CADVISOR_ENABLED=false\n",
 			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockDockerManager {
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				// Expect the docker manager to be triggered
@@ -1296,7 +1346,8 @@ func TestStatus(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "Restarting",
+			name:   "Restarting",
+			dotEnv: "CADVISOR_ENABLED=true\n",
 			mocker: func(t *testing.T, ctrl *gomock.Controller) *mocks.MockDockerManager {
 				dockerManager := mocks.NewMockDockerManager(ctrl)
 				// Expect the docker manager to be triggered
@@ -1304,6 +1355,7 @@ func TestStatus(t *testing.T) {
 					dockerManager.EXPECT().ContainerStatus(GrafanaContainerName).Return(common.Restarting, nil),
 					dockerManager.EXPECT().ContainerStatus(PrometheusContainerName).Return(common.Restarting, nil),
 					dockerManager.EXPECT().ContainerStatus(NodeExporterContainerName).Return(common.Restarting, nil),
+					dockerManager.EXPECT().ContainerStatus(CadvisorContainerName).Return(common.Restarting, nil),
 				)
 				return dockerManager
 			},
@@ -1352,15 +1404,34 @@ func TestStatus(t *testing.T) {
 			// Create a mock locker
 			locker := mock_locker.NewMockLocker(ctrl)
 			// Expect the lock to be acquired
-			locker.EXPECT().New(filepath.Join(userDataHome, ".eigen", "monitoring", ".lock")).Return(locker)
+			gomock.InOrder(
+				locker.EXPECT().New(filepath.Join(userDataHome, ".eigen", "monitoring", ".lock")).Return(locker),
+				locker.EXPECT().Lock().Return(nil),
+				locker.EXPECT().Locked().Return(true),
+				locker.EXPECT().Unlock().Return(nil),
+			)
+
+			afs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(afs, filepath.Join(userDataHome, ".eigen", "monitoring", ".env"), []byte(tt.dotEnv), 0o644))
+
+			var services []ServiceAPI
+			for _, name := range []string{GrafanaContainerName, PrometheusContainerName, NodeExporterContainerName} {
+				service := mocks.NewMockServiceAPI(ctrl)
+				service.EXPECT().ContainerName().Return(name).AnyTimes()
+				services = append(services, service)
+			}
+			cadvisor := mocks.NewMockServiceAPI(ctrl)
+			cadvisor.EXPECT().ContainerName().Return(CadvisorContainerName).AnyTimes()
+			services = append(services, &optionalServiceMock{MockServiceAPI: cadvisor, option: "CADVISOR_ENABLED"})
 
 			// Create a monitoring manager
 			manager := NewMonitoringManager(
-				[]ServiceAPI{mocks.NewMockServiceAPI(ctrl)},
+				services,
 				mocks.NewMockComposeManager(ctrl),
 				tt.mocker(t, ctrl),
-				afero.NewMemMapFs(),
+				afs,
 				locker,
+				log.StandardLogger(),
 			)
 
 			status, err := manager.Status()
@@ -1484,6 +1555,7 @@ func TestInstallationStatus(t *testing.T) {
 				mocks.NewMockDockerManager(ctrl),
 				fs,
 				locker,
+				log.StandardLogger(),
 			)
 
 			status, err := manager.InstallationStatus()
@@ -1621,6 +1693,7 @@ func TestCleanup(t *testing.T) {
 				mocks.NewMockDockerManager(ctrl),
 				afs,
 				locker,
+				log.StandardLogger(),
 			)
 
 			if !tt.noInstall {
@@ -1665,12 +1738,378 @@ func TestServiceEndpoints(t *testing.T) {
 		nodeExporterMock.EXPECT().Endpoint().Return("http://node-exporter:9105"),
 	)
 
+	locker := mock_locker.NewMockLocker(ctrl)
+	locker.EXPECT().New(gomock.Any()).Return(locker)
+	locker.EXPECT().Lock().Return(nil)
+	locker.EXPECT().Locked().Return(true)
+	locker.EXPECT().Unlock().Return(nil)
+
 	// Init monitoring manager and services
-	manager := MonitoringManager{
-		services: []ServiceAPI{grafanaMock, promMock, nodeExporterMock},
-	}
+	manager := NewMonitoringManager(
+		[]ServiceAPI{grafanaMock, promMock, nodeExporterMock},
+		mocks.NewMockComposeManager(ctrl),
+		mocks.NewMockDockerManager(ctrl),
+		afero.NewMemMapFs(),
+		locker,
+		log.StandardLogger(),
+	)
 
 	// Check endpoints
 	endpoints := manager.ServiceEndpoints()
 	assert.Equal(t, want, endpoints)
 }
+
+// optionalServiceMock is a ServiceAPI mock that also implements
+// OptionalService. It is enabled if its option is true.
+type optionalServiceMock struct {
+	*mocks.MockServiceAPI
+	option string
+}
+
+func (o *optionalServiceMock) Enabled(dotEnv map[string]string) (bool, error) {
+	if dotEnv[o.option] == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(dotEnv[o.option])
+}
+
+// exporterServiceMock is a ServiceAPI mock that also implements MetricsExporter.
+type exporterServiceMock struct {
+	*mocks.MockServiceAPI
+	target types.MonitoringTarget
+}
+
+func (e *exporterServiceMock) MetricsTarget() types.MonitoringTarget {
+	return e.target
+}
+
+func TestEnabledServices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := mocks.NewMockServiceAPI(ctrl)
+	optional := &optionalServiceMock{MockServiceAPI: mocks.NewMockServiceAPI(ctrl), option: "OPTIONAL_ENABLED"}
+
+	enabled, err := enabledServices([]ServiceAPI{service, optional}, map[string]string{"OPTIONAL_ENABLED": "true"})
+	require.NoError(t, err)
+	assert.Equal(t, []ServiceAPI{service, optional}, enabled)
+
+	enabled, err = enabledServices([]ServiceAPI{service, optional}, map[string]string{"OPTIONAL_ENABLED": "false"})
+	require.NoError(t, err)
+	assert.Equal(t, []ServiceAPI{service}, enabled)
+
+	_, err = enabledServices([]ServiceAPI{service, optional}, map[string]string{"OPTIONAL_ENABLED": "maybe"})
+	assert.Error(t, err)
+}
+
+// rulesServiceMock is a ServiceAPI mock that also implements RulesProvisioner.
+type rulesServiceMock struct {
+	*mocks.MockServiceAPI
+	added   map[string][][]byte
+	removed []string
+}
+
+func (r *rulesServiceMock) AddRules(instanceID string, rules [][]byte) error {
+	r.added[instanceID] = rules
+	return nil
+}
+
+func (r *rulesServiceMock) RemoveRules(instanceID string) error {
+	r.removed = append(r.removed, instanceID)
+	return nil
+}
+
+func TestAddAndRemoveRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	rulesService := &rulesServiceMock{
+		MockServiceAPI: mocks.NewMockServiceAPI(ctrl),
+		added:          make(map[string][][]byte),
+	}
+	// Services that don't implement RulesProvisioner only see the target removal
+	plainService := mocks.NewMockServiceAPI(ctrl)
+	dockerManager := mocks.NewMockDockerManager(ctrl)
+
+	gomock.InOrder(
+		rulesService.MockServiceAPI.EXPECT().RemoveTarget("mock-avs-default").Return("eigenlayer", nil),
+		rulesService.MockServiceAPI.EXPECT().ContainerName().Return(PrometheusContainerName),
+		dockerManager.EXPECT().NetworkDisconnect(PrometheusContainerName, "eigenlayer").Return(nil),
+		plainService.EXPECT().RemoveTarget("mock-avs-default").Return("eigenlayer", nil),
+		plainService.EXPECT().ContainerName().Return(GrafanaContainerName),
+		dockerManager.EXPECT().NetworkDisconnect(GrafanaContainerName, "eigenlayer").Return(nil),
+	)
+
+	manager := MonitoringManager{
+		services:      []ServiceAPI{rulesService, plainService},
+		dockerManager: dockerManager,
+		logger:        log.StandardLogger(),
+	}
+
+	rules := [][]byte{[]byte("groups: []")}
+	require.NoError(t, manager.AddRules("mock-avs-default", rules))
+	assert.Equal(t, map[string][][]byte{"mock-avs-default": rules}, rulesService.added)
+
+	require.NoError(t, manager.RemoveTarget("mock-avs-default"))
+	assert.Equal(t, []string{"mock-avs-default"}, rulesService.removed)
+}
+
+// dashboardsServiceMock is a ServiceAPI mock that also implements DashboardsProvisioner.
+type dashboardsServiceMock struct {
+	*mocks.MockServiceAPI
+	added   map[string]map[string][]byte
+	removed []string
+}
+
+func (d *dashboardsServiceMock) AddDashboards(instanceID string, dashboards map[string][]byte) error {
+	d.added[instanceID] = dashboards
+	return nil
+}
+
+func (d *dashboardsServiceMock) RemoveDashboards(instanceID string) error {
+	d.removed = append(d.removed, instanceID)
+	return nil
+}
+
+func TestAddAndRemoveDashboards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dashboardsService := &dashboardsServiceMock{
+		MockServiceAPI: mocks.NewMockServiceAPI(ctrl),
+		added:          make(map[string]map[string][]byte),
+	}
+	// Services that don't implement DashboardsProvisioner only see the target removal
+	plainService := mocks.NewMockServiceAPI(ctrl)
+	dockerManager := mocks.NewMockDockerManager(ctrl)
+
+	gomock.InOrder(
+		plainService.EXPECT().RemoveTarget("mock-avs-default").Return("eigenlayer", nil),
+		plainService.EXPECT().ContainerName().Return(PrometheusContainerName),
+		dockerManager.EXPECT().NetworkDisconnect(PrometheusContainerName, "eigenlayer").Return(nil),
+		dashboardsService.MockServiceAPI.EXPECT().RemoveTarget("mock-avs-default").Return("eigenlayer", nil),
+		dashboardsService.MockServiceAPI.EXPECT().ContainerName().Return(GrafanaContainerName),
+		dockerManager.EXPECT().NetworkDisconnect(GrafanaContainerName, "eigenlayer").Return(nil),
+	)
+
+	manager := MonitoringManager{
+		services:      []ServiceAPI{plainService, dashboardsService},
+		dockerManager: dockerManager,
+		logger:        log.StandardLogger(),
+	}
+
+	dashboards := map[string][]byte{"overview.json": []byte("{}")}
+	require.NoError(t, manager.AddDashboards("mock-avs-default", dashboards))
+	assert.Equal(t, map[string]map[string][]byte{"mock-avs-default": dashboards}, dashboardsService.added)
+
+	require.NoError(t, manager.RemoveTarget("mock-avs-default"))
+	assert.Equal(t, []string{"mock-avs-default"}, dashboardsService.removed)
+}
+
+// targetsServiceMock is a ServiceAPI mock that also implements TargetLister.
+type targetsServiceMock struct {
+	*mocks.MockServiceAPI
+	targets []string
+	err     error
+}
+
+func (s *targetsServiceMock) Targets() ([]string, error) {
+	return s.targets, s.err
+}
+
+func TestReconcileTargets(t *testing.T) {
+	userDataHome := os.Getenv("XDG_DATA_HOME")
+	if userDataHome == "" {
+		userHome, err := os.UserHomeDir()
+		require.NoError(t, err)
+		userDataHome = filepath.Join(userHome, ".local", "share")
+	}
+
+	tests := []struct {
+		name    string
+		targets []string
+		listErr error
+		active  []string
+		removed []string
+		wantErr error
+	}{
+		{
+			name:    "stale targets are removed",
+			targets: []string{"mock-avs-default", "mock-avs-stale", "mock-avs-second", "mock-avs-crashed"},
+			active:  []string{"mock-avs-default", "mock-avs-second"},
+			removed: []string{"mock-avs-stale", "mock-avs-crashed"},
+		},
+		{
+			name:    "no stale targets",
+			targets: []string{"mock-avs-default"},
+			active:  []string{"mock-avs-default", "mock-avs-second"},
+		},
+		{
+			name:    "no active instances",
+			targets: []string{"mock-avs-default"},
+			removed: []string{"mock-avs-default"},
+		},
+		{
+			name:    "exporter targets are kept",
+			targets: []string{"mock-avs-default", CadvisorContainerName},
+			active:  []string{"mock-avs-default"},
+		},
+		{
+			name:    "listing error",
+			listErr: assert.AnError,
+			active:  []string{"mock-avs-default"},
+			wantErr: assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			targetsService := &targetsServiceMock{
+				MockServiceAPI: mocks.NewMockServiceAPI(ctrl),
+				targets:        tt.targets,
+				err:            tt.listErr,
+			}
+			// Services that don't implement TargetLister only see the target removal
+			plainService := mocks.NewMockServiceAPI(ctrl)
+			exporterService := &exporterServiceMock{MockServiceAPI: plainService}
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+
+			// The targets of the exporters are active
+			plainService.EXPECT().ContainerName().Return(CadvisorContainerName)
+
+			for _, instanceID := range tt.removed {
+				gomock.InOrder(
+					targetsService.MockServiceAPI.EXPECT().RemoveTarget(instanceID).Return("eigenlayer", nil),
+					targetsService.MockServiceAPI.EXPECT().ContainerName().Return(PrometheusContainerName),
+					dockerManager.EXPECT().NetworkDisconnect(PrometheusContainerName, "eigenlayer").Return(nil),
+					plainService.EXPECT().RemoveTarget(instanceID).Return("", nil),
+					plainService.EXPECT().ContainerName().Return(CadvisorContainerName),
+					dockerManager.EXPECT().NetworkDisconnect(CadvisorContainerName, "").Return(nil),
+				)
+			}
+
+			locker := mock_locker.NewMockLocker(ctrl)
+			locker.EXPECT().New(gomock.Any()).Return(locker)
+			locker.EXPECT().Lock().Return(nil)
+			locker.EXPECT().Locked().Return(true)
+			locker.EXPECT().Unlock().Return(nil)
+			afs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(afs, filepath.Join(userDataHome, ".eigen", "monitoring", ".env"), []byte("CADVISOR_ENABLED=true\n"), 0o644))
+
+			manager := NewMonitoringManager(
+				[]ServiceAPI{targetsService, exporterService},
+				mocks.NewMockComposeManager(ctrl),
+				dockerManager,
+				afs,
+				locker,
+				log.StandardLogger(),
+			)
+
+			err := manager.ReconcileTargets(tt.active)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// healthServiceMock is a ServiceAPI mock that also implements TargetsHealthReporter.
+type healthServiceMock struct {
+	*mocks.MockServiceAPI
+	health TargetsHealth
+	err    error
+}
+
+func (s *healthServiceMock) TargetsHealth() (TargetsHealth, error) {
+	return s.health, s.err
+}
+
+func TestStackStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		promStatus common.Status
+		promErr    error
+		healthErr  error
+		want       StackStatus
+		wantErr    error
+	}{
+		{
+			name:       "running stack",
+			promStatus: common.Running,
+			want: StackStatus{
+				Services: []ServiceStatus{
+					{Name: GrafanaContainerName, Running: true, Ports: []string{"3005"}},
+					{Name: PrometheusContainerName, Running: true, Ports: []string{"9095"}},
+				},
+				Targets: TargetsHealth{Active: 3, Up: 2, Down: 1},
+			},
+		},
+		{
+			name:       "prometheus is not running",
+			promStatus: common.Exited,
+			want: StackStatus{
+				Services: []ServiceStatus{
+					{Name: GrafanaContainerName, Running: true, Ports: []string{"3005"}},
+					{Name: PrometheusContainerName, Running: false, Ports: []string{"9095"}},
+				},
+			},
+		},
+		{
+			name:       "prometheus container is missing",
+			promStatus: common.Unknown,
+			promErr:    fmt.Errorf("%w: %s", docker.ErrContainerNotFound, PrometheusContainerName),
+			want: StackStatus{
+				Services: []ServiceStatus{
+					{Name: GrafanaContainerName, Running: true, Ports: []string{"3005"}},
+					{Name: PrometheusContainerName, Running: false, Ports: []string{"9095"}},
+				},
+			},
+		},
+		{
+			name:       "container status error",
+			promStatus: common.Unknown,
+			promErr:    assert.AnError,
+			wantErr:    ErrCheckingMonitoringStack,
+		},
+		{
+			name:       "targets health error",
+			promStatus: common.Running,
+			healthErr:  assert.AnError,
+			wantErr:    ErrCheckingMonitoringStack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			dataDir, err := data.NewDataDir(t.TempDir(), afero.NewOsFs(), locker.NewFLock())
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+			require.NoError(t, stack.Setup(map[string]string{"GRAFANA_PORT": "3005", "PROM_PORT": "9095"}, script))
+
+			grafanaMock := mocks.NewMockServiceAPI(ctrl)
+			grafanaMock.EXPECT().ContainerName().Return(GrafanaContainerName).AnyTimes()
+			grafanaMock.EXPECT().DotEnv().Return(map[string]string{"GRAFANA_PORT": "3000", "GRAFANA_HOST": "localhost"}).AnyTimes()
+			promMock := &healthServiceMock{
+				MockServiceAPI: mocks.NewMockServiceAPI(ctrl),
+				health:         TargetsHealth{Active: 3, Up: 2, Down: 1},
+				err:            tt.healthErr,
+			}
+			promMock.MockServiceAPI.EXPECT().ContainerName().Return(PrometheusContainerName).AnyTimes()
+			promMock.MockServiceAPI.EXPECT().DotEnv().Return(map[string]string{"PROM_PORT": "9090"}).AnyTimes()
+			dockerManager := mocks.NewMockDockerManager(ctrl)
+			dockerManager.EXPECT().ContainerStatus(GrafanaContainerName).Return(common.Running, nil)
+			dockerManager.EXPECT().ContainerStatus(PrometheusContainerName).Return(tt.promStatus, tt.promErr)
+
+			manager := MonitoringManager{
+				services:      []ServiceAPI{grafanaMock, promMock},
+				dockerManager: dockerManager,
+				stack:         stack,
+				logger:        log.StandardLogger(),
+			}
+			status, err := manager.StackStatus()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// SecretFileSuffix is the suffix of the options holding the path of a file to
+// read a secret option from, like PROM_REMOTE_WRITE_PASSWORD_FILE for
+// PROM_REMOTE_WRITE_PASSWORD.
+const SecretFileSuffix = "_FILE"
+
+// SecretsDir is the directory of the containers of the stack where the secret
+// files of the key+SecretFileSuffix options are mounted.
+const SecretsDir = "/run/secrets"
+
+// Secret is a secret option of the stack. Only one of its fields is set.
+type Secret struct {
+	// Value is the secret set in the options.
+	Value string
+	// File is the path of the secret file in the container, e.g.
+	// /run/secrets/grafana_admin_password.
+	File string
+}
+
+// ResolveSecret returns the secret option key. Following the container secrets
+// convention, if the key+SecretFileSuffix option is set, the secret is kept in
+// the file at that path. The file is mounted in the container at SecretFile(key)
+// and read from there, so the secret is never written to the config files of
+// the stack. The file takes precedence over the key option, so secrets don't
+// need to be passed as plain environment variables.
+func ResolveSecret(opts map[string]string, key string) (Secret, error) {
+	fileKey := key + SecretFileSuffix
+	secretPath := opts[fileKey]
+	if secretPath == "" {
+		return Secret{Value: opts[key]}, nil
+	}
+	info, err := os.Stat(secretPath)
+	if err != nil {
+		return Secret{}, fmt.Errorf("%w: %s: %w", ErrReadingSecret, fileKey, err)
+	}
+	if info.IsDir() {
+		return Secret{}, fmt.Errorf("%w: %s: %s is a directory", ErrReadingSecret, fileKey, secretPath)
+	}
+	return Secret{File: SecretFile(key)}, nil
+}
+
+// SecretFile returns the path of the secret file of the option key in the
+// containers of the stack.
+func SecretFile(key string) string {
+	return path.Join(SecretsDir, strings.ToLower(key))
+}
+
+// ValidateOptions checks that every option required by the given services is
+// set to a non-empty value in opts. Instead of stopping at the first missing
+// option, it returns an ErrMissingOption error for each of them, so all of
+// them can be fixed at once. Options required by more than one service are
+// reported once. Services that do not implement OptionsRequirer are skipped.
+func ValidateOptions(services []ServiceAPI, opts map[string]string) []error {
+	var keys []string
+	requiredBy := make(map[string][]string)
+	for _, service := range services {
+		requirer, ok := service.(OptionsRequirer)
+		if !ok {
+			continue
+		}
+		for _, key := range requirer.RequiredOptions() {
+			if _, seen := requiredBy[key]; !seen {
+				keys = append(keys, key)
+			}
+			requiredBy[key] = append(requiredBy[key], service.ContainerName())
+		}
+	}
+
+	var errs []error
+	for _, key := range keys {
+		value, ok := opts[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s missing in options, required by %s", ErrMissingOption, key, strings.Join(requiredBy[key], ", ")))
+		} else if value == "" {
+			errs = append(errs, fmt.Errorf("%w: %s can't be empty, required by %s", ErrMissingOption, key, strings.Join(requiredBy[key], ", ")))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,110 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requirerServiceMock is a ServiceAPI mock that also implements OptionsRequirer.
+type requirerServiceMock struct {
+	*mocks.MockServiceAPI
+	required []string
+}
+
+func (s *requirerServiceMock) RequiredOptions() []string {
+	return s.required
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]string
+		want []string
+	}{
+		{
+			name: "all options set",
+			opts: map[string]string{
+				"GRAFANA_PORT":       "3000",
+				"PROM_PORT":          "9090",
+				"NODE_EXPORTER_PORT": "9100",
+			},
+		},
+		{
+			name: "multiple missing options",
+			opts: map[string]string{
+				"GRAFANA_PORT": "3000",
+			},
+			want: []string{
+				"required option is missing: PROM_PORT missing in options, required by egn_grafana, egn_prometheus",
+				"required option is missing: NODE_EXPORTER_PORT missing in options, required by egn_prometheus",
+			},
+		},
+		{
+			name: "missing and empty options",
+			opts: map[string]string{
+				"GRAFANA_PORT": "",
+				"PROM_PORT":    "9090",
+			},
+			want: []string{
+				"required option is missing: GRAFANA_PORT can't be empty, required by egn_grafana",
+				"required option is missing: NODE_EXPORTER_PORT missing in options, required by egn_prometheus",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			grafana := &requirerServiceMock{MockServiceAPI: mocks.NewMockServiceAPI(ctrl), required: []string{"GRAFANA_PORT", "PROM_PORT"}}
+			grafana.EXPECT().ContainerName().Return(GrafanaContainerName).AnyTimes()
+			prometheus := &requirerServiceMock{MockServiceAPI: mocks.NewMockServiceAPI(ctrl), required: []string{"PROM_PORT", "NODE_EXPORTER_PORT"}}
+			prometheus.EXPECT().ContainerName().Return(PrometheusContainerName).AnyTimes()
+			// Services without required options are skipped
+			other := mocks.NewMockServiceAPI(ctrl)
+
+			errs := ValidateOptions([]ServiceAPI{grafana, prometheus, other}, tt.opts)
+			assert.Len(t, errs, len(tt.want))
+			for i, err := range errs {
+				assert.ErrorIs(t, err, ErrMissingOption)
+				assert.EqualError(t, err, tt.want[i])
+			}
+		})
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\r\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		opts    map[string]string
+		want    Secret
+		wantErr error
+	}{
+		{name: "not set", opts: map[string]string{}, want: Secret{}},
+		{name: "env", opts: map[string]string{"PASSWORD": "secret"}, want: Secret{Value: "secret"}},
+		{name: "empty file option", opts: map[string]string{"PASSWORD": "secret", "PASSWORD_FILE": ""}, want: Secret{Value: "secret"}},
+		{name: "file", opts: map[string]string{"PASSWORD_FILE": secretFile}, want: Secret{File: "/run/secrets/password"}},
+		{name: "file wins", opts: map[string]string{"PASSWORD": "secret", "PASSWORD_FILE": secretFile}, want: Secret{File: "/run/secrets/password"}},
+		{name: "missing file", opts: map[string]string{"PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")}, wantErr: ErrReadingSecret},
+		{name: "directory", opts: map[string]string{"PASSWORD_FILE": t.TempDir()}, wantErr: ErrReadingSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSecret(tt.opts, "PASSWORD")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorContains(t, err, "PASSWORD_FILE")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
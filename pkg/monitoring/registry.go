@@ -0,0 +1,83 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+)
+
+// ServiceFactory creates a new instance of a monitoring service.
+type ServiceFactory func() ServiceAPI
+
+// Registry holds the monitoring services available to the monitoring stack,
+// keyed by name. Services register themselves in the registry, usually from
+// the init function of their package, so new services can be added to the
+// stack without editing a central list.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]ServiceFactory
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]ServiceFactory),
+	}
+}
+
+// Register adds the service with the given name and factory to the registry.
+// It panics if the name is empty, the factory is nil or a service with the
+// same name is already registered.
+func (r *Registry) Register(name string, factory ServiceFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		panic("monitoring: Register service with empty name")
+	}
+	if factory == nil {
+		panic("monitoring: Register service factory is nil for " + name)
+	}
+	if _, ok := r.factories[name]; ok {
+		panic("monitoring: Register called twice for service " + name)
+	}
+	r.factories[name] = factory
+}
+
+// Names returns the names of the registered services in lexical order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Services returns a new instance of each registered service, ordered by the
+// service name.
+func (r *Registry) Services() []ServiceAPI {
+	names := r.Names()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	services := make([]ServiceAPI, 0, len(names))
+	for _, name := range names {
+		services = append(services, r.factories[name]())
+	}
+	return services
+}
+
+// DefaultRegistry is the registry used by Register and RegisteredServices.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the service with the given name and factory to the
+// DefaultRegistry.
+func Register(name string, factory ServiceFactory) {
+	DefaultRegistry.Register(name, factory)
+}
+
+// RegisteredServices returns a new instance of each service registered in the
+// DefaultRegistry.
+func RegisteredServices() []ServiceAPI {
+	return DefaultRegistry.Services()
+}
@@ -0,0 +1,116 @@
+package monitoring
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/internal/compose"
+	mock_locker "github.com/NethermindEth/eigenlayer/internal/locker/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring/mocks"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	registry := NewRegistry()
+	assert.Empty(t, registry.Names())
+	assert.Empty(t, registry.Services())
+
+	created := 0
+	for _, name := range []string{"loki", "alertmanager"} {
+		registry.Register(name, func() ServiceAPI {
+			created++
+			return mocks.NewMockServiceAPI(ctrl)
+		})
+	}
+	assert.Equal(t, []string{"alertmanager", "loki"}, registry.Names())
+
+	// Each call creates new service instances
+	assert.Len(t, registry.Services(), 2)
+	assert.Len(t, registry.Services(), 2)
+	assert.Equal(t, 4, created)
+
+	assert.Panics(t, func() {
+		registry.Register("loki", func() ServiceAPI { return mocks.NewMockServiceAPI(ctrl) })
+	})
+	assert.Panics(t, func() { registry.Register("", func() ServiceAPI { return mocks.NewMockServiceAPI(ctrl) }) })
+	assert.Panics(t, func() { registry.Register("tempo", nil) })
+}
+
+func TestRegistryServiceSetup(t *testing.T) {
+	// Silence logger
+	log.SetOutput(io.Discard)
+
+	userDataHome := os.Getenv("XDG_DATA_HOME")
+	if userDataHome == "" {
+		userHome, err := os.UserHomeDir()
+		require.NoError(t, err)
+		userDataHome = filepath.Join(userHome, ".local", "share")
+	}
+
+	ctrl := gomock.NewController(t)
+	locker := mock_locker.NewMockLocker(ctrl)
+	gomock.InOrder(
+		locker.EXPECT().New(filepath.Join(userDataHome, ".eigen", "monitoring", ".lock")).Return(locker),
+		locker.EXPECT().Lock().Return(nil),
+		locker.EXPECT().Locked().Return(true),
+		locker.EXPECT().Unlock().Return(nil),
+	)
+	// stack.Installed() lock
+	gomock.InOrder(
+		locker.EXPECT().Lock().Return(nil),
+		locker.EXPECT().Locked().Return(true),
+		locker.EXPECT().Unlock().Return(nil),
+	)
+
+	manager := NewMonitoringManager(
+		[]ServiceAPI{},
+		mocks.NewMockComposeManager(ctrl),
+		mocks.NewMockDockerManager(ctrl),
+		afero.NewMemMapFs(),
+		locker,
+		log.StandardLogger(),
+	)
+
+	// Custom service registered by name
+	dotenv := map[string]string{
+		"LOKI_PORT": "3100",
+	}
+	loki := mocks.NewMockServiceAPI(ctrl)
+	gomock.InOrder(
+		loki.EXPECT().DotEnv().Return(dotenv),
+		loki.EXPECT().Init(types.ServiceOptions{
+			Stack:  manager.stack,
+			Dotenv: dotenv,
+		}).Return(nil),
+		loki.EXPECT().Setup(gomock.Any(), dotenv).Return(nil),
+		loki.EXPECT().ContainerName().Return("egn_loki"),
+		loki.EXPECT().SetContainerIP(net.ParseIP("127.0.0.1")).Return(),
+	)
+	registry := NewRegistry()
+	registry.Register("loki", func() ServiceAPI { return loki })
+
+	composeManager := mocks.NewMockComposeManager(ctrl)
+	composeManager.EXPECT().Create(compose.DockerComposeCreateOptions{Path: filepath.Join(manager.stack.Path(), "docker-compose.yml")}).Return(nil)
+	composeManager.EXPECT().Up(gomock.Any(), compose.DockerComposeUpOptions{Path: filepath.Join(manager.stack.Path(), "docker-compose.yml")}).Return(nil)
+	dockerManager := mocks.NewMockDockerManager(ctrl)
+	dockerManager.EXPECT().ContainerIP("egn_loki").Return("127.0.0.1", nil)
+
+	manager.services = registry.Services()
+	manager.composeManager = composeManager
+	manager.dockerManager = dockerManager
+
+	require.NoError(t, manager.InstallStack(context.Background()))
+	installed, err := manager.stack.Installed()
+	require.NoError(t, err)
+	assert.True(t, installed)
+}
@@ -0,0 +1,145 @@
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/afero"
+)
+
+// composeTemplate is the path of the docker-compose.yml template of the stack
+// in the embedded script directory.
+const composeTemplate = "script/docker-compose.yml"
+
+// dockerSocketPath is the path of the docker socket mounted in Prometheus when
+// the instance containers are discovered through it.
+const dockerSocketPath = "/var/run/docker.sock"
+
+// composeData is the data of the docker-compose.yml template of the stack.
+type composeData struct {
+	// DockerSD mounts the docker socket in Prometheus, so it discovers the
+	// instance containers. It is set by the PROM_DOCKER_SD option.
+	DockerSD bool
+	// DockerGID is the group of the docker socket, added to Prometheus so it
+	// can read the socket. It is the PROM_DOCKER_GID option or else the group
+	// owning the socket.
+	DockerGID string
+	// GrafanaAdminPassword is the secret file of the Grafana admin password
+	// mounted in Grafana, if GRAFANA_ADMIN_PASSWORD_FILE is set. Otherwise the
+	// GRAFANA_ADMIN_PASSWORD option is passed in the Grafana environment.
+	GrafanaAdminPassword *secretMount
+	// Cadvisor adds the cAdvisor service. It is set by the CADVISOR_ENABLED
+	// option.
+	Cadvisor bool
+	// PromRemoteWritePassword is the secret file of the remote write password
+	// mounted in Prometheus, if PROM_REMOTE_WRITE_PASSWORD_FILE is set.
+	PromRemoteWritePassword *secretMount
+}
+
+// secretMount is a secret file mounted read-only in a container.
+type secretMount struct {
+	// Source is the absolute path of the secret file in the host.
+	Source string
+	// Target is the path of the secret file in the container.
+	Target string
+}
+
+// newSecretMount returns the mount of the secret file of the option key, or
+// nil if the key+SecretFileSuffix option is not set.
+func newSecretMount(dotEnv map[string]string, key string) (*secretMount, error) {
+	source := dotEnv[key+SecretFileSuffix]
+	if source == "" {
+		return nil, nil
+	}
+	// Relative paths in the compose file are relative to the stack directory
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrReadingSecret, key+SecretFileSuffix, err)
+	}
+	return &secretMount{Source: source, Target: SecretFile(key)}, nil
+}
+
+// newComposeData returns the data of the docker-compose.yml template for the
+// given dotenv values.
+func newComposeData(dotEnv map[string]string) (composeData, error) {
+	var (
+		data composeData
+		err  error
+	)
+	if data.GrafanaAdminPassword, err = newSecretMount(dotEnv, "GRAFANA_ADMIN_PASSWORD"); err != nil {
+		return data, err
+	}
+	if data.PromRemoteWritePassword, err = newSecretMount(dotEnv, "PROM_REMOTE_WRITE_PASSWORD"); err != nil {
+		return data, err
+	}
+	if rawCadvisor := dotEnv["CADVISOR_ENABLED"]; rawCadvisor != "" {
+		if data.Cadvisor, err = strconv.ParseBool(rawCadvisor); err != nil {
+			return data, fmt.Errorf("%s is not a valid boolean", "CADVISOR_ENABLED")
+		}
+	}
+	if rawDockerSD := dotEnv["PROM_DOCKER_SD"]; rawDockerSD != "" {
+		dockerSD, err := strconv.ParseBool(rawDockerSD)
+		if err != nil {
+			return data, fmt.Errorf("%s is not a valid boolean", "PROM_DOCKER_SD")
+		}
+		data.DockerSD = dockerSD
+	}
+	if !data.DockerSD {
+		return data, nil
+	}
+	data.DockerGID = dotEnv["PROM_DOCKER_GID"]
+	if data.DockerGID == "" {
+		gid, err := fileGID(dockerSocketPath)
+		if err != nil {
+			return data, fmt.Errorf("PROM_DOCKER_SD is enabled but the group of the docker socket is unknown, set PROM_DOCKER_GID: %w", err)
+		}
+		data.DockerGID = gid
+	} else if _, err := strconv.ParseUint(data.DockerGID, 10, 32); err != nil {
+		return data, fmt.Errorf("%s is not a valid group ID", "PROM_DOCKER_GID")
+	}
+	return data, nil
+}
+
+// renderScript returns the files of the stack to install, with the
+// docker-compose.yml template rendered for the given dotenv values.
+func renderScript(dotEnv map[string]string) (fs.FS, error) {
+	data, err := newComposeData(dotEnv)
+	if err != nil {
+		return nil, err
+	}
+	rawTemplate, err := fs.ReadFile(script, composeTemplate)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := ParseTemplate(composeTemplate, string(rawTemplate))
+	if err != nil {
+		return nil, err
+	}
+	var composeFile bytes.Buffer
+	if err = tmpl.Execute(&composeFile, data); err != nil {
+		return nil, err
+	}
+	rendered := afero.NewMemMapFs()
+	if err = afero.WriteFile(rendered, composeTemplate, composeFile.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	return afero.NewIOFS(rendered), nil
+}
+
+// fileGID returns the ID of the group owning the given file.
+func fileGID(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("unsupported file info of %s", path)
+	}
+	return strconv.FormatUint(uint64(stat.Gid), 10), nil
+}
@@ -0,0 +1,158 @@
+package monitoring
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderScript(t *testing.T) {
+	type composeService struct {
+		Volumes  []string `yaml:"volumes"`
+		GroupAdd []string `yaml:"group_add"`
+	}
+	tests := []struct {
+		name     string
+		dotEnv   map[string]string
+		volumes  []string
+		groupAdd []string
+		wantErr  bool
+	}{
+		{
+			name:    "docker service discovery disabled by default",
+			dotEnv:  map[string]string{},
+			volumes: []string{"${PROM_CONF}:/etc/prometheus/prometheus.yml", "${PROM_RULES}:/etc/prometheus/rules"},
+		},
+		{
+			name:    "docker service discovery disabled",
+			dotEnv:  map[string]string{"PROM_DOCKER_SD": "false", "PROM_DOCKER_GID": "999"},
+			volumes: []string{"${PROM_CONF}:/etc/prometheus/prometheus.yml", "${PROM_RULES}:/etc/prometheus/rules"},
+		},
+		{
+			name:     "docker service discovery enabled",
+			dotEnv:   map[string]string{"PROM_DOCKER_SD": "true", "PROM_DOCKER_GID": "999"},
+			volumes:  []string{"${PROM_CONF}:/etc/prometheus/prometheus.yml", "${PROM_RULES}:/etc/prometheus/rules", "/var/run/docker.sock:/var/run/docker.sock:ro"},
+			groupAdd: []string{"999"},
+		},
+		{
+			name:    "invalid docker service discovery flag",
+			dotEnv:  map[string]string{"PROM_DOCKER_SD": "yes please"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid docker group",
+			dotEnv:  map[string]string{"PROM_DOCKER_SD": "true", "PROM_DOCKER_GID": "docker"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stackFiles, err := renderScript(tt.dotEnv)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			rawCompose, err := fs.ReadFile(stackFiles, "script/docker-compose.yml")
+			require.NoError(t, err)
+			var composeFile struct {
+				Services map[string]composeService `yaml:"services"`
+			}
+			require.NoError(t, yaml.Unmarshal(rawCompose, &composeFile))
+			prometheus := composeFile.Services[PrometheusServiceName]
+			assert.Equal(t, tt.volumes, prometheus.Volumes)
+			assert.Equal(t, tt.groupAdd, prometheus.GroupAdd)
+		})
+	}
+}
+
+func TestRenderScriptSecrets(t *testing.T) {
+	type composeService struct {
+		Volumes     []string `yaml:"volumes"`
+		Environment []string `yaml:"environment"`
+	}
+	stackFiles, err := renderScript(map[string]string{
+		"GRAFANA_ADMIN_PASSWORD_FILE":     "/etc/egn/admin-password",
+		"PROM_REMOTE_WRITE_PASSWORD_FILE": "remote-write-password",
+	})
+	require.NoError(t, err)
+
+	rawCompose, err := fs.ReadFile(stackFiles, "script/docker-compose.yml")
+	require.NoError(t, err)
+	var composeFile struct {
+		Services map[string]composeService `yaml:"services"`
+	}
+	require.NoError(t, yaml.Unmarshal(rawCompose, &composeFile))
+	assert.Contains(t, composeFile.Services[GrafanaServiceName].Volumes, "/etc/egn/admin-password:/run/secrets/grafana_admin_password:ro")
+	assert.Empty(t, composeFile.Services[GrafanaServiceName].Environment)
+	// Relative paths are resolved from the working directory, not the stack directory
+	source, err := filepath.Abs("remote-write-password")
+	require.NoError(t, err)
+	assert.Contains(t, composeFile.Services[PrometheusServiceName].Volumes, source+":/run/secrets/prom_remote_write_password:ro")
+
+	// Without a secret file, the Grafana admin password is passed in the
+	// environment instead of the config files
+	stackFiles, err = renderScript(map[string]string{})
+	require.NoError(t, err)
+	rawCompose, err = fs.ReadFile(stackFiles, "script/docker-compose.yml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(rawCompose, &composeFile))
+	assert.Equal(t, []string{"GRAFANA_ADMIN_PASSWORD=${GRAFANA_ADMIN_PASSWORD}"}, composeFile.Services[GrafanaServiceName].Environment)
+}
+
+func TestRenderScriptCadvisor(t *testing.T) {
+	type composeService struct {
+		Ports []string `yaml:"ports"`
+	}
+	tests := []struct {
+		name    string
+		dotEnv  map[string]string
+		enabled bool
+		wantErr bool
+	}{
+		{
+			name:   "disabled by default",
+			dotEnv: map[string]string{},
+		},
+		{
+			name:   "disabled",
+			dotEnv: map[string]string{"CADVISOR_ENABLED": "false"},
+		},
+		{
+			name:    "enabled",
+			dotEnv:  map[string]string{"CADVISOR_ENABLED": "true"},
+			enabled: true,
+		},
+		{
+			name:    "invalid flag",
+			dotEnv:  map[string]string{"CADVISOR_ENABLED": "yes please"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stackFiles, err := renderScript(tt.dotEnv)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			rawCompose, err := fs.ReadFile(stackFiles, "script/docker-compose.yml")
+			require.NoError(t, err)
+			var composeFile struct {
+				Services map[string]composeService `yaml:"services"`
+			}
+			require.NoError(t, yaml.Unmarshal(rawCompose, &composeFile))
+			cadvisor, ok := composeFile.Services[CadvisorServiceName]
+			assert.Equal(t, tt.enabled, ok)
+			// cAdvisor is only reachable in the network of the stack
+			assert.Empty(t, cadvisor.Ports)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"context"
 	"net"
 
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
@@ -22,7 +23,8 @@ type ServiceAPI interface {
 	DotEnv() map[string]string
 
 	// Setup configures the service given a map of options. The options should include the values for the environment variables.
-	Setup(options map[string]string) error
+	// The setup is aborted if the context is done before it finishes.
+	Setup(ctx context.Context, options map[string]string) error
 
 	// Init initializes the service with the given ServiceOptions.
 	Init(types.ServiceOptions) error
@@ -36,3 +38,96 @@ type ServiceAPI interface {
 	// Endpoint returns the endpoint of the service.
 	Endpoint() string
 }
+
+// RulesProvisioner is implemented by services that support alerting rules
+// shipped with the AVS packages, like Prometheus.
+type RulesProvisioner interface {
+	// AddRules adds the given rule files to the service's configuration. The
+	// rules are identified by the instanceID of the node that ships them.
+	AddRules(instanceID string, rules [][]byte) error
+
+	// RemoveRules removes the rules of the given instanceID from the service's
+	// configuration. It does nothing if the instance has no rules.
+	RemoveRules(instanceID string) error
+}
+
+// TargetLister is implemented by services that keep the monitoring targets in
+// their configuration, like Prometheus.
+type TargetLister interface {
+	// Targets returns the instance IDs of the targets in the service's
+	// configuration.
+	Targets() ([]string, error)
+}
+
+// DashboardsProvisioner is implemented by services that support dashboards
+// shipped with the AVS packages, like Grafana.
+type DashboardsProvisioner interface {
+	// AddDashboards adds the given dashboards, keyed by their file name, to the
+	// service. The dashboards are identified by the instanceID of the node that
+	// ships them.
+	AddDashboards(instanceID string, dashboards map[string][]byte) error
+
+	// RemoveDashboards removes the dashboards of the given instanceID from the
+	// service. It does nothing if the instance has no dashboards.
+	RemoveDashboards(instanceID string) error
+}
+
+// ProvisioningValidator is implemented by services that can check the files
+// they provisioned in the monitoring stack, like the Grafana dashboards.
+type ProvisioningValidator interface {
+	// ValidateProvisioning returns an error if a provisioned file of the
+	// service is missing or invalid.
+	ValidateProvisioning() error
+}
+
+// EmbeddedAssetsValidator is implemented by services that embed config
+// templates or static assets in egn, so a bad asset is caught when egn starts
+// instead of when the service is set up.
+type EmbeddedAssetsValidator interface {
+	// ValidateEmbeddedAssets returns an error for each embedded asset of the
+	// service that is invalid, joined.
+	ValidateEmbeddedAssets() error
+}
+
+// TargetsHealth is the health of the scrape targets of a service.
+type TargetsHealth struct {
+	// Active is the number of targets being scraped.
+	Active int
+	// Up is the number of active targets whose last scrape succeeded.
+	Up int
+	// Down is the number of active targets whose last scrape failed.
+	Down int
+}
+
+// TargetsHealthReporter is implemented by services that scrape the monitoring
+// targets, like Prometheus.
+type TargetsHealthReporter interface {
+	// TargetsHealth returns the health of the targets scraped by the running
+	// service.
+	TargetsHealth() (TargetsHealth, error)
+}
+
+// OptionsRequirer is implemented by services that require some options to be
+// set to a non-empty value in the dotenv passed to Init and Setup.
+type OptionsRequirer interface {
+	// RequiredOptions returns the keys of the options required by the service.
+	RequiredOptions() []string
+}
+
+// OptionalService is implemented by services that are only part of the stack
+// when the dotenv options enable them, like cAdvisor. The disabled services are
+// neither set up nor run.
+type OptionalService interface {
+	// Enabled returns true if the given dotenv values enable the service.
+	Enabled(dotEnv map[string]string) (bool, error)
+}
+
+// MetricsExporter is implemented by services of the stack that expose metrics
+// to scrape, like cAdvisor. Their target is added to the other services with
+// AddTarget when the stack is initialized, like the targets of the instances,
+// identified by the container name of the service.
+type MetricsExporter interface {
+	// MetricsTarget returns the target of the metrics of the service in the
+	// network of the stack.
+	MetricsTarget() types.MonitoringTarget
+}
@@ -0,0 +1,8 @@
+package cadvisor
+
+var dotEnv map[string]string = map[string]string{
+	"CADVISOR_IMAGE": "gcr.io/cadvisor/cadvisor:v0.47.2",
+	// cAdvisor runs privileged with access to the docker data of the host, so
+	// it is only part of the stack when enabled
+	"CADVISOR_ENABLED": "false",
+}
@@ -0,0 +1,5 @@
+package cadvisor
+
+import "errors"
+
+var ErrInvalidOptions = errors.New("invalid options for cadvisor setup")
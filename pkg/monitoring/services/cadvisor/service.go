@@ -0,0 +1,96 @@
+package cadvisor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
+)
+
+var (
+	_ monitoring.ServiceAPI      = &CadvisorService{}
+	_ monitoring.OptionalService = &CadvisorService{}
+	_ monitoring.MetricsExporter = &CadvisorService{}
+)
+
+// port is the port cAdvisor listens on in the network of the stack. It is not
+// published on the host.
+const port = 8080
+
+// CadvisorService implements the ServiceAPI interface for a cAdvisor service,
+// which exposes the CPU, memory and network metrics of each container of the
+// host. It complements the host metrics of the node exporter. It is only part
+// of the stack if CADVISOR_ENABLED is true. Its target is added to Prometheus
+// like the ones of the instances and Grafana ships its dashboard, so the
+// targets of the instances are not added to it.
+type CadvisorService struct {
+	containerIP net.IP
+}
+
+func init() {
+	monitoring.Register(monitoring.CadvisorServiceName, func() monitoring.ServiceAPI { return NewCadvisor() })
+}
+
+// NewCadvisor creates a new CadvisorService.
+func NewCadvisor() *CadvisorService {
+	return &CadvisorService{}
+}
+
+// Init initializes the cAdvisor service with the given options.
+func (c *CadvisorService) Init(opts types.ServiceOptions) error {
+	return nil
+}
+
+// Enabled returns true if CADVISOR_ENABLED is true. It is disabled if the
+// option is not set.
+func (c *CadvisorService) Enabled(dotEnv map[string]string) (bool, error) {
+	rawEnabled := dotEnv["CADVISOR_ENABLED"]
+	if rawEnabled == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "CADVISOR_ENABLED")
+	}
+	return enabled, nil
+}
+
+// MetricsTarget returns the target of the cAdvisor metrics.
+func (c *CadvisorService) MetricsTarget() types.MonitoringTarget {
+	return types.MonitoringTarget{
+		Host: monitoring.CadvisorContainerName,
+		Port: port,
+	}
+}
+
+func (c *CadvisorService) AddTarget(target types.MonitoringTarget, labels map[string]string, jobName string) error {
+	return nil
+}
+
+func (c *CadvisorService) RemoveTarget(instanceID string) (string, error) {
+	return "", nil
+}
+
+// DotEnv returns the dotenv variables and default values for the cAdvisor service.
+func (c *CadvisorService) DotEnv() map[string]string {
+	return dotEnv
+}
+
+func (c *CadvisorService) Setup(ctx context.Context, options map[string]string) error {
+	return nil
+}
+
+func (c *CadvisorService) SetContainerIP(ip net.IP) {
+	c.containerIP = ip
+}
+
+func (c *CadvisorService) ContainerName() string {
+	return monitoring.CadvisorContainerName
+}
+
+func (c *CadvisorService) Endpoint() string {
+	return fmt.Sprintf("http://%s:%d", c.containerIP, port)
+}
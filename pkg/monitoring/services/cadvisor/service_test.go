@@ -0,0 +1,89 @@
+package cadvisor
+
+import (
+	"net"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
+	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	cadvisor := NewCadvisor()
+	assert.NoError(t, cadvisor.Init(types.ServiceOptions{Dotenv: map[string]string{}}))
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "enabled",
+			dotenv: map[string]string{"CADVISOR_ENABLED": "true"},
+			want:   true,
+		},
+		{
+			name:   "disabled",
+			dotenv: map[string]string{"CADVISOR_ENABLED": "false"},
+		},
+		{
+			name:   "missing option",
+			dotenv: map[string]string{},
+		},
+		{
+			name:    "invalid option",
+			dotenv:  map[string]string{"CADVISOR_ENABLED": "yes please"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cadvisor := NewCadvisor()
+			enabled, err := cadvisor.Enabled(tt.dotenv)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidOptions)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, enabled)
+			}
+		})
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	cadvisor := NewCadvisor()
+	assert.Equal(t, dotEnv, cadvisor.DotEnv())
+	assert.Equal(t, "false", cadvisor.DotEnv()["CADVISOR_ENABLED"])
+	assert.NotContains(t, cadvisor.DotEnv(), "CADVISOR_PORT")
+}
+
+func TestMetricsTarget(t *testing.T) {
+	cadvisor := NewCadvisor()
+	assert.Equal(t, types.MonitoringTarget{Host: monitoring.CadvisorContainerName, Port: 8080}, cadvisor.MetricsTarget())
+}
+
+func TestRegistered(t *testing.T) {
+	assert.Contains(t, monitoring.DefaultRegistry.Names(), monitoring.CadvisorServiceName)
+}
+
+func TestContainerName(t *testing.T) {
+	cadvisor := NewCadvisor()
+	assert.Equal(t, monitoring.CadvisorContainerName, cadvisor.ContainerName())
+}
+
+func TestEndpoint(t *testing.T) {
+	cadvisor := NewCadvisor()
+	err := cadvisor.Init(types.ServiceOptions{
+		Dotenv: map[string]string{"CADVISOR_ENABLED": "true"},
+	})
+	require.NoError(t, err)
+	cadvisor.SetContainerIP(net.ParseIP("168.77.88.99"))
+
+	assert.Equal(t, "http://168.77.88.99:8080", cadvisor.Endpoint())
+}
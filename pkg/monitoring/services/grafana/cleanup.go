@@ -0,0 +1,91 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	datadir "github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/spf13/afero"
+)
+
+// stackFiles creates files and directories in the monitoring stack, recording
+// the ones that did not exist before so they can be removed if the setup fails.
+// Once the context is done, no more files or directories are created.
+type stackFiles struct {
+	ctx   context.Context
+	stack *datadir.MonitoringStack
+	// created are the paths created so far, in creation order. Anything inside
+	// a created directory was created too, so it is not recorded.
+	created []string
+}
+
+func newStackFiles(ctx context.Context, stack *datadir.MonitoringStack) *stackFiles {
+	return &stackFiles{ctx: ctx, stack: stack}
+}
+
+// CreateDir creates the directory at the given path and all its parents,
+// recording the topmost one that did not exist before.
+func (s *stackFiles) CreateDir(path string) error {
+	parts := strings.Split(filepath.Clean(path), string(filepath.Separator))
+	for i := range parts {
+		if err := s.record(filepath.Join(parts[:i+1]...)); err != nil {
+			return err
+		}
+	}
+	return s.stack.CreateDir(path)
+}
+
+// Create creates the file at the given path, recording it if it did not exist
+// before.
+func (s *stackFiles) Create(path string) (afero.File, error) {
+	if err := s.record(path); err != nil {
+		return nil, err
+	}
+	return s.stack.Create(path)
+}
+
+// WriteFile writes the file at the given path, recording it if it did not
+// exist before.
+func (s *stackFiles) WriteFile(path string, data []byte) error {
+	if err := s.record(path); err != nil {
+		return err
+	}
+	return s.stack.WriteFile(path, data)
+}
+
+// Rollback removes the recorded files and directories in reverse creation
+// order, leaving the stack as it was before the setup.
+func (s *stackFiles) Rollback() error {
+	for i := len(s.created) - 1; i >= 0; i-- {
+		if err := s.stack.RemoveAll(s.created[i]); err != nil {
+			return fmt.Errorf("removing %s: %w", s.created[i], err)
+		}
+	}
+	s.created = nil
+	return nil
+}
+
+// record adds the path to the created paths if it does not exist yet and it is
+// not inside an already recorded directory. It returns the context error if the
+// context is done, so the path is not created.
+func (s *stackFiles) record(path string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+	for _, c := range s.created {
+		if path == c || strings.HasPrefix(path, c+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	exists, err := s.stack.Exists(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		s.created = append(s.created, path)
+	}
+	return nil
+}
@@ -5,6 +5,28 @@ var dotEnv map[string]string = map[string]string{
 	"GRAFANA_PORT":           "3000",
 	"GRAFANA_ADMIN_USER":     "admin",
 	"GRAFANA_ADMIN_PASSWORD": "admin",
-	"GRAFANA_PROV":           "./grafana/provisioning",
-	"GRAFANA_DATA":           "./grafana/data",
+	// The admin password is read from this file path if it is set
+	"GRAFANA_ADMIN_PASSWORD_FILE": "",
+	"GRAFANA_PROV":                "./grafana/provisioning",
+	"GRAFANA_DATA":                "./grafana/data",
+	"GRAFANA_CONFIG":              "./grafana/grafana.ini",
+	// The datasources use the Prometheus service name unless a host is set
+	"PROM_HOST": "",
+	// Anonymous access is disabled by default
+	"GRAFANA_ANONYMOUS_ENABLED": "false",
+	"GRAFANA_ANONYMOUS_ROLE":    "Viewer",
+	// Grafana is served from the root path unless a root URL is configured
+	"GRAFANA_ROOT_URL":           "",
+	"GRAFANA_SERVE_FROM_SUBPATH": "false",
+	// The dashboards keep their refresh unless a refresh interval is set. The
+	// interval only replaces the refresh set by a dashboard if forced
+	"GRAFANA_DASHBOARD_REFRESH":       "",
+	"GRAFANA_DASHBOARD_REFRESH_FORCE": "false",
+	// The .json dashboards of this directory are provisioned along with the
+	// embedded ones, in an extra folder
+	"GRAFANA_EXTRA_DASHBOARDS_DIR": "",
+	// Alert notifications are disabled unless a contact point is configured
+	"GRAFANA_ALERT_WEBHOOK_URL":     "",
+	"GRAFANA_ALERT_SLACK_URL":       "",
+	"GRAFANA_ALERT_EMAIL_ADDRESSES": "",
 }
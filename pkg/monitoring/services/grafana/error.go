@@ -3,6 +3,8 @@ package grafana
 import "errors"
 
 var (
-	ErrConfigNotFound = errors.New("configuration file not found")
-	ErrInvalidOptions = errors.New("invalid options for grafana setup")
+	ErrConfigNotFound      = errors.New("configuration file not found")
+	ErrInvalidOptions      = errors.New("invalid options for grafana setup")
+	ErrInvalidDashboard    = errors.New("invalid dashboard")
+	ErrInvalidProvisioning = errors.New("invalid provisioning file")
 )
@@ -1,18 +1,31 @@
 package grafana
 
 import (
+	"bytes"
+	"context"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net"
+	"net/mail"
+	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"text/template"
 
 	datadir "github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed config
@@ -21,14 +34,55 @@ var config embed.FS
 //go:embed dashboards
 var dashboards embed.FS
 
-// Verify that GrafanaService implements the ServiceAPI interface.
-var _ monitoring.ServiceAPI = &GrafanaService{}
+// dashboardsDir is the directory of the dashboards in the dashboards FS.
+const dashboardsDir = "dashboards"
+
+// cadvisorDashboardsDir is the directory of the dashboard of the cAdvisor
+// service in the dashboards FS.
+const cadvisorDashboardsDir = "dashboards/cadvisor"
+
+// extraDashboardsDir is the directory of the dashboards of
+// GRAFANA_EXTRA_DASHBOARDS_DIR in the provisioned dashboards directory, so
+// they are shown in a folder of their own.
+const extraDashboardsDir = "extra"
+
+// promDatasourceUID is the UID of the Prometheus datasource. It is fixed, so
+// re-provisioning the datasource keeps the dashboards that reference it by
+// UID working.
+const promDatasourceUID = "egn-prom"
+
+// Delimiters of the template markers of the dashboards. Grafana already uses
+// {{ }} in the legend formats of the panels, so the dashboards use << >> to
+// mark the values substituted by copyDashboards.
+const (
+	dashboardLeftDelim  = "<<"
+	dashboardRightDelim = ">>"
+)
+
+// Verify that GrafanaService implements the ServiceAPI, OptionsRequirer,
+// DashboardsProvisioner and ProvisioningValidator interfaces.
+var (
+	_ monitoring.ServiceAPI            = &GrafanaService{}
+	_ monitoring.OptionsRequirer       = &GrafanaService{}
+	_ monitoring.DashboardsProvisioner = &GrafanaService{}
+	_ monitoring.ProvisioningValidator = &GrafanaService{}
+)
 
 // GrafanaService implements the ServiceAPI interface for a Grafana service.
 type GrafanaService struct {
 	containerIP net.IP
 	port        uint16
 	stack       *datadir.MonitoringStack
+	// cadvisor is true if the cAdvisor service is enabled, so its dashboard
+	// is provisioned.
+	cadvisor bool
+	// refresh is the refresh set on the dashboards, the ones of the stack
+	// and the ones of the instances.
+	refresh dashboardRefresh
+}
+
+func init() {
+	monitoring.Register("grafana", func() monitoring.ServiceAPI { return NewGrafana() })
 }
 
 // NewGrafana creates a new GrafanaService.
@@ -50,8 +104,18 @@ func (g *GrafanaService) Init(opts types.ServiceOptions) error {
 	if err != nil {
 		return fmt.Errorf("%w: %s is not a valid port", ErrInvalidOptions, "GRAFANA_PORT")
 	}
+	cadvisor, err := cadvisorEnabled(opts.Dotenv)
+	if err != nil {
+		return err
+	}
+	refresh, err := dashboardsRefresh(opts.Dotenv)
+	if err != nil {
+		return err
+	}
 	g.port = uint16(port)
 	g.stack = opts.Stack
+	g.cadvisor = cadvisor
+	g.refresh = refresh
 	return nil
 }
 
@@ -68,8 +132,16 @@ func (g *GrafanaService) DotEnv() map[string]string {
 	return dotEnv
 }
 
+// RequiredOptions returns the dotenv variables required by Init and Setup.
+func (g *GrafanaService) RequiredOptions() []string {
+	return []string{"GRAFANA_PORT", "PROM_PORT"}
+}
+
 // Setup sets up the Grafana service provisioning and configuration with the given dotenv values.
-func (g *GrafanaService) Setup(options map[string]string) error {
+// If the setup fails, the files and directories created during the call are
+// removed, leaving the stack as it was. The setup fails with the context error
+// if the context is done before a file is created or written.
+func (g *GrafanaService) Setup(ctx context.Context, options map[string]string) (err error) {
 	// Validate options
 	promPort, ok := options["PROM_PORT"]
 	if !ok {
@@ -77,6 +149,54 @@ func (g *GrafanaService) Setup(options map[string]string) error {
 	} else if promPort == "" {
 		return fmt.Errorf("%w: %s can't be empty", ErrInvalidOptions, "PROM_PORT")
 	}
+	anonymousEnabled, anonymousRole, err := anonymousAccess(options)
+	if err != nil {
+		return err
+	}
+	promURL, err := promEndpoint(options)
+	if err != nil {
+		return err
+	}
+	rootURL, serveFromSubPath, err := serverSettings(options)
+	if err != nil {
+		return err
+	}
+	contacts, err := contactPoints(options)
+	if err != nil {
+		return err
+	}
+	refresh, err := dashboardsRefresh(options)
+	if err != nil {
+		return err
+	}
+	cadvisor, err := cadvisorEnabled(options)
+	if err != nil {
+		return err
+	}
+	extraDashboards, err := readExtraDashboards(options)
+	if err != nil {
+		return err
+	}
+	adminPassword, err := monitoring.ResolveSecret(options, "GRAFANA_ADMIN_PASSWORD")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
+	// Without a secret file, the password is passed to the container in the
+	// environment variable of the option, see the compose file of the stack
+	var adminPasswordEnv string
+	if adminPassword.File == "" && adminPassword.Value != "" {
+		adminPasswordEnv = "GRAFANA_ADMIN_PASSWORD"
+	}
+
+	// Remove the created files if any step fails
+	files := newStackFiles(ctx, g.stack)
+	defer func() {
+		if err != nil {
+			if rollbackErr := files.Rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%w: cleaning up setup: %w", err, rollbackErr)
+			}
+		}
+	}()
 
 	// Read config template
 	rawTmp, err := config.ReadFile("config/prom.yml")
@@ -84,40 +204,41 @@ func (g *GrafanaService) Setup(options map[string]string) error {
 		return fmt.Errorf("%w: %w", ErrConfigNotFound, err)
 	}
 	// Load template
-	tmp, err := template.New("prom.yml").Parse(string(rawTmp))
+	tmp, err := monitoring.ParseTemplate("prom.yml", string(rawTmp))
 	if err != nil {
 		return err
 	}
 
 	// Create config directory
 	grafProvPath := filepath.Join("grafana", "provisioning")
-	if err = g.stack.CreateDir(filepath.Join(grafProvPath, "datasources")); err != nil {
+	if err = files.CreateDir(filepath.Join(grafProvPath, "datasources")); err != nil {
 		return err
 	}
 	// Create config file
-	configFile, err := g.stack.Create(filepath.Join(grafProvPath, "datasources", "prom.yml"))
+	configFile, err := files.Create(filepath.Join(grafProvPath, "datasources", "prom.yml"))
 	if err != nil {
 		return err
 	}
 	defer configFile.Close()
 
 	// Execute template
-	data := struct {
-		PromEndpoint string
+	err = tmp.Execute(configFile, struct {
+		PromEndpoint  string
+		DatasourceUID string
 	}{
-		PromEndpoint: fmt.Sprintf("http://%s:%s", monitoring.PrometheusServiceName, options["PROM_PORT"]),
-	}
-	err = tmp.Execute(configFile, data)
+		PromEndpoint:  promURL,
+		DatasourceUID: promDatasourceUID,
+	})
 	if err != nil {
 		return err
 	}
 
 	// Create provisioning dashboards folder
-	if err = g.stack.CreateDir(filepath.Join(grafProvPath, "dashboards")); err != nil {
+	if err = files.CreateDir(filepath.Join(grafProvPath, "dashboards")); err != nil {
 		return err
 	}
 	// Create dashboards provisioning file
-	dashboardsFile, err := g.stack.Create(filepath.Join(grafProvPath, "dashboards", "dashboards.yml"))
+	dashboardsFile, err := files.Create(filepath.Join(grafProvPath, "dashboards", "dashboards.yml"))
 	if err != nil {
 		return err
 	}
@@ -130,22 +251,390 @@ func (g *GrafanaService) Setup(options map[string]string) error {
 		return err
 	}
 
-	// Copy dashboards
-	if err = g.copyDashboards(filepath.Join("grafana", "data")); err != nil {
+	// Copy dashboards. The dashboards are shared by all the instances, so
+	// there is no instance to pre-filter them to and templated dashboards get
+	// their default values. The cAdvisor dashboard is only provisioned with
+	// the cAdvisor service, and removed if it was provisioned before.
+	data := dashboardData{DatasourceUID: promDatasourceUID}
+	uids := make(map[string]string)
+	var skipDirs []string
+	if !cadvisor {
+		skipDirs = append(skipDirs, cadvisorDashboardsDir)
+	}
+	if err = copyDashboards(dashboards, files, filepath.Join("grafana", "data"), data, refresh, uids, skipDirs...); err != nil {
+		return err
+	}
+	if !cadvisor {
+		if err = g.stack.RemoveAll(filepath.Join("grafana", "data", cadvisorDashboardsDir)); err != nil {
+			return err
+		}
+	}
+	g.cadvisor = cadvisor
+	g.refresh = refresh
+	if err = writeExtraDashboards(files, g.stack, extraDashboards, data, refresh, uids); err != nil {
+		return err
+	}
+
+	// Create grafana.ini
+	if err = writeGrafanaIni(files, iniSettings{
+		AnonymousEnabled:  anonymousEnabled,
+		AnonymousRole:     anonymousRole,
+		RootURL:           rootURL,
+		ServeFromSubPath:  serveFromSubPath,
+		AdminPasswordEnv:  adminPasswordEnv,
+		AdminPasswordFile: adminPassword.File,
+	}); err != nil {
+		return err
+	}
+
+	// Create alerting contact points, if any channel is configured. Otherwise
+	// the contact points of a previous setup are removed
+	contactPointsDir := filepath.Join(grafProvPath, "alerting")
+	if contacts.configured() {
+		if err = writeContactPoints(files, contactPointsDir, contacts); err != nil {
+			return err
+		}
+	} else if err = g.stack.RemoveAll(filepath.Join(contactPointsDir, "contact-points.yml")); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// promEndpoint returns the URL of the Prometheus datasource. It points to the
+// Prometheus service of the stack, unless PROM_HOST is set. PROM_HOST then
+// replaces the service name in the URL, e.g. to use a Prometheus running under
+// another hostname or alias.
+func promEndpoint(options map[string]string) (string, error) {
+	host := monitoring.PrometheusServiceName
+	if promHost := options["PROM_HOST"]; promHost != "" {
+		if u, err := url.Parse("http://" + promHost); err != nil || u.Host != promHost || u.Port() != "" {
+			return "", fmt.Errorf("%w: %s must be a hostname without scheme or port", ErrInvalidOptions, "PROM_HOST")
+		}
+		host = promHost
+	}
+	return fmt.Sprintf("http://%s:%s", host, options["PROM_PORT"]), nil
+}
+
+// iniSettings holds the values rendered into the grafana.ini config file.
+type iniSettings struct {
+	AnonymousEnabled bool
+	AnonymousRole    string
+	RootURL          string
+	ServeFromSubPath bool
+	// AdminPasswordEnv is the variable of the container environment with the
+	// password of the admin user. Grafana reads the password from it, so the
+	// password is not written to the config file.
+	AdminPasswordEnv string
+	// AdminPasswordFile is the path of the file with the admin password in the
+	// container. Grafana reads the password from it, so the password is not
+	// written to the config file.
+	AdminPasswordFile string
+}
+
+// writeGrafanaIni renders the grafana.ini config file into the stack.
+func writeGrafanaIni(files *stackFiles, settings iniSettings) error {
+	rawTmp, err := config.ReadFile("config/grafana.ini")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	tmp, err := monitoring.ParseTemplate("grafana.ini", string(rawTmp))
+	if err != nil {
+		return err
+	}
+	var grafanaIni bytes.Buffer
+	if err = tmp.Execute(&grafanaIni, settings); err != nil {
+		return err
+	}
+	return files.WriteFile(filepath.Join("grafana", "grafana.ini"), grafanaIni.Bytes())
+}
+
+// cadvisorEnabled returns true if the CADVISOR_ENABLED option enables the
+// cAdvisor service of the stack, which is disabled if the option is not set.
+func cadvisorEnabled(options map[string]string) (bool, error) {
+	rawEnabled := options["CADVISOR_ENABLED"]
+	if rawEnabled == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "CADVISOR_ENABLED")
+	}
+	return enabled, nil
+}
+
+// anonymousAccess validates and returns the anonymous access settings from
+// the given dotenv values. Anonymous access is disabled if
+// GRAFANA_ANONYMOUS_ENABLED is not set.
+func anonymousAccess(options map[string]string) (enabled bool, role string, err error) {
+	rawEnabled := options["GRAFANA_ANONYMOUS_ENABLED"]
+	if rawEnabled == "" {
+		return false, "", nil
+	}
+	enabled, err = strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return false, "", fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "GRAFANA_ANONYMOUS_ENABLED")
+	}
+	if !enabled {
+		return false, "", nil
+	}
+	role = options["GRAFANA_ANONYMOUS_ROLE"]
+	switch role {
+	case "":
+		role = "Viewer"
+	case "Viewer", "Editor", "Admin":
+	default:
+		return false, "", fmt.Errorf("%w: %s must be one of Viewer, Editor or Admin", ErrInvalidOptions, "GRAFANA_ANONYMOUS_ROLE")
+	}
+	return true, role, nil
+}
+
+// serverSettings validates and returns the server settings from the given
+// dotenv values. Grafana is served from the root path if GRAFANA_ROOT_URL is
+// not set. Serving from a subpath requires a root URL with a path.
+func serverSettings(options map[string]string) (rootURL string, serveFromSubPath bool, err error) {
+	if rawServe := options["GRAFANA_SERVE_FROM_SUBPATH"]; rawServe != "" {
+		serveFromSubPath, err = strconv.ParseBool(rawServe)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "GRAFANA_SERVE_FROM_SUBPATH")
+		}
+	}
+	rootURL = options["GRAFANA_ROOT_URL"]
+	if rootURL == "" {
+		if serveFromSubPath {
+			return "", false, fmt.Errorf("%w: %s is required to serve from a subpath", ErrInvalidOptions, "GRAFANA_ROOT_URL")
+		}
+		return "", false, nil
+	}
+	u, err := url.ParseRequestURI(rootURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false, fmt.Errorf("%w: %s is not a valid http(s) URL", ErrInvalidOptions, "GRAFANA_ROOT_URL")
+	}
+	if serveFromSubPath && strings.Trim(u.Path, "/") == "" {
+		return "", false, fmt.Errorf("%w: %s must have a path to serve from a subpath", ErrInvalidOptions, "GRAFANA_ROOT_URL")
+	}
+	return rootURL, serveFromSubPath, nil
+}
+
+// alertContacts holds the notification channels of the Grafana contact point.
+type alertContacts struct {
+	WebhookURL     string
+	SlackURL       string
+	EmailAddresses string
+}
+
+// configured returns true if at least one notification channel is set.
+func (c alertContacts) configured() bool {
+	return c.WebhookURL != "" || c.SlackURL != "" || c.EmailAddresses != ""
+}
+
+// contactPoints validates and returns the alert notification channels from
+// the given dotenv values. Channels that are not set are left empty.
+func contactPoints(options map[string]string) (contacts alertContacts, err error) {
+	for _, key := range []string{"GRAFANA_ALERT_WEBHOOK_URL", "GRAFANA_ALERT_SLACK_URL"} {
+		rawURL := options[key]
+		if rawURL == "" {
+			continue
+		}
+		u, err := url.ParseRequestURI(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return alertContacts{}, fmt.Errorf("%w: %s is not a valid http(s) URL", ErrInvalidOptions, key)
+		}
+	}
+	// The option is a comma separated address list, while Grafana splits the
+	// addresses of the email contact point by semicolons
+	var emailAddresses []string
+	if addresses := options["GRAFANA_ALERT_EMAIL_ADDRESSES"]; addresses != "" {
+		list, err := mail.ParseAddressList(addresses)
+		if err != nil {
+			return alertContacts{}, fmt.Errorf("%w: %s is not a valid list of email addresses", ErrInvalidOptions, "GRAFANA_ALERT_EMAIL_ADDRESSES")
+		}
+		for _, address := range list {
+			emailAddresses = append(emailAddresses, address.Address)
+		}
+	}
+	return alertContacts{
+		WebhookURL:     options["GRAFANA_ALERT_WEBHOOK_URL"],
+		SlackURL:       options["GRAFANA_ALERT_SLACK_URL"],
+		EmailAddresses: strings.Join(emailAddresses, ";"),
+	}, nil
+}
+
+// writeContactPoints renders the alerting contact points provisioning file
+// into the given directory of the stack.
+func writeContactPoints(files *stackFiles, dir string, contacts alertContacts) error {
+	rawTmp, err := config.ReadFile("config/contact-points.yml")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	tmp, err := monitoring.ParseTemplate("contact-points.yml", string(rawTmp))
+	if err != nil {
+		return err
+	}
+	var contactPointsYml bytes.Buffer
+	if err = tmp.Execute(&contactPointsYml, contacts); err != nil {
+		return err
+	}
+	if err = files.CreateDir(dir); err != nil {
+		return err
+	}
+	return files.WriteFile(filepath.Join(dir, "contact-points.yml"), contactPointsYml.Bytes())
+}
+
+// AddDashboards provisions the given dashboards of the instance, keyed by their
+// file name, in a directory of their own. The dashboards previously added for
+// the instance are replaced. Dashboards with template markers are rendered
+// with the instanceID and the UID of the Prometheus datasource, and the
+// refresh of the stack dashboards is set on them, see setRefresh.
+func (g *GrafanaService) AddDashboards(instanceID string, dashboards map[string][]byte) (err error) {
+	dir := instanceDashboardsDir(instanceID)
+	if err = g.stack.RemoveAll(dir); err != nil {
 		return err
 	}
+	if len(dashboards) == 0 {
+		return nil
+	}
 
+	files := newStackFiles(context.Background(), g.stack)
+	defer func() {
+		if err != nil {
+			if rollbackErr := files.Rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%w: cleaning up dashboards: %w", err, rollbackErr)
+			}
+		}
+	}()
+	if err = files.CreateDir(dir); err != nil {
+		return err
+	}
+	for name, raw := range dashboards {
+		if !filepath.IsLocal(name) || filepath.Dir(name) != "." {
+			return fmt.Errorf("%w: %s is not a file name", ErrInvalidDashboard, name)
+		}
+		rendered, err := renderDashboard(name, raw, dashboardData{InstanceID: instanceID, DatasourceUID: promDatasourceUID})
+		if err != nil {
+			return err
+		}
+		if err = validateDashboard(name, rendered); err != nil {
+			return err
+		}
+		if rendered, err = setRefresh(name, rendered, g.refresh); err != nil {
+			return err
+		}
+		if err = files.WriteFile(filepath.Join(dir, name), rendered); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// copyDashboards copy dashboards to $DATA_DIR/dashboards
-func (g *GrafanaService) copyDashboards(dst string) (err error) {
-	return fs.WalkDir(dashboards, "dashboards", func(path string, d fs.DirEntry, err error) error {
+// RemoveDashboards removes the dashboards of the instance. It does nothing if
+// the instance has no dashboards.
+func (g *GrafanaService) RemoveDashboards(instanceID string) error {
+	return g.stack.RemoveAll(instanceDashboardsDir(instanceID))
+}
+
+// instanceDashboardsDir returns the directory of the dashboards of the
+// instance in the monitoring stack.
+func instanceDashboardsDir(instanceID string) string {
+	return filepath.Join("grafana", "data", dashboardsDir, instanceID)
+}
+
+// dashboardData holds the instance metadata substituted in the template markers
+// of the dashboards, e.g. << .InstanceID | default `.+` >>. Raw strings are
+// used in the markers as the quotes of the dashboards JSON strings are escaped.
+type dashboardData struct {
+	InstanceID string
+	// DatasourceUID is the UID of the Prometheus datasource, so the dashboards
+	// reference the provisioned datasource with << .DatasourceUID >>.
+	DatasourceUID string
+}
+
+// dashboardRefresh is the auto-refresh interval set on the provisioned
+// dashboards.
+type dashboardRefresh struct {
+	// Interval is the refresh interval, like 10s. The refresh baked in the
+	// dashboards is kept if empty.
+	Interval string
+	// Force sets the interval on the dashboards that set their own refresh
+	// too. Otherwise, it is only set on the dashboards without refresh.
+	Force bool
+}
+
+// refreshIntervalRegex matches the Grafana refresh intervals, like 10s or 1h.
+var refreshIntervalRegex = regexp.MustCompile(`^[1-9][0-9]*[smhdwMy]$`)
+
+// dashboardsRefresh validates and returns the refresh of the dashboards from
+// the given dotenv values. The refresh of the dashboards is kept if
+// GRAFANA_DASHBOARD_REFRESH is not set.
+func dashboardsRefresh(options map[string]string) (dashboardRefresh, error) {
+	interval := options["GRAFANA_DASHBOARD_REFRESH"]
+	if interval == "" {
+		return dashboardRefresh{}, nil
+	}
+	if !refreshIntervalRegex.MatchString(interval) {
+		return dashboardRefresh{}, fmt.Errorf("%w: %s must be an interval like 10s, 1m or 1h", ErrInvalidOptions, "GRAFANA_DASHBOARD_REFRESH")
+	}
+	var force bool
+	if rawForce := options["GRAFANA_DASHBOARD_REFRESH_FORCE"]; rawForce != "" {
+		var err error
+		force, err = strconv.ParseBool(rawForce)
 		if err != nil {
+			return dashboardRefresh{}, fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "GRAFANA_DASHBOARD_REFRESH_FORCE")
+		}
+	}
+	return dashboardRefresh{Interval: interval, Force: force}, nil
+}
+
+// setRefresh sets the refresh of the given dashboard JSON. The dashboard is
+// returned unchanged if the refresh has no interval, or if the dashboard
+// already has a refresh and the refresh is not forced.
+func setRefresh(name string, raw []byte, refresh dashboardRefresh) ([]byte, error) {
+	if refresh.Interval == "" {
+		return raw, nil
+	}
+	var dashboard map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &dashboard); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidDashboard, name, err)
+	}
+	var current any
+	if rawRefresh, ok := dashboard["refresh"]; ok {
+		if err := json.Unmarshal(rawRefresh, &current); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidDashboard, name, err)
+		}
+	}
+	// Grafana saves dashboards without auto-refresh with an empty or false
+	// refresh
+	if current != nil && current != "" && current != false && !refresh.Force {
+		return raw, nil
+	}
+	interval, err := json.Marshal(refresh.Interval)
+	if err != nil {
+		return nil, err
+	}
+	dashboard["refresh"] = interval
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// copyDashboards copy the dashboards directory of src to $DATA_DIR/dashboards.
+// A missing or empty dashboards directory is copied as zero dashboards.
+// Dashboards with template markers are rendered with the given data, the rest
+// are copied unchanged. The refresh interval, if any, is then set on the
+// dashboards, see setRefresh. The UIDs of the dashboards are checked against
+// and recorded in uids, see checkDashboardUID. The directories of src in
+// skipDirs are not copied.
+func copyDashboards(src fs.FS, files *stackFiles, dst string, data dashboardData, refresh dashboardRefresh, uids map[string]string, skipDirs ...string) (err error) {
+	return fs.WalkDir(src, dashboardsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dashboardsDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
 			return err
 		}
+		if d.IsDir() && slices.Contains(skipDirs, path) {
+			return fs.SkipDir
+		}
 		if !d.IsDir() {
-			dashboard, err := dashboards.Open(path)
+			dashboard, err := src.Open(path)
 			if err != nil {
 				return err
 			}
@@ -155,15 +644,30 @@ func (g *GrafanaService) copyDashboards(dst string) (err error) {
 					err = cerr
 				}
 			}()
-			data, err := io.ReadAll(dashboard)
+			raw, err := io.ReadAll(dashboard)
+			if err != nil {
+				return err
+			}
+			rendered, err := renderDashboard(path, raw, data)
 			if err != nil {
 				return err
 			}
-			if err = g.stack.WriteFile(filepath.Join(dst, path), data); err != nil {
+			if filepath.Ext(path) == ".json" {
+				if err = validateDashboard(path, rendered); err != nil {
+					return err
+				}
+				if err = checkDashboardUID(path, rendered, uids); err != nil {
+					return err
+				}
+				if rendered, err = setRefresh(path, rendered, refresh); err != nil {
+					return err
+				}
+			}
+			if err = files.WriteFile(filepath.Join(dst, path), rendered); err != nil {
 				return err
 			}
 		} else {
-			if err = g.stack.CreateDir(filepath.Join(dst, path)); err != nil {
+			if err = files.CreateDir(filepath.Join(dst, path)); err != nil {
 				return err
 			}
 		}
@@ -171,6 +675,189 @@ func (g *GrafanaService) copyDashboards(dst string) (err error) {
 	})
 }
 
+// readExtraDashboards reads the .json files of the GRAFANA_EXTRA_DASHBOARDS_DIR
+// directory, keyed by their file name, and checks they are valid JSON. It
+// returns nil if the option is not set. The rest of the files and the
+// subdirectories of the directory are ignored.
+func readExtraDashboards(options map[string]string) (map[string][]byte, error) {
+	dir := options["GRAFANA_EXTRA_DASHBOARDS_DIR"]
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidOptions, "GRAFANA_EXTRA_DASHBOARDS_DIR", err)
+	}
+	extra := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		extra[entry.Name()] = raw
+	}
+	return extra, nil
+}
+
+// writeExtraDashboards provisions the given extra dashboards, keyed by their
+// file name, like copyDashboards does with the embedded ones. The extra
+// dashboards of a previous setup are replaced.
+func writeExtraDashboards(files *stackFiles, stack *datadir.MonitoringStack, extra map[string][]byte, data dashboardData, refresh dashboardRefresh, uids map[string]string) error {
+	dir := filepath.Join("grafana", "data", dashboardsDir, extraDashboardsDir)
+	if err := stack.RemoveAll(dir); err != nil {
+		return err
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	if err := files.CreateDir(dir); err != nil {
+		return err
+	}
+	// Sorted, so the UID collisions are reported on the same dashboard
+	names := maps.Keys(extra)
+	sort.Strings(names)
+	for _, name := range names {
+		rendered, err := renderDashboard(name, extra[name], data)
+		if err != nil {
+			return err
+		}
+		if err = validateDashboard(name, rendered); err != nil {
+			return err
+		}
+		if err = checkDashboardUID(name, rendered, uids); err != nil {
+			return err
+		}
+		if rendered, err = setRefresh(name, rendered, refresh); err != nil {
+			return err
+		}
+		if err = files.WriteFile(filepath.Join(dir, name), rendered); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDashboardUID returns an ErrInvalidDashboard error if the UID of the
+// given dashboard is already in uids, the UIDs of the dashboards provisioned
+// so far mapped to their names, as Grafana only provisions one of the
+// dashboards with the same UID. Otherwise, the UID is added to uids.
+// Dashboards without UID get a generated one, so they never collide.
+func checkDashboardUID(name string, raw []byte, uids map[string]string) error {
+	var dashboard struct {
+		UID string `json:"uid"`
+	}
+	if err := json.Unmarshal(raw, &dashboard); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidDashboard, name, err)
+	}
+	if dashboard.UID == "" {
+		return nil
+	}
+	if other, ok := uids[dashboard.UID]; ok {
+		return fmt.Errorf("%w: %s has the UID %s of %s", ErrInvalidDashboard, name, dashboard.UID, other)
+	}
+	uids[dashboard.UID] = name
+	return nil
+}
+
+// validateDashboard returns an ErrInvalidDashboard error if the given dashboard
+// is not valid JSON.
+func validateDashboard(name string, raw []byte) error {
+	if !json.Valid(raw) {
+		return fmt.Errorf("%w: %s is not valid JSON", ErrInvalidDashboard, name)
+	}
+	return nil
+}
+
+// ValidateProvisioning checks that the provisioning files written by Setup are
+// valid YAML and that the provisioned dashboards are valid JSON.
+func (g *GrafanaService) ValidateProvisioning() error {
+	grafProvPath := filepath.Join("grafana", "provisioning")
+	for _, path := range []string{
+		filepath.Join(grafProvPath, "datasources", "prom.yml"),
+		filepath.Join(grafProvPath, "dashboards", "dashboards.yml"),
+	} {
+		raw, err := g.stack.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidProvisioning, err)
+		}
+		var provisioning map[string]any
+		if err = yaml.Unmarshal(raw, &provisioning); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidProvisioning, path, err)
+		}
+	}
+	return fs.WalkDir(dashboards, dashboardsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// The cAdvisor dashboard is only provisioned with the cAdvisor service
+		if d.IsDir() && path == cadvisorDashboardsDir && !g.cadvisor {
+			return fs.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		raw, err := g.stack.ReadFile(filepath.Join("grafana", "data", path))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidDashboard, err)
+		}
+		return validateDashboard(path, raw)
+	})
+}
+
+// ValidateEmbeddedAssets checks that the embedded config templates parse, that
+// dashboards.yml is valid YAML and that the embedded dashboards are valid JSON
+// once rendered.
+func (g *GrafanaService) ValidateEmbeddedAssets() error {
+	errs := []error{monitoring.ValidateAssets(config, "config/prom.yml", "config/grafana.ini", "config/contact-points.yml")}
+	err := fs.WalkDir(dashboards, dashboardsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		raw, err := dashboards.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rendered, err := renderDashboard(path, raw, dashboardData{})
+		if err == nil {
+			err = validateDashboard(path, rendered)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", monitoring.ErrInvalidEmbeddedAsset, err))
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", monitoring.ErrInvalidEmbeddedAsset, err))
+	}
+	return errors.Join(errs...)
+}
+
+// renderDashboard executes the template markers of the given dashboard with
+// the given data. A dashboard without template markers is returned unchanged.
+func renderDashboard(name string, raw []byte, data dashboardData) ([]byte, error) {
+	if !bytes.Contains(raw, []byte(dashboardLeftDelim)) {
+		return raw, nil
+	}
+	tmp, err := template.New(name).
+		Delims(dashboardLeftDelim, dashboardRightDelim).
+		Funcs(monitoring.TemplateFuncs()).
+		Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDashboard, err)
+	}
+	var rendered bytes.Buffer
+	if err = tmp.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDashboard, err)
+	}
+	return rendered.Bytes(), nil
+}
+
 func (g *GrafanaService) SetContainerIP(ip net.IP) {
 	g.containerIP = ip
 }
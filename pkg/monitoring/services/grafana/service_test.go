@@ -1,10 +1,18 @@
 package grafana
 
 import (
+	"context"
+	"embed"
+	"encoding/json"
 	"fmt"
+	"io/fs"
+	"maps"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"testing/fstest"
 
 	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/locker/mocks"
@@ -47,6 +55,27 @@ type ExemplarTraceIdDestination struct {
 	Name          string `yaml:"name"`
 }
 
+type AlertingConfig struct {
+	APIVersion    int            `yaml:"apiVersion"`
+	ContactPoints []ContactPoint `yaml:"contactPoints"`
+	Policies      []Policy       `yaml:"policies"`
+}
+
+type ContactPoint struct {
+	Name      string     `yaml:"name"`
+	Receivers []Receiver `yaml:"receivers"`
+}
+
+type Receiver struct {
+	UID      string            `yaml:"uid"`
+	Type     string            `yaml:"type"`
+	Settings map[string]string `yaml:"settings"`
+}
+
+type Policy struct {
+	Receiver string `yaml:"receiver"`
+}
+
 func TestInit(t *testing.T) {
 	// Create an in-memory filesystem
 	afs := afero.NewMemMapFs()
@@ -104,27 +133,40 @@ func TestInit(t *testing.T) {
 }
 
 func TestSetup(t *testing.T) {
-	okLocker := func(t *testing.T) *mocks.MockLocker {
-		// Create a mock locker
-		ctrl := gomock.NewController(t)
-		locker := mocks.NewMockLocker(ctrl)
+	lockerWithWrites := func(times int) func(t *testing.T) *mocks.MockLocker {
+		return func(t *testing.T) *mocks.MockLocker {
+			// Create a mock locker
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
 
-		// Expect the lock to be acquired
-		gomock.InOrder(
-			locker.EXPECT().New("/monitoring/.lock").Return(locker),
-			locker.EXPECT().Lock().Return(nil),
-			locker.EXPECT().Locked().Return(true),
-			locker.EXPECT().Unlock().Return(nil),
-		)
-		for i := 0; i < 9; i++ {
+			// Expect the lock to be acquired
 			gomock.InOrder(
+				locker.EXPECT().New("/monitoring/.lock").Return(locker),
 				locker.EXPECT().Lock().Return(nil),
 				locker.EXPECT().Locked().Return(true),
 				locker.EXPECT().Unlock().Return(nil),
 			)
+			for i := 0; i < times; i++ {
+				gomock.InOrder(
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+				)
+			}
+			return locker
 		}
-		return locker
 	}
+	// Checking that the grafana directory does not exist yet takes one more,
+	// removing the extra dashboards of a previous setup another one,
+	// removing the cAdvisor dashboard without cAdvisor another one, and
+	// removing the contact points without alerts another one
+	okLocker := lockerWithWrites(14)
+	// Creating the cAdvisor dashboard directory and file instead of removing
+	// it takes one more
+	cadvisorLocker := lockerWithWrites(15)
+	// Creating the alerting directory and contact points file instead of
+	// removing it takes one more
+	alertingLocker := lockerWithWrites(15)
 	onlyNewLocker := func(t *testing.T) *mocks.MockLocker {
 		// Create a mock locker
 		ctrl := gomock.NewController(t)
@@ -135,10 +177,23 @@ func TestSetup(t *testing.T) {
 		return locker
 	}
 
+	// Secret file mounted in the container
+	passwordFile := filepath.Join(t.TempDir(), "admin-password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-secret\n"), 0o600))
+
 	tests := []struct {
-		name    string
-		mocker  func(t *testing.T) *mocks.MockLocker
-		options map[string]string
+		name       string
+		mocker     func(t *testing.T) *mocks.MockLocker
+		options    map[string]string
+		grafanaIni string
+		// receivers are the expected contact point receiver types, nil if
+		// no contact points file should be provisioned.
+		receivers []string
+		// addresses are the expected addresses of the email receiver.
+		addresses string
+		// refresh is the expected refresh of the provisioned dashboards, keyed
+		// by their file, if checked.
+		refresh map[string]string
 		wantErr bool
 	}{
 		{
@@ -148,6 +203,325 @@ func TestSetup(t *testing.T) {
 				"PROM_PORT":    "9090",
 				"GRAFANA_PORT": "3000",
 			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, anonymous access disabled",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ANONYMOUS_ENABLED": "false",
+				"GRAFANA_ANONYMOUS_ROLE":    "Viewer",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, anonymous access enabled",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ANONYMOUS_ENABLED": "true",
+				"GRAFANA_ANONYMOUS_ROLE":    "Editor",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = true\norg_role = Editor\n",
+		},
+		{
+			name:   "ok, anonymous access enabled with default role",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ANONYMOUS_ENABLED": "true",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = true\norg_role = Viewer\n",
+		},
+		{
+			name:   "invalid anonymous access toggle",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ANONYMOUS_ENABLED": "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid anonymous role",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ANONYMOUS_ENABLED": "true",
+				"GRAFANA_ANONYMOUS_ROLE":    "Owner",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "ok, served from root path",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9090",
+				"GRAFANA_PORT":               "3000",
+				"GRAFANA_ROOT_URL":           "",
+				"GRAFANA_SERVE_FROM_SUBPATH": "false",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, served from subpath",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9090",
+				"GRAFANA_PORT":               "3000",
+				"GRAFANA_ROOT_URL":           "https://example.com/grafana/",
+				"GRAFANA_SERVE_FROM_SUBPATH": "true",
+			},
+			grafanaIni: "[server]\nroot_url = https://example.com/grafana/\nserve_from_sub_path = true\n\n[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, root URL behind a rewriting proxy",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":        "9090",
+				"GRAFANA_PORT":     "3000",
+				"GRAFANA_ROOT_URL": "https://example.com/grafana/",
+			},
+			grafanaIni: "[server]\nroot_url = https://example.com/grafana/\nserve_from_sub_path = false\n\n[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, admin password",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":              "9090",
+				"GRAFANA_PORT":           "3000",
+				"GRAFANA_ADMIN_PASSWORD": "secret",
+			},
+			// The password is read from the container environment, not written to the config
+			grafanaIni: "[auth.anonymous]\nenabled = false\n\n[security]\nadmin_password = $__env{GRAFANA_ADMIN_PASSWORD}\n",
+		},
+		{
+			name:   "ok, admin password file",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                   "9090",
+				"GRAFANA_PORT":                "3000",
+				"GRAFANA_ADMIN_PASSWORD":      "admin",
+				"GRAFANA_ADMIN_PASSWORD_FILE": passwordFile,
+			},
+			// The password is read from the mounted file, not written to the config
+			grafanaIni: "[auth.anonymous]\nenabled = false\n\n[security]\nadmin_password = $__file{/run/secrets/grafana_admin_password}\n",
+		},
+		{
+			name:   "missing admin password file",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                   "9090",
+				"GRAFANA_PORT":                "3000",
+				"GRAFANA_ADMIN_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid root URL",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":        "9090",
+				"GRAFANA_PORT":     "3000",
+				"GRAFANA_ROOT_URL": "example.com/grafana",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid serve from subpath toggle",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9090",
+				"GRAFANA_PORT":               "3000",
+				"GRAFANA_ROOT_URL":           "https://example.com/grafana/",
+				"GRAFANA_SERVE_FROM_SUBPATH": "sometimes",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "serve from subpath without root URL",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9090",
+				"GRAFANA_PORT":               "3000",
+				"GRAFANA_SERVE_FROM_SUBPATH": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "serve from subpath with root URL without path",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9090",
+				"GRAFANA_PORT":               "3000",
+				"GRAFANA_ROOT_URL":           "https://example.com/",
+				"GRAFANA_SERVE_FROM_SUBPATH": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "ok, webhook contact point",
+			mocker: alertingLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ALERT_WEBHOOK_URL": "https://alerts.example.com/hook",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+			receivers:  []string{"webhook"},
+		},
+		{
+			name:   "ok, all contact points",
+			mocker: alertingLocker,
+			options: map[string]string{
+				"PROM_PORT":                     "9090",
+				"GRAFANA_PORT":                  "3000",
+				"GRAFANA_ALERT_WEBHOOK_URL":     "http://alerts.example.com/hook",
+				"GRAFANA_ALERT_SLACK_URL":       "https://hooks.slack.com/services/T000/B000/XXX",
+				"GRAFANA_ALERT_EMAIL_ADDRESSES": "ops@example.com, On Call <oncall@example.com>",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+			receivers:  []string{"webhook", "slack", "email"},
+			addresses:  "ops@example.com;oncall@example.com",
+		},
+		{
+			name:   "ok, prometheus host override",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":    "9090",
+				"GRAFANA_PORT": "3000",
+				"PROM_HOST":    "external-prom",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "ok, dashboard refresh",
+			mocker: cadvisorLocker,
+			options: map[string]string{
+				"PROM_PORT":                       "9090",
+				"GRAFANA_PORT":                    "3000",
+				"GRAFANA_DASHBOARD_REFRESH":       "10s",
+				"GRAFANA_DASHBOARD_REFRESH_FORCE": "false",
+				"CADVISOR_ENABLED":                "true",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+			refresh: map[string]string{
+				"common-metrics/common-metrics.json": "10s",
+				"node-exporter/node-exporter.json":   "10s",
+				// The cadvisor dashboard sets its own refresh
+				"cadvisor/cadvisor.json": "30s",
+			},
+		},
+		{
+			name:   "ok, forced dashboard refresh",
+			mocker: cadvisorLocker,
+			options: map[string]string{
+				"PROM_PORT":                       "9090",
+				"GRAFANA_PORT":                    "3000",
+				"GRAFANA_DASHBOARD_REFRESH":       "1m",
+				"GRAFANA_DASHBOARD_REFRESH_FORCE": "true",
+				"CADVISOR_ENABLED":                "true",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+			refresh: map[string]string{
+				"common-metrics/common-metrics.json": "1m",
+				"node-exporter/node-exporter.json":   "1m",
+				"cadvisor/cadvisor.json":             "1m",
+			},
+		},
+		{
+			name:   "ok, dashboards keep their refresh",
+			mocker: cadvisorLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_DASHBOARD_REFRESH": "",
+				"CADVISOR_ENABLED":          "true",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+			refresh: map[string]string{
+				"common-metrics/common-metrics.json": "",
+				"cadvisor/cadvisor.json":             "30s",
+			},
+		},
+		{
+			name:   "invalid dashboard refresh",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_DASHBOARD_REFRESH": "10 seconds",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid dashboard refresh force toggle",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                       "9090",
+				"GRAFANA_PORT":                    "3000",
+				"GRAFANA_DASHBOARD_REFRESH":       "10s",
+				"GRAFANA_DASHBOARD_REFRESH_FORCE": "always",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid prometheus host",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":    "9090",
+				"GRAFANA_PORT": "3000",
+				"PROM_HOST":    "http://external-prom",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "ok, empty contact points",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                     "9090",
+				"GRAFANA_PORT":                  "3000",
+				"GRAFANA_ALERT_WEBHOOK_URL":     "",
+				"GRAFANA_ALERT_SLACK_URL":       "",
+				"GRAFANA_ALERT_EMAIL_ADDRESSES": "",
+			},
+			grafanaIni: "[auth.anonymous]\nenabled = false\n",
+		},
+		{
+			name:   "invalid webhook URL",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                 "9090",
+				"GRAFANA_PORT":              "3000",
+				"GRAFANA_ALERT_WEBHOOK_URL": "alerts.example.com/hook",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid slack URL scheme",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":               "9090",
+				"GRAFANA_PORT":            "3000",
+				"GRAFANA_ALERT_SLACK_URL": "ftp://hooks.slack.com/services",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid email addresses",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                     "9090",
+				"GRAFANA_PORT":                  "3000",
+				"GRAFANA_ALERT_EMAIL_ADDRESSES": "not an email",
+			},
+			wantErr: true,
 		},
 		{
 			name:   "missing prometheus port",
@@ -228,7 +602,7 @@ func TestSetup(t *testing.T) {
 			})
 
 			// Setup the Grafana service
-			err = grafana.Setup(tt.options)
+			err = grafana.Setup(context.Background(), tt.options)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -247,7 +621,11 @@ func TestSetup(t *testing.T) {
 				assert.NoError(t, err)
 
 				// Check the Prometheus port
-				promEndpoint := fmt.Sprintf("http://%s:%s", monitoring.PrometheusServiceName, tt.options["PROM_PORT"])
+				promHost := monitoring.PrometheusServiceName
+				if tt.options["PROM_HOST"] != "" {
+					promHost = tt.options["PROM_HOST"]
+				}
+				promEndpoint := fmt.Sprintf("http://%s:%s", promHost, tt.options["PROM_PORT"])
 				assert.Equal(t, promEndpoint, prom.Datasources[0].URL)
 
 				// Check the Dashboards config file
@@ -266,6 +644,10 @@ func TestSetup(t *testing.T) {
 					"/monitoring/grafana/data/dashboards/common-metrics/common-metrics-global.json",
 					"/monitoring/grafana/data/dashboards/node-exporter/node-exporter.json",
 				}
+				// The cAdvisor dashboard is only provisioned with cAdvisor
+				ok, err = afero.Exists(afs, "/monitoring/grafana/data/dashboards/cadvisor/cadvisor.json")
+				assert.NoError(t, err)
+				assert.Equal(t, tt.options["CADVISOR_ENABLED"] == "true", ok)
 				for _, folder := range foldersToCheck {
 					ok, err = afero.DirExists(afs, folder)
 					assert.True(t, ok)
@@ -276,52 +658,532 @@ func TestSetup(t *testing.T) {
 					assert.True(t, ok)
 					assert.NoError(t, err)
 				}
+				for file, want := range tt.refresh {
+					var dashboard struct {
+						Refresh string `json:"refresh"`
+					}
+					raw, err := afero.ReadFile(afs, filepath.Join("/monitoring/grafana/data/dashboards", file))
+					require.NoError(t, err)
+					require.NoError(t, json.Unmarshal(raw, &dashboard))
+					assert.Equal(t, want, dashboard.Refresh, file)
+				}
+
+				// Check the grafana.ini file
+				grafanaIni, err := afero.ReadFile(afs, "/monitoring/grafana/grafana.ini")
+				assert.NoError(t, err)
+				assert.Equal(t, tt.grafanaIni, string(grafanaIni))
+
+				// Check the contact points file
+				contactPointsPath := "/monitoring/grafana/provisioning/alerting/contact-points.yml"
+				if tt.receivers == nil {
+					ok, err = afero.Exists(afs, contactPointsPath)
+					assert.False(t, ok)
+					assert.NoError(t, err)
+				} else {
+					var alerting AlertingConfig
+					contactPointsYml, err := afero.ReadFile(afs, contactPointsPath)
+					require.NoError(t, err)
+					require.NoError(t, yaml.Unmarshal(contactPointsYml, &alerting))
+					require.Len(t, alerting.ContactPoints, 1)
+					var receivers []string
+					for _, receiver := range alerting.ContactPoints[0].Receivers {
+						receivers = append(receivers, receiver.Type)
+						switch receiver.Type {
+						case "webhook":
+							assert.Equal(t, tt.options["GRAFANA_ALERT_WEBHOOK_URL"], receiver.Settings["url"])
+						case "slack":
+							assert.Equal(t, tt.options["GRAFANA_ALERT_SLACK_URL"], receiver.Settings["url"])
+						case "email":
+							assert.Equal(t, tt.addresses, receiver.Settings["addresses"])
+						}
+					}
+					assert.Equal(t, tt.receivers, receivers)
+					require.Len(t, alerting.Policies, 1)
+					assert.Equal(t, alerting.ContactPoints[0].Name, alerting.Policies[0].Receiver)
+				}
 			}
 		})
 	}
 }
 
-func TestDotEnv(t *testing.T) {
-	// Create a new Grafana service
+func TestSetupTwice(t *testing.T) {
+	// Create an in-memory filesystem
+	afs := afero.NewMemMapFs()
+
+	// Create a mock locker
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	options := map[string]string{
+		"PROM_PORT":                 "9090",
+		"GRAFANA_PORT":              "3000",
+		"GRAFANA_ALERT_WEBHOOK_URL": "https://alerts.example.com/hook",
+	}
 	grafana := NewGrafana()
-	// Verify the dotEnv
-	assert.EqualValues(t, dotEnv, grafana.DotEnv())
+	require.NoError(t, grafana.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: options,
+	}))
+
+	provisioned := []string{
+		"/monitoring/grafana/provisioning/datasources/prom.yml",
+		"/monitoring/grafana/provisioning/dashboards/dashboards.yml",
+		"/monitoring/grafana/provisioning/alerting/contact-points.yml",
+		"/monitoring/grafana/grafana.ini",
+	}
+	readAll := func() map[string]string {
+		files := make(map[string]string)
+		for _, path := range provisioned {
+			content, err := afero.ReadFile(afs, path)
+			require.NoError(t, err)
+			files[path] = string(content)
+		}
+		return files
+	}
+
+	require.NoError(t, grafana.Setup(context.Background(), options))
+	first := readAll()
+	require.NoError(t, grafana.Setup(context.Background(), options))
+
+	// The provisioning files are regenerated, not appended to
+	assert.Equal(t, first, readAll())
+	var prom Config
+	require.NoError(t, yaml.Unmarshal([]byte(first["/monitoring/grafana/provisioning/datasources/prom.yml"]), &prom))
+	assert.Len(t, prom.Datasources, 1)
+	var alerting AlertingConfig
+	require.NoError(t, yaml.Unmarshal([]byte(first["/monitoring/grafana/provisioning/alerting/contact-points.yml"]), &alerting))
+	assert.Len(t, alerting.ContactPoints, 1)
+
+	// Disabling the alerts removes the contact points of the previous setup
+	delete(options, "GRAFANA_ALERT_WEBHOOK_URL")
+	require.NoError(t, grafana.Setup(context.Background(), options))
+	ok, err := afero.Exists(afs, "/monitoring/grafana/provisioning/alerting/contact-points.yml")
+	require.NoError(t, err)
+	assert.False(t, ok)
 }
 
-func TestSetContainerIP(t *testing.T) {
+func TestSetupExtraDashboards(t *testing.T) {
 	tests := []struct {
-		name string
-		ip   net.IP
+		name       string
+		dashboards map[string]string
+		files      []string
+		wantErr    error
 	}{
 		{
 			name: "ok",
-			ip:   net.ParseIP("127.0.0.1"),
+			dashboards: map[string]string{
+				"custom.json":   `{"uid": "custom", "title": "Custom"}`,
+				"no-uid.json":   `{"title": "Without UID", "panels": [{"datasource": {"uid": "<< .DatasourceUID >>"}}]}`,
+				"README.md":     "not a dashboard",
+				"nested/x.json": `{"uid": "nested"}`,
+			},
+			files: []string{"custom.json", "no-uid.json"},
 		},
 		{
-			name: "empty",
-			ip:   nil,
+			name: "invalid JSON",
+			dashboards: map[string]string{
+				"custom.json": `{"uid": "custom"`,
+			},
+			wantErr: ErrInvalidDashboard,
+		},
+		{
+			name: "UID of an embedded dashboard",
+			dashboards: map[string]string{
+				"cadvisor.json": `{"uid": "egn-cadvisor"}`,
+			},
+			wantErr: ErrInvalidDashboard,
+		},
+		{
+			name: "duplicated UID",
+			dashboards: map[string]string{
+				"a.json": `{"uid": "custom"}`,
+				"b.json": `{"uid": "custom"}`,
+			},
+			wantErr: ErrInvalidDashboard,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a new Grafana service
+			extraDir := t.TempDir()
+			for name, content := range tt.dashboards {
+				require.NoError(t, os.MkdirAll(filepath.Join(extraDir, filepath.Dir(name)), 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(extraDir, name), []byte(content), 0o644))
+			}
+
+			afs := afero.NewMemMapFs()
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			options := map[string]string{
+				"PROM_PORT":                    "9090",
+				"GRAFANA_PORT":                 "3000",
+				"GRAFANA_EXTRA_DASHBOARDS_DIR": extraDir,
+				"CADVISOR_ENABLED":             "true",
+			}
 			grafana := NewGrafana()
-			grafana.SetContainerIP(tt.ip)
-			assert.Equal(t, tt.ip, grafana.containerIP)
+			require.NoError(t, grafana.Init(types.ServiceOptions{
+				Stack:  stack,
+				Dotenv: options,
+			}))
+			err = grafana.Setup(context.Background(), options)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			// The extra dashboards land next to the embedded ones, rendered
+			extra := "/monitoring/grafana/data/dashboards/extra"
+			entries, err := afero.ReadDir(afs, extra)
+			require.NoError(t, err)
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			assert.ElementsMatch(t, tt.files, names)
+			custom, err := afero.ReadFile(afs, filepath.Join(extra, "custom.json"))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.dashboards["custom.json"], string(custom))
+			noUID, err := afero.ReadFile(afs, filepath.Join(extra, "no-uid.json"))
+			require.NoError(t, err)
+			assert.Contains(t, string(noUID), promDatasourceUID)
+			ok, err := afero.Exists(afs, "/monitoring/grafana/data/dashboards/cadvisor/cadvisor.json")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// A dashboard removed from the directory is removed on the next setup
+			require.NoError(t, os.Remove(filepath.Join(extraDir, "custom.json")))
+			require.NoError(t, grafana.Setup(context.Background(), options))
+			ok, err = afero.Exists(afs, filepath.Join(extra, "custom.json"))
+			require.NoError(t, err)
+			assert.False(t, ok)
 		})
 	}
-}
-
-func TestContainerName(t *testing.T) {
-	want := monitoring.GrafanaContainerName
 
-	// Create a new Grafana service
-	grafana := NewGrafana()
-	assert.Equal(t, want, grafana.ContainerName())
+	t.Run("missing directory", func(t *testing.T) {
+		options := map[string]string{
+			"PROM_PORT":                    "9090",
+			"GRAFANA_PORT":                 "3000",
+			"GRAFANA_EXTRA_DASHBOARDS_DIR": filepath.Join(t.TempDir(), "missing"),
+		}
+		_, err := readExtraDashboards(options)
+		assert.ErrorIs(t, err, ErrInvalidOptions)
+	})
 }
 
-func TestEndpoint(t *testing.T) {
+func TestDatasourceUID(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	grafana := NewGrafana()
+	require.NoError(t, grafana.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: map[string]string{"PROM_PORT": "9090", "GRAFANA_PORT": "3000"},
+	}))
+	datasourceUID := func() string {
+		raw, err := afero.ReadFile(afs, "/monitoring/grafana/provisioning/datasources/prom.yml")
+		require.NoError(t, err)
+		var prom Config
+		require.NoError(t, yaml.Unmarshal(raw, &prom))
+		require.Len(t, prom.Datasources, 1)
+		return prom.Datasources[0].UID
+	}
+
+	// The UID is the same across setups, even with other options
+	require.NoError(t, grafana.Setup(context.Background(), map[string]string{"PROM_PORT": "9090", "GRAFANA_PORT": "3000"}))
+	uid := datasourceUID()
+	require.NoError(t, grafana.Setup(context.Background(), map[string]string{"PROM_PORT": "9091", "GRAFANA_PORT": "3001"}))
+	assert.Equal(t, uid, datasourceUID())
+	assert.Equal(t, promDatasourceUID, uid)
+
+	// The provisioned dashboards reference the datasource by its UID, or let
+	// the user pick it with a datasource variable
+	err = afero.Walk(afs, "/monitoring/grafana/data/dashboards", func(path string, info fs.FileInfo, err error) error {
+		require.NoError(t, err)
+		if info.IsDir() {
+			return nil
+		}
+		raw, err := afero.ReadFile(afs, path)
+		require.NoError(t, err)
+		var dashboard any
+		require.NoError(t, json.Unmarshal(raw, &dashboard), path)
+		for _, ref := range datasourceUIDs(dashboard) {
+			assert.Contains(t, []string{uid, "${DS_PROMETHEUS}", "-- Grafana --", "grafana"}, ref, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The instance dashboards get the same UID
+	require.NoError(t, grafana.AddDashboards("mock-avs-default", map[string][]byte{
+		"avs.json": []byte(`{"panels": [{"datasource": {"type": "prometheus", "uid": "<< .DatasourceUID >>"}}]}`),
+	}))
+	raw, err := afero.ReadFile(afs, "/monitoring/grafana/data/dashboards/mock-avs-default/avs.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"panels": [{"datasource": {"type": "prometheus", "uid": "`+uid+`"}}]}`, string(raw))
+}
+
+// datasourceUIDs returns the UIDs of the datasources referenced in the given
+// dashboard JSON value.
+func datasourceUIDs(v any) []string {
+	var uids []string
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if ds, ok := value.(map[string]any); ok && key == "datasource" {
+				if uid, ok := ds["uid"].(string); ok {
+					uids = append(uids, uid)
+				}
+			}
+			uids = append(uids, datasourceUIDs(value)...)
+		}
+	case []any:
+		for _, value := range v {
+			uids = append(uids, datasourceUIDs(value)...)
+		}
+	}
+	return uids
+}
+
+func TestSetupRollback(t *testing.T) {
+	lockCycles := func(locker *mocks.MockLocker, times int) {
+		for i := 0; i < times; i++ {
+			gomock.InOrder(
+				locker.EXPECT().Lock().Return(nil),
+				locker.EXPECT().Locked().Return(true),
+				locker.EXPECT().Unlock().Return(nil),
+			)
+		}
+	}
+
+	tests := []struct {
+		name string
+		// existing are the files in the stack before the setup.
+		existing []string
+		// checks are the existence checks done before the datasource write.
+		checks int
+		// removed and kept are the paths expected after the failed setup.
+		removed []string
+		kept    []string
+	}{
+		{
+			name:   "fresh stack",
+			checks: 1,
+			removed: []string{
+				"/monitoring/grafana/provisioning/datasources/prom.yml",
+				"/monitoring/grafana",
+			},
+		},
+		{
+			name:     "existing grafana directory",
+			existing: []string{"/monitoring/grafana/data/keep.json"},
+			checks:   2,
+			removed: []string{
+				"/monitoring/grafana/provisioning/datasources/prom.yml",
+				"/monitoring/grafana/provisioning",
+			},
+			kept: []string{"/monitoring/grafana/data/keep.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create an in-memory filesystem
+			afs := afero.NewMemMapFs()
+			for _, path := range tt.existing {
+				require.NoError(t, afero.WriteFile(afs, path, []byte("{}"), 0o644))
+			}
+
+			// Create a mock locker
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			// Initialize the stack, check the existing paths, create the
+			// datasources directory and write the datasource file
+			lockCycles(locker, 1+tt.checks+2)
+			// Fail to create the dashboards directory
+			locker.EXPECT().Lock().Return(assert.AnError)
+			// Remove the created paths
+			lockCycles(locker, 1)
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			options := map[string]string{
+				"PROM_PORT":    "9090",
+				"GRAFANA_PORT": "3000",
+			}
+			grafana := NewGrafana()
+			require.NoError(t, grafana.Init(types.ServiceOptions{
+				Stack:  stack,
+				Dotenv: options,
+			}))
+
+			err = grafana.Setup(context.Background(), options)
+			require.ErrorIs(t, err, assert.AnError)
+
+			for _, path := range tt.removed {
+				ok, err := afero.Exists(afs, path)
+				require.NoError(t, err)
+				assert.False(t, ok, path)
+			}
+			for _, path := range tt.kept {
+				ok, err := afero.Exists(afs, path)
+				require.NoError(t, err)
+				assert.True(t, ok, path)
+			}
+		})
+	}
+}
+
+// cancelAfter is a context that is canceled once its Err method has been
+// called n times, to cancel an operation midway.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestSetupCanceled(t *testing.T) {
+	tests := []struct {
+		name string
+		// checks are the context checks that pass before the cancellation.
+		checks int
+	}{
+		{
+			name: "canceled before the setup",
+		},
+		{
+			name:   "canceled after the datasource",
+			checks: 5,
+		},
+		{
+			name:   "canceled while copying the dashboards",
+			checks: 12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(afs, "/monitoring/grafana/data/keep.json", []byte("{}"), 0o644))
+
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			options := map[string]string{
+				"PROM_PORT":    "9090",
+				"GRAFANA_PORT": "3000",
+			}
+			grafana := NewGrafana()
+			require.NoError(t, grafana.Init(types.ServiceOptions{
+				Stack:  stack,
+				Dotenv: options,
+			}))
+
+			err = grafana.Setup(&cancelAfter{Context: context.Background(), n: tt.checks}, options)
+			require.ErrorIs(t, err, context.Canceled)
+
+			// No partial files are left behind
+			var files []string
+			require.NoError(t, afero.Walk(afs, "/monitoring/grafana", func(path string, info fs.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					files = append(files, path)
+				}
+				return err
+			}))
+			assert.Equal(t, []string{"/monitoring/grafana/data/keep.json"}, files)
+			ok, err := afero.Exists(afs, "/monitoring/grafana/provisioning")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	// Create a new Grafana service
+	grafana := NewGrafana()
+	// Verify the dotEnv
+	assert.EqualValues(t, dotEnv, grafana.DotEnv())
+}
+
+func TestSetContainerIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+	}{
+		{
+			name: "ok",
+			ip:   net.ParseIP("127.0.0.1"),
+		},
+		{
+			name: "empty",
+			ip:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a new Grafana service
+			grafana := NewGrafana()
+			grafana.SetContainerIP(tt.ip)
+			assert.Equal(t, tt.ip, grafana.containerIP)
+		})
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	want := monitoring.GrafanaContainerName
+
+	// Create a new Grafana service
+	grafana := NewGrafana()
+	assert.Equal(t, want, grafana.ContainerName())
+}
+
+func TestEndpoint(t *testing.T) {
 	dotenv := map[string]string{
 		"GRAFANA_PORT": "3333",
 	}
@@ -338,3 +1200,462 @@ func TestEndpoint(t *testing.T) {
 	endpoint := grafana.Endpoint()
 	assert.Equal(t, want, endpoint)
 }
+
+func TestCopyDashboards(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   fs.FS
+		files []string
+	}{
+		{
+			name: "empty embed",
+			src:  embed.FS{},
+		},
+		{
+			name:  "empty dashboards directory",
+			src:   fstest.MapFS{"dashboards": &fstest.MapFile{Mode: fs.ModeDir}},
+			files: []string{"/monitoring/grafana/data/dashboards"},
+		},
+		{
+			name: "dashboards",
+			src: fstest.MapFS{
+				"dashboards/avs.json":          &fstest.MapFile{Data: []byte("{}")},
+				"dashboards/nested/nodes.json": &fstest.MapFile{Data: []byte("{}")},
+			},
+			files: []string{
+				"/monitoring/grafana/data/dashboards/avs.json",
+				"/monitoring/grafana/data/dashboards/nested/nodes.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			require.NoError(t, copyDashboards(tt.src, newStackFiles(context.Background(), stack), filepath.Join("grafana", "data"), dashboardData{}, dashboardRefresh{}, map[string]string{}))
+			for _, file := range tt.files {
+				ok, err := afero.Exists(afs, file)
+				require.NoError(t, err)
+				assert.True(t, ok, file)
+			}
+
+		})
+	}
+}
+
+func TestCopyDashboardsTemplate(t *testing.T) {
+	src := fstest.MapFS{
+		"dashboards/plain.json":     &fstest.MapFile{Data: []byte(`{"legendFormat": "{{instance}}", "expr": "up{job=\"node\"}"}`)},
+		"dashboards/templated.json": &fstest.MapFile{Data: []byte(`{"legendFormat": "{{instance}}", "expr": "up{job=\"node\", instanceID=\"<< .InstanceID | default ` + "`.+`" + ` >>\"}"}`)},
+	}
+	tests := []struct {
+		name      string
+		data      dashboardData
+		plain     string
+		templated string
+	}{
+		{
+			name:      "instance metadata",
+			data:      dashboardData{InstanceID: "mock-avs-default"},
+			plain:     `{"legendFormat": "{{instance}}", "expr": "up{job=\"node\"}"}`,
+			templated: `{"legendFormat": "{{instance}}", "expr": "up{job=\"node\", instanceID=\"mock-avs-default\"}"}`,
+		},
+		{
+			name:      "default values",
+			plain:     `{"legendFormat": "{{instance}}", "expr": "up{job=\"node\"}"}`,
+			templated: `{"legendFormat": "{{instance}}", "expr": "up{job=\"node\", instanceID=\".+\"}"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			require.NoError(t, copyDashboards(src, newStackFiles(context.Background(), stack), filepath.Join("grafana", "data"), tt.data, dashboardRefresh{}, map[string]string{}))
+
+			plain, err := afero.ReadFile(afs, "/monitoring/grafana/data/dashboards/plain.json")
+			require.NoError(t, err)
+			assert.Equal(t, tt.plain, string(plain))
+			templated, err := afero.ReadFile(afs, "/monitoring/grafana/data/dashboards/templated.json")
+			require.NoError(t, err)
+			assert.Equal(t, tt.templated, string(templated))
+		})
+	}
+}
+
+func TestSetRefresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		refresh dashboardRefresh
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no interval",
+			raw:  `{"refresh": "", "panels": []}`,
+			want: `{"refresh": "", "panels": []}`,
+		},
+		{
+			name:    "empty refresh",
+			raw:     `{"refresh": "", "panels": [{"targets": [{"expr": "up", "refresh": 1}]}]}`,
+			refresh: dashboardRefresh{Interval: "10s"},
+			want:    "{\n  \"panels\": [\n    {\n      \"targets\": [\n        {\n          \"expr\": \"up\",\n          \"refresh\": 1\n        }\n      ]\n    }\n  ],\n  \"refresh\": \"10s\"\n}",
+		},
+		{
+			name:    "missing refresh",
+			raw:     `{"title": "AVS"}`,
+			refresh: dashboardRefresh{Interval: "10s"},
+			want:    "{\n  \"refresh\": \"10s\",\n  \"title\": \"AVS\"\n}",
+		},
+		{
+			name:    "disabled refresh",
+			raw:     `{"refresh": false}`,
+			refresh: dashboardRefresh{Interval: "10s"},
+			want:    "{\n  \"refresh\": \"10s\"\n}",
+		},
+		{
+			name:    "explicit refresh is kept",
+			raw:     `{"refresh": "30s"}`,
+			refresh: dashboardRefresh{Interval: "10s"},
+			want:    `{"refresh": "30s"}`,
+		},
+		{
+			name:    "explicit refresh is overridden",
+			raw:     `{"refresh": "30s"}`,
+			refresh: dashboardRefresh{Interval: "10s", Force: true},
+			want:    "{\n  \"refresh\": \"10s\"\n}",
+		},
+		{
+			name:    "not a dashboard",
+			raw:     `[]`,
+			refresh: dashboardRefresh{Interval: "10s"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setRefresh("avs.json", []byte(tt.raw), tt.refresh)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDashboard)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSetupCadvisorDashboard(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	options := map[string]string{
+		"PROM_PORT":        "9090",
+		"GRAFANA_PORT":     "3000",
+		"CADVISOR_ENABLED": "true",
+	}
+	grafana := NewGrafana()
+	require.NoError(t, grafana.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: options,
+	}))
+	require.NoError(t, grafana.Setup(context.Background(), options))
+	ok, err := afero.Exists(afs, "/monitoring/grafana/data/dashboards/cadvisor/cadvisor.json")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, grafana.ValidateProvisioning())
+
+	// The dashboard is removed once cAdvisor is disabled
+	options["CADVISOR_ENABLED"] = "false"
+	require.NoError(t, grafana.Setup(context.Background(), options))
+	ok, err = afero.DirExists(afs, "/monitoring/grafana/data/dashboards/cadvisor")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, grafana.ValidateProvisioning())
+
+	options["CADVISOR_ENABLED"] = "maybe"
+	assert.ErrorIs(t, grafana.Setup(context.Background(), options), ErrInvalidOptions)
+}
+
+func TestValidateProvisioning(t *testing.T) {
+	ts := []struct {
+		name    string
+		corrupt map[string]string
+		err     error
+	}{
+		{
+			name: "valid provisioning",
+		},
+		{
+			name: "invalid datasources",
+			corrupt: map[string]string{
+				"/monitoring/grafana/provisioning/datasources/prom.yml": "datasources: [",
+			},
+			err: ErrInvalidProvisioning,
+		},
+		{
+			name: "invalid dashboard",
+			corrupt: map[string]string{
+				"/monitoring/grafana/data/dashboards/node-exporter/node-exporter.json": `{"panels": [`,
+			},
+			err: ErrInvalidDashboard,
+		},
+		{
+			name: "missing dashboard",
+			corrupt: map[string]string{
+				"/monitoring/grafana/data/dashboards/common-metrics/common-metrics.json": "",
+			},
+			err: ErrInvalidDashboard,
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+
+			options := map[string]string{
+				"PROM_PORT":    "9090",
+				"GRAFANA_PORT": "3000",
+			}
+			grafana := NewGrafana()
+			require.NoError(t, grafana.Init(types.ServiceOptions{
+				Stack:  stack,
+				Dotenv: options,
+			}))
+			require.NoError(t, grafana.Setup(context.Background(), options))
+
+			for path, content := range tt.corrupt {
+				if content == "" {
+					require.NoError(t, afs.Remove(path))
+					continue
+				}
+				require.NoError(t, afero.WriteFile(afs, path, []byte(content), 0o644))
+			}
+
+			err = grafana.ValidateProvisioning()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAddDashboards(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    map[string]string
+		dashboards map[string][]byte
+		want       map[string]string
+		err        error
+	}{
+		{
+			name: "rendered dashboards",
+			dashboards: map[string][]byte{
+				"plain.json":     []byte(`{"expr": "up"}`),
+				"templated.json": []byte(`{"expr": "up{instanceID=\"<< .InstanceID >>\"}"}`),
+			},
+			want: map[string]string{
+				"plain.json":     `{"expr": "up"}`,
+				"templated.json": `{"expr": "up{instanceID=\"mock-avs-default\"}"}`,
+			},
+		},
+		{
+			name: "dashboard refresh",
+			options: map[string]string{
+				"GRAFANA_DASHBOARD_REFRESH": "10s",
+			},
+			dashboards: map[string][]byte{
+				"plain.json": []byte(`{"expr": "up"}`),
+				"own.json":   []byte(`{"expr": "up", "refresh": "1m"}`),
+			},
+			want: map[string]string{
+				"plain.json": `{"expr": "up", "refresh": "10s"}`,
+				"own.json":   `{"expr": "up", "refresh": "1m"}`,
+			},
+		},
+		{
+			name: "forced dashboard refresh",
+			options: map[string]string{
+				"GRAFANA_DASHBOARD_REFRESH":       "10s",
+				"GRAFANA_DASHBOARD_REFRESH_FORCE": "true",
+			},
+			dashboards: map[string][]byte{
+				"own.json": []byte(`{"expr": "up", "refresh": "1m"}`),
+			},
+			want: map[string]string{
+				"own.json": `{"expr": "up", "refresh": "10s"}`,
+			},
+		},
+		{
+			name:       "no dashboards",
+			dashboards: map[string][]byte{},
+			want:       map[string]string{},
+		},
+		{
+			name: "invalid dashboard",
+			dashboards: map[string][]byte{
+				"invalid.json": []byte(`{"panels": [`),
+			},
+			err: ErrInvalidDashboard,
+		},
+		{
+			name: "not a file name",
+			dashboards: map[string][]byte{
+				"../plain.json": []byte(`{"expr": "up"}`),
+			},
+			err: ErrInvalidDashboard,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afs := afero.NewMemMapFs()
+			ctrl := gomock.NewController(t)
+			locker := mocks.NewMockLocker(ctrl)
+			locker.EXPECT().New("/monitoring/.lock").Return(locker)
+			locker.EXPECT().Lock().Return(nil).AnyTimes()
+			locker.EXPECT().Locked().Return(true).AnyTimes()
+			locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+			dataDir, err := data.NewDataDir("/", afs, locker)
+			require.NoError(t, err)
+			stack, err := dataDir.MonitoringStack()
+			require.NoError(t, err)
+			options := map[string]string{"GRAFANA_PORT": "3000"}
+			maps.Copy(options, tt.options)
+			grafana := NewGrafana()
+			require.NoError(t, grafana.Init(types.ServiceOptions{
+				Stack:  stack,
+				Dotenv: options,
+			}))
+
+			// A stale dashboard of a previous install is replaced
+			dir := "/monitoring/grafana/data/dashboards/mock-avs-default"
+			require.NoError(t, afs.MkdirAll(dir, 0o755))
+			require.NoError(t, afero.WriteFile(afs, filepath.Join(dir, "stale.json"), []byte(`{}`), 0o644))
+
+			err = grafana.AddDashboards("mock-avs-default", tt.dashboards)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				exists, err := afero.DirExists(afs, dir)
+				require.NoError(t, err)
+				assert.False(t, exists)
+				return
+			}
+			require.NoError(t, err)
+
+			files, err := afero.ReadDir(afs, dir)
+			if len(tt.want) > 0 {
+				require.NoError(t, err)
+			}
+			require.Len(t, files, len(tt.want))
+			for _, file := range files {
+				content, err := afero.ReadFile(afs, filepath.Join(dir, file.Name()))
+				require.NoError(t, err)
+				require.Contains(t, tt.want, file.Name())
+				assert.JSONEq(t, tt.want[file.Name()], string(content), file.Name())
+			}
+
+			require.NoError(t, grafana.RemoveDashboards("mock-avs-default"))
+			exists, err := afero.DirExists(afs, dir)
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestRenderDashboardInvalid(t *testing.T) {
+	_, err := renderDashboard("invalid.json", []byte(`{"expr": "<< .Unknown >>"}`), dashboardData{})
+	assert.ErrorIs(t, err, ErrInvalidDashboard)
+	_, err = renderDashboard("invalid.json", []byte(`{"expr": "<< .InstanceID"}`), dashboardData{})
+	assert.ErrorIs(t, err, ErrInvalidDashboard)
+}
+
+func TestPromEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		promHost string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "prometheus service",
+			want: "http://prometheus:9090",
+		},
+		{
+			name:     "prometheus host override",
+			promHost: "prom.example.com",
+			want:     "http://prom.example.com:9090",
+		},
+		{
+			name:     "prometheus host with scheme",
+			promHost: "http://prom.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "prometheus host with port",
+			promHost: "prom.example.com:9091",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, err := promEndpoint(map[string]string{
+				"PROM_PORT": "9090",
+				"PROM_HOST": tt.promHost,
+			})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidOptions)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, endpoint)
+		})
+	}
+}
+
+func TestValidateEmbeddedAssets(t *testing.T) {
+	assert.NoError(t, NewGrafana().ValidateEmbeddedAssets())
+}
@@ -1,6 +1,7 @@
 package node_exporter
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strconv"
@@ -9,13 +10,20 @@ import (
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
 )
 
-var _ monitoring.ServiceAPI = &NodeExporterService{}
+var (
+	_ monitoring.ServiceAPI      = &NodeExporterService{}
+	_ monitoring.OptionsRequirer = &NodeExporterService{}
+)
 
 type NodeExporterService struct {
 	containerIP net.IP
 	port        uint16
 }
 
+func init() {
+	monitoring.Register("node_exporter", func() monitoring.ServiceAPI { return NewNodeExporter() })
+}
+
 func NewNodeExporter() *NodeExporterService {
 	return &NodeExporterService{}
 }
@@ -49,7 +57,12 @@ func (n *NodeExporterService) DotEnv() map[string]string {
 	return dotEnv
 }
 
-func (n *NodeExporterService) Setup(options map[string]string) error {
+// RequiredOptions returns the dotenv variables required by Init.
+func (n *NodeExporterService) RequiredOptions() []string {
+	return []string{"NODE_EXPORTER_PORT"}
+}
+
+func (n *NodeExporterService) Setup(ctx context.Context, options map[string]string) error {
 	return nil
 }
 
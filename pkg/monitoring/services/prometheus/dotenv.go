@@ -4,4 +4,20 @@ var dotEnv map[string]string = map[string]string{
 	"PROM_IMAGE": "prom/prometheus:v2.37.0",
 	"PROM_PORT":  "9090",
 	"PROM_CONF":  "./prometheus/prometheus.yml",
+	"PROM_RULES": "./prometheus/rules",
+	// Comma-separated key=value labels added to the series sent to other
+	// systems, like a federating Prometheus
+	"PROM_EXTERNAL_LABELS": "",
+	// Remote write is disabled when PROM_REMOTE_WRITE_URL is empty
+	"PROM_REMOTE_WRITE_URL":      "",
+	"PROM_REMOTE_WRITE_USERNAME": "",
+	"PROM_REMOTE_WRITE_PASSWORD": "",
+	// The remote write password is read from this file path if it is set
+	"PROM_REMOTE_WRITE_PASSWORD_FILE": "",
+	// The instance containers are discovered through the docker socket and
+	// scraped when PROM_DOCKER_SD is true. The socket is only mounted in
+	// Prometheus then, which gets the PROM_DOCKER_GID group to read it, or
+	// the group owning the socket if empty
+	"PROM_DOCKER_SD":  "false",
+	"PROM_DOCKER_GID": "",
 }
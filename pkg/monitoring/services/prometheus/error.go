@@ -3,6 +3,12 @@ package prometheus
 import "errors"
 
 var (
-	ErrReloadFailed   = errors.New("failed to reload Prometheus config")
-	ErrInvalidOptions = errors.New("invalid options for grafana setup")
+	ErrReloadFailed    = errors.New("failed to reload Prometheus config")
+	ErrInvalidOptions  = errors.New("invalid options for grafana setup")
+	ErrInvalidRules    = errors.New("invalid alerting rules")
+	ErrUnsupported     = errors.New("unsupported by Prometheus")
+	ErrInvalidConfig   = errors.New("invalid Prometheus config")
+	ErrInvalidTargets  = errors.New("invalid Prometheus targets file")
+	ErrInvalidTarget   = errors.New("invalid monitoring target")
+	ErrQueryingTargets = errors.New("failed to query Prometheus targets")
 )
@@ -1,19 +1,30 @@
 package prometheus
 
 import (
+	"context"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/data"
+	"github.com/NethermindEth/eigenlayer/internal/docker"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring"
 	"github.com/NethermindEth/eigenlayer/pkg/monitoring/services/types"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
 	"gopkg.in/yaml.v3"
@@ -22,38 +33,144 @@ import (
 //go:embed config
 var config embed.FS
 
+//go:embed rules
+var rules embed.FS
+
+const (
+	// configFile is the Prometheus configuration file in the monitoring stack.
+	configFile = "prometheus/prometheus.yml"
+	// rulesDir is the directory of the default alerting rules in the monitoring stack.
+	rulesDir = "prometheus/rules"
+	// avsRulesDir is the directory of the alerting rules shipped with AVS packages.
+	avsRulesDir = "prometheus/rules/avs"
+	// targetsFile is the file in the monitoring stack with the targets added
+	// with AddTarget. It is the source of truth of the scrape jobs of the
+	// targets, prometheus.yml is rendered from it.
+	targetsFile = "prometheus/targets.json"
+	// dockerSDJobName is the name of the scrape job of the instance containers
+	// discovered through the docker socket.
+	dockerSDJobName = "egn-containers"
+	// dockerSocket is the docker socket mounted in the Prometheus container.
+	dockerSocket = "unix:///var/run/docker.sock"
+	// scrapeInterval is the global scrape_interval of config/prometheus.yml.
+	// Prometheus rejects a config with a scrape timeout larger than it.
+	scrapeInterval = 15 * time.Second
+)
+
 // Config represents the Prometheus configuration.
 type Config struct {
-	Global        GlobalConfig   `yaml:"global"`
-	ScrapeConfigs []ScrapeConfig `yaml:"scrape_configs"`
+	Global        GlobalConfig        `yaml:"global"`
+	RuleFiles     []string            `yaml:"rule_files,omitempty"`
+	ScrapeConfigs []ScrapeConfig      `yaml:"scrape_configs"`
+	RemoteWrite   []RemoteWriteConfig `yaml:"remote_write,omitempty"`
 }
 
 // GlobalConfig represents the global configuration for Prometheus.
 type GlobalConfig struct {
-	ScrapeInterval string `yaml:"scrape_interval"`
+	ScrapeInterval string            `yaml:"scrape_interval"`
+	ExternalLabels map[string]string `yaml:"external_labels,omitempty"`
 }
 
 // ScrapeConfig represents the configuration for a Prometheus scrape job.
 type ScrapeConfig struct {
-	JobName       string         `yaml:"job_name"`
-	StaticConfigs []StaticConfig `yaml:"static_configs"`
-	MetricsPath   string         `yaml:"metrics_path,omitempty"`
+	JobName         string           `yaml:"job_name" json:"job_name"`
+	StaticConfigs   []StaticConfig   `yaml:"static_configs,omitempty" json:"static_configs"`
+	DockerSDConfigs []DockerSDConfig `yaml:"docker_sd_configs,omitempty" json:"docker_sd_configs,omitempty"`
+	RelabelConfigs  []RelabelConfig  `yaml:"relabel_configs,omitempty" json:"relabel_configs,omitempty"`
+	MetricsPath     string           `yaml:"metrics_path,omitempty" json:"metrics_path,omitempty"`
+	Scheme          string           `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	ScrapeTimeout   string           `yaml:"scrape_timeout,omitempty" json:"scrape_timeout,omitempty"`
+	HonorLabels     bool             `yaml:"honor_labels,omitempty" json:"honor_labels,omitempty"`
+}
+
+// DockerSDConfig represents the configuration of the Prometheus docker service
+// discovery.
+type DockerSDConfig struct {
+	Host    string           `yaml:"host" json:"host"`
+	Filters []DockerSDFilter `yaml:"filters,omitempty" json:"filters,omitempty"`
+}
+
+// DockerSDFilter represents a filter of the containers listed by the
+// Prometheus docker service discovery, like the docker ps --filter option.
+type DockerSDFilter struct {
+	Name   string   `yaml:"name" json:"name"`
+	Values []string `yaml:"values" json:"values"`
+}
+
+// RelabelConfig represents a Prometheus relabeling rule.
+type RelabelConfig struct {
+	SourceLabels []string `yaml:"source_labels,omitempty" json:"source_labels,omitempty"`
+	Regex        string   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	TargetLabel  string   `yaml:"target_label,omitempty" json:"target_label,omitempty"`
+	Replacement  string   `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+	Action       string   `yaml:"action,omitempty" json:"action,omitempty"`
 }
 
 // StaticConfig represents the static configuration for a Prometheus scrape job.
 type StaticConfig struct {
-	Targets []string          `yaml:"targets"`
-	Labels  map[string]string `yaml:"labels,omitempty"`
+	Targets []string          `yaml:"targets" json:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// RemoteWriteConfig represents the configuration for a Prometheus remote write endpoint.
+type RemoteWriteConfig struct {
+	URL       string     `yaml:"url"`
+	BasicAuth *BasicAuth `yaml:"basic_auth,omitempty"`
+}
+
+// BasicAuth represents the basic authentication configuration for a Prometheus endpoint.
+type BasicAuth struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+}
+
+// RuleFile represents a Prometheus rule file.
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup represents a group of Prometheus rules.
+type RuleGroup struct {
+	Name     string `yaml:"name"`
+	Interval string `yaml:"interval,omitempty"`
+	Limit    int    `yaml:"limit,omitempty"`
+	Rules    []Rule `yaml:"rules"`
+}
+
+// Rule represents a Prometheus alerting or recording rule.
+type Rule struct {
+	Alert         string            `yaml:"alert,omitempty"`
+	Record        string            `yaml:"record,omitempty"`
+	Expr          string            `yaml:"expr"`
+	For           string            `yaml:"for,omitempty"`
+	KeepFiringFor string            `yaml:"keep_firing_for,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Annotations   map[string]string `yaml:"annotations,omitempty"`
 }
 
-// Verify that PrometheusService implements the ServiceAPI interface.
-var _ monitoring.ServiceAPI = &PrometheusService{}
+// Verify that PrometheusService implements the ServiceAPI, RulesProvisioner and OptionsRequirer interfaces.
+var (
+	_ monitoring.ServiceAPI            = &PrometheusService{}
+	_ monitoring.RulesProvisioner      = &PrometheusService{}
+	_ monitoring.OptionsRequirer       = &PrometheusService{}
+	_ monitoring.TargetLister          = &PrometheusService{}
+	_ monitoring.ProvisioningValidator = &PrometheusService{}
+	_ monitoring.TargetsHealthReporter = &PrometheusService{}
+)
 
 // PrometheusService implements the ServiceAPI interface for a Prometheus service.
 type PrometheusService struct {
 	stack       *data.MonitoringStack
 	containerIP net.IP
 	port        uint16
+	// targetsMu serializes the read-modify-write of the targets file, so
+	// the targets added concurrently are not lost.
+	targetsMu sync.Mutex
+}
+
+func init() {
+	monitoring.Register("prometheus", func() monitoring.ServiceAPI { return NewPrometheus() })
 }
 
 // NewPrometheus creates a new PrometheusService.
@@ -80,29 +197,31 @@ func (p *PrometheusService) Init(opts types.ServiceOptions) error {
 	return nil
 }
 
-// AddTarget adds a new target to the Prometheus config and reloads the Prometheus configuration.
-// Assumes endpoint is in the form http://<ip/domain>:<port>
+// AddTarget adds a new target to the targets file, renders the Prometheus config and
+// reloads the Prometheus configuration. A target with the job name of an existing one
+// replaces it, so its labels are updated. Assumes endpoint is in the form
+// http://<ip/domain>:<port>. Prometheus only scrapes targets over TCP, so unix socket
+// targets are rejected with an ErrUnsupported error, and targets with a scrape timeout
+// larger than the scrape interval with an ErrInvalidTarget error. It is safe to call it
+// concurrently.
 func (p *PrometheusService) AddTarget(target types.MonitoringTarget, labels map[string]string, jobName string) error {
-	path := filepath.Join("prometheus", "prometheus.yml")
-	// Read the existing config
-	rawConfig, err := p.stack.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	// Unmarshal the YAML data into the Config struct
-	var config Config
-	if err = yaml.Unmarshal(rawConfig, &config); err != nil {
-		return err
+	if target.IsUnixSocket() {
+		return fmt.Errorf("%w: target %s is a unix socket, metrics can only be scraped over http or https", ErrUnsupported, target.Host)
 	}
-
-	// Add a new job for the new endpoint
-	// Check if the job already exists
-	for _, job := range config.ScrapeConfigs {
-		if job.JobName == jobName {
-			// There is no need to add the job if it already exists
-			return nil
+	if target.ScrapeTimeout != "" {
+		timeout, err := model.ParseDuration(target.ScrapeTimeout)
+		if err != nil {
+			return fmt.Errorf("%w: scrape timeout %s: %w", ErrInvalidTarget, target.ScrapeTimeout, err)
 		}
+		if time.Duration(timeout) > scrapeInterval {
+			return fmt.Errorf("%w: scrape timeout %s is larger than the scrape interval %s", ErrInvalidTarget, target.ScrapeTimeout, scrapeInterval)
+		}
+	}
+	p.targetsMu.Lock()
+	defer p.targetsMu.Unlock()
+	targets, err := p.loadTargets()
+	if err != nil {
+		return err
 	}
 
 	// Default to /metrics if no path is provided
@@ -110,6 +229,11 @@ func (p *PrometheusService) AddTarget(target types.MonitoringTarget, labels map[
 	if target.Path != "" {
 		metricsPath = target.Path
 	}
+	// Default to http if no scheme is provided
+	scheme := "http"
+	if target.Scheme != "" {
+		scheme = target.Scheme
+	}
 	job := ScrapeConfig{
 		JobName: jobName,
 		StaticConfigs: []StaticConfig{
@@ -119,17 +243,33 @@ func (p *PrometheusService) AddTarget(target types.MonitoringTarget, labels map[
 			},
 		},
 		MetricsPath: metricsPath,
+		Scheme:      scheme,
+		// Empty and false values are omitted, so Prometheus defaults apply
+		ScrapeTimeout: target.ScrapeTimeout,
+		HonorLabels:   target.HonorLabels,
+	}
+	// Replace the job if it already exists, like when the labels of the
+	// instance changed since it was added
+	replaced := false
+	for i, existing := range targets {
+		if existing.JobName == jobName {
+			if reflect.DeepEqual(existing, job) {
+				// There is no need to reload the config if nothing changed
+				return nil
+			}
+			targets[i] = job
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		targets = append(targets, job)
 	}
-	config.ScrapeConfigs = append(config.ScrapeConfigs, job)
 
-	// Marshal the updated config back to YAML
-	newConfig, err := yaml.Marshal(&config)
-	if err != nil {
+	if err = p.saveTargets(targets); err != nil {
 		return err
 	}
-
-	// Write the updated YAML data back to the file
-	if err = p.stack.WriteFile(path, newConfig); err != nil {
+	if err = p.renderTargets(targets); err != nil {
 		return err
 	}
 
@@ -141,24 +281,19 @@ func (p *PrometheusService) AddTarget(target types.MonitoringTarget, labels map[
 	return nil
 }
 
-// RemoveTarget removes a target from the Prometheus config and reloads the Prometheus configuration.
+// RemoveTarget removes a target from the targets file, renders the Prometheus config
+// and reloads the Prometheus configuration.
 func (p *PrometheusService) RemoveTarget(instanceID string) (string, error) {
-	path := filepath.Join("prometheus", "prometheus.yml")
-	// Read the existing config
-	rawConfig, err := p.stack.ReadFile(path)
+	p.targetsMu.Lock()
+	defer p.targetsMu.Unlock()
+	targets, err := p.loadTargets()
 	if err != nil {
 		return "", err
 	}
 
-	// Unmarshal the YAML data into the Config struct
-	var config Config
-	if err = yaml.Unmarshal(rawConfig, &config); err != nil {
-		return "", err
-	}
-
 	// Remove the target from the jobs
 	var network string
-	config.ScrapeConfigs = funk.Filter(config.ScrapeConfigs, func(job ScrapeConfig) bool {
+	targets = funk.Filter(targets, func(job ScrapeConfig) bool {
 		if strings.Contains(job.JobName, instanceID) {
 			network = strings.Split(strings.TrimPrefix(job.JobName, instanceID), "++")[1]
 			return false
@@ -172,14 +307,10 @@ func (p *PrometheusService) RemoveTarget(instanceID string) (string, error) {
 		return "", fmt.Errorf("%w: %s", monitoring.ErrNonexistingTarget, instanceID)
 	}
 
-	// Marshal the updated config back to YAML
-	newConfig, err := yaml.Marshal(&config)
-	if err != nil {
+	if err = p.saveTargets(targets); err != nil {
 		return network, err
 	}
-
-	// Write the updated YAML data back to the file
-	if err = p.stack.WriteFile(path, newConfig); err != nil {
+	if err = p.renderTargets(targets); err != nil {
 		return network, err
 	}
 
@@ -191,13 +322,95 @@ func (p *PrometheusService) RemoveTarget(instanceID string) (string, error) {
 	return network, nil
 }
 
+// Targets returns the instance IDs of the targets added with AddTarget.
+func (p *PrometheusService) Targets() ([]string, error) {
+	jobs, err := p.loadTargets()
+	if err != nil {
+		return nil, err
+	}
+	var instanceIDs []string
+	for _, job := range jobs {
+		// Job names have the format <instance_id>--<container_name>++<network>
+		instanceID, _, ok := strings.Cut(job.JobName, "--")
+		if ok && !funk.ContainsString(instanceIDs, instanceID) {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	return instanceIDs, nil
+}
+
+// AddRules merges the given rule files into a single rule file for the instance
+// and reloads the Prometheus configuration. Group names must be unique across
+// the given files.
+func (p *PrometheusService) AddRules(instanceID string, rules [][]byte) error {
+	var merged RuleFile
+	groupNames := make(map[string]bool)
+	for i, rawRules := range rules {
+		var ruleFile RuleFile
+		if err := yaml.Unmarshal(rawRules, &ruleFile); err != nil {
+			return fmt.Errorf("%w: rule file #%d: %w", ErrInvalidRules, i+1, err)
+		}
+		for _, group := range ruleFile.Groups {
+			if group.Name == "" {
+				return fmt.Errorf("%w: rule file #%d: group without name", ErrInvalidRules, i+1)
+			}
+			if groupNames[group.Name] {
+				return fmt.Errorf("%w: rule file #%d: duplicated group %s", ErrInvalidRules, i+1, group.Name)
+			}
+			for _, rule := range group.Rules {
+				if rule.Expr == "" {
+					return fmt.Errorf("%w: rule file #%d: rule without expr in group %s", ErrInvalidRules, i+1, group.Name)
+				}
+			}
+			groupNames[group.Name] = true
+			merged.Groups = append(merged.Groups, group)
+		}
+	}
+
+	rawMerged, err := yaml.Marshal(&merged)
+	if err != nil {
+		return err
+	}
+	if err = p.stack.WriteFile(filepath.Join(avsRulesDir, instanceID+".yml"), rawMerged); err != nil {
+		return err
+	}
+
+	return p.reloadConfig()
+}
+
+// RemoveRules removes the rule file of the instance and reloads the Prometheus
+// configuration. It does nothing if the instance has no rule file.
+func (p *PrometheusService) RemoveRules(instanceID string) error {
+	err := p.stack.RemoveFile(filepath.Join(avsRulesDir, instanceID+".yml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.reloadConfig()
+}
+
 // DotEnv returns the dotenv variables and default values for the Prometheus service.
 func (p *PrometheusService) DotEnv() map[string]string {
 	return dotEnv
 }
 
+// RequiredOptions returns the dotenv variables required by Init and Setup.
+func (p *PrometheusService) RequiredOptions() []string {
+	return []string{"PROM_PORT", "NODE_EXPORTER_PORT"}
+}
+
 // Setup sets up the Prometheus service configuration files with the given dotenv values.
-func (p *PrometheusService) Setup(options map[string]string) error {
+// The node exporter is always scraped. The other exporters of the stack, like cAdvisor,
+// are added with AddTarget.
+// The instance containers are discovered through the docker socket if PROM_DOCKER_SD
+// is true.
+// The scrape jobs of the targets are rendered from the targets file, so Setup can be run
+// again without losing them. The files are not written if the context is done
+// before.
+func (p *PrometheusService) Setup(ctx context.Context, options map[string]string) error {
 	// Validate options
 	nodeExporterPort, ok := options["NODE_EXPORTER_PORT"]
 	if !ok {
@@ -205,6 +418,18 @@ func (p *PrometheusService) Setup(options map[string]string) error {
 	} else if nodeExporterPort == "" {
 		return fmt.Errorf("%w: %s can't be empty", ErrInvalidOptions, "NODE_EXPORTER_PORT")
 	}
+	remoteWrite, err := remoteWriteConfig(options)
+	if err != nil {
+		return err
+	}
+	labels, err := externalLabels(options)
+	if err != nil {
+		return err
+	}
+	dockerSD, err := dockerSDEnabled(options)
+	if err != nil {
+		return err
+	}
 
 	// Read config from the embedded FS
 	rawConfig, err := config.ReadFile("config/prometheus.yml")
@@ -218,18 +443,21 @@ func (p *PrometheusService) Setup(options map[string]string) error {
 		return err
 	}
 
-	// Add node exporter target
-	endpoint := fmt.Sprintf("%s:%s", monitoring.NodeExporterContainerName, options["NODE_EXPORTER_PORT"])
-	config.ScrapeConfigs = []ScrapeConfig{
-		{
-			JobName: endpoint,
-			StaticConfigs: []StaticConfig{
-				{
-					Targets: []string{endpoint},
-				},
-			},
-		},
+	// Add the targets of the exporters of the stack
+	targets, err := p.loadTargets()
+	if err != nil {
+		return err
 	}
+	config.ScrapeConfigs = []ScrapeConfig{stackJob(monitoring.NodeExporterContainerName, nodeExporterPort)}
+	if dockerSD {
+		config.ScrapeConfigs = append(config.ScrapeConfigs, dockerSDJob(targetNetworks(targets)))
+	}
+	config.ScrapeConfigs = append(config.ScrapeConfigs, targets...)
+
+	if remoteWrite != nil {
+		config.RemoteWrite = []RemoteWriteConfig{*remoteWrite}
+	}
+	config.Global.ExternalLabels = labels
 
 	// Marshal the updated config back to YAML
 	newConfig, err := yaml.Marshal(&config)
@@ -237,19 +465,353 @@ func (p *PrometheusService) Setup(options map[string]string) error {
 		return err
 	}
 
-	// Create config directory
-	if err = p.stack.CreateDir("prometheus"); err != nil {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	// Create config and rules directories
+	if err = p.stack.CreateDir(avsRulesDir); err != nil {
 		return err
 	}
 
 	// Write the updated YAML data to datadir
-	if err = p.stack.WriteFile("prometheus/prometheus.yml", newConfig); err != nil {
+	if err = p.stack.WriteFile(configFile, newConfig); err != nil {
 		return err
 	}
+	// Write the targets file, in case the targets were read from a
+	// configuration rendered before the targets file existed
+	if err = p.saveTargets(targets); err != nil {
+		return err
+	}
+
+	// Copy the default alerting rules
+	ruleFiles, err := fs.ReadDir(rules, "rules")
+	if err != nil {
+		return err
+	}
+	for _, ruleFile := range ruleFiles {
+		rawRules, err := rules.ReadFile("rules/" + ruleFile.Name())
+		if err != nil {
+			return err
+		}
+		if err = p.stack.WriteFile(filepath.Join(rulesDir, ruleFile.Name()), rawRules); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// ValidateProvisioning checks that the Prometheus configuration written by
+// Setup is valid and that every scrape job has a name and targets.
+func (p *PrometheusService) ValidateProvisioning() error {
+	rawConfig, err := p.stack.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	var config Config
+	if err = yaml.Unmarshal(rawConfig, &config); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	for i, job := range config.ScrapeConfigs {
+		if job.JobName == "" {
+			return fmt.Errorf("%w: scrape config #%d has no job name", ErrInvalidConfig, i+1)
+		}
+		if len(job.StaticConfigs) == 0 && len(job.DockerSDConfigs) == 0 {
+			return fmt.Errorf("%w: job %s has no targets", ErrInvalidConfig, job.JobName)
+		}
+	}
+	return nil
+}
+
+// ValidateEmbeddedAssets checks that the embedded Prometheus config and
+// alerting rules are valid YAML.
+func (p *PrometheusService) ValidateEmbeddedAssets() error {
+	return errors.Join(monitoring.ValidateAssets(config), monitoring.ValidateAssets(rules))
+}
+
+// loadTargets returns the scrape configs of the targets added with AddTarget from
+// the targets file. Without a targets file, the targets are read from the current
+// Prometheus configuration, which is how stacks set up before the targets file
+// existed kept them.
+func (p *PrometheusService) loadTargets() ([]ScrapeConfig, error) {
+	path := targetsFile
+	exists, err := p.stack.Exists(path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return p.addedTargets()
+	}
+	rawTargets, err := p.stack.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []ScrapeConfig
+	if err = json.Unmarshal(rawTargets, &targets); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTargets, path, err)
+	}
+	return targets, nil
+}
+
+// saveTargets writes the given scrape configs to the targets file.
+func (p *PrometheusService) saveTargets(targets []ScrapeConfig) error {
+	if targets == nil {
+		targets = []ScrapeConfig{}
+	}
+	rawTargets, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return p.stack.WriteFile(targetsFile, rawTargets)
+}
+
+// renderTargets replaces the scrape jobs of the targets in the current Prometheus
+// configuration with the given ones. The rest of the configuration, written by
+// Setup, is kept, but the docker service discovery job follows the networks of
+// the given targets.
+func (p *PrometheusService) renderTargets(targets []ScrapeConfig) error {
+	path := configFile
+	rawConfig, err := p.stack.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config Config
+	if err = yaml.Unmarshal(rawConfig, &config); err != nil {
+		return err
+	}
+	config.ScrapeConfigs = funk.Filter(config.ScrapeConfigs, isStackJob).([]ScrapeConfig)
+	for i, job := range config.ScrapeConfigs {
+		if job.JobName == dockerSDJobName {
+			config.ScrapeConfigs[i] = dockerSDJob(targetNetworks(targets))
+		}
+	}
+	config.ScrapeConfigs = append(config.ScrapeConfigs, targets...)
+	newConfig, err := yaml.Marshal(&config)
+	if err != nil {
+		return err
+	}
+	return p.stack.WriteFile(path, newConfig)
+}
+
+// stackJob returns the scrape job of an exporter of the monitoring stack, like
+// the node exporter, with the given container name and port.
+func stackJob(containerName, port string) ScrapeConfig {
+	endpoint := fmt.Sprintf("%s:%s", containerName, port)
+	return ScrapeConfig{
+		JobName: endpoint,
+		StaticConfigs: []StaticConfig{
+			{
+				Targets: []string{endpoint},
+			},
+		},
+	}
+}
+
+// dockerSDJob returns the scrape job of the containers discovered through the
+// docker socket. Only the containers of the instances, with the
+// data.InstanceContainerLabel label, are discovered, and of them the ones with
+// the data.MetricsPortContainerLabel label are scraped, in that port and in
+// the data.MetricsPathContainerLabel path. The monitoring targets of the
+// instances are skipped, as they are scraped by their own jobs. The instance
+// ID of the label is added to the metrics.
+//
+// Prometheus can only reach the containers in the given networks, the ones it
+// is connected to, so the containers in other networks are skipped. The
+// containers are scraped by name, so a container in several of the networks
+// is scraped once.
+func dockerSDJob(networks []string) ScrapeConfig {
+	portLabel := dockerLabelName(data.MetricsPortContainerLabel)
+	quoted := make([]string, len(networks))
+	for i, network := range networks {
+		quoted[i] = regexp.QuoteMeta(network)
+	}
+	return ScrapeConfig{
+		JobName: dockerSDJobName,
+		DockerSDConfigs: []DockerSDConfig{{
+			Host:    dockerSocket,
+			Filters: []DockerSDFilter{{Name: "label", Values: []string{data.InstanceContainerLabel}}},
+		}},
+		RelabelConfigs: []RelabelConfig{
+			{
+				SourceLabels: []string{portLabel},
+				Regex:        ".+",
+				Action:       "keep",
+			},
+			{
+				SourceLabels: []string{dockerLabelName(data.MonitoringTargetContainerLabel)},
+				Regex:        "true",
+				Action:       "drop",
+			},
+			{
+				SourceLabels: []string{"__meta_docker_network_name"},
+				Regex:        strings.Join(quoted, "|"),
+				Action:       "keep",
+			},
+			// A target is discovered for each network and port of the
+			// container, so they all get the address of the container name
+			// and the metrics port and Prometheus merges them
+			{
+				SourceLabels: []string{"__meta_docker_container_name", portLabel},
+				Regex:        "/(.+);(.+)",
+				TargetLabel:  "__address__",
+				Replacement:  "$1:$2",
+			},
+			{
+				SourceLabels: []string{dockerLabelName(data.MetricsPathContainerLabel)},
+				Regex:        "(.+)",
+				TargetLabel:  "__metrics_path__",
+			},
+			{
+				SourceLabels: []string{dockerLabelName(data.InstanceContainerLabel)},
+				TargetLabel:  monitoring.InstanceIDLabel,
+			},
+			{
+				SourceLabels: []string{"__meta_docker_container_name"},
+				Regex:        "/(.*)",
+				TargetLabel:  "container",
+			},
+		},
+	}
+}
+
+// targetNetworks returns the docker networks Prometheus is connected to, the
+// network of the monitoring stack and the networks of the given targets added
+// with AddTarget, sorted. The host network is skipped, as its containers can't
+// be reached by name.
+func targetNetworks(targets []ScrapeConfig) []string {
+	networks := []string{monitoring.MonitoringNetwork}
+	for _, job := range targets {
+		// Job names have the format <instance_id>--<container_name>++<network>
+		_, network, ok := strings.Cut(job.JobName, "++")
+		if ok && network != "" && network != docker.NetworkHost && !slices.Contains(networks, network) {
+			networks = append(networks, network)
+		}
+	}
+	slices.Sort(networks)
+	return networks
+}
+
+// dockerLabelName returns the name of the meta label of the docker service
+// discovery with the value of the given docker label.
+func dockerLabelName(label string) string {
+	return "__meta_docker_container_label_" + labelNameReplacer.Replace(label)
+}
+
+// labelNameReplacer replaces the characters of docker labels that are not valid
+// in Prometheus label names, like the docker service discovery does.
+var labelNameReplacer = strings.NewReplacer(".", "_", "-", "_", "/", "_")
+
+// isStackJob returns true if the given scrape job is a job of the monitoring
+// stack generated by Setup, like the node exporter job.
+func isStackJob(job ScrapeConfig) bool {
+	return strings.HasPrefix(job.JobName, monitoring.NodeExporterContainerName+":") ||
+		job.JobName == dockerSDJobName
+}
+
+// addedTargets returns the scrape configs of the targets added with AddTarget to
+// the current Prometheus configuration. It returns nil if there is no configuration yet.
+func (p *PrometheusService) addedTargets() ([]ScrapeConfig, error) {
+	path := configFile
+	exists, err := p.stack.Exists(path)
+	if err != nil || !exists {
+		return nil, err
+	}
+	rawConfig, err := p.stack.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var current Config
+	if err = yaml.Unmarshal(rawConfig, &current); err != nil {
+		return nil, err
+	}
+	var targets []ScrapeConfig
+	for _, job := range current.ScrapeConfigs {
+		// The jobs of the exporters of the stack are generated by Setup
+		if isStackJob(job) {
+			continue
+		}
+		targets = append(targets, job)
+	}
+	return targets, nil
+}
+
+// remoteWriteConfig builds the remote write configuration from the given dotenv
+// values. It returns nil if PROM_REMOTE_WRITE_URL is not set. If
+// PROM_REMOTE_WRITE_PASSWORD_FILE is set, Prometheus reads the password from
+// the file mounted in its container instead.
+func remoteWriteConfig(options map[string]string) (*RemoteWriteConfig, error) {
+	remoteWriteURL := options["PROM_REMOTE_WRITE_URL"]
+	if remoteWriteURL == "" {
+		return nil, nil
+	}
+	parsedURL, err := url.ParseRequestURI(remoteWriteURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, fmt.Errorf("%w: %s is not a valid HTTP(S) URL", ErrInvalidOptions, "PROM_REMOTE_WRITE_URL")
+	}
+	password, err := monitoring.ResolveSecret(options, "PROM_REMOTE_WRITE_PASSWORD")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
+	remoteWrite := RemoteWriteConfig{URL: remoteWriteURL}
+	if username := options["PROM_REMOTE_WRITE_USERNAME"]; username != "" {
+		remoteWrite.BasicAuth = &BasicAuth{
+			Username:     username,
+			Password:     password.Value,
+			PasswordFile: password.File,
+		}
+	} else if password != (monitoring.Secret{}) {
+		return nil, fmt.Errorf("%w: %s is set without %s", ErrInvalidOptions, "PROM_REMOTE_WRITE_PASSWORD", "PROM_REMOTE_WRITE_USERNAME")
+	}
+	return &remoteWrite, nil
+}
+
+// dockerSDEnabled returns true if the PROM_DOCKER_SD option enables the
+// discovery of the instance containers through the docker socket. It is
+// disabled if the option is not set.
+func dockerSDEnabled(options map[string]string) (bool, error) {
+	rawEnabled := options["PROM_DOCKER_SD"]
+	if rawEnabled == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s is not a valid boolean", ErrInvalidOptions, "PROM_DOCKER_SD")
+	}
+	return enabled, nil
+}
+
+// labelNameRegex matches the valid Prometheus label names.
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// externalLabels returns the external labels of the PROM_EXTERNAL_LABELS
+// option, a comma-separated list of key=value pairs like host=node-1,region=eu.
+// Prometheus adds them to the series and alerts it sends to other systems, so
+// the Prometheus of each host can be told apart when federating them. It
+// returns nil if the option is empty.
+func externalLabels(options map[string]string) (map[string]string, error) {
+	rawLabels := strings.TrimSpace(options["PROM_EXTERNAL_LABELS"])
+	if rawLabels == "" {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(rawLabels, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %s must be a comma-separated list of key=value pairs, got %q", ErrInvalidOptions, "PROM_EXTERNAL_LABELS", pair)
+		}
+		if !labelNameRegex.MatchString(key) || strings.HasPrefix(key, "__") {
+			return nil, fmt.Errorf("%w: %s has an invalid label name %q", ErrInvalidOptions, "PROM_EXTERNAL_LABELS", key)
+		}
+		if _, ok := labels[key]; ok {
+			return nil, fmt.Errorf("%w: %s has the label %s more than once", ErrInvalidOptions, "PROM_EXTERNAL_LABELS", key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
 // SetContainerIP sets the container IP for the Prometheus service.
 func (p *PrometheusService) SetContainerIP(ip net.IP) {
 	p.containerIP = ip
@@ -263,6 +825,53 @@ func (p *PrometheusService) Endpoint() string {
 	return fmt.Sprintf("http://%s:%d", p.containerIP, p.port)
 }
 
+// apiClient is the HTTP client of the requests to the Prometheus API. It has
+// a timeout, so a Prometheus that doesn't answer, like a paused container,
+// doesn't block the caller forever.
+var apiClient = &http.Client{Timeout: 10 * time.Second}
+
+// targetsResponse is the response of the Prometheus /api/v1/targets endpoint.
+type targetsResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ActiveTargets []struct {
+			Health string `json:"health"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// TargetsHealth implements monitoring.TargetsHealthReporter. It queries the
+// active targets of the Prometheus API. Targets that were not scraped yet are
+// active but neither up nor down.
+func (p *PrometheusService) TargetsHealth() (monitoring.TargetsHealth, error) {
+	resp, err := apiClient.Get(fmt.Sprintf("http://%s:%d/api/v1/targets?state=active", p.containerIP, p.port))
+	if err != nil {
+		return monitoring.TargetsHealth{}, fmt.Errorf("%w: %w", ErrQueryingTargets, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return monitoring.TargetsHealth{}, fmt.Errorf("%w: %s", ErrQueryingTargets, resp.Status)
+	}
+
+	var targets targetsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return monitoring.TargetsHealth{}, fmt.Errorf("%w: %w", ErrQueryingTargets, err)
+	}
+	if targets.Status != "success" {
+		return monitoring.TargetsHealth{}, fmt.Errorf("%w: status %s", ErrQueryingTargets, targets.Status)
+	}
+	health := monitoring.TargetsHealth{Active: len(targets.Data.ActiveTargets)}
+	for _, target := range targets.Data.ActiveTargets {
+		switch target.Health {
+		case "up":
+			health.Up++
+		case "down":
+			health.Down++
+		}
+	}
+	return health, nil
+}
+
 // reloadConfig reloads the Prometheus config by making a POST request to the /-/reload endpoint
 func (p *PrometheusService) reloadConfig() error {
 	// Adding exponential retry
@@ -270,7 +879,7 @@ func (p *PrometheusService) reloadConfig() error {
 	b.MaxElapsedTime = time.Minute
 
 	err := backoff.Retry(func() (err error) {
-		resp, err := http.Post(fmt.Sprintf("http://%s:%d/-/reload", p.containerIP, p.port), "", nil)
+		resp, err := apiClient.Post(fmt.Sprintf("http://%s:%d/-/reload", p.containerIP, p.port), "", nil)
 		if err != nil {
 			// TODO: Use fields to log the error
 			log.Debug("Retrying request...")
@@ -1,13 +1,19 @@
 package prometheus
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/eigenlayer/internal/data"
 	"github.com/NethermindEth/eigenlayer/internal/locker/mocks"
@@ -143,11 +149,14 @@ func TestSetup(t *testing.T) {
 			locker.EXPECT().Locked().Return(true),
 			locker.EXPECT().Unlock().Return(nil),
 		)
-		gomock.InOrder(
-			locker.EXPECT().Lock().Return(nil),
-			locker.EXPECT().Locked().Return(true),
-			locker.EXPECT().Unlock().Return(nil),
-		)
+		// Check for an existing targets.json and prometheus.yml, write them and the default rules file
+		for i := 0; i < 5; i++ {
+			gomock.InOrder(
+				locker.EXPECT().Lock().Return(nil),
+				locker.EXPECT().Locked().Return(true),
+				locker.EXPECT().Unlock().Return(nil),
+			)
+		}
 		return locker
 	}
 	onlyNewLocker := func(t *testing.T) *mocks.MockLocker {
@@ -160,12 +169,19 @@ func TestSetup(t *testing.T) {
 		return locker
 	}
 
+	// Secret file mounted in the container
+	passwordFile := filepath.Join(t.TempDir(), "remote-write-password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-secret\n"), 0o600))
+
 	tests := []struct {
-		name    string
-		mocker  func(t *testing.T) *mocks.MockLocker
-		options map[string]string
-		targets []string
-		wantErr bool
+		name           string
+		mocker         func(t *testing.T) *mocks.MockLocker
+		options        map[string]string
+		targets        []string
+		remoteWrite    []RemoteWriteConfig
+		externalLabels map[string]string
+		dockerSD       bool
+		wantErr        bool
 	}{
 		{
 			name:   "ok",
@@ -178,6 +194,197 @@ func TestSetup(t *testing.T) {
 				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
 			},
 		},
+		{
+			name:   "ok, cadvisor enabled",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+				"CADVISOR_ENABLED":   "true",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+		},
+		{
+			name:   "ok, empty remote write url",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":             "9999",
+				"NODE_EXPORTER_PORT":    "9100",
+				"PROM_REMOTE_WRITE_URL": "",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+		},
+		{
+			name:   "ok, remote write",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":             "9999",
+				"NODE_EXPORTER_PORT":    "9100",
+				"PROM_REMOTE_WRITE_URL": "https://metrics.example.com/api/v1/write",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+			remoteWrite: []RemoteWriteConfig{
+				{URL: "https://metrics.example.com/api/v1/write"},
+			},
+		},
+		{
+			name:   "ok, remote write with basic auth",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9999",
+				"NODE_EXPORTER_PORT":         "9100",
+				"PROM_REMOTE_WRITE_URL":      "https://metrics.example.com/api/v1/write",
+				"PROM_REMOTE_WRITE_USERNAME": "user",
+				"PROM_REMOTE_WRITE_PASSWORD": "secret",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+			remoteWrite: []RemoteWriteConfig{
+				{
+					URL: "https://metrics.example.com/api/v1/write",
+					BasicAuth: &BasicAuth{
+						Username: "user",
+						Password: "secret",
+					},
+				},
+			},
+		},
+		{
+			name:   "ok, remote write with password file",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":                       "9999",
+				"NODE_EXPORTER_PORT":              "9100",
+				"PROM_REMOTE_WRITE_URL":           "https://metrics.example.com/api/v1/write",
+				"PROM_REMOTE_WRITE_USERNAME":      "user",
+				"PROM_REMOTE_WRITE_PASSWORD":      "secret",
+				"PROM_REMOTE_WRITE_PASSWORD_FILE": passwordFile,
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+			remoteWrite: []RemoteWriteConfig{
+				{
+					URL: "https://metrics.example.com/api/v1/write",
+					// The password is read from the mounted file, not written to the config
+					BasicAuth: &BasicAuth{
+						Username:     "user",
+						PasswordFile: "/run/secrets/prom_remote_write_password",
+					},
+				},
+			},
+		},
+		{
+			name:   "ok, external labels",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":            "9999",
+				"NODE_EXPORTER_PORT":   "9100",
+				"PROM_EXTERNAL_LABELS": "host=node-1, region=eu-west",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+			externalLabels: map[string]string{
+				"host":   "node-1",
+				"region": "eu-west",
+			},
+		},
+		{
+			name:   "ok, docker service discovery",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+				"PROM_DOCKER_SD":     "true",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+			dockerSD: true,
+		},
+		{
+			name:   "ok, docker service discovery disabled",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+				"PROM_DOCKER_SD":     "false",
+			},
+			targets: []string{
+				fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+			},
+		},
+		{
+			name:   "invalid docker service discovery flag",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+				"PROM_DOCKER_SD":     "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "external labels without value",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":            "9999",
+				"NODE_EXPORTER_PORT":   "9100",
+				"PROM_EXTERNAL_LABELS": "host=node-1,region",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "missing remote write password file",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                       "9999",
+				"NODE_EXPORTER_PORT":              "9100",
+				"PROM_REMOTE_WRITE_URL":           "https://metrics.example.com/api/v1/write",
+				"PROM_REMOTE_WRITE_USERNAME":      "user",
+				"PROM_REMOTE_WRITE_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid remote write url",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":             "9999",
+				"NODE_EXPORTER_PORT":    "9100",
+				"PROM_REMOTE_WRITE_URL": "metrics.example.com",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "invalid remote write url scheme",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":             "9999",
+				"NODE_EXPORTER_PORT":    "9100",
+				"PROM_REMOTE_WRITE_URL": "ftp://metrics.example.com",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "remote write password without username",
+			mocker: onlyNewLocker,
+			options: map[string]string{
+				"PROM_PORT":                  "9999",
+				"NODE_EXPORTER_PORT":         "9100",
+				"PROM_REMOTE_WRITE_URL":      "https://metrics.example.com/api/v1/write",
+				"PROM_REMOTE_WRITE_PASSWORD": "secret",
+			},
+			wantErr: true,
+		},
 		{
 			name:   "missing node exporter port",
 			mocker: onlyNewLocker,
@@ -258,7 +465,7 @@ func TestSetup(t *testing.T) {
 			require.NoError(t, err)
 
 			// Setup the Prometheus service
-			err = prometheus.Setup(tt.options)
+			err = prometheus.Setup(context.Background(), tt.options)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -274,11 +481,81 @@ func TestSetup(t *testing.T) {
 				err = yaml.Unmarshal(promYml, &prom)
 				assert.NoError(t, err)
 
+				// Check the job of the discovered instance containers is only
+				// present when enabled, after the jobs of the stack
+				assert.Equal(t, tt.dockerSD, strings.Contains(string(promYml), "docker_sd_configs:"))
+				if tt.dockerSD {
+					require.Len(t, prom.ScrapeConfigs, len(tt.targets)+1)
+					job := prom.ScrapeConfigs[len(tt.targets)]
+					assert.Equal(t, "egn-containers", job.JobName)
+					assert.Empty(t, job.StaticConfigs)
+					assert.Equal(t, []DockerSDConfig{{
+						Host:    "unix:///var/run/docker.sock",
+						Filters: []DockerSDFilter{{Name: "label", Values: []string{"egn.instance_id"}}},
+					}}, job.DockerSDConfigs)
+					assert.Equal(t, []RelabelConfig{
+						{
+							SourceLabels: []string{"__meta_docker_container_label_egn_metrics_port"},
+							Regex:        ".+",
+							Action:       "keep",
+						},
+						{
+							SourceLabels: []string{"__meta_docker_container_label_egn_monitoring_target"},
+							Regex:        "true",
+							Action:       "drop",
+						},
+						{
+							SourceLabels: []string{"__meta_docker_network_name"},
+							Regex:        "egn-monitor-network",
+							Action:       "keep",
+						},
+						{
+							SourceLabels: []string{"__meta_docker_container_name", "__meta_docker_container_label_egn_metrics_port"},
+							Regex:        "/(.+);(.+)",
+							TargetLabel:  "__address__",
+							Replacement:  "$1:$2",
+						},
+						{
+							SourceLabels: []string{"__meta_docker_container_label_egn_metrics_path"},
+							Regex:        "(.+)",
+							TargetLabel:  "__metrics_path__",
+						},
+						{
+							SourceLabels: []string{"__meta_docker_container_label_egn_instance_id"},
+							TargetLabel:  monitoring.InstanceIDLabel,
+						},
+						{
+							SourceLabels: []string{"__meta_docker_container_name"},
+							Regex:        "/(.*)",
+							TargetLabel:  "container",
+						},
+					}, job.RelabelConfigs)
+					prom.ScrapeConfigs = prom.ScrapeConfigs[:len(tt.targets)]
+				}
+
 				// Check the Prometheus initial targets
+				require.Len(t, prom.ScrapeConfigs, len(tt.targets))
 				for i := 0; i < len(tt.targets); i++ {
 					assert.Equal(t, tt.targets[i], prom.ScrapeConfigs[i].JobName)
 					assert.Equal(t, tt.targets[i], prom.ScrapeConfigs[i].StaticConfigs[0].Targets[0])
 				}
+
+				// Check the remote write block is only present when configured
+				assert.Equal(t, tt.remoteWrite, prom.RemoteWrite)
+				assert.Equal(t, tt.remoteWrite != nil, strings.Contains(string(promYml), "remote_write:"))
+				assert.Equal(t, tt.externalLabels, prom.Global.ExternalLabels)
+				assert.Equal(t, tt.externalLabels != nil, strings.Contains(string(promYml), "external_labels:"))
+
+				// Check the alerting rules are copied and referenced
+				assert.Equal(t, []string{"/etc/prometheus/rules/*.yml", "/etc/prometheus/rules/avs/*.yml"}, prom.RuleFiles)
+				wantRules, err := rules.ReadFile("rules/node.yml")
+				require.NoError(t, err)
+				gotRules, err := afero.ReadFile(afs, "/monitoring/prometheus/rules/node.yml")
+				require.NoError(t, err)
+				assert.Equal(t, wantRules, gotRules)
+				ok, err = afero.DirExists(afs, "/monitoring/prometheus/rules/avs")
+				assert.NoError(t, err)
+				assert.True(t, ok)
 			}
 		})
 	}
@@ -297,7 +574,9 @@ func TestAddTarget(t *testing.T) {
 			locker.EXPECT().Locked().Return(true),
 			locker.EXPECT().Unlock().Return(nil),
 		)
-		for i := 0; i < times*2+1; i++ {
+		// Setup checks for an existing targets.json and prometheus.yml, writes them and the
+		// default rules file. Each target reads and writes both files.
+		for i := 0; i < times*5+5; i++ {
 			gomock.InOrder(
 				locker.EXPECT().Lock().Return(nil),
 				locker.EXPECT().Locked().Return(true),
@@ -324,6 +603,7 @@ func TestAddTarget(t *testing.T) {
 		options     map[string]string
 		toAdd       []target
 		targets     []ScrapeConfig
+		wantYml     []string
 		badEndpoint bool
 		wantErr     bool
 	}{
@@ -376,6 +656,7 @@ func TestAddTarget(t *testing.T) {
 						},
 					},
 					MetricsPath: "/metrics",
+					Scheme:      "http",
 				},
 			},
 		},
@@ -429,8 +710,125 @@ func TestAddTarget(t *testing.T) {
 						},
 					},
 					MetricsPath: "/custom-path",
+					Scheme:      "http",
+				},
+			},
+		},
+		{
+			name:   "ok, 1 target, custom path and scheme",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+			},
+			toAdd: []target{
+				{
+					instanceID:  "test-avs",
+					commitHash:  "a0c93c0ce7af88bd6387d2a2522b6d7390e50d09",
+					avsName:     "secure-avs",
+					avsVersion:  "v0.2.0",
+					specVersion: "v1.1.0",
+					network:     "testnet",
+					target: types.MonitoringTarget{
+						Host:   "localhost",
+						Port:   8000,
+						Path:   "/custom/metrics",
+						Scheme: "https",
+					},
+				},
+			},
+			targets: []ScrapeConfig{
+				{
+					JobName: fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+					StaticConfigs: []StaticConfig{
+						{
+							Targets: []string{
+								fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+							},
+						},
+					},
+				},
+				{
+					JobName: "test-avs--0++testnet",
+					StaticConfigs: []StaticConfig{
+						{
+							Targets: []string{
+								"localhost:8000",
+							},
+							Labels: map[string]string{
+								monitoring.InstanceIDLabel:  "test-avs",
+								monitoring.CommitHashLabel:  "a0c93c0ce7af88bd6387d2a2522b6d7390e50d09",
+								monitoring.AVSNameLabel:     "secure-avs",
+								monitoring.AVSVersionLabel:  "v0.2.0",
+								monitoring.SpecVersionLabel: "v1.1.0",
+							},
+						},
+					},
+					MetricsPath: "/custom/metrics",
+					Scheme:      "https",
+				},
+			},
+		},
+		{
+			name:   "ok, 1 target, scrape timeout and honor labels",
+			mocker: okLocker,
+			options: map[string]string{
+				"PROM_PORT":          "9999",
+				"NODE_EXPORTER_PORT": "9100",
+			},
+			toAdd: []target{
+				{
+					instanceID:  "test-avs",
+					commitHash:  "a0c93c0ce7af88bd6387d2a2522b6d7390e50d09",
+					avsName:     "slow-avs",
+					avsVersion:  "v0.3.0",
+					specVersion: "v1.1.0",
+					network:     "testnet",
+					target: types.MonitoringTarget{
+						Host:          "localhost",
+						Port:          8000,
+						ScrapeTimeout: "10s",
+						HonorLabels:   true,
+					},
+				},
+			},
+			targets: []ScrapeConfig{
+				{
+					JobName: fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+					StaticConfigs: []StaticConfig{
+						{
+							Targets: []string{
+								fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName),
+							},
+						},
+					},
+				},
+				{
+					JobName: "test-avs--0++testnet",
+					StaticConfigs: []StaticConfig{
+						{
+							Targets: []string{
+								"localhost:8000",
+							},
+							Labels: map[string]string{
+								monitoring.InstanceIDLabel:  "test-avs",
+								monitoring.CommitHashLabel:  "a0c93c0ce7af88bd6387d2a2522b6d7390e50d09",
+								monitoring.AVSNameLabel:     "slow-avs",
+								monitoring.AVSVersionLabel:  "v0.3.0",
+								monitoring.SpecVersionLabel: "v1.1.0",
+							},
+						},
+					},
+					MetricsPath:   "/metrics",
+					Scheme:        "http",
+					ScrapeTimeout: "10s",
+					HonorLabels:   true,
 				},
 			},
+			wantYml: []string{
+				"scrape_timeout: 10s",
+				"honor_labels: true",
+			},
 		},
 		{
 			name:   "ok, 2 targets",
@@ -494,6 +892,7 @@ func TestAddTarget(t *testing.T) {
 						},
 					},
 					MetricsPath: "/metrics",
+					Scheme:      "http",
 				},
 				{
 					JobName: "test-avs2--1++testnet2",
@@ -512,6 +911,7 @@ func TestAddTarget(t *testing.T) {
 						},
 					},
 					MetricsPath: "/custom-path2",
+					Scheme:      "http",
 				},
 			},
 		},
@@ -564,6 +964,7 @@ func TestAddTarget(t *testing.T) {
 						},
 					},
 					MetricsPath: "/metrics",
+					Scheme:      "http",
 				},
 			},
 			badEndpoint: true,
@@ -584,6 +985,18 @@ func TestAddTarget(t *testing.T) {
 					locker.EXPECT().Lock().Return(nil),
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
 				)
 				locker.EXPECT().Lock().Return(fmt.Errorf("error"))
 				return locker
@@ -624,6 +1037,18 @@ func TestAddTarget(t *testing.T) {
 					locker.EXPECT().Lock().Return(nil),
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
 				)
 				gomock.InOrder(
 					locker.EXPECT().Lock().Return(nil),
@@ -673,7 +1098,7 @@ func TestAddTarget(t *testing.T) {
 			require.NoError(t, err)
 
 			// Setup the Prometheus service
-			err = prometheus.Setup(tt.options)
+			err = prometheus.Setup(context.Background(), tt.options)
 			require.NoError(t, err)
 
 			if !tt.badEndpoint {
@@ -731,10 +1156,381 @@ func TestAddTarget(t *testing.T) {
 				}
 				assert.EqualValues(t, target, prom.ScrapeConfigs[i], target)
 			}
+			for _, line := range tt.wantYml {
+				assert.Contains(t, string(promYml), line)
+			}
+			if tt.wantYml == nil {
+				// Prometheus defaults are kept when the options are not set
+				assert.NotContains(t, string(promYml), "scrape_timeout")
+				assert.NotContains(t, string(promYml), "honor_labels")
+			}
 		})
 	}
 }
 
+func TestSetupKeepsAddedTargets(t *testing.T) {
+	// Create an in-memory filesystem
+	afs := afero.NewMemMapFs()
+
+	// Create a mock locker
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	options := map[string]string{
+		"PROM_PORT":          "9999",
+		"NODE_EXPORTER_PORT": "9100",
+		"PROM_DOCKER_SD":     "true",
+	}
+	prometheus := NewPrometheus()
+	require.NoError(t, prometheus.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: options,
+	}))
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+
+	// Setup mock http server to reload the config
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/-/reload" && r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	split := strings.Split(server.URL, ":")
+	host, port := split[1][2:], split[2]
+	prometheus.containerIP = net.ParseIP(host)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	prometheus.port = uint16(p)
+
+	labels := map[string]string{
+		monitoring.InstanceIDLabel: "test-avs",
+	}
+	err = prometheus.AddTarget(types.MonitoringTarget{
+		Host: "localhost",
+		Port: 8000,
+		Path: "/custom/metrics",
+	}, labels, "test-avs--0++testnet")
+	require.NoError(t, err)
+
+	// The docker service discovery follows the networks Prometheus joins
+	var prom Config
+	promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(promYml, &prom))
+	require.Len(t, prom.ScrapeConfigs, 3)
+	assert.Equal(t, dockerSDJob([]string{monitoring.MonitoringNetwork, "testnet"}), prom.ScrapeConfigs[1])
+
+	// Run Setup again, with a different node exporter port
+	options["NODE_EXPORTER_PORT"] = "9200"
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+
+	prom = Config{}
+	promYml, err = afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(promYml, &prom))
+
+	nodeExporter := fmt.Sprintf("%s:9200", monitoring.NodeExporterContainerName)
+	assert.Equal(t, []ScrapeConfig{
+		{
+			JobName: nodeExporter,
+			StaticConfigs: []StaticConfig{
+				{
+					Targets: []string{nodeExporter},
+				},
+			},
+		},
+		dockerSDJob([]string{monitoring.MonitoringNetwork, "testnet"}),
+		{
+			JobName: "test-avs--0++testnet",
+			StaticConfigs: []StaticConfig{
+				{
+					Targets: []string{"localhost:8000"},
+					Labels:  labels,
+				},
+			},
+			MetricsPath: "/custom/metrics",
+			Scheme:      "http",
+		},
+	}, prom.ScrapeConfigs)
+}
+
+func TestTargetNetworks(t *testing.T) {
+	ts := []struct {
+		name    string
+		targets []string
+		want    []string
+	}{
+		{
+			name: "no targets",
+			want: []string{monitoring.MonitoringNetwork},
+		},
+		{
+			name:    "sorted and unique",
+			targets: []string{"b-avs--main++zeta", "a-avs--main++alpha", "a-avs--sidecar++alpha"},
+			want:    []string{"alpha", monitoring.MonitoringNetwork, "zeta"},
+		},
+		{
+			name:    "host network",
+			targets: []string{"a-avs--main++host", "node-exporter--node-exporter++" + monitoring.MonitoringNetwork},
+			want:    []string{monitoring.MonitoringNetwork},
+		},
+	}
+	for _, tt := range ts {
+		t.Run(tt.name, func(t *testing.T) {
+			var targets []ScrapeConfig
+			for _, job := range tt.targets {
+				targets = append(targets, ScrapeConfig{JobName: job})
+			}
+			assert.Equal(t, tt.want, targetNetworks(targets))
+		})
+	}
+}
+
+func TestTargetsFile(t *testing.T) {
+	prometheus, afs := setupRulesTest(t)
+	options := map[string]string{
+		"PROM_PORT":          "9999",
+		"NODE_EXPORTER_PORT": "9100",
+	}
+	readJobs := func() []string {
+		var prom Config
+		promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+		require.NoError(t, err)
+		require.NoError(t, yaml.Unmarshal(promYml, &prom))
+		var jobs []string
+		for _, job := range prom.ScrapeConfigs {
+			jobs = append(jobs, job.JobName)
+		}
+		return jobs
+	}
+	nodeExporter := fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName)
+
+	// The targets file is written by Setup
+	rawTargets, err := afero.ReadFile(afs, "/monitoring/prometheus/targets.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, "[]", string(rawTargets))
+
+	labels := map[string]string{monitoring.InstanceIDLabel: "test-avs"}
+	require.NoError(t, prometheus.AddTarget(types.MonitoringTarget{Host: "localhost", Port: 8000}, labels, "test-avs--main++testnet"))
+	require.NoError(t, prometheus.AddTarget(types.MonitoringTarget{Host: "localhost", Port: 8001}, labels, "test-avs--sidecar++testnet"))
+
+	var targets []ScrapeConfig
+	rawTargets, err = afero.ReadFile(afs, "/monitoring/prometheus/targets.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(rawTargets, &targets))
+	require.Len(t, targets, 2)
+	assert.Equal(t, "test-avs--main++testnet", targets[0].JobName)
+	assert.Equal(t, []string{"localhost:8000"}, targets[0].StaticConfigs[0].Targets)
+	assert.Equal(t, labels, targets[0].StaticConfigs[0].Labels)
+	assert.Equal(t, "test-avs--sidecar++testnet", targets[1].JobName)
+
+	// The targets are rendered again when prometheus.yml is lost
+	require.NoError(t, afs.Remove("/monitoring/prometheus/prometheus.yml"))
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+	assert.Equal(t, []string{nodeExporter, "test-avs--main++testnet", "test-avs--sidecar++testnet"}, readJobs())
+
+	// Targets in prometheus.yml but not in the targets file are dropped
+	require.NoError(t, afero.WriteFile(afs, "/monitoring/prometheus/targets.json", []byte(`[{"job_name": "other-avs--main++testnet", "static_configs": [{"targets": ["localhost:9000"]}]}]`), 0o644))
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+	assert.Equal(t, []string{nodeExporter, "other-avs--main++testnet"}, readJobs())
+	instanceIDs, err := prometheus.Targets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-avs"}, instanceIDs)
+
+	network, err := prometheus.RemoveTarget("other-avs")
+	require.NoError(t, err)
+	assert.Equal(t, "testnet", network)
+	assert.Equal(t, []string{nodeExporter}, readJobs())
+	rawTargets, err = afero.ReadFile(afs, "/monitoring/prometheus/targets.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, "[]", string(rawTargets))
+
+	// An invalid targets file is not overwritten
+	require.NoError(t, afero.WriteFile(afs, "/monitoring/prometheus/targets.json", []byte("not json"), 0o644))
+	assert.ErrorIs(t, prometheus.Setup(context.Background(), options), ErrInvalidTargets)
+	_, err = prometheus.Targets()
+	assert.ErrorIs(t, err, ErrInvalidTargets)
+}
+
+func TestAddTargetConcurrent(t *testing.T) {
+	afs := slowFs{afero.NewMemMapFs()}
+	prometheus := setupRulesTestFs(t, afs)
+
+	// The instances started concurrently add their targets at the same time
+	const instances = 10
+	var wg sync.WaitGroup
+	errs := make([]error, instances)
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instanceID := fmt.Sprintf("test-avs-%d", i)
+			errs[i] = prometheus.AddTarget(
+				types.MonitoringTarget{Host: "localhost", Port: uint16(8000 + i)},
+				map[string]string{monitoring.InstanceIDLabel: instanceID},
+				instanceID+"--main++testnet",
+			)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	var targets []ScrapeConfig
+	rawTargets, err := afero.ReadFile(afs, "/monitoring/prometheus/targets.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(rawTargets, &targets))
+	var jobs []string
+	for _, job := range targets {
+		jobs = append(jobs, job.JobName)
+	}
+	var prom Config
+	promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(promYml, &prom))
+	var rendered []string
+	for _, job := range prom.ScrapeConfigs {
+		rendered = append(rendered, job.JobName)
+	}
+	for i := 0; i < instances; i++ {
+		jobName := fmt.Sprintf("test-avs-%d--main++testnet", i)
+		assert.Contains(t, jobs, jobName)
+		assert.Contains(t, rendered, jobName)
+	}
+	assert.Len(t, jobs, instances)
+}
+
+func TestAddTargetUnixSocket(t *testing.T) {
+	tests := []struct {
+		name   string
+		target types.MonitoringTarget
+	}{
+		{
+			name:   "unix host",
+			target: types.MonitoringTarget{Host: "unix:///var/run/avs/metrics.sock", Path: "/metrics"},
+		},
+		{
+			name:   "unix scheme",
+			target: types.MonitoringTarget{Host: "/var/run/avs/metrics.sock", Scheme: types.UnixScheme},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The target is rejected before the stack is read, so no locks are taken
+			prometheus := NewPrometheus()
+			err := prometheus.AddTarget(tt.target, map[string]string{monitoring.InstanceIDLabel: "test-avs"}, "test-avs--0++testnet")
+			assert.ErrorIs(t, err, ErrUnsupported)
+		})
+	}
+}
+
+func TestAddTargetUpdatesLabels(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	prometheus := setupRulesTestFs(t, afs)
+
+	target := types.MonitoringTarget{Host: "localhost", Port: 8000}
+	jobName := "test-avs--main++testnet"
+	require.NoError(t, prometheus.AddTarget(target, map[string]string{monitoring.InstanceIDLabel: "test-avs", "env": "staging"}, jobName))
+	// Adding the target again, like when the instance is restarted after its
+	// labels changed, replaces the labels
+	require.NoError(t, prometheus.AddTarget(target, map[string]string{monitoring.InstanceIDLabel: "test-avs", "team": "infra"}, jobName))
+
+	var prom Config
+	promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(promYml, &prom))
+	var jobs []ScrapeConfig
+	for _, job := range prom.ScrapeConfigs {
+		if job.JobName == jobName {
+			jobs = append(jobs, job)
+		}
+	}
+	require.Len(t, jobs, 1)
+	assert.Equal(t, map[string]string{monitoring.InstanceIDLabel: "test-avs", "team": "infra"}, jobs[0].StaticConfigs[0].Labels)
+}
+
+func TestAddTargetScrapeTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+	}{
+		{
+			name:    "larger than the scrape interval",
+			timeout: "30s",
+		},
+		{
+			name:    "invalid duration",
+			timeout: "fast",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The target is rejected before the stack is read, so no locks are taken
+			prometheus := NewPrometheus()
+			target := types.MonitoringTarget{Host: "localhost", Port: 8000, ScrapeTimeout: tt.timeout}
+			err := prometheus.AddTarget(target, map[string]string{monitoring.InstanceIDLabel: "test-avs"}, "test-avs--0++testnet")
+			assert.ErrorIs(t, err, ErrInvalidTarget)
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	afs := afero.NewMemMapFs()
+
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	prometheus := NewPrometheus()
+	require.NoError(t, prometheus.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: map[string]string{"PROM_PORT": "9999"},
+	}))
+
+	// No configuration yet
+	targets, err := prometheus.Targets()
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	nodeExporter := fmt.Sprintf("%s:9100", monitoring.NodeExporterContainerName)
+	config := Config{
+		ScrapeConfigs: []ScrapeConfig{
+			{JobName: nodeExporter, StaticConfigs: []StaticConfig{{Targets: []string{nodeExporter}}}},
+			{JobName: "mock-avs-default--main++eigenlayer", StaticConfigs: []StaticConfig{{Targets: []string{"main:8080"}}}},
+			{JobName: "mock-avs-default--sidecar++eigenlayer", StaticConfigs: []StaticConfig{{Targets: []string{"sidecar:8080"}}}},
+			{JobName: "mock-avs-second--main++eigenlayer", StaticConfigs: []StaticConfig{{Targets: []string{"main:8080"}}}},
+		},
+	}
+	rawConfig, err := yaml.Marshal(&config)
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(afs, "/monitoring/prometheus/prometheus.yml", rawConfig, 0o644))
+
+	targets, err = prometheus.Targets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mock-avs-default", "mock-avs-second"}, targets)
+}
+
 func TestRemoveTarget(t *testing.T) {
 	okLocker := func(t *testing.T, times int) *mocks.MockLocker {
 		// Create a mock locker
@@ -748,7 +1544,9 @@ func TestRemoveTarget(t *testing.T) {
 			locker.EXPECT().Locked().Return(true),
 			locker.EXPECT().Unlock().Return(nil),
 		)
-		for i := 0; i < times*2+1; i++ {
+		// Setup checks for an existing targets.json and prometheus.yml, writes them and the
+		// default rules file. Each target reads and writes both files.
+		for i := 0; i < times*5+5; i++ {
 			gomock.InOrder(
 				locker.EXPECT().Lock().Return(nil),
 				locker.EXPECT().Locked().Return(true),
@@ -875,7 +1673,7 @@ func TestRemoveTarget(t *testing.T) {
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
 				)
-				for i := 0; i < times+1; i++ {
+				for i := 0; i < times*2+5; i++ {
 					gomock.InOrder(
 						locker.EXPECT().Lock().Return(nil),
 						locker.EXPECT().Locked().Return(true),
@@ -942,6 +1740,18 @@ func TestRemoveTarget(t *testing.T) {
 					locker.EXPECT().Lock().Return(nil),
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
 				)
 				locker.EXPECT().Lock().Return(fmt.Errorf("error"))
 				return locker
@@ -974,6 +1784,18 @@ func TestRemoveTarget(t *testing.T) {
 					locker.EXPECT().Lock().Return(nil),
 					locker.EXPECT().Locked().Return(true),
 					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
+					locker.EXPECT().Lock().Return(nil),
+					locker.EXPECT().Locked().Return(true),
+					locker.EXPECT().Unlock().Return(nil),
 				)
 				gomock.InOrder(
 					locker.EXPECT().Lock().Return(nil),
@@ -1016,7 +1838,7 @@ func TestRemoveTarget(t *testing.T) {
 			require.NoError(t, err)
 
 			// Setup the Prometheus service
-			err = prometheus.Setup(tt.options)
+			err = prometheus.Setup(context.Background(), tt.options)
 			require.NoError(t, err)
 
 			if !tt.badEndpoint {
@@ -1043,13 +1865,8 @@ func TestRemoveTarget(t *testing.T) {
 				prometheus.port = uint16(p)
 			}
 
-			// Read the prom.yml file
-			var prom Config
-			promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
-			assert.NoError(t, err)
-			err = yaml.Unmarshal(promYml, &prom)
-			assert.NoError(t, err)
 			// Add the targets
+			var targets []ScrapeConfig
 			for i, target := range tt.toAdd {
 				job := ScrapeConfig{
 					JobName: fmt.Sprintf("%s--%d++%s", target.instanceID, i, target.network),
@@ -1060,12 +1877,12 @@ func TestRemoveTarget(t *testing.T) {
 						},
 					},
 				}
-				prom.ScrapeConfigs = append(prom.ScrapeConfigs, job)
+				targets = append(targets, job)
 			}
-			// Save the prom.yml file
-			promYml, err = yaml.Marshal(prom)
+			// Save the targets file
+			rawTargets, err := json.Marshal(targets)
 			assert.NoError(t, err)
-			err = afero.WriteFile(afs, "/monitoring/prometheus/prometheus.yml", promYml, 0o644)
+			err = afero.WriteFile(afs, "/monitoring/prometheus/targets.json", rawTargets, 0o644)
 			assert.NoError(t, err)
 
 			// Remove the targets
@@ -1080,7 +1897,8 @@ func TestRemoveTarget(t *testing.T) {
 			}
 
 			// Read the prom.yml file
-			promYml, err = afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
+			var prom Config
+			promYml, err := afero.ReadFile(afs, "/monitoring/prometheus/prometheus.yml")
 			assert.NoError(t, err)
 			err = yaml.Unmarshal(promYml, &prom)
 			assert.NoError(t, err)
@@ -1091,6 +1909,175 @@ func TestRemoveTarget(t *testing.T) {
 	}
 }
 
+// setupRulesTest returns a Prometheus service already set up on an in-memory
+// filesystem and pointing to a mock server that accepts config reloads.
+func setupRulesTest(t *testing.T) (*PrometheusService, afero.Fs) {
+	t.Helper()
+	afs := afero.NewMemMapFs()
+	return setupRulesTestFs(t, afs), afs
+}
+
+// setupRulesTestFs is setupRulesTest with the stack in the given file system.
+func setupRulesTestFs(t *testing.T, afs afero.Fs) *PrometheusService {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	options := map[string]string{
+		"PROM_PORT":          "9999",
+		"NODE_EXPORTER_PORT": "9100",
+	}
+	prometheus := NewPrometheus()
+	require.NoError(t, prometheus.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: options,
+	}))
+	require.NoError(t, prometheus.Setup(context.Background(), options))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/-/reload" && r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	split := strings.Split(server.URL, ":")
+	host, port := split[1][2:], split[2]
+	prometheus.containerIP = net.ParseIP(host)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	prometheus.port = uint16(p)
+
+	return prometheus
+}
+
+// slowFs is a file system that takes a while to open files, so the concurrent
+// changes of a file overlap.
+type slowFs struct {
+	afero.Fs
+}
+
+func (f slowFs) Open(name string) (afero.File, error) {
+	time.Sleep(time.Millisecond)
+	return f.Fs.Open(name)
+}
+
+func TestAddRules(t *testing.T) {
+	nodeRules := `
+groups:
+  - name: avs-node
+    rules:
+      - alert: AVSNodeDown
+        expr: up{job="avs"} == 0
+        for: 1m
+        labels:
+          severity: critical
+`
+	apiRules := `
+groups:
+  - name: avs-api
+    rules:
+      - alert: AVSAPIErrors
+        expr: rate(api_errors_total[5m]) > 0
+`
+	tests := []struct {
+		name    string
+		rules   []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "one rule file",
+			rules: []string{nodeRules},
+			want:  []string{"avs-node"},
+		},
+		{
+			name:  "rule files are merged",
+			rules: []string{nodeRules, apiRules},
+			want:  []string{"avs-node", "avs-api"},
+		},
+		{
+			name:    "duplicated group",
+			rules:   []string{nodeRules, nodeRules},
+			wantErr: ErrInvalidRules,
+		},
+		{
+			name:    "group without name",
+			rules:   []string{"groups:\n  - rules:\n      - alert: A\n        expr: up == 0\n"},
+			wantErr: ErrInvalidRules,
+		},
+		{
+			name:    "rule without expr",
+			rules:   []string{"groups:\n  - name: a\n    rules:\n      - alert: A\n"},
+			wantErr: ErrInvalidRules,
+		},
+		{
+			name:    "invalid yaml",
+			rules:   []string{"groups: ["},
+			wantErr: ErrInvalidRules,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prometheus, afs := setupRulesTest(t)
+
+			rules := make([][]byte, 0, len(tt.rules))
+			for _, r := range tt.rules {
+				rules = append(rules, []byte(r))
+			}
+			err := prometheus.AddRules("mock-avs-default", rules)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			rawRules, err := afero.ReadFile(afs, "/monitoring/prometheus/rules/avs/mock-avs-default.yml")
+			require.NoError(t, err)
+			var ruleFile RuleFile
+			require.NoError(t, yaml.Unmarshal(rawRules, &ruleFile))
+			groups := make([]string, 0, len(ruleFile.Groups))
+			for _, group := range ruleFile.Groups {
+				groups = append(groups, group.Name)
+			}
+			assert.Equal(t, tt.want, groups)
+		})
+	}
+}
+
+func TestRemoveRules(t *testing.T) {
+	prometheus, afs := setupRulesTest(t)
+	rulesPath := "/monitoring/prometheus/rules/avs/mock-avs-default.yml"
+
+	// Removing the rules of an instance without rules is a no-op
+	require.NoError(t, prometheus.RemoveRules("mock-avs-default"))
+
+	require.NoError(t, prometheus.AddRules("mock-avs-default", [][]byte{[]byte("groups:\n  - name: a\n    rules:\n      - alert: A\n        expr: up == 0\n")}))
+	ok, err := afero.Exists(afs, rulesPath)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, prometheus.RemoveRules("mock-avs-default"))
+	ok, err = afero.Exists(afs, rulesPath)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The default rules are kept
+	ok, err = afero.Exists(afs, "/monitoring/prometheus/rules/node.yml")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
 func TestSetContainerIP(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1140,3 +2127,206 @@ func TestEndpoint(t *testing.T) {
 	endpoint := prometheus.Endpoint()
 	assert.Equal(t, want, endpoint)
 }
+
+func TestSetupCanceled(t *testing.T) {
+	afs := afero.NewMemMapFs()
+
+	ctrl := gomock.NewController(t)
+	locker := mocks.NewMockLocker(ctrl)
+	locker.EXPECT().New("/monitoring/.lock").Return(locker)
+	locker.EXPECT().Lock().Return(nil).AnyTimes()
+	locker.EXPECT().Locked().Return(true).AnyTimes()
+	locker.EXPECT().Unlock().Return(nil).AnyTimes()
+
+	dataDir, err := data.NewDataDir("/", afs, locker)
+	require.NoError(t, err)
+	stack, err := dataDir.MonitoringStack()
+	require.NoError(t, err)
+
+	options := map[string]string{
+		"PROM_PORT":          "9999",
+		"NODE_EXPORTER_PORT": "9100",
+	}
+	prometheus := NewPrometheus()
+	require.NoError(t, prometheus.Init(types.ServiceOptions{
+		Stack:  stack,
+		Dotenv: options,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, prometheus.Setup(ctx, options), context.Canceled)
+	ok, err := afero.Exists(afs, "/monitoring/prometheus/prometheus.yml")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestValidateEmbeddedAssets(t *testing.T) {
+	assert.NoError(t, NewPrometheus().ValidateEmbeddedAssets())
+}
+
+func TestExternalLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "blank",
+			labels: "  ",
+		},
+		{
+			name:   "one label",
+			labels: "host=node-1",
+			want:   map[string]string{"host": "node-1"},
+		},
+		{
+			name:   "several labels with spaces",
+			labels: " host = node-1 , region=eu-west,_zone=a ",
+			want:   map[string]string{"host": "node-1", "region": "eu-west", "_zone": "a"},
+		},
+		{
+			name:   "value with equal sign",
+			labels: "selector=a=b",
+			want:   map[string]string{"selector": "a=b"},
+		},
+		{
+			name:    "missing value",
+			labels:  "host=",
+			wantErr: true,
+		},
+		{
+			name:    "missing equal sign",
+			labels:  "host",
+			wantErr: true,
+		},
+		{
+			name:    "empty pair",
+			labels:  "host=node-1,,region=eu",
+			wantErr: true,
+		},
+		{
+			name:    "invalid label name",
+			labels:  "host-name=node-1",
+			wantErr: true,
+		},
+		{
+			name:    "reserved label name",
+			labels:  "__name__=up",
+			wantErr: true,
+		},
+		{
+			name:    "duplicated label",
+			labels:  "host=node-1,host=node-2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := externalLabels(map[string]string{"PROM_EXTERNAL_LABELS": tt.labels})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidOptions)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, labels)
+		})
+	}
+}
+
+func TestTargetsHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     monitoring.TargetsHealth
+		wantErr  bool
+	}{
+		{
+			name:   "up and down targets",
+			status: http.StatusOK,
+			response: `{"status": "success", "data": {"activeTargets": [
+				{"labels": {"job": "prometheus"}, "health": "up"},
+				{"labels": {"job": "mock-avs-default"}, "health": "up"},
+				{"labels": {"job": "mock-avs-second"}, "health": "down"},
+				{"labels": {"job": "mock-avs-third"}, "health": "unknown"}
+			], "droppedTargets": []}}`,
+			want: monitoring.TargetsHealth{Active: 4, Up: 2, Down: 1},
+		},
+		{
+			name:     "no targets",
+			status:   http.StatusOK,
+			response: `{"status": "success", "data": {"activeTargets": [], "droppedTargets": []}}`,
+			want:     monitoring.TargetsHealth{},
+		},
+		{
+			name:     "api error",
+			status:   http.StatusOK,
+			response: `{"status": "error", "errorType": "internal", "error": "error"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid response",
+			status:   http.StatusOK,
+			response: `not json`,
+			wantErr:  true,
+		},
+		{
+			name:    "unavailable",
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/targets", r.URL.Path)
+				assert.Equal(t, "active", r.URL.Query().Get("state"))
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+			split := strings.Split(server.URL, ":")
+			host, port := split[1][2:], split[2]
+			prometheus := NewPrometheus()
+			prometheus.containerIP = net.ParseIP(host)
+			p, err := strconv.Atoi(port)
+			require.NoError(t, err)
+			prometheus.port = uint16(p)
+
+			health, err := prometheus.TargetsHealth()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrQueryingTargets)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, health)
+		})
+	}
+}
+
+func TestTargetsHealthTimeout(t *testing.T) {
+	client := apiClient
+	apiClient = &http.Client{Timeout: 100 * time.Millisecond}
+	defer func() { apiClient = client }()
+
+	// The server doesn't answer until the request is given up
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	split := strings.Split(server.URL, ":")
+	host, port := split[1][2:], split[2]
+	prometheus := NewPrometheus()
+	prometheus.containerIP = net.ParseIP(host)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	prometheus.port = uint16(p)
+
+	_, err = prometheus.TargetsHealth()
+	assert.ErrorIs(t, err, ErrQueryingTargets)
+}
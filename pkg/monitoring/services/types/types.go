@@ -2,6 +2,7 @@ package types
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/NethermindEth/eigenlayer/internal/data"
 )
@@ -23,6 +24,24 @@ type MonitoringTarget struct {
 	Port uint16
 	// Path is the path of the monitoring target endpoint, e.g. /metrics
 	Path string
+	// Scheme is the protocol scheme of the monitoring target endpoint, e.g. https
+	Scheme string
+	// ScrapeTimeout is the timeout of each scrape of the target, e.g. 10s. The
+	// Prometheus global scrape timeout is used if it is empty.
+	ScrapeTimeout string
+	// HonorLabels keeps the labels exposed by the target, like instance, when
+	// they conflict with the labels added by Prometheus.
+	HonorLabels bool
+}
+
+// UnixScheme is the scheme of monitoring target endpoints that are unix
+// sockets, e.g. unix:///var/run/avs/metrics.sock
+const UnixScheme = "unix"
+
+// IsUnixSocket returns true if the monitoring target endpoint is a unix socket,
+// either because of its scheme or because its host is a unix:// address.
+func (t MonitoringTarget) IsUnixSocket() bool {
+	return t.Scheme == UnixScheme || strings.HasPrefix(t.Host, UnixScheme+"://")
 }
 
 func (t MonitoringTarget) String() string {
@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions available to the config templates of the
+// monitoring services:
+//
+//   - default: returns the given default value if the value is empty, e.g.
+//     {{ .Port | default "9090" }}
+//   - join: joins the elements of a list with the given separator, e.g.
+//     {{ .Targets | join ", " }}
+//   - quote: returns the value as a double-quoted string, e.g.
+//     {{ .URL | quote }}
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": defaultValue,
+		"join":    join,
+		"quote":   quote,
+	}
+}
+
+// ParseTemplate parses a monitoring service config template with the given
+// name, including the functions of TemplateFuncs.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs()).Parse(text)
+}
+
+// defaultValue returns def if value is nil, a zero value or an empty list or
+// map. Otherwise, it returns value.
+func defaultValue(def, value any) any {
+	if empty(value) {
+		return def
+	}
+	return value
+}
+
+func empty(value any) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// join joins the elements of list, which must be a slice or an array, with
+// sep. Non-string elements are formatted with their default format.
+func join(sep string, list any) (string, error) {
+	v := reflect.ValueOf(list)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+	elems := make([]string, v.Len())
+	for i := range elems {
+		elems[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(elems, sep), nil
+}
+
+// quote returns value as a double-quoted Go string literal, which is also a
+// valid YAML double-quoted string.
+func quote(value any) string {
+	return strconv.Quote(fmt.Sprint(value))
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "nil", value: nil, want: "default"},
+		{name: "empty string", value: "", want: "default"},
+		{name: "zero int", value: 0, want: "default"},
+		{name: "false", value: false, want: "default"},
+		{name: "empty slice", value: []string{}, want: "default"},
+		{name: "empty map", value: map[string]string{}, want: "default"},
+		{name: "string", value: "value", want: "value"},
+		{name: "int", value: 9090, want: 9090},
+		{name: "true", value: true, want: true},
+		{name: "slice", value: []string{"a"}, want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, defaultValue("default", tt.value))
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    any
+		want    string
+		wantErr bool
+	}{
+		{name: "nil", list: nil, want: ""},
+		{name: "empty", list: []string{}, want: ""},
+		{name: "strings", list: []string{"node1:9100", "node2:9100"}, want: "node1:9100, node2:9100"},
+		{name: "ints", list: []int{9090, 9100}, want: "9090, 9100"},
+		{name: "array", list: [2]string{"a", "b"}, want: "a, b"},
+		{name: "not a list", list: "node1:9100", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := join(", ", tt.list)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"http://localhost:9090"`, quote("http://localhost:9090"))
+	assert.Equal(t, `"a \"quoted\" value"`, quote(`a "quoted" value`))
+	assert.Equal(t, `"9090"`, quote(9090))
+}
+
+func TestParseTemplate(t *testing.T) {
+	tmp, err := ParseTemplate("test.yml", `targets: [{{ .Targets | join ", " }}]
+port: {{ .Port | default 9090 }}
+url: {{ .URL | quote }}`)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = tmp.Execute(&out, struct {
+		Targets []string
+		Port    int
+		URL     string
+	}{
+		Targets: []string{"node1:9100", "node2:9100"},
+		URL:     "http://localhost:9090",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `targets: [node1:9100, node2:9100]
+port: 9090
+url: "http://localhost:9090"`, out.String())
+}
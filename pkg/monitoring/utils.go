@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -36,6 +38,37 @@ func assignPorts(host string, defaults map[string]uint16) (ports map[string]uint
 	return
 }
 
+// checkPortConflicts checks the ports configured in the given options, the
+// values of the *_PORT keys, before any container of the stack starts. It
+// returns an ErrPortConflict error with the offending port if two options share
+// the same port or if the port is already in use in the given host.
+func checkPortConflicts(host string, opts map[string]string) error {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		if strings.HasSuffix(k, "_PORT") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	used := make(map[uint16]string, len(keys))
+	for _, k := range keys {
+		p, err := strconv.ParseUint(opts[k], 10, 16)
+		if err != nil || p == 0 {
+			return fmt.Errorf("%w: %s", ErrDefaultPortInvalid, k)
+		}
+		port := uint16(p)
+		if other, ok := used[port]; ok {
+			return fmt.Errorf("%w: port %d is used by %s and %s", ErrPortConflict, port, other, k)
+		}
+		used[port] = k
+		if !portAvailable(host, port) {
+			return fmt.Errorf("%w: port %d of %s is already in use", ErrPortConflict, port, k)
+		}
+	}
+	return nil
+}
+
 // Checks if port is occupied in a given host
 func portAvailable(ip string, port uint16) bool {
 	log.Debugf("checking occupation of %s:%d", ip, port)
@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -138,6 +139,60 @@ func TestAssignPorts(t *testing.T) {
 	}
 }
 
+func TestCheckPortConflicts(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		}))
+	defer server.Close()
+	split := strings.Split(server.URL, ":")
+	host, strPort := split[1][2:], split[2]
+
+	tcs := []struct {
+		name    string
+		host    string
+		opts    map[string]string
+		wantErr error
+	}{
+		{
+			"Test case 1, no conflicts",
+			"b@dh0$t",
+			map[string]string{"PROM_PORT": "9090", "GRAFANA_PORT": "3000", "PROM_SCRAPE_INTERVAL": "30s"},
+			nil,
+		},
+		{
+			"Test case 2, duplicated port within the options",
+			"b@dh0$t",
+			map[string]string{"PROM_PORT": "9090", "GRAFANA_PORT": "9090", "NODE_EXPORTER_PORT": "9100"},
+			ErrPortConflict,
+		},
+		{
+			"Test case 3, port in use by a listening socket",
+			host,
+			map[string]string{"PROM_PORT": strPort},
+			ErrPortConflict,
+		},
+		{
+			"Test case 4, invalid port",
+			"b@dh0$t",
+			map[string]string{"PROM_PORT": "not-a-port"},
+			ErrDefaultPortInvalid,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkPortConflicts(tc.host, tc.opts)
+			if tc.wantErr == nil && err != nil {
+				t.Errorf("checkPortConflicts(%s, %+v) failed, unexpected error: %v", tc.host, tc.opts, err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("checkPortConflicts(%s, %+v) failed, expected error %v, got: %v", tc.host, tc.opts, tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func checkErr(descr string, isErr bool, err error) error {
 	l := err == nil && isErr
 	r := err != nil && !isErr